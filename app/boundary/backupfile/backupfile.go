@@ -0,0 +1,229 @@
+package backupfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+)
+
+const (
+	// subDir は状態ディレクトリ内のバックアップの置き場所
+	subDir = "backup"
+	// originName はバックアップ元のパスを記録するファイル名
+	originName = "origin"
+	// extension はバックアップファイルの拡張子
+	extension = ".bak"
+	// timeLayout はバックアップファイル名に使う時刻の書式（文字列の順序が時刻の順序になる）
+	timeLayout = "20060102T150405.000000000Z"
+)
+
+// Saver は上書きする前の内容をバックアップする
+type Saver interface {
+	Save(original string, data []byte, now time.Time) (string, error)
+}
+
+// TildeStore は元のファイルと同じディレクトリに「ファイル名~」としてバックアップを置く
+// 保存のたびに直前の内容で置き換えるので、残るのは1世代だけ
+type TildeStore struct{}
+
+// Save は original の変更前の内容を original~ に書き出し、その場所を返す
+// 新しく作る場合は元のファイルのパーミッションに合わせる
+func (TildeStore) Save(original string, data []byte, _ time.Time) (string, error) {
+	path := original + "~"
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(original); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := atomicfile.WriteFile(path, data, perm); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Backup はバックアップ1件を表す
+type Backup struct {
+	Original string // バックアップ元のパス
+	SavedAt  time.Time
+	Path     string // バックアップファイル自体のパス
+}
+
+// Policy はバックアップの保持方針
+// 新しい順に Keep 件以内のものと、MaxAge より新しいものを残す。どちらも0なら全て残す
+type Policy struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// Unlimited は削除を行わない方針かどうかを返す
+func (p Policy) Unlimited() bool {
+	return p.Keep <= 0 && p.MaxAge <= 0
+}
+
+// retain は新しい順で index 番目、saved の時刻に保存されたバックアップを残すかどうかを返す
+func (p Policy) retain(index int, saved, now time.Time) bool {
+	if p.Unlimited() {
+		return true
+	}
+	return (p.Keep > 0 && index < p.Keep) || (p.MaxAge > 0 && now.Sub(saved) < p.MaxAge)
+}
+
+// DirStore はディレクトリにバックアップを置くストア
+// ファイルごとにパスのハッシュを名前にしたディレクトリを作り、その中に保存時刻ごとのバックアップを置く
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore は dir を保存先とする DirStore を作成する
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の backup を保存先とする DirStore を作成する
+func NewDefaultStore() *DirStore {
+	return NewDirStore(filepath.Join(statedir.Dir(), subDir))
+}
+
+// dirFor は original のバックアップを置くディレクトリを返す
+// 長いパスや特殊な文字を含むパスでも扱えるよう、絶対パスのハッシュを名前にする
+func (s *DirStore) dirFor(original string) string {
+	if abs, err := filepath.Abs(original); err == nil {
+		original = abs
+	}
+	sum := sha256.Sum256([]byte(original))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8]))
+}
+
+// Save は original の変更前の内容をバックアップし、その場所を返す
+func (s *DirStore) Save(original string, data []byte, now time.Time) (string, error) {
+	dir := s.dirFor(original)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("バックアップ用ディレクトリを作成できません: %w", err)
+	}
+	abs, err := filepath.Abs(original)
+	if err != nil {
+		abs = original
+	}
+	if err := atomicfile.WriteFile(filepath.Join(dir, originName), []byte(abs+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	// 同じ時刻のバックアップがあれば上書きしないよう時刻をずらす
+	path := filepath.Join(dir, now.UTC().Format(timeLayout)+extension)
+	for {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+		path = filepath.Join(dir, now.UTC().Format(timeLayout)+extension)
+	}
+	if err := atomicfile.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List は original のバックアップを新しい順に返す
+func (s *DirStore) List(original string) ([]Backup, error) {
+	dir := s.dirFor(original)
+	backups, err := listDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return backups, nil
+}
+
+// Load は original の最新のバックアップの内容を返す
+func (s *DirStore) Load(original string) ([]byte, error) {
+	backups, err := s.List(original)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("%s: %w", original, os.ErrNotExist)
+	}
+	return os.ReadFile(backups[0].Path)
+}
+
+// Prune は全てのファイルのバックアップのうち policy で残さないものを削除し、削除した件数を返す
+// バックアップが1つも残らなかったファイルのディレクトリも削除する
+func (s *DirStore) Prune(policy Policy, now time.Time) (int, error) {
+	if policy.Unlimited() {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	var errs []error
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, e.Name())
+		backups, err := listDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		kept := 0
+		for i, b := range backups {
+			if policy.retain(i, b.SavedAt, now) {
+				kept++
+				continue
+			}
+			if err := os.Remove(b.Path); err != nil {
+				errs = append(errs, err)
+				kept++
+				continue
+			}
+			removed++
+		}
+		if kept == 0 {
+			if err := os.RemoveAll(dir); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// listDir は1ファイル分のバックアップディレクトリの内容を新しい順に返す
+func listDir(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	original := ""
+	if data, err := os.ReadFile(filepath.Join(dir, originName)); err == nil {
+		original = strings.TrimSuffix(string(data), "\n")
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, extension) {
+			continue
+		}
+		saved, err := time.Parse(timeLayout, strings.TrimSuffix(name, extension))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Original: original, SavedAt: saved, Path: filepath.Join(dir, name)})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].SavedAt.After(backups[j].SavedAt) })
+	return backups, nil
+}
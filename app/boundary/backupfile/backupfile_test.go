@@ -0,0 +1,86 @@
+package backupfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+	original := filepath.Join(t.TempDir(), "a.txt")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	first, err := store.Save(original, []byte("v1"), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 同じ時刻でも前のバックアップを上書きしない
+	second, err := store.Save(original, []byte("v2"), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first == second || filepath.Dir(first) != filepath.Dir(second) {
+		t.Errorf("unexpected paths: %s %s", first, second)
+	}
+
+	data, err := store.Load(original)
+	if err != nil || string(data) != "v2" {
+		t.Errorf("Load() = %q, %v", data, err)
+	}
+	backups, err := store.List(original)
+	if err != nil || len(backups) != 2 || backups[0].Path != second || backups[0].Original != original {
+		t.Errorf("List() = %+v, %v", backups, err)
+	}
+
+	if _, err := store.Load(filepath.Join(t.TempDir(), "other.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// a.txt は1日前から5日前まで毎日、b.txt は100日前に1回だけ
+	a := filepath.Join(t.TempDir(), "a.txt")
+	for i := 1; i <= 5; i++ {
+		if _, err := store.Save(a, []byte("a"), now.Add(-time.Duration(i)*day)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := filepath.Join(t.TempDir(), "b.txt")
+	if _, err := store.Save(b, []byte("b"), now.Add(-100*day)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := store.Prune(Policy{}, now); n != 0 || err != nil {
+		t.Errorf("unlimited policy must not remove anything: %d %v", n, err)
+	}
+
+	// 新しい2件か、3日より新しいものを残すので、a.txt の4日前と5日前が消える
+	removed, err := store.Prune(Policy{Keep: 2, MaxAge: 3*day + time.Hour}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 removed, got %d", removed)
+	}
+	if backups, _ := store.List(a); len(backups) != 3 {
+		t.Errorf("expected 3 backups of a.txt, got %+v", backups)
+	}
+
+	// b.txt は Keep の範囲内なので残る
+	if backups, _ := store.List(b); len(backups) != 1 {
+		t.Errorf("expected the latest backup of b.txt to be kept, got %+v", backups)
+	}
+	if _, err := store.Prune(Policy{MaxAge: day}, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(store.dirFor(b)); !os.IsNotExist(err) {
+		t.Errorf("empty backup directory must be removed: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package bookmarkfile
+
+import (
+	"os"
+
+	"github.com/wasya-io/go-kilo/app/entity/bookmark"
+)
+
+// Store はブックマークをファイルへ書き出し、読み込むためのインターフェース
+type Store interface {
+	Export(dest string, source string, marks []bookmark.Bookmark) error
+	Import(src string, source string) ([]bookmark.Bookmark, error)
+}
+
+// FileStore はローカルファイルを使用した Store の実装
+type FileStore struct{}
+
+// NewFileStore は新しい FileStore を作成する
+func NewFileStore() *FileStore {
+	return &FileStore{}
+}
+
+// Export は source のブックマークを dest に書き出す
+func (s *FileStore) Export(dest string, source string, marks []bookmark.Bookmark) error {
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if err := bookmark.Export(f, source, marks); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Import は src から source に対応するブックマークを読み込む
+func (s *FileStore) Import(src string, source string) ([]bookmark.Bookmark, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bookmark.Import(f, source)
+}
@@ -0,0 +1,64 @@
+package dirlist
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Entry はディレクトリ内の項目1件
+type Entry struct {
+	Name string
+	Dir  bool  // ディレクトリ（ディレクトリへのシンボリックリンクを含む）
+	Size int64 // ファイルの大きさ（バイト）。ディレクトリでは 0
+}
+
+// Lister はディレクトリの内容を一覧にする
+type Lister interface {
+	List(dir string) ([]Entry, error)
+}
+
+// OSLister はローカルファイルシステムのディレクトリを一覧にする Lister
+type OSLister struct{}
+
+// NewOSLister は新しい OSLister を作成する
+func NewOSLister() *OSLister {
+	return &OSLister{}
+}
+
+// List は dir の項目をディレクトリ、ファイルの順に、それぞれ名前順で返す
+// 情報を読めない項目（リンク切れのシンボリックリンクなど）はファイルとして扱う
+func (OSLister) List(dir string) ([]Entry, error) {
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		e := Entry{Name: item.Name(), Dir: item.IsDir()}
+		if info, err := os.Stat(filepath.Join(dir, item.Name())); err == nil {
+			e.Dir = info.IsDir()
+			if !e.Dir {
+				e.Size = info.Size()
+			}
+		}
+		entries = append(entries, e)
+	}
+	Sort(entries)
+	return entries, nil
+}
+
+// Sort は項目をディレクトリ、ファイルの順に、それぞれ大文字小文字を区別しない名前順で並べる
+func Sort(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		if la, lb := strings.ToLower(a.Name), strings.ToLower(b.Name); la != lb {
+			return la < lb
+		}
+		return a.Name < b.Name
+	})
+}
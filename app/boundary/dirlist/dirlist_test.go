@@ -0,0 +1,50 @@
+package dirlist
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOSLister_List(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "A.go", "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("12345"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"src", "Docs"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ディレクトリへのリンクはディレクトリ、リンク切れはファイルとして扱う
+	if err := os.Symlink(filepath.Join(dir, "src"), filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := NewOSLister().List(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Entry{
+		{Name: "Docs", Dir: true},
+		{Name: "link", Dir: true},
+		{Name: "src", Dir: true},
+		{Name: "A.go", Size: 5},
+		{Name: "b.txt", Size: 5},
+		{Name: "broken"},
+		{Name: "c", Size: 5},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("got %+v, want %+v", entries, want)
+	}
+
+	if _, err := NewOSLister().List(filepath.Join(dir, "missing")); err == nil {
+		t.Error("listing a missing directory must fail")
+	}
+}
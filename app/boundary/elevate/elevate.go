@@ -0,0 +1,89 @@
+package elevate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DefaultCommand は権限を昇格して書き込むコマンドの既定値。保存先のパスを -- に続けて最後の引数として渡す
+const DefaultCommand = "sudo tee"
+
+// ErrNoCommand はコマンドが設定されていない場合のエラー
+var ErrNoCommand = errors.New("no privilege escalation command configured")
+
+// Writer は実行中のユーザーでは書き込めないファイルを、権限を昇格して書き込むインターフェース
+type Writer interface {
+	// WriteFile は data を filename に書き込む
+	WriteFile(filename string, data []byte) error
+	// Command は実行するコマンドを返す（プロンプトでの表示用）
+	Command() string
+}
+
+// CommandWriter は内容を一時ファイルに書き出し、それを標準入力として sudo tee などのコマンドに渡す Writer の実装
+// パスワードの入力はコマンド自身が端末から受け付けるため、エディタが認証情報を扱うことはない
+type CommandWriter struct {
+	command string
+	stdout  io.Writer // コマンドの標準出力（tee が書き戻す内容）の出力先
+	stderr  io.Writer // コマンドの標準エラー出力と案内の出力先
+}
+
+// NewCommandWriter は command を実行する CommandWriter を作成する
+// command は空白で区切ってコマンドと引数に分ける（例: "sudo tee", "doas tee"）
+func NewCommandWriter(command string) *CommandWriter {
+	return &CommandWriter{command: strings.TrimSpace(command), stdout: io.Discard, stderr: os.Stderr}
+}
+
+// Command は実行するコマンドを返す
+func (w *CommandWriter) Command() string {
+	return w.command
+}
+
+// WriteFile は data を一時ファイル（所有者のみ読み書きできる）に書き出し、コマンドの標準入力として渡す
+// 一時ファイルは成否に関わらず削除する。失敗した場合は標準エラー出力の先頭行をエラーメッセージに含める
+func (w *CommandWriter) WriteFile(filename string, data []byte) error {
+	args := strings.Fields(w.command)
+	if len(args) == 0 {
+		return ErrNoCommand
+	}
+
+	tmp, err := os.CreateTemp("", "go-kilo-elevate-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w.stderr, "Writing %s with %q. Enter your password if prompted.\n", filename, w.command)
+	// - で始まるファイル名をオプションとして読まないよう、-- の後に渡す
+	cmd := exec.Command(args[0], append(args[1:], "--", filename)...)
+	cmd.Stdin = tmp
+	cmd.Stdout = w.stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(w.stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", args[0], err, lastLine(msg))
+		}
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+// lastLine は複数行のメッセージの最終行を返す。認証の再試行の後に最終的な失敗の理由が出力されるため
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package elevate
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandWriter_WriteFile(t *testing.T) {
+	if _, err := exec.LookPath("tee"); err != nil {
+		t.Skip("tee is not available")
+	}
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	w := NewCommandWriter(" tee ")
+	w.stderr = &out
+	if err := w.WriteFile(path, []byte("new\ncontent")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\ncontent" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if !strings.Contains(out.String(), path) {
+		t.Errorf("the target must be announced before running the command: %q", out.String())
+	}
+	// 一時ファイルを残さない
+	if matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "go-kilo-elevate-*")); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestCommandWriter_DashFilename(t *testing.T) {
+	if _, err := exec.LookPath("tee"); err != nil {
+		t.Skip("tee is not available")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.WriteFile("-a", []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -a が tee のオプション（追記）として読まれると、ファイルは書き換わらない
+	w := NewCommandWriter("tee")
+	w.stderr = &bytes.Buffer{}
+	if err := w.WriteFile("-a", []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("-a"); string(data) != "new" {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
+
+func TestCommandWriter_Errors(t *testing.T) {
+	if err := NewCommandWriter("  ").WriteFile("x", nil); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("expected ErrNoCommand, got %v", err)
+	}
+
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false is not available")
+	}
+	w := NewCommandWriter("false")
+	w.stderr = &bytes.Buffer{}
+	if err := w.WriteFile(filepath.Join(t.TempDir(), "x"), []byte("data")); err == nil || !strings.HasPrefix(err.Error(), "false: ") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
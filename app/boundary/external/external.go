@@ -0,0 +1,60 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner は外部コマンドを実行するインターフェース
+type Runner interface {
+	// Run は dir をカレントディレクトリとして name を実行し、標準出力を返す
+	Run(ctx context.Context, dir string, name string, args []string, stdin []byte) ([]byte, error)
+	// Available はコマンドが実行可能かどうかを返す
+	Available(name string) bool
+}
+
+// CommandRunner は os/exec を使用した Runner の実装
+type CommandRunner struct{}
+
+// NewCommandRunner は新しい CommandRunner を作成する
+func NewCommandRunner() *CommandRunner {
+	return &CommandRunner{}
+}
+
+// Run は外部コマンドを実行する
+// 失敗した場合は標準エラー出力の内容をエラーメッセージに含める
+func (r *CommandRunner) Run(ctx context.Context, dir string, name string, args []string, stdin []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%s: %w: %s", name, err, firstLine(msg))
+		}
+		return stdout.Bytes(), fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Available はコマンドが PATH 上に存在するかどうかを返す
+func (r *CommandRunner) Available(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// firstLine は複数行のメッセージの先頭行を返す
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
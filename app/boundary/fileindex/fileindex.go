@@ -0,0 +1,127 @@
+package fileindex
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// batchSize は見つけたファイルをまとめて渡す件数
+const batchSize = 256
+
+// MaxFiles は一覧にするファイル数の上限。巨大なディレクトリで開いても応答できるよう打ち切る
+const MaxFiles = 100000
+
+// ErrTooManyFiles はファイル数が MaxFiles を超えたため一覧を打ち切った場合のエラー
+var ErrTooManyFiles = errors.New("too many files")
+
+// Lister はディレクトリ以下のファイルを列挙する
+type Lister interface {
+	// List は root 以下の通常ファイルのパスを root からの相対パスで、見つけた順にまとめて found に渡す
+	List(ctx context.Context, root string, found func(paths []string)) error
+}
+
+// Walker は Go のファイル走査による Lister の実装
+// .git などのディレクトリと、名前が . で始まるディレクトリは辿らない
+// 一覧は裏で作成するため、SetExclude は List の実行中に呼び出してもよい
+type Walker struct {
+	mu           sync.Mutex
+	skipDirs     map[string]bool
+	excludeNames map[string]bool
+	excludePaths map[string]bool
+}
+
+// NewWalker は新しい Walker を作成する
+func NewWalker() *Walker {
+	return &Walker{
+		skipDirs: map[string]bool{"node_modules": true, "vendor": true},
+	}
+}
+
+// SetExclude は一覧から除外するディレクトリを設定する（既存の設定は置き換える）
+// 区切り文字を含まない名前はどの階層でも一致し、含むものは base からの相対パスとして扱う
+// 実行中の List には影響せず、次の List から使う
+func (w *Walker) SetExclude(base string, dirs []string) {
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, dir := range dirs {
+		dir = filepath.Clean(filepath.FromSlash(dir))
+		if !strings.ContainsRune(dir, filepath.Separator) {
+			names[dir] = true
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		paths[dir] = true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.excludeNames, w.excludePaths = names, paths
+}
+
+// skipper はディレクトリを一覧の対象から外すかどうかを判定する関数を、現在の設定で作る
+func (w *Walker) skipper() func(path, name string) bool {
+	w.mu.Lock()
+	names, paths := w.excludeNames, w.excludePaths
+	w.mu.Unlock()
+	return func(path, name string) bool {
+		if strings.HasPrefix(name, ".") || w.skipDirs[name] || names[name] {
+			return true
+		}
+		if len(paths) == 0 {
+			return false
+		}
+		abs, err := filepath.Abs(path)
+		return err == nil && paths[abs]
+	}
+}
+
+// List は root 以下のファイルを名前順に辿って列挙する。読めないディレクトリは読み飛ばす
+// ctx が取り消されると ctx のエラーを、MaxFiles を超えるとそこまでを渡して ErrTooManyFiles を返す
+func (w *Walker) List(ctx context.Context, root string, found func(paths []string)) error {
+	skip := w.skipper()
+	batch := make([]string, 0, batchSize)
+	count := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != root && skip(path, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if count >= MaxFiles {
+			return ErrTooManyFiles
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		batch = append(batch, rel)
+		count++
+		if len(batch) == batchSize {
+			found(batch)
+			batch = make([]string, 0, batchSize)
+		}
+		return nil
+	})
+	if len(batch) > 0 {
+		found(batch)
+	}
+	return err
+}
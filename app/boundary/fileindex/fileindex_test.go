@@ -0,0 +1,81 @@
+package fileindex
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func listAll(t *testing.T, w *Walker, root string) []string {
+	t.Helper()
+	var paths []string
+	if err := w.List(context.Background(), root, func(batch []string) {
+		paths = append(paths, batch...)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return paths
+}
+
+func TestWalker_List(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"main.go", "app/a.go", "app/sub/b.go", ".hidden", ".git/config",
+		"node_modules/x.js", "build/out.bin", "docs/build/keep.md",
+	)
+
+	w := NewWalker()
+	w.SetExclude(root, []string{"build"})
+	// . で始まるファイルは一覧にするが、. で始まるディレクトリや除外したディレクトリは辿らない
+	want := []string{
+		".hidden",
+		filepath.Join("app", "a.go"),
+		filepath.Join("app", "sub", "b.go"),
+		"main.go",
+	}
+	if got := listAll(t, w, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	// 区切り文字を含む除外は root からの相対パスとして扱う
+	w.SetExclude(root, []string{"docs/build"})
+	got := listAll(t, w, root)
+	for _, p := range got {
+		if p == filepath.Join("docs", "build", "keep.md") {
+			t.Errorf("excluded path was listed: %v", got)
+		}
+	}
+	if len(got) != 5 {
+		t.Errorf("List = %v, want 5 files including build/out.bin", got)
+	}
+}
+
+func TestWalker_ListCanceled(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "a.txt", "b.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := NewWalker().List(ctx, root, func([]string) {
+		t.Error("no files should be reported after cancellation")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,99 @@
+package filemanager
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// DiskStamp はディスク上のファイルの更新日時と大きさ
+type DiskStamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Equal は2つの状態が同じかを返す
+func (s DiskStamp) Equal(other DiskStamp) bool {
+	return s.ModTime.Equal(other.ModTime) && s.Size == other.Size
+}
+
+// DiskWatcher は開いているファイルがほかのプロセスに変更されたかを確認できる FileManager
+type DiskWatcher interface {
+	// DiskChange は開いた・保存した時点からファイルが変更されていれば、現在の状態と true を返す
+	DiskChange() (DiskStamp, bool)
+	// AcceptDisk は stamp を既知の状態とし、以降はその状態からの変更だけを報告する
+	AcceptDisk(stamp DiskStamp)
+	// ReadDisk はバッファを変更せずにディスク上の現在の内容を行に分割して返す
+	ReadDisk() ([]string, error)
+}
+
+// diskState は開いているファイルについて最後に確認したディスク上の状態
+// 変更の確認は定期処理から呼び出されるため、ロックで保護する
+type diskState struct {
+	mu    sync.Mutex
+	path  string
+	stamp DiskStamp
+	known bool // 記録した時点でファイルが存在した
+}
+
+// stampOf はファイルの現在の状態を返す。ファイルがなければ false を返す
+func stampOf(path string) (DiskStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return DiskStamp{}, false
+	}
+	return DiskStamp{ModTime: info.ModTime(), Size: info.Size()}, true
+}
+
+// recordDisk はファイルを開いた・保存した時点のディスク上の状態を記録する
+func (fm *StandardFileManager) recordDisk(path string) {
+	stamp, ok := stampOf(path)
+	fm.disk.mu.Lock()
+	defer fm.disk.mu.Unlock()
+	fm.disk.path, fm.disk.stamp, fm.disk.known = path, stamp, ok
+}
+
+// DiskChange はファイルがほかのプロセスに変更・作成されていれば、現在の状態と true を返す
+// 削除された場合は保存すれば作り直せるので変更とはみなさない
+func (fm *StandardFileManager) DiskChange() (DiskStamp, bool) {
+	fm.disk.mu.Lock()
+	path, recorded, known := fm.disk.path, fm.disk.stamp, fm.disk.known
+	fm.disk.mu.Unlock()
+	if path == "" {
+		return DiskStamp{}, false
+	}
+	stamp, ok := stampOf(path)
+	if !ok || (known && stamp.Equal(recorded)) {
+		return DiskStamp{}, false
+	}
+	return stamp, true
+}
+
+// AcceptDisk は stamp を既知の状態とする
+func (fm *StandardFileManager) AcceptDisk(stamp DiskStamp) {
+	fm.disk.mu.Lock()
+	defer fm.disk.mu.Unlock()
+	fm.disk.stamp, fm.disk.known = stamp, true
+}
+
+// ReadDisk はディスク上の現在の内容を返す
+// 文字コードと改行コードは読み込んだ内容から改めて判定し、バッファと同じ形（\r を除いた UTF-8）にそろえる
+func (fm *StandardFileManager) ReadDisk() ([]string, error) {
+	if fm.filename == "" {
+		return nil, ErrNoFilename
+	}
+	// 比較のための読み込みは、ファイルを開いたときの計測結果に含めない
+	stats := fm.stats
+	defer func() { fm.stats = stats }()
+	lines, _, err := fm.readFile(fm.filename, autoDetect)
+	if err != nil {
+		return nil, err
+	}
+	terminated := lines[:len(lines)-1]
+	if contents.DetectLineEnding(terminated) == contents.LineEndingCRLF {
+		contents.TrimCR(terminated)
+	}
+	return lines, nil
+}
@@ -1,21 +1,54 @@
 package filemanager
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
 	"github.com/wasya-io/go-kilo/app/entity/contents"
 )
 
+// ReadaheadThreshold はこのバイト数以上のファイルを読み込む際にカーネルへ先読みを指示する
+const ReadaheadThreshold = 1 << 20
+
+// RestChunkSize は OpenFileHead が返す rest が1回に読み込むおおよそのバイト数
+// 行の途中では区切らないため、長い行を含む場合はこれより大きくなる
+const RestChunkSize = 4 << 20
+
 // StandardFileManager はファイル操作を管理する構造体
 type StandardFileManager struct {
-	buffer   *contents.Contents
-	filename string
+	buffer    *contents.Contents
+	filename  string
+	readahead bool             // 大きなファイルの読み込みで先読みを指示する
+	stats     OpenStats        // 最後にファイルを開いたときの計測結果
+	disk      diskState        // 開いた・保存した時点のディスク上の状態
+	backup    backupfile.Saver // 上書きする前の内容の保存先（nil ならバックアップしない）
+}
+
+// OpenStats はファイルを開く処理の段階ごとの所要時間
+// 先頭だけを読み込んだ場合、Read と Split は先頭の分だけを表す
+type OpenStats struct {
+	Read  time.Duration // ファイルの読み込み
+	Split time.Duration // 行への分割
+	Bytes int64
+	Lines int
+}
+
+// StatsReporter は最後にファイルを開いたときの計測結果を返す
+type StatsReporter interface {
+	LastOpenStats() OpenStats
 }
 
 type FileManager interface {
 	OpenFile(filename string) error
+	OpenFileHead(filename string, maxLines int) (rest func() (lines []string, percent int, err error), err error)
 	SaveFile(filename string, content []string) error
 	SaveCurrentFile() error
 	GetFilename() string
@@ -35,37 +68,212 @@ func NewFileManager(buffer *contents.Contents) *StandardFileManager {
 	}
 }
 
+// SetReadahead は ReadaheadThreshold 以上のファイルを読み込む際に先読みを指示するかどうかを設定する
+func (fm *StandardFileManager) SetReadahead(enabled bool) {
+	fm.readahead = enabled
+}
+
+// SetBackup は上書きする前の内容を保存する先を設定する。nil ならバックアップしない
+func (fm *StandardFileManager) SetBackup(store backupfile.Saver) {
+	fm.backup = store
+}
+
+// LastOpenStats は最後にファイルを開いたときの計測結果を返す
+func (fm *StandardFileManager) LastOpenStats() OpenStats {
+	return fm.stats
+}
+
 // OpenFile は指定されたファイルを開く
+// 文字コードを判定して UTF-8 に変換し、保存する際は元の文字コードで書き戻す
+// バイナリのファイルは開かずに ErrBinaryFile を返す
+// 改行コードが CRLF に揃っていれば行末の \r を取り除き、保存する際に CRLF で書き戻す
 func (fm *StandardFileManager) OpenFile(filename string) error {
-	content, err := fm.readFile(filename)
+	return fm.openFile(filename, autoDetect)
+}
+
+// OpenFileWithEncoding は文字コードを enc として指定されたファイルを開く
+// enc として正しくないバイトを含む場合は開かずにエラーを返す
+func (fm *StandardFileManager) OpenFileWithEncoding(filename string, enc contents.Encoding) error {
+	return fm.openFile(filename, enc)
+}
+
+// openFile は文字コードを enc（autoDetect なら判定する）としてファイルを開く
+func (fm *StandardFileManager) openFile(filename string, enc contents.Encoding) error {
+	content, enc, err := fm.readFile(filename, enc)
 	if err != nil {
 		return err
 	}
+	ending := trimLineEnding(content)
 	fm.filename = filename
+	fm.recordDisk(filename)
 	fm.buffer.LoadContent(content)
+	fm.buffer.SetLineEnding(ending)
+	fm.buffer.SetEncoding(enc)
 
 	return nil
 }
 
+// OpenFileHead はファイルの先頭 maxLines 行だけを読み込んでバッファに設定する
+// 残りがある場合は、残りを RestChunkSize ごとに読み込む関数 rest を返す
+// rest は呼び出すたびに続きの行と、ファイル全体のうち読み込んだ割合（%）を返し、最後の行を返すときは io.EOF を返す
+// rest はバッファを変更しないので別の goroutine から呼び出せる。ただし同時に呼び出してはならない
+// 読み込んだ行と rest が返す行をつなげると、OpenFile で読み込んだ場合と同じ内容になる
+// ファイル全体が maxLines 行に収まった場合、rest は nil
+// 改行コードと文字コードは先頭の行から判定し、残りの行にも同じものを使う
+// 先頭にバイナリや変換できない部分があればエラーを返し、残りにあれば rest がエラーを返す
+func (fm *StandardFileManager) OpenFileHead(filename string, maxLines int) (func() ([]string, int, error), error) {
+	start := time.Now()
+	f, err := fm.open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var head strings.Builder
+	complete := false
+	for n := 0; n < maxLines && !complete; n++ {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		head.WriteString(line)
+		// 最終行（改行で終わらない部分）まで読み込めた
+		complete = err == io.EOF
+	}
+
+	data := []byte(head.String())
+	offset := int64(len(data))
+	if err := checkBinary(filename, data, 0); err != nil {
+		return nil, err
+	}
+	enc := DetectEncoding(data)
+	text, err := decode(filename, data, enc, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	lines := strings.Split(text, "\n")
+	terminated := lines
+	if complete {
+		terminated = lines[:len(lines)-1]
+	}
+	ending := contents.DetectLineEnding(terminated)
+	if ending == contents.LineEndingCRLF {
+		contents.TrimCR(terminated)
+	}
+	fm.stats = OpenStats{Read: time.Since(start), Bytes: offset, Lines: len(lines)}
+	fm.filename = filename
+	fm.recordDisk(filename)
+	fm.buffer.LoadContent(lines)
+	fm.buffer.SetLineEnding(ending)
+	fm.buffer.SetEncoding(enc)
+	if complete {
+		return nil, nil
+	}
+
+	var total int64
+	if info, err := f.Stat(); err == nil {
+		total = info.Size()
+	}
+	rest := func() ([]string, int, error) {
+		data, done, err := fm.readChunk(filename, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := checkBinary(filename, data, offset); err != nil {
+			return nil, 0, err
+		}
+		text, err := decode(filename, data, enc, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += int64(len(data))
+		if !done {
+			text = strings.TrimSuffix(text, "\n")
+		}
+		lines := strings.Split(text, "\n")
+		terminated := lines
+		if done {
+			terminated = lines[:len(lines)-1]
+		}
+		if ending == contents.LineEndingCRLF {
+			contents.TrimCR(terminated)
+		}
+		if done {
+			return lines, 100, io.EOF
+		}
+		// 読み込み中にファイルが伸びた場合も、読み終わるまでは 100% と表示しない
+		percent := 99
+		if total > 0 {
+			percent = min(int(offset*100/total), 99)
+		}
+		return lines, percent, nil
+	}
+	return rest, nil
+}
+
+// readChunk はファイルの offset の位置から RestChunkSize バイトほどを、行の終わりまで読み込む
+// ファイルの終わりまで読み込んだ場合は done が true になる
+func (fm *StandardFileManager) readChunk(filename string, offset int64) (data []byte, done bool, err error) {
+	f, err := fm.open(filename)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+
+	data = make([]byte, RestChunkSize)
+	n, err := io.ReadFull(f, data)
+	data = data[:n]
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return data, true, nil
+	case err != nil:
+		return nil, false, err
+	case data[n-1] == '\n':
+		return data, false, nil
+	}
+	// 行の途中で区切らないよう、行の終わりまで読み足す
+	tail, err := bufio.NewReader(f).ReadBytes('\n')
+	data = append(data, tail...)
+	if err == io.EOF {
+		return data, true, nil
+	}
+	return data, false, err
+}
+
 // SaveFile はバッファの内容をファイルに保存する
 func (fm *StandardFileManager) SaveFile(filename string, content []string) error {
 	if filename == "" {
 		return ErrNoFilename
 	}
 
-	// ファイルに書き込む
-	file, err := os.Create(filename)
+	// 書き込み途中で失敗しても元のファイルが壊れないよう、一時ファイル経由で置き換える
+	// バックアップ先が設定されていれば、置き換える前の内容をバックアップする
+	// BOM と、LF と混在した行末の \r は行の内容として保持しているので、そのまま書き戻せば元の形式が保たれる
+	// 開いたときの文字コードに変換してから書き出す
+	separator := "\n"
+	if fm.buffer != nil {
+		separator = fm.buffer.LineEnding().Separator()
+	}
+	enc := contents.EncodingUTF8
+	if fm.buffer != nil {
+		enc = fm.buffer.Encoding()
+	}
+	data, err := Encode(strings.Join(content, separator), enc)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// 内容を書き込む
-	for i, line := range content {
-		if i > 0 {
-			file.WriteString("\n")
-		}
-		file.WriteString(line)
+	if err := fm.backupBefore(filename, data); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(filename, data, 0644); err != nil {
+		return err
 	}
 
 	// バッファのダーティフラグをクリア
@@ -73,12 +281,36 @@ func (fm *StandardFileManager) SaveFile(filename string, content []string) error
 		fm.buffer.SetDirty(false)
 	}
 
-	// 保存に成功したら、管理しているファイル名を更新する
+	// 保存に成功したら、管理しているファイル名と、変更の確認に使うディスク上の状態を更新する
 	fm.filename = filename
+	fm.recordDisk(filename)
 
 	return nil
 }
 
+// backupBefore は filename を data で上書きする前に、現在の内容をバックアップする
+// ファイルがまだない場合と、内容が変わらない場合は何もしない
+// バックアップできなければ、元の内容を失わないよう保存をやめてエラーを返す
+func (fm *StandardFileManager) backupBefore(filename string, data []byte) error {
+	if fm.backup == nil {
+		return nil
+	}
+	old, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the file to back up: %w", err)
+	}
+	if bytes.Equal(old, data) {
+		return nil
+	}
+	if _, err := fm.backup.Save(filename, old, time.Now()); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
 // SaveCurrentFile は現在のファイルに保存する
 func (fm *StandardFileManager) SaveCurrentFile() error {
 	if fm.buffer == nil {
@@ -95,14 +327,73 @@ func (fm *StandardFileManager) GetFilename() string {
 	return fm.filename
 }
 
-// readFile はファイルを読み込む
-func (fm *StandardFileManager) readFile(filename string) ([]string, error) {
-	data, err := os.ReadFile(filename)
+// open はファイルを読み込み用に開く。大きなファイルでは設定に応じて先読みを指示する
+func (fm *StandardFileManager) open(filename string) (*os.File, error) {
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	lines := strings.Split(string(data), "\n")
-	return lines, nil
+	if fm.readahead {
+		if info, err := f.Stat(); err == nil && info.Size() >= ReadaheadThreshold {
+			adviseSequential(f)
+		}
+	}
+	return f, nil
+}
+
+// readFile はファイルを読み込んで文字コードを enc（autoDetect なら判定する）から変換し、行に分割する
+// 読み込みと、変換を含む行への分割の所要時間を記録する
+func (fm *StandardFileManager) readFile(filename string, enc contents.Encoding) ([]string, contents.Encoding, error) {
+	start := time.Now()
+	data, err := fm.readAll(filename)
+	if err != nil {
+		return nil, enc, err
+	}
+	read := time.Since(start)
+
+	start = time.Now()
+	if err := checkBinary(filename, data, 0); err != nil {
+		return nil, enc, err
+	}
+	if enc == autoDetect {
+		enc = DetectEncoding(data)
+	}
+	text, err := decode(filename, data, enc, 0)
+	if err != nil {
+		return nil, enc, err
+	}
+	lines := strings.Split(text, "\n")
+	fm.stats = OpenStats{Read: read, Split: time.Since(start), Bytes: int64(len(data)), Lines: len(lines)}
+	return lines, enc, nil
+}
+
+// readAll はファイル全体を読み込む
+func (fm *StandardFileManager) readAll(filename string) ([]byte, error) {
+	f, err := fm.open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var size int
+	if info, err := f.Stat(); err == nil {
+		size = int(info.Size())
+	}
+	// 大きさが分かっていれば一度の確保で読み切る。読み込み中にファイルが伸びた場合は広げる
+	data := make([]byte, 0, size+512)
+	for {
+		n, err := f.Read(data[len(data):cap(data)])
+		data = data[:len(data)+n]
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == cap(data) {
+			data = append(data, 0)[:len(data)]
+		}
+	}
 }
 
 // HandleSaveRequest はSystemEventのSaveリクエストを処理する
@@ -1,11 +1,20 @@
 package filemanager
 
 import (
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
 	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
 )
 
 func TestFileManager_OpenFile_FileNotExists(t *testing.T) {
@@ -28,3 +37,433 @@ func TestFileManager_OpenFile_FileNotExists(t *testing.T) {
 		t.Errorf("Expected error os.ErrNotExist, got %v", err)
 	}
 }
+
+func TestFileManager_OpenFileHead(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		maxLines int
+		wantHead []string
+		complete bool
+	}{
+		{"収まる場合は全体を読み込む", "a\nb", 5, []string{"a", "b"}, true},
+		{"先頭だけ読み込む", "a\r\nb\nc\n", 2, []string{"a\r", "b"}, false},
+		{"改行で終わる場合", "a\nb\n", 2, []string{"a", "b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "f.txt")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			buffer := contents.NewContents(logger.New(false))
+			fm := NewFileManager(buffer)
+
+			rest, err := fm.OpenFileHead(path, tt.maxLines)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(buffer.GetAllLines(), tt.wantHead) || fm.GetFilename() != path {
+				t.Errorf("head = %q, filename = %q", buffer.GetAllLines(), fm.GetFilename())
+			}
+			if (rest == nil) != tt.complete {
+				t.Fatalf("complete = %v, want %v", rest == nil, tt.complete)
+			}
+
+			// 先頭と残りをつなげると全体を読み込んだ場合と同じになる
+			all := buffer.GetAllLines()
+			if rest != nil {
+				all = append(all, readRest(t, rest)...)
+			}
+			if want := strings.Split(tt.data, "\n"); !reflect.DeepEqual(all, want) {
+				t.Errorf("lines = %q, want %q", all, want)
+			}
+		})
+	}
+}
+
+// readRest は rest を最後まで呼び出して残りの行を返す。割合は増えていき、最後は 100% になる
+func readRest(t *testing.T, rest func() ([]string, int, error)) []string {
+	t.Helper()
+	var all []string
+	last := -1
+	for {
+		lines, percent, err := rest()
+		if err != nil && err != io.EOF {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if percent < last || (err == io.EOF) != (percent == 100) {
+			t.Errorf("percent %d after %d (err %v)", percent, last, err)
+		}
+		last = percent
+		all = append(all, lines...)
+		if err == io.EOF {
+			return all
+		}
+	}
+}
+
+func TestFileManager_OpenFileHead_Chunks(t *testing.T) {
+	// 区切りの位置をまたぐ長い行と CRLF を含む、RestChunkSize の数倍の大きさのファイル
+	var b strings.Builder
+	b.WriteString("head\r\n")
+	for i := 0; b.Len() < RestChunkSize*5/2; i++ {
+		b.WriteString(strings.Repeat("x", i%5000) + "\r\n")
+	}
+	b.WriteString(strings.Repeat("y", RestChunkSize) + "\r\nlast")
+	data := b.String()
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := contents.NewContents(logger.New(false))
+	rest, err := NewFileManager(buffer).OpenFileHead(path, 1)
+	if err != nil || rest == nil {
+		t.Fatalf("head: %v", err)
+	}
+	chunks := 0
+	all := buffer.GetAllLines()
+	for {
+		lines, _, err := rest()
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		chunks++
+		all = append(all, lines...)
+		if err == io.EOF {
+			break
+		}
+	}
+	if chunks < 3 {
+		t.Errorf("the rest must be read in several chunks: %d", chunks)
+	}
+	if want := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n"); !reflect.DeepEqual(all, want) {
+		t.Errorf("got %d lines, want %d", len(all), len(want))
+	}
+}
+
+func TestFileManager_OpenStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	data := strings.Repeat("line\n", ReadaheadThreshold/5+1)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := contents.NewContents(logger.New(false))
+	fm := NewFileManager(buffer)
+	// 先読みを指示しても読み込んだ内容は変わらない
+	fm.SetReadahead(true)
+	if err := fm.OpenFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats := fm.LastOpenStats()
+	if stats.Bytes != int64(len(data)) || stats.Lines != buffer.GetLineCount() || stats.Lines != ReadaheadThreshold/5+2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if got := strings.Join(buffer.GetAllLines(), "\n"); got != data {
+		t.Errorf("content differs: %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if !Writable(path) {
+		t.Error("a new file in a writable directory must be writable")
+	}
+	if err := os.WriteFile(path, []byte("a\n"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to any file")
+	}
+	if Writable(path) {
+		t.Error("a file without write permission must not be writable")
+	}
+}
+
+func TestFileManager_LineEnding(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		ending contents.LineEnding
+		lines  []string
+	}{
+		{"LF", "a\nb\n", contents.LineEndingLF, []string{"a", "b", ""}},
+		{"CRLF", "a\r\nb\r\nc", contents.LineEndingCRLF, []string{"a", "b", "c"}},
+		// 混在している場合は \r を行の内容として残し、そのまま書き戻す
+		{"混在", "a\r\nb\n", contents.LineEndingLF, []string{"a\r", "b", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.txt")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			buffer := contents.NewContents(logger.New(false))
+			fm := NewFileManager(buffer)
+			if err := fm.OpenFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if buffer.LineEnding() != tt.ending || !reflect.DeepEqual(buffer.GetAllLines(), tt.lines) {
+				t.Errorf("got %v %q", buffer.LineEnding(), buffer.GetAllLines())
+			}
+			if err := fm.SaveCurrentFile(); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.data {
+				t.Errorf("saved %q, want %q", data, tt.data)
+			}
+		})
+	}
+
+	// 先頭だけ読み込んだ場合も、残りの行の \r を取り除く
+	path := filepath.Join(t.TempDir(), "b.txt")
+	if err := os.WriteFile(path, []byte("a\r\nb\r\nc\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buffer := contents.NewContents(logger.New(false))
+	rest, err := NewFileManager(buffer).OpenFileHead(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := readRest(t, rest)
+	if buffer.LineEnding() != contents.LineEndingCRLF || !reflect.DeepEqual(lines, []string{"b", "c", ""}) {
+		t.Errorf("rest: %q", lines)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	lines, ending, enc, err := DecodeText("stdin", []byte("a\r\nb\r\n"))
+	if err != nil || ending != contents.LineEndingCRLF || enc != contents.EncodingUTF8 || !reflect.DeepEqual(lines, []string{"a", "b", ""}) {
+		t.Errorf("DecodeText() = %q, %v, %v, %v", lines, ending, enc, err)
+	}
+	if _, _, _, err := DecodeText("stdin", []byte("a\x00b")); !errors.Is(err, ErrBinaryFile) {
+		t.Errorf("expected ErrBinaryFile, got %v", err)
+	}
+}
+
+func TestFileManager_RejectsNonText(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		open func(fm *StandardFileManager, path string) error
+		want error
+		msg  string
+	}{
+		{"NUL を含む", "ab\ncd\x00ef", (*StandardFileManager).OpenFile, ErrBinaryFile, "offset 5"},
+		{"UTF-8 として不正", "あい\n\x82\xa0", utf8Opener, ErrInvalidUTF8, "0x82 at offset 7"},
+		{"途中で切れた文字", "あ\xe3\x81", utf8Opener, ErrInvalidUTF8, "offset 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.bin")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			buffer := contents.NewContents(logger.New(false))
+			buffer.LoadContent([]string{"previous"})
+			fm := NewFileManager(buffer)
+			err := tt.open(fm, path)
+			if !errors.Is(err, tt.want) || !strings.Contains(err.Error(), tt.msg) {
+				t.Fatalf("got %v, want %v (%s)", err, tt.want, tt.msg)
+			}
+			// 開けなかった場合はバッファもファイル名も変えない
+			if fm.GetFilename() != "" || !reflect.DeepEqual(buffer.GetAllLines(), []string{"previous"}) {
+				t.Errorf("buffer changed: %q %q", fm.GetFilename(), buffer.GetAllLines())
+			}
+		})
+	}
+
+	// 先頭だけ読み込む場合、残りにあるバイナリや先頭と異なる文字コードの部分は rest がエラーを返す
+	for _, tt := range []struct {
+		data string
+		want error
+	}{
+		{"ab\n\x00ef", ErrBinaryFile},
+		{"ab\n\x82\xa0", ErrInvalidUTF8},
+	} {
+		path := filepath.Join(t.TempDir(), "a.bin")
+		if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rest, err := NewFileManager(contents.NewContents(logger.New(false))).OpenFileHead(path, 1)
+		if err != nil || rest == nil {
+			t.Fatalf("head: %v", err)
+		}
+		if _, _, err := rest(); !errors.Is(err, tt.want) || !strings.Contains(err.Error(), "offset 3") {
+			t.Errorf("rest: got %v, want %v at offset 3", err, tt.want)
+		}
+	}
+}
+
+func utf8Opener(fm *StandardFileManager, path string) error {
+	return fm.OpenFileWithEncoding(path, contents.EncodingUTF8)
+}
+
+func TestFileManager_Encoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want contents.Encoding
+	}{
+		{"UTF-8", "日本語\n", contents.EncodingUTF8},
+		{"Shift_JIS", "\x93\xfa\x96\x7b\x8c\xea\r\nabc\r\n", contents.EncodingShiftJIS},
+		{"EUC-JP", "\xc6\xfc\xcb\xdc\xb8\xec\nabc\n", contents.EncodingEUCJP},
+		{"Latin-1", "caf\xe9 cr\xe8me\n", contents.EncodingLatin1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.txt")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, head := range []bool{false, true} {
+				buffer := contents.NewContents(logger.New(false))
+				fm := NewFileManager(buffer)
+				if head {
+					if _, err := fm.OpenFileHead(path, 10); err != nil {
+						t.Fatal(err)
+					}
+				} else if err := fm.OpenFile(path); err != nil {
+					t.Fatal(err)
+				}
+				if buffer.Encoding() != tt.want {
+					t.Errorf("detected %v, want %v", buffer.Encoding(), tt.want)
+				}
+				if line := buffer.GetContentLine(0); line != "日本語" && line != "café crème" {
+					t.Errorf("decoded %q", line)
+				}
+				// 保存すると元のバイト列に戻る
+				if err := fm.SaveCurrentFile(); err != nil {
+					t.Fatal(err)
+				}
+				if data, _ := os.ReadFile(path); string(data) != tt.data {
+					t.Errorf("saved %q, want %q", data, tt.data)
+				}
+			}
+		})
+	}
+
+	// 変換できない文字を含む場合は保存せず、文字と行番号を返す
+	path := filepath.Join(t.TempDir(), "sjis.txt")
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"あ", "x😀"})
+	buffer.SetEncoding(contents.EncodingShiftJIS)
+	err := NewFileManager(buffer).SaveFile(path, buffer.GetAllLines())
+	if !errors.Is(err, ErrUnencodable) || !strings.Contains(err.Error(), "U+1F600") || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the file must not be written")
+	}
+
+	// 指定した文字コードとして正しくない場合は開かない
+	if err := os.WriteFile(path, []byte("\x82"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileManager(buffer).OpenFileWithEncoding(path, contents.EncodingShiftJIS); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestFileManager_DiskChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("a\r\nb\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buffer := contents.NewContents(logger.New(false))
+	fm := NewFileManager(buffer)
+	if err := fm.OpenFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed := fm.DiskChange(); changed {
+		t.Fatal("a file just opened must not be reported as changed")
+	}
+
+	// ほかのプロセスによる書き換え
+	if err := os.WriteFile(path, []byte("a\r\nc\r\nd\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stamp, changed := fm.DiskChange()
+	if !changed {
+		t.Fatal("the rewrite must be reported")
+	}
+	lines, err := fm.ReadDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "c", "d", ""}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("ReadDisk() = %q, want %q", lines, want)
+	}
+	if got := buffer.GetAllLines(); !reflect.DeepEqual(got, []string{"a", "b", ""}) {
+		t.Errorf("ReadDisk must not change the buffer: %q", got)
+	}
+
+	fm.AcceptDisk(stamp)
+	if _, changed := fm.DiskChange(); changed {
+		t.Error("an accepted change must not be reported again")
+	}
+
+	// 更新日時だけが変わった場合も変更とみなす
+	later := stamp.ModTime.Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed := fm.DiskChange(); !changed {
+		t.Error("a new modification time must be reported")
+	}
+
+	// 自分で保存した後は変更とみなさない
+	if err := fm.SaveCurrentFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed := fm.DiskChange(); changed {
+		t.Error("saving must record the new state")
+	}
+}
+
+// failingBackup は常に失敗するバックアップ先
+type failingBackup struct{}
+
+func (failingBackup) Save(string, []byte, time.Time) (string, error) {
+	return "", errors.New("disk full")
+}
+
+func TestFileManager_SaveFile_Backup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("old\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	buffer := contents.NewContents(logger.New(false))
+	fm := NewFileManager(buffer)
+	fm.SetBackup(backupfile.TildeStore{})
+
+	if err := fm.SaveFile(path, []string{"new", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path + "~"); err != nil || string(data) != "old\n" {
+		t.Errorf("backup = %q, %v; want the previous content", data, err)
+	}
+	if info, err := os.Stat(path + "~"); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("the backup must have the original permission: %v", err)
+	}
+
+	// 内容が変わらない保存では、直前のバックアップを残す
+	if err := fm.SaveFile(path, []string{"new", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path + "~"); string(data) != "old\n" {
+		t.Errorf("backup = %q after saving the same content", data)
+	}
+
+	// バックアップできなければ上書きしない
+	fm.SetBackup(failingBackup{})
+	if err := fm.SaveFile(path, []string{"newer", ""}); err == nil {
+		t.Error("a failed backup must abort the save")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("file = %q; the original must be kept", data)
+	}
+}
@@ -75,6 +75,21 @@ func (mr *MockFileManagerMockRecorder) OpenFile(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFile", reflect.TypeOf((*MockFileManager)(nil).OpenFile), arg0)
 }
 
+// OpenFileHead mocks base method.
+func (m *MockFileManager) OpenFileHead(arg0 string, arg1 int) (func() ([]string, int, error), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFileHead", arg0, arg1)
+	ret0, _ := ret[0].(func() ([]string, int, error))
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenFileHead indicates an expected call of OpenFileHead.
+func (mr *MockFileManagerMockRecorder) OpenFileHead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFileHead", reflect.TypeOf((*MockFileManager)(nil).OpenFileHead), arg0, arg1)
+}
+
 // SaveCurrentFile mocks base method.
 func (m *MockFileManager) SaveCurrentFile() error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,72 @@
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// writeBenchFile は size バイト程度の、80桁の行からなるファイルを作成する
+func writeBenchFile(b *testing.B, size int) string {
+	b.Helper()
+	line := strings.Repeat("x", 79) + "\n"
+	path := filepath.Join(b.TempDir(), fmt.Sprintf("%d.txt", size))
+	if err := os.WriteFile(path, []byte(strings.Repeat(line, size/len(line))), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// evict はファイルのページキャッシュを破棄し、キャッシュのない状態から読み込ませる
+func evict(b *testing.B, path string) {
+	b.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	dropCache(f)
+	f.Close()
+}
+
+// BenchmarkOpenFile は従来の読み込み（os.ReadFile と分割）と、先読みを指示する読み込みを比べる
+func BenchmarkOpenFile(b *testing.B) {
+	for _, size := range []int{10 << 20, 100 << 20} {
+		path := writeBenchFile(b, size)
+		name := fmt.Sprintf("%dMB", size>>20)
+
+		b.Run(name+"/current", func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				evict(b, path)
+				b.StartTimer()
+				data, err := os.ReadFile(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = strings.Split(string(data), "\n")
+			}
+		})
+		b.Run(name+"/readahead", func(b *testing.B) {
+			fm := NewFileManager(contents.NewContents(logger.New(false)))
+			fm.SetReadahead(true)
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				evict(b, path)
+				b.StartTimer()
+				if _, _, err := fm.readFile(path, autoDetect); err != nil {
+					b.Fatal(err)
+				}
+			}
+			stats := fm.LastOpenStats()
+			b.ReportMetric(float64(stats.Read.Microseconds()), "read-µs")
+			b.ReportMetric(float64(stats.Split.Microseconds()), "split-µs")
+		})
+	}
+}
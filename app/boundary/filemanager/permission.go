@@ -0,0 +1,19 @@
+package filemanager
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// Writable は filename に書き込めるかどうかを実行中のユーザーの権限で判定する
+// ファイルがまだなければ、作成するディレクトリに書き込めるかどうかを返す
+func Writable(filename string) bool {
+	err := unix.Access(filename, unix.W_OK)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = unix.Access(filepath.Dir(filename), unix.W_OK)
+	}
+	return err == nil
+}
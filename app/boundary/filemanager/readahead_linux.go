@@ -0,0 +1,19 @@
+package filemanager
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseSequential はファイル全体を先頭から読むことをカーネルに伝え、先読みを促す
+func adviseSequential(f *os.File) {
+	fd := int(f.Fd())
+	_ = unix.Fadvise(fd, 0, 0, unix.FADV_SEQUENTIAL)
+	_ = unix.Fadvise(fd, 0, 0, unix.FADV_WILLNEED)
+}
+
+// dropCache はファイルのページキャッシュを破棄する。キャッシュのない状態で読み込みを計測するために使う
+func dropCache(f *os.File) {
+	_ = unix.Fadvise(int(f.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package filemanager
+
+import "os"
+
+// adviseSequential は先読みの指示に対応していない環境では何もしない
+func adviseSequential(f *os.File) {}
+
+// dropCache はページキャッシュの破棄に対応していない環境では何もしない
+func dropCache(f *os.File) {}
@@ -0,0 +1,163 @@
+package filemanager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// テキストとして開けない、または保存できない場合のエラー
+var (
+	ErrBinaryFile      = errors.New("binary file")
+	ErrInvalidUTF8     = errors.New("invalid UTF-8")
+	ErrInvalidEncoding = errors.New("invalid byte sequence")
+	ErrUnencodable     = errors.New("character cannot be encoded")
+)
+
+// autoDetect は開く際に文字コードを判定することを表す
+const autoDetect contents.Encoding = -1
+
+// EncodingOpener は文字コードを指定してファイルを開き直せる FileManager
+type EncodingOpener interface {
+	OpenFileWithEncoding(filename string, enc contents.Encoding) error
+}
+
+// codec は文字コードの変換器を返す。UTF-8 は変換しないので nil
+func codec(enc contents.Encoding) encoding.Encoding {
+	switch enc {
+	case contents.EncodingShiftJIS:
+		return japanese.ShiftJIS
+	case contents.EncodingEUCJP:
+		return japanese.EUCJP
+	case contents.EncodingLatin1:
+		return charmap.ISO8859_1
+	}
+	return nil
+}
+
+// checkBinary は NUL を含むデータをバイナリとみなしてエラーを返す
+// 開いたまま保存すると元のバイト列を壊してしまうため、バイナリのファイルは開かない
+// base はデータのファイル内での位置で、エラーに含めるオフセットの計算に使う
+func checkBinary(filename string, data []byte, base int64) error {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return fmt.Errorf("%s: %w (NUL byte at offset %d)", filename, ErrBinaryFile, base+int64(i))
+	}
+	return nil
+}
+
+// DetectEncoding はデータの文字コードを判定する
+// UTF-8 として正しければ UTF-8、Shift_JIS か EUC-JP として正しく日本語の文字を含めば日本語の文字が多い方
+// どれにも当てはまらなければ、すべてのバイトを表せる Latin-1 とみなす
+func DetectEncoding(data []byte) contents.Encoding {
+	if utf8.Valid(data) {
+		return contents.EncodingUTF8
+	}
+	detected, best := contents.EncodingLatin1, 0
+	for _, enc := range []contents.Encoding{contents.EncodingShiftJIS, contents.EncodingEUCJP} {
+		text, err := codec(enc).NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(text, utf8.RuneError) {
+			continue
+		}
+		if score := japaneseRunes(text); score > best {
+			detected, best = enc, score
+		}
+	}
+	return detected
+}
+
+// japaneseRunes は UTF-8 のテキストに含まれるひらがな・全角カタカナ・漢字の数を返す
+func japaneseRunes(text []byte) int {
+	n := 0
+	for _, r := range string(text) {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) && (r < 0xff61 || r > 0xff9f) {
+			n++
+		}
+	}
+	return n
+}
+
+// decode は文字コード enc のデータを UTF-8 に変換する
+// 変換できないバイトを含む場合は、保存で元のバイト列を壊さないようエラーを返す
+// base はデータのファイル内での位置で、エラーに含めるオフセットの計算に使う
+func decode(filename string, data []byte, enc contents.Encoding, base int64) (string, error) {
+	c := codec(enc)
+	if c == nil {
+		if !utf8.Valid(data) {
+			for i := 0; i < len(data); {
+				r, size := utf8.DecodeRune(data[i:])
+				if r == utf8.RuneError && size == 1 {
+					return "", fmt.Errorf("%s: %w (byte 0x%02x at offset %d)", filename, ErrInvalidUTF8, data[i], base+int64(i))
+				}
+				i += size
+			}
+		}
+		return string(data), nil
+	}
+	text, err := c.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filename, err)
+	}
+	if bytes.ContainsRune(text, utf8.RuneError) {
+		return "", fmt.Errorf("%s: %w for %s", filename, ErrInvalidEncoding, enc)
+	}
+	return string(text), nil
+}
+
+// DecodeText は標準入力などファイル以外から読み込んだ data を、ファイルを開く場合と同じく行に分ける
+// 文字コードと改行コードを判定して返す。name はエラーメッセージに使う
+func DecodeText(name string, data []byte) ([]string, contents.LineEnding, contents.Encoding, error) {
+	if err := checkBinary(name, data, 0); err != nil {
+		return nil, contents.LineEndingLF, contents.EncodingUTF8, err
+	}
+	enc := DetectEncoding(data)
+	text, err := decode(name, data, enc, 0)
+	if err != nil {
+		return nil, contents.LineEndingLF, enc, err
+	}
+	lines := strings.Split(text, "\n")
+	return lines, trimLineEnding(lines), enc, nil
+}
+
+// trimLineEnding は改行で分けた行の改行コードを判定し、CRLF に揃っていれば行末の \r を取り除く
+// 最後の要素は改行で終わっていないため判定に含めない
+func trimLineEnding(lines []string) contents.LineEnding {
+	terminated := lines[:len(lines)-1]
+	ending := contents.DetectLineEnding(terminated)
+	if ending == contents.LineEndingCRLF {
+		contents.TrimCR(terminated)
+	}
+	return ending
+}
+
+// Encode は UTF-8 のテキストを文字コード enc に変換する
+// 変換できない文字を含む場合は、最初の文字とその行番号をエラーに含める
+func Encode(text string, enc contents.Encoding) ([]byte, error) {
+	c := codec(enc)
+	if c == nil {
+		return []byte(text), nil
+	}
+	encoder := c.NewEncoder()
+	data, err := encoder.String(text)
+	if err == nil {
+		return []byte(data), nil
+	}
+	line := 1
+	for _, r := range text {
+		if r == '\n' {
+			line++
+			continue
+		}
+		if _, err := encoder.String(string(r)); err != nil {
+			return nil, fmt.Errorf("%w: %q (U+%04X) on line %d cannot be saved as %s", ErrUnencodable, r, r, line, enc)
+		}
+	}
+	return nil, err
+}
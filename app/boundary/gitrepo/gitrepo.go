@@ -0,0 +1,122 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+	entity "github.com/wasya-io/go-kilo/app/entity/git"
+)
+
+// gitCommand は git の実行ファイル名
+const gitCommand = "git"
+
+// Repository は git コマンドでファイルの変更とブランチを調べる
+type Repository struct {
+	runner external.Runner
+}
+
+// New は runner で git を実行する Repository を作成する
+func New(runner external.Runner) *Repository {
+	return &Repository{runner: runner}
+}
+
+// Available は git が実行可能かどうかを返す
+func (r *Repository) Available() bool {
+	return r.runner.Available(gitCommand)
+}
+
+// Changes は path のファイルを HEAD と比べた行ごとの変更を返す
+// 保存済みの内容と比べるため、未保存の編集は含まない。リポジトリの外のファイルはエラーを返す
+func (r *Repository) Changes(ctx context.Context, path string) (entity.LineChanges, error) {
+	dir, name := filepath.Split(path)
+	out, err := r.runner.Run(ctx, dir, gitCommand, []string{"diff", "--no-color", "--no-ext-diff", "-U0", "HEAD", "--", name}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(out)
+}
+
+// Branch は dir を含むリポジトリの現在のブランチ名を返す
+// ブランチにいない（detached HEAD の）場合はコミットの短い ID を返す
+func (r *Repository) Branch(ctx context.Context, dir string) (string, error) {
+	if out, err := r.runner.Run(ctx, dir, gitCommand, []string{"symbolic-ref", "--short", "-q", "HEAD"}, nil); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+	out, err := r.runner.Run(ctx, dir, gitCommand, []string{"rev-parse", "--short", "HEAD"}, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parseDiff は -U0 で出力した差分のハンクの見出し（@@ -a,b +c,d @@）から行ごとの変更を求める
+// 削除した行は残った行に含まれないため、直前の行（先頭なら最初の行）に印を付ける
+func parseDiff(out []byte) (entity.LineChanges, error) {
+	changes := make(entity.LineChanges)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("malformed hunk header %q", line)
+		}
+		_, removed, err := parseRange(fields[1], "-")
+		if err != nil {
+			return nil, err
+		}
+		start, added, err := parseRange(fields[2], "+")
+		if err != nil {
+			return nil, err
+		}
+
+		if added == 0 {
+			// 削除だけのハンクでは start は削除した位置の直前の行（1始まり）
+			at := max(start-1, 0)
+			if _, ok := changes[at]; !ok {
+				changes[at] = entity.ChangeDeleted
+			}
+			continue
+		}
+		for i := 0; i < added; i++ {
+			kind := entity.ChangeAdded
+			if i < removed {
+				kind = entity.ChangeModified
+			}
+			changes[start-1+i] = kind
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// parseRange は "-a,b" や "+c" の形の範囲を開始行と行数にする。行数を省略した場合は1行
+func parseRange(field, prefix string) (int, int, error) {
+	spec, ok := strings.CutPrefix(field, prefix)
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+	}
+	startText, countText, hasCount := strings.Cut(spec, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+		}
+	}
+	return start, count, nil
+}
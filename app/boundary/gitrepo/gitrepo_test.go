@@ -0,0 +1,93 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	entity "github.com/wasya-io/go-kilo/app/entity/git"
+)
+
+// fakeRunner は git の代わりにコマンドごとに決まった出力を返す
+type fakeRunner struct {
+	outputs map[string]string // 最初の引数ごとの出力
+	errs    map[string]error
+	dir     string
+	args    []string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.dir, r.args = dir, args
+	return []byte(r.outputs[args[0]]), r.errs[args[0]]
+}
+
+func (r *fakeRunner) Available(name string) bool { return true }
+
+func TestChanges(t *testing.T) {
+	out := strings.Join([]string{
+		"diff --git a/main.go b/main.go",
+		"index 1234567..89abcde 100644",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -0,0 +1,2 @@",
+		"+// Package main",
+		"+",
+		"@@ -5 +7 @@ func main() {",
+		"-	old()",
+		"+	new()",
+		"@@ -8,2 +10,3 @@",
+		"-a",
+		"-b",
+		"+a2",
+		"+b2",
+		"+c2",
+		"@@ -12,3 +14,0 @@",
+		"-x",
+		"-y",
+		"-z",
+		"@@ -1 +0,0 @@",
+		"-first",
+	}, "\n")
+	runner := &fakeRunner{outputs: map[string]string{"diff": out}}
+	changes, err := New(runner).Changes(context.Background(), "/repo/app/main.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := entity.LineChanges{
+		0: entity.ChangeAdded, 1: entity.ChangeAdded,
+		6: entity.ChangeModified,
+		9: entity.ChangeModified, 10: entity.ChangeModified, 11: entity.ChangeAdded,
+		13: entity.ChangeDeleted,
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+	if runner.dir != "/repo/app/" || runner.args[len(runner.args)-1] != "main.go" {
+		t.Errorf("git diff must run in the file's directory: dir=%q args=%v", runner.dir, runner.args)
+	}
+}
+
+func TestChanges_MalformedHunk(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{"diff": "@@ -a +1 @@"}}
+	if _, err := New(runner).Changes(context.Background(), "main.go"); err == nil {
+		t.Error("a malformed hunk header must be an error")
+	}
+}
+
+func TestBranch(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{"symbolic-ref": "main\n"}}
+	if branch, err := New(runner).Branch(context.Background(), "/repo"); err != nil || branch != "main" {
+		t.Errorf("Branch() = %q, %v", branch, err)
+	}
+
+	// detached HEAD ではコミットの ID を返す
+	runner = &fakeRunner{
+		outputs: map[string]string{"rev-parse": "1a2b3c4\n"},
+		errs:    map[string]error{"symbolic-ref": errors.New("exit status 1")},
+	}
+	if branch, err := New(runner).Branch(context.Background(), "/repo"); err != nil || branch != "1a2b3c4" {
+		t.Errorf("Branch() = %q, %v", branch, err)
+	}
+}
@@ -0,0 +1,151 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	entity "github.com/wasya-io/go-kilo/app/entity/grep"
+)
+
+// maxFileSize は検索対象とするファイルサイズの上限
+const maxFileSize = 10 * 1024 * 1024
+
+// Searcher はプロジェクト内の文字列検索を行うインターフェース
+type Searcher interface {
+	Search(ctx context.Context, root string, pattern string) ([]entity.Match, error)
+}
+
+// Walker は Go のファイル走査による Searcher の実装
+type Walker struct {
+	skipDirs     map[string]bool
+	excludeNames map[string]bool
+	excludePaths map[string]bool
+}
+
+// NewWalker は新しい Walker を作成する
+func NewWalker() *Walker {
+	return &Walker{
+		skipDirs: map[string]bool{".git": true, "node_modules": true, "vendor": true},
+	}
+}
+
+// SetExclude は検索から除外するディレクトリを設定する（既存の設定は置き換える）
+// 区切り文字を含まない名前はどの階層でも一致し、含むものは base からの相対パスとして扱う
+func (w *Walker) SetExclude(base string, dirs []string) {
+	w.excludeNames = make(map[string]bool)
+	w.excludePaths = make(map[string]bool)
+	for _, dir := range dirs {
+		dir = filepath.Clean(filepath.FromSlash(dir))
+		if !strings.ContainsRune(dir, filepath.Separator) {
+			w.excludeNames[dir] = true
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		w.excludePaths[dir] = true
+	}
+}
+
+// skip はディレクトリを検索対象から外すかどうかを返す
+func (w *Walker) skip(path, name string) bool {
+	if w.skipDirs[name] || w.excludeNames[name] {
+		return true
+	}
+	if len(w.excludePaths) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && w.excludePaths[abs]
+}
+
+// Search は root 以下のテキストファイルから pattern を含む行を探す
+func (w *Walker) Search(ctx context.Context, root string, pattern string) ([]entity.Match, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	var matches []entity.Match
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 読めないディレクトリは読み飛ばす
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != root && w.skip(path, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		found, err := searchFile(path, pattern)
+		if err != nil {
+			return nil
+		}
+		matches = append(matches, found...)
+		return nil
+	})
+	return matches, err
+}
+
+// searchFile は1つのファイルを検索する。バイナリファイルは対象外とする
+func searchFile(path, pattern string) ([]entity.Match, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isBinary(data) {
+		return nil, nil
+	}
+
+	var matches []entity.Match
+	patternLen := utf8.RuneCountInString(pattern)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for lineNo := 0; scanner.Scan(); lineNo++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		offset := 0
+		for {
+			i := strings.Index(line[offset:], pattern)
+			if i < 0 {
+				break
+			}
+			byteCol := offset + i
+			matches = append(matches, entity.Match{
+				File:   path,
+				Line:   lineNo,
+				Col:    utf8.RuneCountInString(line[:byteCol]),
+				Length: patternLen,
+				Text:   line,
+			})
+			offset = byteCol + len(pattern)
+		}
+	}
+	return matches, scanner.Err()
+}
+
+// isBinary はNULバイトを含むかどうかでバイナリファイルを判定する
+func isBinary(data []byte) bool {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	return bytes.IndexByte(head, 0) >= 0
+}
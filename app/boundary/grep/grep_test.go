@@ -0,0 +1,53 @@
+package grep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalker_Search(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("日本語 foo\r\nno\nfoofoo\n"), 0644)
+	os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("foo\n"), 0644)
+	os.WriteFile(filepath.Join(root, ".git", "c.txt"), []byte("foo\n"), 0644)
+	os.WriteFile(filepath.Join(root, "bin.dat"), []byte("foo\x00"), 0644)
+
+	matches, err := NewWalker().Search(context.Background(), root, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("expected 4 matches, got %+v", matches)
+	}
+	if m := matches[0]; m.Line != 0 || m.Col != 4 || m.Text != "日本語 foo" {
+		t.Errorf("unexpected first match: %+v", m)
+	}
+	if m := matches[2]; m.Line != 2 || m.Col != 3 {
+		t.Errorf("unexpected repeated match: %+v", m)
+	}
+}
+
+func TestWalker_SetExclude(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "gen"), 0755)
+	os.MkdirAll(filepath.Join(root, "pkg", "gen"), 0755)
+	os.MkdirAll(filepath.Join(root, "docs", "api"), 0755)
+	os.MkdirAll(filepath.Join(root, "api"), 0755)
+	for _, dir := range []string{"gen", "pkg/gen", "docs/api", "api"} {
+		os.WriteFile(filepath.Join(root, dir, "x.txt"), []byte("foo\n"), 0644)
+	}
+
+	w := NewWalker()
+	w.SetExclude(root, []string{"gen", "docs/api"})
+	matches, err := w.Search(context.Background(), root, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].File != filepath.Join(root, "api", "x.txt") {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+}
@@ -0,0 +1,173 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+	entity "github.com/wasya-io/go-kilo/app/entity/grep"
+)
+
+// 検索に使う実装の名前（設定の GREP_BACKEND）
+const (
+	BackendAuto    = "auto"    // ripgrep があれば ripgrep、なければ Go の走査
+	BackendRipgrep = "ripgrep" // ripgrep を使う（見つからなければ Go の走査）
+	BackendBuiltin = "builtin" // Go の走査
+)
+
+// ripgrepCommand は ripgrep の実行ファイル名
+const ripgrepCommand = "rg"
+
+// Excluder は検索から除外するディレクトリを設定できる Searcher
+type Excluder interface {
+	SetExclude(base string, dirs []string)
+}
+
+// Select は backend に応じた Searcher を返す。ripgrep が実行できなければ Go の走査を使う
+func Select(backend string, runner external.Runner) Searcher {
+	if backend != BackendBuiltin && runner.Available(ripgrepCommand) {
+		return NewRipgrep(runner)
+	}
+	return NewWalker()
+}
+
+// Ripgrep は ripgrep の JSON 出力による Searcher の実装
+// .gitignore で無視したファイルは検索しない
+type Ripgrep struct {
+	runner       external.Runner
+	excludeNames []string
+	excludePaths []string
+}
+
+// NewRipgrep は runner で ripgrep を実行する Ripgrep を作成する
+func NewRipgrep(runner external.Runner) *Ripgrep {
+	return &Ripgrep{runner: runner}
+}
+
+// SetExclude は検索から除外するディレクトリを設定する（既存の設定は置き換える）
+// Walker と同じく、区切り文字を含まない名前はどの階層でも一致し、含むものは base からの相対パスとして扱う
+func (r *Ripgrep) SetExclude(base string, dirs []string) {
+	r.excludeNames, r.excludePaths = nil, nil
+	for _, dir := range dirs {
+		dir = filepath.Clean(filepath.FromSlash(dir))
+		if !strings.ContainsRune(dir, filepath.Separator) {
+			r.excludeNames = append(r.excludeNames, dir)
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		r.excludePaths = append(r.excludePaths, dir)
+	}
+}
+
+// args は root 以下で pattern を探す ripgrep の引数を返す
+// Walker と同じく隠しファイルも検索し、.git・node_modules・vendor と除外したディレクトリは検索しない
+func (r *Ripgrep) args(root, pattern string) []string {
+	args := []string{"--json", "--fixed-strings", "--hidden", "--no-config", "--max-filesize", "10M"}
+	for _, name := range []string{".git", "node_modules", "vendor"} {
+		args = append(args, "--glob", "!"+name+"/")
+	}
+	for _, name := range r.excludeNames {
+		args = append(args, "--glob", "!"+filepath.ToSlash(name)+"/")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err == nil {
+		for _, path := range r.excludePaths {
+			rel, err := filepath.Rel(absRoot, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			// 先頭の / で検索の起点からのパスとして扱う
+			args = append(args, "--glob", "!/"+filepath.ToSlash(rel)+"/")
+		}
+	}
+	return append(args, "--", pattern, root)
+}
+
+// Search は root 以下のテキストファイルから pattern を含む行を探す
+// 結果はファイルのパスと行の順に並べる
+func (r *Ripgrep) Search(ctx context.Context, root string, pattern string) ([]entity.Match, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	out, err := r.runner.Run(ctx, "", ripgrepCommand, r.args(root, pattern), nil)
+	if err != nil {
+		// 終了コード 1 は一致なし、2 は一部のファイルを読めなかった場合で、見つかった分は出力されている
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() > 2 || (exit.ExitCode() == 2 && len(out) == 0) {
+			return nil, err
+		}
+	}
+	matches, err := parseRipgrepJSON(out)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].File != matches[j].File {
+			return matches[i].File < matches[j].File
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches, nil
+}
+
+// ripgrepMessage は ripgrep の JSON 出力の1行
+type ripgrepMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       ripgrepText `json:"path"`
+		Lines      ripgrepText `json:"lines"`
+		LineNumber int         `json:"line_number"`
+		Submatches []struct {
+			Match ripgrepText `json:"match"`
+			Start int         `json:"start"`
+		} `json:"submatches"`
+	} `json:"data"`
+}
+
+// ripgrepText は ripgrep が出力する文字列。UTF-8 でない場合は text の代わりに bytes（base64）が入る
+type ripgrepText struct {
+	Text  *string `json:"text"`
+	Bytes string  `json:"bytes"`
+}
+
+// parseRipgrepJSON は ripgrep の JSON 出力から一致箇所を取り出す
+// UTF-8 として扱えないパスや行は読み飛ばす
+func parseRipgrepJSON(out []byte) ([]entity.Match, error) {
+	var matches []entity.Match
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for scanner.Scan() {
+		var msg ripgrepMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, err
+		}
+		if msg.Type != "match" || msg.Data.Path.Text == nil || msg.Data.Lines.Text == nil {
+			continue
+		}
+		raw := *msg.Data.Lines.Text
+		line := strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+		for _, sub := range msg.Data.Submatches {
+			if sub.Match.Text == nil || sub.Start > len(line) {
+				continue
+			}
+			matches = append(matches, entity.Match{
+				File:   *msg.Data.Path.Text,
+				Line:   msg.Data.LineNumber - 1,
+				Col:    utf8.RuneCountInString(line[:sub.Start]),
+				Length: utf8.RuneCountInString(*sub.Match.Text),
+				Text:   line,
+			})
+		}
+	}
+	return matches, scanner.Err()
+}
@@ -0,0 +1,88 @@
+package grep
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	entity "github.com/wasya-io/go-kilo/app/entity/grep"
+)
+
+// fakeRunner は ripgrep の代わりに決まった出力を返す
+type fakeRunner struct {
+	available bool
+	out       string
+	err       error
+	args      []string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.args = args
+	return []byte(r.out), r.err
+}
+
+func (r *fakeRunner) Available(name string) bool { return r.available }
+
+func TestRipgrep_Search(t *testing.T) {
+	out := strings.Join([]string{
+		`{"type":"begin","data":{"path":{"text":"root/b.txt"}}}`,
+		`{"type":"match","data":{"path":{"text":"root/b.txt"},"lines":{"text":"foofoo\n"},"line_number":3,"submatches":[{"match":{"text":"foo"},"start":0,"end":3},{"match":{"text":"foo"},"start":3,"end":6}]}}`,
+		`{"type":"match","data":{"path":{"text":"root/a.txt"},"lines":{"text":"日本語 foo\r\n"},"line_number":1,"submatches":[{"match":{"text":"foo"},"start":10,"end":13}]}}`,
+		`{"type":"match","data":{"path":{"bytes":"/w=="},"lines":{"text":"foo\n"},"line_number":1,"submatches":[{"match":{"text":"foo"},"start":0,"end":3}]}}`,
+		`{"type":"summary","data":{}}`,
+	}, "\n")
+	runner := &fakeRunner{out: out}
+	matches, err := NewRipgrep(runner).Search(context.Background(), "root", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []entity.Match{
+		{File: "root/a.txt", Line: 0, Col: 4, Length: 3, Text: "日本語 foo"},
+		{File: "root/b.txt", Line: 2, Col: 0, Length: 3, Text: "foofoo"},
+		{File: "root/b.txt", Line: 2, Col: 3, Length: 3, Text: "foofoo"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("matches = %+v", matches)
+	}
+	if got := runner.args[len(runner.args)-3:]; !reflect.DeepEqual(got, []string{"--", "foo", "root"}) {
+		t.Errorf("pattern must follow --: %q", runner.args)
+	}
+}
+
+func TestRipgrep_NoMatchAndExclude(t *testing.T) {
+	// 一致がない場合、ripgrep は終了コード 1 で終わる
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	runner := &fakeRunner{err: exitErr}
+	rg := NewRipgrep(runner)
+	root := t.TempDir()
+	rg.SetExclude(root, []string{"gen", "docs/api"})
+	matches, err := rg.Search(context.Background(), root, "foo")
+	if err != nil || len(matches) != 0 {
+		t.Errorf("no match: %+v %v", matches, err)
+	}
+	args := strings.Join(runner.args, " ")
+	for _, want := range []string{"--glob !gen/", "--glob !/docs/api/", "--glob !.git/", "--fixed-strings"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("missing %q in %q", want, args)
+		}
+	}
+
+	runner.err = exec.Command("sh", "-c", "exit 3").Run()
+	if _, err := rg.Search(context.Background(), root, "foo"); err == nil {
+		t.Error("other failures must be reported")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	if _, ok := Select(BackendAuto, &fakeRunner{available: true}).(*Ripgrep); !ok {
+		t.Error("auto must use ripgrep when available")
+	}
+	if _, ok := Select(BackendRipgrep, &fakeRunner{}).(*Walker); !ok {
+		t.Error("must fall back to the walker when ripgrep is missing")
+	}
+	if _, ok := Select(BackendBuiltin, &fakeRunner{available: true}).(*Walker); !ok {
+		t.Error("builtin must use the walker")
+	}
+}
@@ -0,0 +1,86 @@
+package historyfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// extension は編集履歴ファイルの拡張子
+const extension = ".history"
+
+// Entry はファイル1つ分の編集履歴を表す
+type Entry struct {
+	Original string        `json:"original"`
+	SavedAt  time.Time     `json:"saved_at"`
+	Checksum string        `json:"checksum"` // 履歴を保存した時点のファイルの内容のハッシュ
+	History  command.Saved `json:"history"`
+}
+
+// Store は編集履歴をファイルごとに保存・読み込むためのインターフェース
+type Store interface {
+	Save(entry Entry) error
+	Load(original string) (Entry, error)
+}
+
+// DirStore はディレクトリに編集履歴ファイルを置く Store の実装
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore は dir を保存先とする DirStore を作成する
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の history を保存先とする DirStore を作成する
+func NewDefaultStore() *DirStore {
+	return NewDirStore(filepath.Join(statedir.Dir(), "history"))
+}
+
+// PathFor は original に対応する編集履歴ファイルのパスを返す
+func (s *DirStore) PathFor(original string) string {
+	return filepath.Join(s.dir, statedir.EncodePath(original)+extension)
+}
+
+// Save は編集履歴を保存する。同じファイルの履歴は上書きする
+func (s *DirStore) Save(entry Entry) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("編集履歴のディレクトリを作成できません: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.PathFor(entry.Original), data, 0600)
+}
+
+// Load は original の編集履歴を読み込む。保存されていない場合は os.ErrNotExist を返す
+func (s *DirStore) Load(original string) (Entry, error) {
+	path := s.PathFor(original)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, fmt.Errorf("編集履歴を読み込めません: %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// Checksum はファイルの内容のハッシュを返す
+// 履歴を保存した後にファイルが変更されていないかを確かめるために使う
+func Checksum(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
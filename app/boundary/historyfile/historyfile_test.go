@@ -0,0 +1,50 @@
+package historyfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+func TestDirStore_SaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	store := NewDirStore(dir)
+
+	if _, err := store.Load("/src/a.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+
+	entry := Entry{
+		Original: "/src/a.txt",
+		SavedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Checksum: Checksum([]string{"a", "b"}),
+		History: command.Saved{
+			Done:      []command.SavedEdit{{Kind: "text", Start: contents.Position{X: 1}, Inserted: []string{"x"}}},
+			SavePoint: 1,
+		},
+	}
+	if err := store.Save(entry); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if filepath.Dir(store.PathFor(entry.Original)) != dir {
+		t.Errorf("history file must be placed in the store: %s", store.PathFor(entry.Original))
+	}
+
+	got, err := store.Load("/src/a.txt")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, entry) {
+		t.Errorf("got %+v, want %+v", got, entry)
+	}
+
+	if Checksum([]string{"a", "b"}) == Checksum([]string{"ab"}) {
+		t.Error("checksum must distinguish line breaks")
+	}
+}
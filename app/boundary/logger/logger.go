@@ -1,11 +1,17 @@
 package logger
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
 	"github.com/wasya-io/go-kilo/app/entity/core"
 )
 
@@ -14,11 +20,14 @@ type LogEntry struct {
 	Timestamp string `json:"timestamp"`
 	Message   string `json:"message"`
 	Type      string `json:"type"`
+	Goroutine uint64 `json:"goroutine"` // 記録したゴルーチンの ID
 	processor *Logger
 }
 
 // Logger はロギング機能を提供する構造体
+// メインループだけでなく、シグナルハンドラーや定期処理のゴルーチンからも呼び出されるため、どのゴルーチンから呼び出してもよい
 type Logger struct {
+	mu        sync.Mutex // 以下のフィールドを保護する
 	debugMode bool
 	entries   []LogEntry
 	filePath  string
@@ -32,7 +41,7 @@ func New(debugMode bool) *Logger {
 	return &Logger{
 		debugMode: debugMode,
 		entries:   make([]LogEntry, 0),
-		filePath:  fmt.Sprintf("log-%s.json", startTime.Format("20060102-150405")),
+		filePath:  filepath.Join(statedir.Dir(), "logs", fmt.Sprintf("log-%s-%d.json", startTime.Format("20060102-150405"), os.Getpid())),
 		maxBuffer: 100,
 		startTime: startTime,
 	}
@@ -48,6 +57,8 @@ func (l *Logger) ReadyWithType(messageType string) core.LogEntry {
 
 // Log はメッセージをログに記録する
 func (l *Logger) Log(messageType string, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if !l.debugMode {
 		return
 	}
@@ -56,25 +67,35 @@ func (l *Logger) Log(messageType string, message string) {
 		Timestamp: time.Now().Format(time.RFC3339),
 		Message:   message,
 		Type:      messageType,
+		Goroutine: goroutineID(),
 	}
 	l.entries = append(l.entries, entry)
 
 	// バッファが一定量に達したらフラッシュ
 	if len(l.entries) >= l.maxBuffer {
-		l.Flush()
+		l.flush()
 	}
 }
 
 // Flush は現在のログエントリをファイルに書き出す
 func (l *Logger) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush()
+}
+
+// flush は Flush の本体。mu を取得した状態で呼び出す
+// 書き出しの途中で別のゴルーチンが記録しても、ファイルの JSON が混ざらないようロックしたまま書き出す
+func (l *Logger) flush() {
 	if len(l.entries) == 0 {
 		return
 	}
 
 	// ログをJSONとして書き出す
+	// ログは作業ディレクトリではなく状態ディレクトリに書き出す
 	data, err := json.MarshalIndent(l.entries, "", "  ")
-	if err == nil {
-		os.WriteFile(l.filePath, data, 0644)
+	if err == nil && os.MkdirAll(filepath.Dir(l.filePath), 0700) == nil {
+		os.WriteFile(l.filePath, data, 0600)
 	}
 
 	// ログをクリア
@@ -83,9 +104,27 @@ func (l *Logger) Flush() {
 
 // SetDebugMode はデバッグモードの状態を設定する
 func (l *Logger) SetDebugMode(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.debugMode = enabled
 }
 
+// goroutineID は呼び出したゴルーチンの ID を返す。読み取れない場合は 0 を返す
+// ランタイムは ID を公開していないため、スタックトレースの先頭行（"goroutine 12 [running]:"）から読み取る
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := bytes.Fields(bytes.TrimPrefix(buf[:n], []byte("goroutine ")))
+	if len(fields) == 0 {
+		return 0
+	}
+	id, err := strconv.ParseUint(string(fields[0]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
 func (e *LogEntry) WithType() core.LogEntry {
 	e.Message = e.Message + " %T"
 	return e
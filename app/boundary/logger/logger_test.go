@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestLogger_ConcurrentLog(t *testing.T) {
+	t.Setenv("KILO_STATE_DIR", t.TempDir())
+	l := New(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				l.Log("test", fmt.Sprintf("goroutine %d entry %d", i, j))
+				if j%50 == 0 {
+					l.SetDebugMode(true)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	l.Log("test", "last")
+	l.Flush()
+
+	data, err := os.ReadFile(l.filePath)
+	if err != nil {
+		t.Fatalf("log file not written: %v", err)
+	}
+	var entries []LogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("log file is not valid JSON: %v", err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Message != "last" {
+		t.Fatalf("last entry missing: %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Goroutine == 0 {
+			t.Errorf("entry without goroutine ID: %+v", e)
+		}
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	if id == 0 {
+		t.Fatal("goroutine ID not found")
+	}
+	if again := goroutineID(); again != id {
+		t.Errorf("goroutine ID changed within a goroutine: %d -> %d", id, again)
+	}
+
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	if got := <-other; got == 0 || got == id {
+		t.Errorf("another goroutine has ID %d (this one %d)", got, id)
+	}
+}
@@ -0,0 +1,327 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout はサーバーの終了を待つ時間。過ぎたらプロセスを強制終了する
+const shutdownTimeout = 2 * time.Second
+
+// DiagnosticsFunc はサーバーが path の診断を報告したときに呼び出される
+// 受信用のゴルーチンから呼び出すため、時間のかかる処理をしないこと
+type DiagnosticsFunc func(path string, diags []Diagnostic)
+
+// notification は送信を待っている通知。method が空なら、それまでの通知を送り終えたことを done で知らせる
+type notification struct {
+	method string
+	uri    string
+	params interface{}
+	done   chan struct{}
+}
+
+// Client は1つの言語サーバーとの接続
+// 初期化と通知の送信は専用のゴルーチンで順に行うため、呼び出し側は待たされない
+type Client struct {
+	conn   *Conn
+	closer io.Closer
+	wait   func() error // サーバーのプロセスの終了を待つ（プロセスでなければ nil）
+	kill   func()
+
+	ready   chan struct{} // 初期化が終わったら閉じる
+	initErr error
+
+	mu      sync.Mutex
+	queue   []notification
+	wake    chan struct{}
+	stopped bool
+}
+
+// Start は command（空白区切りでコマンドと引数）を root をカレントディレクトリとして起動し、接続する
+func Start(command, root string, onDiagnostics DiagnosticsFunc) (*Client, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty language server command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// サーバーのログが画面に混ざらないよう、標準エラー出力は捨てる
+	cmd.Stderr = nil
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+	client := NewClient(stdout, stdin, root, onDiagnostics)
+	client.wait = cmd.Wait
+	client.kill = func() { _ = cmd.Process.Kill() }
+	return client, nil
+}
+
+// NewClient は r と w でサーバーと通信するクライアントを作成し、裏で初期化を始める
+// w は Shutdown で閉じる
+func NewClient(r io.Reader, w io.WriteCloser, root string, onDiagnostics DiagnosticsFunc) *Client {
+	c := &Client{
+		closer: w,
+		ready:  make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+	c.conn = NewConn(r, w, func(method string, params json.RawMessage) interface{} {
+		return handleServerMessage(method, params, onDiagnostics)
+	})
+	go c.run(root)
+	return c
+}
+
+// handleServerMessage はサーバーからの通知と要求を処理する
+// 設定の問い合わせには項目ごとに null（既定の設定）を返し、それ以外の要求には null を返す
+func handleServerMessage(method string, params json.RawMessage, onDiagnostics DiagnosticsFunc) interface{} {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p publishDiagnosticsParams
+		if err := json.Unmarshal(params, &p); err != nil || onDiagnostics == nil {
+			return nil
+		}
+		if path, ok := PathFromURI(p.URI); ok {
+			onDiagnostics(path, p.Diagnostics)
+		}
+	case "workspace/configuration":
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(params, &p); err == nil {
+			return make([]interface{}, len(p.Items))
+		}
+	}
+	return nil
+}
+
+// run はサーバーを初期化してから、通知を順に送信する
+func (c *Client) run(root string) {
+	c.initErr = c.initialize(root)
+	close(c.ready)
+	for {
+		n, ok := c.next()
+		if !ok {
+			return
+		}
+		if n.method != "" && c.initErr == nil {
+			_ = c.conn.Notify(n.method, n.params)
+		}
+		if n.done != nil {
+			close(n.done)
+		}
+	}
+}
+
+// initialize は initialize 要求と initialized 通知を送信する
+func (c *Client) initialize(root string) error {
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   FileURI(root),
+		"workspaceFolders": []map[string]string{
+			{"uri": FileURI(root), "name": root},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": false},
+				"publishDiagnostics": map[string]interface{}{},
+				"completion": map[string]interface{}{
+					"completionItem": map[string]interface{}{"snippetSupport": false},
+				},
+			},
+			"general": map[string]interface{}{"positionEncodings": []string{"utf-16"}},
+		},
+	}
+	if err := c.conn.Call(context.Background(), "initialize", params, nil); err != nil {
+		return fmt.Errorf("failed to initialize language server: %w", err)
+	}
+	return c.conn.Notify("initialized", struct{}{})
+}
+
+// next は次に送信する通知を取り出す。Shutdown 後に待ちがなくなったら false を返す
+func (c *Client) next() (notification, bool) {
+	for {
+		c.mu.Lock()
+		if len(c.queue) > 0 {
+			n := c.queue[0]
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			return n, true
+		}
+		stopped := c.stopped
+		c.mu.Unlock()
+		if stopped {
+			return notification{}, false
+		}
+		<-c.wake
+	}
+}
+
+// enqueue は通知を送信待ちに加える
+// 同じ文書の didChange が送信されずに残っていれば、新しい内容で置き換える（内容を全て送るため古いものは不要）
+func (c *Client) enqueue(n notification) {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		if n.done != nil {
+			close(n.done)
+		}
+		return
+	}
+	replaced := false
+	if n.method == "textDocument/didChange" {
+		for i := len(c.queue) - 1; i >= 0; i-- {
+			if c.queue[i].uri != n.uri {
+				continue
+			}
+			if c.queue[i].method == n.method {
+				c.queue[i] = n
+				replaced = true
+			}
+			break
+		}
+	}
+	if !replaced {
+		c.queue = append(c.queue, n)
+	}
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush はそれまでに加えた通知を送り終えるまで待つ
+func (c *Client) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	c.enqueue(notification{done: done})
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// textDocument は文書を識別するパラメーター
+type textDocument struct {
+	URI string `json:"uri"`
+}
+
+// DidOpen は path を開いたことを通知する
+func (c *Client) DidOpen(path, languageID string, version int, text string) {
+	uri := FileURI(path)
+	c.enqueue(notification{method: "textDocument/didOpen", uri: uri, params: map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": languageID, "version": version, "text": text},
+	}})
+}
+
+// DidChange は path の内容が text に変わったことを通知する
+func (c *Client) DidChange(path string, version int, text string) {
+	uri := FileURI(path)
+	c.enqueue(notification{method: "textDocument/didChange", uri: uri, params: map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+		"contentChanges": []map[string]string{{"text": text}},
+	}})
+}
+
+// DidClose は path を閉じたことを通知する
+func (c *Client) DidClose(path string) {
+	uri := FileURI(path)
+	c.enqueue(notification{method: "textDocument/didClose", uri: uri, params: map[string]interface{}{
+		"textDocument": textDocument{URI: uri},
+	}})
+}
+
+// Completion は path の pos の位置の補完候補を問い合わせる
+// それまでに通知した変更を送り終えてから問い合わせる
+func (c *Client) Completion(ctx context.Context, path string, pos Position) ([]CompletionItem, error) {
+	if err := c.waitReady(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.flush(ctx); err != nil {
+		return nil, err
+	}
+	var result completionResult
+	err := c.conn.Call(ctx, "textDocument/completion", map[string]interface{}{
+		"textDocument": textDocument{URI: FileURI(path)},
+		"position":     pos,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// waitReady は初期化が終わるまで待ち、初期化のエラーを返す
+func (c *Client) waitReady(ctx context.Context) error {
+	select {
+	case <-c.ready:
+		return c.initErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err はサーバーとの接続が切れていればその理由を返す
+func (c *Client) Err() error {
+	select {
+	case <-c.ready:
+		if c.initErr != nil {
+			return c.initErr
+		}
+	default:
+	}
+	return c.conn.Err()
+}
+
+// Shutdown は残りの通知を送ってからサーバーに終了を要求し、プロセスの終了を待つ
+// 待ちきれない場合はプロセスを強制終了する
+func (c *Client) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	var err error
+	if c.waitReady(ctx) == nil && c.flush(ctx) == nil {
+		if err = c.conn.Call(ctx, "shutdown", nil, nil); err == nil {
+			err = c.conn.Notify("exit", nil)
+		}
+	}
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	if closeErr := c.closer.Close(); err == nil {
+		err = closeErr
+	}
+
+	if c.wait == nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- c.wait() }()
+	select {
+	case <-exited:
+	case <-ctx.Done():
+		c.kill()
+		<-exited
+	}
+	return err
+}
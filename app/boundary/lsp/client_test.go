@@ -0,0 +1,211 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer はテスト用の言語サーバー。開いた文書の内容を覚え、内容に応じた診断と補完候補を返す
+type fakeServer struct {
+	t    *testing.T
+	conn *Conn
+
+	mu      sync.Mutex
+	methods []string
+	text    string
+}
+
+// startFakeServer はパイプでつないだ fakeServer とクライアントを作成する
+func startFakeServer(t *testing.T, onDiagnostics DiagnosticsFunc) (*fakeServer, *Client) {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	s := &fakeServer{t: t}
+	s.conn = NewConn(serverR, serverW, s.handle)
+	client := NewClient(clientR, clientW, t.TempDir(), onDiagnostics)
+	t.Cleanup(func() {
+		serverW.Close()
+		serverR.Close()
+	})
+	return s, client
+}
+
+func (s *fakeServer) handle(method string, params json.RawMessage) interface{} {
+	s.mu.Lock()
+	s.methods = append(s.methods, method)
+	s.mu.Unlock()
+
+	var p struct {
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	_ = json.Unmarshal(params, &p)
+
+	switch method {
+	case "initialize":
+		return map[string]interface{}{"capabilities": map[string]interface{}{}}
+	case "textDocument/didOpen", "textDocument/didChange":
+		text := p.TextDocument.Text
+		if len(p.ContentChanges) > 0 {
+			text = p.ContentChanges[0].Text
+		}
+		s.mu.Lock()
+		s.text = text
+		s.mu.Unlock()
+		_ = s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI: p.TextDocument.URI,
+			Diagnostics: []Diagnostic{{
+				Range:    Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: len(text)}},
+				Severity: 1,
+				Message:  "got " + text,
+			}},
+		})
+	case "textDocument/completion":
+		s.mu.Lock()
+		text := s.text
+		s.mu.Unlock()
+		return map[string]interface{}{"isIncomplete": false, "items": []CompletionItem{{Label: text + "Println"}}}
+	case "shutdown":
+		return nil
+	}
+	return nil
+}
+
+func (s *fakeServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func TestClient_DiagnosticsAndCompletion(t *testing.T) {
+	diags := make(chan []Diagnostic, 10)
+	var gotPath string
+	server, client := startFakeServer(t, func(path string, d []Diagnostic) {
+		gotPath = path
+		diags <- d
+	})
+	path := filepath.Join(t.TempDir(), "main.go")
+
+	client.DidOpen(path, "go", 1, "fmt.")
+	select {
+	case d := <-diags:
+		if len(d) != 1 || d[0].Message != "got fmt." || gotPath != path {
+			t.Errorf("unexpected diagnostics for %s: %+v", gotPath, d)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("diagnostics were not published")
+	}
+
+	// 補完の前に、それまでの変更を送り終える
+	client.DidChange(path, 2, "log.")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	items, err := client.Completion(ctx, path, Position{Line: 0, Character: 4})
+	if err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	if len(items) != 1 || items[0].Text() != "log.Println" {
+		t.Errorf("unexpected completion items: %+v", items)
+	}
+
+	if err := client.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	want := []string{"initialize", "initialized", "textDocument/didOpen", "textDocument/didChange", "textDocument/completion", "shutdown", "exit"}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(server.received()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := server.received()
+	if len(got) != len(want) {
+		t.Fatalf("server received %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("server received %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestClient_CoalescesChanges(t *testing.T) {
+	c := &Client{wake: make(chan struct{}, 1)}
+	c.enqueue(notification{method: "textDocument/didOpen", uri: "a", params: 1})
+	c.enqueue(notification{method: "textDocument/didChange", uri: "a", params: 2})
+	c.enqueue(notification{method: "textDocument/didChange", uri: "b", params: 3})
+	c.enqueue(notification{method: "textDocument/didChange", uri: "a", params: 4})
+	c.enqueue(notification{method: "textDocument/didChange", uri: "b", params: 5})
+
+	// 送信前の didChange は最新の内容だけを残す。didOpen は置き換えない
+	var got []interface{}
+	for _, n := range c.queue {
+		got = append(got, n.params)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 4 || got[2] != 5 {
+		t.Errorf("queued params = %v, want [1 4 5]", got)
+	}
+}
+
+func TestConn_RepliesToServerRequests(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	defer serverW.Close()
+	NewClient(clientR, clientW, t.TempDir(), nil)
+
+	// クライアントの initialize 要求を読み飛ばしてから、設定を問い合わせる
+	r := bufio.NewReader(serverR)
+	if _, err := readMessage(r); err != nil {
+		t.Fatal(err)
+	}
+	id := json.RawMessage("7")
+	params, _ := json.Marshal(map[string]interface{}{"items": []map[string]string{{"section": "gopls"}, {"section": "go"}}})
+	w := &Conn{w: serverW}
+	if err := w.write(message{ID: &id, Method: "workspace/configuration", Params: params}); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := readMessage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID == nil || string(*reply.ID) != "7" || string(reply.Result) != "[null,null]" {
+		t.Errorf("unexpected reply: id=%v result=%s", reply.ID, reply.Result)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	line := "a😀b"
+	if got := UTF16Column(line, 2); got != 3 {
+		t.Errorf("UTF16Column() = %d, want 3", got)
+	}
+	if got := RuneColumn(line, 3); got != 2 {
+		t.Errorf("RuneColumn() = %d, want 2", got)
+	}
+	if got := RuneColumn(line, 2); got != 1 {
+		t.Errorf("RuneColumn(inside surrogate pair) = %d, want 1", got)
+	}
+	if got := RuneColumn(line, 10); got != 3 {
+		t.Errorf("RuneColumn(past the end) = %d, want 3", got)
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a b.go")
+	uri := FileURI(path)
+	if got, ok := PathFromURI(uri); !ok || got != path {
+		t.Errorf("PathFromURI(%q) = %q, %v; want %q", uri, got, ok, path)
+	}
+	if _, ok := PathFromURI("untitled:1"); ok {
+		t.Error("non-file URIs must be rejected")
+	}
+}
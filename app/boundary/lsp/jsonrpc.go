@@ -0,0 +1,225 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrClosed は接続が閉じた後に送受信しようとした場合のエラー
+var ErrClosed = errors.New("connection closed")
+
+// Handler はサーバーから届いた通知と要求を処理する
+// 要求（id を持つメッセージ）の場合は戻り値を結果として返信する。通知の場合は戻り値を使わない
+type Handler func(method string, params json.RawMessage) interface{}
+
+// message は JSON-RPC 2.0 のメッセージ。要求・通知・応答のいずれも表す
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// ResponseError はサーバーが返したエラー応答
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Conn は Content-Length ヘッダーで区切った JSON-RPC 2.0 のメッセージを送受信する接続
+// 受信は専用のゴルーチンで行い、応答は対応する Call に渡し、通知と要求は Handler に渡す
+type Conn struct {
+	w       io.Writer
+	writeMu sync.Mutex
+	handler Handler
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan message
+	err     error // 受信を終えた理由（nil なら受信中）
+	done    chan struct{}
+}
+
+// NewConn は r から受信し、w へ送信する接続を作成し、受信を開始する
+func NewConn(r io.Reader, w io.Writer, handler Handler) *Conn {
+	c := &Conn{
+		w:       w,
+		handler: handler,
+		pending: make(map[int64]chan message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// Done は受信を終えると閉じるチャネルを返す
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err は受信を終えた理由を返す。受信中は nil を返す
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Call は要求を送信して応答を待ち、結果を result に格納する（result が nil なら結果を捨てる）
+func (c *Conn) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	raw := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.send(message{ID: &raw, Method: method}, params); err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-c.done:
+		return c.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify は通知を送信する
+func (c *Conn) Notify(method string, params interface{}) error {
+	return c.send(message{Method: method}, params)
+}
+
+// send は params を付けたメッセージを送信する
+func (c *Conn) send(msg message, params interface{}) error {
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", msg.Method, err)
+		}
+		msg.Params = raw
+	}
+	return c.write(msg)
+}
+
+// write はメッセージを1件書き込む。ヘッダーと本文が他のメッセージと混ざらないよう排他する
+func (c *Conn) write(msg message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(body)
+	return err
+}
+
+// readLoop はメッセージを受信し続ける。読み込みに失敗したら待っている Call に知らせて終える
+func (c *Conn) readLoop(r *bufio.Reader) {
+	var err error
+	for {
+		var msg message
+		if msg, err = readMessage(r); err != nil {
+			break
+		}
+		c.dispatch(msg)
+	}
+	if err == io.EOF {
+		err = ErrClosed
+	}
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// dispatch は受信したメッセージを応答・要求・通知に振り分ける
+func (c *Conn) dispatch(msg message) {
+	switch {
+	case msg.Method == "" && msg.ID != nil:
+		id, err := strconv.ParseInt(string(*msg.ID), 10, 64)
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		reply, ok := c.pending[id]
+		c.mu.Unlock()
+		if ok {
+			reply <- msg
+		}
+	case msg.ID != nil:
+		// サーバーからの要求には必ず返信しないと、サーバーが応答を待ち続けることがある
+		var result interface{}
+		if c.handler != nil {
+			result = c.handler(msg.Method, msg.Params)
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			raw = json.RawMessage("null")
+		}
+		_ = c.write(message{ID: msg.ID, Result: raw})
+	case c.handler != nil:
+		c.handler(msg.Method, msg.Params)
+	}
+}
+
+// readMessage はヘッダーと本文からなるメッセージを1件読み込む
+func readMessage(r *bufio.Reader) (message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+		return message{}, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return message{}, fmt.Errorf("invalid Content-Length header: %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return message{}, err
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return message{}, fmt.Errorf("invalid message: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,152 @@
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Position は文書内の位置。Character は UTF-16 のコード単位で数える
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range は文書内の範囲 [Start, End)
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic はサーバーが報告した診断
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"` // 省略された場合は 0
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// TextEdit は範囲を置き換える編集
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// CompletionItem は補完候補
+type CompletionItem struct {
+	Label      string    `json:"label"`
+	Detail     string    `json:"detail,omitempty"`
+	InsertText string    `json:"insertText,omitempty"`
+	FilterText string    `json:"filterText,omitempty"`
+	SortText   string    `json:"sortText,omitempty"`
+	TextEdit   *TextEdit `json:"textEdit,omitempty"`
+}
+
+// Text は候補を選んだときに挿入する文字列を返す
+func (item CompletionItem) Text() string {
+	switch {
+	case item.TextEdit != nil:
+		return item.TextEdit.NewText
+	case item.InsertText != "":
+		return item.InsertText
+	}
+	return item.Label
+}
+
+// completionResult は CompletionItem の配列と CompletionList のどちらの形の応答も受け取る
+type completionResult struct {
+	Items []CompletionItem
+}
+
+func (r *completionResult) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &r.Items)
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	r.Items = list.Items
+	return nil
+}
+
+// publishDiagnosticsParams は textDocument/publishDiagnostics 通知のパラメーター
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// FileURI はファイルのパスを file スキームの URI にする
+func FileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed // Windows のドライブ名の前にも / を付ける
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
+// PathFromURI は file スキームの URI をファイルのパスにする。file スキームでなければ false を返す
+func PathFromURI(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:] // Windows のドライブ名
+	}
+	return filepath.FromSlash(path), true
+}
+
+// UTF16Column は line のルーン単位の列位置 col を UTF-16 のコード単位の位置にする
+func UTF16Column(line string, col int) int {
+	n := 0
+	for i, r := range []rune(line) {
+		if i >= col {
+			break
+		}
+		n += utf16Len(r)
+	}
+	return n
+}
+
+// RuneColumn は line の UTF-16 のコード単位の位置 col をルーン単位の列位置にする
+// サロゲートペアの途中を指している場合はその文字の位置を返す
+func RuneColumn(line string, col int) int {
+	n := 0
+	for i, r := range []rune(line) {
+		n += utf16Len(r)
+		if n > col {
+			return i
+		}
+	}
+	return len([]rune(line))
+}
+
+// utf16Len は r を UTF-16 で表すのに必要なコード単位の数を返す
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2 // サロゲートペア
+	}
+	return 1
+}
+
+// languageIDs はファイルの種類ごとの言語 ID（ファイルの種類を小文字にしたものと異なるものだけ）
+var languageIDs = map[string]string{
+	"Shell":     "shellscript",
+	"Go Module": "go.mod",
+}
+
+// LanguageID はファイルの種類（filetype の名前）に対応する言語 ID を返す
+func LanguageID(fileType string) string {
+	if id, ok := languageIDs[fileType]; ok {
+		return id
+	}
+	return strings.ToLower(fileType)
+}
@@ -0,0 +1,114 @@
+package positionfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+)
+
+const (
+	// fileName は状態ディレクトリ内のカーソル位置の記録の名前
+	fileName = "positions.json"
+	// MaxEntries は記録しておくファイルの数の上限。超えた分は古いものから捨てる
+	MaxEntries = 500
+)
+
+// Position はファイルごとに記録したカーソルの位置
+type Position struct {
+	Line    int       `json:"line"`   // 0 始まり
+	Column  int       `json:"column"` // 文字単位、0 始まり
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Store はファイルごとのカーソルの位置を保存・読み込むためのインターフェース
+type Store interface {
+	Remember(positions map[string]Position) error
+	Recall(path string) (Position, bool, error)
+}
+
+// FileStore は1つの JSON ファイルに絶対パスごとの位置を置く Store の実装
+type FileStore struct {
+	path string
+}
+
+// NewFileStore は path を保存先とする FileStore を作成する
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の positions.json を保存先とする FileStore を作成する
+func NewDefaultStore() *FileStore {
+	return NewFileStore(filepath.Join(statedir.Dir(), fileName))
+}
+
+// key はファイルのパスを記録のキーとなる絶対パスにする
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// Remember はファイルごとの位置を記録に加える。同じファイルの位置は上書きする
+// 記録が MaxEntries を超える場合は、記録した時刻の古いものから捨てる
+func (s *FileStore) Remember(positions map[string]Position) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	for path, pos := range positions {
+		all[key(path)] = pos
+	}
+	if len(all) > MaxEntries {
+		paths := make([]string, 0, len(all))
+		for path := range all {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool { return all[paths[i]].SavedAt.After(all[paths[j]].SavedAt) })
+		for _, path := range paths[MaxEntries:] {
+			delete(all, path)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("カーソル位置の記録のディレクトリを作成できません: %w", err)
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0600)
+}
+
+// Recall は path について記録した位置を返す。記録がなければ false を返す
+func (s *FileStore) Recall(path string) (Position, bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return Position{}, false, err
+	}
+	pos, ok := all[key(path)]
+	return pos, ok, nil
+}
+
+// load は記録全体を読み込む。まだ記録がなければ空の記録を返す
+func (s *FileStore) load() (map[string]Position, error) {
+	all := map[string]Position{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("カーソル位置の記録を読み込めません: %s: %w", s.path, err)
+	}
+	return all, nil
+}
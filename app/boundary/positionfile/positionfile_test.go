@@ -0,0 +1,45 @@
+package positionfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_RememberAndRecall(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state", "positions.json"))
+	if _, ok, err := store.Recall("/tmp/a.txt"); ok || err != nil {
+		t.Fatalf("Recall() before remembering = %v, %v", ok, err)
+	}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := store.Remember(map[string]Position{"/tmp/a.txt": {Line: 3, Column: 1, SavedAt: now}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remember(map[string]Position{"/tmp/b.txt": {Line: 7, SavedAt: now}}); err != nil {
+		t.Fatal(err)
+	}
+	pos, ok, err := store.Recall("/tmp/../tmp/a.txt")
+	if err != nil || !ok || pos.Line != 3 || pos.Column != 1 {
+		t.Errorf("Recall() = %+v, %v, %v; other files must be kept", pos, ok, err)
+	}
+}
+
+func TestFileStore_DropsOldestEntries(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "positions.json"))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	positions := map[string]Position{}
+	for i := 0; i <= MaxEntries; i++ {
+		positions[fmt.Sprintf("/tmp/%d.txt", i)] = Position{Line: i, SavedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	if err := store.Remember(positions); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Recall("/tmp/0.txt"); ok {
+		t.Error("the oldest entry must be dropped")
+	}
+	if _, ok, _ := store.Recall(fmt.Sprintf("/tmp/%d.txt", MaxEntries)); !ok {
+		t.Error("the newest entry must be kept")
+	}
+}
@@ -0,0 +1,141 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// ErrClosed は閉じた AsyncProvider から読み取ろうとしたことを表す
+var ErrClosed = errors.New("input provider closed")
+
+// ContextProvider は読み取りを待つ間に ctx の取り消しで戻れる Provider
+type ContextProvider interface {
+	GetInputEventsContext(ctx context.Context) (key.KeyEvent, []key.KeyEvent, error)
+}
+
+// Canceler は入力を待っている読み取りを中断できる入力元
+type Canceler interface {
+	Cancel()
+}
+
+// readResult は端末の Provider の1回の読み取りの結果
+type readResult struct {
+	event key.KeyEvent
+	rest  []key.KeyEvent
+	err   error
+}
+
+// AsyncProvider は端末の Provider の読み取りを専用の goroutine で行い、待つ間も取り消しを受け付ける Provider
+// 読み取りは要求されたときだけ行い、先読みはしない。外部のコマンドに端末を渡している間に入力を奪わないようにするため
+// 取り消しで待つのをやめた読み取りは続けて、その結果を次の読み取りで返す
+// GetInputEvents・GetInputEventsContext・HasInput は1つの goroutine から呼び出す
+type AsyncProvider struct {
+	terminal  Provider
+	requests  chan struct{}
+	results   chan readResult
+	done      chan struct{}
+	reading   bool // 要求した読み取りの結果をまだ受け取っていない
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewAsyncProvider は terminal から読み取る AsyncProvider を作成し、読み取りの goroutine を開始する
+func NewAsyncProvider(terminal Provider) *AsyncProvider {
+	p := &AsyncProvider{
+		terminal: terminal,
+		requests: make(chan struct{}),
+		results:  make(chan readResult, 1),
+		done:     make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// run は要求を受けるたびに端末の Provider から1回読み取る
+func (p *AsyncProvider) run() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.requests:
+		case <-p.done:
+			return
+		}
+		ev, rest, err := p.terminal.GetInputEvents()
+		// 要求は結果を受け取るまで1つしかないため、送信は待たない
+		p.results <- readResult{event: ev, rest: rest, err: err}
+	}
+}
+
+// GetInputEvents は次の読み取りの結果を返す
+func (p *AsyncProvider) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	return p.GetInputEventsContext(context.Background())
+}
+
+// GetInputEventsContext は次の読み取りの結果を返す。結果が届く前に ctx が取り消されたら ctx のエラーを返す
+// 閉じた後は、Close で中断した読み取りの結果が届いていても ErrClosed を返す
+func (p *AsyncProvider) GetInputEventsContext(ctx context.Context) (key.KeyEvent, []key.KeyEvent, error) {
+	if p.closed() {
+		return key.KeyEvent{}, nil, ErrClosed
+	}
+	if !p.reading {
+		select {
+		case p.requests <- struct{}{}:
+			p.reading = true
+		case <-ctx.Done():
+			return key.KeyEvent{}, nil, ctx.Err()
+		case <-p.done:
+			return key.KeyEvent{}, nil, ErrClosed
+		}
+	}
+	select {
+	case r := <-p.results:
+		p.reading = false
+		if p.closed() {
+			return key.KeyEvent{}, nil, ErrClosed
+		}
+		return r.event, r.rest, r.err
+	case <-ctx.Done():
+		return key.KeyEvent{}, nil, ctx.Err()
+	case <-p.done:
+		return key.KeyEvent{}, nil, ErrClosed
+	}
+}
+
+// closed は Close が呼ばれたかどうかを返す
+func (p *AsyncProvider) closed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasInput は待たずに返せる読み取りの結果があるか、端末に入力が届いているかを返す
+func (p *AsyncProvider) HasInput() bool {
+	if len(p.results) > 0 {
+		return true
+	}
+	poller, ok := p.terminal.(Poller)
+	return ok && poller.HasInput()
+}
+
+// Close は読み取りの goroutine を止める。以後の読み取りは ErrClosed を返す
+// 端末の Provider が Canceler を実装していれば待っている読み取りを中断し、goroutine が終わるまで待つ
+// 実装していなければ、goroutine は読み取り中の1回が終わった後に終わる
+func (p *AsyncProvider) Close() {
+	canceler, ok := p.terminal.(Canceler)
+	p.closeOnce.Do(func() {
+		close(p.done)
+		if ok {
+			canceler.Cancel()
+		}
+	})
+	if ok {
+		p.wg.Wait()
+	}
+}
@@ -0,0 +1,100 @@
+package input
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// blockingTerminal は reads に送られた入力を返し、Cancel されるまで待つ端末
+// 読み取りを始めるたびに started に通知する
+type blockingTerminal struct {
+	reads    chan key.KeyEvent
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func newBlockingTerminal() *blockingTerminal {
+	return &blockingTerminal{
+		reads:    make(chan key.KeyEvent),
+		started:  make(chan struct{}, 10),
+		canceled: make(chan struct{}),
+	}
+}
+
+func (f *blockingTerminal) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	f.started <- struct{}{}
+	select {
+	case ev := <-f.reads:
+		return ev, nil, nil
+	case <-f.canceled:
+		return key.KeyEvent{}, nil, context.Canceled
+	}
+}
+
+func (f *blockingTerminal) Cancel() {
+	close(f.canceled)
+}
+
+func TestAsyncProvider_CancelKeepsPendingRead(t *testing.T) {
+	terminal := newBlockingTerminal()
+	p := NewAsyncProvider(terminal)
+	defer p.Close()
+
+	// 読み取りが始まってから待つのをやめても、読み取り中の入力は次の読み取りで返す
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-terminal.started
+		cancel()
+	}()
+	_, _, err := p.GetInputEventsContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	go func() { terminal.reads <- chars("a")[0] }()
+	ev, _, err := p.GetInputEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', ev.Rune)
+	assert.Empty(t, terminal.started, "the canceled wait does not start another read")
+	assert.False(t, p.HasInput())
+}
+
+func TestAsyncProvider_Close(t *testing.T) {
+	terminal := newBlockingTerminal()
+	p := NewAsyncProvider(terminal)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := p.GetInputEvents()
+		errs <- err
+	}()
+	<-terminal.started
+
+	// 待っている読み取りを中断し、読み取りの goroutine が終わるまで待つ
+	// 中断した読み取りのエラーではなく ErrClosed を返す
+	p.Close()
+	assert.ErrorIs(t, <-errs, ErrClosed)
+	_, _, err := p.GetInputEvents()
+	assert.ErrorIs(t, err, ErrClosed)
+	p.Close()
+}
+
+func TestAsyncProvider_ResultAfterClose(t *testing.T) {
+	terminal := newBlockingTerminal()
+	p := NewAsyncProvider(terminal)
+
+	// 読み取り中に閉じ、中断した読み取りの結果が届いた後に受け取っても ErrClosed を返す
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-terminal.started
+		cancel()
+	}()
+	_, _, err := p.GetInputEventsContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	p.Close()
+	assert.True(t, p.HasInput(), "the interrupted read has delivered its result")
+
+	_, _, err = p.GetInputEvents()
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,185 @@
+package input
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// DefaultBurst は端末に入力が届いている場合に、合成したイベントを続けて返す上限
+const DefaultBurst = 32
+
+// Poller は読み取らずに入力が届いているかを確認できる入力元
+type Poller interface {
+	HasInput() bool
+}
+
+// Source は端末以外から合成したキーイベント（マクロの再生、RPC のコマンド、貼り付けなど）を保持する
+// 別のゴルーチンからも追加できる
+type Source struct {
+	name   string
+	mu     sync.Mutex
+	events []key.KeyEvent
+}
+
+// NewSource は name という名前の空の Source を作成する
+func NewSource(name string) *Source {
+	return &Source{name: name}
+}
+
+// Name は Source の名前を返す
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Push はイベントを末尾に追加する
+func (s *Source) Push(events ...key.KeyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+}
+
+// PushFront はイベントを先頭に追加する（再生中のマクロから別のマクロを再生する場合など）
+func (s *Source) PushFront(events ...key.KeyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(append([]key.KeyEvent{}, events...), s.events...)
+}
+
+// Len はまだ返していないイベントの数を返す
+func (s *Source) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+// Clear はまだ返していないイベントを破棄する
+func (s *Source) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = nil
+}
+
+// next は先頭のイベントを取り出す
+func (s *Source) next() (key.KeyEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) == 0 {
+		return key.KeyEvent{}, false
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, true
+}
+
+// CompositeProvider は合成したイベントの Source を優先度順に読み、どれも空のときだけ端末から読む Provider
+// 合成したイベントを burst 件続けて返した後は、端末に入力が届いていれば端末の入力を1回分先に返し、
+// 止まらない Source があっても実際のキー入力が処理されないままにならないようにする
+// 端末の入力が届いているかは、端末の Provider が Poller を実装している場合だけ確認する
+type CompositeProvider struct {
+	terminal  Provider
+	sources   []*Source
+	burst     int
+	streak    int            // 合成したイベントを続けて返した数
+	pending   []key.KeyEvent // 端末から1回で読み取ったイベントの残り
+	synthetic bool           // 直前に返したイベントが合成したものか
+	err       error          // TakeReady で端末から読み取る際に起きた、まだ返していないエラー
+}
+
+// NewCompositeProvider は端末の Provider と、優先度の高い順に並べた Source から CompositeProvider を作成する
+// burst が0以下の場合は DefaultBurst を使う
+func NewCompositeProvider(terminal Provider, burst int, sources ...*Source) *CompositeProvider {
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+	return &CompositeProvider{terminal: terminal, sources: sources, burst: burst}
+}
+
+// GetInputEvents は次のイベントを1件返す
+// 端末から複数のイベントを一度に読み取った場合も残りは内部に保持し、合成したイベントより後に返す
+func (p *CompositeProvider) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	return p.GetInputEventsContext(context.Background())
+}
+
+// GetInputEventsContext は GetInputEvents と同じく次のイベントを1件返す
+// 端末の Provider が ContextProvider を実装していれば、端末の入力を待つ間に ctx が取り消されたら ctx のエラーを返す
+func (p *CompositeProvider) GetInputEventsContext(ctx context.Context) (key.KeyEvent, []key.KeyEvent, error) {
+	p.synthetic = false
+	if p.streak < p.burst || !p.terminalReady() {
+		for _, s := range p.sources {
+			if ev, ok := s.next(); ok {
+				p.streak++
+				p.synthetic = true
+				return ev, nil, nil
+			}
+		}
+	}
+
+	p.streak = 0
+	if p.err != nil {
+		err := p.err
+		p.err = nil
+		return key.KeyEvent{}, nil, err
+	}
+	if len(p.pending) > 0 {
+		ev := p.pending[0]
+		p.pending = p.pending[1:]
+		return ev, nil, nil
+	}
+	var ev key.KeyEvent
+	var rest []key.KeyEvent
+	var err error
+	if terminal, ok := p.terminal.(ContextProvider); ok {
+		ev, rest, err = terminal.GetInputEventsContext(ctx)
+	} else {
+		ev, rest, err = p.terminal.GetInputEvents()
+	}
+	if err != nil {
+		return key.KeyEvent{}, nil, err
+	}
+	p.pending = append(p.pending, rest...)
+	return ev, nil, nil
+}
+
+// TakeReady は端末の入力のうち、読み取りを待たずに返せて先頭から match を満たし続けるイベントを、最大 limit 件取り出す
+// 続けて届いたマウスホイールのイベントをまとめて処理するために使う。合成したイベントが残っている場合は順序を保つため何も取り出さない
+// 読み取ったが match を満たさなかったイベントは、次の GetInputEvents で返す
+func (p *CompositeProvider) TakeReady(match func(key.KeyEvent) bool, limit int) []key.KeyEvent {
+	for _, s := range p.sources {
+		if s.Len() > 0 {
+			return nil
+		}
+	}
+	var taken []key.KeyEvent
+	for len(taken) < limit && p.err == nil && p.terminalReady() {
+		if len(p.pending) == 0 {
+			ev, rest, err := p.terminal.GetInputEvents()
+			if err != nil {
+				p.err = err
+				break
+			}
+			p.pending = append([]key.KeyEvent{ev}, rest...)
+		}
+		if !match(p.pending[0]) {
+			break
+		}
+		taken = append(taken, p.pending[0])
+		p.pending = p.pending[1:]
+	}
+	return taken
+}
+
+// Synthetic は直前に返したイベントが端末以外から合成したものかどうかを返す
+func (p *CompositeProvider) Synthetic() bool {
+	return p.synthetic
+}
+
+// terminalReady は読み取らずに返せる端末の入力があるかどうかを返す
+func (p *CompositeProvider) terminalReady() bool {
+	if len(p.pending) > 0 {
+		return true
+	}
+	poller, ok := p.terminal.(Poller)
+	return ok && poller.HasInput()
+}
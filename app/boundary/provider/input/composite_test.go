@@ -0,0 +1,121 @@
+package input
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// fakeTerminal は1回の読み取りで reads の先頭の入力をまとめて返す端末
+type fakeTerminal struct {
+	reads [][]key.KeyEvent
+	err   error
+}
+
+func (f *fakeTerminal) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	if f.err != nil {
+		return key.KeyEvent{}, nil, f.err
+	}
+	events := f.reads[0]
+	f.reads = f.reads[1:]
+	return events[0], events[1:], nil
+}
+
+func (f *fakeTerminal) HasInput() bool {
+	return len(f.reads) > 0
+}
+
+func chars(s string) []key.KeyEvent {
+	var events []key.KeyEvent
+	for _, r := range s {
+		events = append(events, key.KeyEvent{Type: key.KeyEventChar, Rune: r})
+	}
+	return events
+}
+
+// readAll は n 件のイベントを読み取り、文字と合成したイベントかどうかを返す
+func readAll(t *testing.T, p *CompositeProvider, n int) (string, []bool) {
+	t.Helper()
+	var runes []rune
+	var synthetic []bool
+	for i := 0; i < n; i++ {
+		ev, rest, err := p.GetInputEvents()
+		assert.NoError(t, err)
+		assert.Empty(t, rest)
+		runes = append(runes, ev.Rune)
+		synthetic = append(synthetic, p.Synthetic())
+	}
+	return string(runes), synthetic
+}
+
+func TestCompositeProvider_Priority(t *testing.T) {
+	terminal := &fakeTerminal{reads: [][]key.KeyEvent{chars("xy"), chars("z")}}
+	macro, rpc := NewSource("macro"), NewSource("rpc")
+	p := NewCompositeProvider(terminal, 0, macro, rpc)
+
+	// 端末から読み取った残りより、後から追加した合成イベントを先に返す
+	got, _ := readAll(t, p, 1)
+	assert.Equal(t, "x", got)
+	rpc.Push(chars("r")...)
+	macro.Push(chars("ab")...)
+	macro.PushFront(chars("c")...)
+
+	got, synthetic := readAll(t, p, 5)
+	assert.Equal(t, "cabry", got)
+	assert.Equal(t, []bool{true, true, true, true, false}, synthetic)
+	assert.Equal(t, 0, macro.Len())
+}
+
+func TestCompositeProvider_Fairness(t *testing.T) {
+	terminal := &fakeTerminal{}
+	macro := NewSource("macro")
+	macro.Push(chars("aaaaaaa")...)
+	p := NewCompositeProvider(terminal, 3, macro)
+
+	// 端末に入力がなければ上限を超えても合成イベントを返し続ける
+	got, _ := readAll(t, p, 4)
+	assert.Equal(t, "aaaa", got)
+
+	// 端末に入力が届いたら、上限を超えた時点で端末の入力を1回分先に返す
+	terminal.reads = [][]key.KeyEvent{chars("kl")}
+	got, synthetic := readAll(t, p, 5)
+	assert.Equal(t, "kaaal", got)
+	assert.Equal(t, []bool{false, true, true, true, false}, synthetic)
+
+	macro.Clear()
+	assert.Equal(t, 0, macro.Len())
+}
+
+func TestCompositeProvider_TakeReady(t *testing.T) {
+	wheel := key.KeyEvent{Type: key.KeyEventMouse, Key: key.KeyMouseWheel, MouseAction: key.MouseScrollDown}
+	isWheel := func(ev key.KeyEvent) bool { return ev.Type == key.KeyEventMouse }
+	terminal := &fakeTerminal{reads: [][]key.KeyEvent{
+		{wheel, wheel, wheel},
+		{wheel, chars("a")[0], wheel},
+	}}
+	macro := NewSource("macro")
+	p := NewCompositeProvider(terminal, 0, macro)
+
+	// 合成したイベントが残っている間は、順序を保つため取り出さない
+	macro.Push(chars("m")...)
+	assert.Empty(t, p.TakeReady(isWheel, 10))
+	readAll(t, p, 1)
+
+	// 上限まで取り出し、残りは次の読み取りに回す
+	assert.Len(t, p.TakeReady(isWheel, 2), 2)
+	// 端末から続けて読み取り、条件を満たさないイベントの手前で止まる
+	assert.Len(t, p.TakeReady(isWheel, 10), 2)
+	got, _ := readAll(t, p, 1)
+	assert.Equal(t, "a", got)
+	assert.Len(t, p.TakeReady(isWheel, 10), 1)
+	assert.Empty(t, p.TakeReady(isWheel, 10), "nothing is ready")
+
+	// 読み取りのエラーは次の GetInputEvents で返す
+	terminal.reads = [][]key.KeyEvent{chars("z")}
+	terminal.err = errors.New("hangup")
+	assert.Empty(t, p.TakeReady(isWheel, 10))
+	_, _, err := p.GetInputEvents()
+	assert.EqualError(t, err, "hangup")
+}
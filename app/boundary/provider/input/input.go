@@ -2,6 +2,7 @@ package input
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/wasya-io/go-kilo/app/boundary/reader"
 	"github.com/wasya-io/go-kilo/app/entity/core"
@@ -9,6 +10,15 @@ import (
 	"github.com/wasya-io/go-kilo/app/usecase/parser"
 )
 
+// escapeWait は読み取った入力が ESC で終わる場合に、Alt との同時押しの続きを待つ時間
+// 端末は Alt+キーを ESC とキーの連続として送るが、2回の読み込みに分かれて届くことがある
+const escapeWait = 30 * time.Millisecond
+
+// Waiter は入力が届くまで一定時間待てる入力元
+type Waiter interface {
+	WaitInput(timeout time.Duration) bool
+}
+
 type Provider interface {
 	GetInputEvents() (key.KeyEvent, []key.KeyEvent, error)
 }
@@ -30,11 +40,15 @@ func NewStandardInputProvider(logger core.Logger, reader reader.KeyReader, parse
 func (p *StandardInputProvider) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
 	buf, n, err := p.reader.Read()
 	if err != nil {
-		return key.KeyEvent{}, nil, fmt.Errorf("input error: %v", err)
+		return key.KeyEvent{}, nil, fmt.Errorf("input error: %w", err)
 	}
 	if n == 0 {
 		return key.KeyEvent{}, nil, fmt.Errorf("no input")
 	}
+	buf, n, err = p.joinEscape(buf, n)
+	if err != nil {
+		return key.KeyEvent{}, nil, fmt.Errorf("input error: %w", err)
+	}
 	events, err := p.parser.Parse(buf, n)
 	if err != nil {
 		return key.KeyEvent{}, nil, fmt.Errorf("input error: %v", err)
@@ -42,3 +56,30 @@ func (p *StandardInputProvider) GetInputEvents() (key.KeyEvent, []key.KeyEvent,
 	p.logger.ReadyWithType("GetInputEvents").WithType().WithString().WithString().Do(events[0], events[0].Type, events[0].Rune)
 	return events[0], events[1:], nil
 }
+
+// joinEscape は入力が ESC で終わる場合に続きを escapeWait だけ待ち、届いた続きを後ろにつなげる
+// 続きが届かなければ ESC 単体の入力として扱う。読み取り元が Waiter を実装していなければ待たない
+func (p *StandardInputProvider) joinEscape(buf []byte, n int) ([]byte, int, error) {
+	waiter, ok := p.reader.(Waiter)
+	if !ok || buf[n-1] != '\x1b' || !waiter.WaitInput(escapeWait) {
+		return buf, n, nil
+	}
+	more, m, err := p.reader.Read()
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(buf[:n:n], more[:m]...), n + m, nil
+}
+
+// HasInput は読み取らずに端末の入力が届いているかを返す
+func (p *StandardInputProvider) HasInput() bool {
+	poller, ok := p.reader.(Poller)
+	return ok && poller.HasInput()
+}
+
+// Cancel は入力を待っている読み取りを中断する（読み取り元が Canceler を実装している場合のみ）
+func (p *StandardInputProvider) Cancel() {
+	if canceler, ok := p.reader.(Canceler); ok {
+		canceler.Cancel()
+	}
+}
@@ -0,0 +1,63 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/parser"
+)
+
+// splitReader は1回の読み取りで reads の先頭を返す読み取り元
+// WaitInput は次の読み取りが残っていれば届いたとみなす
+type splitReader struct {
+	reads []string
+	waits []time.Duration
+}
+
+func (r *splitReader) Read() ([]byte, int, error) {
+	s := r.reads[0]
+	r.reads = r.reads[1:]
+	return []byte(s), len(s), nil
+}
+
+func (r *splitReader) WaitInput(timeout time.Duration) bool {
+	r.waits = append(r.waits, timeout)
+	return len(r.reads) > 0
+}
+
+func newSplitProvider(reads ...string) (*StandardInputProvider, *splitReader) {
+	l := logger.New(false)
+	r := &splitReader{reads: reads}
+	return NewStandardInputProvider(l, r, parser.NewStandardInputParser(l)), r
+}
+
+func TestStandardInputProvider_AltSplitAcrossReads(t *testing.T) {
+	p, r := newSplitProvider("\x1b", "f")
+
+	ev, rest, err := p.GetInputEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, key.KeyEventChar, ev.Type)
+	assert.Equal(t, 'f', ev.Rune)
+	assert.True(t, ev.Modifiers.Has(key.ModAlt), "ESC and the key read separately are still Alt+key")
+	assert.Equal(t, []time.Duration{escapeWait}, r.waits)
+}
+
+func TestStandardInputProvider_LoneEsc(t *testing.T) {
+	p, r := newSplitProvider("\x1b")
+
+	ev, _, err := p.GetInputEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, key.KeyEventSpecial, ev.Type)
+	assert.Equal(t, key.KeyEsc, ev.Key)
+	assert.Len(t, r.waits, 1, "a lone ESC is reported after waiting for a following key")
+
+	p, r = newSplitProvider("a", "b")
+	ev, _, err = p.GetInputEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', ev.Rune)
+	assert.Empty(t, r.waits, "input not ending in ESC is returned without waiting")
+}
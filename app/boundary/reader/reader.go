@@ -1,16 +1,26 @@
 package reader
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/wasya-io/go-kilo/app/entity/core"
+	"golang.org/x/sys/unix"
 )
 
+// ErrHangup は端末との接続が切れた（SSH の切断などで入力が終わった）ことを表す
+var ErrHangup = errors.New("terminal hung up")
+
+// ErrCanceled は Cancel で読み取りを中断したことを表す
+var ErrCanceled = errors.New("read canceled")
+
 type StandardKeyReader struct {
 	logger core.Logger
 	in     io.Reader
+	wake   [2]int // Cancel で書き込み、入力を待っている Read を起こすパイプ（作成できなければ -1）
 }
 
 type KeyReader interface {
@@ -18,26 +28,36 @@ type KeyReader interface {
 }
 
 func NewStandardKeyReader(logger core.Logger) *StandardKeyReader {
-	return &StandardKeyReader{
-		logger: logger,
-		in:     os.Stdin,
-	}
+	return NewStandardKeyReaderWithInput(logger, os.Stdin)
 }
 
 // NewStandardKeyReaderWithInput はテスト用の初期化関数です
 func NewStandardKeyReaderWithInput(logger core.Logger, in io.Reader) *StandardKeyReader {
-	return &StandardKeyReader{
+	kr := &StandardKeyReader{
 		logger: logger,
 		in:     in,
+		wake:   [2]int{-1, -1},
+	}
+	if err := unix.Pipe2(kr.wake[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		logger.Log("error", fmt.Sprintf("Failed to create wake pipe: %v", err))
+		kr.wake = [2]int{-1, -1}
 	}
+	return kr
 }
 
 func (kr *StandardKeyReader) Read() ([]byte, int, error) {
+	if err := kr.wait(); err != nil {
+		return nil, 0, err
+	}
 	// 指定された io.Reader (通常は os.Stdin) から読み取り
 	buf := make([]byte, 4096)
 	n, err := kr.in.Read(buf[:])
+	// 接続が切れた端末の読み取りは EOF か EIO になる
+	if errors.Is(err, io.EOF) || errors.Is(err, unix.EIO) {
+		return nil, n, fmt.Errorf("input error: %w: %v", ErrHangup, err)
+	}
 	if err != nil {
-		return nil, n, fmt.Errorf("input error: %v", err)
+		return nil, n, fmt.Errorf("input error: %w", err)
 	}
 	if n == 0 {
 		return nil, n, fmt.Errorf("no input")
@@ -45,3 +65,65 @@ func (kr *StandardKeyReader) Read() ([]byte, int, error) {
 
 	return buf, n, nil
 }
+
+// wait は入力が届くか Cancel が呼ばれるまで待つ
+// 端末などのファイル以外からの入力や、パイプを作成できなかった場合は待たずに戻る
+func (kr *StandardKeyReader) wait() error {
+	f, ok := kr.in.(*os.File)
+	if !ok || kr.wake[0] < 0 {
+		return nil
+	}
+	fds := []unix.PollFd{
+		{Fd: int32(f.Fd()), Events: unix.POLLIN},
+		{Fd: int32(kr.wake[0]), Events: unix.POLLIN},
+	}
+	for {
+		_, err := unix.Poll(fds, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("input error: %w", err)
+		}
+		if fds[1].Revents != 0 {
+			return ErrCanceled
+		}
+		// 入力のほか、切断やエラーも続く Read で報告する
+		return nil
+	}
+}
+
+// Cancel は入力を待っている Read を中断する。以後の Read はすべて ErrCanceled を返す
+// 終了時に、読み取りの goroutine を残さないために使う
+func (kr *StandardKeyReader) Cancel() {
+	if kr.wake[1] >= 0 {
+		unix.Write(kr.wake[1], []byte{0})
+	}
+}
+
+// WaitInput は入力が届くまで最大 timeout 待ち、届いたかを返す（端末などのファイル以外からの入力では待たずに false）
+func (kr *StandardKeyReader) WaitInput(timeout time.Duration) bool {
+	f, ok := kr.in.(*os.File)
+	if !ok {
+		return false
+	}
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLIN}}
+	for {
+		n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		return err == nil && n > 0
+	}
+}
+
+// HasInput は読み取らずに入力が届いているかを返す（端末などのファイル以外からの入力では常に false）
+func (kr *StandardKeyReader) HasInput() bool {
+	f, ok := kr.in.(*os.File)
+	if !ok {
+		return false
+	}
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, 0)
+	return err == nil && n > 0
+}
@@ -2,7 +2,9 @@ package reader
 
 import (
 	"bytes"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wasya-io/go-kilo/app/boundary/logger"
@@ -24,3 +26,48 @@ func TestStandardKeyReader_ReadLongJapaneseInput(t *testing.T) {
 	assert.Equal(t, 75, n, "Should read all 75 bytes of the input at once")
 	assert.Equal(t, inputStr, string(buf[:n]), "The read string should exactly match the input")
 }
+
+func TestStandardKeyReader_Hangup(t *testing.T) {
+	kr := NewStandardKeyReaderWithInput(logger.New(false), bytes.NewReader(nil))
+
+	_, _, err := kr.Read()
+	assert.ErrorIs(t, err, ErrHangup, "EOF on the terminal means the connection was lost")
+}
+
+func TestStandardKeyReader_Cancel(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	kr := NewStandardKeyReaderWithInput(logger.New(false), r)
+
+	_, err = w.Write([]byte("a"))
+	assert.NoError(t, err)
+	buf, n, err := kr.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(buf[:n]))
+
+	// 入力を待っている Read を中断する。Read が待ち始める前に Cancel しても中断する
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := kr.Read()
+		errs <- err
+	}()
+	kr.Cancel()
+	assert.ErrorIs(t, <-errs, ErrCanceled)
+	_, _, err = kr.Read()
+	assert.ErrorIs(t, err, ErrCanceled)
+}
+
+func TestStandardKeyReader_WaitInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	kr := NewStandardKeyReaderWithInput(logger.New(false), r)
+
+	assert.False(t, kr.WaitInput(10*time.Millisecond), "no input arrives before the timeout")
+	_, err = w.Write([]byte("f"))
+	assert.NoError(t, err)
+	assert.True(t, kr.WaitInput(10*time.Millisecond))
+}
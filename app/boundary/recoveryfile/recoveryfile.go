@@ -0,0 +1,139 @@
+package recoveryfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+)
+
+// extension は復元用ファイルの拡張子
+const extension = ".recovery"
+
+// Entry は復元用ファイル1件を表す
+type Entry struct {
+	// Original は元ファイルのパス。名前のないバッファの場合は空
+	Original string    `json:"original"`
+	SavedAt  time.Time `json:"saved_at"`
+	Lines    []string  `json:"lines,omitempty"`
+	// Path は復元用ファイル自体のパス
+	Path string `json:"-"`
+}
+
+// Name は一覧表示用の名前を返す
+func (e Entry) Name() string {
+	if e.Original == "" {
+		return "[No Name]"
+	}
+	return e.Original
+}
+
+// Store は復元用ファイルを保存・列挙するためのインターフェース
+type Store interface {
+	Save(original string, lines []string, now time.Time) (string, error)
+	List() ([]Entry, error)
+	Load(path string) (Entry, error)
+	Remove(path string) error
+	PathFor(original string) string
+}
+
+// DirStore はディレクトリに復元用ファイルを置く Store の実装
+// 編集中のファイルの隣ではなく状態ディレクトリに置くので、読み取り専用のディレクトリでも動作する
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore は dir を保存先とする DirStore を作成する
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の recovery を保存先とする DirStore を作成する
+func NewDefaultStore() *DirStore {
+	return NewDirStore(filepath.Join(statedir.Dir(), "recovery"))
+}
+
+// PathFor は original に対応する復元用ファイルのパスを返す
+func (s *DirStore) PathFor(original string) string {
+	return filepath.Join(s.dir, statedir.EncodePath(original)+extension)
+}
+
+// Save はバッファの内容を復元用ファイルとして保存し、そのパスを返す
+// 名前のないバッファは保存時刻とプロセスIDで区別する
+func (s *DirStore) Save(original string, lines []string, now time.Time) (string, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("復元用ディレクトリを作成できません: %w", err)
+	}
+
+	path := s.PathFor(original)
+	if original == "" {
+		path = filepath.Join(s.dir, fmt.Sprintf("untitled-%s-%d%s", now.Format("20060102-150405"), os.Getpid(), extension))
+	}
+
+	data, err := json.Marshal(Entry{Original: original, SavedAt: now, Lines: lines})
+	if err != nil {
+		return "", err
+	}
+	if err := atomicfile.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List は保存されている復元用ファイルを新しい順に返す。Lines は読み込まない
+func (s *DirStore) List() ([]Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), extension) {
+			continue
+		}
+		entry, err := s.Load(filepath.Join(s.dir, f.Name()))
+		if err != nil {
+			// 壊れたファイルがあっても他の一覧は表示する
+			continue
+		}
+		entry.Lines = nil
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SavedAt.After(entries[j].SavedAt)
+	})
+	return entries, nil
+}
+
+// Load は復元用ファイルを読み込む
+func (s *DirStore) Load(path string) (Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, fmt.Errorf("復元用ファイルを読み込めません: %s: %w", path, err)
+	}
+	entry.Path = path
+	return entry, nil
+}
+
+// Remove は復元用ファイルを削除する。存在しない場合はエラーにしない
+func (s *DirStore) Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package recoveryfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirStore_SaveListLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recovery")
+	store := NewDirStore(dir)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	first, err := store.Save("/src/メモ.txt", []string{"a", "b"}, now)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if filepath.Dir(first) != dir {
+		t.Errorf("recovery file must be placed in the store: %s", first)
+	}
+	if _, err := store.Save("", []string{"scratch"}, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "broken"+extension), []byte("{"), 0600)
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if entries[0].Name() != "[No Name]" || entries[1].Original != "/src/メモ.txt" {
+		t.Errorf("unexpected order: %+v", entries)
+	}
+	if entries[1].Lines != nil {
+		t.Error("List must not load buffer contents")
+	}
+
+	loaded, err := store.Load(entries[1].Path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Lines) != 2 || loaded.Lines[1] != "b" {
+		t.Errorf("unexpected lines: %v", loaded.Lines)
+	}
+
+	if err := store.Remove(first); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if err := store.Remove(first); err != nil {
+		t.Errorf("removing a missing file must succeed: %v", err)
+	}
+}
+
+func TestDirStore_ListMissingDir(t *testing.T) {
+	entries, err := NewDirStore(filepath.Join(t.TempDir(), "none")).List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("unexpected result: %v, %v", entries, err)
+	}
+}
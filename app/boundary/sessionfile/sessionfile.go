@@ -0,0 +1,77 @@
+package sessionfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+)
+
+// fileName は状態ディレクトリ内のセッションファイルの名前
+const fileName = "session.json"
+
+// Buffer は開いていたバッファ1つ分の状態
+type Buffer struct {
+	Path      string `json:"path"`   // 絶対パス
+	Line      int    `json:"line"`   // カーソルの行（0 始まり）
+	Column    int    `json:"column"` // カーソルの桁（文字単位、0 始まり）
+	RowOffset int    `json:"row_offset"`
+	ColOffset int    `json:"col_offset"`
+}
+
+// Session は終了時に開いていたバッファを開いた順に表す
+type Session struct {
+	SavedAt time.Time `json:"saved_at"`
+	Buffers []Buffer  `json:"buffers"`
+	Focus   int       `json:"focus"` // フォーカスのあったバッファの Buffers での位置
+}
+
+// Store はセッションを保存・読み込むためのインターフェース
+type Store interface {
+	Save(session Session) error
+	Load() (Session, error)
+}
+
+// FileStore は1つの JSON ファイルにセッションを置く Store の実装
+type FileStore struct {
+	path string
+}
+
+// NewFileStore は path を保存先とする FileStore を作成する
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の session.json を保存先とする FileStore を作成する
+func NewDefaultStore() *FileStore {
+	return NewFileStore(filepath.Join(statedir.Dir(), fileName))
+}
+
+// Save はセッションを保存する。前回のセッションは上書きする
+func (s *FileStore) Save(session Session) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("セッションのディレクトリを作成できません: %w", err)
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0600)
+}
+
+// Load は保存したセッションを読み込む。保存されていない場合は os.ErrNotExist を返す
+func (s *FileStore) Load() (Session, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return Session{}, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Session{}, fmt.Errorf("セッションを読み込めません: %s: %w", s.path, err)
+	}
+	return session, nil
+}
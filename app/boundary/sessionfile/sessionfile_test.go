@@ -0,0 +1,43 @@
+package sessionfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileStore_SaveAndLoad(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state", "session.json"))
+	if _, err := store.Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load() before saving = %v, want os.ErrNotExist", err)
+	}
+
+	want := Session{
+		SavedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Buffers: []Buffer{
+			{Path: "/tmp/a.txt", Line: 10, Column: 3, RowOffset: 5},
+			{Path: "/tmp/b.go", Line: 1, ColOffset: 8},
+		},
+		Focus: 1,
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(store.path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a broken session file must be reported: %v", err)
+	}
+}
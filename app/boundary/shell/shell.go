@@ -0,0 +1,58 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+)
+
+// defaultShell は SHELL が設定されていない場合に使うシェル
+const defaultShell = "sh"
+
+// Shell はユーザーのシェルでコマンドラインを実行する
+// コマンドラインはシェルの -c に渡すため、パイプやリダイレクトも使える
+type Shell struct {
+	runner external.Runner
+	path   string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// New は SHELL（設定されていなければ sh）で実行する Shell を作成する
+// Output は runner で実行し、Interactive は端末の標準入出力を使う（標準エラー出力も標準出力に表示する）
+func New(runner external.Runner) *Shell {
+	path := os.Getenv("SHELL")
+	if path == "" {
+		path = defaultShell
+	}
+	return &Shell{runner: runner, path: path, stdin: os.Stdin, stdout: os.Stdout}
+}
+
+// Output は dir をカレントディレクトリとして command を実行し、標準出力を返す
+// stdin が nil でなければ標準入力として渡す。失敗した場合は標準エラー出力の先頭行をエラーメッセージに含める
+func (s *Shell) Output(ctx context.Context, dir, command string, stdin []byte) ([]byte, error) {
+	return s.runner.Run(ctx, dir, s.path, []string{"-c", command}, stdin)
+}
+
+// Interactive は端末の標準入出力で command を実行し、端末に表示した標準出力と標準エラー出力をまとめて返す
+// 入力を求めるコマンドも使えるよう、呼び出し側で端末を Raw モードから戻しておくこと
+func (s *Shell) Interactive(dir, command string) ([]byte, error) {
+	fmt.Fprintf(s.stdout, "$ %s\n", command)
+	var out bytes.Buffer
+	// 標準出力と標準エラー出力に同じ writer を渡すと、出力した順のまま1つのパイプで受け取れる
+	w := io.MultiWriter(s.stdout, &out)
+	cmd := exec.Command(s.path, "-c", command)
+	cmd.Dir = dir
+	cmd.Stdin = s.stdin
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return out.Bytes(), fmt.Errorf("%s: %w", command, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRunner は実行したコマンドを記録する
+type fakeRunner struct {
+	name  string
+	args  []string
+	stdin []byte
+}
+
+func (r *fakeRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.name, r.args, r.stdin = name, args, stdin
+	return []byte("ok\n"), nil
+}
+
+func (r *fakeRunner) Available(name string) bool { return true }
+
+func TestOutput_RunsThroughShell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+	runner := &fakeRunner{}
+	out, err := New(runner).Output(context.Background(), "/tmp", "sort | uniq", []byte("b\na\n"))
+	if err != nil || string(out) != "ok\n" {
+		t.Fatalf("Output() = %q, %v", out, err)
+	}
+	if runner.name != "/bin/zsh" || !reflect.DeepEqual(runner.args, []string{"-c", "sort | uniq"}) || string(runner.stdin) != "b\na\n" {
+		t.Errorf("unexpected command: %s %v %q", runner.name, runner.args, runner.stdin)
+	}
+}
+
+func TestInteractive_CapturesOutput(t *testing.T) {
+	var terminal bytes.Buffer
+	s := &Shell{path: defaultShell, stdin: strings.NewReader(""), stdout: &terminal}
+	out, err := s.Interactive(t.TempDir(), "echo out; echo err >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "out\nerr\n" {
+		t.Errorf("captured output = %q", out)
+	}
+	if terminal.String() != "$ echo out; echo err >&2\nout\nerr\n" {
+		t.Errorf("terminal output = %q", terminal.String())
+	}
+
+	// 失敗した場合も出力を返す
+	out, err = s.Interactive(t.TempDir(), "echo partial; exit 3")
+	if err == nil || string(out) != "partial\n" {
+		t.Errorf("Interactive() = %q, %v", out, err)
+	}
+}
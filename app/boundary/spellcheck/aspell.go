@@ -0,0 +1,129 @@
+package spellcheck
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// aspellCommand は aspell の実行ファイル名
+const aspellCommand = "aspell"
+
+// ErrClosed は aspell が終了した後に問い合わせた場合のエラー
+var ErrClosed = errors.New("aspell has exited")
+
+// Aspell は aspell -a（ispell 互換のパイプ）による Checker の実装
+// 1つのプロセスを起動したまま、1語ずつ問い合わせる
+type Aspell struct {
+	mutex  sync.Mutex
+	w      io.Writer
+	r      *bufio.Reader
+	closed bool
+}
+
+// StartAspell は aspell をパイプのモードで起動する。language が空でなければ使う辞書の言語を指定する
+func StartAspell(language string) (*Aspell, error) {
+	args := []string{"-a"}
+	if language != "" {
+		args = append(args, "--lang="+language)
+	}
+	cmd := exec.Command(aspellCommand, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start aspell: %w", err)
+	}
+	a, err := NewAspell(stdout, stdin)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	// 標準入力を閉じると aspell は終了する。エディタの終了時に閉じられる
+	go func() { _ = cmd.Wait() }()
+	return a, nil
+}
+
+// NewAspell は r から応答を読み、w に問い合わせを書く Aspell を作成する
+// 起動時に aspell が出力する見出しの行を読んでから返す
+func NewAspell(r io.Reader, w io.Writer) (*Aspell, error) {
+	a := &Aspell{w: w, r: bufio.NewReader(r)}
+	banner, err := a.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to start aspell: %w", err)
+	}
+	if !strings.HasPrefix(banner, "@(#)") {
+		return nil, fmt.Errorf("unexpected aspell banner %q", strings.TrimSpace(banner))
+	}
+	return a, nil
+}
+
+// Check は word の綴りが正しいかどうかを返す
+func (a *Aspell) Check(word string) (bool, error) {
+	result, err := a.query(word)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case result == "", result[0] == '*', result[0] == '+', result[0] == '-':
+		return true, nil
+	}
+	return false, nil
+}
+
+// Suggest は aspell が挙げた候補を返す。綴りが正しければ候補はない
+func (a *Aspell) Suggest(word string) ([]string, error) {
+	result, err := a.query(word)
+	if err != nil || !strings.HasPrefix(result, "&") {
+		return nil, err
+	}
+	// & 語 候補の数 位置: 候補1, 候補2, ...
+	_, list, ok := strings.Cut(result, ": ")
+	if !ok {
+		return nil, nil
+	}
+	suggestions := strings.Split(list, ", ")
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions, nil
+}
+
+// query は word を問い合わせ、応答の最初の行を返す。応答は空行で終わる
+// 先頭に ^ を付けて、語が aspell のコマンドとして解釈されないようにする
+func (a *Aspell) query(word string) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.closed {
+		return "", ErrClosed
+	}
+	if _, err := io.WriteString(a.w, "^"+word+"\n"); err != nil {
+		a.closed = true
+		return "", fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	var result string
+	for {
+		line, err := a.r.ReadString('\n')
+		if err != nil {
+			a.closed = true
+			return "", fmt.Errorf("%w: %v", ErrClosed, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return result, nil
+		}
+		if result == "" {
+			result = line
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package spellcheck
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeAspell は aspell -a の代わりに、決まった語だけを正しい綴りとして応答する
+func fakeAspell(t *testing.T) *Aspell {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		defer outW.Close()
+		io.WriteString(outW, "@(#) International Ispell Version 3.1.20 (but really Aspell 0.60.8)\n")
+		scanner := bufio.NewScanner(inR)
+		for scanner.Scan() {
+			word := strings.TrimPrefix(scanner.Text(), "^")
+			switch word {
+			case "hello":
+				io.WriteString(outW, "*\n\n")
+			case "helo":
+				io.WriteString(outW, "& helo 3 0: hello, halo, help\n\n")
+			case "quit":
+				return
+			default:
+				io.WriteString(outW, "# "+word+" 0\n\n")
+			}
+		}
+	}()
+	a, err := NewAspell(outR, inW)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return a
+}
+
+func TestAspell(t *testing.T) {
+	a := fakeAspell(t)
+	if ok, err := a.Check("hello"); !ok || err != nil {
+		t.Errorf("Check(hello) = %v, %v", ok, err)
+	}
+	if ok, err := a.Check("helo"); ok || err != nil {
+		t.Errorf("Check(helo) = %v, %v", ok, err)
+	}
+	if got, err := a.Suggest("helo"); err != nil || !reflect.DeepEqual(got, []string{"hello", "halo", "help"}) {
+		t.Errorf("Suggest(helo) = %v, %v", got, err)
+	}
+	if got, err := a.Suggest("xyzzy"); err != nil || got != nil {
+		t.Errorf("Suggest(xyzzy) = %v, %v", got, err)
+	}
+
+	// aspell が終了した後はエラーを返す
+	if _, err := a.Check("quit"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if _, err := a.Check("hello"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestNewAspell_UnexpectedBanner(t *testing.T) {
+	if _, err := NewAspell(strings.NewReader("Error: No word lists can be found\n"), io.Discard); err == nil {
+		t.Error("an unexpected banner must be an error")
+	}
+}
@@ -0,0 +1,116 @@
+package spellcheck
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+	"github.com/wasya-io/go-kilo/app/entity/spell"
+)
+
+// 綴りの確認に使う実装の名前（設定の SPELL_CHECK）
+const (
+	ModeOff      = "off"      // 確認しない
+	ModeAuto     = "auto"     // aspell があれば aspell、なければ単語の一覧
+	ModeAspell   = "aspell"   // aspell を使う
+	ModeWordList = "wordlist" // 単語の一覧のファイルを使う
+)
+
+// maxSuggestions は候補の数の上限
+const maxSuggestions = 10
+
+// Checker は単語の綴りを確認し、正しい綴りの候補を挙げる
+type Checker interface {
+	// Check は word の綴りが正しいかどうかを返す
+	Check(word string) (bool, error)
+	// Suggest は word に近い正しい綴りの候補を近い順に返す
+	Suggest(word string) ([]string, error)
+}
+
+// Select は mode に応じた Checker を返す。ModeOff なら nil を返す
+// dictionary は単語の一覧のファイル、language は aspell で使う辞書の言語（空なら aspell の既定）
+func Select(mode, dictionary, language string, runner external.Runner) (Checker, error) {
+	switch mode {
+	case ModeOff, "":
+		return nil, nil
+	case ModeAspell:
+		return StartAspell(language)
+	case ModeWordList:
+		return LoadWordList(dictionary)
+	}
+	if runner.Available(aspellCommand) {
+		return StartAspell(language)
+	}
+	return LoadWordList(dictionary)
+}
+
+// WordList は単語の一覧のファイルによる Checker の実装
+// 大文字・小文字は区別せずに比べる
+type WordList struct {
+	words map[string]bool // 小文字にした単語
+	list  []string
+}
+
+// NewWordList は words を正しい綴りとする WordList を作成する
+func NewWordList(words []string) *WordList {
+	w := &WordList{words: make(map[string]bool, len(words)), list: words}
+	for _, word := range words {
+		w.words[strings.ToLower(word)] = true
+	}
+	return w
+}
+
+// LoadWordList は1行に1語を書いたファイル（/usr/share/dict/words など）から WordList を作成する
+// hunspell の .dic のように1行目が語数で、語の後ろに / で区切ってフラグを書いた形式も読めるが、接辞は展開しない
+func LoadWordList(path string) (*WordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the word list: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+		if i := strings.IndexByte(line, '/'); i >= 0 {
+			line = line[:i]
+		}
+		if line != "" {
+			words = append(words, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the word list: %w", err)
+	}
+	if len(words) == 0 {
+		return nil, errors.New("the word list is empty")
+	}
+	return NewWordList(words), nil
+}
+
+// Check は word が一覧にあるかどうかを返す。所有格の 's は除いて比べる
+func (w *WordList) Check(word string) (bool, error) {
+	lower := strings.ToLower(word)
+	if w.words[lower] {
+		return true, nil
+	}
+	if base, ok := strings.CutSuffix(lower, "'s"); ok && w.words[base] {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Suggest は一覧のうち word との編集距離が小さい単語を返す
+func (w *WordList) Suggest(word string) ([]string, error) {
+	return spell.Suggest(w.list, word, maxSuggestions), nil
+}
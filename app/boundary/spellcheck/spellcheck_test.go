@@ -0,0 +1,37 @@
+package spellcheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadWordList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "en.dic")
+	if err := os.WriteFile(path, []byte("3\nhello/MS\nworld\nParis\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadWordList(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for word, want := range map[string]bool{"hello": true, "Hello": true, "paris": true, "world's": true, "wrld": false, "3": false} {
+		if ok, _ := list.Check(word); ok != want {
+			t.Errorf("Check(%q) = %v, want %v", word, ok, want)
+		}
+	}
+	if got, _ := list.Suggest("wrld"); !reflect.DeepEqual(got, []string{"world"}) {
+		t.Errorf("Suggest() = %v", got)
+	}
+
+	if _, err := LoadWordList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("a missing word list must be an error")
+	}
+}
+
+func TestSelect_Off(t *testing.T) {
+	if checker, err := Select(ModeOff, "", "", nil); checker != nil || err != nil {
+		t.Errorf("Select(off) = %v, %v", checker, err)
+	}
+}
@@ -0,0 +1,105 @@
+package statedir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxNameLength はファイル名として使用する長さの上限（多くのファイルシステムの上限255バイトに余裕を持たせる）
+const maxNameLength = 200
+
+// Dir はログや復元用ファイルなどの状態ファイルを置くディレクトリを返す
+// KILO_STATE_DIR > XDG_STATE_HOME/go-kilo > ~/.local/state/go-kilo の順に決定する
+func Dir() string {
+	if dir := os.Getenv("KILO_STATE_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "go-kilo")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "go-kilo")
+	}
+	return filepath.Join(os.TempDir(), "go-kilo-state")
+}
+
+// Ensure は状態ディレクトリ配下のサブディレクトリを作成してそのパスを返す
+// 他のユーザーに内容を読まれないよう、パーミッションは 0700 とする
+func Ensure(sub string) (string, error) {
+	dir := filepath.Join(Dir(), sub)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("状態ディレクトリを作成できません: %w", err)
+	}
+	return dir, nil
+}
+
+// EncodePath は元ファイルのパスを、状態ディレクトリ内で衝突しないファイル名に変換する
+// 英数字と . _ - 以外のバイトは %XX に置き換えるため、異なるパスが同じ名前になることはなく、
+// ファイル名の文字コードに依存しない。長すぎる場合は末尾を残してハッシュを付ける
+func EncodePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if isSafe(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	name := b.String()
+	if len(name) <= maxNameLength {
+		return name
+	}
+
+	// 切り詰めた名前には通常の名前に現れない '~' を含めるので、元の名前と衝突しない
+	sum := sha256.Sum256([]byte(path))
+	suffix := "~" + hex.EncodeToString(sum[:8])
+	cut := len(name) - (maxNameLength - len(suffix))
+	// エスケープ列の途中から始まらないようにする
+	switch {
+	case name[cut-1] == '%':
+		cut += 2
+	case name[cut-2] == '%':
+		cut++
+	}
+	tail := name[cut:]
+	return tail + suffix
+}
+
+// DecodePath は EncodePath で変換した名前から元のパスを復元する
+// ハッシュ付きに切り詰めた名前は復元できないため false を返す
+func DecodePath(name string) (string, bool) {
+	if strings.Contains(name, "~") {
+		return "", false
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '%' {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i+2 >= len(name) {
+			return "", false
+		}
+		decoded, err := hex.DecodeString(name[i+1 : i+3])
+		if err != nil {
+			return "", false
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), true
+}
+
+func isSafe(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '.' || c == '_' || c == '-'
+}
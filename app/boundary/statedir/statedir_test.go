@@ -0,0 +1,60 @@
+package statedir
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"ASCII", "/home/user/main.go", "%2Fhome%2Fuser%2Fmain.go"},
+		{"日本語", "/tmp/メモ.txt", "%2Ftmp%2F%E3%83%A1%E3%83%A2.txt"},
+		{"エスケープ文字自体", "/a%2Fb", "%2Fa%252Fb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodePath(tt.path)
+			if got != tt.expected {
+				t.Errorf("EncodePath(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+			decoded, ok := DecodePath(got)
+			if !ok || decoded != tt.path {
+				t.Errorf("DecodePath(%q) = %q, %v", got, decoded, ok)
+			}
+		})
+	}
+}
+
+func TestEncodePath_Long(t *testing.T) {
+	base := "/" + strings.Repeat("ディレクトリ/", 30)
+	a := EncodePath(base + "a.txt")
+	b := EncodePath(base + "b.txt")
+	if len(a) > maxNameLength || len(b) > maxNameLength {
+		t.Fatalf("name too long: %d, %d", len(a), len(b))
+	}
+	if a == b {
+		t.Error("different paths must not collide")
+	}
+	if !strings.HasPrefix(a, "%") {
+		t.Errorf("truncated name must start at an escape boundary: %q", a[:10])
+	}
+	if _, ok := DecodePath(a); ok {
+		t.Error("truncated names cannot be decoded")
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("KILO_STATE_DIR", "/custom")
+	if Dir() != "/custom" {
+		t.Errorf("unexpected dir: %s", Dir())
+	}
+	t.Setenv("KILO_STATE_DIR", "")
+	t.Setenv("XDG_STATE_HOME", "/xdg")
+	if Dir() != "/xdg/go-kilo" {
+		t.Errorf("unexpected dir: %s", Dir())
+	}
+}
@@ -0,0 +1,100 @@
+package tagsfile
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/tags"
+)
+
+// FileName はプロジェクトルートに置かれるタグファイルの名前
+const FileName = "tags"
+
+// GtagsFileName は GNU GLOBAL（gtags）がプロジェクトルートに作成するタグファイルの名前
+const GtagsFileName = "GTAGS"
+
+// Find は startDir から親ディレクトリへ遡ってタグファイルを探す
+// 見つからない場合は空文字列を返す
+func Find(startDir string) string {
+	return findUp(startDir, FileName)
+}
+
+// FindGtags は startDir から親ディレクトリへ遡って GTAGS を探し、それを置くディレクトリを返す
+// 見つからない場合は空文字列を返す
+func FindGtags(startDir string) string {
+	if path := findUp(startDir, GtagsFileName); path != "" {
+		return filepath.Dir(path)
+	}
+	return ""
+}
+
+// findUp は startDir から親ディレクトリへ遡って name という名前のファイルを探す
+func findUp(startDir, name string) string {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Source はタグファイルを遅延読み込みし、変更があれば読み直す
+type Source struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	index   *tags.Index
+}
+
+// NewSource は path のタグファイルを読み込む Source を作成する
+// 実際の読み込みは最初に Index が呼ばれた時点で行う
+func NewSource(path string) *Source {
+	return &Source{path: path}
+}
+
+// Path はタグファイルのパスを返す
+func (s *Source) Path() string {
+	return s.path
+}
+
+// Index は最新のタグ索引を返す
+// タグファイルの更新時刻またはサイズが変わっていれば読み直す
+func (s *Source) Index() (*tags.Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if s.index != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.index, nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	index, err := tags.Parse(f, filepath.Dir(s.path))
+	if err != nil {
+		return nil, err
+	}
+	s.index = index
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return index, nil
+}
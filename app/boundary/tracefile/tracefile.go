@@ -0,0 +1,52 @@
+package tracefile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// 書き出す形式
+const (
+	FormatJSON    = "json"
+	FormatMermaid = "mermaid"
+)
+
+// Write はイベントの記録を状態ディレクトリの traces/ に書き出し、そのパスを返す
+// format が FormatMermaid の場合はシーケンス図、それ以外は JSON で書き出す
+func Write(tracer *event.Tracer, format string, now time.Time) (string, error) {
+	dir, err := statedir.Ensure("traces")
+	if err != nil {
+		return "", err
+	}
+	return WriteTo(dir, tracer, format, now)
+}
+
+// WriteTo は dir にイベントの記録を書き出し、そのパスを返す
+func WriteTo(dir string, tracer *event.Tracer, format string, now time.Time) (string, error) {
+	ext := ".json"
+	if format == FormatMermaid {
+		ext = ".mmd"
+	}
+	path := filepath.Join(dir, "trace-"+now.Format("20060102-150405")+ext)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("トレースを書き出せません: %w", err)
+	}
+	if format == FormatMermaid {
+		err = tracer.WriteMermaid(f)
+	} else {
+		err = tracer.WriteJSON(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("トレースを書き出せません: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,36 @@
+package tracefile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+func TestWriteTo(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	tracer := event.NewTracer(0)
+
+	path, err := WriteTo(dir, tracer, FormatMermaid, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "trace-20240506-070809.mmd") {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "sequenceDiagram") {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	path, err = WriteTo(dir, tracer, FormatJSON, now)
+	if err != nil || filepath.Ext(path) != ".json" {
+		t.Errorf("JSON trace: %s %v", path, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("trace must be readable only by the user: %v %v", info.Mode(), err)
+	}
+}
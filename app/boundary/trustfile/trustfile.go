@@ -0,0 +1,90 @@
+package trustfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/boundary/statedir"
+)
+
+// fileName は状態ディレクトリ内の信頼したプロジェクト設定の記録の名前
+const fileName = "trusted.json"
+
+// Store はビルドコマンドの実行を許可したプロジェクト設定を保存・照会するためのインターフェース
+type Store interface {
+	Trust(path, digest string) error
+	Trusted(path, digest string) (bool, error)
+}
+
+// FileStore は1つの JSON ファイルに設定ファイルの絶対パスごとの内容のダイジェストを置く Store の実装
+type FileStore struct {
+	path string
+}
+
+// NewFileStore は path を保存先とする FileStore を作成する
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// NewDefaultStore は状態ディレクトリ配下の trusted.json を保存先とする FileStore を作成する
+func NewDefaultStore() *FileStore {
+	return NewFileStore(filepath.Join(statedir.Dir(), fileName))
+}
+
+// key は設定ファイルのパスを記録のキーとなる絶対パスにする
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// Trust は path の設定ファイルを内容が digest のときに限って信頼したと記録する
+// 同じファイルの以前の記録は上書きする
+func (s *FileStore) Trust(path, digest string) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[key(path)] = digest
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("信頼したプロジェクトの記録のディレクトリを作成できません: %w", err)
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0600)
+}
+
+// Trusted は path の設定ファイルを今の内容（digest）で信頼したかを返す
+// 信頼した後に内容が変わっていれば false を返す
+func (s *FileStore) Trusted(path, digest string) (bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	trusted, ok := all[key(path)]
+	return ok && trusted == digest, nil
+}
+
+// load は記録全体を読み込む。まだ記録がなければ空の記録を返す
+func (s *FileStore) load() (map[string]string, error) {
+	all := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("信頼したプロジェクトの記録を読み込めません: %s: %w", s.path, err)
+	}
+	return all, nil
+}
@@ -0,0 +1,26 @@
+package trustfile
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore_TrustAndTrusted(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state", "trusted.json"))
+	if ok, err := store.Trusted("/tmp/p/.go-kilo.toml", "abc"); ok || err != nil {
+		t.Fatalf("Trusted() before trusting = %v, %v", ok, err)
+	}
+
+	if err := store.Trust("/tmp/p/.go-kilo.toml", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Trust("/tmp/q/.go-kilo.toml", "def"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Trusted("/tmp/../tmp/p/.go-kilo.toml", "abc"); !ok || err != nil {
+		t.Errorf("Trusted() = %v, %v; other projects must be kept", ok, err)
+	}
+	if ok, _ := store.Trusted("/tmp/p/.go-kilo.toml", "changed"); ok {
+		t.Error("a project whose settings changed after trusting must not be trusted")
+	}
+}
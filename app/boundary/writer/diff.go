@@ -0,0 +1,195 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffWriter は前回書き出した画面を覚えておき、内容の変わった行だけを書き直す ScreenWriter
+// Screen は描画のたびに画面全体を消して書き直すフレームを組み立てるが、そのまま書き出すと
+// SSH 越しなどでちらつくため、フレームを行ごとに分解して前回と比べ、変わった行だけを
+// カーソル移動と行の書き直しのエスケープシーケンスにして w に書き出す
+type DiffWriter struct {
+	w    ScreenWriter
+	prev map[int]string // 行ごとの前回の内容。nil なら次のフレームはそのまま書き出す
+}
+
+// NewDiffWriter は w に差分だけを書き出す DiffWriter を作成する
+func NewDiffWriter(w ScreenWriter) *DiffWriter {
+	return &DiffWriter{w: w}
+}
+
+// Invalidate は前回の画面を忘れ、次のフレームを全体を書き直させる
+// 端末の大きさが変わった場合など、端末の表示が前回書き出した内容と食い違う場合に呼び出す
+func (d *DiffWriter) Invalidate() {
+	d.prev = nil
+}
+
+// Write は s を書き出す。画面を消すシーケンスを含む s はフレームとして扱い、前回と違う行だけを書き出す
+// フレームでない s（部分的な更新）はそのまま書き出し、触れた行は次のフレームで書き直す
+func (d *DiffWriter) Write(s string) error {
+	f := parseFrame(s)
+	if !f.full {
+		for row := range f.rows {
+			delete(d.prev, row)
+		}
+		return d.w.Write(s)
+	}
+	// 最後に文字を書いた位置が分からない場合や初回は、フレームをそのまま書き出す
+	if d.prev == nil || !f.cursorKnown {
+		d.prev = f.rows
+		return d.w.Write(s)
+	}
+
+	var out strings.Builder
+	for _, row := range f.changedRows(d.prev) {
+		out.WriteString(moveTo(row, 0) + "\x1b[m\x1b[2K")
+		out.WriteString(f.rows[row])
+	}
+	out.WriteString(f.global)
+	out.WriteString(moveTo(f.cursorRow, f.cursorCol))
+	d.prev = f.rows
+	return d.w.Write(out.String())
+}
+
+// frame は1回分の描画を行ごとに分解したもの
+type frame struct {
+	full        bool           // 画面を消してから描画している（書かれていない行は空）
+	rows        map[int]string // 行ごとの内容（位置を指定するシーケンスと文字）
+	global      string         // 行によらないシーケンス（端末のタイトルなど）
+	cursorRow   int            // 描画を終えた後のカーソルの位置
+	cursorCol   int
+	cursorKnown bool // 最後に位置を指定した後に文字を書いていない
+}
+
+// changedRows は前回の内容 prev から変わった行（消えた行を含む）を昇順で返す
+func (f *frame) changedRows(prev map[int]string) []int {
+	var rows []int
+	for row, line := range f.rows {
+		if prev[row] != line {
+			rows = append(rows, row)
+		}
+	}
+	for row := range prev {
+		if _, ok := f.rows[row]; !ok {
+			rows = append(rows, row)
+		}
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// parseFrame は Screen が組み立てた文字列を行ごとに分解する
+// 位置の指定（ESC [ row ; col H、CR、LF）ごとに区切り、区切った位置と続く文字を行の内容として集める
+// 文字の表示幅は数えないため、位置の指定の後に文字を書いた場合は描画後のカーソル位置が分からない
+func parseFrame(s string) *frame {
+	f := &frame{rows: make(map[int]string)}
+	row, col := 0, 0
+	var segment strings.Builder
+	started := false // segment に位置の指定を書いたか
+
+	write := func(text string) {
+		if !started {
+			segment.WriteString(moveTo(row, col))
+			started = true
+		}
+		segment.WriteString(text)
+		f.cursorKnown = false
+	}
+	flush := func() {
+		if started {
+			f.rows[row] += segment.String()
+			segment.Reset()
+			started = false
+		}
+	}
+	move := func(r, c int) {
+		flush()
+		row, col = r, c
+		f.cursorKnown = true
+	}
+
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "\x1b["):
+			end := i + 2
+			for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+				end++
+			}
+			if end == len(s) {
+				write(s[i:])
+				i = end
+				continue
+			}
+			seq := s[i : end+1]
+			switch {
+			case seq == "\x1b[2J":
+				f.full = true
+			case s[end] == 'H':
+				r, c := parseMove(s[i+2 : end])
+				move(r, c)
+			default:
+				write(seq)
+			}
+			i = end + 1
+		case strings.HasPrefix(s[i:], "\x1b]"):
+			// OSC は BEL か ST（ESC \）で終わる
+			end, term := len(s), 0
+			if j := strings.IndexByte(s[i:], '\a'); j >= 0 {
+				end, term = i+j, 1
+			}
+			if j := strings.Index(s[i:], "\x1b\\"); j >= 0 && i+j < end {
+				end, term = i+j, 2
+			}
+			seq := s[i:min(end+term, len(s))]
+			// 端末のタイトルは行の内容ではないため、毎回書き出す
+			if strings.HasPrefix(seq, "\x1b]0;") || strings.HasPrefix(seq, "\x1b]1;") || strings.HasPrefix(seq, "\x1b]2;") {
+				f.global += seq
+			} else {
+				write(seq)
+			}
+			i += len(seq)
+		case strings.HasPrefix(s[i:], "\r\n"):
+			move(row+1, 0)
+			i += 2
+		case s[i] == '\r':
+			move(row, 0)
+			i++
+		case s[i] == '\n':
+			move(row+1, 0)
+			i++
+		default:
+			j := i + 1
+			for j < len(s) && s[j] != '\x1b' && s[j] != '\r' && s[j] != '\n' {
+				j++
+			}
+			write(s[i:j])
+			i = j
+		}
+	}
+	flush()
+	f.cursorRow, f.cursorCol = row, col
+	return f
+}
+
+// parseMove は ESC [ row ; col H の引数を 0 始まりの位置にする。省略した値は 1 とみなす
+func parseMove(params string) (int, int) {
+	r, c := 1, 1
+	parts := strings.SplitN(params, ";", 2)
+	if n, err := strconv.Atoi(parts[0]); err == nil && n > 0 {
+		r = n
+	}
+	if len(parts) == 2 {
+		if n, err := strconv.Atoi(parts[1]); err == nil && n > 0 {
+			c = n
+		}
+	}
+	return r - 1, c - 1
+}
+
+// moveTo は 0 始まりの位置にカーソルを移すシーケンスを返す
+func moveTo(row, col int) string {
+	return fmt.Sprintf("\x1b[%d;%dH", row+1, col+1)
+}
@@ -0,0 +1,127 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+)
+
+// recorder は書き出された文字列を記録する ScreenWriter
+type recorder struct {
+	writes []string
+}
+
+func (r *recorder) Write(s string) error {
+	r.writes = append(r.writes, s)
+	return nil
+}
+
+func (r *recorder) last() string {
+	return r.writes[len(r.writes)-1]
+}
+
+// testFrame は Screen と同じく画面を消してから rows を描画し、カーソルを (1, 2) に移すフレームを返す
+func testFrame(rows ...string) string {
+	var b strings.Builder
+	b.WriteString("\x1b[2J\x1b[H")
+	for _, row := range rows {
+		b.WriteString("\x1b[2K" + row + "\r\n")
+	}
+	b.WriteString("\x1b[2;3H")
+	return b.String()
+}
+
+func TestDiffWriter_WritesChangedRows(t *testing.T) {
+	r := &recorder{}
+	d := NewDiffWriter(r)
+
+	first := testFrame("alpha", "beta", "gamma")
+	d.Write(first)
+	if r.last() != first {
+		t.Fatalf("first frame must be written as is: %q", r.last())
+	}
+
+	// 同じフレームはカーソルの移動だけになる
+	d.Write(first)
+	if got := r.last(); got != "\x1b[2;3H" {
+		t.Errorf("unchanged frame: got %q", got)
+	}
+
+	d.Write(testFrame("alpha", "BETA", "gamma"))
+	got := r.last()
+	if !strings.Contains(got, "\x1b[2;1H\x1b[m\x1b[2K\x1b[2;1H\x1b[2KBETA") {
+		t.Errorf("changed row not rewritten: %q", got)
+	}
+	if strings.Contains(got, "alpha") || strings.Contains(got, "gamma") {
+		t.Errorf("unchanged rows rewritten: %q", got)
+	}
+	if !strings.HasSuffix(got, "\x1b[2;3H") {
+		t.Errorf("cursor not restored: %q", got)
+	}
+
+	// 描かれなくなった行は消す
+	d.Write(testFrame("alpha", "BETA"))
+	if got := r.last(); got != "\x1b[3;1H\x1b[m\x1b[2K\x1b[2;3H" {
+		t.Errorf("removed row not cleared: %q", got)
+	}
+}
+
+func TestDiffWriter_Invalidate(t *testing.T) {
+	r := &recorder{}
+	d := NewDiffWriter(r)
+	frame := testFrame("alpha")
+	d.Write(frame)
+
+	d.Invalidate()
+	d.Write(frame)
+	if r.last() != frame {
+		t.Errorf("frame after Invalidate must be written as is: %q", r.last())
+	}
+}
+
+func TestDiffWriter_PartialWrite(t *testing.T) {
+	r := &recorder{}
+	d := NewDiffWriter(r)
+	frame := testFrame("alpha", "beta")
+	d.Write(frame)
+
+	// 画面を消さない書き出しはそのまま書き出し、触れた行は次のフレームで書き直す
+	partial := "\x1b[2;1Hoverwritten"
+	d.Write(partial)
+	if r.last() != partial {
+		t.Fatalf("partial write changed: %q", r.last())
+	}
+	d.Write(frame)
+	if got := r.last(); !strings.Contains(got, "beta") || strings.Contains(got, "alpha") {
+		t.Errorf("row touched by the partial write not rewritten: %q", got)
+	}
+}
+
+func TestDiffWriter_SplitPanesAndTitle(t *testing.T) {
+	r := &recorder{}
+	d := NewDiffWriter(r)
+	frame := func(left, right string) string {
+		return "\x1b[2J\x1b[1;1H" + left + "\x1b[1;6H|\x1b[1;7H" + right + "\x1b]2;title\x07\x1b[1;2H"
+	}
+	d.Write(frame("left", "right"))
+
+	d.Write(frame("left", "RIGHT"))
+	got := r.last()
+	// 同じ行の別の区画も含めて行全体を書き直し、タイトルは毎回書き出す
+	want := "\x1b[1;1H\x1b[m\x1b[2K\x1b[1;1Hleft\x1b[1;6H|\x1b[1;7HRIGHT\x1b]2;title\x07\x1b[1;2H"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDiffWriter_UnknownCursor(t *testing.T) {
+	r := &recorder{}
+	d := NewDiffWriter(r)
+	d.Write(testFrame("alpha"))
+
+	// 最後に文字を書いた場合はカーソルの位置が分からないため、そのまま書き出す
+	frame := "\x1b[2J\x1b[Halpha"
+	d.Write(frame)
+	if r.last() != frame {
+		t.Errorf("frame ending with text must be written as is: %q", r.last())
+	}
+}
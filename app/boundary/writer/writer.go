@@ -8,6 +8,11 @@ type ScreenWriter interface {
 	Write(s string) error
 }
 
+// Invalidator は前回書き出した画面を覚えていて、忘れさせることができる ScreenWriter
+type Invalidator interface {
+	Invalidate()
+}
+
 type StandardScreenWriter struct {
 }
 
@@ -1,78 +1,136 @@
 package config
 
 import (
-"os"
-"strconv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 
-"github.com/joho/godotenv"
+	"github.com/joho/godotenv"
 )
 
 const (
-defaultTabWidth = 4
+	defaultTabWidth = 4
 )
 
 // Config はエディタの設定を保持する構造体
 type Config struct {
-TabWidth              int
-SmoothScroll          bool
-ScrollSteps           int
-DebugMode             bool
-StatusMessageDuration int  // ステータスメッセージの表示時間（秒）
-MetricsEnabled        bool // パフォーマンスメトリクスの有効化
+	TabWidth               int
+	SmoothScroll           bool
+	ScrollSteps            int
+	DebugMode              bool
+	StatusMessageDuration  int    // ステータスメッセージの表示時間（秒）
+	MetricsEnabled         bool   // パフォーマンスメトリクスの有効化
+	GoImportsOnSave        bool   // Goファイルの保存時にgoimportsを実行する
+	TrimTrailingWhitespace bool   // 保存時に行末の空白を取り除く
+	FormatOnSave           string // ファイルの種類ごとに保存時に内容を通す整形コマンド（「種類=コマンド」のセミコロン区切り）
+	TerminalTitle          bool   // 端末タイトルにファイル名を表示する
+	Hyperlinks             bool   // バッファ内の URL を OSC 8 ハイパーリンクとして描画する
+	WordWrap               bool   // 長い行を折り返して表示する
+	StatusSegments         string // ステータスバーに表示する項目（カンマ区切り）
+	Theme                  string // 描画に使うテーマ（auto / default / high-contrast / monochrome / dusk）
+	ColorDepth             string // 端末の色数（auto / truecolor / 256 / 16 / 8 / none）
+	WelcomeMessage         string // 空のバッファに表示するメッセージ
+	TabBar                 bool   // 複数のバッファを開いている場合に画面の上端にタブバーを表示する
+	AutoPair               bool   // 開き括弧・引用符の入力で閉じる文字を補う
+	KeyPrecedence          string // 同じキーを別の設定で割り当てた場合に優先する設定（project / default）
+	PersistentUndo         bool   // 編集履歴をセッションをまたいで残す
+	ResumeSession          bool   // ファイルを指定せずに起動した場合に前回のセッションを復元する
+	RememberPosition       bool   // ファイルごとに最後のカーソル位置を記録し、次に開いたときに戻す
+	ElevateCommand         string // 書き込み権限のないファイルを保存するコマンド（-- と保存先のパスを最後の引数に加える）
+	LanguageServers        string // ファイルの種類ごとの言語サーバーの起動コマンド（「種類=コマンド」のセミコロン区切り）
+	GitSigns               bool   // git リポジトリ内のファイルで、HEAD からの変更をガターに、ブランチをステータスバーに表示する
+	SpellCheck             string // Markdown とテキストのファイルの綴りの確認に使う実装（off / auto / aspell / wordlist）
+	SpellDictionary        string // 綴りの確認に使う単語の一覧のファイル（wordlist の場合）
+	SpellLanguage          string // aspell で使う辞書の言語
+	Readahead              bool   // 大きなファイルを開く際にカーネルへ先読みを指示する
+	GrepBackend            string // プロジェクト検索に使う実装（auto / ripgrep / builtin）
+	UnsavedReminderMinutes int    // 未保存状態がこの分数続いたら保存を促す（0で無効）
+	RecoveryInterval       int    // 編集が続いても未保存の変更を書き出すまでの最長の秒数（0で自動の書き出しを無効）
+	RecoveryIdle           int    // 編集が止まってから未保存の変更を書き出すまでの秒数
+	RecoveryMinInterval    int    // 自動の書き出しの最短間隔（秒）
+	Backup                 string // 保存で上書きする前の内容のバックアップ先（dir / tilde / off）
+	BackupKeep             int    // ファイルごとに残すバックアップの件数
+	BackupMaxAgeDays       int    // この日数より新しいバックアップは件数に関わらず残す
+	EventTrace             string // イベントバスの記録を書き出す形式（off / json / mermaid）
 }
 
 // GetTabWidth はタブ幅を取得する
 func GetTabWidth() int {
-if width := os.Getenv("TAB_WIDTH"); width != "" {
-if val, err := strconv.Atoi(width); err == nil && val > 0 {
-return val
+	if width := os.Getenv("TAB_WIDTH"); width != "" {
+		if val, err := strconv.Atoi(width); err == nil && val > 0 {
+			return val
+		}
+	}
+	return defaultTabWidth
 }
-}
-return defaultTabWidth
+
+// Default はスキーマに定義されたデフォルト値で設定を作成する
+func Default() *Config {
+	config := &Config{}
+	for _, f := range Schema() {
+		// デフォルト値はスキーマ定義の一部なので、ここで失敗することはない
+		f.set(config, f.Default)
+	}
+	return config
 }
 
-// LoadConfig は.envファイルから設定を読み込む
+// LoadConfig は.envファイルと設定ファイルから設定を読み込む
+// 不正な値は無視してデフォルト値を使用する。エラーの一覧が必要な場合は Load を使用する
 func LoadConfig() *Config {
-// .envファイルを読み込む
-godotenv.Load()
-
-config := &Config{
-TabWidth:              defaultTabWidth,
-SmoothScroll:          true,
-ScrollSteps:           3,
-DebugMode:             false,
-StatusMessageDuration: 5, // デフォルトは5秒
-MetricsEnabled:        false,
+	config, _ := Load()
+	return config
 }
 
-// TAB_WIDTHの環境変数を読み込む
-if tabWidth := os.Getenv("TAB_WIDTH"); tabWidth != "" {
-if width, err := strconv.Atoi(tabWidth); err == nil && width > 0 {
-config.TabWidth = width
-}
-}
+// Load は設定を読み込み、不正な設定の一覧とともに返す
+// 優先順位は デフォルト値 < 設定ファイル < 環境変数(.env) の順
+// 不正な値はその項目だけ読み飛ばし、起動は継続できるようにする
+func Load() (*Config, ValidationErrors) {
+	// .envファイルを読み込む
+	godotenv.Load()
 
-// SMOOTH_SCROLL環境変数から設定を読み込む
-if smooth := os.Getenv("SMOOTH_SCROLL"); smooth != "" {
-config.SmoothScroll = smooth != "0" && smooth != "false"
-}
+	config := Default()
+	var errs ValidationErrors
 
-// SCROLL_STEPS環境変数から設定を読み込む
-if steps := os.Getenv("SCROLL_STEPS"); steps != "" {
-if val, err := strconv.Atoi(steps); err == nil && val > 0 {
-config.ScrollSteps = val
-}
-}
+	// 構造化された設定ファイルを読み込む
+	if path := FilePath(); path != "" {
+		errs = append(errs, applyFile(config, path)...)
+	}
+
+	// 環境変数から設定を読み込む
+	errs = append(errs, applyEnv(config, os.Getenv)...)
 
-// DEBUG環境変数から設定を読み込む
-if debug := os.Getenv("DEBUG"); debug != "" {
-config.DebugMode = debug == "true"
+	return config, errs
 }
 
-// KILO_METRICS_ENABLED環境変数から設定を読み込む
-if metrics := os.Getenv("KILO_METRICS_ENABLED"); metrics != "" {
-config.MetricsEnabled = metrics != "0" && metrics != "false"
+// ParseLanguageServers は「種類=コマンド」をセミコロンで区切った指定を、ファイルの種類（小文字）ごとのコマンドにする
+func ParseLanguageServers(value string) (map[string]string, error) {
+	servers := make(map[string]string)
+	for _, item := range strings.Split(value, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		name, command, ok := strings.Cut(item, "=")
+		name, command = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("%w: expected TYPE=COMMAND, got %q", ErrInvalidValue, strings.TrimSpace(item))
+		}
+		servers[name] = command
+	}
+	return servers, nil
 }
 
-return config
+// formatLanguageServers は ParseLanguageServers の結果を種類の名前順に並べた指定に戻す
+func formatLanguageServers(servers map[string]string) string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		items = append(items, name+"="+servers[name])
+	}
+	return strings.Join(items, ";")
 }
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	if c.TabWidth != defaultTabWidth || !c.SmoothScroll || c.ScrollSteps != 3 || c.StatusMessageDuration != 5 {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr []string
+	}{
+		{"正常な値", map[string]string{"TAB_WIDTH": "2", "DEBUG": "yes"}, func(c *Config) bool { return c.TabWidth == 2 && c.DebugMode }, nil},
+		{"不正な整数", map[string]string{"TAB_WIDTH": "abc"}, func(c *Config) bool { return c.TabWidth == defaultTabWidth }, []string{"TAB_WIDTH"}},
+		{"範囲外の整数", map[string]string{"SCROLL_STEPS": "0"}, func(c *Config) bool { return c.ScrollSteps == 3 }, []string{"SCROLL_STEPS"}},
+		{"全ての不正値を報告", map[string]string{"SMOOTH_SCROLL": "maybe", "TAB_WIDTH": "-1"}, func(c *Config) bool { return c.SmoothScroll }, []string{"TAB_WIDTH", "SMOOTH_SCROLL"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			errs := applyEnv(c, func(k string) string { return tt.env[k] })
+			if !tt.check(c) {
+				t.Errorf("unexpected config: %+v", c)
+			}
+			if len(errs) != len(tt.wantErr) {
+				t.Fatalf("got errors %v, want keys %v", errs, tt.wantErr)
+			}
+			for i, key := range tt.wantErr {
+				if errs[i].Key != key || !errors.Is(errs[i], ErrInvalidValue) {
+					t.Errorf("unexpected error: %v", errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"tab_width": 8, "smooth_scroll": false, "colour": "red", "scroll_steps": "fast"}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Default()
+	errs := applyFile(c, path)
+	if c.TabWidth != 8 || c.SmoothScroll {
+		t.Errorf("file values not applied: %+v", c)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if !errors.Is(errs[0], ErrUnknownKey) || !errors.Is(errs[1], ErrInvalidValue) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	if errs := applyFile(c, filepath.Join(t.TempDir(), "missing.json")); errs != nil {
+		t.Errorf("missing file should be ignored, got %v", errs)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	var sb strings.Builder
+	if err := Document(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range Schema() {
+		if !strings.Contains(sb.String(), f.Env) {
+			t.Errorf("document does not mention %s", f.Env)
+		}
+	}
+}
+
+func TestEventTraceChoice(t *testing.T) {
+	c := Default()
+	if c.EventTrace != "off" {
+		t.Fatalf("default EventTrace = %q", c.EventTrace)
+	}
+	errs := applyEnv(c, func(key string) string {
+		if key == "EVENT_TRACE" {
+			return "Mermaid"
+		}
+		return ""
+	})
+	if len(errs) != 0 || c.EventTrace != "mermaid" {
+		t.Errorf("EventTrace = %q, errs = %v", c.EventTrace, errs)
+	}
+
+	errs = applyEnv(c, func(key string) string {
+		if key == "EVENT_TRACE" {
+			return "xml"
+		}
+		return ""
+	})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidValue) || c.EventTrace != "mermaid" {
+		t.Errorf("invalid choice must be rejected: %q %v", c.EventTrace, errs)
+	}
+}
+
+func TestStatusSegmentsList(t *testing.T) {
+	c := Default()
+	errs := applyEnv(c, func(key string) string {
+		if key == "STATUS_SEGMENTS" {
+			return " Name, position ,,EOL"
+		}
+		return ""
+	})
+	if len(errs) != 0 || c.StatusSegments != "name,position,eol" {
+		t.Errorf("StatusSegments = %q, errs = %v", c.StatusSegments, errs)
+	}
+
+	// 設定ファイルでは配列でも指定できる
+	errs = applyJSON(c, "config.json", []byte(`{"status_segments": ["percent", "filetype"]}`))
+	if len(errs) != 0 || c.StatusSegments != "percent,filetype" {
+		t.Errorf("StatusSegments = %q, errs = %v", c.StatusSegments, errs)
+	}
+
+	errs = applyJSON(c, "config.json", []byte(`{"status_segments": "name,clock"}`))
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidValue) || c.StatusSegments != "percent,filetype" {
+		t.Errorf("unknown segment must be rejected: %q %v", c.StatusSegments, errs)
+	}
+}
+
+func TestLanguageServers(t *testing.T) {
+	c := Default()
+	if c.LanguageServers != "" {
+		t.Fatalf("default LanguageServers = %q", c.LanguageServers)
+	}
+	errs := applyEnv(c, func(key string) string {
+		if key == "LANGUAGE_SERVERS" {
+			return "Python = pylsp ; Go=gopls -remote=auto;"
+		}
+		return ""
+	})
+	if len(errs) != 0 || c.LanguageServers != "go=gopls -remote=auto;python=pylsp" {
+		t.Errorf("LanguageServers = %q, errs = %v", c.LanguageServers, errs)
+	}
+	servers, err := ParseLanguageServers(c.LanguageServers)
+	if err != nil || len(servers) != 2 || servers["go"] != "gopls -remote=auto" {
+		t.Errorf("ParseLanguageServers() = %v, %v", servers, err)
+	}
+
+	// 設定ファイルでは種類をキーにしたオブジェクトでも指定できる
+	errs = applyJSON(c, "config.json", []byte(`{"language_servers": {"Shell": "bash-language-server start"}}`))
+	if len(errs) != 0 || c.LanguageServers != "shell=bash-language-server start" {
+		t.Errorf("LanguageServers = %q, errs = %v", c.LanguageServers, errs)
+	}
+
+	errs = applyJSON(c, "config.json", []byte(`{"language_servers": "gopls"}`))
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidValue) || c.LanguageServers != "shell=bash-language-server start" {
+		t.Errorf("a command without a type must be rejected: %q %v", c.LanguageServers, errs)
+	}
+}
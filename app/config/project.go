@@ -0,0 +1,240 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectFileName はプロジェクトごとの設定ファイル名
+const ProjectFileName = ".go-kilo.toml"
+
+// maxIndentWidth はインデントの1段に指定できるスペースの数の上限（タブ幅と同じ）
+const maxIndentWidth = 16
+
+// 保存時の整形に使うツールの選択肢
+const (
+	FormatterGoImports = "goimports"
+	FormatterGofmt     = "gofmt"
+	FormatterNone      = "none"
+)
+
+// キー割り当てが食い違う場合に優先する設定の選択肢（KEY_PRECEDENCE）
+const (
+	KeyPrecedenceProject = "project" // プロジェクト設定の割り当てを使う
+	KeyPrecedenceDefault = "default" // 既定の割り当てを使う
+)
+
+// Project はプロジェクトのルートに置かれた .go-kilo.toml の内容
+//
+//	formatter = "gofmt"
+//	exclude = ["testdata", "dist"]
+//
+//	[keys]            # 通常時のキー割り当て
+//	"C-f" = "goto-definition"
+//
+//	[keys.C-k]        # Ctrl-K の後に押すキーの割り当て
+//	"B" = "build"
+//
+//	[build]           # ビルドコマンド（名前 = コマンドライン）
+//	default = "go build ./..."
+//	test = "go test ./..."
+//
+//	[indent.Python]   # ファイルの種類ごとの自動インデントの規則
+//	after = [":"]
+//	dedent = ")]}"
+//	unit = 4          # 1段の幅（"tab" でタブ）
+type Project struct {
+	Path      string                       // 設定ファイルのパス
+	Digest    string                       // 設定ファイルの内容の SHA-256（信頼した内容から変わっていないかの確認に使う）
+	Root      string                       // 設定ファイルが置かれたディレクトリ
+	Formatter string                       // 保存時の整形ツール（空ならユーザー設定に従う）
+	Exclude   []string                     // プロジェクト検索から除外するディレクトリ
+	Keys      map[string]map[string]string // レイヤー名 -> キー -> コマンド
+	Build     map[string]string            // ビルドコマンド名 -> コマンドライン
+	Indent    map[string]IndentRule        // ファイルの種類 -> 自動インデントの規則
+}
+
+// IndentRule はファイルの種類ごとの自動インデントの規則の設定
+type IndentRule struct {
+	After  []string // 行末がこれらで終わる行の次の行を1段深くする
+	Dedent string   // 行頭に入力すると1段浅くする文字
+	Unit   string   // 1段のインデント（タブまたはスペースの並び。空なら既定）
+}
+
+// FindProjectFile は dir から親ディレクトリへ向かって .go-kilo.toml を探す
+// 見つからない場合は空文字を返す
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProject はプロジェクト設定ファイルを読み込む
+// 書式の誤りはエラーとして返し、未知の項目や不正な値は ValidationErrors として報告する
+func LoadProject(path string) (*Project, ValidationErrors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := parseTOML(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	p := &Project{
+		Path:   path,
+		Digest: fmt.Sprintf("%x", sha256.Sum256(data)),
+		Root:   filepath.Dir(path),
+		Keys:   make(map[string]map[string]string),
+		Build:  make(map[string]string),
+		Indent: make(map[string]IndentRule),
+	}
+	var errs ValidationErrors
+	invalid := func(key string, value interface{}, msg string) {
+		errs = append(errs, ValidationError{Source: path, Key: key, Value: fmt.Sprint(value), Err: fmt.Errorf("%w: %s", ErrInvalidValue, msg)})
+	}
+
+	for _, table := range sortedKeys(doc) {
+		values := doc[table]
+		switch {
+		case table == "":
+			for _, k := range sortedKeys(values) {
+				v := values[k]
+				switch k {
+				case "formatter":
+					s, ok := v.(string)
+					if !ok || (s != FormatterGoImports && s != FormatterGofmt && s != FormatterNone) {
+						invalid(k, v, "expected goimports, gofmt or none")
+						continue
+					}
+					p.Formatter = s
+				case "exclude":
+					dirs, ok := stringArray(v)
+					if !ok {
+						invalid(k, v, "expected an array of strings")
+						continue
+					}
+					p.Exclude = dirs
+				default:
+					errs = append(errs, ValidationError{Source: path, Key: k, Err: ErrUnknownKey})
+				}
+			}
+		case table == "keys" || strings.HasPrefix(table, "keys."):
+			layer := strings.TrimPrefix(strings.TrimPrefix(table, "keys"), ".")
+			if layer == "" {
+				layer = "global"
+			}
+			for _, k := range sortedKeys(values) {
+				cmd, ok := values[k].(string)
+				if !ok {
+					invalid(table+"."+k, values[k], "expected a command name")
+					continue
+				}
+				if p.Keys[layer] == nil {
+					p.Keys[layer] = make(map[string]string)
+				}
+				p.Keys[layer][k] = cmd
+			}
+		case table == "build":
+			for _, k := range sortedKeys(values) {
+				cmd, ok := values[k].(string)
+				if !ok || strings.TrimSpace(cmd) == "" {
+					invalid("build."+k, values[k], "expected a command line")
+					continue
+				}
+				p.Build[k] = cmd
+			}
+		case strings.HasPrefix(table, "indent."):
+			fileType := strings.TrimPrefix(table, "indent.")
+			var rule IndentRule
+			for _, k := range sortedKeys(values) {
+				v := values[k]
+				switch k {
+				case "after":
+					after, ok := stringArray(v)
+					if !ok {
+						invalid(table+"."+k, v, "expected an array of strings")
+						continue
+					}
+					rule.After = after
+				case "dedent":
+					s, ok := v.(string)
+					if !ok {
+						invalid(table+"."+k, v, "expected a string of characters")
+						continue
+					}
+					rule.Dedent = s
+				case "unit":
+					unit, ok := indentUnit(v)
+					if !ok {
+						invalid(table+"."+k, v, fmt.Sprintf("expected \"tab\" or 1-%d", maxIndentWidth))
+						continue
+					}
+					rule.Unit = unit
+				default:
+					errs = append(errs, ValidationError{Source: path, Key: table + "." + k, Err: ErrUnknownKey})
+				}
+			}
+			p.Indent[fileType] = rule
+		default:
+			errs = append(errs, ValidationError{Source: path, Key: "[" + table + "]", Err: ErrUnknownKey})
+		}
+	}
+	return p, errs, nil
+}
+
+// indentUnit は "tab" またはスペースの数で指定したインデントの1段を文字列にする
+func indentUnit(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return "\t", v == "tab"
+	case int64:
+		if v < 1 || v > maxIndentWidth {
+			return "", false
+		}
+		return strings.Repeat(" ", int(v)), true
+	}
+	return "", false
+}
+
+// stringArray は TOML の配列を文字列のスライスに変換する
+func stringArray(v interface{}) ([]string, bool) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, s)
+	}
+	return strs, true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
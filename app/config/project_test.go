@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	data := `
+# コメント
+formatter = "gofmt" # 行末のコメント
+exclude = [
+  "testdata",
+  'dist', # 末尾のカンマも許す
+]
+count = 1_000
+enabled = true
+
+[keys]
+"C-f" = "goto-definition"
+"M-#" = "save"
+
+[keys.C-k]
+B = "build"
+`
+	doc, err := parseTOML([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := doc[""]
+	if root["formatter"] != "gofmt" || root["count"] != int64(1000) || root["enabled"] != true {
+		t.Errorf("unexpected root table: %v", root)
+	}
+	if !reflect.DeepEqual(root["exclude"], []interface{}{"testdata", "dist"}) {
+		t.Errorf("unexpected array: %v", root["exclude"])
+	}
+	if doc["keys"]["C-f"] != "goto-definition" || doc["keys"]["M-#"] != "save" {
+		t.Errorf("unexpected keys table: %v", doc["keys"])
+	}
+	if doc["keys.C-k"]["B"] != "build" {
+		t.Errorf("unexpected nested table: %v", doc)
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	for _, data := range []string{
+		`formatter`,
+		`formatter = "gofmt`,
+		`exclude = ["a" "b"]`,
+		`[keys`,
+		"a = 1\na = 2",
+		`value = nope`,
+	} {
+		if _, err := parseTOML([]byte(data)); !errors.Is(err, ErrTOMLSyntax) {
+			t.Errorf("parseTOML(%q) = %v, want syntax error", data, err)
+		}
+	}
+}
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(root, ProjectFileName)
+	if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectFile(sub); got != path {
+		t.Errorf("FindProjectFile = %q, want %q", got, path)
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	content := `
+formatter = "black"
+exclude = ["gen", "third_party"]
+colour = "red"
+
+[keys]
+"C-f" = "goto-definition"
+
+[keys.C-k]
+B = "build"
+T = 1
+
+[build]
+default = "go build ./..."
+test = "go test ./..."
+
+[indent.Python]
+after = [":"]
+unit = 2
+
+[indent."Go Module"]
+unit = "tab"
+dedent = ")"
+after = "("
+
+[unknown]
+x = 1
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, errs, err := LoadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != filepath.Dir(path) || p.Formatter != "" {
+		t.Errorf("unexpected project: %+v", p)
+	}
+	if !reflect.DeepEqual(p.Exclude, []string{"gen", "third_party"}) {
+		t.Errorf("unexpected exclude: %v", p.Exclude)
+	}
+	if p.Keys["global"]["C-f"] != "goto-definition" || p.Keys["C-k"]["B"] != "build" {
+		t.Errorf("unexpected keys: %v", p.Keys)
+	}
+	if p.Build["default"] != "go build ./..." || p.Build["test"] != "go test ./..." {
+		t.Errorf("unexpected build commands: %v", p.Build)
+	}
+
+	if want := (IndentRule{After: []string{":"}, Unit: "  "}); !reflect.DeepEqual(p.Indent["Python"], want) {
+		t.Errorf("unexpected indent rule: %+v", p.Indent["Python"])
+	}
+	if want := (IndentRule{Dedent: ")", Unit: "\t"}); !reflect.DeepEqual(p.Indent["Go Module"], want) {
+		t.Errorf("unexpected indent rule: %+v", p.Indent["Go Module"])
+	}
+
+	// formatter, colour, keys.C-k.T, indent.Go Module.after, [unknown] の5件
+	if len(errs) != 5 {
+		t.Fatalf("expected 5 errors, got %v", errs)
+	}
+	if !errors.Is(errs[0], ErrUnknownKey) || !errors.Is(errs[1], ErrInvalidValue) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	if err := os.WriteFile(path, []byte("formatter = "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadProject(path); !errors.Is(err, ErrTOMLSyntax) {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+}
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind は設定値の型を表す
+type Kind string
+
+const (
+	KindInt    Kind = "int"
+	KindBool   Kind = "bool"
+	KindString Kind = "string"
+)
+
+// Field は設定項目1つ分のスキーマ定義
+type Field struct {
+	Env         string // 環境変数および.envファイルでのキー
+	Key         string // 設定ファイルでのキー
+	Kind        Kind
+	Default     string
+	Description string
+	set         func(c *Config, value string) error
+}
+
+// ValidationError は不正な設定値の情報を保持する
+type ValidationError struct {
+	Source string // 値の読み込み元（env, ファイルパスなど）
+	Key    string
+	Value  string
+	Err    error
+}
+
+func (e ValidationError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s: %v", e.Source, e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s: %s=%q: %v", e.Source, e.Key, e.Value, e.Err)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors は起動時に検出された不正な設定の一覧
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	return strings.Join(errs.Lines(), "; ")
+}
+
+// Lines は各エラーを1行ずつの文字列として返す（オーバーレイ表示用）
+func (errs ValidationErrors) Lines() []string {
+	lines := make([]string, 0, len(errs))
+	for _, e := range errs {
+		lines = append(lines, e.Error())
+	}
+	return lines
+}
+
+var (
+	ErrUnknownKey   = errors.New("unknown setting")
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// Schema は全設定項目の定義を返す
+func Schema() []Field {
+	return []Field{
+		intField("TAB_WIDTH", "tab_width", strconv.Itoa(defaultTabWidth), "タブ幅（空白の数）", 1, 16,
+			func(c *Config) *int { return &c.TabWidth }),
+		boolField("SMOOTH_SCROLL", "smooth_scroll", "true", "スムーズスクロールを有効にする",
+			func(c *Config) *bool { return &c.SmoothScroll }),
+		intField("SCROLL_STEPS", "scroll_steps", "3", "スクロール1回あたりの移動行数", 1, 100,
+			func(c *Config) *int { return &c.ScrollSteps }),
+		boolField("DEBUG", "debug", "false", "デバッグログを出力する",
+			func(c *Config) *bool { return &c.DebugMode }),
+		intField("STATUS_MESSAGE_DURATION", "status_message_duration", "5", "ステータスメッセージの表示時間（秒）", 1, 3600,
+			func(c *Config) *int { return &c.StatusMessageDuration }),
+		boolField("KILO_METRICS_ENABLED", "metrics_enabled", "false", "パフォーマンスメトリクスを記録する",
+			func(c *Config) *bool { return &c.MetricsEnabled }),
+		boolField("GOIMPORTS_ON_SAVE", "goimports_on_save", "true", "Goファイルの保存時にgoimportsで整形する",
+			func(c *Config) *bool { return &c.GoImportsOnSave }),
+		boolField("TRIM_TRAILING_WHITESPACE", "trim_trailing_whitespace", "false", "保存時に各行の末尾の空白を取り除く",
+			func(c *Config) *bool { return &c.TrimTrailingWhitespace }),
+		serversField("FORMAT_ON_SAVE", "format_on_save", "", "ファイルの種類ごとに保存時に内容を標準入力に渡し、成功すれば標準出力で置き換える整形コマンド（例: Python=black -q -;JavaScript=prettier --stdin-filepath {file}。{file} は保存するファイルのパス。Go を指定すると goimports の代わりに使う）",
+			func(c *Config) *string { return &c.FormatOnSave }),
+		boolField("TERMINAL_TITLE", "terminal_title", "true", "端末タイトルにファイル名を表示する",
+			func(c *Config) *bool { return &c.TerminalTitle }),
+		boolField("HYPERLINKS", "hyperlinks", "true", "URLを端末のハイパーリンク（OSC 8）として表示する",
+			func(c *Config) *bool { return &c.Hyperlinks }),
+		boolField("WORD_WRAP", "word_wrap", "false", "長い行を横にスクロールせず折り返して表示する",
+			func(c *Config) *bool { return &c.WordWrap }),
+		listField("STATUS_SEGMENTS", "status_segments", "name,dirty,search,branch,filetype,encoding,eol,position,percent", "ステータスバーに表示する項目（カンマ区切り。name / dirty は左端、それ以外は右端に並べる）",
+			[]string{"name", "dirty", "search", "branch", "filetype", "encoding", "eol", "position", "percent"},
+			func(c *Config) *string { return &c.StatusSegments }),
+		choiceField("THEME", "theme", "auto", "描画に使うテーマ（auto / default / high-contrast / monochrome / dusk。auto は8色以下の端末で monochrome を使う。dusk は RGB の色を端末の色数に合わせて描画する）", []string{"auto", "default", "high-contrast", "monochrome", "dusk"},
+			func(c *Config) *string { return &c.Theme }),
+		choiceField("COLOR_DEPTH", "color_depth", "auto", "端末の色数（auto / truecolor / 256 / 16 / 8 / none。auto は COLORTERM・terminfo・TERM から判定する）", []string{"auto", "truecolor", "256", "16", "8", "none"},
+			func(c *Config) *string { return &c.ColorDepth }),
+		stringField("WELCOME_MESSAGE", "welcome_message", "Kilo editor -- version 1.0", "空のバッファの中央に表示するメッセージ（全角文字は表示幅で中央に揃える）",
+			func(c *Config) *string { return &c.WelcomeMessage }),
+		boolField("TAB_BAR", "tab_bar", "true", "複数のバッファを開いている場合に、画面の上端にバッファのタブを表示する",
+			func(c *Config) *bool { return &c.TabBar }),
+		boolField("AUTO_PAIR", "auto_pair", "true", "開き括弧・引用符を入力すると閉じる文字を補い、閉じる文字の入力ではカーソルを進める",
+			func(c *Config) *bool { return &c.AutoPair }),
+		choiceField("KEY_PRECEDENCE", "key_precedence", "project", "プロジェクト設定の割り当てが既定の割り当てと食い違う場合に優先する方（project / default）", []string{KeyPrecedenceProject, KeyPrecedenceDefault},
+			func(c *Config) *string { return &c.KeyPrecedence }),
+		boolField("PERSISTENT_UNDO", "persistent_undo", "true", "保存時に編集履歴を残し、次に同じファイルを開いたときに取り消せるようにする",
+			func(c *Config) *bool { return &c.PersistentUndo }),
+		boolField("RESUME_SESSION", "resume_session", "false", "ファイルを指定せずに起動した場合に、前回の終了時に開いていたファイルとカーソルの位置を復元する（--resume と同じ）",
+			func(c *Config) *bool { return &c.ResumeSession }),
+		boolField("REMEMBER_POSITION", "remember_position", "true", "ファイルごとに閉じたときのカーソル位置を記録し、次に同じファイルを開いたときにその位置へ移動する",
+			func(c *Config) *bool { return &c.RememberPosition }),
+		stringField("ELEVATE_COMMAND", "elevate_command", "sudo tee", "書き込み権限がなく保存できない場合に、確認のうえ内容を標準入力として渡すコマンド（-- と保存先のパスを最後の引数に加える）",
+			func(c *Config) *string { return &c.ElevateCommand }),
+		serversField("LANGUAGE_SERVERS", "language_servers", "", "ファイルの種類ごとに起動する言語サーバー（例: Go=gopls;Python=pylsp。設定ファイルでは種類をキーにしたオブジェクトでも指定できる。空なら起動しない）",
+			func(c *Config) *string { return &c.LanguageServers }),
+		boolField("GIT_SIGNS", "git_signs", "true", "git リポジトリ内のファイルで、保存した内容の HEAD からの変更（追加 + / 変更 ~ / 削除 -）を本文の左に、現在のブランチをステータスバーに表示する",
+			func(c *Config) *bool { return &c.GitSigns }),
+		choiceField("SPELL_CHECK", "spell_check", "off", "Markdown とテキストのファイルで綴りの誤りに下線を引く（off / auto / aspell / wordlist。auto は aspell があれば aspell、なければ SPELL_DICTIONARY の単語の一覧を使う）", []string{"off", "auto", "aspell", "wordlist"},
+			func(c *Config) *string { return &c.SpellCheck }),
+		stringField("SPELL_DICTIONARY", "spell_dictionary", "/usr/share/dict/words", "綴りの確認に使う単語の一覧のファイル（1行に1語。hunspell の .dic も読めるが接辞は展開しない）",
+			func(c *Config) *string { return &c.SpellDictionary }),
+		stringField("SPELL_LANGUAGE", "spell_language", "en", "aspell で使う辞書の言語（例: en_US / en_GB）",
+			func(c *Config) *string { return &c.SpellLanguage }),
+		boolField("READAHEAD", "readahead", "true", "1MB以上のファイルを開く際にカーネルへ先読みを指示する（Linux のみ）",
+			func(c *Config) *bool { return &c.Readahead }),
+		choiceField("GREP_BACKEND", "grep_backend", "auto", "プロジェクト検索に使う実装（auto / ripgrep / builtin。ripgrep が見つからなければ builtin を使う）", []string{"auto", "ripgrep", "builtin"},
+			func(c *Config) *string { return &c.GrepBackend }),
+		intField("UNSAVED_REMINDER_MINUTES", "unsaved_reminder_minutes", "0", "未保存状態がこの分数続いたら保存を促す（0で無効）", 0, 1440,
+			func(c *Config) *int { return &c.UnsavedReminderMinutes }),
+		intField("RECOVERY_INTERVAL", "recovery_interval", "30", "編集が続いても未保存の変更を復元用ファイルに書き出すまでの最長の秒数（0で自動の書き出しを無効）", 0, 3600,
+			func(c *Config) *int { return &c.RecoveryInterval }),
+		intField("RECOVERY_IDLE", "recovery_idle", "3", "一連の編集が止まってから未保存の変更を書き出すまでの秒数", 1, 3600,
+			func(c *Config) *int { return &c.RecoveryIdle }),
+		intField("RECOVERY_MIN_INTERVAL", "recovery_min_interval", "10", "編集の区切りでの書き出しの最短間隔（秒）", 0, 3600,
+			func(c *Config) *int { return &c.RecoveryMinInterval }),
+		choiceField("BACKUP", "backup", "dir", "保存で上書きする前の内容のバックアップ先（dir: 状態ディレクトリの backup/ / tilde: 同じディレクトリの「ファイル名~」/ off: バックアップしない）", []string{"dir", "tilde", "off"},
+			func(c *Config) *string { return &c.Backup }),
+		intField("BACKUP_KEEP", "backup_keep", "10", "ファイルごとに残すバックアップの件数（0で件数による保持なし）", 0, 1000,
+			func(c *Config) *int { return &c.BackupKeep }),
+		intField("BACKUP_MAX_AGE_DAYS", "backup_max_age_days", "30", "この日数より新しいバックアップは件数に関わらず残す（BACKUP_KEEP と共に0なら削除しない）", 0, 3650,
+			func(c *Config) *int { return &c.BackupMaxAgeDays }),
+		choiceField("EVENT_TRACE", "event_trace", "off", "イベントバスの記録を終了時に書き出す形式（off / json / mermaid）", []string{"off", "json", "mermaid"},
+			func(c *Config) *string { return &c.EventTrace }),
+	}
+}
+
+// FilePath は構造化設定ファイルのパスを返す
+// KILO_CONFIG が指定されていればそれを優先し、なければユーザー設定ディレクトリ配下を使用する
+func FilePath() string {
+	if path := os.Getenv("KILO_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-kilo", "config.json")
+}
+
+// Document は全設定項目とデフォルト値の説明を書き出す
+func Document(w io.Writer) error {
+	for _, f := range Schema() {
+		if _, err := fmt.Fprintf(w, "%-24s %-24s %-6s default=%-6s %s\n", f.Env, f.Key, f.Kind, f.Default, f.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEnv は環境変数の値を設定に反映する
+func applyEnv(config *Config, getenv func(string) string) ValidationErrors {
+	var errs ValidationErrors
+	for _, f := range Schema() {
+		value := getenv(f.Env)
+		if value == "" {
+			continue
+		}
+		if err := f.set(config, value); err != nil {
+			errs = append(errs, ValidationError{Source: "env", Key: f.Env, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+// applyFile はJSON形式の設定ファイルを読み込み、設定に反映する
+// ファイルが存在しない場合は何もしない
+func applyFile(config *Config, path string) ValidationErrors {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return ValidationErrors{{Source: path, Key: "(file)", Err: err}}
+	}
+	return applyJSON(config, path, data)
+}
+
+// applyJSON はJSONの内容を設定に反映する
+func applyJSON(config *Config, source string, data []byte) ValidationErrors {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ValidationErrors{{Source: source, Key: "(file)", Err: err}}
+	}
+
+	fields := make(map[string]Field)
+	for _, f := range Schema() {
+		fields[f.Key] = f
+	}
+
+	// エラーの出力順を安定させるためにキーをソートする
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs ValidationErrors
+	for _, k := range keys {
+		f, ok := fields[k]
+		if !ok {
+			errs = append(errs, ValidationError{Source: source, Key: k, Err: ErrUnknownKey})
+			continue
+		}
+		value := stringify(raw[k])
+		if err := f.set(config, value); err != nil {
+			errs = append(errs, ValidationError{Source: source, Key: k, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+// stringify はJSONの値を文字列表現に変換し、環境変数と同じ解析処理を通せるようにする
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+func intField(env, key, def, desc string, min, max int, ptr func(c *Config) *int) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindInt, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%w: expected an integer", ErrInvalidValue)
+			}
+			if n < min || n > max {
+				return fmt.Errorf("%w: must be between %d and %d", ErrInvalidValue, min, max)
+			}
+			*ptr(c) = n
+			return nil
+		},
+	}
+}
+
+func boolField(env, key, def, desc string, ptr func(c *Config) *bool) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindBool, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			*ptr(c) = b
+			return nil
+		},
+	}
+}
+
+// stringField は空でない任意の文字列を受け付ける
+func stringField(env, key, def, desc string, ptr func(c *Config) *string) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindString, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return fmt.Errorf("%w: must not be empty", ErrInvalidValue)
+			}
+			*ptr(c) = value
+			return nil
+		},
+	}
+}
+
+func choiceField(env, key, def, desc string, choices []string, ptr func(c *Config) *string) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindString, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			value = strings.ToLower(strings.TrimSpace(value))
+			for _, choice := range choices {
+				if value == choice {
+					*ptr(c) = value
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: expected one of %s", ErrInvalidValue, strings.Join(choices, ", "))
+		},
+	}
+}
+
+// listField はカンマ区切りの項目の並びを受け付ける。設定ファイルでは文字列の配列でも指定できる
+func listField(env, key, def, desc string, choices []string, ptr func(c *Config) *string) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindString, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			items := strings.Split(value, ",")
+			if strings.HasPrefix(strings.TrimSpace(value), "[") {
+				if err := json.Unmarshal([]byte(value), &items); err != nil {
+					return fmt.Errorf("%w: expected a list", ErrInvalidValue)
+				}
+			}
+			var list []string
+			for _, item := range items {
+				item = strings.ToLower(strings.TrimSpace(item))
+				if item == "" {
+					continue
+				}
+				if !containsString(choices, item) {
+					return fmt.Errorf("%w: %q is not one of %s", ErrInvalidValue, item, strings.Join(choices, ", "))
+				}
+				list = append(list, item)
+			}
+			if len(list) == 0 {
+				return fmt.Errorf("%w: expected at least one of %s", ErrInvalidValue, strings.Join(choices, ", "))
+			}
+			*ptr(c) = strings.Join(list, ",")
+			return nil
+		},
+	}
+}
+
+// serversField はファイルの種類ごとのコマンドの指定を受け付ける。設定ファイルでは種類をキーにしたオブジェクトでも指定できる
+// 空の場合は何も起動しない
+func serversField(env, key, def, desc string, ptr func(c *Config) *string) Field {
+	return Field{
+		Env: env, Key: key, Kind: KindString, Default: def, Description: desc,
+		set: func(c *Config, value string) error {
+			servers, err := ParseLanguageServers(value)
+			if strings.HasPrefix(strings.TrimSpace(value), "{") {
+				servers, err = make(map[string]string), nil
+				var object map[string]string
+				if json.Unmarshal([]byte(value), &object) != nil {
+					return fmt.Errorf("%w: expected an object of commands", ErrInvalidValue)
+				}
+				for name, command := range object {
+					if strings.TrimSpace(name) == "" || strings.TrimSpace(command) == "" {
+						return fmt.Errorf("%w: expected TYPE=COMMAND, got %q", ErrInvalidValue, name+"="+command)
+					}
+					servers[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(command)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			*ptr(c) = formatLanguageServers(servers)
+			return nil
+		},
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBool は真偽値を解析する（strconv.ParseBool に加えて yes/no/on/off を受け付ける）
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("%w: expected true or false", ErrInvalidValue)
+	}
+	return b, nil
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTOMLSyntax はプロジェクト設定ファイルの書式が不正な場合のエラー
+var ErrTOMLSyntax = errors.New("syntax error")
+
+// tomlDocument は TOML のテーブル名（ドット区切り、ルートは空文字）ごとのキーと値
+// 値は string, int64, bool, []interface{} のいずれか
+type tomlDocument map[string]map[string]interface{}
+
+// parseTOML はプロジェクト設定に必要な範囲の TOML を解析する
+// 対応するのはテーブル見出し、文字列・整数・真偽値・配列の値とコメントのみで、
+// インラインテーブルや日時には対応しない
+func parseTOML(data []byte) (tomlDocument, error) {
+	doc := tomlDocument{"": {}}
+	table := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, tomlError(lineNo, "invalid table header")
+			}
+			parts, err := splitKey(strings.TrimSpace(line[1 : len(line)-1]))
+			if err != nil {
+				return nil, tomlError(lineNo, err.Error())
+			}
+			table = strings.Join(parts, ".")
+			if _, ok := doc[table]; ok && len(doc[table]) > 0 {
+				return nil, tomlError(lineNo, fmt.Sprintf("table [%s] defined twice", table))
+			}
+			doc[table] = map[string]interface{}{}
+			continue
+		}
+
+		eq := indexOutsideQuotes(line, '=')
+		if eq < 0 {
+			return nil, tomlError(lineNo, "expected key = value")
+		}
+		parts, err := splitKey(strings.TrimSpace(line[:eq]))
+		if err != nil {
+			return nil, tomlError(lineNo, err.Error())
+		}
+		raw := strings.TrimSpace(line[eq+1:])
+		// 複数行にまたがる配列は閉じ括弧が現れるまで読み進める
+		for strings.HasPrefix(raw, "[") && !bracketsClosed(raw) && scanner.Scan() {
+			lineNo++
+			raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+		value, rest, err := parseValue(raw)
+		if err != nil {
+			return nil, tomlError(lineNo, err.Error())
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, tomlError(lineNo, "unexpected text after value")
+		}
+
+		// a.b = 1 のようなドット区切りのキーは [table.a] の b として扱う
+		name := strings.Join(append([]string{table}, parts[:len(parts)-1]...), ".")
+		name = strings.TrimPrefix(name, ".")
+		if doc[name] == nil {
+			doc[name] = map[string]interface{}{}
+		}
+		key := parts[len(parts)-1]
+		if _, ok := doc[name][key]; ok {
+			return nil, tomlError(lineNo, fmt.Sprintf("key %q defined twice", key))
+		}
+		doc[name][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func tomlError(line int, msg string) error {
+	return fmt.Errorf("%w: line %d: %s", ErrTOMLSyntax, line, msg)
+}
+
+// stripComment は文字列の外にある # 以降を取り除く
+func stripComment(line string) string {
+	if i := indexOutsideQuotes(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// indexOutsideQuotes は引用符の外にある最初の c の位置を返す
+func indexOutsideQuotes(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// bracketsClosed は配列の括弧が全て閉じているかどうかを返す
+func bracketsClosed(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// splitKey はドット区切りのキーを分解する。各要素は裸のキーか引用符付きの文字列
+func splitKey(s string) ([]string, error) {
+	var parts []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, errors.New("empty key")
+		}
+		var part string
+		if s[0] == '"' || s[0] == '\'' {
+			value, rest, err := parseString(s)
+			if err != nil {
+				return nil, err
+			}
+			part, s = value, rest
+		} else {
+			end := 0
+			for end < len(s) && isBareKeyChar(s[end]) {
+				end++
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid key %q", s)
+			}
+			part, s = s[:end], s[end:]
+		}
+		parts = append(parts, part)
+
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return parts, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key near %q", s)
+		}
+		s = s[1:]
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue は先頭の値を1つ解析し、残りの文字列とともに返す
+func parseValue(s string) (interface{}, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, "", errors.New("missing value")
+	}
+	switch s[0] {
+	case '"', '\'':
+		return parseString(s)
+	case '[':
+		return parseArray(s)
+	}
+
+	end := 0
+	for end < len(s) && s[end] != ',' && s[end] != ']' && s[end] != ' ' && s[end] != '\t' {
+		end++
+	}
+	token, rest := s[:end], s[end:]
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid value %q", token)
+	}
+	return n, rest, nil
+}
+
+// parseString は基本文字列（"..."）またはリテラル文字列（'...'）を解析する
+func parseString(s string) (string, string, error) {
+	quote := s[0]
+	if quote == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return sb.String(), s[i+1:], nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", errors.New("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case '"', '\\':
+				sb.WriteByte(s[i])
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u':
+				if i+4 >= len(s) {
+					return "", "", errors.New("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", "", errors.New("invalid unicode escape")
+				}
+				sb.WriteRune(rune(r))
+				i += 4
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// parseArray は [a, b, ...] 形式の配列を解析する（末尾のカンマを許す）
+func parseArray(s string) ([]interface{}, string, error) {
+	values := []interface{}{}
+	s = strings.TrimSpace(s[1:])
+	for {
+		if s == "" {
+			return nil, "", errors.New("unterminated array")
+		}
+		if s[0] == ']' {
+			return values, s[1:], nil
+		}
+		value, rest, err := parseValue(s)
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, value)
+		s = strings.TrimSpace(rest)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("expected , or ] in array")
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package annotation
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kind は注釈の種類を表す
+type Kind int
+
+const (
+	// KindURL は URL を表す注釈
+	KindURL Kind = iota
+)
+
+// Span は行内の注釈の範囲（ルーン単位の [Start, End)）
+type Span struct {
+	Start  int
+	End    int
+	Kind   Kind
+	Target string // 注釈が指す先（URL ならその URL）
+}
+
+// Contains は位置 x が範囲に含まれるかどうかを返す
+func (s Span) Contains(x int) bool {
+	return s.Start <= x && x < s.End
+}
+
+// Annotator は1行分の文字列から注釈を抽出する
+type Annotator interface {
+	Annotate(runes []rune) []Span
+}
+
+// AnnotatorFunc は関数を Annotator として扱うための型
+type AnnotatorFunc func(runes []rune) []Span
+
+// Annotate は f を呼び出す
+func (f AnnotatorFunc) Annotate(runes []rune) []Span {
+	return f(runes)
+}
+
+// Line は全ての annotators を適用し、開始位置順に並べた注釈を返す
+// 範囲が重なる場合は先に始まるものを優先する
+func Line(runes []rune, annotators ...Annotator) []Span {
+	var spans []Span
+	for _, a := range annotators {
+		spans = append(spans, a.Annotate(runes)...)
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+	merged := spans[:0]
+	end := 0
+	for _, s := range spans {
+		if s.Start < end {
+			continue
+		}
+		merged = append(merged, s)
+		end = s.End
+	}
+	return merged
+}
+
+// At は位置 x を含む注釈を返す
+func At(spans []Span, x int) (Span, bool) {
+	for _, s := range spans {
+		if s.Contains(x) {
+			return s, true
+		}
+	}
+	return Span{}, false
+}
+
+// urlSchemes は URL として認識するスキーム
+var urlSchemes = []string{"https://", "http://", "ftp://", "file://"}
+
+// URLs は行内の URL を検出する Annotator
+var URLs Annotator = AnnotatorFunc(findURLs)
+
+// findURLs はスキームで始まる URL を探す
+// 文末の句読点や、URL の外側の閉じ括弧は含めない
+func findURLs(runes []rune) []Span {
+	line := string(runes)
+	if !strings.Contains(line, "://") {
+		return nil
+	}
+
+	var spans []Span
+	for i := 0; i < len(runes); i++ {
+		// 単語の途中から始まるものは URL とみなさない（例: xhttp://）
+		if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			continue
+		}
+		scheme := schemeAt(runes, i)
+		if scheme == 0 {
+			continue
+		}
+		end := i + scheme
+		for end < len(runes) && isURLRune(runes[end]) {
+			end++
+		}
+		end = trimURL(runes, i+scheme, end)
+		if end == i+scheme {
+			continue
+		}
+		spans = append(spans, Span{Start: i, End: end, Kind: KindURL, Target: string(runes[i:end])})
+		i = end - 1
+	}
+	return spans
+}
+
+// schemeAt は位置 i から始まるスキームの長さを返す（なければ 0）
+func schemeAt(runes []rune, i int) int {
+	for _, scheme := range urlSchemes {
+		n := len(scheme)
+		if i+n <= len(runes) && strings.EqualFold(string(runes[i:i+n]), scheme) {
+			return n
+		}
+	}
+	return 0
+}
+
+// isURLRune は URL に含まれうる文字かどうかを返す
+func isURLRune(r rune) bool {
+	if r > unicode.MaxASCII {
+		// 国際化ドメインやパスの非ASCII文字は許すが、全角の区切り記号は含めない
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return true
+	}
+	return strings.ContainsRune("-._~:/?#[]@!$&'()*+,;=%", r)
+}
+
+// trimURL は URL の末尾から句読点と対応の取れない閉じ括弧を取り除いた終端を返す
+func trimURL(runes []rune, start, end int) int {
+	for end > start {
+		last := runes[end-1]
+		switch {
+		case strings.ContainsRune(".,;:!?'", last):
+			end--
+		case last == ')' && unbalanced(runes[start:end], '(', ')'):
+			end--
+		case last == ']' && unbalanced(runes[start:end], '[', ']'):
+			end--
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+// unbalanced は閉じ括弧が開き括弧より多いかどうかを返す
+func unbalanced(runes []rune, open, close rune) bool {
+	depth := 0
+	for _, r := range runes {
+		switch r {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return depth < 0
+}
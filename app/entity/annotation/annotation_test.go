@@ -0,0 +1,53 @@
+package annotation
+
+import "testing"
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"単純なURL", "see https://example.com/path?q=1 for details", []string{"https://example.com/path?q=1"}},
+		{"文末の句読点を除く", "Visit http://example.com.", []string{"http://example.com"}},
+		{"外側の括弧を除く", "(https://example.com/a_(b))", []string{"https://example.com/a_(b)"}},
+		{"複数のURL", "ftp://a.example https://b.example", []string{"ftp://a.example", "https://b.example"}},
+		{"全角文字の前で終わる", "リンク：https://example.com、です", []string{"https://example.com"}},
+		{"単語の途中は無視", "xhttp://example.com", nil},
+		{"スキームだけは無視", "http:// only", nil},
+		{"URLなし", "no links here", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runes := []rune(tt.line)
+			spans := URLs.Annotate(runes)
+			if len(spans) != len(tt.want) {
+				t.Fatalf("got %+v, want %q", spans, tt.want)
+			}
+			for i, s := range spans {
+				if s.Target != tt.want[i] || string(runes[s.Start:s.End]) != tt.want[i] || s.Kind != KindURL {
+					t.Errorf("span %d = %+v, want %q", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLineAndAt(t *testing.T) {
+	other := AnnotatorFunc(func(runes []rune) []Span {
+		return []Span{{Start: 6, End: 9, Kind: Kind(99)}, {Start: 0, End: 2, Kind: Kind(99)}}
+	})
+	runes := []rune("ab go http://x.example")
+	spans := Line(runes, URLs, other)
+	if len(spans) != 2 || spans[0].Start != 0 || spans[1].Kind != KindURL {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+
+	if s, ok := At(spans, 10); !ok || s.Target != "http://x.example" {
+		t.Errorf("At(10) = %+v, %v", s, ok)
+	}
+	if _, ok := At(spans, 3); ok {
+		t.Error("At(3) must not find a span")
+	}
+}
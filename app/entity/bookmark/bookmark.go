@@ -0,0 +1,144 @@
+package bookmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// Bookmark はバッファ内の1行に付けられた目印を表す
+type Bookmark struct {
+	Line  int    // 0始まりの行番号
+	Label string // 任意のラベル
+}
+
+// Namespace はブックマークを置く行のメタデータの名前空間
+const Namespace = "bookmark"
+
+// Lines はブックマークを置くバッファの行ごとのメタデータ（contents.Contents が実装する）
+type Lines interface {
+	RegisterLineMeta(namespace string, policy contents.MetaPolicy)
+	SetLineMeta(namespace string, line int, value interface{})
+	LineMeta(namespace string, line int) (interface{}, bool)
+	DeleteLineMeta(namespace string, line int)
+	ClearLineMeta(namespace string)
+	LineMetaLines(namespace string) []int
+}
+
+// Bookmarks はバッファごとのブックマーク集合を管理する構造体
+// ブックマークはバッファの行のメタデータとして持つので、行の挿入・削除には自動で追従し、行を削除すると消える
+type Bookmarks struct {
+	lines Lines
+}
+
+// New は lines の行に置くブックマーク集合を作成する
+func New(lines Lines) *Bookmarks {
+	// 行を編集してもブックマークは残す
+	lines.RegisterLineMeta(Namespace, contents.MetaKeepOnEdit)
+	return &Bookmarks{lines: lines}
+}
+
+// Toggle は指定行のブックマークを切り替え、設定された場合はtrueを返す
+func (b *Bookmarks) Toggle(line int, label string) bool {
+	if b.Has(line) {
+		b.lines.DeleteLineMeta(Namespace, line)
+		return false
+	}
+	b.Set(line, label)
+	return b.Has(line)
+}
+
+// Set は指定行にブックマークを設定する（既存のラベルは上書きする）。バッファの範囲外の行は無視する
+func (b *Bookmarks) Set(line int, label string) {
+	b.lines.SetLineMeta(Namespace, line, label)
+}
+
+// Has は指定行にブックマークがあるかどうかを返す
+func (b *Bookmarks) Has(line int) bool {
+	_, ok := b.lines.LineMeta(Namespace, line)
+	return ok
+}
+
+// Clear は全てのブックマークを削除する
+func (b *Bookmarks) Clear() {
+	b.lines.ClearLineMeta(Namespace)
+}
+
+// Len はブックマーク数を返す
+func (b *Bookmarks) Len() int {
+	return len(b.lines.LineMetaLines(Namespace))
+}
+
+// List は行番号順に並べたブックマークの一覧を返す
+func (b *Bookmarks) List() []Bookmark {
+	lines := b.lines.LineMetaLines(Namespace)
+	list := make([]Bookmark, 0, len(lines))
+	for _, line := range lines {
+		value, _ := b.lines.LineMeta(Namespace, line)
+		label, _ := value.(string)
+		list = append(list, Bookmark{Line: line, Label: label})
+	}
+	return list
+}
+
+// Export はブックマークを "path:line:label" 形式で書き出す（行番号は1始まり）
+func Export(w io.Writer, path string, marks []Bookmark) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range marks {
+		label := strings.ReplaceAll(m.Label, "\n", " ")
+		if _, err := fmt.Fprintf(bw, "%s:%d:%s\n", path, m.Line+1, label); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Import は "path:line:label" 形式のデータを読み込む
+// path が空でない場合は、そのファイルに対するエントリのみを返す
+// 空行と '#' で始まる行は無視する
+func Import(r io.Reader, path string) ([]Bookmark, error) {
+	var marks []Bookmark
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		entryPath, line, label, err := parseEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if path != "" && filepath.Clean(entryPath) != filepath.Clean(path) {
+			continue
+		}
+		marks = append(marks, Bookmark{Line: line - 1, Label: label})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return marks, nil
+}
+
+// parseEntry は1エントリを解析する
+// ラベルはコロンや数字を含めてそのまま書き出すため、行番号は前方から最初に数字だけの区切りを探す
+// （パスにもコロンは含められるが、「:数字:」を含むパスは正しく読み込めない）
+func parseEntry(text string) (string, int, string, error) {
+	parts := strings.Split(text, ":")
+	for i := 1; i < len(parts); i++ {
+		line, err := strconv.Atoi(parts[i])
+		if err != nil || line < 1 {
+			continue
+		}
+		path := strings.Join(parts[:i], ":")
+		label := strings.Join(parts[i+1:], ":")
+		return path, line, label, nil
+	}
+	return "", 0, "", fmt.Errorf("malformed bookmark entry: %q", text)
+}
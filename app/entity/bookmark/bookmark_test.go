@@ -0,0 +1,97 @@
+package bookmark
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// newLines は n 行のバッファを作成する
+func newLines(n int) *contents.Contents {
+	c := contents.NewContents(logger.New(false))
+	c.LoadContent(make([]string, n))
+	return c
+}
+
+func TestBookmarks_ToggleAndList(t *testing.T) {
+	b := New(newLines(6))
+	if !b.Toggle(3, "todo") {
+		t.Fatalf("expected bookmark to be set")
+	}
+	b.Set(1, "first")
+	if b.Toggle(3, "") {
+		t.Fatalf("expected bookmark to be removed")
+	}
+	b.Set(5, "last")
+	b.Set(6, "out of range")
+
+	list := b.List()
+	if len(list) != 2 || list[0] != (Bookmark{Line: 1, Label: "first"}) || list[1] != (Bookmark{Line: 5, Label: "last"}) {
+		t.Errorf("unexpected list: %v", list)
+	}
+}
+
+func TestBookmarks_FollowEdits(t *testing.T) {
+	lines := newLines(6)
+	b := New(lines)
+	b.Set(0, "a")
+	b.Set(2, "b")
+	b.Set(5, "c")
+
+	// 行の挿入で後続行が下がり、内容を編集してもブックマークは残る
+	if err := lines.InsertNewline(contents.Position{X: 0, Y: 2}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := lines.InsertChar(contents.Position{X: 0, Y: 3}, 'x'); err != nil {
+		t.Fatal(err)
+	}
+	// 前の行に結合して削除した行のブックマークは消える
+	if err := lines.DeleteChar(contents.Position{X: 0, Y: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := b.List()
+	want := []Bookmark{{Line: 0, Label: "a"}, {Line: 3, Label: "b"}}
+	if len(list) != len(want) || list[0] != want[0] || list[1] != want[1] {
+		t.Errorf("got %v, want %v", list, want)
+	}
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	marks := []Bookmark{{Line: 0, Label: "header"}, {Line: 41, Label: "check: error path"}, {Line: 9, Label: "step:2"}}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, "src/main.go", marks); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if got := buf.String(); got != "src/main.go:1:header\nsrc/main.go:42:check: error path\nsrc/main.go:10:step:2\n" {
+		t.Errorf("unexpected export output: %q", got)
+	}
+
+	imported, err := Import(&buf, "./src/main.go")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if !reflect.DeepEqual(imported, marks) {
+		t.Errorf("a label containing \":<digits>\" must round-trip: %v", imported)
+	}
+}
+
+func TestImport_FiltersAndErrors(t *testing.T) {
+	input := "# review checklist\n\na.txt:3:x\nb.txt:4:y\nC:\\work\\a.txt:7:win\n"
+	marks, err := Import(strings.NewReader(input), "a.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(marks) != 1 || marks[0].Line != 2 {
+		t.Errorf("unexpected marks: %v", marks)
+	}
+
+	if _, err := Import(strings.NewReader("no line number here\n"), ""); err == nil {
+		t.Errorf("expected error for malformed entry")
+	}
+}
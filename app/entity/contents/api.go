@@ -0,0 +1,48 @@
+package contents
+
+// APIVersion は外部のツール（スクリプト、プラグイン、テスト）向けの編集 API（API と RowAPI）の版
+// セマンティックバージョニングに従い、メジャーバージョンが 1 の間はメソッドの削除やシグネチャの変更を行わない
+// メソッドを追加した場合はマイナーバージョンを、動作の修正だけの場合はパッチバージョンを上げる
+const APIVersion = "1.0.0"
+
+// API は Contents の安定した編集 API（v1）
+// 位置は Position（行・列ともに 0 始まりで、列はルーン単位）、範囲は start から end の手前まで [start, end) で表す
+// 範囲外の位置はバッファの範囲に丸める。編集できない行を含む編集は何も変更せずに ErrReadOnly を返す
+// ここに含まれない Contents のメソッドはエディタ内部用で、予告なく変更することがある
+type API interface {
+	// 読み取り
+	GetLineCount() int
+	GetContentLine(lineNum int) string
+	GetAllLines() []string
+	GetRow(y int) *Row
+	TextRange(start, end Position) []string
+	Snapshot() *Snapshot
+	Version() uint64
+	IsDirty() bool
+	IsReadOnly(line int) bool
+
+	// 編集
+	InsertText(pos Position, text []string) (Position, error)
+	DeleteRange(start, end Position) ([]string, error)
+	ReplaceAll(reps []Replacement) ([]LineChange, error)
+
+	// トランザクションと変更の監視
+	Transaction(fn func(tx *Tx) error) error
+	Observe(fn func(Change)) (cancel func())
+}
+
+// RowAPI は Row の安定した API（v1）。Row は1行分の読み取り専用の表示情報として扱う
+type RowAPI interface {
+	GetContent() string
+	GetRuneCount() int
+	GetRunes() []rune
+	GetRuneAt(offset int) (rune, bool)
+	GetRuneWidth(offset int) int
+	ScreenPositionToOffset(screenPos int) int
+	OffsetToScreenPosition(offset int) int
+}
+
+var (
+	_ API    = (*Contents)(nil)
+	_ RowAPI = (*Row)(nil)
+)
@@ -0,0 +1,127 @@
+package contents
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+// methodSignatures はインターフェースのメソッド名とシグネチャの対応を返す
+func methodSignatures(iface reflect.Type) map[string]string {
+	sigs := make(map[string]string)
+	for i := 0; i < iface.NumMethod(); i++ {
+		m := iface.Method(i)
+		sigs[m.Name] = m.Type.String()
+	}
+	return sigs
+}
+
+// v1 の API を変更するとこのテストが失敗する。互換性を壊す変更はメジャーバージョンを上げてから行うこと
+// メソッドを追加した場合は APIVersion のマイナーバージョンを上げ、ここに追記する
+func TestAPI_V1Compatibility(t *testing.T) {
+	if APIVersion != "1.0.0" {
+		t.Fatalf("update the expected signatures for %s", APIVersion)
+	}
+
+	wantAPI := map[string]string{
+		"GetLineCount":   "func() int",
+		"GetContentLine": "func(int) string",
+		"GetAllLines":    "func() []string",
+		"GetRow":         "func(int) *contents.Row",
+		"TextRange":      "func(contents.Position, contents.Position) []string",
+		"Snapshot":       "func() *contents.Snapshot",
+		"Version":        "func() uint64",
+		"IsDirty":        "func() bool",
+		"IsReadOnly":     "func(int) bool",
+		"InsertText":     "func(contents.Position, []string) (contents.Position, error)",
+		"DeleteRange":    "func(contents.Position, contents.Position) ([]string, error)",
+		"ReplaceAll":     "func([]contents.Replacement) ([]contents.LineChange, error)",
+		"Transaction":    "func(func(*contents.Tx) error) error",
+		"Observe":        "func(func(contents.Change)) func()",
+	}
+	if got := methodSignatures(reflect.TypeOf((*API)(nil)).Elem()); !reflect.DeepEqual(got, wantAPI) {
+		t.Errorf("API changed:\n got %v\nwant %v", got, wantAPI)
+	}
+
+	wantRow := map[string]string{
+		"GetContent":             "func() string",
+		"GetRuneCount":           "func() int",
+		"GetRunes":               "func() []int32",
+		"GetRuneAt":              "func(int) (int32, bool)",
+		"GetRuneWidth":           "func(int) int",
+		"ScreenPositionToOffset": "func(int) int",
+		"OffsetToScreenPosition": "func(int) int",
+	}
+	if got := methodSignatures(reflect.TypeOf((*RowAPI)(nil)).Elem()); !reflect.DeepEqual(got, wantRow) {
+		t.Errorf("RowAPI changed:\n got %v\nwant %v", got, wantRow)
+	}
+
+	// API で使う値の型のフィールドも互換性の対象
+	for typ, want := range map[reflect.Type][]string{
+		reflect.TypeOf(Position{}):    {"X int", "Y int"},
+		reflect.TypeOf(Replacement{}): {"Line int", "Col int", "Length int", "Text string"},
+		reflect.TypeOf(LineChange{}):  {"Line int", "Old string", "New string"},
+		reflect.TypeOf(Change{}):      {"Version uint64"},
+	} {
+		var got []string
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			got = append(got, f.Name+" "+f.Type.String())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s fields changed: got %v, want %v", typ.Name(), got, want)
+		}
+	}
+}
+
+func TestTransaction_RollbackAndSingleNotification(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"hello", "world"})
+
+	var changes []Change
+	cancel := c.Observe(func(ch Change) { changes = append(changes, ch) })
+
+	// 途中でエラーになったトランザクションは全ての編集を元に戻す
+	errStop := errors.New("stop")
+	err := c.Transaction(func(tx *Tx) error {
+		if _, err := tx.InsertText(Position{X: 5, Y: 0}, []string{",", "big"}); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteRange(Position{X: 0, Y: 2}, Position{X: 1, Y: 2}); err != nil {
+			return err
+		}
+		if _, err := tx.ReplaceAll([]Replacement{{Line: 0, Col: 0, Length: 1, Text: "J"}}); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected the error from fn, got %v", err)
+	}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, []string{"hello", "world"}) || c.IsDirty() {
+		t.Errorf("the transaction must be rolled back: %q dirty=%v", got, c.IsDirty())
+	}
+	if len(changes) != 1 || changes[0].Version != c.Version() {
+		t.Errorf("expected a single notification, got %+v", changes)
+	}
+
+	// 成功したトランザクションの編集は残る
+	changes = nil
+	c.Transaction(func(tx *Tx) error {
+		tx.InsertText(Position{X: 5, Y: 1}, []string{"!"})
+		tx.InsertText(Position{X: 0, Y: 0}, []string{">"})
+		return nil
+	})
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, []string{">hello", "world!"}) || len(changes) != 1 {
+		t.Errorf("unexpected state: %q, %d notifications", got, len(changes))
+	}
+
+	// 登録を解除すると通知されない
+	cancel()
+	c.InsertText(Position{}, []string{"x"})
+	if len(changes) != 1 {
+		t.Errorf("cancelled observer must not be notified: %d", len(changes))
+	}
+}
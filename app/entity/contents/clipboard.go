@@ -0,0 +1,25 @@
+package contents
+
+// SetClipboard はコピー・切り取りした文字列を内部のクリップボードに保存する
+// text は行ごとの文字列で、2行以上なら改行を含む。ファイルを開き直しても内容は残る
+func (b *Contents) SetClipboard(text []string) {
+	b.clipboard = append([]string{}, text...)
+	b.clipboardBlock = false
+}
+
+// SetBlockClipboard は矩形選択からコピー・切り取りした文字列を内部のクリップボードに保存する
+// text は上の行から順に各行の選択部分で、貼り付けるときは同じ桁に揃えて続く行に挿入する
+func (b *Contents) SetBlockClipboard(text []string) {
+	b.clipboard = append([]string{}, text...)
+	b.clipboardBlock = true
+}
+
+// Clipboard は内部のクリップボードの内容を返す
+func (b *Contents) Clipboard() []string {
+	return append([]string{}, b.clipboard...)
+}
+
+// ClipboardIsBlock はクリップボードの内容が矩形選択から保存したものかどうかを返す
+func (b *Contents) ClipboardIsBlock() bool {
+	return b.clipboardBlock
+}
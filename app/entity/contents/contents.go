@@ -2,6 +2,7 @@ package contents
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/wasya-io/go-kilo/app/entity/core"
 )
@@ -10,9 +11,33 @@ type (
 	// Contents はテキストバッファを管理する構造体
 	Contents struct {
 		logger   core.Logger
-		lines    []string
+		lines    gapBuffer
 		isDirty  bool
 		rowCache map[int]*Row
+
+		regions      []*Region // 編集できない範囲
+		nextRegionID int
+		readOnly     bool       // バッファ全体が編集できない
+		fileType     string     // ファイル名から判定した種類の代わりに使う種類
+		lineEnding   LineEnding // 保存する際の改行コード
+		encoding     Encoding   // 保存する際の文字コード
+
+		mu      sync.Mutex // lines の差し替えとスナップショットの取得を保護する
+		shared  bool       // lines の配列をスナップショットと共有している
+		version uint64     // 内容を変更するたびに増える版
+
+		txDepth       int  // 実行中のトランザクションの入れ子の深さ
+		pendingNotify bool // トランザクションの終わりに変更を通知する
+
+		obsMu          sync.Mutex // observers を保護する
+		observers      map[int]func(Change)
+		nextObserverID int
+
+		metaMu sync.Mutex           // meta を保護する
+		meta   map[string]*lineMeta // 名前空間ごとの行のメタデータ
+
+		clipboard      []string // コピー・切り取りした文字列
+		clipboardBlock bool     // clipboard は矩形選択から保存した
 	}
 
 	ContentsState struct {
@@ -26,7 +51,7 @@ type (
 func NewContents(logger core.Logger) *Contents {
 	return &Contents{
 		logger:   logger,
-		lines:    make([]string, 0),
+		lines:    newGapBuffer(nil),
 		isDirty:  false,
 		rowCache: make(map[int]*Row),
 		// eventManager: eventManager,
@@ -35,70 +60,98 @@ func NewContents(logger core.Logger) *Contents {
 
 // LoadContent はバッファに内容をロードする
 func (b *Contents) LoadContent(lines []string) {
+	b.beginEdit()
+	defer b.endEdit()
+
 	// prevState := b.getCurrentState()
 
-	b.lines = lines
+	b.lines = newGapBuffer(lines)
 	b.isDirty = false
 	b.rowCache = make(map[int]*Row)
+	b.clearLines()
+}
+
+// AppendLines は末尾に行を追加する。ファイルの残りを後から読み込む場合に使うため、ダーティフラグは変更しない
+// 内部用: 安定 API（API）には含まれない
+func (b *Contents) AppendLines(lines []string) {
+	b.beginEdit()
+	defer b.endEdit()
+
+	b.lines.Insert(b.lines.Len(), lines...)
 }
 
 // GetContentLine は指定行の内容を取得する
 func (b *Contents) GetContentLine(lineNum int) string {
-	if lineNum >= 0 && lineNum < len(b.lines) {
-		return b.lines[lineNum]
+	if lineNum >= 0 && lineNum < b.lines.Len() {
+		return b.lines.Get(lineNum)
 	}
 	return ""
 }
 
 // GetAllLines はバッファの全内容を[]string形式で取得する
 func (b *Contents) GetAllLines() []string {
-	return append([]string{}, b.lines...)
+	return b.lines.Lines()
 }
 
 // InsertChar は指定位置に文字を挿入する
-func (b *Contents) InsertChar(pos Position, ch rune) {
+// 編集できない行の場合は何もせずに ErrReadOnly を返す
+func (b *Contents) InsertChar(pos Position, ch rune) error {
+	b.beginEdit()
+	defer b.endEdit()
+
+	if b.IsReadOnly(pos.Y) {
+		return ErrReadOnly
+	}
 	// prevState := b.getCurrentState()
 
 	// 空のバッファの場合、最初の行を作成
-	if len(b.lines) == 0 {
-		b.lines = append(b.lines, "")
+	if b.lines.Len() == 0 {
+		b.lines.Insert(0, "")
 		b.rowCache = make(map[int]*Row)
 	}
 
 	// 指定位置の行のRowオブジェクトを取得
 	row := b.GetRow(pos.Y)
 	if row == nil {
-		return
+		return nil
 	}
 
 	// 文字を挿入
 	row.InsertChar(pos.X, ch)
-	b.lines[pos.Y] = row.GetContent()
+	b.lines.Set(pos.Y, row.GetContent())
 	delete(b.rowCache, pos.Y)
+	b.touchLine(pos.Y)
 	b.isDirty = true
 
 	b.logger.Log("edit", fmt.Sprintf("character inserted: %c on %d,%d(x,y)", ch, pos.X, pos.Y))
-
+	return nil
 }
 
 // InsertChars は複数の文字を一度に挿入する
-func (b *Contents) InsertChars(pos Position, chars []rune) {
+// 編集できない行の場合は何もせずに ErrReadOnly を返す
+func (b *Contents) InsertChars(pos Position, chars []rune) error {
+	b.beginEdit()
+	defer b.endEdit()
+
 	if len(chars) == 0 {
-		return
+		return nil
+	}
+	if b.IsReadOnly(pos.Y) {
+		return ErrReadOnly
 	}
 
 	// prevState := b.getCurrentState()
 
 	// 空のバッファの場合、最初の行を作成
-	if len(b.lines) == 0 {
-		b.lines = append(b.lines, "")
+	if b.lines.Len() == 0 {
+		b.lines.Insert(0, "")
 		b.rowCache = make(map[int]*Row)
 	}
 
 	// 指定位置の行のRowオブジェクトを取得
 	row := b.GetRow(pos.Y)
 	if row == nil {
-		return
+		return nil
 	}
 
 	// すべての文字を指定位置の行に挿入
@@ -108,18 +161,27 @@ func (b *Contents) InsertChars(pos Position, chars []rune) {
 	}
 
 	// 行の内容を更新
-	b.lines[pos.Y] = row.GetContent()
+	b.lines.Set(pos.Y, row.GetContent())
 	delete(b.rowCache, pos.Y)
+	b.touchLine(pos.Y)
 	b.isDirty = true
 
 	// 一度だけイベントを発行
 	// b.publishBufferEvent(events.BufferContentChanged, pos, chars, prevState)
+	return nil
 }
 
 // DeleteChar は指定位置の文字を削除する
-func (b *Contents) DeleteChar(pos Position) {
-	if len(b.lines) == 0 || pos.Y >= len(b.lines) {
-		return
+// 行頭では前の行と結合するため、どちらかの行が編集できない場合は何もせずに ErrReadOnly を返す
+func (b *Contents) DeleteChar(pos Position) error {
+	b.beginEdit()
+	defer b.endEdit()
+
+	if b.lines.Len() == 0 || pos.Y >= b.lines.Len() {
+		return nil
+	}
+	if b.IsReadOnly(pos.Y) || (pos.X == 0 && pos.Y > 0 && b.IsReadOnly(pos.Y-1)) {
+		return ErrReadOnly
 	}
 
 	// prevState := b.getCurrentState()
@@ -128,22 +190,22 @@ func (b *Contents) DeleteChar(pos Position) {
 	if pos.X == 0 {
 		if pos.Y > 0 {
 			// 前の行に結合する処理
-			prevLine := b.lines[pos.Y-1]
-			currLine := b.lines[pos.Y]
+			prevLine := b.lines.Get(pos.Y - 1)
+			currLine := b.lines.Get(pos.Y)
 
 			// 行を結合（現在の行が空でない場合のみ）
 			if currLine != "" {
-				b.lines[pos.Y-1] = prevLine + currLine
+				b.lines.Set(pos.Y-1, prevLine+currLine)
 			}
 
-			// 現在の行より後ろの行をすべて1つ前にシフト
-			copy(b.lines[pos.Y:], b.lines[pos.Y+1:])
-			// スライスの長さを1つ減らす
-			b.lines = b.lines[:len(b.lines)-1]
+			// 現在の行を取り除き、後ろの行を1つ前に詰める
+			b.lines.Delete(pos.Y, pos.Y+1)
 
 			// キャッシュをクリア
-			for i := pos.Y - 1; i < len(b.lines); i++ {
-				delete(b.rowCache, i)
+			b.invalidateFrom(pos.Y - 1)
+			b.shiftLines(pos.Y+1, -1)
+			if currLine != "" {
+				b.touchLine(pos.Y - 1)
 			}
 			b.isDirty = true
 		}
@@ -152,30 +214,43 @@ func (b *Contents) DeleteChar(pos Position) {
 		row := b.GetRow(pos.Y)
 		if row != nil && pos.X > 0 {
 			row.DeleteChar(pos.X - 1)
-			b.lines[pos.Y] = row.GetContent()
+			b.lines.Set(pos.Y, row.GetContent())
 			delete(b.rowCache, pos.Y)
+			b.touchLine(pos.Y)
 			b.isDirty = true
 		}
 	}
 
 	// イベントを発行
 	// b.publishBufferEvent(events.BufferContentChanged, pos, nil, prevState)
+	return nil
 }
 
 // InsertNewline は指定位置で改行を挿入する
-func (b *Contents) InsertNewline(pos Position, indentSize int) {
+// 編集できない行の内容を分割する場合は何もせずに ErrReadOnly を返す
+func (b *Contents) InsertNewline(pos Position, indentSize int) error {
+	b.beginEdit()
+	defer b.endEdit()
+
+	if b.readOnly {
+		return ErrReadOnly
+	}
+
 	// prevState := b.getCurrentState()
 
 	// 空のバッファの場合、新しい行を追加
-	if len(b.lines) == 0 {
-		b.lines = append(b.lines, "", "")
+	if b.lines.Len() == 0 {
+		b.lines.Insert(0, "", "")
 		b.isDirty = true
 		// イベントを発行
 		// b.publishBufferEvent(events.BufferStructuralChange, pos, nil, prevState)
-		return
+		return nil
+	}
+	if err := b.checkNewline(pos); err != nil {
+		return err
 	}
 
-	currentLine := b.lines[pos.Y]
+	currentLine := b.lines.Get(pos.Y)
 	currentRunes := []rune(currentLine)
 
 	// 現在の行を分割
@@ -191,7 +266,7 @@ func (b *Contents) InsertNewline(pos Position, indentSize int) {
 	}
 
 	// 元の行を更新
-	b.lines[pos.Y] = firstPart
+	b.lines.Set(pos.Y, firstPart)
 
 	// 引数から受け取ったインデントサイズに基づいてインデントを構築
 	indentation := ""
@@ -199,32 +274,35 @@ func (b *Contents) InsertNewline(pos Position, indentSize int) {
 		indentation += " " // スペース文字でインデント
 	}
 
-	// 新しい行を挿入するためのスペースを確保
-	b.lines = append(b.lines, "")
-	copy(b.lines[pos.Y+2:], b.lines[pos.Y+1:])
-
 	// 新しい行にインデントを適用してから残りの部分を追加
-	b.lines[pos.Y+1] = indentation + secondPart
+	b.lines.Insert(pos.Y+1, indentation+secondPart)
 
 	b.isDirty = true
 
 	// 関連する行のキャッシュを更新
-	for i := pos.Y; i < len(b.lines); i++ {
-		delete(b.rowCache, i)
+	b.invalidateFrom(pos.Y)
+
+	// 行頭での改行は行全体が下に移動するので、その行から始まる範囲やメタデータもずらす
+	if pos.X == 0 {
+		b.shiftLines(pos.Y, 1)
+		if indentation != "" {
+			b.touchLine(pos.Y + 1)
+		}
+	} else {
+		b.shiftLines(pos.Y+1, 1)
+		b.touchLine(pos.Y)
 	}
 
 	// 構造的な変更を通知
 	// b.publishBufferEvent(events.BufferStructuralChange, pos, nil, prevState)
 
 	b.logger.Log("edit", fmt.Sprintf("newline inserted at %d,%d with indentation size: %d", pos.X, pos.Y, indentSize))
+	return nil
 }
 
 // GetLineCount は行数を返す
 func (b *Contents) GetLineCount() int {
-	if b.lines == nil {
-		return 0
-	}
-	return len(b.lines)
+	return b.lines.Len()
 }
 
 // IsDirty は未保存の変更があるかどうかを返す
@@ -242,10 +320,14 @@ func (b *Contents) SetDirty(dirty bool) {
 }
 
 // RestoreState は以前の状態にバッファを復元する
+// 内部用: 安定 API（API）には含まれない
 func (b *Contents) RestoreState(state interface{}) error {
 	fmt.Printf("Debug: Buffer.RestoreState called with state type: %T\n", state)
 
 	if bufferState, ok := state.(ContentsState); ok {
+		b.beginEdit()
+		defer b.endEdit()
+
 		fmt.Printf("Debug: Restoring buffer state: Content=%q, IsDirty=%v, Lines=%v\n",
 			bufferState.Content, bufferState.IsDirty, bufferState.Lines)
 
@@ -254,21 +336,20 @@ func (b *Contents) RestoreState(state interface{}) error {
 		// b.eventManager = nil
 
 		// バッファを完全にクリア
-		b.lines = []string{""}
+		b.lines = newGapBuffer([]string{""})
 		b.rowCache = make(map[int]*Row)
 		b.isDirty = false
 
 		// 新しい状態を直接設定
 		if len(bufferState.Lines) > 0 {
-			b.lines = make([]string, len(bufferState.Lines))
-			copy(b.lines, bufferState.Lines)
+			b.lines = newGapBuffer(append([]string{}, bufferState.Lines...))
 		} else if bufferState.Content != "" {
-			b.lines = []string{bufferState.Content}
+			b.lines = newGapBuffer([]string{bufferState.Content})
 		}
 
 		// 行キャッシュを再構築
 		b.rowCache = make(map[int]*Row)
-		for i := range b.lines {
+		for i := 0; i < b.lines.Len(); i++ {
 			b.GetRow(i)
 		}
 
@@ -282,7 +363,7 @@ func (b *Contents) RestoreState(state interface{}) error {
 		// 	b.publishBufferEvent(events.BufferEventSetState, events.Position{}, bufferState, prevState)
 		// }
 
-		fmt.Printf("Debug: Buffer state restored. Final content: %q\n", b.lines)
+		fmt.Printf("Debug: Buffer state restored. Final content: %q\n", b.lines.Lines())
 		return nil
 	}
 	return fmt.Errorf("invalid state type for buffer restoration: %T", state)
@@ -290,7 +371,7 @@ func (b *Contents) RestoreState(state interface{}) error {
 
 // GetRow は指定された行のRowオブジェクトを取得する
 func (b *Contents) GetRow(y int) *Row {
-	if y < 0 || y >= len(b.lines) {
+	if y < 0 || y >= b.lines.Len() {
 		return nil
 	}
 
@@ -298,38 +379,42 @@ func (b *Contents) GetRow(y int) *Row {
 		return row
 	}
 
-	row := NewRow(b.lines[y])
+	row := NewRow(b.lines.Get(y))
 	b.rowCache[y] = row
 	return row
 }
 
-// getCurrentState は現在のバッファ状態を取得する
+// GetCurrentState は現在のバッファ状態を取得する
+// 内部用: 安定 API（API）には含まれない
 func (b *Contents) GetCurrentState() ContentsState {
 	// 完全な状態をコピーして返す
 	state := ContentsState{
 		Content: "",
 		IsDirty: b.isDirty,
-		Lines:   make([]string, len(b.lines)),
+		Lines:   b.lines.Lines(), // すべての行を Lines フィールドにコピー
 	}
 
-	// 内容をコピー
-	if len(b.lines) > 0 {
-		state.Content = b.lines[0] // 最初の行を Content フィールドに設定
-		copy(state.Lines, b.lines) // すべての行を Lines フィールドにコピー
+	if b.lines.Len() > 0 {
+		state.Content = b.lines.Get(0) // 最初の行を Content フィールドに設定
 	}
 
 	return state
 }
 
-// resetToCleanState はバッファを初期状態にリセットする
+// Initialize はバッファを初期状態にリセットする
+// 内部用: 安定 API（API）には含まれない
 func (b *Contents) Initialize() error {
+	b.beginEdit()
+	defer b.endEdit()
+
 	// 現在の状態を保存
 	// prevState := b.getCurrentState()
 
 	// バッファを完全にリセット
-	b.lines = []string{""}
+	b.lines = newGapBuffer([]string{""})
 	b.rowCache = make(map[int]*Row)
 	b.isDirty = false
+	b.clearLines()
 
 	// リセットイベントを発行
 	// if b.eventManager != nil {
@@ -0,0 +1,20 @@
+package contents
+
+import "fmt"
+
+// ControlNotation は制御文字を端末にそのまま出力せずに表示するための表記を返す
+// C0 制御文字と DEL はキャレット表記（^L, ^[, ^@, ^?）、C1 制御文字は <9b> のような16進表記にする
+// タブは別途空白として描画するため対象外。制御文字でない場合は false を返す
+func ControlNotation(r rune) (string, bool) {
+	switch {
+	case r == '\t':
+		return "", false
+	case r < 0x20:
+		return "^" + string(r+'@'), true
+	case r == 0x7f:
+		return "^?", true
+	case 0x80 <= r && r < 0xa0:
+		return fmt.Sprintf("<%02x>", r), true
+	}
+	return "", false
+}
@@ -0,0 +1,44 @@
+package contents
+
+import "testing"
+
+func TestControlNotation(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want string
+		ok   bool
+	}{
+		{0x00, "^@", true},
+		{0x0c, "^L", true},
+		{0x1b, "^[", true},
+		{0x7f, "^?", true},
+		{0x9b, "<9b>", true},
+		{'\t', "", false},
+		{'a', "", false},
+		{'あ', "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ControlNotation(tt.r)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ControlNotation(%U) = %q, %v, want %q, %v", tt.r, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRow_ControlCharacterIsSingleUnit(t *testing.T) {
+	row := NewRow("a\x0cb")
+	if row.GetRuneWidth(1) != 2 {
+		t.Errorf("width of ^L = %d, want 2", row.GetRuneWidth(1))
+	}
+	// キャレット表記の2桁目を指しても同じ文字として扱う
+	if got := row.ScreenPositionToOffset(2); got != 1 {
+		t.Errorf("ScreenPositionToOffset(2) = %d, want 1", got)
+	}
+	if got := row.OffsetToScreenPosition(2); got != 3 {
+		t.Errorf("OffsetToScreenPosition(2) = %d, want 3", got)
+	}
+	row.DeleteChar(1)
+	if row.GetContent() != "ab" {
+		t.Errorf("unexpected content after delete: %q", row.GetContent())
+	}
+}
@@ -0,0 +1,54 @@
+package contents
+
+import "strings"
+
+// Encoding はファイルの文字コード。バッファには UTF-8 に変換した内容を保持する
+type Encoding int
+
+const (
+	EncodingUTF8     Encoding = iota // UTF-8
+	EncodingShiftJIS                 // Shift_JIS（Windows-31J）
+	EncodingEUCJP                    // EUC-JP
+	EncodingLatin1                   // ISO-8859-1
+)
+
+// Encodings は扱える文字コードの一覧
+var Encodings = []Encoding{EncodingUTF8, EncodingShiftJIS, EncodingEUCJP, EncodingLatin1}
+
+// String は文字コードの表記（UTF-8 / Shift_JIS / EUC-JP / Latin-1）を返す
+func (e Encoding) String() string {
+	switch e {
+	case EncodingShiftJIS:
+		return "Shift_JIS"
+	case EncodingEUCJP:
+		return "EUC-JP"
+	case EncodingLatin1:
+		return "Latin-1"
+	}
+	return "UTF-8"
+}
+
+// ParseEncoding は表記（utf-8 / shift_jis / euc-jp / latin-1 と別名、大文字小文字は区別しない）を文字コードに変換する
+func ParseEncoding(name string) (Encoding, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "utf-8", "utf8":
+		return EncodingUTF8, true
+	case "shift_jis", "shift-jis", "sjis", "cp932", "windows-31j":
+		return EncodingShiftJIS, true
+	case "euc-jp", "eucjp":
+		return EncodingEUCJP, true
+	case "latin-1", "latin1", "iso-8859-1":
+		return EncodingLatin1, true
+	}
+	return EncodingUTF8, false
+}
+
+// SetEncoding は保存する際の文字コードを設定する
+func (b *Contents) SetEncoding(encoding Encoding) {
+	b.encoding = encoding
+}
+
+// Encoding は保存する際の文字コードを返す
+func (b *Contents) Encoding() Encoding {
+	return b.encoding
+}
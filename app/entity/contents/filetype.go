@@ -0,0 +1,12 @@
+package contents
+
+// SetFileType はファイル名から判定した種類の代わりに使うファイルの種類を設定する
+// 空文字列を設定するとファイル名からの判定に戻る
+func (b *Contents) SetFileType(fileType string) {
+	b.fileType = fileType
+}
+
+// FileType は SetFileType で設定したファイルの種類を返す。設定していなければ空文字列を返す
+func (b *Contents) FileType() string {
+	return b.fileType
+}
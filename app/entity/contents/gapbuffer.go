@@ -0,0 +1,116 @@
+package contents
+
+// gapBuffer は行の並びを、編集している位置に空き（ギャップ）を置いた配列で保持する
+// 同じ辺りで続けて行を挿入・削除する間はギャップを動かすだけで済み、後ろの行をずらしたり
+// 配列全体を作り直したりしないため、行数の多いファイルでも改行や行の結合が行数によらず軽い
+type gapBuffer struct {
+	data  []string
+	start int // ギャップの先頭
+	end   int // ギャップの末尾の次
+}
+
+// newGapBuffer は lines を内容とする gapBuffer を作成する。lines の配列はそのまま使う
+func newGapBuffer(lines []string) gapBuffer {
+	return gapBuffer{data: lines, start: len(lines), end: len(lines)}
+}
+
+// Len は行数を返す
+func (g *gapBuffer) Len() int {
+	return len(g.data) - (g.end - g.start)
+}
+
+// index は i 行目の配列上の位置を返す
+func (g *gapBuffer) index(i int) int {
+	if i < g.start {
+		return i
+	}
+	return i + g.end - g.start
+}
+
+// Get は i 行目の内容を返す。i は範囲内であること
+func (g *gapBuffer) Get(i int) string {
+	return g.data[g.index(i)]
+}
+
+// Set は i 行目の内容を line に置き換える。i は範囲内であること
+func (g *gapBuffer) Set(i int, line string) {
+	g.data[g.index(i)] = line
+}
+
+// Insert は i 行目の前に lines を挿入する
+func (g *gapBuffer) Insert(i int, lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	g.moveGap(i)
+	g.grow(len(lines))
+	copy(g.data[g.start:], lines)
+	g.start += len(lines)
+}
+
+// Delete は from 行目から to 行目の手前までを削除する
+func (g *gapBuffer) Delete(from, to int) {
+	if from >= to {
+		return
+	}
+	g.moveGap(from)
+	// 削除した行の文字列を早く解放できるよう、ギャップに入る要素は空にしておく
+	clear(g.data[g.end : g.end+to-from])
+	g.end += to - from
+}
+
+// Slice は from 行目から to 行目の手前までの複製を返す
+func (g *gapBuffer) Slice(from, to int) []string {
+	lines := make([]string, 0, to-from)
+	if from < g.start {
+		lines = append(lines, g.data[from:min(to, g.start)]...)
+	}
+	if to > g.start {
+		lines = append(lines, g.data[g.index(max(from, g.start)):g.index(to)]...)
+	}
+	return lines
+}
+
+// Lines は全ての行の複製を返す
+func (g *gapBuffer) Lines() []string {
+	return g.Slice(0, g.Len())
+}
+
+// clone は配列を共有しない複製を返す
+func (g *gapBuffer) clone() gapBuffer {
+	data := make([]string, len(g.data))
+	copy(data, g.data)
+	return gapBuffer{data: data, start: g.start, end: g.end}
+}
+
+// moveGap はギャップの先頭を i 行目の前に移す。移した分の行だけを動かす
+func (g *gapBuffer) moveGap(i int) {
+	switch {
+	case i < g.start:
+		n := g.start - i
+		copy(g.data[g.end-n:g.end], g.data[i:g.start])
+		clear(g.data[i : g.end-n])
+		g.start -= n
+		g.end -= n
+	case i > g.start:
+		n := i - g.start
+		copy(g.data[g.start:], g.data[g.end:g.end+n])
+		clear(g.data[g.start+n : g.end+n])
+		g.start += n
+		g.end += n
+	}
+}
+
+// grow はギャップが n 行以上になるよう配列を広げる
+func (g *gapBuffer) grow(n int) {
+	if g.end-g.start >= n {
+		return
+	}
+	size := max(2*len(g.data), len(g.data)+n, 16)
+	data := make([]string, size)
+	copy(data, g.data[:g.start])
+	tail := len(g.data) - g.end
+	copy(data[size-tail:], g.data[g.end:])
+	g.data = data
+	g.end = size - tail
+}
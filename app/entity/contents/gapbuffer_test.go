@@ -0,0 +1,77 @@
+package contents
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestGapBuffer_MatchesSlice(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	g := newGapBuffer([]string{"a", "b", "c"})
+	want := []string{"a", "b", "c"}
+
+	for step := 0; step < 2000; step++ {
+		switch i := rng.Intn(len(want) + 1); rng.Intn(3) {
+		case 0:
+			line := fmt.Sprint(step)
+			g.Insert(i, line)
+			want = append(want[:i], append([]string{line}, want[i:]...)...)
+		case 1:
+			if i < len(want) {
+				to := min(i+rng.Intn(3)+1, len(want))
+				g.Delete(i, to)
+				want = append(want[:i], want[to:]...)
+			}
+		default:
+			if i < len(want) {
+				g.Set(i, "set")
+				want[i] = "set"
+			}
+		}
+
+		if g.Len() != len(want) {
+			t.Fatalf("step %d: Len() = %d, want %d", step, g.Len(), len(want))
+		}
+		if got := g.Lines(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("step %d: Lines() = %q, want %q", step, got, want)
+		}
+	}
+
+	from, to := len(want)/3, 2*len(want)/3
+	if got := g.Slice(from, to); !reflect.DeepEqual(got, want[from:to]) {
+		t.Errorf("Slice(%d, %d) = %q, want %q", from, to, got, want[from:to])
+	}
+}
+
+func TestGapBuffer_CloneIsIndependent(t *testing.T) {
+	g := newGapBuffer([]string{"one", "two"})
+	g.Insert(1, "inserted")
+
+	c := g.clone()
+	c.Set(0, "changed")
+	c.Delete(1, 2)
+
+	if got := g.Lines(); !reflect.DeepEqual(got, []string{"one", "inserted", "two"}) {
+		t.Errorf("original changed through clone: %q", got)
+	}
+}
+
+func BenchmarkContents_InsertNewline(b *testing.B) {
+	lines := make([]string, 100000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	c := NewContents(logger.New(false))
+	c.LoadContent(lines)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// 中ほどの行で改行し、すぐに結合して行数を保つ
+		c.InsertNewline(Position{X: 2, Y: 50000}, 0)
+		c.DeleteChar(Position{X: 0, Y: 50001})
+	}
+}
@@ -0,0 +1,70 @@
+package contents
+
+import "strings"
+
+// LineEnding はファイルの改行コード
+type LineEnding int
+
+const (
+	LineEndingLF   LineEnding = iota // \n
+	LineEndingCRLF                   // \r\n
+)
+
+// String は改行コードの表記（LF / CRLF）を返す
+func (e LineEnding) String() string {
+	if e == LineEndingCRLF {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// Separator は保存する際に行の間に置く文字列を返す
+func (e LineEnding) Separator() string {
+	if e == LineEndingCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// ParseLineEnding は表記（lf / crlf、大文字小文字は区別しない）を改行コードに変換する
+func ParseLineEnding(name string) (LineEnding, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "lf":
+		return LineEndingLF, true
+	case "crlf":
+		return LineEndingCRLF, true
+	}
+	return LineEndingLF, false
+}
+
+// DetectLineEnding は改行で終わる行 terminated（\n で分割した、末尾の \r を含む行）から改行コードを判定する
+// すべての行が \r で終わっていれば CRLF、それ以外（LF と混在している場合を含む）は LF
+func DetectLineEnding(terminated []string) LineEnding {
+	if len(terminated) == 0 {
+		return LineEndingLF
+	}
+	for _, line := range terminated {
+		if !strings.HasSuffix(line, "\r") {
+			return LineEndingLF
+		}
+	}
+	return LineEndingCRLF
+}
+
+// TrimCR は各行の末尾の \r を取り除く。lines をそのまま書き換える
+func TrimCR(lines []string) {
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+}
+
+// SetLineEnding は保存する際の改行コードを設定する
+// 行の内容は変えないため、LF と混在したファイルの \r は行の内容として残る
+func (b *Contents) SetLineEnding(ending LineEnding) {
+	b.lineEnding = ending
+}
+
+// LineEnding は保存する際の改行コードを返す
+func (b *Contents) LineEnding() LineEnding {
+	return b.lineEnding
+}
@@ -0,0 +1,142 @@
+package contents
+
+import "sort"
+
+// MetaPolicy は行の内容が変わったときに、その行のメタデータをどう扱うか
+type MetaPolicy int
+
+const (
+	MetaDropOnEdit MetaPolicy = iota // 行の内容が変わったら破棄する（診断やスペルチェックの結果など）
+	MetaKeepOnEdit                   // 行の内容が変わっても残す（折りたたみの状態など）
+)
+
+// lineMeta は1つの名前空間の行ごとのメタデータ
+type lineMeta struct {
+	policy MetaPolicy
+	values map[int]interface{}
+}
+
+// RegisterLineMeta は名前空間 namespace のメタデータの扱いを設定する
+// 登録していない名前空間は MetaDropOnEdit として扱う
+func (b *Contents) RegisterLineMeta(namespace string, policy MetaPolicy) {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	b.namespace(namespace).policy = policy
+}
+
+// SetLineMeta は line 行目に名前空間 namespace のメタデータを設定する。範囲外の行は無視する
+// メタデータは行の挿入・削除に合わせて行と共に移動し、行を削除すると破棄される
+// 機能ごとに名前空間を分けることで、別の機能の結果を上書きせずに行ごとの結果を保持できる
+func (b *Contents) SetLineMeta(namespace string, line int, value interface{}) {
+	if line < 0 || line >= b.lines.Len() {
+		return
+	}
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	b.namespace(namespace).values[line] = value
+}
+
+// LineMeta は line 行目の名前空間 namespace のメタデータを返す
+func (b *Contents) LineMeta(namespace string, line int) (interface{}, bool) {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	m, ok := b.meta[namespace]
+	if !ok {
+		return nil, false
+	}
+	value, ok := m.values[line]
+	return value, ok
+}
+
+// DeleteLineMeta は line 行目の名前空間 namespace のメタデータを削除する
+func (b *Contents) DeleteLineMeta(namespace string, line int) {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	if m, ok := b.meta[namespace]; ok {
+		delete(m.values, line)
+	}
+}
+
+// ClearLineMeta は名前空間 namespace のメタデータを全て削除する。扱いの設定は残す
+func (b *Contents) ClearLineMeta(namespace string) {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	if m, ok := b.meta[namespace]; ok {
+		m.values = make(map[int]interface{})
+	}
+}
+
+// LineMetaLines は名前空間 namespace のメタデータがある行を昇順に返す
+func (b *Contents) LineMetaLines(namespace string) []int {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	m, ok := b.meta[namespace]
+	if !ok {
+		return nil
+	}
+	lines := make([]int, 0, len(m.values))
+	for line := range m.values {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// namespace は名前空間のメタデータを返す。なければ作成する。metaMu を保持して呼び出すこと
+func (b *Contents) namespace(name string) *lineMeta {
+	if b.meta == nil {
+		b.meta = make(map[string]*lineMeta)
+	}
+	m, ok := b.meta[name]
+	if !ok {
+		m = &lineMeta{values: make(map[int]interface{})}
+		b.meta[name] = m
+	}
+	return m
+}
+
+// shiftLines は行の挿入・削除に合わせて、行に結び付いた情報（編集できない範囲とメタデータ）をずらす
+// from 行以降を delta 行移動する。delta が負の場合は from+delta 行から from 行の手前までが削除されたものとする
+func (b *Contents) shiftLines(from, delta int) {
+	b.shiftRegions(from, delta)
+
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	for _, m := range b.meta {
+		shifted := make(map[int]interface{}, len(m.values))
+		for line, value := range m.values {
+			switch {
+			case line >= from:
+				shifted[line+delta] = value
+			case delta < 0 && line >= from+delta:
+				// 削除された行
+			default:
+				shifted[line] = value
+			}
+		}
+		m.values = shifted
+	}
+}
+
+// touchLine は line 行目の内容が変わったことを記録し、MetaDropOnEdit のメタデータを破棄する
+func (b *Contents) touchLine(line int) {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	for _, m := range b.meta {
+		if m.policy == MetaDropOnEdit {
+			delete(m.values, line)
+		}
+	}
+}
+
+// clearLines は内容を読み込み直したときに、行に結び付いた情報を全て破棄する
+// 名前空間ごとの扱いの設定は残す
+func (b *Contents) clearLines() {
+	b.clearRegions()
+
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	for _, m := range b.meta {
+		m.values = make(map[int]interface{})
+	}
+}
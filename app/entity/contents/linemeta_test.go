@@ -0,0 +1,109 @@
+package contents
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestLineMeta_FollowsLines(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"a", "b", "c", "d", "e"})
+	c.RegisterLineMeta("fold", MetaKeepOnEdit)
+	for y, v := range []string{"A", "B", "C", "D", "E"} {
+		c.SetLineMeta("fold", y, v)
+		c.SetLineMeta("diag", y, v)
+	}
+	c.SetLineMeta("diag", 10, "out of range")
+
+	// 行頭での改行は行ごと下に移動する
+	if err := c.InsertNewline(Position{X: 0, Y: 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	// 複数行の挿入で後ろの行がずれ、挿入した行の内容が変わる
+	if _, err := c.InsertText(Position{X: 1, Y: 3}, []string{"x", "y", ""}); err != nil {
+		t.Fatal(err)
+	}
+	// 範囲の削除で削除した行のメタデータは破棄される
+	if _, err := c.DeleteRange(Position{X: 1, Y: 0}, Position{X: 0, Y: 2}); err != nil {
+		t.Fatal(err)
+	}
+	// 空行を前の行に結合しても、前の行の内容は変わらない
+	if err := c.DeleteChar(Position{X: 0, Y: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.GetAllLines(), []string{"ab", "cx", "y", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	if got := c.LineMetaLines("fold"); !reflect.DeepEqual(got, []int{0, 1, 3, 4}) {
+		t.Errorf("fold lines = %v", got)
+	}
+	if v, _ := c.LineMeta("fold", 1); v != "C" {
+		t.Errorf("fold meta must follow its line: %v", v)
+	}
+	// 内容が変わった行の診断結果は破棄し、変わらない行は残す
+	if got := c.LineMetaLines("diag"); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("diag lines = %v", got)
+	}
+	if v, ok := c.LineMeta("diag", 3); !ok || v != "D" {
+		t.Errorf("diag meta of an unchanged line: %v", v)
+	}
+
+	c.DeleteLineMeta("fold", 0)
+	c.ClearLineMeta("diag")
+	if _, ok := c.LineMeta("fold", 0); ok || len(c.LineMetaLines("diag")) != 0 {
+		t.Error("meta must be deleted")
+	}
+
+	// 読み込み直すと破棄する
+	c.LoadContent([]string{"new"})
+	if len(c.LineMetaLines("fold")) != 0 {
+		t.Error("meta must be cleared on reload")
+	}
+}
+
+func TestLineMeta_WholeLineInsertAndDelete(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"a", "b"})
+	c.RegisterLineMeta("mark", MetaKeepOnEdit)
+	c.SetLineMeta("mark", 1, "B")
+	c.SetLineMeta("diag", 1, "B")
+
+	// 行頭に行を丸ごと挿入すると、元の行は内容を変えずに下へ移る
+	if _, err := c.InsertText(Position{X: 0, Y: 1}, []string{"x", "y", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.LineMetaLines("mark"); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("mark lines after insert = %v", got)
+	}
+	if v, ok := c.LineMeta("diag", 3); !ok || v != "B" {
+		t.Errorf("diag meta of a moved line: %v", v)
+	}
+
+	// 挿入した行を取り除くと元の位置に戻る
+	if _, err := c.DeleteRange(Position{X: 0, Y: 1}, Position{X: 0, Y: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.LineMetaLines("mark"); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("mark lines after delete = %v", got)
+	}
+	if got := c.LineMetaLines("diag"); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("diag lines after delete = %v", got)
+	}
+}
+
+func TestLineMeta_ReplaceDropsEditedLines(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"foo", "bar"})
+	c.SetLineMeta("spell", 0, true)
+	c.SetLineMeta("spell", 1, true)
+
+	if _, err := c.ReplaceAll([]Replacement{{Line: 1, Col: 0, Length: 3, Text: "baz"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.LineMetaLines("spell"); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("spell lines = %v", got)
+	}
+}
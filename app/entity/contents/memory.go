@@ -0,0 +1,46 @@
+package contents
+
+// MemoryStats はバッファが保持しているメモリの概算
+type MemoryStats struct {
+	Lines      int // 行数
+	Bytes      int // 行の内容の合計バイト数
+	Slots      int // 行の配列の大きさ（ギャップを含む）
+	CachedRows int // 表示用にキャッシュしている行数
+}
+
+// MemoryStats はバッファが保持しているメモリの概算を返す
+func (b *Contents) MemoryStats() MemoryStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := MemoryStats{Lines: b.lines.Len(), Slots: len(b.lines.data), CachedRows: len(b.rowCache)}
+	for i := 0; i < stats.Lines; i++ {
+		stats.Bytes += len(b.lines.Get(i))
+	}
+	return stats
+}
+
+// Compact は表示用の行キャッシュを捨て、行の配列をギャップのない大きさに作り直す
+// 内容と版は変わらない。大きなファイルの編集を終えたバッファのメモリを手放すために使う
+func (b *Contents) Compact() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rowCache = make(map[int]*Row)
+	if !b.shared && b.lines.end == b.lines.start && len(b.lines.data) == b.lines.Len() {
+		return
+	}
+	// スナップショットと共有している配列は変更せず、新しい配列に移す
+	b.lines = newGapBuffer(b.lines.Lines())
+	b.shared = false
+}
+
+// RetainRows は from 行目から to 行目の手前まで以外の行キャッシュを捨てる
+// キャッシュの map は縮まないため、残す行を入れた新しい map に置き換える
+func (b *Contents) RetainRows(from, to int) {
+	kept := make(map[int]*Row)
+	for y, row := range b.rowCache {
+		if y >= from && y < to {
+			kept[y] = row
+		}
+	}
+	b.rowCache = kept
+}
@@ -0,0 +1,52 @@
+package contents
+
+import (
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestContents_CompactKeepsContent(t *testing.T) {
+	b := NewContents(logger.New(false))
+	b.LoadContent([]string{"one", "two", "three"})
+	for i := 0; i < 20; i++ {
+		if err := b.InsertNewline(Position{X: 0, Y: 1}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.GetRow(0)
+	snapshot := b.Snapshot()
+	version := b.Version()
+
+	before := b.MemoryStats()
+	if before.Slots <= before.Lines || before.CachedRows == 0 {
+		t.Fatalf("expected a gap and cached rows before compacting: %+v", before)
+	}
+	b.Compact()
+	after := b.MemoryStats()
+	if after.Slots != after.Lines || after.CachedRows != 0 || after.Bytes != before.Bytes {
+		t.Errorf("unexpected stats after compacting: %+v", after)
+	}
+	if b.Version() != version || b.GetContentLine(22) != "three" {
+		t.Error("compacting must not change the content")
+	}
+	// スナップショットは元の内容のまま
+	if snapshot.LineCount() != 23 || snapshot.Line(0) != "one" {
+		t.Error("the snapshot must not be affected")
+	}
+}
+
+func TestContents_RetainRows(t *testing.T) {
+	b := NewContents(logger.New(false))
+	b.LoadContent([]string{"a", "b", "c", "d"})
+	for y := 0; y < 4; y++ {
+		b.GetRow(y)
+	}
+	b.RetainRows(1, 3)
+	if n := b.MemoryStats().CachedRows; n != 2 {
+		t.Errorf("expected 2 cached rows, got %d", n)
+	}
+	if b.GetRow(0).GetContent() != "a" {
+		t.Error("dropped rows must be rebuilt on demand")
+	}
+}
@@ -5,18 +5,27 @@ import (
 	"time"
 )
 
+// Persistent は閉じる操作をするまで表示し続けるメッセージの表示時間
+const Persistent time.Duration = -1
+
 type Message interface {
 	String() string
 	Clear()
 	SetMessage(format string, args ...interface{})
+	// SetMessageFor は表示時間を指定してメッセージを設定する（0 なら既定の表示時間、Persistent なら閉じるまで表示）
+	SetMessageFor(duration time.Duration, format string, args ...interface{})
 	Get() string
 	GetTime() int64
+	// Expired は now の時点で表示時間を過ぎているかを返す。メッセージ個別の指定がなければ defaultDuration を使う
+	Expired(now time.Time, defaultDuration time.Duration) bool
 }
 
 type StandardMessage struct {
 	Message     string
 	Args        []interface{}
 	MessageTime int64
+	Duration    time.Duration // 0 なら既定の表示時間
+	setAt       time.Time
 }
 
 type DebugMessage string
@@ -36,13 +45,20 @@ func (m *StandardMessage) String() string {
 func (m *StandardMessage) Clear() {
 	m.Message = ""
 	m.Args = make([]interface{}, 0)
+	m.Duration = 0
 }
 
 func (m *StandardMessage) SetMessage(format string, args ...interface{}) {
+	m.SetMessageFor(0, format, args...)
+}
+
+func (m *StandardMessage) SetMessageFor(duration time.Duration, format string, args ...interface{}) {
 	m.Message = format
 	m.Args = make([]interface{}, len(args))
 	copy(m.Args, args)
-	m.MessageTime = time.Now().Unix()
+	m.setAt = time.Now()
+	m.MessageTime = m.setAt.Unix()
+	m.Duration = duration
 }
 
 func (m *StandardMessage) Get() string {
@@ -53,6 +69,20 @@ func (m *StandardMessage) GetTime() int64 {
 	return m.MessageTime
 }
 
+func (m *StandardMessage) Expired(now time.Time, defaultDuration time.Duration) bool {
+	if m.Message == "" {
+		return true
+	}
+	duration := m.Duration
+	if duration == 0 {
+		duration = defaultDuration
+	}
+	if duration < 0 {
+		return false
+	}
+	return now.Sub(m.setAt) >= duration
+}
+
 func (d DebugMessage) String() string {
 	return string(d)
 }
@@ -0,0 +1,36 @@
+package contents
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStandardMessage_Expired(t *testing.T) {
+	m := NewMessage("")
+	now := time.Now()
+	if !m.Expired(now, time.Second) {
+		t.Error("empty message must be treated as expired")
+	}
+
+	m.SetMessage("saved")
+	if m.Expired(now, time.Minute) {
+		t.Error("message must be shown for the default duration")
+	}
+	if !m.Expired(now.Add(2*time.Second), time.Second) {
+		t.Error("message must expire after the default duration")
+	}
+
+	m.SetMessageFor(time.Hour, "long")
+	if m.Expired(now.Add(time.Minute), time.Second) {
+		t.Error("per-message duration must override the default")
+	}
+
+	m.SetMessageFor(Persistent, "error")
+	if m.Expired(now.Add(24*time.Hour), time.Second) {
+		t.Error("persistent message must not expire")
+	}
+	m.Clear()
+	if !m.Expired(now, time.Second) || m.Duration != 0 {
+		t.Error("cleared message must be expired and reset its duration")
+	}
+}
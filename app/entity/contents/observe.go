@@ -0,0 +1,127 @@
+package contents
+
+// Change は内容の変更の通知
+type Change struct {
+	Version uint64 // 変更後の版（Version と同じ値）
+}
+
+// Observe は内容を変更するたびに fn を呼び出すよう登録し、登録を解除する関数を返す
+// fn は編集を行ったゴルーチンで、編集を終えた後に呼び出す。トランザクション中の編集はトランザクションの終わりに1回だけ通知する
+func (b *Contents) Observe(fn func(Change)) (cancel func()) {
+	b.obsMu.Lock()
+	defer b.obsMu.Unlock()
+	if b.observers == nil {
+		b.observers = make(map[int]func(Change))
+	}
+	id := b.nextObserverID
+	b.nextObserverID++
+	b.observers[id] = fn
+	return func() {
+		b.obsMu.Lock()
+		defer b.obsMu.Unlock()
+		delete(b.observers, id)
+	}
+}
+
+// notifyObservers は登録されている関数に現在の版を通知する
+func (b *Contents) notifyObservers() {
+	b.obsMu.Lock()
+	if len(b.observers) == 0 {
+		b.obsMu.Unlock()
+		return
+	}
+	fns := make([]func(Change), 0, len(b.observers))
+	for _, fn := range b.observers {
+		fns = append(fns, fn)
+	}
+	b.obsMu.Unlock()
+
+	change := Change{Version: b.Version()}
+	for _, fn := range fns {
+		fn(change)
+	}
+}
+
+// Tx はトランザクション中の編集。Transaction に渡した関数の中でだけ使う
+type Tx struct {
+	b    *Contents
+	undo []func() // 編集を元に戻す操作（適用した順）
+}
+
+// Transaction は fn の中の編集をまとめて1つの変更として適用する
+// fn がエラーを返した場合はそれまでの編集を元に戻し、そのエラーを返す
+func (b *Contents) Transaction(fn func(tx *Tx) error) error {
+	b.mu.Lock()
+	b.txDepth++
+	dirty := b.isDirty
+	b.mu.Unlock()
+
+	tx := &Tx{b: b}
+	err := fn(tx)
+	if err != nil {
+		tx.rollback()
+		b.isDirty = dirty
+	}
+
+	b.mu.Lock()
+	b.txDepth--
+	notify := b.txDepth == 0 && b.pendingNotify
+	if notify {
+		b.pendingNotify = false
+	}
+	b.mu.Unlock()
+	if notify {
+		b.notifyObservers()
+	}
+	return err
+}
+
+// InsertText は Contents.InsertText と同じく pos に文字列を挿入する
+func (tx *Tx) InsertText(pos Position, text []string) (Position, error) {
+	b := tx.b
+	start := b.clampPosition(pos)
+	end, err := b.InsertText(pos, text)
+	if err == nil && len(text) > 0 {
+		tx.undo = append(tx.undo, func() { b.DeleteRange(start, end) })
+	}
+	return end, err
+}
+
+// DeleteRange は Contents.DeleteRange と同じく範囲の文字列を削除する
+func (tx *Tx) DeleteRange(start, end Position) ([]string, error) {
+	b := tx.b
+	from, _, ok := b.normalizeRange(start, end)
+	removed, err := b.DeleteRange(start, end)
+	if err == nil && ok {
+		tx.undo = append(tx.undo, func() { b.InsertText(from, removed) })
+	}
+	return removed, err
+}
+
+// ReplaceAll は Contents.ReplaceAll と同じく複数の置き換えをまとめて適用する
+func (tx *Tx) ReplaceAll(reps []Replacement) ([]LineChange, error) {
+	b := tx.b
+	changes, err := b.ReplaceAll(reps)
+	if err == nil && len(changes) > 0 {
+		tx.undo = append(tx.undo, func() { b.ApplyLineChanges(changes, true) })
+	}
+	return changes, err
+}
+
+// rollback はトランザクション中の編集を新しいものから順に元に戻す
+func (tx *Tx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
+
+// clampPosition は pos をバッファの範囲に収めた位置を返す
+func (b *Contents) clampPosition(pos Position) Position {
+	if b.lines.Len() == 0 {
+		return Position{}
+	}
+	pos.Y = min(max(pos.Y, 0), b.lines.Len()-1)
+	pos.X = min(max(pos.X, 0), len([]rune(b.lines.Get(pos.Y))))
+	return pos
+}
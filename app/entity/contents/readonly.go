@@ -0,0 +1,114 @@
+package contents
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrReadOnly は編集できない範囲を変更しようとした場合のエラー
+var ErrReadOnly = errors.New("read-only region")
+
+// Region は編集できない行の範囲 [Start, End)
+// 行の挿入・結合に合わせて Contents が位置を追従させる
+type Region struct {
+	ID         int
+	Start, End int
+}
+
+// Contains は行が範囲内にあるかどうかを返す
+func (r Region) Contains(line int) bool {
+	return r.Start <= line && line < r.End
+}
+
+// ProtectLines は start 行から end 行の手前までを編集できない範囲として登録し、そのIDを返す
+// チュートリアルの説明文やコマンドの出力の見出しなど、利用者に変更させたくない行に使う
+// 範囲はバッファの内容を読み込み直すと解除される
+func (b *Contents) ProtectLines(start, end int) int {
+	b.nextRegionID++
+	b.regions = append(b.regions, &Region{ID: b.nextRegionID, Start: start, End: end})
+	return b.nextRegionID
+}
+
+// Unprotect は登録した範囲を解除する
+func (b *Contents) Unprotect(id int) {
+	for i, r := range b.regions {
+		if r.ID == id {
+			b.regions = append(b.regions[:i], b.regions[i+1:]...)
+			return
+		}
+	}
+}
+
+// ReadOnlyRegions は編集できない範囲を開始行の順に返す
+func (b *Contents) ReadOnlyRegions() []Region {
+	regions := make([]Region, len(b.regions))
+	for i, r := range b.regions {
+		regions[i] = *r
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Start < regions[j].Start })
+	return regions
+}
+
+// SetReadOnly はバッファ全体を編集できないようにするかどうかを切り替える
+// 参照用に開いたファイルを誤って変更しないために使い、ファイル自体の書き込み権限とは関係しない
+// 内容を読み込み直しても解除されない
+func (b *Contents) SetReadOnly(readOnly bool) {
+	b.readOnly = readOnly
+}
+
+// ReadOnly はバッファ全体が編集できない状態かどうかを返す
+func (b *Contents) ReadOnly() bool {
+	return b.readOnly
+}
+
+// IsReadOnly は行が編集できない範囲に含まれているかどうかを返す
+// バッファ全体が編集できない状態の場合は全ての行で true を返す
+func (b *Contents) IsReadOnly(line int) bool {
+	if b.readOnly {
+		return true
+	}
+	for _, r := range b.regions {
+		if r.Contains(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNewline は pos で改行できるかどうかを判定する
+// 保護された行でも、範囲の先頭行の行頭での改行（範囲全体を下にずらす）と
+// 範囲の最終行の行末での改行（範囲の後に行を追加する）は保護された内容を変えないので許可する
+func (b *Contents) checkNewline(pos Position) error {
+	if b.readOnly {
+		return ErrReadOnly
+	}
+	for _, r := range b.regions {
+		if !r.Contains(pos.Y) {
+			continue
+		}
+		if pos.X == 0 && pos.Y == r.Start {
+			continue
+		}
+		if pos.X >= len([]rune(b.lines.Get(pos.Y))) && pos.Y == r.End-1 {
+			continue
+		}
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// shiftRegions は行の挿入・削除に合わせて範囲をずらす
+// from 行以降で始まる範囲を delta 行移動する
+func (b *Contents) shiftRegions(from, delta int) {
+	for _, r := range b.regions {
+		if r.Start >= from {
+			r.Start += delta
+			r.End += delta
+		}
+	}
+}
+
+// clearRegions は全ての範囲を解除する
+func (b *Contents) clearRegions() {
+	b.regions = nil
+}
@@ -0,0 +1,117 @@
+package contents
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func newProtectedContents() *Contents {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"title", "=====", "", "text"})
+	c.ProtectLines(0, 2)
+	return c
+}
+
+func TestReadOnly_RejectsEdits(t *testing.T) {
+	c := newProtectedContents()
+	before := c.GetAllLines()
+
+	if err := c.InsertChar(Position{X: 1, Y: 0}, 'x'); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertChar: expected ErrReadOnly, got %v", err)
+	}
+	if err := c.InsertChars(Position{X: 0, Y: 1}, []rune("xy")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertChars: expected ErrReadOnly, got %v", err)
+	}
+	if err := c.DeleteChar(Position{X: 2, Y: 1}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteChar: expected ErrReadOnly, got %v", err)
+	}
+	// 保護された行への結合も拒否する
+	if err := c.DeleteChar(Position{X: 0, Y: 2}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteChar(join): expected ErrReadOnly, got %v", err)
+	}
+	if err := c.InsertNewline(Position{X: 2, Y: 0}, 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertNewline: expected ErrReadOnly, got %v", err)
+	}
+	if err := c.InsertNewline(Position{X: 0, Y: 1}, 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertNewline inside region: expected ErrReadOnly, got %v", err)
+	}
+
+	if !reflect.DeepEqual(c.GetAllLines(), before) || c.IsDirty() {
+		t.Errorf("protected contents changed: %q dirty=%v", c.GetAllLines(), c.IsDirty())
+	}
+}
+
+func TestReadOnly_RegionFollowsLineChanges(t *testing.T) {
+	c := newProtectedContents()
+
+	// 範囲の先頭で改行すると範囲ごと下に移動する
+	if err := c.InsertNewline(Position{X: 0, Y: 0}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.ReadOnlyRegions()[0]; got.Start != 1 || got.End != 3 {
+		t.Errorf("unexpected region after newline: %+v", got)
+	}
+	// 範囲の最終行の行末で改行すると、範囲の後に編集できる行が追加される
+	if err := c.InsertNewline(Position{X: 5, Y: 2}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.IsReadOnly(3) {
+		t.Error("new line after the region should be editable")
+	}
+	// 範囲より前の行を結合すると範囲は上に移動する
+	if err := c.InsertNewline(Position{X: 0, Y: 0}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.DeleteChar(Position{X: 0, Y: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"", "title", "=====", "", "", "text"}
+	if !reflect.DeepEqual(c.GetAllLines(), want) {
+		t.Errorf("unexpected lines: %q", c.GetAllLines())
+	}
+	if got := c.ReadOnlyRegions()[0]; got.Start != 1 || got.End != 3 {
+		t.Errorf("unexpected region: %+v", got)
+	}
+}
+
+func TestReadOnly_ClearedOnLoad(t *testing.T) {
+	c := newProtectedContents()
+	c.LoadContent([]string{"a"})
+	if len(c.ReadOnlyRegions()) != 0 {
+		t.Error("regions should be cleared when new content is loaded")
+	}
+	id := c.ProtectLines(0, 1)
+	c.Unprotect(id)
+	if err := c.InsertChar(Position{X: 0, Y: 0}, 'x'); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadOnly_WholeBuffer(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"a", "b"})
+	c.SetReadOnly(true)
+
+	if err := c.InsertChar(Position{X: 0, Y: 1}, 'x'); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertChar: expected ErrReadOnly, got %v", err)
+	}
+	if err := c.InsertNewline(Position{X: 1, Y: 1}, 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertNewline: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := c.InsertText(Position{}, []string{"x"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertText: expected ErrReadOnly, got %v", err)
+	}
+	// 読み込み直しても解除されない
+	c.LoadContent([]string{"c"})
+	if err := c.DeleteChar(Position{X: 1, Y: 0}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteChar: expected ErrReadOnly, got %v", err)
+	}
+
+	c.SetReadOnly(false)
+	if err := c.InsertChar(Position{X: 0, Y: 0}, 'x'); err != nil || c.GetContentLine(0) != "xc" {
+		t.Errorf("unlocked buffer must be editable: %v %q", err, c.GetContentLine(0))
+	}
+}
@@ -0,0 +1,122 @@
+package contents
+
+import (
+	"sort"
+	"strings"
+)
+
+// Replacement は Line 行目の Col 文字目から Length 文字を Text に置き換える編集（ルーン単位）
+// Text は改行を含まない
+type Replacement struct {
+	Line   int
+	Col    int
+	Length int
+	Text   string
+}
+
+// LineChange は1行の内容の置き換え。取り消せるよう置き換える前の内容も持つ
+type LineChange struct {
+	Line int
+	Old  string
+	New  string
+}
+
+// ReplaceAll は複数の置き換えをまとめて適用し、内容が変わった行を行順に返す
+// 置き換えは互いに重ならないこと（並び順は問わない）。範囲外の置き換えは無視する
+// 各行は1回の走査で組み立て直すため、1行に多数の置き換えがあっても行の長さと置き換えの数に比例した時間で済む
+// いずれかの行が編集できない場合は何も変更せずに ErrReadOnly を返す
+func (b *Contents) ReplaceAll(reps []Replacement) ([]LineChange, error) {
+	b.beginEdit()
+	defer b.endEdit()
+
+	if len(reps) == 0 {
+		return nil, nil
+	}
+	for _, r := range reps {
+		if b.IsReadOnly(r.Line) {
+			return nil, ErrReadOnly
+		}
+	}
+
+	// 検索結果は通常は位置の順に並んでいるため、その場合は複製と並べ替えを省く
+	sorted := reps
+	less := func(i, j int) bool {
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Col < sorted[j].Col
+	}
+	if !sort.SliceIsSorted(sorted, less) {
+		sorted = append([]Replacement{}, reps...)
+		sort.Slice(sorted, less)
+	}
+
+	var changes []LineChange
+	for i := 0; i < len(sorted); {
+		line := sorted[i].Line
+		j := i + 1
+		for j < len(sorted) && sorted[j].Line == line {
+			j++
+		}
+		if line >= 0 && line < b.lines.Len() {
+			old := b.lines.Get(line)
+			if updated := rebuildLine(old, sorted[i:j]); updated != old {
+				changes = append(changes, LineChange{Line: line, Old: old, New: updated})
+				b.lines.Set(line, updated)
+				delete(b.rowCache, line)
+				b.touchLine(line)
+			}
+		}
+		i = j
+	}
+	if len(changes) > 0 {
+		b.isDirty = true
+	}
+	return changes, nil
+}
+
+// ApplyLineChanges は changes の各行を New の内容に置き換える。undo が true の場合は Old の内容に戻す
+// 範囲外の行は無視する。いずれかの行が編集できない場合は何も変更せずに ErrReadOnly を返す
+func (b *Contents) ApplyLineChanges(changes []LineChange, undo bool) error {
+	b.beginEdit()
+	defer b.endEdit()
+
+	for _, c := range changes {
+		if b.IsReadOnly(c.Line) {
+			return ErrReadOnly
+		}
+	}
+	for _, c := range changes {
+		if c.Line < 0 || c.Line >= b.lines.Len() {
+			continue
+		}
+		text := c.New
+		if undo {
+			text = c.Old
+		}
+		b.lines.Set(c.Line, text)
+		delete(b.rowCache, c.Line)
+		b.touchLine(c.Line)
+	}
+	if len(changes) > 0 {
+		b.isDirty = true
+	}
+	return nil
+}
+
+// rebuildLine は line に同じ行の置き換え（位置の順）を適用した内容を返す
+func rebuildLine(line string, reps []Replacement) string {
+	runes := []rune(line)
+	var builder strings.Builder
+	builder.Grow(len(line))
+	prev := 0
+	for _, r := range reps {
+		start := min(max(r.Col, prev), len(runes))
+		end := min(start+max(r.Length, 0), len(runes))
+		builder.WriteString(string(runes[prev:start]))
+		builder.WriteString(r.Text)
+		prev = end
+	}
+	builder.WriteString(string(runes[prev:]))
+	return builder.String()
+}
@@ -0,0 +1,105 @@
+package contents
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestReplaceAll(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"foo bar foo", "keep", "日本foo"})
+
+	changes, err := c.ReplaceAll([]Replacement{
+		{Line: 0, Col: 0, Length: 3, Text: "x"},
+		{Line: 2, Col: 2, Length: 3, Text: "語"},
+		{Line: 0, Col: 8, Length: 3, Text: "baz"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"x bar baz", "keep", "日本語"}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// 変更した行だけを、置き換える前の内容と共に行順で返す
+	wantChanges := []LineChange{{Line: 0, Old: "foo bar foo", New: "x bar baz"}, {Line: 2, Old: "日本foo", New: "日本語"}}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if !c.IsDirty() {
+		t.Error("buffer must be dirty after replacing")
+	}
+
+	// 取り消しとやり直し
+	if err := c.ApplyLineChanges(changes, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, []string{"foo bar foo", "keep", "日本foo"}) {
+		t.Errorf("undo: got %q", got)
+	}
+	c.ApplyLineChanges(changes, false)
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("redo: got %q", got)
+	}
+}
+
+func TestReplaceAll_ManyMatchesInLongLine(t *testing.T) {
+	const n = 10000
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{strings.Repeat("ab", n)})
+
+	reps := make([]Replacement, n)
+	for i := range reps {
+		reps[i] = Replacement{Line: 0, Col: i * 2, Length: 1, Text: "xy"}
+	}
+	changes, err := c.ReplaceAll(reps)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("ReplaceAll() = %d changes, %v", len(changes), err)
+	}
+	if got := c.GetContentLine(0); got != strings.Repeat("xyb", n) {
+		t.Errorf("unexpected line: %.20q...", got)
+	}
+}
+
+func TestReplaceAll_ReadOnly(t *testing.T) {
+	c := newProtectedContents()
+	before := c.GetAllLines()
+
+	_, err := c.ReplaceAll([]Replacement{
+		{Line: 3, Col: 0, Length: 1, Text: "T"},
+		{Line: 0, Col: 0, Length: 1, Text: "T"},
+	})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, before) {
+		t.Errorf("nothing must change: %q", got)
+	}
+}
+
+func TestReplaceAll_LargeBuffer(t *testing.T) {
+	const n = 100000
+	lines := make([]string, n)
+	reps := make([]Replacement, 0, n/2)
+	for i := range lines {
+		lines[i] = "foo"
+		if i%2 == 0 {
+			reps = append(reps, Replacement{Line: i, Col: 0, Length: 3, Text: "bar"})
+		}
+	}
+	c := NewContents(logger.New(false))
+	c.LoadContent(lines)
+
+	// 変更した行だけを記録する
+	changes, err := c.ReplaceAll(reps)
+	if err != nil || len(changes) != n/2 {
+		t.Fatalf("ReplaceAll() = %d changes, %v", len(changes), err)
+	}
+	if c.GetContentLine(n-2) != "bar" || c.GetContentLine(n-1) != "foo" {
+		t.Errorf("unexpected tail: %q %q", c.GetContentLine(n-2), c.GetContentLine(n-1))
+	}
+}
@@ -23,14 +23,7 @@ func NewRow(chars string) *Row {
 		}
 	}
 
-	runeSlice := []rune(chars)
-	r := &Row{
-		chars:      chars,
-		runeSlice:  runeSlice,
-		widths:     make([]int, len(runeSlice)),
-		positions:  make([]int, len(runeSlice)+1),
-		totalWidth: 0,
-	}
+	r := &Row{chars: chars}
 	r.updateWidths()
 	return r
 }
@@ -41,6 +34,7 @@ func (r *Row) GetContent() string {
 }
 
 // InsertChar は指定位置に文字を挿入する
+// 内部用: 安定 API（RowAPI）には含まれない。バッファの内容は変わらないため、編集には Contents を使う
 func (r *Row) InsertChar(at int, ch rune) {
 	runes := []rune(r.chars)
 	if at > len(runes) {
@@ -53,6 +47,7 @@ func (r *Row) InsertChar(at int, ch rune) {
 }
 
 // DeleteChar は指定位置の文字を削除する
+// 内部用: 安定 API（RowAPI）には含まれない
 func (r *Row) DeleteChar(at int) {
 	if at < 0 || at >= len(r.runeSlice) {
 		return
@@ -128,40 +123,21 @@ func (r *Row) GetRuneWidth(offset int) int {
 }
 
 // updateWidths は行の文字幅情報を更新する
+// 幅情報は共有キャッシュのものをそのまま参照するため、スライスの内容を書き換えてはならない
 func (r *Row) updateWidths() {
-	// runeSliceがnilの場合は初期化
-	if r.runeSlice == nil {
-		r.runeSlice = []rune(r.chars)
-	}
-
-	// widthsとpositionsの長さを確認し、必要に応じて再割り当て
-	if len(r.widths) != len(r.runeSlice) {
-		r.widths = make([]int, len(r.runeSlice))
-	}
-	if len(r.positions) != len(r.runeSlice)+1 {
-		r.positions = make([]int, len(r.runeSlice)+1)
-	}
-
-	r.totalWidth = 0
-	for i, ch := range r.runeSlice {
-		w := getCharWidth(ch)
-		r.widths[i] = w
-		r.positions[i] = r.totalWidth
-		r.totalWidth += w
-	}
-	r.positions[len(r.runeSlice)] = r.totalWidth
-
-	// 一貫性チェック
-	if len(r.runeSlice) != len(r.widths) || len(r.positions) != len(r.runeSlice)+1 {
-		// 長さの不整合を修正
-		r.widths = make([]int, len(r.runeSlice))
-		r.positions = make([]int, len(r.runeSlice)+1)
-		r.updateWidths() // 再帰呼び出しは1回限り
-	}
+	r.runeSlice = []rune(r.chars)
+	e := sharedWidthCache.lookup(r.chars, r.runeSlice)
+	r.widths = e.widths
+	r.positions = e.positions
+	r.totalWidth = e.totalWidth
 }
 
 // getCharWidth は文字の表示幅を返す
+// 制御文字は ControlNotation の表記で表示するため、その文字数を幅とする
 func getCharWidth(ch rune) int {
+	if notation, ok := ControlNotation(ch); ok {
+		return len(notation)
+	}
 	p := width.LookupRune(ch)
 	switch p.Kind() {
 	case width.EastAsianFullwidth, width.EastAsianWide:
@@ -0,0 +1,75 @@
+package contents
+
+// Snapshot はある時点のバッファの内容の読み取り専用のビュー
+// ハイライトやバッファ内検索など、裏で動く処理が入力中のバッファを読むために使う
+// 作成後にバッファを編集しても内容は変わらないため、ロックなしで別のゴルーチンから読み取れる
+type Snapshot struct {
+	lines   gapBuffer
+	version uint64
+}
+
+// LineCount は行数を返す
+func (s *Snapshot) LineCount() int {
+	return s.lines.Len()
+}
+
+// Line は指定行の内容を返す。範囲外の場合は空文字列を返す
+func (s *Snapshot) Line(y int) string {
+	if y < 0 || y >= s.lines.Len() {
+		return ""
+	}
+	return s.lines.Get(y)
+}
+
+// Lines は全ての行の複製を返す
+func (s *Snapshot) Lines() []string {
+	return s.lines.Lines()
+}
+
+// Version はスナップショットを取得した時点のバッファの版を返す
+// Contents.Version と比べることで、処理結果が古くなっていないかを判定できる
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// Snapshot は現在の内容のスナップショットを返す。どのゴルーチンから呼び出してもよい
+// 行の配列はコピーせずに共有し、次に編集するときに初めて複製する（コピーオンライト）
+// そのため取得自体は行数によらず軽く、編集側もスナップショットの読み取りを待たない
+func (b *Contents) Snapshot() *Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.shared = true
+	return &Snapshot{lines: b.lines, version: b.version}
+}
+
+// Version は内容の版を返す。内容を変更する操作のたびに増える
+func (b *Contents) Version() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.version
+}
+
+// beginEdit は内容を変更する前に呼び出す
+// スナップショットと行の配列を共有している場合は複製してから変更させる
+// 変更を終えたら endEdit を呼び出すこと
+func (b *Contents) beginEdit() {
+	b.mu.Lock()
+	if b.shared {
+		b.lines = b.lines.clone()
+		b.shared = false
+	}
+	b.version++
+}
+
+// endEdit は beginEdit で始めた変更を終え、変更を監視している関数に通知する
+// トランザクション中は通知をトランザクションの終わりまで遅らせる
+func (b *Contents) endEdit() {
+	notify := b.txDepth == 0
+	if !notify {
+		b.pendingNotify = true
+	}
+	b.mu.Unlock()
+	if notify {
+		b.notifyObservers()
+	}
+}
@@ -0,0 +1,64 @@
+package contents
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestSnapshot_IsImmutable(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"one", "two"})
+
+	snap := c.Snapshot()
+	if snap.Version() != c.Version() {
+		t.Fatalf("version mismatch: %d %d", snap.Version(), c.Version())
+	}
+
+	c.InsertChar(Position{X: 3, Y: 0}, '!')
+	c.InsertNewline(Position{X: 3, Y: 1}, 0)
+	c.AppendLines([]string{"three"})
+
+	if got := snap.Lines(); !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Errorf("snapshot must not change after edits: %q", got)
+	}
+	if snap.LineCount() != 2 || snap.Line(1) != "two" || snap.Line(5) != "" {
+		t.Errorf("unexpected snapshot contents: %q", snap.Lines())
+	}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, []string{"one!", "two", "", "three"}) {
+		t.Errorf("unexpected buffer contents: %q", got)
+	}
+	if c.Version() <= snap.Version() {
+		t.Error("version must increase after edits")
+	}
+}
+
+func TestSnapshot_ConcurrentReaders(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"abc"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				snap := c.Snapshot()
+				for y := 0; y < snap.LineCount(); y++ {
+					_ = snap.Line(y)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		c.InsertChar(Position{X: 0, Y: 0}, 'x')
+		c.InsertNewline(Position{X: 1, Y: 0}, 0)
+	}
+	wg.Wait()
+
+	if c.GetLineCount() != 101 {
+		t.Errorf("unexpected line count: %d", c.GetLineCount())
+	}
+}
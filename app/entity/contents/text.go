@@ -0,0 +1,146 @@
+package contents
+
+// EndOf は start に text を挿入した場合の末尾の位置を返す
+// text は改行で区切った行の並びで、2行以上なら改行を含む
+func EndOf(start Position, text []string) Position {
+	switch len(text) {
+	case 0:
+		return start
+	case 1:
+		return Position{X: start.X + len([]rune(text[0])), Y: start.Y}
+	}
+	return Position{X: len([]rune(text[len(text)-1])), Y: start.Y + len(text) - 1}
+}
+
+// InsertText は pos に複数行にわたる文字列を挿入し、挿入した文字列の末尾の位置を返す
+// text の各要素の間で改行する。編集できない行の場合は何もせずに ErrReadOnly を返す
+func (b *Contents) InsertText(pos Position, text []string) (Position, error) {
+	b.beginEdit()
+	defer b.endEdit()
+
+	if len(text) == 0 {
+		return pos, nil
+	}
+	if b.readOnly {
+		return pos, ErrReadOnly
+	}
+	if b.lines.Len() == 0 {
+		b.lines.Insert(0, "")
+		b.rowCache = make(map[int]*Row)
+	}
+	if pos.Y >= b.lines.Len() {
+		pos.Y = b.lines.Len() - 1
+	}
+	if b.IsReadOnly(pos.Y) {
+		return pos, ErrReadOnly
+	}
+
+	runes := []rune(b.lines.Get(pos.Y))
+	if pos.X > len(runes) {
+		pos.X = len(runes)
+	}
+	head, tail := string(runes[:pos.X]), string(runes[pos.X:])
+
+	inserted := make([]string, len(text))
+	copy(inserted, text)
+	inserted[0] = head + inserted[0]
+	inserted[len(inserted)-1] += tail
+
+	b.lines.Set(pos.Y, inserted[0])
+	b.lines.Insert(pos.Y+1, inserted[1:]...)
+
+	b.invalidateFrom(pos.Y)
+	// 行頭に2行以上を挿入すると元の行は最後の行へ移るので、InsertNewline と同じく行に結び付いた情報もずらす
+	if pos.X == 0 && len(text) > 1 {
+		last := pos.Y + len(text) - 1
+		b.shiftLines(pos.Y, len(text)-1)
+		if text[len(text)-1] != "" {
+			b.touchLine(last)
+		}
+	} else {
+		b.shiftLines(pos.Y+1, len(text)-1)
+		b.touchLine(pos.Y)
+	}
+	b.isDirty = true
+	return EndOf(pos, text), nil
+}
+
+// TextRange は start から end の手前までの文字列を行ごとに返す
+func (b *Contents) TextRange(start, end Position) []string {
+	start, end, ok := b.normalizeRange(start, end)
+	if !ok {
+		return nil
+	}
+	return b.textBetween(start, end)
+}
+
+// DeleteRange は start から end の手前までを削除し、削除した文字列を行ごとに返す
+// 範囲に編集できない行が含まれる場合は何もせずに ErrReadOnly を返す
+func (b *Contents) DeleteRange(start, end Position) ([]string, error) {
+	b.beginEdit()
+	defer b.endEdit()
+
+	start, end, ok := b.normalizeRange(start, end)
+	if !ok {
+		return nil, nil
+	}
+	for y := start.Y; y <= end.Y; y++ {
+		if b.IsReadOnly(y) {
+			return nil, ErrReadOnly
+		}
+	}
+
+	removed := b.textBetween(start, end)
+	joined := string([]rune(b.lines.Get(start.Y))[:start.X]) + string([]rune(b.lines.Get(end.Y))[end.X:])
+	b.lines.Delete(start.Y+1, end.Y+1)
+	b.lines.Set(start.Y, joined)
+
+	b.invalidateFrom(start.Y)
+	// 行頭から行頭までの削除は行を丸ごと取り除くので、end の行は内容を変えずに start の行へ移る
+	if start.X == 0 && end.X == 0 {
+		b.shiftLines(end.Y, start.Y-end.Y)
+	} else {
+		b.shiftLines(end.Y+1, start.Y-end.Y)
+		b.touchLine(start.Y)
+	}
+	b.isDirty = true
+	return removed, nil
+}
+
+// normalizeRange は start が end より前になるよう並べ替え、バッファの範囲に収める
+// 範囲が空の場合は ok が false
+func (b *Contents) normalizeRange(start, end Position) (Position, Position, bool) {
+	if end.Y < start.Y || (end.Y == start.Y && end.X < start.X) {
+		start, end = end, start
+	}
+	n := b.lines.Len()
+	if n == 0 || start.Y >= n {
+		return start, end, false
+	}
+	if end.Y >= n {
+		end = Position{X: len([]rune(b.lines.Get(n - 1))), Y: n - 1}
+	}
+	start.X = min(start.X, len([]rune(b.lines.Get(start.Y))))
+	end.X = min(end.X, len([]rune(b.lines.Get(end.Y))))
+	return start, end, start != end
+}
+
+// textBetween は正規化済みの範囲の文字列を行ごとに返す
+func (b *Contents) textBetween(start, end Position) []string {
+	first := []rune(b.lines.Get(start.Y))
+	if start.Y == end.Y {
+		return []string{string(first[start.X:end.X])}
+	}
+	text := []string{string(first[start.X:])}
+	text = append(text, b.lines.Slice(start.Y+1, end.Y)...)
+	return append(text, string([]rune(b.lines.Get(end.Y))[:end.X]))
+}
+
+// invalidateFrom は y 行目以降の行キャッシュを破棄する
+func (b *Contents) invalidateFrom(y int) {
+	for i := range b.rowCache {
+		if i >= y {
+			delete(b.rowCache, i)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package contents
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+)
+
+func TestInsertTextAndDeleteRange(t *testing.T) {
+	c := NewContents(logger.New(false))
+	c.LoadContent([]string{"hello world", "last"})
+
+	end, err := c.InsertText(Position{X: 5, Y: 0}, []string{",", "new line", "and"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"hello,", "new line", "and world", "last"}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("InsertText: got %q, want %q", got, want)
+	}
+	if end != (Position{X: 3, Y: 2}) || EndOf(Position{X: 5, Y: 0}, []string{",", "new line", "and"}) != end {
+		t.Errorf("unexpected end position: %+v", end)
+	}
+
+	if got := c.TextRange(end, Position{X: 5, Y: 0}); !reflect.DeepEqual(got, []string{",", "new line", "and"}) {
+		t.Errorf("TextRange: got %q", got)
+	}
+
+	removed, err := c.DeleteRange(Position{X: 5, Y: 0}, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{",", "new line", "and"}) {
+		t.Errorf("DeleteRange removed %q", removed)
+	}
+	if got := c.GetAllLines(); !reflect.DeepEqual(got, []string{"hello world", "last"}) {
+		t.Errorf("DeleteRange: got %q", got)
+	}
+
+	// 逆順に指定しても同じ範囲を削除する
+	removed, _ = c.DeleteRange(Position{X: 2, Y: 1}, Position{X: 6, Y: 0})
+	if !reflect.DeepEqual(removed, []string{"world", "la"}) || c.GetContentLine(0) != "hello st" {
+		t.Errorf("unexpected result: %q %q", removed, c.GetAllLines())
+	}
+}
+
+func TestInsertTextAndDeleteRange_ReadOnly(t *testing.T) {
+	c := newProtectedContents()
+
+	if _, err := c.InsertText(Position{X: 0, Y: 1}, []string{"x"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("InsertText: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := c.DeleteRange(Position{X: 0, Y: 1}, Position{X: 0, Y: 2}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteRange: expected ErrReadOnly, got %v", err)
+	}
+
+	// 保護された範囲より前の行の増減に範囲が追従する
+	c.InsertText(Position{X: 0, Y: 3}, []string{"a", "b", ""})
+	c.DeleteRange(Position{X: 0, Y: 2}, Position{X: 0, Y: 4})
+	if got := c.ReadOnlyRegions(); got[0].Start != 0 || got[0].End != 2 {
+		t.Errorf("regions must stay in place: %+v", got)
+	}
+	c.InsertText(Position{X: 0, Y: 2}, []string{"", ""})
+	c.ProtectLines(4, 5)
+	c.DeleteRange(Position{X: 0, Y: 2}, Position{X: 0, Y: 3})
+	if got := c.ReadOnlyRegions(); got[1].Start != 3 || got[1].End != 4 {
+		t.Errorf("regions after the range must move up: %+v", got)
+	}
+}
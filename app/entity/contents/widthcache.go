@@ -0,0 +1,155 @@
+package contents
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	// defaultWidthCacheSize はキャッシュする行数の上限
+	defaultWidthCacheSize = 4096
+	// minCachedRunes より短い行は計算の方が安いのでキャッシュしない
+	minCachedRunes = 32
+)
+
+// widthEntry は1行分の文字幅情報。共有されるため作成後は変更しない
+type widthEntry struct {
+	chars      string
+	widths     []int
+	positions  []int
+	totalWidth int
+}
+
+// WidthCacheStats はキャッシュの利用状況
+type WidthCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// HitRate はヒット率（0〜1）を返す
+func (s WidthCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// WidthCache は行の内容をキーに文字幅情報を保持するキャッシュ
+// 行番号ではなく内容で引くため、編集で rowCache が破棄された後や
+// スクロールで再描画する場合も、変更のない行の幅は再計算しない
+type WidthCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[uint64]*widthEntry
+	order    []uint64 // 追加順（古いものから削除する）
+	next     int
+	stats    WidthCacheStats
+}
+
+// NewWidthCache は capacity 行を上限とする WidthCache を作成する
+func NewWidthCache(capacity int) *WidthCache {
+	return &WidthCache{
+		capacity: capacity,
+		entries:  make(map[uint64]*widthEntry, capacity),
+		order:    make([]uint64, 0, capacity),
+	}
+}
+
+var sharedWidthCache = NewWidthCache(defaultWidthCacheSize)
+
+// SharedWidthCache は全てのバッファで共有される WidthCache を返す
+func SharedWidthCache() *WidthCache {
+	return sharedWidthCache
+}
+
+// Stats はキャッシュの利用状況を返す
+func (c *WidthCache) Stats() WidthCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = len(c.entries)
+	return stats
+}
+
+// lookup は runes の幅情報を返す。キャッシュにない場合は計算して追加する
+func (c *WidthCache) lookup(chars string, runes []rune) *widthEntry {
+	if len(runes) < minCachedRunes {
+		return computeWidths(chars, runes)
+	}
+
+	key := hashLine(chars)
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && e.chars == chars {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// 幅の計算はロックの外で行い、結果を後から書き込む
+	e := computeWidths(chars, runes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		if len(c.order) < c.capacity {
+			c.order = append(c.order, key)
+		} else {
+			delete(c.entries, c.order[c.next])
+			c.order[c.next] = key
+			c.next = (c.next + 1) % c.capacity
+			c.stats.Evictions++
+		}
+	}
+	c.entries[key] = e
+	return e
+}
+
+// computeWidths は行の文字幅と各文字の表示位置を計算する
+func computeWidths(chars string, runes []rune) *widthEntry {
+	e := &widthEntry{
+		chars:     chars,
+		widths:    make([]int, len(runes)),
+		positions: make([]int, len(runes)+1),
+	}
+	for i, ch := range runes {
+		w := getCharWidth(ch)
+		e.widths[i] = w
+		e.positions[i] = e.totalWidth
+		e.totalWidth += w
+	}
+	e.positions[len(runes)] = e.totalWidth
+	return e
+}
+
+func hashLine(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Trim は新しく追加した keep 行分を残してキャッシュを捨てる
+// map は要素を消しても縮まないため、残すものだけを入れて作り直す
+func (c *WidthCache) Trim(keep int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keep = max(keep, 0)
+	// order は c.next から古い順に並んでいる
+	ordered := append(append([]uint64(nil), c.order[c.next:]...), c.order[:c.next]...)
+	if len(ordered) <= keep {
+		return
+	}
+	ordered = ordered[len(ordered)-keep:]
+	entries := make(map[uint64]*widthEntry, c.capacity)
+	for _, key := range ordered {
+		entries[key] = c.entries[key]
+	}
+	c.stats.Evictions += int64(len(c.entries) - len(entries))
+	c.entries = entries
+	c.order = append(make([]uint64, 0, c.capacity), ordered...)
+	c.next = 0
+}
@@ -0,0 +1,74 @@
+package contents
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWidthCache_HitAndEvict(t *testing.T) {
+	c := NewWidthCache(2)
+	long := func(s string) string { return strings.Repeat(s, minCachedRunes) }
+
+	a := long("あ")
+	e := c.lookup(a, []rune(a))
+	if e.totalWidth != 2*minCachedRunes || e.positions[1] != 2 {
+		t.Fatalf("unexpected widths: total=%d", e.totalWidth)
+	}
+	if again := c.lookup(a, []rune(a)); again != e {
+		t.Error("same content must hit the cache")
+	}
+
+	c.lookup(long("b"), []rune(long("b")))
+	c.lookup(long("c"), []rune(long("c")))
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 3 || stats.Evictions != 1 || stats.Size != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.HitRate() != 0.25 {
+		t.Errorf("unexpected hit rate: %v", stats.HitRate())
+	}
+
+	// 短い行はキャッシュしない
+	c.lookup("short", []rune("short"))
+	if c.Stats().Misses != 3 {
+		t.Error("short lines must bypass the cache")
+	}
+}
+
+func TestRow_EditDoesNotCorruptSharedWidths(t *testing.T) {
+	line := strings.Repeat("x", minCachedRunes)
+	r1 := NewRow(line)
+	r2 := NewRow(line)
+
+	r1.InsertChar(0, 'あ')
+	if r2.OffsetToScreenPosition(minCachedRunes) != minCachedRunes {
+		t.Errorf("editing one row must not affect another: %d", r2.OffsetToScreenPosition(minCachedRunes))
+	}
+	if r1.OffsetToScreenPosition(1) != 2 {
+		t.Errorf("edited row must be recomputed: %d", r1.OffsetToScreenPosition(1))
+	}
+}
+
+func TestWidthCache_TrimKeepsNewest(t *testing.T) {
+	c := NewWidthCache(3)
+	long := func(s string) string { return strings.Repeat(s, minCachedRunes) }
+	for _, s := range []string{"a", "b", "c", "d"} {
+		c.lookup(long(s), []rune(long(s)))
+	}
+
+	c.Trim(2)
+	if stats := c.Stats(); stats.Size != 2 || stats.Evictions != 2 {
+		t.Fatalf("unexpected stats after trim: %+v", stats)
+	}
+	// 新しい c と d が残る
+	c.lookup(long("d"), []rune(long("d")))
+	c.lookup(long("c"), []rune(long("c")))
+	if hits := c.Stats().Hits; hits != 2 {
+		t.Errorf("the newest entries must survive the trim: hits=%d", hits)
+	}
+	c.lookup(long("e"), []rune(long("e")))
+	c.lookup(long("f"), []rune(long("f")))
+	if size := c.Stats().Size; size != 3 {
+		t.Errorf("the cache must fill up to its capacity again: %d", size)
+	}
+}
@@ -30,6 +30,13 @@ type MetricsCollector struct {
 	lastAlloc       uint64
 	lastSys         uint64
 	lastNumGoroutine int
+	widthCacheHits   int64
+	widthCacheMisses int64
+	widthCacheSize   int
+	openReadMs       float64
+	openSplitMs      float64
+	openRenderMs     float64
+	openBytes        int64
 }
 
 // NewMetricsCollector は新しい MetricsCollector を作成します。
@@ -111,6 +118,43 @@ func (m *MetricsCollector) RecordSystemStats(alloc uint64, totalAlloc uint64, sy
 	}
 }
 
+// RecordWidthCacheStats は行の文字幅キャッシュの累計ヒット数・ミス数と現在の件数を記録します。
+func (m *MetricsCollector) RecordWidthCacheStats(hits, misses int64, size int) {
+	if !m.Enabled() {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.widthCacheHits = hits
+	m.widthCacheMisses = misses
+	m.widthCacheSize = size
+	if m.logger != nil {
+		rate := 0.0
+		if hits+misses > 0 {
+			rate = float64(hits) / float64(hits+misses) * 100
+		}
+		m.logger.Log("metrics", fmt.Sprintf("width cache hits=%d misses=%d size=%d hitRate=%.1f%%", hits, misses, size, rate))
+	}
+}
+
+// RecordFileOpen はファイルを開く処理の読み込み・行への分割・最初の描画の所要時間を記録します。
+func (m *MetricsCollector) RecordFileOpen(read, split, render time.Duration, bytes int64, lines int) {
+	if !m.Enabled() {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.openReadMs = float64(read.Microseconds()) / 1000
+	m.openSplitMs = float64(split.Microseconds()) / 1000
+	m.openRenderMs = float64(render.Microseconds()) / 1000
+	m.openBytes = bytes
+	if m.logger != nil {
+		m.logger.Log("metrics", fmt.Sprintf("file open bytes=%d lines=%d read=%s split=%s firstRender=%s", bytes, lines, read, split, render))
+	}
+}
+
 func (m *MetricsCollector) Snapshot() map[string]interface{} {
 	if !m.Enabled() {
 		return nil
@@ -127,5 +171,12 @@ func (m *MetricsCollector) Snapshot() map[string]interface{} {
 		"allocBytes":       m.lastAlloc,
 		"sysBytes":         m.lastSys,
 		"goroutines":       m.lastNumGoroutine,
+		"widthCacheHits":   m.widthCacheHits,
+		"widthCacheMisses": m.widthCacheMisses,
+		"widthCacheSize":   m.widthCacheSize,
+		"openReadMs":       m.openReadMs,
+		"openSplitMs":      m.openSplitMs,
+		"openRenderMs":     m.openRenderMs,
+		"openBytes":        m.openBytes,
 	}
 }
@@ -0,0 +1,38 @@
+package term
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Colors は端末が表示できる色数を返す
+// COLORTERM でフルカラー対応を示していればそれを優先し、次に terminfo（tput colors）の値を使う
+// tput を実行できない場合は TERM から推定する
+func Colors() int {
+	if ct := strings.ToLower(os.Getenv("COLORTERM")); ct == "truecolor" || ct == "24bit" {
+		return 1 << 24
+	}
+	if out, err := exec.Command("tput", "colors").Output(); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(string(out))); err == nil {
+			return n
+		}
+	}
+	return colorsFromTerm(os.Getenv("TERM"))
+}
+
+// colorsFromTerm は TERM の値から色数を推定する。色を表示できない端末は 0 を返す
+func colorsFromTerm(name string) int {
+	switch {
+	case strings.HasSuffix(name, "-direct") || strings.Contains(name, "truecolor") || strings.Contains(name, "24bit"):
+		return 1 << 24
+	case strings.Contains(name, "256color"):
+		return 256
+	case strings.Contains(name, "16color"):
+		return 16
+	case name == "" || name == "dumb" || strings.HasPrefix(name, "vt"):
+		return 0
+	}
+	return 8
+}
@@ -57,20 +57,22 @@ func InitTerminal() error {
 }
 
 func GetWinSize() (screenRows, screenCols int) {
-	// ウィンドウサイズの取得
-	var ws *unix.Winsize
-	var err error
-	ws, err = unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	screenRows, screenCols, err := QueryWinSize()
 	if err != nil {
 		panic(err)
 	}
-
-	screenRows = int(ws.Row)
-	screenCols = int(ws.Col)
-
 	return screenRows, screenCols
 }
 
+// QueryWinSize は端末の大きさを取得する。起動後の大きさの変更に追従するために使う
+func QueryWinSize() (screenRows, screenCols int, err error) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Row), int(ws.Col), nil
+}
+
 // enableRawMode は端末をRawモードに設定する
 func EnableRawMode() (*TerminalState, error) {
 	term := &TerminalState{}
@@ -108,3 +110,40 @@ func (term *TerminalState) DisableRawMode() error {
 	}
 	return nil
 }
+
+// Suspend は端末を Raw モードにする前の状態に戻して run を実行し、終わったら Raw モードに戻す
+// sudo のパスワード入力など、外部のコマンドに端末を使わせる間に使う。run のエラーを返す
+func (term *TerminalState) Suspend(run func() error) error {
+	if term.origTermios != nil {
+		os.Stdout.WriteString("\x1b[?1049l\x1b[?1000l\x1b[?1002l\x1b[?1015l\x1b[?1006l")
+		if err := unix.IoctlSetTermios(int(os.Stdin.Fd()), unix.TCSETS, term.origTermios); err != nil {
+			return err
+		}
+	}
+	err := run()
+	if term.origTermios != nil {
+		if initErr := InitTerminal(); initErr != nil && err == nil {
+			err = initErr
+		}
+	}
+	return err
+}
+
+// Stop は端末を Raw モードにする前の状態に戻してプロセスを停止する（シェルのジョブ制御の Ctrl-Z と同じ）
+// fg などで SIGCONT を受けて再開したら、Raw モードと代替画面・マウスの報告を有効に戻す
+// SIGTSTP は受け取って処理するため、捕捉できない SIGSTOP で停止する
+func (term *TerminalState) Stop() error {
+	return term.Suspend(func() error {
+		return unix.Kill(unix.Getpid(), unix.SIGSTOP)
+	})
+}
+
+// PushTitle は現在の端末タイトルを端末側のスタックに退避する（xterm互換端末のみ有効）
+func (term *TerminalState) PushTitle() {
+	os.Stdout.WriteString("\x1b[22;0t")
+}
+
+// PopTitle は PushTitle で退避した端末タイトルを復元する
+func (term *TerminalState) PopTitle() {
+	os.Stdout.WriteString("\x1b[23;0t")
+}
@@ -0,0 +1,85 @@
+package diagnostic
+
+import "sort"
+
+// Severity は診断の深刻度。値は LSP の DiagnosticSeverity に合わせている
+type Severity int
+
+const (
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
+)
+
+// String は深刻度の表記を返す
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return "unknown"
+}
+
+// Diagnostic は言語サーバーなどが報告した1件の診断を表す
+// 位置は0始まりで、列はルーン単位。範囲は (Line, Col) から (EndLine, EndCol) の手前まで
+type Diagnostic struct {
+	Line     int
+	Col      int
+	EndLine  int
+	EndCol   int
+	Severity Severity
+	Message  string
+	Source   string // 診断を報告したツール（例: "compiler"）
+}
+
+// Sort は診断を位置の順に並べ替える
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Col < diags[j].Col
+	})
+}
+
+// Next は位置の順に並んだ diags のうち、(line, col) より後ろにある最初の診断の位置を返す
+// 後ろになければ先頭に戻る。診断がなければ false を返す
+func Next(diags []Diagnostic, line, col int) (int, bool) {
+	if len(diags) == 0 {
+		return 0, false
+	}
+	for i, d := range diags {
+		if d.Line > line || (d.Line == line && d.Col > col) {
+			return i, true
+		}
+	}
+	return 0, true
+}
+
+// At は line 行目の col 列目を含む診断を返す。その行に範囲を含む診断がなければ行内の最初の診断を返す
+func At(diags []Diagnostic, line, col int) (Diagnostic, bool) {
+	var first *Diagnostic
+	for i := range diags {
+		d := &diags[i]
+		if line < d.Line || line > d.EndLine {
+			continue
+		}
+		if (line > d.Line || col >= d.Col) && (line < d.EndLine || col < d.EndCol) {
+			return *d, true
+		}
+		if first == nil {
+			first = d
+		}
+	}
+	if first != nil {
+		return *first, true
+	}
+	return Diagnostic{}, false
+}
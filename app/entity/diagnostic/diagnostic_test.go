@@ -0,0 +1,52 @@
+package diagnostic
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	diags := []Diagnostic{{Line: 2, Col: 4}, {Line: 0, Col: 1}, {Line: 2, Col: 0}}
+	Sort(diags)
+
+	tests := []struct {
+		line, col int
+		want      Diagnostic
+	}{
+		{0, 0, Diagnostic{Line: 0, Col: 1}},
+		{0, 1, Diagnostic{Line: 2, Col: 0}},
+		{2, 0, Diagnostic{Line: 2, Col: 4}},
+		{5, 0, Diagnostic{Line: 0, Col: 1}}, // 末尾を越えたら先頭に戻る
+	}
+	for _, tt := range tests {
+		i, ok := Next(diags, tt.line, tt.col)
+		if !ok || diags[i] != tt.want {
+			t.Errorf("Next(%d, %d) = %v, %v; want %v", tt.line, tt.col, diags[i], ok, tt.want)
+		}
+	}
+	if _, ok := Next(nil, 0, 0); ok {
+		t.Error("Next on no diagnostics should fail")
+	}
+}
+
+func TestAt(t *testing.T) {
+	diags := []Diagnostic{
+		{Line: 1, Col: 0, EndLine: 1, EndCol: 3, Message: "first"},
+		{Line: 1, Col: 5, EndLine: 2, EndCol: 2, Message: "second"},
+	}
+	tests := []struct {
+		line, col int
+		want      string
+	}{
+		{1, 1, "first"},
+		{1, 6, "second"},
+		{2, 1, "second"},
+		{1, 4, "first"}, // 範囲外でも同じ行の診断を返す
+	}
+	for _, tt := range tests {
+		d, ok := At(diags, tt.line, tt.col)
+		if !ok || d.Message != tt.want {
+			t.Errorf("At(%d, %d) = %q, %v; want %q", tt.line, tt.col, d.Message, ok, tt.want)
+		}
+	}
+	if _, ok := At(diags, 0, 0); ok {
+		t.Error("At on a line without diagnostics should fail")
+	}
+}
@@ -21,6 +21,7 @@ type Bus struct {
 	defaultHandler Handler
 	synchronous    bool
 	metrics        *core.MetricsCollector
+	tracer         *Tracer
 }
 
 // NewBus は新しいイベントバスを作成します。
@@ -77,6 +78,20 @@ func (b *Bus) EventQueueLength() int {
 	return len(b.eventChan)
 }
 
+// SetTracer はイベントの記録を開始する。nil を渡すと記録を止める
+func (b *Bus) SetTracer(t *Tracer) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tracer = t
+}
+
+// Tracer は記録中の Tracer を返す。記録していない場合は nil
+func (b *Bus) Tracer() *Tracer {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.tracer
+}
+
 // SetSynchronous はイベントバスの同期モードを設定します。
 // テスト用：trueの場合、Publishはイベントを即座に処理します。
 func (b *Bus) SetSynchronous(sync bool) {
@@ -90,11 +105,15 @@ func (b *Bus) Publish(event Event) {
 	b.mutex.RLock()
 	isSync := b.synchronous
 	metrics := b.metrics
+	tracer := b.tracer
 	b.mutex.RUnlock()
 
 	if metrics != nil {
 		metrics.RecordEventPublished(string(event.Type))
 	}
+	if tracer != nil {
+		event.traceSeq = tracer.published(event)
+	}
 
 	if isSync {
 		b.dispatchEvent(event)
@@ -144,7 +163,12 @@ func (b *Bus) dispatchEvent(event Event) {
 	handlers, exists := b.handlers[event.Type]
 	defaultHandler := b.defaultHandler
 	responseChan, hasResponseChan := b.responseChans[event.Type]
+	tracer := b.tracer
 	b.mutex.RUnlock()
+	if event.traceSeq == 0 {
+		// 記録を開始する前に発行されたイベントは記録しない
+		tracer = nil
+	}
 
 	var handled bool
 	var lastErr error
@@ -152,8 +176,16 @@ func (b *Bus) dispatchEvent(event Event) {
 
 	// 登録されたハンドラーにイベントを配送
 	if exists {
-		for _, handler := range handlers {
+		for i, handler := range handlers {
+			handlerStart := time.Now()
 			success, err := handler.HandleEvent(event)
+			if tracer != nil {
+				trace := HandlerTrace{Name: handlerName(handler, i), Duration: time.Since(handlerStart), Handled: success}
+				if err != nil {
+					trace.Error = err.Error()
+				}
+				tracer.handled(event.traceSeq, handlerStart, trace)
+			}
 			if err != nil {
 				lastErr = fmt.Errorf("handler error: %w", err)
 			}
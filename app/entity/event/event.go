@@ -1,27 +1,39 @@
 package event
 
-import "github.com/wasya-io/go-kilo/app/entity/cursor"
+import (
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/diagnostic"
+)
 
 // EventType はイベントの種類を表す型です。
 type EventType string
 
 // 定義済みイベントタイプ
 const (
-	TypeSave     EventType = "save"     // 保存イベント
-	TypeQuit     EventType = "quit"     // 終了イベント
-	TypeInput    EventType = "input"    // 入力イベント
-	TypeRefresh  EventType = "refresh"  // 画面更新イベント
-	TypeCursor   EventType = "cursor"   // カーソルイベント
-	TypeBuffer   EventType = "buffer"   // バッファイベント
-	TypeCommand  EventType = "command"  // コマンド実行イベント
-	TypeResponse EventType = "response" // 応答イベント
-	TypeError    EventType = "error"    // エラーイベント
+	TypeSave        EventType = "save"        // 保存イベント
+	TypeQuit        EventType = "quit"        // 終了イベント
+	TypeInput       EventType = "input"       // 入力イベント
+	TypeRefresh     EventType = "refresh"     // 画面更新イベント
+	TypeCursor      EventType = "cursor"      // カーソルイベント
+	TypeBuffer      EventType = "buffer"      // バッファイベント
+	TypeCommand     EventType = "command"     // コマンド実行イベント
+	TypeResponse    EventType = "response"    // 応答イベント
+	TypeError       EventType = "error"       // エラーイベント
+	TypeCheckpoint  EventType = "checkpoint"  // 復元用スナップショットの取得イベント
+	TypeKeyHandled  EventType = "keyhandled"  // キー入力の処理完了イベント
+	TypeFileLoaded  EventType = "fileloaded"  // ファイルの残りの読み込み完了イベント
+	TypeResize      EventType = "resize"      // 端末の大きさの変更イベント
+	TypeDiagnostics EventType = "diagnostics" // 言語サーバーの診断の更新イベント
 )
 
 // Event はアプリケーション内で発生するイベントを表します。
 type Event struct {
-	Type    EventType   // イベントの種類
-	Payload interface{} // イベントデータ
+	Type     EventType   // イベントの種類
+	Payload  interface{} // イベントデータ
+	traceSeq int         // トレース中に発行された場合の記録の通し番号
 }
 
 // SaveEvent は保存イベントのペイロードを表します。
@@ -40,6 +52,7 @@ type CursorEvent struct {
 	Action cursor.Movement // カーソル移動アクション
 	Row    int             // CursorSetの場合の行位置
 	Col    int             // CursorSetの場合の列位置
+	Count  int             // 移動を繰り返す回数（0 は1回として扱う）
 }
 
 // BufferAction はバッファ操作の種類を表します。
@@ -49,12 +62,95 @@ const (
 	BufferInsert BufferAction = iota
 	BufferDelete
 	BufferNewline
+	BufferUndo
+	BufferRedo
+	BufferSelectExtend   // 選択していなければカーソル位置から選択を始める
+	BufferSelectCollapse // Shift+矢印キーで始めた選択を解除する
+	BufferSelectToggle   // 選択の開始・解除を切り替える
+	BufferSelectCancel   // 選択を解除する
+	BufferCopy
+	BufferCut
+	BufferPaste
+	BufferTransposeChars
+	BufferTransposeLines
+	BufferSplitHorizontal
+	BufferSplitVertical
+	BufferFocusNext
+	BufferFocusWindow // Window の位置のウィンドウにフォーカスを移す
+	BufferCloseOthers
+	BufferZoomWindow   // フォーカスのあるウィンドウの最大化と、元の分割への復元を切り替える
+	BufferReplace      // Replacements の置き換えをまとめて適用する
+	BufferResizeWindow // フォーカスのあるウィンドウを Size 行（左右に分割している場合は桁）大きくする
+	BufferMoveDivider  // 区画の境界を画面上の位置 Size に移す
+	BufferShowBuffer   // Buffer の位置の開いているバッファをフォーカスのあるウィンドウに表示する
+	BufferCloseBuffer  // フォーカスのあるウィンドウのバッファを閉じる
+	BufferLineEnding   // 保存する際の改行コードを LineEnding に変える
+	BufferSetLines     // バッファ全体を Lines に置き換える
+	BufferRevert       // 変更を破棄してファイルを読み直す
+	BufferSelectBlock  // 矩形選択の開始・解除を切り替える
+	BufferSelectRange  // Start から End までを選択し、カーソルを End に置く（同じ位置なら選択を解除して移動する）
+	BufferInsertText   // Lines をカーソル位置に挿入する（選択中の場合は選択範囲を置き換える）
+	BufferDeleteRange  // Start から End の手前までを削除する
+	BufferDeleteNext   // カーソル位置の文字を削除する（行末では次の行と結合する）
+	BufferKillLine     // カーソル行全体を削除してクリップボードに保存する
+	BufferDupLine      // カーソル行を複製して下に挿入する
+	BufferMoveLine     // カーソル行を Size 行（-1 なら上、1 なら下）移動する
+	BufferJoinLine     // カーソル行と次の行を空白1つで結合する
+	BufferEncoding     // 保存する際の文字コードを Encoding に変える
+	BufferReopen       // 変更を破棄して、文字コードを Encoding としてファイルを読み直す
+	BufferReloadFiles  // Files のいずれかを表示している、未保存の変更がないバッファを読み直す
+	BufferDetach       // フォーカスのあるウィンドウに空の新しいバッファを割り当てる
+	BufferDiscard      // フォーカスのあるウィンドウのバッファを閉じ、Buffer の位置のバッファを表示し直す
 )
 
 // BufferEvent はバッファイベントのペイロードを表します。
 type BufferEvent struct {
-	Action BufferAction
-	Rune   rune
+	Action       BufferAction
+	Rune         rune
+	Replacements []contents.Replacement // BufferReplace の場合の置き換え
+	Window       int                    // BufferFocusWindow の場合のウィンドウの位置
+	Size         int                    // BufferResizeWindow の場合の増分、BufferMoveDivider の場合の位置、BufferMoveLine の場合の向き
+	Buffer       int                    // BufferShowBuffer / BufferDiscard の場合の開いているバッファの位置
+	LineEnding   contents.LineEnding    // BufferLineEnding の場合の改行コード
+	Encoding     contents.Encoding      // BufferEncoding / BufferReopen の場合の文字コード
+	Lines        []string               // BufferSetLines の場合の置き換え後の内容、BufferInsertText の場合の挿入する文字列
+	Start, End   contents.Position      // BufferSelectRange / BufferDeleteRange の範囲
+	Files        []string               // BufferReloadFiles の場合の読み直すファイル
+	Result       chan<- error           // nil でなければ処理の結果を送る（処理の完了を待つ場合に使う）
+}
+
+// CheckpointEvent は復元用スナップショット取得イベントのペイロードを表します。
+type CheckpointEvent struct {
+	Time time.Time // 取得を要求した時刻
+	Idle bool      // 一連の編集の区切りでだけ取得する（書き出す時機でなければ何もしない）
+}
+
+// KeyHandledEvent はキー入力の処理完了イベントのペイロードを表します。
+type KeyHandledEvent struct {
+	Key string // 処理したキーの表記（例: "C-s"）
+}
+
+// FileLoadedEvent はファイルの残りの読み込み完了イベントのペイロードを表します。
+// Partial の場合は読み込みの途中で、続きの行が後のイベントで届きます。
+type FileLoadedEvent struct {
+	Generation int      // 読み込みを開始したときの世代（別のファイルを開き直した場合に古い結果を捨てるため）
+	Lines      []string // 読み込んだ残りの行
+	Err        error    // 読み込みに失敗した場合のエラー
+	Partial    bool     // 読み込みの途中
+	Percent    int      // Partial の場合の、ファイル全体のうち読み込んだ割合（%）
+}
+
+// ResizeEvent は端末の大きさの変更イベントのペイロードを表します。
+type ResizeEvent struct {
+	Rows int // 端末の行数
+	Cols int // 端末の桁数
+}
+
+// DiagnosticsEvent は言語サーバーの診断の更新イベントのペイロードを表します。
+// 列位置は言語サーバーが報告したまま（UTF-16 のコード単位）で、バッファの内容に合わせてルーン単位にするのは受け取った側で行います。
+type DiagnosticsEvent struct {
+	Path        string                  // 診断の対象のファイルの絶対パス
+	Diagnostics []diagnostic.Diagnostic // 対象のファイルの全ての診断（空ならすべて解消した）
 }
 
 // ResponseEvent はコマンド応答イベントのペイロードを表します。
@@ -94,11 +190,21 @@ func NewEvent(eventType EventType, payload interface{}) Event {
 	}
 }
 
+// RefreshEvent は画面更新イベントのペイロードを表します。
+type RefreshEvent struct {
+	Now bool // 遅らせずにすぐ再描画する（遅らせた再描画の時刻になった場合）
+}
+
 // NewRefreshEvent は新しい画面更新イベントを作成します。
 func NewRefreshEvent() Event {
 	return NewEvent(TypeRefresh, nil)
 }
 
+// NewRefreshNowEvent は遅らせずにすぐ再描画する画面更新イベントを作成します。
+func NewRefreshNowEvent() Event {
+	return NewEvent(TypeRefresh, RefreshEvent{Now: true})
+}
+
 // NewSaveEvent は新しい保存イベントを作成します。
 func NewSaveEvent(filename string, force bool) Event {
 	return NewEvent(TypeSave, SaveEvent{
@@ -121,6 +227,15 @@ func NewCursorEvent(action cursor.Movement) Event {
 	})
 }
 
+// NewRepeatedCursorEvent は action を count 回繰り返すカーソルイベントを作成します。
+// 続けて届いたマウスホイールのイベントを、1回の移動と描画にまとめるために使います。
+func NewRepeatedCursorEvent(action cursor.Movement, count int) Event {
+	return NewEvent(TypeCursor, CursorEvent{
+		Action: action,
+		Count:  count,
+	})
+}
+
 // NewCursorSetEvent は新しいカーソル位置指定イベントを作成します。
 func NewCursorSetEvent(row, col int) Event {
 	return NewEvent(TypeCursor, CursorEvent{
@@ -138,6 +253,181 @@ func NewBufferEvent(action BufferAction, r rune) Event {
 	})
 }
 
+// NewReplaceEvent は置き換えをまとめて適用するバッファイベントを作成します。
+func NewReplaceEvent(reps []contents.Replacement) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action:       BufferReplace,
+		Replacements: reps,
+	})
+}
+
+// NewFocusWindowEvent は index の位置のウィンドウにフォーカスを移すバッファイベントを作成します。
+func NewFocusWindowEvent(index int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferFocusWindow,
+		Window: index,
+	})
+}
+
+// NewResizeWindowEvent はフォーカスのあるウィンドウを delta だけ大きくするバッファイベントを作成します。
+func NewResizeWindowEvent(delta int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferResizeWindow,
+		Size:   delta,
+	})
+}
+
+// NewMoveDividerEvent は区画の境界を画面上の位置 pos に移すバッファイベントを作成します。
+func NewMoveDividerEvent(pos int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferMoveDivider,
+		Size:   pos,
+	})
+}
+
+// NewMoveLineEvent はカーソル行を delta 行（-1 なら上、1 なら下）移動するバッファイベントを作成します。
+func NewMoveLineEvent(delta int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferMoveLine,
+		Size:   delta,
+	})
+}
+
+// NewShowBufferEvent は index の位置の開いているバッファをフォーカスのあるウィンドウに表示するバッファイベントを作成します。
+func NewShowBufferEvent(index int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferShowBuffer,
+		Buffer: index,
+	})
+}
+
+// NewDetachBufferEvent はフォーカスのあるウィンドウに空の新しいバッファを割り当てるバッファイベントを作成します。
+func NewDetachBufferEvent() Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferDetach,
+	})
+}
+
+// NewDiscardBufferEvent はフォーカスのあるウィンドウのバッファを閉じ、index の位置のバッファを表示し直すバッファイベントを作成します。
+// ファイルを開けなかった場合に、そのために割り当てた新しいバッファを片付けるのに使います。
+func NewDiscardBufferEvent(index int) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferDiscard,
+		Buffer: index,
+	})
+}
+
+// NewLineEndingEvent は保存する際の改行コードを ending に変えるバッファイベントを作成します。
+func NewLineEndingEvent(ending contents.LineEnding) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action:     BufferLineEnding,
+		LineEnding: ending,
+	})
+}
+
+// NewEncodingEvent は保存する際の文字コードを enc に変えるバッファイベントを作成します。
+func NewEncodingEvent(enc contents.Encoding) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action:   BufferEncoding,
+		Encoding: enc,
+	})
+}
+
+// NewReopenEvent は文字コードを enc としてファイルを読み直すバッファイベントを作成します。
+func NewReopenEvent(enc contents.Encoding) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action:   BufferReopen,
+		Encoding: enc,
+	})
+}
+
+// NewReloadFilesEvent は files のいずれかを表示しているバッファを読み直すバッファイベントを作成します。
+func NewReloadFilesEvent(files []string) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferReloadFiles,
+		Files:  files,
+	})
+}
+
+// NewSelectRangeEvent は start から end までを選択するバッファイベントを作成します。
+// result には処理の結果が送られます。
+func NewSelectRangeEvent(start, end contents.Position, result chan<- error) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferSelectRange,
+		Start:  start,
+		End:    end,
+		Result: result,
+	})
+}
+
+// NewInsertTextEvent はカーソル位置に text を挿入するバッファイベントを作成します。
+// result には処理の結果が送られます。
+func NewInsertTextEvent(text []string, result chan<- error) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferInsertText,
+		Lines:  text,
+		Result: result,
+	})
+}
+
+// NewDeleteRangeEvent は start から end の手前までを削除するバッファイベントを作成します。
+// result には処理の結果が送られます。
+func NewDeleteRangeEvent(start, end contents.Position, result chan<- error) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferDeleteRange,
+		Start:  start,
+		End:    end,
+		Result: result,
+	})
+}
+
+// NewSetLinesEvent はバッファ全体を lines に置き換えるバッファイベントを作成します。
+func NewSetLinesEvent(lines []string) Event {
+	return NewEvent(TypeBuffer, BufferEvent{
+		Action: BufferSetLines,
+		Lines:  lines,
+	})
+}
+
+// NewCheckpointEvent は新しいスナップショット取得イベントを作成します。
+// バッファを編集するのと同じゴルーチンでスナップショットを取るため、イベントとして発行します。
+func NewCheckpointEvent(now time.Time) Event {
+	return NewEvent(TypeCheckpoint, CheckpointEvent{Time: now})
+}
+
+// NewIdleCheckpointEvent は一連の編集の区切りでだけスナップショットを取得するイベントを作成します。
+func NewIdleCheckpointEvent(now time.Time) Event {
+	return NewEvent(TypeCheckpoint, CheckpointEvent{Time: now, Idle: true})
+}
+
+// NewKeyHandledEvent は新しいキー入力処理完了イベントを作成します。
+// キー入力によって発行されたイベントの後に処理されるため、編集結果を観察するのに使います。
+func NewKeyHandledEvent(key string) Event {
+	return NewEvent(TypeKeyHandled, KeyHandledEvent{Key: key})
+}
+
+// NewFileLoadedEvent は新しい読み込み完了イベントを作成します。
+// バッファを編集するのと同じゴルーチンで行を追加するため、イベントとして発行します。
+func NewFileLoadedEvent(generation int, lines []string, err error) Event {
+	return NewEvent(TypeFileLoaded, FileLoadedEvent{Generation: generation, Lines: lines, Err: err})
+}
+
+// NewFileProgressEvent は読み込みの途中で、続きの行 lines を読み込んだイベントを作成します。
+func NewFileProgressEvent(generation int, lines []string, percent int) Event {
+	return NewEvent(TypeFileLoaded, FileLoadedEvent{Generation: generation, Lines: lines, Partial: true, Percent: percent})
+}
+
+// NewResizeEvent は新しい端末の大きさの変更イベントを作成します。
+// 描画と同じゴルーチンで画面の大きさを変えるため、イベントとして発行します。
+func NewResizeEvent(rows, cols int) Event {
+	return NewEvent(TypeResize, ResizeEvent{Rows: rows, Cols: cols})
+}
+
+// NewDiagnosticsEvent は言語サーバーの診断の更新イベントを作成します。
+func NewDiagnosticsEvent(path string, diags []diagnostic.Diagnostic) Event {
+	return NewEvent(TypeDiagnostics, DiagnosticsEvent{Path: path, Diagnostics: diags})
+}
+
 // NewResponseEvent は新しい応答イベントを作成します。
 func NewResponseEvent(success bool, message string, err error) Event {
 	return NewEvent(TypeResponse, ResponseEvent{
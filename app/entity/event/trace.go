@@ -0,0 +1,193 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTraceDetail はイベントの内容として記録する文字数の上限
+const maxTraceDetail = 80
+
+// TraceRecord は発行された1件のイベントの記録
+type TraceRecord struct {
+	Seq       int            `json:"seq"`
+	Type      EventType      `json:"type"`
+	Detail    string         `json:"detail,omitempty"`
+	Published time.Time      `json:"published"`
+	QueueWait time.Duration  `json:"queue_wait_ns"` // 発行からハンドラーの呼び出しまでの待ち時間
+	Handlers  []HandlerTrace `json:"handlers,omitempty"`
+}
+
+// HandlerTrace はイベントを受け取った1つのハンドラーの処理の記録
+type HandlerTrace struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration_ns"`
+	Handled  bool          `json:"handled"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Tracer はイベントバスに発行されたイベントとハンドラーの処理時間を記録する
+// 上限を超えた場合は古い記録から捨てる
+type Tracer struct {
+	mu      sync.Mutex
+	records []TraceRecord
+	nextSeq int
+	limit   int
+	dropped int
+}
+
+// NewTracer は最大 limit 件のイベントを記録する Tracer を作成する
+func NewTracer(limit int) *Tracer {
+	return &Tracer{limit: limit, nextSeq: 1}
+}
+
+// published はイベントの発行を記録し、記録の通し番号を返す
+func (t *Tracer) published(e Event) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seq := t.nextSeq
+	t.nextSeq++
+	t.records = append(t.records, TraceRecord{Seq: seq, Type: e.Type, Detail: traceDetail(e.Payload), Published: time.Now()})
+	if t.limit > 0 && len(t.records) > t.limit {
+		t.records = t.records[1:]
+		t.dropped++
+	}
+	return seq
+}
+
+// handled はハンドラーの処理を seq のイベントの記録に追加する
+func (t *Tracer) handled(seq int, start time.Time, h HandlerTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := t.record(seq)
+	if r == nil {
+		return
+	}
+	if len(r.Handlers) == 0 {
+		r.QueueWait = start.Sub(r.Published)
+	}
+	r.Handlers = append(r.Handlers, h)
+}
+
+// record は seq の記録を返す。既に捨てた記録の場合は nil を返す
+func (t *Tracer) record(seq int) *TraceRecord {
+	if len(t.records) == 0 {
+		return nil
+	}
+	i := seq - t.records[0].Seq
+	if i < 0 || i >= len(t.records) {
+		return nil
+	}
+	return &t.records[i]
+}
+
+// Records は記録したイベントを発行順に返す
+func (t *Tracer) Records() []TraceRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := make([]TraceRecord, len(t.records))
+	for i, r := range t.records {
+		r.Handlers = append([]HandlerTrace(nil), r.Handlers...)
+		records[i] = r
+	}
+	return records
+}
+
+// Dropped は上限を超えて捨てた記録の件数を返す
+func (t *Tracer) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// WriteJSON は記録を JSON として書き出す
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Dropped int           `json:"dropped"`
+		Events  []TraceRecord `json:"events"`
+	}{t.Dropped(), t.Records()})
+}
+
+// WriteMermaid は記録を mermaid のシーケンス図として書き出す
+// イベントごとにバスからハンドラーへの呼び出しと、処理時間を付けた戻りを描く
+func (t *Tracer) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	b.WriteString("    participant Bus\n")
+	if dropped := t.Dropped(); dropped > 0 {
+		fmt.Fprintf(&b, "    Note over Bus: %d older events dropped\n", dropped)
+	}
+	for _, r := range t.Records() {
+		label := fmt.Sprintf("#%d %s", r.Seq, r.Type)
+		if r.Detail != "" {
+			label += " " + r.Detail
+		}
+		if len(r.Handlers) == 0 {
+			fmt.Fprintf(&b, "    Note over Bus: %s (%s, not handled)\n", mermaidText(label), r.Published.Format("15:04:05.000"))
+			continue
+		}
+		for _, h := range r.Handlers {
+			id := mermaidID(h.Name)
+			fmt.Fprintf(&b, "    Bus->>+%s: %s\n", id, mermaidText(label))
+			result := "ok"
+			switch {
+			case h.Error != "":
+				result = "error: " + h.Error
+			case !h.Handled:
+				result = "skipped"
+			}
+			fmt.Fprintf(&b, "    %s-->>-Bus: %s in %s\n", id, mermaidText(result), h.Duration)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// handlerName はトレースに表示するハンドラーの名前を返す（処理するイベントタイプと登録順）
+func handlerName(h Handler, index int) string {
+	var types []string
+	for _, t := range h.GetHandledEventTypes() {
+		types = append(types, string(t))
+	}
+	name := strings.Join(types, "+")
+	if name == "" {
+		name = "handler"
+	}
+	if index > 0 {
+		name += fmt.Sprintf("#%d", index+1)
+	}
+	return name
+}
+
+// traceDetail はイベントの内容を短い文字列にする
+func traceDetail(payload interface{}) string {
+	if payload == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%+v", payload)
+	if r := []rune(s); len(r) > maxTraceDetail {
+		s = string(r[:maxTraceDetail]) + "…"
+	}
+	return s
+}
+
+// mermaidID はハンドラーの名前を mermaid の参加者名として使える形にする
+func mermaidID(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// mermaidText は mermaid の文法と衝突する文字（文の区切りと改行）を置き換える
+func mermaidText(s string) string {
+	return strings.NewReplacer(";", ",", "\n", " ").Replace(s)
+}
@@ -0,0 +1,85 @@
+package event_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+func newTracedBus(t *testing.T, limit int) (*event.Bus, *event.Tracer) {
+	t.Helper()
+	bus := event.NewBus()
+	bus.SetSynchronous(true)
+	t.Cleanup(bus.Shutdown)
+	tracer := event.NewTracer(limit)
+	bus.SetTracer(tracer)
+	return bus, tracer
+}
+
+func TestTracer_RecordsEventsAndHandlers(t *testing.T) {
+	bus, tracer := newTracedBus(t, 0)
+	bus.Subscribe(event.NewSingleTypeHandler(event.TypeSave, func(e event.Event) (bool, error) {
+		// ハンドラーの中から発行したイベントも順に記録される
+		bus.Publish(event.NewRefreshEvent())
+		return true, nil
+	}))
+	bus.Subscribe(event.NewSingleTypeHandler(event.TypeRefresh, func(e event.Event) (bool, error) {
+		return false, errors.New("boom")
+	}))
+
+	bus.Publish(event.NewSaveEvent("a.txt", false))
+
+	records := tracer.Records()
+	if len(records) < 2 || records[0].Type != event.TypeSave || records[1].Type != event.TypeRefresh {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if !strings.Contains(records[0].Detail, "a.txt") || len(records[0].Handlers) != 1 || !records[0].Handlers[0].Handled {
+		t.Errorf("save record: %+v", records[0])
+	}
+	if h := records[1].Handlers; len(h) != 1 || h[0].Name != "refresh" || h[0].Error != "boom" {
+		t.Errorf("refresh handlers: %+v", h)
+	}
+
+	var buf bytes.Buffer
+	if err := tracer.WriteMermaid(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"sequenceDiagram", "Bus->>+save: #1 save", "refresh-->>-Bus: error: boom in "} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("mermaid output must contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := tracer.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Events []event.TraceRecord `json:"events"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Events) != len(records) {
+		t.Errorf("invalid JSON trace: %v %s", err, buf.String())
+	}
+}
+
+func TestTracer_Limit(t *testing.T) {
+	bus, tracer := newTracedBus(t, 2)
+	for i := 0; i < 3; i++ {
+		bus.Publish(event.NewRefreshEvent())
+	}
+	records := tracer.Records()
+	if len(records) != 2 || records[0].Seq != 2 || tracer.Dropped() != 1 {
+		t.Errorf("oldest record must be dropped: %+v dropped=%d", records, tracer.Dropped())
+	}
+
+	// 記録を止めた後のイベントは記録しない
+	bus.SetTracer(nil)
+	bus.Publish(event.NewRefreshEvent())
+	if len(tracer.Records()) != 2 {
+		t.Error("events after stopping must not be recorded")
+	}
+}
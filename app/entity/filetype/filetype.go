@@ -0,0 +1,49 @@
+package filetype
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Text は種類を判定できないファイルの種類
+const Text = "Text"
+
+// byExtension は拡張子（小文字）ごとのファイルの種類
+var byExtension = map[string]string{
+	".go":   "Go",
+	".md":   "Markdown",
+	".py":   "Python",
+	".js":   "JavaScript",
+	".ts":   "TypeScript",
+	".json": "JSON",
+	".yaml": "YAML",
+	".yml":  "YAML",
+	".toml": "TOML",
+	".sh":   "Shell",
+	".c":    "C",
+	".h":    "C",
+	".txt":  Text,
+}
+
+// byName は拡張子を持たないファイル名ごとのファイルの種類
+var byName = map[string]string{
+	"Makefile": "Makefile",
+	"go.mod":   "Go Module",
+}
+
+// Detect はファイル名からファイルの種類を判定する。判定できない場合は Text を返す
+func Detect(filename string) string {
+	base := filepath.Base(filename)
+	if t, ok := byName[base]; ok {
+		return t
+	}
+	if t, ok := byExtension[strings.ToLower(filepath.Ext(base))]; ok {
+		return t
+	}
+	return Text
+}
+
+// Names は選択できるファイルの種類を名前順に返す
+func Names() []string {
+	return []string{"C", "Go", "Go Module", "JSON", "JavaScript", "Makefile", "Markdown", "Python", "Shell", "TOML", Text, "TypeScript", "YAML"}
+}
@@ -0,0 +1,45 @@
+package filetype
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"main.go":          "Go",
+		"/tmp/README.MD":   "Markdown",
+		"config.yml":       "YAML",
+		"src/Makefile":     "Makefile",
+		"go.mod":           "Go Module",
+		"notes":            Text,
+		"":                 Text,
+		"archive.tar.json": "JSON",
+	}
+	for filename, want := range tests {
+		if got := Detect(filename); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestNames_CoversDetectedTypes(t *testing.T) {
+	names := Names()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("names must be sorted: %v", names)
+	}
+	known := make(map[string]bool)
+	for _, n := range names {
+		known[n] = true
+	}
+	for _, t2 := range byExtension {
+		if !known[t2] {
+			t.Errorf("%q is missing from Names", t2)
+		}
+	}
+	for _, t2 := range byName {
+		if !known[t2] {
+			t.Errorf("%q is missing from Names", t2)
+		}
+	}
+}
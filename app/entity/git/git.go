@@ -0,0 +1,27 @@
+package git
+
+// Change は HEAD と比べた行の変更の種類
+type Change int
+
+const (
+	ChangeNone     Change = iota
+	ChangeAdded           // 追加した行
+	ChangeModified        // 変更した行
+	ChangeDeleted         // この行の直後（先頭の行では直前）の行を削除した
+)
+
+// Sign はガターに表示する変更の印を返す
+func (c Change) Sign() string {
+	switch c {
+	case ChangeAdded:
+		return "+"
+	case ChangeModified:
+		return "~"
+	case ChangeDeleted:
+		return "-"
+	}
+	return " "
+}
+
+// LineChanges は0始まりの行番号ごとの変更
+type LineChanges map[int]Change
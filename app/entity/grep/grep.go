@@ -0,0 +1,23 @@
+package grep
+
+// Match はプロジェクト検索で見つかった1件の一致箇所を表す
+type Match struct {
+	File   string // 一致したファイルのパス
+	Line   int    // 0始まりの行番号
+	Col    int    // 0始まりのルーン単位の列位置
+	Length int    // 一致部分のルーン数
+	Text   string // 一致した行の内容
+}
+
+// GroupByFile は一致箇所をファイルごとにまとめる（ファイルの出現順を保持する）
+func GroupByFile(matches []Match) ([]string, map[string][]Match) {
+	var files []string
+	byFile := make(map[string][]Match)
+	for _, m := range matches {
+		if _, ok := byFile[m.File]; !ok {
+			files = append(files, m.File)
+		}
+		byFile[m.File] = append(byFile[m.File], m)
+	}
+	return files, byFile
+}
@@ -0,0 +1,108 @@
+package indent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultUnit はインデントの1段を決められない場合に使う幅
+const DefaultUnit = "    "
+
+// Rule はファイルの種類ごとの自動インデントの規則
+type Rule struct {
+	After  []string // 行末（末尾の空白を除く）がこれらのいずれかで終わる行で改行すると、次の行を1段深くする
+	Dedent string   // 行頭の空白の直後にこれらの文字を入力すると、その行を1段浅くする
+	Unit   string   // インデントのない行から1段深くする場合の1段（空なら DefaultUnit）
+}
+
+// Increases は line の後で改行した場合に1段深くするかどうかを返す
+// 語で終わる規則（"then" など）は、直前が語の一部でない場合だけ一致する
+func (r Rule) Increases(line string) bool {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	for _, suffix := range r.After {
+		if suffix == "" || !strings.HasSuffix(line, suffix) {
+			continue
+		}
+		rest := []rune(strings.TrimSuffix(line, suffix))
+		first := []rune(suffix)[0]
+		if len(rest) > 0 && isWordRune(first) && isWordRune(rest[len(rest)-1]) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Dedents は ch を行頭の空白の直後に入力した場合に1段浅くするかどうかを返す
+func (r Rule) Dedents(ch rune) bool {
+	return strings.ContainsRune(r.Dedent, ch)
+}
+
+// Step は indent の行から1段深くする場合の1段を返す
+// タブでインデントしている行はタブ、スペースの行はスペースで揃える
+func (r Rule) Step(indent string) string {
+	unit := r.Unit
+	if unit == "" {
+		unit = DefaultUnit
+	}
+	switch {
+	case strings.HasPrefix(indent, "\t"):
+		return "\t"
+	case strings.HasPrefix(indent, " ") && strings.Trim(unit, " ") != "":
+		return DefaultUnit
+	}
+	return unit
+}
+
+// Outdent は indent から1段分を取り除いたインデントを返す
+// 末尾がタブならタブを1つ、スペースなら1段の幅まで取り除く
+func (r Rule) Outdent(indent string) string {
+	if strings.HasSuffix(indent, "\t") {
+		return indent[:len(indent)-1]
+	}
+	width := len(r.Step(indent))
+	trimmed := strings.TrimRight(indent, " ")
+	if len(indent)-len(trimmed) > width {
+		return indent[:len(indent)-width]
+	}
+	return trimmed
+}
+
+// Leading は line の行頭の空白を返す
+func Leading(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Rules はファイルの種類ごとの規則の集まり
+type Rules struct {
+	byType map[string]Rule
+}
+
+// Default は既定の規則を作成する
+func Default() *Rules {
+	braces := Rule{After: []string{"{", "(", "["}, Dedent: "})]"}
+	return &Rules{byType: map[string]Rule{
+		"Go":         {After: braces.After, Dedent: braces.Dedent, Unit: "\t"},
+		"C":          braces,
+		"JavaScript": braces,
+		"TypeScript": braces,
+		"JSON":       braces,
+		"Python":     {After: []string{":", "(", "[", "{"}, Dedent: ")]}"},
+		"YAML":       {After: []string{":"}, Unit: "  "},
+		"Shell":      {After: []string{"then", "do", "{"}, Dedent: "}"},
+	}}
+}
+
+// Set は fileType の規則を rule に置き換える
+func (rs *Rules) Set(fileType string, rule Rule) {
+	rs.byType[fileType] = rule
+}
+
+// For は fileType の規則を返す。規則のない種類では行頭の空白を引き継ぐだけの空の規則を返す
+func (rs *Rules) For(fileType string) Rule {
+	return rs.byType[fileType]
+}
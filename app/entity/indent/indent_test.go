@@ -0,0 +1,75 @@
+package indent
+
+import "testing"
+
+func TestRule_Increases(t *testing.T) {
+	rules := Default()
+	tests := []struct {
+		fileType string
+		line     string
+		want     bool
+	}{
+		{"Go", "func main() {", true},
+		{"Go", "\tx := []int{  ", true},
+		{"Go", "\treturn x", false},
+		{"Python", "def f(x):", true},
+		{"Python", "    return x", false},
+		{"Shell", "if true; then", true},
+		{"Shell", "for x in a b; do", true},
+		// 語で終わる規則は語の途中では一致しない
+		{"Shell", "echo undo", false},
+		{"Text", "anything {", false},
+	}
+	for _, tt := range tests {
+		if got := rules.For(tt.fileType).Increases(tt.line); got != tt.want {
+			t.Errorf("%s: Increases(%q) = %v, want %v", tt.fileType, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRule_StepAndOutdent(t *testing.T) {
+	goRule := Default().For("Go")
+	if got := goRule.Step(""); got != "\t" {
+		t.Errorf("Go Step(\"\") = %q", got)
+	}
+	// 既存の行のインデントに揃える
+	if got := goRule.Step("  "); got != DefaultUnit {
+		t.Errorf("Go Step(spaces) = %q", got)
+	}
+	yaml := Default().For("YAML")
+	if got := yaml.Step("  "); got != "  " {
+		t.Errorf("YAML Step = %q", got)
+	}
+	if got := (Rule{}).Step("\t"); got != "\t" {
+		t.Errorf("empty rule Step(tab) = %q", got)
+	}
+
+	for _, tt := range []struct {
+		rule         Rule
+		indent, want string
+	}{
+		{goRule, "\t\t", "\t"},
+		{Rule{}, "        ", "    "},
+		{Rule{}, "  ", ""},
+		{yaml, "    ", "  "},
+		{Rule{}, "", ""},
+	} {
+		if got := tt.rule.Outdent(tt.indent); got != tt.want {
+			t.Errorf("Outdent(%q) = %q, want %q", tt.indent, got, tt.want)
+		}
+	}
+}
+
+func TestRules_Set(t *testing.T) {
+	rules := Default()
+	rules.Set("Text", Rule{After: []string{"->"}})
+	if !rules.For("Text").Increases("a ->") {
+		t.Error("the rule set for Text must be used")
+	}
+	if !Default().For("Python").Dedents(')') || Default().For("Python").Dedents(':') {
+		t.Error("unexpected dedent characters for Python")
+	}
+	if Leading("\t  x  ") != "\t  " {
+		t.Errorf("Leading = %q", Leading("\t  x  "))
+	}
+}
@@ -7,6 +7,22 @@ type KeyEvent struct {
 	MouseRow    int         // マウスイベントの行位置
 	MouseCol    int         // マウスイベントの列位置
 	MouseAction MouseAction // マウスイベントの種類（型をintからMouseActionに変更）
+	Modifiers   Modifiers   // 同時に押されていた修飾キー
+}
+
+// Modifiers は修飾キーの組み合わせを表す
+type Modifiers uint8
+
+const (
+	// ModAlt は Alt（Meta）キー。端末からは ESC に続くキーとして送られる
+	ModAlt Modifiers = 1 << iota
+	// ModShift は Shift キー。矢印キーとの同時押しのみ区別できる
+	ModShift
+)
+
+// Has は指定した修飾キーが含まれているかどうかを返す
+func (m Modifiers) Has(mod Modifiers) bool {
+	return m&mod != 0
 }
 
 // KeyEventType はキーイベントの種類を表す
@@ -37,7 +53,22 @@ const (
 	KeyTab
 	KeyShiftTab // Add Shift+Tab key
 	KeyMouseWheel
-	KeyMouseClick // 追加：マウスクリック用のキー
+	KeyMouseClick       // 追加：マウスクリック用のキー
+	KeyCtrlB            // ブックマークの切り替え
+	KeyCtrlN            // 単語補完
+	KeyCtrlRightBracket // 定義へジャンプ (Ctrl-])
+	KeyCtrlD            // go doc の表示
+	KeyCtrlK            // プレフィックスキー (Ctrl-K)
+	KeyCtrlF            // インクリメンタル検索
+	KeyCtrlZ            // 取り消し
+	KeyCtrlY            // やり直し
+	KeyCtrlT            // 文字の入れ替え
+	KeyCtrlG            // 指定した行へ移動
+	KeyCtrlW            // プレフィックスキー (Ctrl-W)
+	KeyCtrlO            // ファイルを開く
+	KeyCtrlP            // ファイル検索
+	KeyDelete           // Delete キー（カーソル位置の文字を削除）
+	KeyCtrlSpace        // 言語サーバーによる補完 (Ctrl-Space)
 )
 
 // MouseAction はマウスアクションの種類を表す
@@ -50,4 +81,5 @@ const (
 	MouseRightClick  // 右クリック
 	MouseMiddleClick // 中クリック
 	MouseDrag        // ドラッグ
+	MouseRelease     // ボタンを離した
 )
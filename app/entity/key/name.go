@@ -0,0 +1,64 @@
+package key
+
+import "fmt"
+
+// keyNames はキーマップなどで使用する特殊キー・コントロールキーの表記
+var keyNames = map[Key]string{
+	KeyArrowUp:          "Up",
+	KeyArrowDown:        "Down",
+	KeyArrowLeft:        "Left",
+	KeyArrowRight:       "Right",
+	KeyBackspace:        "Backspace",
+	KeyDelete:           "Delete",
+	KeyEnter:            "Enter",
+	KeyEsc:              "Esc",
+	KeyTab:              "Tab",
+	KeyShiftTab:         "S-Tab",
+	KeyCtrlX:            "C-x",
+	KeyCtrlC:            "C-c",
+	KeyCtrlS:            "C-s",
+	KeyCtrlB:            "C-b",
+	KeyCtrlN:            "C-n",
+	KeyCtrlRightBracket: "C-]",
+	KeyCtrlD:            "C-d",
+	KeyCtrlK:            "C-k",
+	KeyCtrlF:            "C-f",
+	KeyCtrlZ:            "C-z",
+	KeyCtrlY:            "C-y",
+	KeyCtrlT:            "C-t",
+	KeyCtrlG:            "C-g",
+	KeyCtrlW:            "C-w",
+	KeyCtrlO:            "C-o",
+	KeyCtrlP:            "C-p",
+	KeyCtrlSpace:        "C-Space",
+}
+
+// Name はキーの表記を返す（例: "C-s", "Up"）
+func (k Key) Name() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Key(%d)", int(k))
+}
+
+// Name はキーイベントの表記を返す。文字入力の場合はその文字自体を返す
+// Shift が押されている場合は "S-"、Alt が押されている場合は "M-" を前に付ける（例: "M-d", "S-Up"）
+// マウスイベントには表記がないため空文字列を返す
+func (e KeyEvent) Name() string {
+	var name string
+	switch e.Type {
+	case KeyEventChar:
+		name = string(e.Rune)
+	case KeyEventSpecial, KeyEventControl:
+		name = e.Key.Name()
+	default:
+		return ""
+	}
+	if e.Modifiers.Has(ModShift) {
+		name = "S-" + name
+	}
+	if e.Modifiers.Has(ModAlt) {
+		return "M-" + name
+	}
+	return name
+}
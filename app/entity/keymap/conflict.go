@@ -0,0 +1,70 @@
+package keymap
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SourceDefault は組み込みの既定の割り当ての出どころ
+const SourceDefault = "default"
+
+// Precedence は別の設定が同じキーを割り当てた場合に、どちらを残すかの方針
+type Precedence string
+
+const (
+	// PrecedenceLast は後から読み込んだ設定（プロジェクト設定など）の割り当てを残す
+	PrecedenceLast Precedence = "last"
+	// PrecedenceFirst は先に登録した割り当て（既定の割り当てなど）を残す
+	PrecedenceFirst Precedence = "first"
+)
+
+// Conflict は同じキーへの食い違う割り当て
+type Conflict struct {
+	Layer    string
+	Key      string
+	Active   Binding // 有効な割り当て
+	Shadowed Binding // 隠された割り当て
+	Prefix   bool    // Active ではなく、同じ名前のレイヤーを開くプレフィックスキーに隠されている
+}
+
+// String は競合を一覧に表示する形にする（例: "C-k s: save (default) shadowed by build (project)"）
+func (c Conflict) String() string {
+	key := c.Key
+	if c.Layer != LayerGlobal {
+		key = c.Layer + " " + c.Key
+	}
+	if c.Prefix {
+		return fmt.Sprintf("%s: %s (%s) shadowed by the %s prefix", key, c.Shadowed.Command, c.Shadowed.source(), c.Key)
+	}
+	return fmt.Sprintf("%s: %s (%s) shadowed by %s (%s)", key, c.Shadowed.Command, c.Shadowed.source(), c.Active.Command, c.Active.source())
+}
+
+// source は割り当ての出どころを返す
+func (b Binding) source() string {
+	if b.Source == "" {
+		return SourceDefault
+	}
+	return b.Source
+}
+
+// conflictsWith は b が別の設定で異なるコマンドを割り当てているかどうかを返す
+// 同じ設定の中での割り当て直しは競合として扱わない
+func (b Binding) conflictsWith(other Binding) bool {
+	return b.source() != other.source() && (b.Command != other.Command || !slices.Equal(b.Args, other.Args))
+}
+
+// SetPrecedence は競合した割り当てのどちらを残すかを設定する。既定は PrecedenceLast
+func (k *Keymap) SetPrecedence(p Precedence) {
+	k.precedence = p
+}
+
+// Conflicts は登録で見つかった競合と、プレフィックスキーに隠されて使えない割り当てを返す
+func (k *Keymap) Conflicts() []Conflict {
+	conflicts := append([]Conflict{}, k.conflicts...)
+	for _, b := range k.layers[LayerGlobal] {
+		if b.Key == LayerCtrlK || b.Key == LayerCtrlW {
+			conflicts = append(conflicts, Conflict{Layer: LayerGlobal, Key: b.Key, Shadowed: b, Prefix: true})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,141 @@
+package keymap
+
+import (
+	"sort"
+	"strings"
+)
+
+// レイヤー名
+const (
+	// LayerGlobal は通常の編集中に有効なキーマップ
+	LayerGlobal = "global"
+	// LayerCtrlK は Ctrl-K を押した後に有効になるキーマップ
+	LayerCtrlK = "C-k"
+	// LayerCtrlW は Ctrl-W を押した後に有効になるキーマップ
+	LayerCtrlW = "C-w"
+)
+
+// Binding はキーとコマンドの対応を表す
+type Binding struct {
+	Key         string
+	Command     string
+	Args        []string
+	Description string
+	Source      string // 割り当てを定義した設定（空なら SourceDefault）
+}
+
+// Keymap はレイヤーごとのキー割り当てを管理する
+// 割り当ては登録順に保持され、メニュー表示の順序になる
+type Keymap struct {
+	layers     map[string][]Binding
+	precedence Precedence
+	conflicts  []Conflict
+}
+
+// New は空の Keymap を作成する
+func New() *Keymap {
+	return &Keymap{layers: make(map[string][]Binding)}
+}
+
+// Bind は layer の key に割り当てを追加する。既に割り当てがある場合は置き換える
+// 別の設定の割り当てと食い違う場合は優先順位に従ってどちらかを残し、競合として記録する
+func (k *Keymap) Bind(layer string, b Binding) {
+	bindings := k.layers[layer]
+	for i := range bindings {
+		if bindings[i].Key != b.Key {
+			continue
+		}
+		if !bindings[i].conflictsWith(b) {
+			bindings[i] = b
+			return
+		}
+		c := Conflict{Layer: layer, Key: b.Key, Active: b, Shadowed: bindings[i]}
+		if k.precedence == PrecedenceFirst {
+			c.Active, c.Shadowed = bindings[i], b
+		}
+		bindings[i] = c.Active
+		k.conflicts = append(k.conflicts, c)
+		return
+	}
+	k.layers[layer] = append(bindings, b)
+}
+
+// Unbind は layer の key の割り当てを削除する
+func (k *Keymap) Unbind(layer, key string) {
+	bindings := k.layers[layer]
+	for i := range bindings {
+		if bindings[i].Key == key {
+			k.layers[layer] = append(bindings[:i], bindings[i+1:]...)
+			return
+		}
+	}
+}
+
+// Lookup は layer で key に割り当てられたコマンドを返す
+func (k *Keymap) Lookup(layer, key string) (Binding, bool) {
+	for _, b := range k.layers[layer] {
+		if b.Key == key {
+			return b, true
+		}
+	}
+	return Binding{}, false
+}
+
+// Bindings は layer の割り当てを登録順に返す
+func (k *Keymap) Bindings(layer string) []Binding {
+	return append([]Binding{}, k.layers[layer]...)
+}
+
+// Summary はメッセージバーに表示するための割り当て一覧を返す（例: "s=save 0-9=bookmark"）
+// 同じ説明を持つ連続した1文字キーは範囲としてまとめる
+func Summary(bindings []Binding) string {
+	var parts []string
+	for i := 0; i < len(bindings); {
+		j := i + 1
+		for j < len(bindings) && bindings[j].Description == bindings[i].Description &&
+			isSequential(bindings[j-1].Key, bindings[j].Key) {
+			j++
+		}
+		keys := bindings[i].Key
+		if j-i > 1 {
+			keys += "-" + bindings[j-1].Key
+		}
+		parts = append(parts, keys+"="+bindings[i].Description)
+		i = j
+	}
+	return strings.Join(parts, " ")
+}
+
+// isSequential は b が a の次の文字かどうかを返す
+func isSequential(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	return len(ra) == 1 && len(rb) == 1 && rb[0] == ra[0]+1
+}
+
+// KeysFor は引数なしで command を呼び出すキーの表記を返す（例: "C-s", "C-k s"）
+// 通常のレイヤーのキーを先に、それ以外はレイヤー名の順に並べる
+func (k *Keymap) KeysFor(command string) []string {
+	layers := make([]string, 0, len(k.layers))
+	for layer := range k.layers {
+		if layer != LayerGlobal {
+			layers = append(layers, layer)
+		}
+	}
+	sort.Strings(layers)
+	layers = append([]string{LayerGlobal}, layers...)
+
+	var keys []string
+	for _, layer := range layers {
+		for _, b := range k.layers[layer] {
+			if b.Command != command || len(b.Args) > 0 {
+				continue
+			}
+			if layer == LayerGlobal {
+				keys = append(keys, b.Key)
+			} else {
+				keys = append(keys, layer+" "+b.Key)
+			}
+		}
+	}
+	return keys
+}
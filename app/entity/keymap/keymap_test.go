@@ -0,0 +1,98 @@
+package keymap
+
+import "testing"
+
+func TestKeymap_BindLookup(t *testing.T) {
+	k := New()
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "save", Description: "save"})
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "save", Description: "save"})
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "save-as", Description: "save as"})
+
+	b, ok := k.Lookup(LayerGlobal, "C-s")
+	if !ok || b.Command != "save-as" {
+		t.Errorf("rebinding must replace the command: %+v", b)
+	}
+	if len(k.Bindings(LayerGlobal)) != 1 {
+		t.Errorf("unexpected bindings: %+v", k.Bindings(LayerGlobal))
+	}
+	if _, ok := k.Lookup(LayerGlobal, "s"); ok {
+		t.Error("layers must be independent")
+	}
+
+	k.Unbind(LayerCtrlK, "s")
+	if _, ok := k.Lookup(LayerCtrlK, "s"); ok {
+		t.Error("unbound key must not be found")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	bindings := []Binding{
+		{Key: "s", Description: "save"},
+		{Key: "0", Description: "bookmark"},
+		{Key: "1", Description: "bookmark"},
+		{Key: "2", Description: "bookmark"},
+		{Key: "b", Description: "macro"},
+		{Key: "c", Description: "macro"},
+	}
+	expected := "s=save 0-2=bookmark b-c=macro"
+	if got := Summary(bindings); got != expected {
+		t.Errorf("Summary() = %q, want %q", got, expected)
+	}
+}
+
+func TestKeysFor(t *testing.T) {
+	k := New()
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "save"})
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "save"})
+	k.Bind(LayerCtrlK, Binding{Key: "1", Command: "goto-bookmark", Args: []string{"1"}})
+
+	if got := k.KeysFor("save"); len(got) != 2 || got[0] != "C-s" || got[1] != "C-k s" {
+		t.Errorf("KeysFor(save) = %q", got)
+	}
+	if got := k.KeysFor("goto-bookmark"); len(got) != 0 {
+		t.Errorf("bindings with arguments must be skipped: %q", got)
+	}
+}
+
+func TestKeymap_Conflicts(t *testing.T) {
+	k := New()
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "save"})
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "save"})
+	// 同じ設定の中での割り当て直しや、同じコマンドの割り当ては競合にしない
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "save-as"})
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "save", Source: "project"})
+	if got := k.Conflicts(); len(got) != 0 {
+		t.Fatalf("unexpected conflicts: %v", got)
+	}
+
+	k.Bind(LayerGlobal, Binding{Key: "C-s", Command: "build", Source: "project"})
+	k.Bind(LayerGlobal, Binding{Key: "C-k", Command: "kill-line", Source: "project"})
+	got := k.Conflicts()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 conflicts, got %v", got)
+	}
+	if b, _ := k.Lookup(LayerGlobal, "C-s"); b.Command != "build" {
+		t.Errorf("the later binding must win by default: %+v", b)
+	}
+	if want := "C-s: save-as (default) shadowed by build (project)"; got[0].String() != want {
+		t.Errorf("String() = %q, want %q", got[0].String(), want)
+	}
+	if want := "C-k: kill-line (project) shadowed by the C-k prefix"; got[1].String() != want {
+		t.Errorf("String() = %q, want %q", got[1].String(), want)
+	}
+}
+
+func TestKeymap_PrecedenceFirst(t *testing.T) {
+	k := New()
+	k.SetPrecedence(PrecedenceFirst)
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "save"})
+	k.Bind(LayerCtrlK, Binding{Key: "s", Command: "build", Source: "project"})
+
+	if b, _ := k.Lookup(LayerCtrlK, "s"); b.Command != "save" {
+		t.Errorf("the first binding must win: %+v", b)
+	}
+	got := k.Conflicts()
+	if len(got) != 1 || got[0].String() != "C-k s: build (project) shadowed by save (default)" {
+		t.Errorf("unexpected conflicts: %v", got)
+	}
+}
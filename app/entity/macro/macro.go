@@ -0,0 +1,53 @@
+package macro
+
+import "github.com/wasya-io/go-kilo/app/entity/key"
+
+// Recorder はキーボードマクロの記録を管理する
+type Recorder struct {
+	recording bool
+	events    []key.KeyEvent
+	last      []key.KeyEvent
+}
+
+// New は新しい Recorder を作成する
+func New() *Recorder {
+	return &Recorder{}
+}
+
+// Start は記録を開始する。記録中だった内容は破棄する
+func (r *Recorder) Start() {
+	r.recording = true
+	r.events = nil
+}
+
+// Stop は記録を終了し、記録したイベント数を返す
+// 末尾の trim 件（記録終了の操作自体）は記録から除く
+func (r *Recorder) Stop(trim int) int {
+	if !r.recording {
+		return 0
+	}
+	r.recording = false
+	if trim > len(r.events) {
+		trim = len(r.events)
+	}
+	r.last = r.events[:len(r.events)-trim]
+	r.events = nil
+	return len(r.last)
+}
+
+// Recording は記録中かどうかを返す
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Record は記録中であればイベントを追加する
+func (r *Recorder) Record(ev key.KeyEvent) {
+	if r.recording {
+		r.events = append(r.events, ev)
+	}
+}
+
+// Last は最後に記録したマクロを返す
+func (r *Recorder) Last() []key.KeyEvent {
+	return append([]key.KeyEvent{}, r.last...)
+}
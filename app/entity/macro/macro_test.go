@@ -0,0 +1,34 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestRecorder(t *testing.T) {
+	r := New()
+	r.Record(key.KeyEvent{Type: key.KeyEventChar, Rune: 'x'})
+	if len(r.Last()) != 0 {
+		t.Fatal("events must not be recorded before Start")
+	}
+
+	r.Start()
+	r.Record(key.KeyEvent{Type: key.KeyEventChar, Rune: 'a'})
+	r.Record(key.KeyEvent{Type: key.KeyEventChar, Rune: 'b'})
+	r.Record(key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlK})
+	r.Record(key.KeyEvent{Type: key.KeyEventChar, Rune: 'e'})
+	if n := r.Stop(2); n != 2 {
+		t.Errorf("expected 2 events, got %d", n)
+	}
+	if r.Recording() {
+		t.Error("recorder must stop")
+	}
+	last := r.Last()
+	if last[0].Rune != 'a' || last[1].Rune != 'b' {
+		t.Errorf("unexpected macro: %+v", last)
+	}
+	if r.Stop(0) != 0 || len(r.Last()) != 2 {
+		t.Error("stopping twice must keep the last macro")
+	}
+}
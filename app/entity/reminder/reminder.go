@@ -0,0 +1,54 @@
+package reminder
+
+import "time"
+
+// Reminder は未保存状態が長く続いているバッファに保存を促すタイミングを判定する
+type Reminder struct {
+	after      time.Duration // 未保存状態がこの時間続いたら通知を始める
+	interval   time.Duration // 通知を繰り返す間隔
+	dirtySince time.Time
+	lastNotice time.Time
+}
+
+// New は新しい Reminder を作成する。after が0以下の場合は通知しない
+func New(after, interval time.Duration) *Reminder {
+	if interval <= 0 {
+		interval = after
+	}
+	return &Reminder{after: after, interval: interval}
+}
+
+// Enabled は通知が有効かどうかを返す
+func (r *Reminder) Enabled() bool {
+	return r != nil && r.after > 0
+}
+
+// Update はバッファの状態を記録し、通知すべきかどうかを返す
+// 戻り値の elapsed は未保存状態が続いている時間
+func (r *Reminder) Update(dirty bool, now time.Time) (notify bool, elapsed time.Duration) {
+	if !r.Enabled() {
+		return false, 0
+	}
+	if !dirty {
+		r.dirtySince = time.Time{}
+		r.lastNotice = time.Time{}
+		return false, 0
+	}
+	if r.dirtySince.IsZero() {
+		r.dirtySince = now
+	}
+	elapsed = now.Sub(r.dirtySince)
+	if elapsed < r.after {
+		return false, elapsed
+	}
+	if !r.lastNotice.IsZero() && now.Sub(r.lastNotice) < r.interval {
+		return false, elapsed
+	}
+	r.lastNotice = now
+	return true, elapsed
+}
+
+// Overdue は最後の Update の時点で通知対象の状態になっているかどうかを返す
+func (r *Reminder) Overdue(now time.Time) bool {
+	return r.Enabled() && !r.dirtySince.IsZero() && now.Sub(r.dirtySince) >= r.after
+}
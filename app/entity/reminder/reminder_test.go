@@ -0,0 +1,40 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReminder_Update(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(10*time.Minute, 5*time.Minute)
+
+	steps := []struct {
+		name   string
+		dirty  bool
+		offset time.Duration
+		notify bool
+	}{
+		{"変更直後は通知しない", true, 0, false},
+		{"閾値前は通知しない", true, 9 * time.Minute, false},
+		{"閾値を超えたら通知", true, 10 * time.Minute, true},
+		{"間隔内は再通知しない", true, 12 * time.Minute, false},
+		{"間隔経過後に再通知", true, 15 * time.Minute, true},
+		{"保存されたらリセット", false, 16 * time.Minute, false},
+		{"再度変更されたら計測し直す", true, 17 * time.Minute, false},
+	}
+	for _, st := range steps {
+		t.Run(st.name, func(t *testing.T) {
+			if notify, _ := r.Update(st.dirty, base.Add(st.offset)); notify != st.notify {
+				t.Errorf("Update at %v = %v, want %v", st.offset, notify, st.notify)
+			}
+		})
+	}
+}
+
+func TestReminder_Disabled(t *testing.T) {
+	r := New(0, 0)
+	if notify, _ := r.Update(true, time.Now().Add(time.Hour)); notify || r.Enabled() {
+		t.Errorf("disabled reminder must never notify")
+	}
+}
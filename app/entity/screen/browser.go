@@ -0,0 +1,52 @@
+package screen
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// directoryColor はファイル一覧でディレクトリの名前に付ける色（太字）
+const directoryColor = "\x1b[1m"
+
+// BrowserItem はファイル一覧の情報パネルに表示する項目1件
+type BrowserItem struct {
+	Name   string
+	Dir    bool   // ディレクトリなら名前の後ろに / を付けて強調する
+	Detail string // 行の右端に揃えて表示する補足（ファイルの大きさなど）
+}
+
+// SetBrowserOverlay はファイル一覧の情報パネルを表示する
+// 各行は名前を左に、補足を右端に揃えて描画し、ディレクトリは強調する。スクロールは SetListOverlay と同じ
+func (s *Screen) SetBrowserOverlay(title string, items []BrowserItem, selected int, footer string) {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	s.SetListOverlay(title, names, selected, footer)
+	s.overlay.items = append([]BrowserItem{}, items...)
+}
+
+// browserLine はファイル一覧の1行を幅 width に収めて描画する
+// 名前が収まらない場合は補足より名前を優先し、補足を省く
+func browserLine(item BrowserItem, width int) string {
+	name := " " + item.Name
+	if item.Dir {
+		name += "/"
+	}
+	detail := ""
+	if item.Detail != "" {
+		detail = item.Detail + " "
+	}
+	nameWidth := contents.NewRow(name).OffsetToScreenPosition(len([]rune(name)))
+	if nameWidth+1+len(detail) > width {
+		detail = ""
+	}
+	fitted := fitWidth(name, width-len(detail))
+	if item.Dir {
+		// 選択行の反転表示を打ち消さないよう、太字だけを解除する
+		trimmed := strings.TrimRight(fitted, " ")
+		return directoryColor + trimmed + "\x1b[22m" + fitted[len(trimmed):] + detail
+	}
+	return fitted + detail
+}
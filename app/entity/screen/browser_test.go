@@ -0,0 +1,60 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+)
+
+func TestBrowserLine(t *testing.T) {
+	tests := []struct {
+		name string
+		item BrowserItem
+		want string
+	}{
+		{"file", BrowserItem{Name: "main.go", Detail: "1.5K"}, " main.go       1.5K "},
+		{"directory", BrowserItem{Name: "docs", Dir: true}, directoryColor + " docs/\x1b[22m" + strings.Repeat(" ", 14)},
+		{"double width", BrowserItem{Name: "日本語.txt", Detail: "3B"}, " 日本語.txt      3B "},
+		// 名前が収まらない場合は補足を省く
+		{"long name", BrowserItem{Name: "a_very_long_name.go", Detail: "12K"}, " a_very_long_name.go"},
+	}
+	for _, tt := range tests {
+		if got := browserLine(tt.item, 20); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBrowserOverlay(t *testing.T) {
+	w := &recordingWriter{}
+	s := NewScreen(contents.NewBuilder(), w, contents.NewMessage(""), cursor.NewCursor(), 10, 30)
+	s.SetBrowserOverlay("/tmp/project", []BrowserItem{
+		{Name: "..", Dir: true},
+		{Name: "src", Dir: true},
+		{Name: "go.mod", Detail: "120B"},
+	}, 2, "Esc: close")
+
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"hello"})
+	if err := s.Redraw(buffer, "a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"/tmp/project", directoryColor + " src/", "\x1b[7m go.mod", "120B ", "Esc: close"} {
+		if !strings.Contains(w.last, want) {
+			t.Errorf("the overlay must contain %q: %q", want, w.last)
+		}
+	}
+	if strings.Contains(w.last, "hello") {
+		t.Errorf("the overlay must cover the buffer: %q", w.last)
+	}
+
+	// 通常の一覧に戻すと項目の描画も戻る
+	s.SetListOverlay("list", []string{"src"}, -1, "")
+	s.Redraw(buffer, "a.txt")
+	if strings.Contains(w.last, directoryColor) {
+		t.Errorf("a plain list must not use the browser rendering: %q", w.last)
+	}
+}
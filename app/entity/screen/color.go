@@ -0,0 +1,181 @@
+package screen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColorDepth は端末が表示できる色の段階
+type ColorDepth int
+
+const (
+	DepthNone ColorDepth = iota // 色を使わない（太字などの装飾だけ）
+	Depth8                      // 基本の8色
+	Depth16                     // 明るい色を含む16色
+	Depth256                    // xterm の256色
+	DepthTrue                   // 24ビットのフルカラー
+)
+
+// DepthFor は端末の色数から色の段階を返す
+func DepthFor(colors int) ColorDepth {
+	switch {
+	case colors >= 1<<24:
+		return DepthTrue
+	case colors >= 256:
+		return Depth256
+	case colors >= 16:
+		return Depth16
+	case colors >= 8:
+		return Depth8
+	}
+	return DepthNone
+}
+
+// colorDepthNames は設定で指定できる色の段階と、それに相当する色数
+var colorDepthNames = map[string]int{
+	"truecolor": 1 << 24,
+	"256":       256,
+	"16":        16,
+	"8":         8,
+	"none":      0,
+}
+
+// ColorsByName は設定の色の段階（truecolor / 256 / 16 / 8 / none）を色数に変換する
+func ColorsByName(name string) (int, bool) {
+	colors, ok := colorDepthNames[name]
+	return colors, ok
+}
+
+// Color は RGB で指定した色。ゼロ値は端末の既定の色を表す
+type Color uint32
+
+// colorSet は RGB の値が指定されていることを示すビット
+const colorSet Color = 1 << 24
+
+// RGB は 0xRRGGBB の形の値から色を作る
+func RGB(hex uint32) Color {
+	return colorSet | Color(hex&0xffffff)
+}
+
+// rgb は色の成分を返す
+func (c Color) rgb() (r, g, b int) {
+	return int(c>>16) & 0xff, int(c>>8) & 0xff, int(c) & 0xff
+}
+
+// ansiPalette は16色の番号ごとの色（xterm の既定値）
+var ansiPalette = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels は256色の 6x6x6 の色の立方体で使う成分の値
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// distance は2つの色の距離（差の2乗和）を返す
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+// grayChroma は灰色とみなす成分の最大と最小の差の上限
+const grayChroma = 32
+
+// nearestANSI は n 色（8 か 16）の中で最も近い色の番号を返す
+// 灰色に近い色は、距離が近くても色味のある色にならないよう黒・灰色・白から選ぶ
+func (c Color) nearestANSI(n int) int {
+	r, g, b := c.rgb()
+	gray := max(r, g, b)-min(r, g, b) < grayChroma
+	best, bestDist := 0, -1
+	for i, p := range ansiPalette[:n] {
+		if gray && i != 0 && i != 7 && i != 8 && i != 15 {
+			continue
+		}
+		if d := distance(r, g, b, p[0], p[1], p[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// nearest256 は256色の色の立方体と灰色の階調の中で最も近い色の番号を返す
+func (c Color) nearest256() int {
+	r, g, b := c.rgb()
+	level := func(v int) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	ri, gi, bi := level(r), level(g), level(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	gray := min(max((r+g+b)/3-3, 0)/10, 23)
+	v := 8 + 10*gray
+	if distance(r, g, b, v, v, v) < cubeDist {
+		return 232 + gray
+	}
+	return cube
+}
+
+// sgr は色を depth で表せる SGR のパラメーターにする。base は文字色なら 30、背景色なら 40
+// 既定の色の場合と、色を使わない場合は空文字列を返す
+func (c Color) sgr(depth ColorDepth, base int) string {
+	if c&colorSet == 0 {
+		return ""
+	}
+	switch depth {
+	case DepthTrue:
+		r, g, b := c.rgb()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	case Depth256:
+		return fmt.Sprintf("%d;5;%d", base+8, c.nearest256())
+	case Depth16:
+		// 明るい色（8〜15）は 90 番台・100 番台で指定する
+		i := c.nearestANSI(16)
+		if i >= 8 {
+			return fmt.Sprint(base + 60 + i - 8)
+		}
+		return fmt.Sprint(base + i)
+	case Depth8:
+		return fmt.Sprint(base + c.nearestANSI(8))
+	}
+	return ""
+}
+
+// Style は色と装飾の組み合わせ
+type Style struct {
+	Fg, Bg    Color
+	Bold      bool
+	Dim       bool
+	Underline bool
+	Reverse   bool
+}
+
+// Sequence は depth の端末で表示するエスケープシーケンスを返す
+// 色は表示できる色の中で最も近いものに置き換え、色を使わない端末では装飾だけを使う
+func (s Style) Sequence(depth ColorDepth) string {
+	var params []string
+	for _, attr := range []struct {
+		on    bool
+		param string
+	}{{s.Bold, "1"}, {s.Dim, "2"}, {s.Underline, "4"}, {s.Reverse, "7"}} {
+		if attr.on {
+			params = append(params, attr.param)
+		}
+	}
+	if fg := s.Fg.sgr(depth, 30); fg != "" {
+		params = append(params, fg)
+	}
+	if bg := s.Bg.sgr(depth, 40); bg != "" {
+		params = append(params, bg)
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
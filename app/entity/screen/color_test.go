@@ -0,0 +1,73 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStyle_Sequence(t *testing.T) {
+	style := Style{Bold: true, Fg: RGB(0xff0000), Bg: RGB(0x808080)}
+	tests := []struct {
+		depth ColorDepth
+		want  string
+	}{
+		{DepthTrue, "\x1b[1;38;2;255;0;0;48;2;128;128;128m"},
+		// 灰色は色の立方体より灰色の階調の方が近い
+		{Depth256, "\x1b[1;38;5;196;48;5;244m"},
+		{Depth16, "\x1b[1;91;100m"},
+		{Depth8, "\x1b[1;31;47m"},
+		{DepthNone, "\x1b[1m"},
+	}
+	for _, tt := range tests {
+		if got := style.Sequence(tt.depth); got != tt.want {
+			t.Errorf("depth %d: got %q, want %q", tt.depth, got, tt.want)
+		}
+	}
+
+	// 既定の色は指定しない
+	if got := (Style{Underline: true}).Sequence(DepthTrue); got != "\x1b[4m" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Style{}).Sequence(DepthTrue); got != "" {
+		t.Errorf("empty style must emit nothing: %q", got)
+	}
+	// 黒も既定の色と区別する
+	if got := (Style{Fg: RGB(0)}).Sequence(Depth256); got != "\x1b[38;5;16m" {
+		t.Errorf("black: %q", got)
+	}
+}
+
+func TestDepthFor(t *testing.T) {
+	for colors, want := range map[int]ColorDepth{1 << 24: DepthTrue, 256: Depth256, 88: Depth16, 16: Depth16, 8: Depth8, 0: DepthNone} {
+		if got := DepthFor(colors); got != want {
+			t.Errorf("DepthFor(%d) = %d, want %d", colors, got, want)
+		}
+	}
+	if colors, ok := ColorsByName("truecolor"); !ok || DepthFor(colors) != DepthTrue {
+		t.Errorf("truecolor: %d %v", colors, ok)
+	}
+	if _, ok := ColorsByName("auto"); ok {
+		t.Error("auto must be resolved by the caller")
+	}
+}
+
+func TestSetTheme_Styled(t *testing.T) {
+	s := &Screen{}
+	for colors, param := range map[int]string{1 << 24: ";2;", 256: ";5;", 16: ""} {
+		if err := s.SetTheme(ThemeDusk, colors); err != nil {
+			t.Fatal(err)
+		}
+		got := s.Theme().Selection
+		if param != "" && !strings.Contains(got, param) {
+			t.Errorf("%d colors: selection %q must contain %q", colors, got, param)
+		}
+		if param == "" && (strings.Contains(got, ";2;") || strings.Contains(got, ";5;")) {
+			t.Errorf("16 colors must not use extended color sequences: %q", got)
+		}
+	}
+	// 色を使えない端末では白黒のテーマで描画する
+	s.SetTheme(ThemeDusk, 0)
+	if s.Theme().Name != ThemeDusk || s.Theme().Selection != reverseVideo {
+		t.Errorf("no colors: %+v", s.Theme())
+	}
+}
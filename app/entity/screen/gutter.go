@@ -0,0 +1,43 @@
+package screen
+
+import "github.com/wasya-io/go-kilo/app/entity/git"
+
+// gutterCols は本文の左に変更の印を表示する桁数
+const gutterCols = 1
+
+// SetGitChanges は本文の左のガターに行ごとの変更の印を表示する（既存の設定は置き換える）
+// nil ならガターを表示しない。変更のないファイルでは空の map を渡すとガターを表示したままにする
+func (s *Screen) SetGitChanges(changes git.LineChanges) {
+	s.gitChanges = changes
+}
+
+// GutterWidth はフォーカスのある区画で本文の左に表示するガターの桁数を返す
+func (s *Screen) GutterWidth() int {
+	if s.gitChanges == nil {
+		return 0
+	}
+	return gutterCols
+}
+
+// gutterCell は表示行 v の左に表示するガターを返す。折り返した行では先頭の表示行にだけ印を表示する
+func (s *Screen) gutterCell(v visualLine) string {
+	if s.gitChanges == nil {
+		return ""
+	}
+	change := git.ChangeNone
+	if !s.wrap || v.col == 0 {
+		change = s.gitChanges[v.line]
+	}
+	color := ""
+	switch change {
+	case git.ChangeAdded:
+		color = s.style().Added
+	case git.ChangeModified:
+		color = s.style().Modified
+	case git.ChangeDeleted:
+		color = s.style().Deleted
+	default:
+		return change.Sign()
+	}
+	return color + change.Sign() + resetColor
+}
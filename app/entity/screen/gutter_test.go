@@ -0,0 +1,48 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/git"
+)
+
+func TestGitChangesGutter(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"one", "two", "three"})
+	s := &Screen{builder: contents.NewBuilder(), rowLines: 6, colLines: 10}
+	if s.GutterWidth() != 0 || s.TextCols() != 10 {
+		t.Fatalf("no gutter without changes: %d, %d", s.GutterWidth(), s.TextCols())
+	}
+
+	s.SetGitChanges(git.LineChanges{0: git.ChangeAdded, 2: git.ChangeDeleted})
+	if s.GutterWidth() != 1 || s.TextCols() != 9 {
+		t.Errorf("the gutter must take one column: %d, %d", s.GutterWidth(), s.TextCols())
+	}
+	if err := s.drawRows(buffer, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := s.builder.Build()
+	for _, want := range []string{
+		addedSign + "+" + resetColor + "one",
+		"\x1b[2K two",
+		deletedSign + "-" + resetColor + "three",
+		"\x1b[2K ~",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+
+	// 変更のないファイルでもガターを表示したままにする
+	s.SetGitChanges(git.LineChanges{})
+	if s.GutterWidth() != 1 {
+		t.Error("an empty map must keep the gutter")
+	}
+	s.SetGitChanges(nil)
+	if s.GutterWidth() != 0 {
+		t.Error("nil must hide the gutter")
+	}
+}
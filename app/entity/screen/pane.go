@@ -0,0 +1,143 @@
+package screen
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+const (
+	// paneSeparator は左右に並べた区画の境界線
+	paneSeparator = "│"
+	// inactiveStatusColor はフォーカスのない区画のステータスバーの色（反転表示を薄くする）
+	inactiveStatusColor = "\x1b[2;7m"
+)
+
+// Region は画面上の区画（0 始まり）。本文を Rows 行 × Cols 桁で表示し、その直下に区画のステータスバーを置く
+type Region struct {
+	Top, Left  int
+	Rows, Cols int
+}
+
+// Pane はフォーカスのない区画に表示するバッファとその表示位置
+type Pane struct {
+	Region    Region
+	Buffer    *contents.Contents
+	Filename  string
+	Cursor    contents.Position // ステータスバーに表示するカーソル位置
+	RowOffset int
+	ColOffset int
+}
+
+// SetLayout は画面を分割して描画する。focused はフォーカスのある区画で、
+// カーソル・スクロール位置・強調表示・情報パネルはこの区画に適用する
+func (s *Screen) SetLayout(focused Region, others []Pane) {
+	s.region = &focused
+	s.panes = others
+}
+
+// ClearLayout は画面の分割を解除する
+func (s *Screen) ClearLayout() {
+	s.region = nil
+	s.panes = nil
+}
+
+// TextRows はフォーカスのある区画で本文を表示できる行数を返す
+func (s *Screen) TextRows() int {
+	if s.region != nil {
+		return s.region.Rows
+	}
+	// ステータスバーとメッセージバー用に2行確保
+	return max(s.rowLines-2, 0)
+}
+
+// TextCols はフォーカスのある区画で本文を表示できる桁数を返す
+func (s *Screen) TextCols() int {
+	if s.region != nil {
+		return max(s.region.Cols-s.GutterWidth(), 0)
+	}
+	return max(s.colLines-s.GutterWidth(), 0)
+}
+
+// redrawPanes は分割した各区画と、区画ごとのステータスバー、メッセージバーを描画する
+func (s *Screen) redrawPanes(buffer *contents.Contents, filename string) error {
+	s.builder.Clear()
+	s.builder.Write(escape + clearSequence)
+
+	if s.tabs != nil {
+		s.drawTabBar()
+	}
+	for _, p := range s.panes {
+		s.drawPane(p, false)
+	}
+	pos := s.cursor.ToPosition()
+	focused := Pane{Region: *s.region, Buffer: buffer, Filename: filename, Cursor: contents.Position{X: pos.X, Y: pos.Y}, RowOffset: s.scrollOffset.y, ColOffset: s.scrollOffset.x}
+	s.drawPane(focused, true)
+
+	if err := s.drawMessageBar(); err != nil {
+		return err
+	}
+
+	if s.prompt != nil {
+		s.builder.Write(s.promptCursor())
+	} else {
+		screenX, screenY := s.getScreenPosition(pos.X, pos.Y, buffer, s.scrollOffset.y, s.scrollOffset.x)
+		s.builder.Write(moveTo(s.region.Top+screenY, s.region.Left+s.GutterWidth()+screenX))
+	}
+
+	s.updateTitle(filename)
+	return s.writer.Write(s.builder.Build())
+}
+
+// drawPane は区画の本文とステータスバーを描画する
+// 強調表示・選択範囲・情報パネル・変更の印はフォーカスのある区画にだけ描画する
+func (s *Screen) drawPane(p Pane, focused bool) {
+	r := p.Region
+	wrapTop, cols := 0, r.Cols
+	if focused {
+		wrapTop, cols = s.wrapTop, max(r.Cols-s.GutterWidth(), 0)
+	}
+	lines := s.visualLines(p.Buffer, p.RowOffset, p.ColOffset, wrapTop, r.Rows, cols)
+	for y := 0; y <= r.Rows; y++ {
+		if r.Left > 0 {
+			// 左隣の区画との境界線
+			s.builder.Write(moveTo(r.Top+y, r.Left-1) + paneSeparator)
+		}
+		s.builder.Write(moveTo(r.Top+y, r.Left))
+		if y == r.Rows {
+			break
+		}
+
+		v := lines[y]
+		if focused {
+			s.builder.Write(s.gutterCell(v))
+			if line, ok := s.overlayRow(y); ok {
+				s.builder.Write(line)
+				continue
+			}
+		}
+		row := p.Buffer.GetRow(v.line)
+		if v.line >= p.Buffer.GetLineCount() || row == nil {
+			s.builder.Write(fitWidth("~", cols))
+			continue
+		}
+		var highlights []Highlight
+		if focused {
+			highlights = s.rowHighlights(p.Buffer, v.line, row)
+		}
+		s.builder.Write(s.drawTextRange(row, v.col, cols, v.end, highlights...))
+	}
+
+	color := s.style().InactiveStatus
+	loading := false
+	if focused {
+		color = s.style().Status
+		loading = s.loading
+	}
+	s.builder.Write(color + s.statusLine(p.Buffer, p.Filename, p.Cursor, r.Cols, loading) + resetColor)
+}
+
+// moveTo は画面上の位置（0 始まり）にカーソルを移動するエスケープシーケンスを返す
+func moveTo(row, col int) string {
+	return fmt.Sprintf("\x1b[%d;%dH", row+1, col+1)
+}
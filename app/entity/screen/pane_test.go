@@ -0,0 +1,54 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestDrawPane(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"one", "two"})
+	s := &Screen{builder: contents.NewBuilder(), message: contents.NewMessage("")}
+	s.SetHighlights([]Highlight{{Line: 1, Col: 0, Length: 1}})
+
+	// 右側の区画は境界線の右に本文とステータスバーを描画する
+	p := Pane{Region: Region{Top: 0, Left: 5, Rows: 3, Cols: 4}, Buffer: buffer, Filename: "a.go", RowOffset: 1}
+	s.drawPane(p, false)
+	got := s.builder.Build()
+	for _, want := range []string{
+		moveTo(0, 4) + paneSeparator + moveTo(0, 5) + "two",
+		moveTo(1, 5) + "~   ",
+		moveTo(3, 5) + inactiveStatusColor + "a.go" + resetColor,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, matchColor) {
+		t.Error("highlights must be drawn only in the focused pane")
+	}
+
+	s.builder.Clear()
+	s.drawPane(p, true)
+	if got := s.builder.Build(); !strings.Contains(got, matchColor+"t") || !strings.Contains(got, reverseVideo+"a.go") {
+		t.Errorf("focused pane: %q", got)
+	}
+}
+
+func TestTextRows_FollowsLayout(t *testing.T) {
+	s := &Screen{rowLines: 24, colLines: 80}
+	if s.TextRows() != 22 || s.TextCols() != 80 {
+		t.Errorf("unsplit: %d x %d", s.TextRows(), s.TextCols())
+	}
+	s.SetLayout(Region{Rows: 10, Cols: 39}, nil)
+	if s.TextRows() != 10 || s.TextCols() != 39 {
+		t.Errorf("split: %d x %d", s.TextRows(), s.TextCols())
+	}
+	s.ClearLayout()
+	if s.TextRows() != 22 {
+		t.Error("layout must be cleared")
+	}
+}
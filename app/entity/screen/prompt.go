@@ -0,0 +1,61 @@
+package screen
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// prompt はメッセージバーで入力中のプロンプト
+type prompt struct {
+	label  string // 入力を促す文字列（"Save as: " など）
+	input  []rune
+	cursor int    // 入力中の文字列でのカーソルの位置（文字単位）
+	note   string // 入力の後ろに添える補足（補完の候補や入力の誤りなど）
+}
+
+// SetPrompt はメッセージバーにプロンプトと入力中の文字列を表示し、端末のカーソルを入力位置に置く
+// cursor は input での文字単位の位置。ClearPrompt を呼ぶまでステータスメッセージより優先して表示する
+func (s *Screen) SetPrompt(label string, input []rune, cursor int, note string) {
+	s.prompt = &prompt{
+		label:  label,
+		input:  append([]rune(nil), input...),
+		cursor: min(max(cursor, 0), len(input)),
+		note:   note,
+	}
+}
+
+// ClearPrompt はプロンプトの表示を終える
+func (s *Screen) ClearPrompt() {
+	s.prompt = nil
+}
+
+// Prompting はプロンプトを表示中かどうかを返す
+func (s *Screen) Prompting() bool {
+	return s.prompt != nil
+}
+
+// layout は幅 width のメッセージバーに表示する文字列と、カーソルを置く桁を返す
+// 文字の表示幅は本文と同じく Row で数え、カーソルが収まらない場合は先頭を省いて表示する
+func (p *prompt) layout(width int) (string, int) {
+	runes := []rune(p.label)
+	offset := len(runes) + p.cursor
+	runes = append(append(runes, p.input...), []rune(p.note)...)
+	row := contents.NewRow(string(runes))
+	col := row.OffsetToScreenPosition(offset)
+
+	start := 0
+	if col >= width {
+		// カーソルが右端の1つ手前に来るよう、表示幅の分だけ先頭の文字を省く
+		start = row.ScreenPositionToOffset(col - width + 1)
+		if row.OffsetToScreenPosition(start) < col-width+1 {
+			start++
+		}
+	}
+	shift := row.OffsetToScreenPosition(start)
+	return fitWidth(string(runes[start:]), width), col - shift
+}
+
+// promptCursor はメッセージバーの入力位置にカーソルを移すシーケンスを返す
+func (s *Screen) promptCursor() string {
+	_, col := s.prompt.layout(s.colLines)
+	return moveTo(s.rowLines-2, col)
+}
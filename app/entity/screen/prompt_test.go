@@ -0,0 +1,59 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+)
+
+func TestPromptLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      prompt
+		width  int
+		line   string
+		cursor int
+	}{
+		{"ascii", prompt{label: "Save as: ", input: []rune("a.txt"), cursor: 5}, 20, "Save as: a.txt      ", 14},
+		{"double width", prompt{label: "Save as: ", input: []rune("日本語.txt"), cursor: 3}, 24, "Save as: 日本語.txt     ", 15},
+		{"note", prompt{label: ":", input: []rune("se"), cursor: 2, note: "  [search set]"}, 20, ":se  [search set]   ", 3},
+		// カーソルが収まらない場合は先頭を省き、幅の広い文字を半分だけ表示しない
+		{"scrolled", prompt{label: "> ", input: []rune("あいうえお"), cursor: 5}, 8, "うえお  ", 6},
+		{"cursor in the middle", prompt{label: "> ", input: []rune("あいうえお"), cursor: 1}, 8, "> あいう", 4},
+	}
+	for _, tt := range tests {
+		line, col := tt.p.layout(tt.width)
+		if line != tt.line || col != tt.cursor {
+			t.Errorf("%s: got %q, %d, want %q, %d", tt.name, line, col, tt.line, tt.cursor)
+		}
+	}
+}
+
+func TestRedraw_PromptCursor(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"hello"})
+	w := &recordingWriter{}
+	s := NewScreen(contents.NewBuilder(), w, contents.NewMessage(""), cursor.NewCursor(), 6, 40)
+	s.SetMessage("older message")
+
+	s.SetPrompt("Save as: ", []rune("日本"), 2, "")
+	if err := s.Redraw(buffer, "a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 端末のカーソルはメッセージバーの入力の末尾（表示幅で 9 + 4 桁目）に置く
+	if !strings.HasSuffix(w.last, moveTo(4, 13)) {
+		t.Errorf("the cursor must be placed after the prompt input: %q", w.last)
+	}
+	if !strings.Contains(w.last, "Save as: 日本") || strings.Contains(w.last, "older message") {
+		t.Errorf("the prompt must replace the message: %q", w.last)
+	}
+
+	s.ClearPrompt()
+	s.Redraw(buffer, "a.txt")
+	if !strings.HasSuffix(w.last, moveTo(0, 0)) || !strings.Contains(w.last, "older message") {
+		t.Errorf("the cursor must return to the buffer after the prompt ends: %q", w.last)
+	}
+}
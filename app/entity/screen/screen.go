@@ -2,12 +2,16 @@ package screen
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/wasya-io/go-kilo/app/boundary/writer"
+	"github.com/wasya-io/go-kilo/app/entity/annotation"
 	"github.com/wasya-io/go-kilo/app/entity/contents"
 	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/diagnostic"
+	"github.com/wasya-io/go-kilo/app/entity/git"
 )
 
 const (
@@ -18,9 +22,38 @@ const (
 	cursorHomeSequence = "[H"   // カーソルを原点に移動
 	defaultTabWidth    = 4      // デフォルトのタブ幅
 
+	// defaultMessageDuration はステータスメッセージの既定の表示時間
+	defaultMessageDuration = 5 * time.Second
+
 	// 色関連
-	controlCharColor = "\x1b[2;37m" // グレー色 (暗い白色)
-	resetColor       = "\x1b[0m"    // 色のリセット
+	controlCharColor = "\x1b[2;37m"  // グレー色 (暗い白色)
+	resetColor       = "\x1b[0m"     // 色のリセット
+	reverseVideo     = "\x1b[7m"     // 反転表示
+	matchColor       = "\x1b[30;43m" // 検索の一致箇所（黄色の背景）
+	currentMatch     = "\x1b[30;46m" // 選択中の一致箇所（水色の背景）
+	bracketMatch     = "\x1b[1;45m"  // カーソル位置の括弧と対応する括弧（紫の背景）
+	trailingSpace    = "\x1b[2;41m"  // 行末の空白（暗い赤の背景）
+	errorUnderline   = "\x1b[4;31m"  // エラーの診断（赤の下線）
+	warningUnderline = "\x1b[4;33m"  // 警告などの診断（黄色の下線）
+	addedSign        = "\x1b[32m"    // 追加した行の印（緑）
+	modifiedSign     = "\x1b[34m"    // 変更した行の印（青）
+	deletedSign      = "\x1b[31m"    // 行を削除した位置の印（赤）
+	misspelled       = "\x1b[4;35m"  // 綴りの誤り（紫の下線）
+
+	// OSC 8 ハイパーリンク（対応していない端末では無視される）
+	hyperlinkOpen  = "\x1b]8;;" // この後に URL と hyperlinkEnd を続ける
+	hyperlinkEnd   = "\x1b\\"
+	hyperlinkClose = hyperlinkOpen + hyperlinkEnd
+
+	// readOnlyMarker は編集できないバッファのステータスバーに表示する印
+	readOnlyMarker = "[RO]"
+
+	// defaultWelcome は空のバッファに表示する既定のメッセージ
+	defaultWelcome = "Kilo editor -- version 1.0"
+
+	// 編集領域を描画できる最小の端末の大きさ。これより小さい場合は案内だけを表示する
+	minRows = 4 // 本文2行とステータスバー、メッセージバー
+	minCols = 10
 )
 
 type Screen struct {
@@ -30,8 +63,71 @@ type Screen struct {
 	builder      contents.Builder
 	writer       writer.ScreenWriter
 	message      contents.Message
+	prompt       *prompt // 入力中のプロンプト（nil なら入力中でない）
 	debugMessage contents.DebugMessage
 	cursor       cursor.Cursor
+	overlay      *overlay
+	titleEnabled bool
+	lastTitle    string
+	dirtyAlert   bool
+	loading      bool // ファイルの残りを読み込み中
+	loadPercent  int  // 読み込み中のファイルのうち読み込んだ割合（%）
+	hyperlinks   bool // URL を OSC 8 ハイパーリンクとして描画する
+	highlights   map[int][]Highlight
+	brackets     []contents.Position // カーソル位置の括弧と対応する括弧の位置
+	gitChanges   git.LineChanges     // ガターに印を表示する行ごとの変更（nil ならガターを表示しない）
+	branch       string              // ステータスバーに表示するブランチ名
+	selection    *selection          // 選択範囲（nil なら選択なし）
+	messageTTL   time.Duration       // ステータスメッセージの既定の表示時間
+	region       *Region             // 画面を分割している場合にフォーカスのある区画（nil なら画面全体）
+	panes        []Pane              // 画面を分割している場合にフォーカスのない区画
+	wrap         bool                // 長い行を折り返して表示する
+	wrapTop      int                 // 折り返し表示で、先頭の行のうち画面の上端より上に隠れている表示行の数
+	segments     []StatusSegment     // ステータスバーに表示する項目（nil なら既定の並び）
+	theme        Theme               // 描画に使う色と装飾
+	tabs         []Tab               // タブバーに表示するタブ（nil ならタブバーを表示しない）
+	activeTab    int                 // 選択中のタブの位置
+	search       SearchStatus        // ステータスバーに表示する検索の状態
+	welcome      string              // 空のバッファに表示するメッセージ（空なら defaultWelcome）
+}
+
+// 下線を引く範囲（[]Highlight）を置く行のメタデータの名前空間
+// 行の挿入・削除に合わせて移動し、内容を編集した行の範囲は消える
+// 診断などほかの強調表示と重なる部分は、検索の一致箇所・診断・綴りの誤りの順に優先する
+const (
+	DiagnosticsMeta  = "screen.diagnostics"  // 言語サーバーの診断（次に診断を受け取るまで消えたまま）
+	MisspellingsMeta = "screen.misspellings" // 綴りの誤り（空なら確認済みで誤りがない。消えた行は次の描画で確認し直す）
+)
+
+// Highlight はバッファ内で強調表示する範囲（ルーン単位）
+type Highlight struct {
+	Line     int
+	Col      int
+	Length   int
+	Current  bool // 選択中の範囲は別の色で表示する
+	Selected bool // 選択範囲は反転表示する
+	Bracket  bool // 対応する括弧は別の色で表示する
+	// 0 以外なら診断の範囲で、深刻度に応じた下線で表示する
+	Diagnostic diagnostic.Severity
+	Misspelled bool // 綴りの誤りは下線で表示する
+}
+
+// selection は選択範囲 [start, end)
+// 矩形選択の場合は start.Y 行目から end.Y 行目までの、表示上の left 桁から right 桁の手前まで
+type selection struct {
+	start, end  contents.Position
+	block       bool
+	left, right int
+}
+
+// overlay は編集領域の上に重ねて表示する情報パネル
+type overlay struct {
+	title    string
+	lines    []string
+	selected int // 選択中の行（-1 なら選択なし）
+	offset   int // 表示を開始する行
+	footer   string
+	items    []BrowserItem // nil でなければ lines の代わりにファイル一覧として描画する
 }
 
 type position struct {
@@ -54,10 +150,12 @@ func NewScreen(
 		message:      message,
 		debugMessage: "",
 		cursor:       cursor,
+		messageTTL:   defaultMessageDuration,
 	}
 }
 
 func (s *Screen) SetRowOffset(y int) {
+	s.wrapTop = 0
 	s.scrollOffset.y = y
 }
 
@@ -89,12 +187,54 @@ func (s *Screen) GetColLines() int {
 	return s.colLines
 }
 
+// Resize は端末の大きさを変更する
+func (s *Screen) Resize(rows, cols int) {
+	s.rowLines = max(rows, 0)
+	s.colLines = max(cols, 0)
+	// 大きさが変わると端末の表示が崩れるため、次の描画では差分ではなく全体を書き直す
+	s.Invalidate()
+}
+
+// Invalidate は次の描画で差分ではなく画面全体を書き直させる
+// 外部のコマンドが端末に出力した後など、端末の表示が前回の描画と異なる場合に使う
+func (s *Screen) Invalidate() {
+	if inv, ok := s.writer.(writer.Invalidator); ok {
+		inv.Invalidate()
+	}
+}
+
+// TooSmall は端末が小さすぎて編集領域を描画できないかどうかを返す
+// 分割している場合は、いずれかの区画に本文を1行も表示できなければ小さすぎるとみなす
+func (s *Screen) TooSmall() bool {
+	if s.rowLines < minRows || s.colLines < minCols {
+		return true
+	}
+	if s.region == nil {
+		return false
+	}
+	if s.region.Rows < 1 || s.region.Cols < 1 {
+		return true
+	}
+	for _, p := range s.panes {
+		if p.Region.Rows < 1 || p.Region.Cols < 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // func (s *Screen) MoveCursor(m cursor.Movement, buffer *contents.Contents) {
 // 	s.builder.MoveCursor(m, buffer)
 // }
 
 // Redraw は画面を再描画する
 func (s *Screen) Redraw(buffer *contents.Contents, filename string) error {
+	if s.TooSmall() {
+		return s.drawTooSmall()
+	}
+	if s.region != nil {
+		return s.redrawPanes(buffer, filename)
+	}
 	isDirty := buffer.IsDirty()
 
 	// 既存のバッファをクリア
@@ -122,7 +262,14 @@ func (s *Screen) Redraw(buffer *contents.Contents, filename string) error {
 	// カーソル位置の設定（画面バッファに追加）
 	pos := s.cursor.ToPosition()
 	screenX, screenY := s.getScreenPosition(pos.X, pos.Y, buffer, s.scrollOffset.y, s.scrollOffset.x)
-	s.builder.Write(fmt.Sprintf("\x1b[%d;%dH", screenY+1, screenX+1))
+	if s.prompt != nil {
+		s.builder.Write(s.promptCursor())
+	} else {
+		s.builder.Write(fmt.Sprintf("\x1b[%d;%dH", screenY+1, s.GutterWidth()+screenX+1))
+	}
+
+	// 端末タイトルの更新（ファイル名が変わった時のみ）
+	s.updateTitle(filename)
 
 	// デバッグ情報の設定（画面描画後）
 	s.debugMessage = contents.DebugMessage(fmt.Sprintf("RefreshScreen: isDirty=%v, filename=%s", isDirty, filename))
@@ -136,6 +283,23 @@ func (s *Screen) Redraw(buffer *contents.Contents, filename string) error {
 	return nil
 }
 
+// drawTooSmall は端末が小さすぎる場合に、編集領域の代わりに案内を表示する
+// 端末が大きくなれば次の描画で元の表示に戻る
+func (s *Screen) drawTooSmall() error {
+	s.builder.Clear()
+	s.builder.Write(escape + clearSequence)
+
+	lines := []string{
+		"Window too small",
+		fmt.Sprintf("%dx%d (min %dx%d)", s.colLines, s.rowLines, minCols, minRows),
+	}
+	for y := 0; y < len(lines) && y < s.rowLines; y++ {
+		s.builder.Write(moveTo(y, 0) + fitWidth(lines[y], s.colLines))
+	}
+	s.builder.Write(escape + cursorHomeSequence)
+	return s.writer.Write(s.builder.Build())
+}
+
 // Flush は画面バッファを画面に反映する
 func (s *Screen) Flush() error {
 	return s.writer.Write(s.builder.Build())
@@ -146,6 +310,42 @@ func (s *Screen) SetMessage(format string, args ...interface{}) {
 	s.message.SetMessage(format, args...)
 }
 
+// SetMessageFor は表示時間を指定してステータスメッセージを設定する
+// contents.Persistent を指定すると DismissMessage を呼ぶまで表示し続ける
+func (s *Screen) SetMessageFor(duration time.Duration, format string, args ...interface{}) {
+	s.message.SetMessageFor(duration, format, args...)
+}
+
+// SetMessageDuration はステータスメッセージの既定の表示時間を設定する
+func (s *Screen) SetMessageDuration(duration time.Duration) {
+	if duration > 0 {
+		s.messageTTL = duration
+	}
+}
+
+// MessageDuration はステータスメッセージの既定の表示時間を返す
+func (s *Screen) MessageDuration() time.Duration {
+	return s.messageTTL
+}
+
+// ExpireMessage は now の時点で表示時間を過ぎたステータスメッセージを消し、消した場合は true を返す
+func (s *Screen) ExpireMessage(now time.Time) bool {
+	if s.message.Get() == "" || !s.message.Expired(now, s.messageTTL) {
+		return false
+	}
+	s.message.Clear()
+	return true
+}
+
+// DismissMessage は表示中のステータスメッセージを閉じる。閉じるメッセージがなければ false を返す
+func (s *Screen) DismissMessage() bool {
+	if s.message.Get() == "" {
+		return false
+	}
+	s.message.Clear()
+	return true
+}
+
 // ClearDebugMessage はデバッグメッセージをクリアする
 func (s *Screen) ClearDebugMessage() {
 	s.debugMessage = ""
@@ -176,6 +376,11 @@ func (s *Screen) calculateNewCursorPosition(movement cursor.Movement, buffer *co
 
 	switch movement {
 	case cursor.CursorUp:
+		if s.wrap {
+			// 折り返し表示では表示行単位で移動する
+			newPos.X, newPos.Y = s.moveVisual(buffer, newPos.X, newPos.Y, -1)
+			break
+		}
 		if newPos.Y > 0 {
 			currentVisualX := currentRow.OffsetToScreenPosition(newPos.X)
 			newPos.Y--
@@ -185,6 +390,10 @@ func (s *Screen) calculateNewCursorPosition(movement cursor.Movement, buffer *co
 			}
 		}
 	case cursor.CursorDown:
+		if s.wrap {
+			newPos.X, newPos.Y = s.moveVisual(buffer, newPos.X, newPos.Y, 1)
+			break
+		}
 		if newPos.Y < buffer.GetLineCount()-1 {
 			currentVisualX := currentRow.OffsetToScreenPosition(newPos.X)
 			newPos.Y++
@@ -252,13 +461,17 @@ func (s *Screen) drawMessageBar() error {
 	// 行をクリア
 	s.builder.Write(escape + clearLineSequence)
 
-	// ステータスメッセージがあれば最優先で表示
-	if s.message.Get() != "" && time.Now().Unix()-s.message.GetTime() < 5 {
-		// 警告メッセージは高優先度で表示
-		s.builder.Write(s.message.String())
+	// 入力中のプロンプトはメッセージより優先して表示
+	if s.prompt != nil {
+		line, _ := s.prompt.layout(s.colLines)
+		s.builder.Write(line)
+	} else if !s.message.Expired(time.Now(), s.messageTTL) {
+		// ステータスメッセージがあれば表示（警告メッセージはデバッグメッセージより優先する）
+		// ファイル名やエラーに含まれる制御文字は端末に送らず、表記に置き換える
+		s.builder.Write(fitWidth(s.message.String(), s.colLines))
 	} else if s.debugMessage != "" {
 		// デバッグメッセージは通常メッセージがない場合のみ表示
-		s.builder.Write(s.debugMessage.String())
+		s.builder.Write(fitWidth(s.debugMessage.String(), s.colLines))
 	} else {
 		s.message.Clear()
 	}
@@ -268,6 +481,10 @@ func (s *Screen) drawMessageBar() error {
 
 // getScreenPosition はバッファ上の位置から画面上の位置を計算する
 func (s *Screen) getScreenPosition(x, y int, buffer *contents.Contents, rowOffset, colOffset int) (int, int) {
+	if s.wrap {
+		return s.wrappedScreenPosition(x, y, buffer, rowOffset)
+	}
+
 	// 行番号の調整：エディタ領域内に収める
 	screenY := y - rowOffset
 
@@ -284,22 +501,15 @@ func (s *Screen) getScreenPosition(x, y int, buffer *contents.Contents, rowOffse
 
 // drawStatusBar はステータスバーを描画する
 func (s *Screen) drawStatusBar(buffer *contents.Contents, filename string) error {
-	status := filename
-	if status == "" {
-		status = "[No Name]"
-	}
-
-	isDirty := buffer.IsDirty()
-	if isDirty {
-		status += " [+]"
-	}
+	pos := s.cursor.ToPosition()
+	status := s.statusLine(buffer, filename, contents.Position{X: pos.X, Y: pos.Y}, s.colLines, s.loading)
 
 	// ステータスバーの描画位置を明示的に設定
 	s.builder.Write(fmt.Sprintf("\x1b[%d;%dH", s.rowLines-2, 0))
 
-	// 反転表示（\x1b[7m）でステータスバーを描画
-	line := "\x1b[7m" + s.padLine(status) + "\x1b[m\r\n"
+	line := s.style().Status + status + "\x1b[m\r\n"
 	s.builder.Write(line)
+	isDirty := buffer.IsDirty()
 
 	// デバッグ情報をログに追加（ステータスバー描画後に設定）
 	s.debugMessage = contents.DebugMessage(fmt.Sprintf("StatusBar: filename=%s, isDirty=%v, fileStatus=%s", filename, isDirty, status))
@@ -307,17 +517,254 @@ func (s *Screen) drawStatusBar(buffer *contents.Contents, filename string) error
 	return nil
 }
 
+// statusLine はステータスバーに表示する文字列を表示幅 width に揃えて返す
+// 左端にファイル名と状態を、右端に設定した項目を幅に収まるだけ表示する
+func (s *Screen) statusLine(buffer *contents.Contents, filename string, pos contents.Position, width int, loading bool) string {
+	left, parts := s.statusLayout(buffer, filename, pos, width, loading)
+
+	// ファイル名やアイコンに全角文字を含む場合があるため表示幅で揃える
+	padded := fitWidth(left, width)
+	if len(parts) > 0 {
+		texts := make([]string, len(parts))
+		for i, p := range parts {
+			texts[i] = p.text
+		}
+		padded = fitWidth(left, parts[0].start) + strings.Join(texts, statusSeparator)
+	}
+	if buffer.IsDirty() && s.dirtyAlert {
+		// 長時間未保存の場合は [+] を太字で強調する（幅の計算後に装飾を加える）
+		padded = strings.Replace(padded, "[+]", "\x1b[1m[+]\x1b[22m", 1)
+	}
+	if s.search.Active && s.search.Total == 0 {
+		// 一致がないことを件数の表示と見分けられるよう、太字にする（ファイル名と重ならないよう右端から探す）
+		if i := strings.LastIndex(padded, noMatches); i >= 0 {
+			padded = padded[:i] + "\x1b[1m" + noMatches + "\x1b[22m" + padded[i+len(noMatches):]
+		}
+	}
+	return padded
+}
+
+// SetLoading はファイルの残りを読み込み中かどうかを設定する。読み込んだ割合は 0% に戻す
+func (s *Screen) SetLoading(loading bool) {
+	s.loading = loading
+	s.loadPercent = 0
+}
+
+// SetLoadProgress は読み込み中のファイルのうち読み込んだ割合（%）を設定する
+func (s *Screen) SetLoadProgress(percent int) {
+	s.loadPercent = percent
+}
+
+// SetDirtyAlert は未保存マーカーの強調表示を切り替え、状態が変わった場合は true を返す
+func (s *Screen) SetDirtyAlert(alert bool) bool {
+	changed := s.dirtyAlert != alert
+	s.dirtyAlert = alert
+	return changed
+}
+
+// SetHighlights は強調表示する範囲を設定する（既存の設定は置き換える）
+func (s *Screen) SetHighlights(highlights []Highlight) {
+	s.highlights = make(map[int][]Highlight)
+	for _, h := range highlights {
+		s.highlights[h.Line] = append(s.highlights[h.Line], h)
+	}
+}
+
+// ClearHighlights は強調表示を全て解除する
+func (s *Screen) ClearHighlights() {
+	s.highlights = nil
+}
+
+// SetBracketMatch はカーソル位置の括弧と対応する括弧を強調表示する（nil で解除）
+// 検索の強調表示とは別に保持し、検索中も表示する
+func (s *Screen) SetBracketMatch(positions []contents.Position) {
+	s.brackets = positions
+}
+
+// SetSelection は start から end の手前までを選択範囲として反転表示する
+func (s *Screen) SetSelection(start, end contents.Position) {
+	s.selection = &selection{start: start, end: end}
+}
+
+// SetBlockSelection は top 行目から bottom 行目までの、表示上の left 桁から right 桁の手前までを矩形選択として反転表示する
+func (s *Screen) SetBlockSelection(top, bottom, left, right int) {
+	s.selection = &selection{start: contents.Position{Y: top}, end: contents.Position{Y: bottom}, block: true, left: left, right: right}
+}
+
+// ClearSelection は選択範囲の表示を解除する
+func (s *Screen) ClearSelection() {
+	s.selection = nil
+}
+
+// selectionHighlight は y 行目（row）のうち選択範囲に含まれる部分を返す
+// 行末を越えて選択している場合は改行も含める
+func (s *Screen) selectionHighlight(y int, row *contents.Row) (Highlight, bool) {
+	if s.selection == nil || y < s.selection.start.Y || y > s.selection.end.Y {
+		return Highlight{}, false
+	}
+	if s.selection.block {
+		from, to := row.ScreenPositionToOffset(s.selection.left), row.ScreenPositionToOffset(s.selection.right)
+		if to <= from {
+			return Highlight{}, false
+		}
+		return Highlight{Line: y, Col: from, Length: to - from, Selected: true}, true
+	}
+	from, to := 0, row.GetRuneCount()+1
+	if y == s.selection.start.Y {
+		from = s.selection.start.X
+	}
+	if y == s.selection.end.Y {
+		to = s.selection.end.X
+	}
+	if to <= from {
+		return Highlight{}, false
+	}
+	return Highlight{Line: y, Col: from, Length: to - from, Selected: true}, true
+}
+
+// EnableHyperlinks はバッファ内の URL をハイパーリンクとして描画するかを切り替える
+func (s *Screen) EnableHyperlinks(enabled bool) {
+	s.hyperlinks = enabled
+}
+
+// EnableTitle は端末タイトルへのファイル名表示を切り替える
+// 一部の端末はOSCシーケンスを正しく扱えないため、設定で無効化できるようにしている
+func (s *Screen) EnableTitle(enabled bool) {
+	s.titleEnabled = enabled
+	s.lastTitle = ""
+}
+
+// updateTitle はファイル名が変わっていれば端末タイトルを更新するシーケンスを出力する
+func (s *Screen) updateTitle(filename string) {
+	if !s.titleEnabled {
+		return
+	}
+	title := TitleFor(filename)
+	if title == s.lastTitle {
+		return
+	}
+	s.lastTitle = title
+	// OSC 2 でウィンドウタイトルを設定する（BELで終端）
+	s.builder.Write("\x1b]2;" + title + "\x07")
+}
+
+// TitleFor は端末タイトルに表示する文字列を返す
+// ファイル名に含まれる制御文字はシーケンスを壊すため取り除く
+func TitleFor(filename string) string {
+	name := "[No Name]"
+	if filename != "" {
+		name = filepath.Base(filename)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	return name + " — go-kilo"
+}
+
+// SetOverlay は編集領域の上部にタイトル付きの情報パネルを表示する
+func (s *Screen) SetOverlay(title string, lines []string) {
+	s.overlay = &overlay{
+		title:    title,
+		lines:    append([]string{}, lines...),
+		selected: -1,
+		footer:   "Press any key to close",
+	}
+}
+
+// SetListOverlay は選択行付きの情報パネルを表示する
+// 選択行が表示範囲に収まるようにパネル内をスクロールする
+func (s *Screen) SetListOverlay(title string, lines []string, selected int, footer string) {
+	offset := 0
+	if s.overlay != nil {
+		offset = s.overlay.offset
+	}
+	if height := s.overlayHeight(); height > 0 && selected >= 0 {
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+height {
+			offset = selected - height + 1
+		}
+	}
+	s.overlay = &overlay{
+		title:    title,
+		lines:    append([]string{}, lines...),
+		selected: selected,
+		offset:   offset,
+		footer:   footer,
+	}
+}
+
+// overlayHeight は情報パネルの本文に使える行数を返す
+func (s *Screen) overlayHeight() int {
+	// 編集領域からタイトル行とフッター行を除いた分
+	return s.TextRows() - 2
+}
+
+// ClearOverlay は情報パネルを閉じる
+func (s *Screen) ClearOverlay() {
+	s.overlay = nil
+}
+
+// HasOverlay は情報パネルが表示中かどうかを返す
+func (s *Screen) HasOverlay() bool {
+	return s.overlay != nil
+}
+
+// overlayRow は情報パネルの y 行目の内容を返す。パネルの範囲外なら false を返す
+func (s *Screen) overlayRow(y int) (string, bool) {
+	if s.overlay == nil {
+		return "", false
+	}
+	if y == 0 {
+		return "\x1b[7m" + fitWidth(" "+s.overlay.title, s.TextCols()) + "\x1b[m", true
+	}
+
+	visible := len(s.overlay.lines) - s.overlay.offset
+	if height := s.overlayHeight(); visible > height {
+		visible = height
+	}
+	switch {
+	case y <= visible:
+		i := s.overlay.offset + y - 1
+		var line string
+		if s.overlay.items != nil {
+			line = browserLine(s.overlay.items[i], s.TextCols())
+		} else {
+			line = fitWidth(" "+s.overlay.lines[i], s.TextCols())
+		}
+		if i == s.overlay.selected {
+			return "\x1b[7m" + line + "\x1b[m", true
+		}
+		return line, true
+	case y == visible+1:
+		return "\x1b[7m" + fitWidth(" "+s.overlay.footer, s.TextCols()) + "\x1b[m", true
+	}
+	return "", false
+}
+
 // drawRows は編集領域を描画する
 func (s *Screen) drawRows(buffer *contents.Contents, rowOffset, colOffset int) error {
-	for y := 0; y < s.rowLines-2; y++ {
-		filerow := y + rowOffset
+	cols := s.TextCols()
+	lines := s.visualLines(buffer, rowOffset, colOffset, s.wrapTop, s.rowLines-2, cols)
+	for y, v := range lines {
 		s.builder.Write("\x1b[2K") // 各行をクリア
+		s.builder.Write(s.gutterCell(v))
+
+		// 情報パネルが表示中の場合はその行を優先して描画
+		if line, ok := s.overlayRow(y); ok {
+			s.builder.Write(line)
+			s.builder.Write("\r\n")
+			continue
+		}
 
 		// ファイル内の有効な行の場合
-		if filerow < buffer.GetLineCount() {
-			row := buffer.GetRow(filerow)
+		if v.line < buffer.GetLineCount() {
+			row := buffer.GetRow(v.line)
 			if row != nil {
-				s.builder.Write(s.drawTextRow(row, colOffset))
+				s.builder.Write(s.drawTextRange(row, v.col, cols, v.end, s.rowHighlights(buffer, v.line, row)...))
 			}
 		} else {
 			// ファイルの終端以降は空行を表示
@@ -329,6 +776,45 @@ func (s *Screen) drawRows(buffer *contents.Contents, rowOffset, colOffset int) e
 	return nil
 }
 
+// rowHighlights は buffer の filerow 行目に描画する強調表示と選択範囲を返す
+func (s *Screen) rowHighlights(buffer *contents.Contents, filerow int, row *contents.Row) []Highlight {
+	highlights := s.highlights[filerow]
+	if h, ok := s.selectionHighlight(filerow, row); ok {
+		highlights = append([]Highlight{h}, highlights...)
+	}
+	for _, p := range s.brackets {
+		if p.Y == filerow {
+			highlights = append(highlights[:len(highlights):len(highlights)], Highlight{Line: p.Y, Col: p.X, Length: 1, Bracket: true})
+		}
+	}
+	if diags := lineHighlights(buffer, DiagnosticsMeta, filerow); len(diags) > 0 {
+		highlights = append(highlights[:len(highlights):len(highlights)], diags...)
+	}
+	if words := lineHighlights(buffer, MisspellingsMeta, filerow); len(words) > 0 {
+		highlights = append(highlights[:len(highlights):len(highlights)], words...)
+	}
+	return highlights
+}
+
+// lineHighlights は buffer の filerow 行目に行のメタデータとして置いた強調表示を返す
+func lineHighlights(buffer *contents.Contents, namespace string, filerow int) []Highlight {
+	if buffer == nil {
+		return nil
+	}
+	value, ok := buffer.LineMeta(namespace, filerow)
+	if !ok {
+		return nil
+	}
+	stored, _ := value.([]Highlight)
+	// 行が移動していても、描画する行の位置で返す
+	highlights := make([]Highlight, len(stored))
+	for i, h := range stored {
+		h.Line = filerow
+		highlights[i] = h
+	}
+	return highlights
+}
+
 // drawEmptyRow は空行（チルダ）またはウェルカムメッセージを描画
 func (s *Screen) drawEmptyRow(y int, totalLines int) string {
 	if totalLines == 0 && y == s.rowLines/3 {
@@ -337,14 +823,22 @@ func (s *Screen) drawEmptyRow(y int, totalLines int) string {
 	return "~"
 }
 
+// SetWelcome は空のバッファに表示するウェルカムメッセージを設定する。空なら既定のメッセージを表示する
+func (s *Screen) SetWelcome(text string) {
+	s.welcome = text
+}
+
 // drawWelcomeMessage はウェルカムメッセージを描画
+// 全角文字を含むメッセージも中央に揃うよう、バイト数ではなく表示幅で揃え、幅に収まらなければ文字の途中で切らずに切り詰める
 func (s *Screen) drawWelcomeMessage() string {
-	// TODO: getにする
-	welcome := "Kilo editor -- version 1.0"
-	if len(welcome) > s.colLines {
-		welcome = welcome[:s.colLines]
+	welcome := s.welcome
+	if welcome == "" {
+		welcome = defaultWelcome
 	}
-	padding := (s.colLines - len(welcome)) / 2
+	if displayWidth(welcome) > s.colLines {
+		welcome = fitWidth(welcome, s.colLines)
+	}
+	padding := (s.colLines - displayWidth(welcome)) / 2
 	var builder strings.Builder
 	if padding > 0 {
 		builder.WriteString("~")
@@ -357,8 +851,18 @@ func (s *Screen) drawWelcomeMessage() string {
 	return builder.String()
 }
 
-// drawTextRow はテキスト行を描画
-func (s *Screen) drawTextRow(row *contents.Row, colOffset int) string {
+// drawTextRow はテキスト行を表示幅 cols で描画
+// highlights に含まれる文字は背景色を付けて描画する
+func (s *Screen) drawTextRow(row *contents.Row, colOffset, cols int, highlights ...Highlight) string {
+	if row == nil {
+		return ""
+	}
+	return s.drawTextRange(row, colOffset, cols, row.GetRuneCount(), highlights...)
+}
+
+// drawTextRange はテキスト行の end 文字目の手前までを表示幅 cols で描画する
+// 折り返し表示では表示行ごとに呼び出し、改行マークは行の最後の表示行にだけ描画する
+func (s *Screen) drawTextRange(row *contents.Row, colOffset, cols, end int, highlights ...Highlight) string {
 	if row == nil {
 		return ""
 	}
@@ -366,6 +870,13 @@ func (s *Screen) drawTextRow(row *contents.Row, colOffset int) string {
 	var builder strings.Builder
 	chars := row.GetRunes()
 	currentPos := 0
+	trailing := trailingSpaceStart(chars)
+
+	var links []annotation.Span
+	if s.hyperlinks {
+		links = annotation.URLs.Annotate(chars)
+	}
+	linkOpen := false
 
 	// colOffsetより前の文字をスキップし、画面幅を超えないように描画
 	for i, char := range chars {
@@ -377,39 +888,87 @@ func (s *Screen) drawTextRow(row *contents.Row, colOffset int) string {
 			continue
 		}
 
-		// 画面幅を超える場合は描画終了
-		if currentPos-colOffset >= s.colLines {
+		// 画面幅を超える場合や、表示する範囲の終わりに達した場合は描画終了
+		if currentPos-colOffset >= cols || i >= end {
 			break
 		}
 
-		// 制御文字を特定のシンボルに置き換え
+		// URL の範囲に入ったらリンクを開始し、抜けたら閉じる
+		if len(links) > 0 {
+			link, inLink := annotation.At(links, i)
+			if linkOpen && (!inLink || i == link.Start) {
+				builder.WriteString(hyperlinkClose)
+				linkOpen = false
+			}
+			if inLink && !linkOpen {
+				builder.WriteString(hyperlinkOpen + link.Target + hyperlinkEnd)
+				linkOpen = true
+			}
+		}
+
+		// 強調表示する文字は空白記号などの装飾を付けずに背景色だけで示す
+		if color, ok := s.highlightColor(highlights, i); ok {
+			builder.WriteString(color)
+			if char == '\t' {
+				builder.WriteString(strings.Repeat(" ", defaultTabWidth))
+			} else if notation, ok := contents.ControlNotation(char); ok {
+				builder.WriteString(notation)
+			} else {
+				builder.WriteRune(char)
+			}
+			builder.WriteString(resetColor)
+			currentPos += width
+			continue
+		}
+
+		// 制御文字を特定のシンボルに置き換え。行末の空白は背景色を付けて目立たせる
+		spaceColor := s.style().ControlChar
+		if i >= trailing {
+			spaceColor = s.style().TrailingSpace
+		}
 		switch char {
 		case '\t':
-			builder.WriteString(controlCharColor)
+			builder.WriteString(spaceColor)
 			builder.WriteString(strings.Repeat(" ", defaultTabWidth))
 			builder.WriteString(resetColor)
 		case ' ':
-			builder.WriteString(controlCharColor)
+			builder.WriteString(spaceColor)
 			builder.WriteRune('·')
 			builder.WriteString(resetColor)
 		default:
-			builder.WriteRune(char)
+			// 制御文字は端末に解釈されないよう、反転表示のキャレット表記で描画する
+			if notation, ok := contents.ControlNotation(char); ok {
+				builder.WriteString(reverseVideo)
+				builder.WriteString(notation)
+				builder.WriteString(resetColor)
+			} else {
+				builder.WriteRune(char)
+			}
 		}
 
 		currentPos += width
 	}
+	if linkOpen {
+		builder.WriteString(hyperlinkClose)
+	}
 
 	// 行末に改行マークを追加（画面幅を超えない場合のみ）
-	if currentPos-colOffset < s.colLines && row.GetContent() != "" {
+	// 改行が選択範囲に含まれる場合は、空行でも反転表示のマークで示す
+	eolColor, eolSelected := s.highlightColor(highlights, len(chars))
+	if end >= len(chars) && currentPos-colOffset < cols && (row.GetContent() != "" || eolSelected) {
 		// 行末に改行マークを追加（グレー色で表示）
-		builder.WriteString(controlCharColor)
+		if eolSelected {
+			builder.WriteString(eolColor)
+		} else {
+			builder.WriteString(s.style().ControlChar)
+		}
 		builder.WriteString("↵")
 		builder.WriteString(resetColor)
 		currentPos++
 	}
 
 	// 行末までスペースで埋める
-	remaining := s.colLines - (currentPos - colOffset)
+	remaining := cols - (currentPos - colOffset)
 	if remaining > 0 {
 		builder.WriteString(strings.Repeat(" ", remaining))
 	}
@@ -417,6 +976,43 @@ func (s *Screen) drawTextRow(row *contents.Row, colOffset int) string {
 	return builder.String()
 }
 
+// trailingSpaceStart は行末に続く空白（スペースとタブ）の始まる位置を返す。行末に空白がなければ len(chars) を返す
+func trailingSpaceStart(chars []rune) int {
+	i := len(chars)
+	for i > 0 && (chars[i-1] == ' ' || chars[i-1] == '\t') {
+		i--
+	}
+	return i
+}
+
+// highlightColor は x 番目の文字を強調表示する色を返す
+func (s *Screen) highlightColor(highlights []Highlight, x int) (string, bool) {
+	for _, h := range highlights {
+		if h.Col <= x && x < h.Col+h.Length {
+			if h.Selected {
+				return s.style().Selection, true
+			}
+			if h.Current {
+				return s.style().CurrentMatch, true
+			}
+			if h.Bracket {
+				return s.style().BracketMatch, true
+			}
+			if h.Diagnostic == diagnostic.SeverityError {
+				return s.style().Error, true
+			}
+			if h.Diagnostic != 0 {
+				return s.style().Warning, true
+			}
+			if h.Misspelled {
+				return s.style().Misspelled, true
+			}
+			return s.style().Match, true
+		}
+	}
+	return "", false
+}
+
 // clearScreen は画面をクリアする
 func (s *Screen) ClearScreen() string {
 	return escape + clearSequence
@@ -427,10 +1023,29 @@ func (s *Screen) MoveCursorToHome() string {
 	return escape + cursorHomeSequence
 }
 
-// padLine は行を画面幅に合わせてパディングする
-func (s *Screen) padLine(line string) string {
-	if len(line) > s.colLines {
-		return line[:s.colLines]
+// fitWidth は文字列を表示幅 width に収まるように切り詰め、足りない分を空白で埋める
+// 全角文字の途中で切れないように、表示幅単位で計算する
+func fitWidth(line string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	row := contents.NewRow(line)
+	var builder strings.Builder
+	used := 0
+	for i, r := range row.GetRunes() {
+		w := row.GetRuneWidth(i)
+		if used+w > width {
+			break
+		}
+		if notation, ok := contents.ControlNotation(r); ok {
+			builder.WriteString(notation)
+		} else {
+			builder.WriteRune(r)
+		}
+		used += w
+	}
+	if used < width {
+		builder.WriteString(strings.Repeat(" ", width-used))
 	}
-	return line + strings.Repeat(" ", s.colLines-len(line))
+	return builder.String()
 }
@@ -0,0 +1,379 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/boundary/writer"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/diagnostic"
+)
+
+func TestTitleFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{"ファイル名のみ表示", "/tmp/work/main.go", "main.go — go-kilo"},
+		{"無名バッファ", "", "[No Name] — go-kilo"},
+		{"制御文字を除去", "evil\x07\x1b]2;x.txt", "evil]2;x.txt — go-kilo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TitleFor(tt.filename); got != tt.expected {
+				t.Errorf("TitleFor(%q) = %q, want %q", tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		width    int
+		expected string
+	}{
+		{"空白で埋める", "abc", 5, "abc  "},
+		{"切り詰め", "abcdef", 4, "abcd"},
+		{"全角文字の途中で切らない", "あいう", 5, "あい "},
+		{"制御文字はキャレット表記", "a\x1bb", 5, "a^[b "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitWidth(tt.line, tt.width); got != tt.expected {
+				t.Errorf("fitWidth(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDrawWelcomeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		welcome  string
+		cols     int
+		expected string
+	}{
+		{"既定のメッセージ", "", 30, "~ Kilo editor -- version 1.0"},
+		// 全角文字は2桁として中央に揃える
+		{"全角文字", "ようこそ", 12, "~ ようこそ"},
+		// 収まらない全角文字は途中で切らずに空白で埋める
+		{"表示幅で切り詰める", "ようこそ", 7, "ようこ "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Screen{colLines: tt.cols}
+			s.SetWelcome(tt.welcome)
+			if got := s.drawWelcomeMessage(); got != tt.expected {
+				t.Errorf("drawWelcomeMessage() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListOverlay_ScrollsToSelection(t *testing.T) {
+	// 編集領域4行のうち本文に使えるのは2行
+	s := &Screen{rowLines: 6, colLines: 10}
+	lines := []string{"a", "b", "c", "d"}
+
+	s.SetListOverlay("T", lines, 0, "F")
+	if row, _ := s.overlayRow(1); row != "\x1b[7m"+fitWidth(" a", 10)+"\x1b[m" {
+		t.Errorf("selected row should be highlighted: %q", row)
+	}
+
+	s.SetListOverlay("T", lines, 3, "F")
+	if row, _ := s.overlayRow(1); row != fitWidth(" c", 10) {
+		t.Errorf("expected scrolled row c, got %q", row)
+	}
+	if row, _ := s.overlayRow(3); row != "\x1b[7m"+fitWidth(" F", 10)+"\x1b[m" {
+		t.Errorf("expected footer, got %q", row)
+	}
+	if _, ok := s.overlayRow(4); ok {
+		t.Error("rows below the footer must not belong to the overlay")
+	}
+}
+
+func TestDrawTextRow_ControlCharacters(t *testing.T) {
+	s := &Screen{colLines: 8}
+	got := s.drawTextRow(contents.NewRow("a\x0cb"), 0, 8)
+	want := "a" + reverseVideo + "^L" + resetColor + "b" + controlCharColor + "↵" + resetColor + "   "
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+}
+
+func TestDrawTextRow_TrailingWhitespace(t *testing.T) {
+	s := &Screen{colLines: 12}
+	got := s.drawTextRow(contents.NewRow("a b \t"), 0, 12)
+	space := controlCharColor + "·" + resetColor
+	want := "a" + space + "b" + trailingSpace + "·" + resetColor + trailingSpace + "    " + resetColor +
+		controlCharColor + "↵" + resetColor
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("drawTextRow() = %q, want prefix %q", got, want)
+	}
+
+	// 強調表示の背景色を優先する
+	got = s.drawTextRow(contents.NewRow("a "), 0, 12, Highlight{Col: 1, Length: 1, Selected: true})
+	if strings.Contains(got, trailingSpace) {
+		t.Errorf("selected trailing whitespace must use the selection color: %q", got)
+	}
+}
+
+func TestDrawTextRow_Hyperlinks(t *testing.T) {
+	s := &Screen{colLines: 40}
+	row := contents.NewRow("x http://a.jp")
+	if got := s.drawTextRow(row, 0, 40); strings.Contains(got, hyperlinkOpen) {
+		t.Fatalf("hyperlinks must be disabled by default: %q", got)
+	}
+
+	s.EnableHyperlinks(true)
+	space := controlCharColor + "·" + resetColor
+	want := "x" + space + hyperlinkOpen + "http://a.jp" + hyperlinkEnd + "http://a.jp" + hyperlinkClose
+	if got := s.drawTextRow(row, 0, 40); !strings.HasPrefix(got, want) {
+		t.Errorf("drawTextRow() = %q, want prefix %q", got, want)
+	}
+
+	// 横スクロールで URL の途中から表示する場合も、リンク先は URL 全体を指す
+	want = hyperlinkOpen + "http://a.jp" + hyperlinkEnd + "p://a.jp" + hyperlinkClose
+	if got := s.drawTextRow(row, 5, 40); !strings.HasPrefix(got, want) {
+		t.Errorf("drawTextRow(offset) = %q, want prefix %q", got, want)
+	}
+}
+
+func TestDrawTextRow_Highlights(t *testing.T) {
+	s := &Screen{colLines: 8}
+	got := s.drawTextRow(contents.NewRow("ab a"), 0, 8,
+		Highlight{Line: 0, Col: 0, Length: 1, Current: true},
+		Highlight{Line: 0, Col: 2, Length: 2})
+	want := currentMatch + "a" + resetColor + "b" +
+		matchColor + " " + resetColor + matchColor + "a" + resetColor +
+		controlCharColor + "↵" + resetColor + "   "
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+}
+
+func TestBracketMatchHighlight(t *testing.T) {
+	s := &Screen{colLines: 4}
+	s.SetHighlights([]Highlight{{Line: 0, Col: 0, Length: 1}})
+	s.SetBracketMatch([]contents.Position{{X: 1, Y: 0}, {X: 0, Y: 2}})
+
+	row := contents.NewRow("(x)")
+	got := s.drawTextRow(row, 0, 4, s.rowHighlights(nil, 0, row)...)
+	want := matchColor + "(" + resetColor + bracketMatch + "x" + resetColor + ")" +
+		controlCharColor + "↵" + resetColor
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+	if len(s.highlights[0]) != 1 || len(s.rowHighlights(nil, 1, row)) != 0 {
+		t.Error("bracket highlights must not change the search highlights")
+	}
+
+	s.SetBracketMatch(nil)
+	if len(s.rowHighlights(nil, 2, row)) != 0 {
+		t.Error("clearing must remove the bracket highlights")
+	}
+}
+
+func TestDiagnosticHighlight(t *testing.T) {
+	s := &Screen{colLines: 4}
+	s.SetHighlights([]Highlight{{Line: 1, Col: 0, Length: 1}})
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"abc"})
+	buffer.SetLineMeta(DiagnosticsMeta, 0, []Highlight{
+		{Line: 0, Col: 0, Length: 2, Diagnostic: diagnostic.SeverityError},
+		{Line: 0, Col: 2, Length: 1, Diagnostic: diagnostic.SeverityHint},
+	})
+
+	// 行頭での改行で診断は行と共に移動し、検索の一致箇所と重なる部分は一致箇所の色を優先する
+	if err := buffer.InsertNewline(contents.Position{X: 0, Y: 0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	row := buffer.GetRow(1)
+	got := s.drawTextRow(row, 0, 4, s.rowHighlights(buffer, 1, row)...)
+	want := matchColor + "a" + resetColor + errorUnderline + "b" + resetColor + warningUnderline + "c" + resetColor +
+		controlCharColor + "↵" + resetColor
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+
+	// 内容を編集した行の診断は消える
+	if err := buffer.InsertChar(contents.Position{X: 3, Y: 1}, 'd'); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.rowHighlights(buffer, 1, buffer.GetRow(1))) != 1 {
+		t.Error("editing the line must remove the diagnostic highlights")
+	}
+}
+
+func TestMisspelledHighlight(t *testing.T) {
+	s := &Screen{colLines: 6}
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"tehx"})
+	buffer.SetLineMeta(DiagnosticsMeta, 0, []Highlight{{Line: 0, Col: 0, Length: 1, Diagnostic: diagnostic.SeverityError}})
+	buffer.SetLineMeta(MisspellingsMeta, 0, []Highlight{{Line: 0, Col: 0, Length: 3, Misspelled: true}})
+
+	// 診断と重なる部分は診断の色を優先する
+	row := buffer.GetRow(0)
+	got := s.drawTextRow(row, 0, 6, s.rowHighlights(buffer, 0, row)...)
+	want := errorUnderline + "t" + resetColor + misspelled + "e" + resetColor + misspelled + "h" + resetColor + "x" +
+		controlCharColor + "↵" + resetColor + " "
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+
+	buffer.ClearLineMeta(MisspellingsMeta)
+	if len(s.rowHighlights(buffer, 0, row)) != 1 {
+		t.Error("clearing must remove the misspelled words")
+	}
+}
+
+func TestSelectionHighlight(t *testing.T) {
+	s := &Screen{colLines: 4}
+	s.SetSelection(contents.Position{X: 1, Y: 0}, contents.Position{X: 0, Y: 2})
+
+	// 最初の行は開始位置から改行まで
+	h, ok := s.selectionHighlight(0, contents.NewRow("ab"))
+	if !ok || h.Col != 1 || h.Length != 2 || !h.Selected {
+		t.Errorf("unexpected highlight: %+v %v", h, ok)
+	}
+	// 途中の空行は改行のマークを反転表示する
+	h, _ = s.selectionHighlight(1, contents.NewRow(""))
+	if got := s.drawTextRow(contents.NewRow(""), 0, 4, h); got != reverseVideo+"↵"+resetColor+"   " {
+		t.Errorf("drawTextRow() = %q", got)
+	}
+	// 終了位置が行頭の行は選択しない
+	if _, ok := s.selectionHighlight(2, contents.NewRow("abc")); ok {
+		t.Error("the last line must not be selected")
+	}
+
+	s.ClearSelection()
+	if _, ok := s.selectionHighlight(0, contents.NewRow("ab")); ok {
+		t.Error("cleared selection must not be drawn")
+	}
+}
+
+func TestBlockSelectionHighlight(t *testing.T) {
+	s := &Screen{colLines: 10}
+	s.SetBlockSelection(0, 1, 2, 4)
+
+	// 表示上の桁で範囲を決めるため、全角文字の行では文字数が変わる
+	h, ok := s.selectionHighlight(0, contents.NewRow("abcdef"))
+	if !ok || h.Col != 2 || h.Length != 2 {
+		t.Errorf("unexpected highlight: %+v %v", h, ok)
+	}
+	h, ok = s.selectionHighlight(1, contents.NewRow("あいう"))
+	if !ok || h.Col != 1 || h.Length != 1 {
+		t.Errorf("unexpected highlight for wide characters: %+v %v", h, ok)
+	}
+	// 左端に届かない短い行は選択しない
+	if _, ok := s.selectionHighlight(1, contents.NewRow("a")); ok {
+		t.Error("a short line must not be selected")
+	}
+	if _, ok := s.selectionHighlight(2, contents.NewRow("abcdef")); ok {
+		t.Error("rows below the block must not be selected")
+	}
+}
+
+func TestExpireMessage(t *testing.T) {
+	s := &Screen{message: contents.NewMessage(""), messageTTL: time.Second}
+	now := time.Now()
+
+	s.SetMessage("saved")
+	if s.ExpireMessage(now) {
+		t.Error("message must not expire immediately")
+	}
+	if !s.ExpireMessage(now.Add(2 * time.Second)) {
+		t.Error("message must expire after the default duration")
+	}
+
+	s.SetMessageFor(contents.Persistent, "error")
+	if s.ExpireMessage(now.Add(time.Hour)) {
+		t.Error("persistent message must not expire")
+	}
+	if !s.DismissMessage() || s.DismissMessage() {
+		t.Error("persistent message must be dismissed exactly once")
+	}
+}
+
+func TestDrawMessageBar_ControlCharacters(t *testing.T) {
+	s := NewScreen(contents.NewBuilder(), nil, contents.NewMessage(""), cursor.NewCursor(), 6, 20)
+	s.SetMessage("open %s", "a\x1b[2Jb")
+	s.drawMessageBar()
+	got := s.builder.Build()
+	if strings.Contains(got, "\x1b[2J") || !strings.Contains(got, "a^[[2Jb") {
+		t.Errorf("control characters must be shown in caret notation: %q", got)
+	}
+}
+
+// recordingWriter は最後に書き出した内容を記録する
+type recordingWriter struct {
+	last string
+}
+
+func (w *recordingWriter) Write(s string) error {
+	w.last = s
+	return nil
+}
+
+func TestRedraw_DiffWriter(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"hello", "world"})
+	w := &recordingWriter{}
+	s := NewScreen(contents.NewBuilder(), writer.NewDiffWriter(w), contents.NewMessage(""), cursor.NewCursor(), 6, 20)
+
+	s.Redraw(buffer, "a.txt")
+	if !strings.Contains(w.last, "hello") {
+		t.Fatalf("the first frame must be drawn: %q", w.last)
+	}
+
+	// カーソルを動かしただけなら本文の行は書き直さない
+	s.SetCursorPosition(2, 1)
+	s.Redraw(buffer, "a.txt")
+	if strings.Contains(w.last, "hello") || strings.Contains(w.last, "world") || strings.Contains(w.last, "\x1b[2J") {
+		t.Errorf("unchanged rows must not be rewritten: %q", w.last)
+	}
+
+	// 大きさが変わった後は全体を書き直す
+	s.Resize(7, 20)
+	s.Redraw(buffer, "a.txt")
+	if !strings.Contains(w.last, "\x1b[2J") || !strings.Contains(w.last, "hello") {
+		t.Errorf("the whole screen must be redrawn after resizing: %q", w.last)
+	}
+}
+
+func TestRedraw_TooSmall(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"hello", "world"})
+	w := &recordingWriter{}
+	s := NewScreen(contents.NewBuilder(), w, contents.NewMessage(""), cursor.NewCursor(), 3, 20)
+
+	for _, size := range [][2]int{{3, 20}, {1, 1}, {0, 0}, {24, 5}} {
+		s.Resize(size[0], size[1])
+		if err := s.Redraw(buffer, "a.txt"); err != nil {
+			t.Fatalf("%v: unexpected error: %v", size, err)
+		}
+		if strings.Contains(w.last, "hello") {
+			t.Errorf("%v: the buffer must not be drawn: %q", size, w.last)
+		}
+	}
+
+	// 大きくなれば元の表示に戻る
+	s.Resize(5, 20)
+	s.Redraw(buffer, "a.txt")
+	if !strings.Contains(w.last, "hello") || strings.Contains(w.last, "too small") {
+		t.Errorf("the buffer must be drawn again: %q", w.last)
+	}
+
+	// 分割した区画に本文を表示できない場合も案内を表示する
+	s.SetLayout(Region{Rows: 0, Cols: 20}, nil)
+	s.Redraw(buffer, "a.txt")
+	if !strings.Contains(w.last, "Window too small") {
+		t.Errorf("expected the placeholder: %q", w.last)
+	}
+}
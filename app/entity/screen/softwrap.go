@@ -0,0 +1,220 @@
+package screen
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/wrap"
+)
+
+// visualLine は画面の1行に表示する内容。line 行目を表示幅で col 桁目から、end 文字目の手前まで表示する
+// line がバッファの行数以上の場合はファイルの終端以降の空行
+type visualLine struct {
+	line     int
+	col, end int
+}
+
+// SetWrap は長い行を折り返して表示するかどうかを切り替える
+// 折り返し表示では横方向にスクロールせず、上下のカーソル移動は表示行単位になる
+func (s *Screen) SetWrap(on bool) {
+	s.wrap = on
+	s.wrapTop = 0
+	if on {
+		s.scrollOffset.x = 0
+	}
+}
+
+// Wrap は長い行を折り返して表示しているかどうかを返す
+func (s *Screen) Wrap() bool {
+	return s.wrap
+}
+
+// ScrollWrapped は折り返し表示で、カーソルのある表示行が上下 margin 行の余白の内側に入るようスクロール位置を調整する
+func (s *Screen) ScrollWrapped(buffer *contents.Contents, margin int) {
+	rows, cols := max(s.TextRows(), 1), s.TextCols()
+	pos := s.cursor.ToPosition()
+	row := buffer.GetRow(pos.Y)
+	if row == nil {
+		return
+	}
+	s.scrollOffset.x = 0
+	// 編集で先頭の行が短くなった場合に備えて、隠れている表示行の数を行の表示行数に収める
+	if top := buffer.GetRow(s.scrollOffset.y); top != nil {
+		s.wrapTop = min(s.wrapTop, len(breaks(top, cols))-1)
+	} else {
+		s.wrapTop = 0
+	}
+
+	seg := segmentOf(breaks(row, cols), pos.X)
+	switch d := visualDistance(buffer, s.scrollOffset.y, s.wrapTop, pos.Y, seg, cols, rows); {
+	case d < margin:
+		s.scrollOffset.y, s.wrapTop = stepBack(buffer, pos.Y, seg, margin, cols)
+	case d >= rows-margin:
+		s.scrollOffset.y, s.wrapTop = stepBack(buffer, pos.Y, seg, rows-margin-1, cols)
+	}
+}
+
+// WrappedPositionAt は折り返し表示で、フォーカスのある区画の row 行 col 桁目（0 始まり）に表示している文字の位置を返す
+// ファイルの終端以降をクリックした場合は最後の行の末尾を返す
+func (s *Screen) WrappedPositionAt(buffer *contents.Contents, row, col int) (x, y int) {
+	lines := s.visualLines(buffer, s.scrollOffset.y, 0, s.wrapTop, row+1, s.TextCols())
+	v := lines[len(lines)-1]
+	if v.line >= buffer.GetLineCount() {
+		y = max(buffer.GetLineCount()-1, 0)
+		if r := buffer.GetRow(y); r != nil {
+			x = r.GetRuneCount()
+		}
+		return x, y
+	}
+	r := buffer.GetRow(v.line)
+	x = r.ScreenPositionToOffset(v.col + max(col, 0))
+	if v.end < r.GetRuneCount() && x >= v.end {
+		// 折り返した表示行の右の余白は、次の表示行の先頭ではなくその表示行の最後の文字とする
+		x = v.end - 1
+	}
+	return min(x, v.end), v.line
+}
+
+// visualLines は rowOffset 行目から n 行分の画面の行に表示する内容を返す
+// 折り返し表示では rowOffset 行目の wrapTop 番目の表示行から始め、そうでなければ colOffset 桁目から表示する
+func (s *Screen) visualLines(buffer *contents.Contents, rowOffset, colOffset, wrapTop, n, cols int) []visualLine {
+	n = max(n, 0)
+	lines := make([]visualLine, 0, n)
+	seg := wrapTop
+	for line := rowOffset; len(lines) < n; line++ {
+		row := buffer.GetRow(line)
+		switch {
+		case line >= buffer.GetLineCount() || row == nil:
+			lines = append(lines, visualLine{line: line})
+		case !s.wrap:
+			lines = append(lines, visualLine{line: line, col: colOffset, end: row.GetRuneCount()})
+		default:
+			b := breaks(row, cols)
+			for ; seg < len(b) && len(lines) < n; seg++ {
+				lines = append(lines, visualLine{line: line, col: row.OffsetToScreenPosition(b[seg]), end: segmentEnd(row, b, seg)})
+			}
+		}
+		seg = 0
+	}
+	return lines
+}
+
+// wrappedScreenPosition は折り返し表示で、バッファ上の位置から画面上の位置を計算する
+func (s *Screen) wrappedScreenPosition(x, y int, buffer *contents.Contents, rowOffset int) (int, int) {
+	row := buffer.GetRow(y)
+	if row == nil {
+		return 0, y - rowOffset
+	}
+	cols := s.TextCols()
+	b := breaks(row, cols)
+	seg := segmentOf(b, x)
+	screenX := row.OffsetToScreenPosition(x) - row.OffsetToScreenPosition(b[seg])
+	return screenX, visualDistance(buffer, rowOffset, s.wrapTop, y, seg, cols, s.TextRows())
+}
+
+// moveVisual は折り返し表示で、(x, y) から dir（-1 は上、1 は下）の方向に1表示行移動した位置を返す
+// 移動先の表示行では、表示行の先頭からの表示幅がなるべく同じになる文字に移動する
+func (s *Screen) moveVisual(buffer *contents.Contents, x, y, dir int) (int, int) {
+	cols := s.TextCols()
+	row := buffer.GetRow(y)
+	if row == nil {
+		return x, y
+	}
+	b := breaks(row, cols)
+	seg := segmentOf(b, x)
+	visualX := row.OffsetToScreenPosition(x) - row.OffsetToScreenPosition(b[seg])
+
+	seg += dir
+	switch {
+	case seg < 0:
+		if y == 0 {
+			return x, y
+		}
+		y--
+		row = buffer.GetRow(y)
+		b = breaks(row, cols)
+		seg = len(b) - 1
+	case seg >= len(b):
+		if y >= buffer.GetLineCount()-1 {
+			return x, y
+		}
+		y++
+		row = buffer.GetRow(y)
+		b = breaks(row, cols)
+		seg = 0
+	}
+
+	end := segmentEnd(row, b, seg)
+	x = row.ScreenPositionToOffset(row.OffsetToScreenPosition(b[seg]) + visualX)
+	if end < row.GetRuneCount() && x >= end {
+		// 最後の表示行以外では、折り返し位置に置くと次の表示行の先頭に表示されるため、その手前に置く
+		x = end - 1
+	}
+	return min(x, end), y
+}
+
+// breaks は row を表示幅 cols で折り返したときの、各表示行の先頭の文字位置を返す
+func breaks(row *contents.Row, cols int) []int {
+	runes := row.GetRunes()
+	widths := make([]int, len(runes))
+	for i := range runes {
+		widths[i] = row.GetRuneWidth(i)
+	}
+	return wrap.Default().Breaks(runes, widths, cols)
+}
+
+// segmentOf は x 文字目を表示する表示行の番号を返す。行末は最後の表示行に含める
+func segmentOf(b []int, x int) int {
+	seg := 0
+	for i, start := range b {
+		if start <= x {
+			seg = i
+		}
+	}
+	return seg
+}
+
+// segmentEnd は seg 番目の表示行の終わりの文字位置を返す
+func segmentEnd(row *contents.Row, b []int, seg int) int {
+	if seg+1 < len(b) {
+		return b[seg+1]
+	}
+	return row.GetRuneCount()
+}
+
+// visualDistance は (fromLine 行目の fromSeg 番目の表示行) から (toLine 行目の toSeg 番目の表示行) までの表示行の数を返す
+// 後者が前にある場合は -1 を返す。limit を超える場合は数えるのをやめて limit を返す
+func visualDistance(buffer *contents.Contents, fromLine, fromSeg, toLine, toSeg, cols, limit int) int {
+	if toLine < fromLine || (toLine == fromLine && toSeg < fromSeg) {
+		return -1
+	}
+	d := -fromSeg
+	for line := fromLine; line < toLine; line++ {
+		if row := buffer.GetRow(line); row != nil {
+			d += len(breaks(row, cols))
+		} else {
+			d++
+		}
+		if d > limit {
+			return limit
+		}
+	}
+	return min(d+toSeg, limit)
+}
+
+// stepBack は line 行目の seg 番目の表示行から n 表示行上に戻った位置を返す。先頭より前には戻らない
+func stepBack(buffer *contents.Contents, line, seg, n, cols int) (int, int) {
+	for ; n > 0; n-- {
+		if seg > 0 {
+			seg--
+			continue
+		}
+		if line == 0 {
+			break
+		}
+		line--
+		seg = 0
+		if row := buffer.GetRow(line); row != nil {
+			seg = len(breaks(row, cols)) - 1
+		}
+	}
+	return line, seg
+}
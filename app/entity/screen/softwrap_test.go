@@ -0,0 +1,86 @@
+package screen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+)
+
+func newWrapScreen(lines []string, rows, cols int) (*Screen, *contents.Contents) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent(lines)
+	s := NewScreen(contents.NewBuilder(), &recordingWriter{}, contents.NewMessage(""), cursor.NewCursor(), rows, cols)
+	s.SetWrap(true)
+	return s, buffer
+}
+
+func TestWrap_VisualLines(t *testing.T) {
+	s, buffer := newWrapScreen([]string{"aaa bbb ccc ddd eee", "x"}, 10, 12)
+
+	// 空白の後で折り返し、2行目は次の行として続く
+	got := s.visualLines(buffer, 0, 0, 0, 4, 12)
+	want := []visualLine{{line: 0, col: 0, end: 12}, {line: 0, col: 12, end: 19}, {line: 1, col: 0, end: 1}, {line: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	row := buffer.GetRow(0)
+	if first := s.drawTextRange(row, 0, 12, 12); strings.Contains(first, "d") || strings.Contains(first, "↵") {
+		t.Errorf("the first visual line must end at the break: %q", first)
+	}
+	if second := s.drawTextRange(row, 12, 12, 19); !strings.HasPrefix(second, "ddd") || !strings.Contains(second, "↵") {
+		t.Errorf("the last visual line must show the rest and the newline mark: %q", second)
+	}
+
+	// カーソルは表示行の中の位置に表示する
+	if x, y := s.getScreenPosition(14, 0, buffer, 0, 0); x != 2 || y != 1 {
+		t.Errorf("screen position = (%d, %d), want (2, 1)", x, y)
+	}
+	if x, y := s.WrappedPositionAt(buffer, 1, 3); x != 15 || y != 0 {
+		t.Errorf("position at (1, 3) = (%d, %d), want (15, 0)", x, y)
+	}
+}
+
+func TestWrap_MoveByVisualLine(t *testing.T) {
+	s, buffer := newWrapScreen([]string{"aaa bbb ccc ddd eee", "x"}, 10, 12)
+
+	s.SetCursorPosition(14, 0)
+	s.MoveCursor(cursor.CursorUp, buffer)
+	if pos := s.GetCursor().ToPosition(); pos.X != 2 || pos.Y != 0 {
+		t.Errorf("up: got (%d, %d), want (2, 0)", pos.X, pos.Y)
+	}
+	s.MoveCursor(cursor.CursorDown, buffer)
+	if pos := s.GetCursor().ToPosition(); pos.X != 14 || pos.Y != 0 {
+		t.Errorf("down: got (%d, %d), want (14, 0)", pos.X, pos.Y)
+	}
+	s.MoveCursor(cursor.CursorDown, buffer)
+	if pos := s.GetCursor().ToPosition(); pos.X != 1 || pos.Y != 1 {
+		t.Errorf("down to the next line: got (%d, %d), want (1, 1)", pos.X, pos.Y)
+	}
+}
+
+func TestWrap_ScrollWrapped(t *testing.T) {
+	// 1行が5つの表示行になる行を2行並べ、本文が4行の画面で2行目の末尾にカーソルを置く
+	long := strings.Repeat("aaaaa ", 10)
+	s, buffer := newWrapScreen([]string{long, long}, 6, 12)
+	s.SetCursorPosition(len(long), 1)
+
+	s.ScrollWrapped(buffer, 1)
+	if _, y := s.GetOffset(); y != 1 || s.wrapTop != 2 {
+		t.Errorf("offset = %d/%d, want 1/2", y, s.wrapTop)
+	}
+	if _, y := s.getScreenPosition(len(long), 1, buffer, 1, 0); y != 2 {
+		t.Errorf("cursor must stay above the bottom margin: row %d", y)
+	}
+
+	// 上に戻ると先頭の行の途中から表示する
+	s.SetCursorPosition(0, 1)
+	s.ScrollWrapped(buffer, 1)
+	if _, y := s.GetOffset(); y != 0 || s.wrapTop != 4 {
+		t.Errorf("offset = %d/%d, want 0/4", y, s.wrapTop)
+	}
+}
@@ -0,0 +1,267 @@
+package screen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/filetype"
+)
+
+// StatusSegment はステータスバーに表示する項目の種類
+type StatusSegment int
+
+const (
+	SegmentNone       StatusSegment = iota
+	SegmentFileType                 // ファイルの種類
+	SegmentPosition                 // カーソル位置（行・列）
+	SegmentName                     // ファイル名（編集できないバッファは [RO] 付き）
+	SegmentDirty                    // 未保存の変更があることを示す [+]
+	SegmentPercent                  // カーソルのある行がファイルのどのあたりか（%）
+	SegmentEncoding                 // 文字コード
+	SegmentLineEnding               // 改行コード
+	SegmentSearch                   // 検索の一致箇所の件数とカーソル位置の一致箇所の番号（検索中のみ）
+	SegmentBranch                   // git の現在のブランチ（リポジトリ内のファイルのみ）
+)
+
+// statusSeparator は右端に並べる項目の区切り
+const statusSeparator = "  "
+
+// statusSegmentNames は設定で項目を指定する際の名前
+var statusSegmentNames = map[string]StatusSegment{
+	"name":     SegmentName,
+	"dirty":    SegmentDirty,
+	"position": SegmentPosition,
+	"percent":  SegmentPercent,
+	"filetype": SegmentFileType,
+	"encoding": SegmentEncoding,
+	"eol":      SegmentLineEnding,
+	"search":   SegmentSearch,
+	"branch":   SegmentBranch,
+}
+
+// statusPriority は幅が足りない場合に項目を残す優先度（小さいものから省く）
+var statusPriority = map[StatusSegment]int{
+	SegmentPercent:    1,
+	SegmentBranch:     2,
+	SegmentEncoding:   3,
+	SegmentLineEnding: 4,
+	SegmentFileType:   5,
+	SegmentPosition:   6,
+	SegmentSearch:     7,
+}
+
+// DefaultStatusSegments はステータスバーに表示する項目の既定の並び
+// ファイル名と [+] は左端に、それ以外は右端に並べる
+var DefaultStatusSegments = []StatusSegment{
+	SegmentName, SegmentDirty, SegmentSearch, SegmentBranch, SegmentFileType, SegmentEncoding, SegmentLineEnding, SegmentPosition, SegmentPercent,
+}
+
+// parseStatusSegments はカンマ区切りの項目名（name, dirty, search, branch, position, percent, filetype, encoding, eol）を項目の並びに変換する
+func parseStatusSegments(spec string) ([]StatusSegment, error) {
+	var segments []StatusSegment
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		seg, ok := statusSegmentNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown status segment %q", name)
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// SetStatusSegments はステータスバーに表示する項目をカンマ区切りの項目名で設定する
+// 不明な項目名を含む場合は設定を変えずにエラーを返す
+func (s *Screen) SetStatusSegments(spec string) error {
+	segments, err := parseStatusSegments(spec)
+	if err != nil {
+		return err
+	}
+	s.segments = segments
+	return nil
+}
+
+// statusSegments はステータスバーに表示する項目の並びを返す
+func (s *Screen) statusSegments() []StatusSegment {
+	if s.segments == nil {
+		return DefaultStatusSegments
+	}
+	return s.segments
+}
+
+// FileTypeOf はバッファのファイルの種類を返す。種類を設定していなければファイル名から判定する
+func FileTypeOf(buffer *contents.Contents, filename string) string {
+	if t := buffer.FileType(); t != "" {
+		return t
+	}
+	return filetype.Detect(filename)
+}
+
+// statusPart はステータスバーの右端に表示する項目1つ分の表示
+type statusPart struct {
+	segment StatusSegment
+	text    string
+	start   int // 表示を始める桁
+}
+
+// StatusSegmentAt はフォーカスのある区画のステータスバーで、左端から x 桁目に表示している項目を返す
+// width はステータスバーの幅、pos はカーソル位置
+func (s *Screen) StatusSegmentAt(buffer *contents.Contents, filename string, pos contents.Position, width, x int) StatusSegment {
+	_, parts := s.statusLayout(buffer, filename, pos, width, s.loading)
+	for _, p := range parts {
+		if x >= p.start && x < p.start+displayWidth(p.text) {
+			return p.segment
+		}
+	}
+	return SegmentNone
+}
+
+// statusLayout はステータスバーの左端の表示と、右端に表示する項目とその表示位置を返す
+// 左端の表示と1桁以上空けて収まらない場合は、優先度の低い項目から省く
+func (s *Screen) statusLayout(buffer *contents.Contents, filename string, pos contents.Position, width int, loading bool) (string, []statusPart) {
+	segments := s.statusSegments()
+	label := ""
+	if loading {
+		label = fmt.Sprintf("[loading %d%%]", s.loadPercent)
+	}
+	left := statusLeft(segments, buffer, filename, label)
+
+	var parts []statusPart
+	for _, seg := range segments {
+		if seg == SegmentSearch {
+			if s.search.Active {
+				parts = append(parts, statusPart{segment: seg, text: s.search.text()})
+			}
+			continue
+		}
+		if seg == SegmentBranch {
+			if s.branch != "" {
+				parts = append(parts, statusPart{segment: seg, text: s.branch})
+			}
+			continue
+		}
+		if text, ok := statusText(seg, buffer, filename, pos); ok {
+			parts = append(parts, statusPart{segment: seg, text: text})
+		}
+	}
+	for len(parts) > 0 && displayWidth(left)+1+partsWidth(parts) > width {
+		lowest := 0
+		for i, p := range parts {
+			if statusPriority[p.segment] < statusPriority[parts[lowest].segment] {
+				lowest = i
+			}
+		}
+		parts = append(parts[:lowest], parts[lowest+1:]...)
+	}
+
+	x := width - partsWidth(parts)
+	for i := range parts {
+		parts[i].start = x
+		x += displayWidth(parts[i].text) + len(statusSeparator)
+	}
+	return left, parts
+}
+
+// statusLeft はステータスバーの左端に表示するファイル名と状態を返す
+// loading は読み込み中の表示で、読み込み中でなければ空文字列
+func statusLeft(segments []StatusSegment, buffer *contents.Contents, filename string, loading string) string {
+	var items []string
+	for _, seg := range segments {
+		switch seg {
+		case SegmentName:
+			name := filename
+			if name == "" {
+				name = "[No Name]"
+			}
+			if buffer.ReadOnly() {
+				name += " " + readOnlyMarker
+			}
+			items = append(items, name)
+		case SegmentDirty:
+			if buffer.IsDirty() {
+				items = append(items, "[+]")
+			}
+		}
+	}
+	if loading != "" {
+		// 読み込み中は行数などが確定していないことを示す
+		items = append(items, loading)
+	}
+	return strings.Join(items, " ")
+}
+
+// statusText は右端に表示する項目の文字列を返す。左端に表示する項目は false を返す
+func statusText(seg StatusSegment, buffer *contents.Contents, filename string, pos contents.Position) (string, bool) {
+	switch seg {
+	case SegmentFileType:
+		return FileTypeOf(buffer, filename), true
+	case SegmentPosition:
+		return fmt.Sprintf("Ln %d, Col %d", pos.Y+1, pos.X+1), true
+	case SegmentPercent:
+		lines := max(buffer.GetLineCount(), 1)
+		return fmt.Sprintf("%d%%", min((pos.Y+1)*100/lines, 100)), true
+	case SegmentEncoding:
+		if buffer.Encoding() == contents.EncodingUTF8 && strings.HasPrefix(buffer.GetContentLine(0), "\ufeff") {
+			return "UTF-8 BOM", true
+		}
+		return buffer.Encoding().String(), true
+	case SegmentLineEnding:
+		return buffer.LineEnding().String(), true
+	}
+	return "", false
+}
+
+// SearchStatus はステータスバーに表示する検索の状態
+type SearchStatus struct {
+	Active bool // 検索中かどうか（false なら表示しない）
+	Index  int  // カーソル位置の一致箇所の番号（0始まり。一致箇所の上になければ -1）
+	Total  int  // 一致箇所の数
+}
+
+// text は検索の状態の表示を返す（例: match 3/17）
+func (st SearchStatus) text() string {
+	switch {
+	case st.Total == 0:
+		return noMatches
+	case st.Index >= 0:
+		return fmt.Sprintf("match %d/%d", st.Index+1, st.Total)
+	case st.Total == 1:
+		return "1 match"
+	}
+	return fmt.Sprintf("%d matches", st.Total)
+}
+
+// noMatches は検索文字列に一致する箇所がない場合の表示
+const noMatches = "no matches"
+
+// SetSearchStatus はステータスバーに表示する検索の状態を設定する
+func (s *Screen) SetSearchStatus(status SearchStatus) {
+	s.search = status
+}
+
+// SetBranch はステータスバーに表示するブランチ名を設定する。空ならブランチを表示しない
+func (s *Screen) SetBranch(name string) {
+	s.branch = name
+}
+
+// partsWidth は項目を区切りを挟んで並べたときの表示幅を返す
+func partsWidth(parts []statusPart) int {
+	w := 0
+	for i, p := range parts {
+		if i > 0 {
+			w += len(statusSeparator)
+		}
+		w += displayWidth(p.text)
+	}
+	return w
+}
+
+// displayWidth は文字列の表示幅を返す
+func displayWidth(str string) int {
+	row := contents.NewRow(str)
+	return row.OffsetToScreenPosition(row.GetRuneCount())
+}
@@ -0,0 +1,149 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestStatusLine_Segments(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	s := &Screen{}
+	pos := contents.Position{X: 4, Y: 2}
+
+	// "main.go" の右端に種類、文字コード、改行コード、位置、割合を並べる
+	line := s.statusLine(buffer, "main.go", pos, 60, false)
+	if !strings.HasPrefix(line, "main.go ") || !strings.HasSuffix(line, "Go  UTF-8  LF  Ln 3, Col 5  100%") || len(line) != 60 {
+		t.Errorf("unexpected status line: %q", line)
+	}
+	for x, want := range map[int]StatusSegment{3: SegmentNone, 28: SegmentFileType, 30: SegmentNone, 32: SegmentEncoding, 39: SegmentLineEnding, 43: SegmentPosition, 57: SegmentPercent} {
+		if got := s.StatusSegmentAt(buffer, "main.go", pos, 60, x); got != want {
+			t.Errorf("segment at %d = %v, want %v", x, got, want)
+		}
+	}
+
+	// 設定したファイルの種類を優先する
+	buffer.SetFileType("Markdown")
+	if line := s.statusLine(buffer, "main.go", pos, 60, false); !strings.Contains(line, "Markdown  UTF-8") {
+		t.Errorf("file type override: %q", line)
+	}
+	buffer.SetFileType("")
+
+	// 幅が足りない場合は優先度の低い項目から省き、最後までカーソル位置を残す
+	if line := s.statusLine(buffer, "main.go", pos, 30, false); !strings.HasSuffix(line, "main.go    Go  LF  Ln 3, Col 5") {
+		t.Errorf("narrow status line: %q", line)
+	}
+	if line := s.statusLine(buffer, "main.go", pos, 20, false); line != "main.go  Ln 3, Col 5" {
+		t.Errorf("narrower status line: %q", line)
+	}
+	if line := s.statusLine(buffer, "main.go", pos, 10, false); line != fitWidth("main.go", 10) {
+		t.Errorf("narrowest status line: %q", line)
+	}
+	if got := s.StatusSegmentAt(buffer, "main.go", pos, 10, 9); got != SegmentNone {
+		t.Errorf("segments must not be hit when hidden: %v", got)
+	}
+
+	buffer.SetReadOnly(true)
+	if line := s.statusLine(buffer, "main.go", pos, 60, false); !strings.HasPrefix(line, "main.go [RO] ") {
+		t.Errorf("read-only marker: %q", line)
+	}
+}
+
+func TestStatusLine_EncodingAndLineEnding(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"\ufeffone", "two"})
+	buffer.SetLineEnding(contents.LineEndingCRLF)
+	s := &Screen{}
+
+	if line := s.statusLine(buffer, "a.txt", contents.Position{}, 60, false); !strings.HasSuffix(line, "Text  UTF-8 BOM  CRLF  Ln 1, Col 1  50%") {
+		t.Errorf("unexpected status line: %q", line)
+	}
+
+	buffer.LoadContent([]string{"one"})
+	buffer.SetEncoding(contents.EncodingShiftJIS)
+	if line := s.statusLine(buffer, "a.txt", contents.Position{}, 60, false); !strings.Contains(line, "Text  Shift_JIS  CRLF") {
+		t.Errorf("unexpected status line: %q", line)
+	}
+}
+
+func TestStatusLine_Search(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	s := &Screen{}
+	pos := contents.Position{}
+
+	for _, tt := range []struct {
+		status SearchStatus
+		want   string
+	}{
+		{SearchStatus{Active: true, Index: 2, Total: 17}, " match 3/17  Go  UTF-8"},
+		{SearchStatus{Active: true, Index: -1, Total: 17}, " 17 matches  Go  UTF-8"},
+		{SearchStatus{Active: true, Index: -1, Total: 1}, " 1 match  Go  UTF-8"},
+		{SearchStatus{Active: true, Total: 0}, " \x1b[1mno matches\x1b[22m  Go  UTF-8"},
+		{SearchStatus{}, "main.go" + strings.Repeat(" ", 21) + "Go  UTF-8"},
+	} {
+		s.SetSearchStatus(tt.status)
+		if line := s.statusLine(buffer, "main.go", pos, 60, false); !strings.Contains(line, tt.want) {
+			t.Errorf("%+v: unexpected status line: %q", tt.status, line)
+		}
+	}
+
+	// 幅が足りない場合もカーソル位置より後まで残す
+	s.SetSearchStatus(SearchStatus{Active: true, Index: 0, Total: 3})
+	if line := s.statusLine(buffer, "main.go", pos, 20, false); line != "main.go    match 1/3" {
+		t.Errorf("narrow status line: %q", line)
+	}
+}
+
+func TestStatusLine_Branch(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	s := &Screen{}
+	s.SetBranch("main")
+	if line := s.statusLine(buffer, "main.go", contents.Position{}, 60, false); !strings.Contains(line, " main  Go  UTF-8") {
+		t.Errorf("unexpected status line: %q", line)
+	}
+
+	// 幅が足りない場合はカーソル位置より先に省く
+	if line := s.statusLine(buffer, "main.go", contents.Position{}, 30, false); strings.Contains(line, "main ") {
+		t.Errorf("the branch must be dropped first: %q", line)
+	}
+
+	s.SetBranch("")
+	if line := s.statusLine(buffer, "main.go", contents.Position{}, 60, false); strings.Contains(line, "main ") {
+		t.Errorf("an empty branch must not be shown: %q", line)
+	}
+}
+
+func TestSetStatusSegments(t *testing.T) {
+	s := &Screen{}
+	if err := s.SetStatusSegments(" Position, name,,eol "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buffer := contents.NewContents(logger.New(false))
+	if line := s.statusLine(buffer, "main.go", contents.Position{}, 30, false); line != "main.go        Ln 1, Col 1  LF" {
+		t.Errorf("unexpected status line: %q", line)
+	}
+
+	if err := s.SetStatusSegments("name,clock"); err == nil {
+		t.Error("unknown segment must be rejected")
+	}
+	if line := s.statusLine(buffer, "main.go", contents.Position{}, 30, false); !strings.HasSuffix(line, "LF") {
+		t.Errorf("segments must be kept after an error: %q", line)
+	}
+}
+
+func TestStatusLine_LoadProgress(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	s := &Screen{}
+	s.SetLoading(true)
+	s.SetLoadProgress(42)
+	if line := s.statusLine(buffer, "big.log", contents.Position{}, 60, true); !strings.HasPrefix(line, "big.log [loading 42%]") {
+		t.Errorf("unexpected status line: %q", line)
+	}
+	// 読み込みを始め直すと 0% に戻る
+	s.SetLoading(true)
+	if line := s.statusLine(buffer, "big.log", contents.Position{}, 60, true); !strings.HasPrefix(line, "big.log [loading 0%]") {
+		t.Errorf("unexpected status line: %q", line)
+	}
+}
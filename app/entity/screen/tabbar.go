@@ -0,0 +1,130 @@
+package screen
+
+const (
+	// tabMoreLeft と tabMoreRight はタブバーの端で、表示しきれないタブがあることを示す
+	tabMoreLeft  = "<"
+	tabMoreRight = ">"
+)
+
+// Tab はタブバーに表示する、開いているバッファ1つ分の表示
+type Tab struct {
+	Name  string
+	Dirty bool // 未保存の変更がある
+}
+
+// tabPart はタブバーに表示するタブ1つ分の表示と位置
+type tabPart struct {
+	index int // SetTabs で渡した並びでの位置
+	text  string
+	start int // 表示を始める桁
+}
+
+// SetTabs は画面の上端の行に表示するタブを設定する。active は選択中のタブの位置。nil ならタブバーを表示しない
+// タブバーは画面を分割して描画する場合にだけ表示するため、SetLayout で区画の上端を1行空けておく
+func (s *Screen) SetTabs(tabs []Tab, active int) {
+	s.tabs = tabs
+	s.activeTab = active
+}
+
+// TabAt はタブバーの左端から x 桁目に表示しているタブの位置を返す。タブがなければ -1
+func (s *Screen) TabAt(x int) int {
+	parts, _, _ := s.tabLayout(s.colLines)
+	for _, p := range parts {
+		if x >= p.start && x < p.start+displayWidth(p.text) {
+			return p.index
+		}
+	}
+	return -1
+}
+
+// tabLabel はタブに表示する文字列を返す
+func tabLabel(t Tab) string {
+	label := " " + t.Name
+	if t.Dirty {
+		label += " [+]"
+	}
+	return label + " "
+}
+
+// tabLayout は width 桁のタブバーに表示するタブとその位置、左右に表示しきれないタブがあるかどうかを返す
+// 選択中のタブが収まるように、必要なら先頭のタブを省いて表示を右にずらす
+func (s *Screen) tabLayout(width int) ([]tabPart, bool, bool) {
+	if len(s.tabs) == 0 || width <= 0 {
+		return nil, false, false
+	}
+	active := min(max(s.activeTab, 0), len(s.tabs)-1)
+	widths := make([]int, len(s.tabs))
+	for i, t := range s.tabs {
+		widths[i] = displayWidth(tabLabel(t))
+	}
+
+	first := 0
+	for first < active {
+		used := 0
+		if first > 0 {
+			used += len(tabMoreLeft)
+		}
+		for i := first; i <= active; i++ {
+			used += widths[i]
+		}
+		if active < len(s.tabs)-1 {
+			used += len(tabMoreRight)
+		}
+		if used <= width {
+			break
+		}
+		first++
+	}
+
+	var parts []tabPart
+	x := 0
+	if first > 0 {
+		x = len(tabMoreLeft)
+	}
+	for i := first; i < len(s.tabs); i++ {
+		limit := width
+		if i < len(s.tabs)-1 {
+			limit -= len(tabMoreRight)
+		}
+		text := tabLabel(s.tabs[i])
+		if x+widths[i] > limit {
+			if i != first {
+				return parts, first > 0, true
+			}
+			// 選択中のタブだけでも収まらない場合は切り詰める
+			text = fitWidth(text, limit-x)
+		}
+		parts = append(parts, tabPart{index: i, text: text, start: x})
+		x += widths[i]
+	}
+	return parts, first > 0, false
+}
+
+// drawTabBar は画面の上端の行にタブバーを描画する
+func (s *Screen) drawTabBar() {
+	width := s.colLines
+	parts, moreLeft, moreRight := s.tabLayout(width)
+	s.builder.Write(moveTo(0, 0) + s.style().TabBar)
+	x := 0
+	if moreLeft {
+		s.builder.Write(tabMoreLeft)
+		x += len(tabMoreLeft)
+	}
+	for _, p := range parts {
+		if p.index == s.activeTab {
+			s.builder.Write(resetColor + s.style().ActiveTab + p.text + resetColor + s.style().TabBar)
+		} else {
+			s.builder.Write(p.text)
+		}
+		x += displayWidth(p.text)
+	}
+	right := 0
+	if moreRight {
+		right = len(tabMoreRight)
+	}
+	s.builder.Write(fitWidth("", width-x-right))
+	if moreRight {
+		s.builder.Write(tabMoreRight)
+	}
+	s.builder.Write(resetColor)
+}
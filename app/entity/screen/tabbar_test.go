@@ -0,0 +1,48 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestTabLayout(t *testing.T) {
+	tabs := []Tab{{Name: "a.go"}, {Name: "b.go", Dirty: true}, {Name: "c.go"}, {Name: "d.go"}}
+	s := &Screen{colLines: 80}
+	s.SetTabs(tabs, 1)
+
+	// " a.go " は 6 桁、" b.go [+] " は 10 桁
+	parts, left, right := s.tabLayout(80)
+	if len(parts) != 4 || left || right || parts[1].start != 6 || parts[1].text != " b.go [+] " {
+		t.Errorf("all tabs must fit: %+v", parts)
+	}
+	if s.TabAt(7) != 1 || s.TabAt(0) != 0 || s.TabAt(79) != -1 {
+		t.Errorf("TabAt: %d %d %d", s.TabAt(7), s.TabAt(0), s.TabAt(79))
+	}
+
+	// 幅が足りなければ、選択中のタブが見えるように先頭のタブを省く
+	s.SetTabs(tabs, 3)
+	parts, left, right = s.tabLayout(14)
+	if !left || right || len(parts) != 2 || parts[0].index != 2 || parts[0].start != 1 {
+		t.Errorf("scrolled: %+v left=%v right=%v", parts, left, right)
+	}
+	s.SetTabs(tabs, 0)
+	parts, left, right = s.tabLayout(14)
+	if left || !right || len(parts) != 1 || parts[0].index != 0 {
+		t.Errorf("overflow on the right: %+v left=%v right=%v", parts, left, right)
+	}
+}
+
+func TestDrawTabBar(t *testing.T) {
+	s := &Screen{builder: contents.NewBuilder(), colLines: 14}
+	s.SetTabs([]Tab{{Name: "a.go"}, {Name: "b.go"}, {Name: "c.go"}}, 2)
+	s.drawTabBar()
+	got := s.builder.Build()
+	if !strings.HasPrefix(got, moveTo(0, 0)+inactiveStatusColor+tabMoreLeft) {
+		t.Errorf("hidden tabs on the left must be marked: %q", got)
+	}
+	if !strings.Contains(got, reverseVideo+" c.go "+resetColor) {
+		t.Errorf("active tab must be highlighted: %q", got)
+	}
+}
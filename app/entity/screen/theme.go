@@ -0,0 +1,229 @@
+package screen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Theme は描画に使う色と装飾のエスケープシーケンス
+type Theme struct {
+	Name           string
+	ControlChar    string // 空白記号と改行マーク
+	Match          string // 検索の一致箇所
+	CurrentMatch   string // 選択中の一致箇所
+	BracketMatch   string // カーソル位置の括弧と対応する括弧
+	TrailingSpace  string // 行末の空白
+	Selection      string // 選択範囲
+	Status         string // フォーカスのある区画のステータスバー
+	InactiveStatus string // フォーカスのない区画のステータスバー
+	TabBar         string // タブバーと選択していないタブ
+	ActiveTab      string // 選択中のタブ
+	Error          string // エラーの診断
+	Warning        string // 警告などエラー以外の診断
+	Added          string // ガターの追加した行の印
+	Modified       string // ガターの変更した行の印
+	Deleted        string // ガターの行を削除した位置の印
+	Misspelled     string // 綴りの誤り
+}
+
+// テーマ名
+const (
+	ThemeAuto         = "auto" // 端末の色数から選ぶ
+	ThemeDefault      = "default"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+	ThemeDusk         = "dusk" // RGB で指定した落ち着いた色。端末の色数に合わせて近い色に置き換える
+)
+
+// monochromeMaxColors は自動選択で白黒のテーマを使う端末の色数の上限
+const monochromeMaxColors = 8
+
+// themes は組み込みのテーマ
+var themes = map[string]Theme{
+	ThemeDefault: {
+		Name:           ThemeDefault,
+		ControlChar:    controlCharColor,
+		Match:          matchColor,
+		CurrentMatch:   currentMatch,
+		BracketMatch:   bracketMatch,
+		TrailingSpace:  trailingSpace,
+		Selection:      reverseVideo,
+		Status:         reverseVideo,
+		InactiveStatus: inactiveStatusColor,
+		TabBar:         inactiveStatusColor,
+		ActiveTab:      reverseVideo,
+		Error:          errorUnderline,
+		Warning:        warningUnderline,
+		Added:          addedSign,
+		Modified:       modifiedSign,
+		Deleted:        deletedSign,
+		Misspelled:     misspelled,
+	},
+	// 背景と文字の明暗の差を大きくし、薄い表示を使わない
+	ThemeHighContrast: {
+		Name:           ThemeHighContrast,
+		ControlChar:    "\x1b[37m",
+		Match:          "\x1b[1;30;103m",
+		CurrentMatch:   "\x1b[1;30;106m",
+		BracketMatch:   "\x1b[1;30;105m",
+		TrailingSpace:  "\x1b[1;37;101m",
+		Selection:      "\x1b[1;7m",
+		Status:         "\x1b[1;30;107m",
+		InactiveStatus: reverseVideo,
+		TabBar:         reverseVideo,
+		ActiveTab:      "\x1b[1;30;107m",
+		Error:          "\x1b[1;4;91m",
+		Warning:        "\x1b[1;4;93m",
+		Added:          "\x1b[1;92m",
+		Modified:       "\x1b[1;94m",
+		Deleted:        "\x1b[1;91m",
+		Misspelled:     "\x1b[1;4;95m",
+	},
+	// 色を使わず太字・下線・反転表示だけで示す
+	ThemeMonochrome: {
+		Name:           ThemeMonochrome,
+		ControlChar:    "",
+		Match:          "\x1b[4m",
+		CurrentMatch:   "\x1b[1;4m",
+		BracketMatch:   "\x1b[1m",
+		TrailingSpace:  "\x1b[4m",
+		Selection:      reverseVideo,
+		Status:         reverseVideo,
+		InactiveStatus: "\x1b[4m",
+		TabBar:         "\x1b[4m",
+		ActiveTab:      reverseVideo,
+		Error:          "\x1b[1;4m",
+		Warning:        "\x1b[4m",
+		Added:          "\x1b[1m",
+		Modified:       "\x1b[1m",
+		Deleted:        "\x1b[1m",
+		Misspelled:     "\x1b[4m",
+	},
+}
+
+// ThemeStyles は RGB の色で指定したテーマ。描画する端末の色数に合わせてエスケープシーケンスにする
+type ThemeStyles struct {
+	ControlChar    Style
+	Match          Style
+	CurrentMatch   Style
+	BracketMatch   Style
+	TrailingSpace  Style
+	Selection      Style
+	Status         Style
+	InactiveStatus Style
+	TabBar         Style
+	ActiveTab      Style
+	Error          Style
+	Warning        Style
+	Added          Style
+	Modified       Style
+	Deleted        Style
+	Misspelled     Style
+}
+
+// styledThemes は RGB の色で指定した組み込みのテーマ
+var styledThemes = map[string]ThemeStyles{
+	ThemeDusk: {
+		ControlChar:    Style{Fg: RGB(0x5c6370)},
+		Match:          Style{Fg: RGB(0x1e2127), Bg: RGB(0xe5c07b)},
+		CurrentMatch:   Style{Fg: RGB(0x1e2127), Bg: RGB(0x56b6c2)},
+		BracketMatch:   Style{Bold: true, Bg: RGB(0x6b4f8a)},
+		TrailingSpace:  Style{Bg: RGB(0x7a3434)},
+		Selection:      Style{Fg: RGB(0xe6e6e6), Bg: RGB(0x3e5a80)},
+		Status:         Style{Fg: RGB(0x1e2127), Bg: RGB(0xabb2bf)},
+		InactiveStatus: Style{Fg: RGB(0xabb2bf), Bg: RGB(0x3b4048)},
+		TabBar:         Style{Fg: RGB(0xabb2bf), Bg: RGB(0x3b4048)},
+		ActiveTab:      Style{Bold: true, Fg: RGB(0x1e2127), Bg: RGB(0xabb2bf)},
+		Error:          Style{Underline: true, Fg: RGB(0xe06c75)},
+		Warning:        Style{Underline: true, Fg: RGB(0xe5c07b)},
+		Added:          Style{Fg: RGB(0x98c379)},
+		Modified:       Style{Fg: RGB(0x61afef)},
+		Deleted:        Style{Fg: RGB(0xe06c75)},
+		Misspelled:     Style{Underline: true, Fg: RGB(0xc678dd)},
+	},
+}
+
+// Theme は depth の端末で描画するテーマを返す
+// 色を使わない端末では選択範囲などが見えなくなるため、白黒のテーマを使う
+func (t ThemeStyles) Theme(name string, depth ColorDepth) Theme {
+	if depth == DepthNone {
+		theme := themes[ThemeMonochrome]
+		theme.Name = name
+		return theme
+	}
+	return Theme{
+		Name:           name,
+		ControlChar:    t.ControlChar.Sequence(depth),
+		Match:          t.Match.Sequence(depth),
+		CurrentMatch:   t.CurrentMatch.Sequence(depth),
+		BracketMatch:   t.BracketMatch.Sequence(depth),
+		TrailingSpace:  t.TrailingSpace.Sequence(depth),
+		Selection:      t.Selection.Sequence(depth),
+		Status:         t.Status.Sequence(depth),
+		InactiveStatus: t.InactiveStatus.Sequence(depth),
+		TabBar:         t.TabBar.Sequence(depth),
+		ActiveTab:      t.ActiveTab.Sequence(depth),
+		Error:          t.Error.Sequence(depth),
+		Warning:        t.Warning.Sequence(depth),
+		Added:          t.Added.Sequence(depth),
+		Modified:       t.Modified.Sequence(depth),
+		Deleted:        t.Deleted.Sequence(depth),
+		Misspelled:     t.Misspelled.Sequence(depth),
+	}
+}
+
+// ThemeNames は設定で指定できるテーマ名を返す
+func ThemeNames() []string {
+	names := []string{ThemeAuto}
+	for name := range themes {
+		names = append(names, name)
+	}
+	for name := range styledThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// selectTheme は name のテーマを返す。auto の場合は端末の色数 colors が8色以下なら白黒、それ以外は既定のテーマを選ぶ
+// RGB で指定したテーマは colors で表せる最も近い色で描画する
+func selectTheme(name string, colors int) (Theme, error) {
+	if name == ThemeAuto || name == "" {
+		if colors <= monochromeMaxColors {
+			return themes[ThemeMonochrome], nil
+		}
+		return themes[ThemeDefault], nil
+	}
+	if styles, ok := styledThemes[name]; ok {
+		return styles.Theme(name, DepthFor(colors)), nil
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q", name)
+	}
+	return theme, nil
+}
+
+// SetTheme は描画に使うテーマを名前で設定する。colors は auto の場合に使う端末の色数
+// 不明なテーマ名の場合は設定を変えずにエラーを返す
+func (s *Screen) SetTheme(name string, colors int) error {
+	theme, err := selectTheme(name, colors)
+	if err != nil {
+		return err
+	}
+	s.theme = theme
+	return nil
+}
+
+// Theme は描画に使っているテーマを返す
+func (s *Screen) Theme() Theme {
+	return s.style()
+}
+
+// style は描画に使うテーマを返す。設定していなければ既定のテーマを使う
+func (s *Screen) style() Theme {
+	if s.theme.Name == "" {
+		return themes[ThemeDefault]
+	}
+	return s.theme
+}
@@ -0,0 +1,52 @@
+package screen
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestSetTheme_Auto(t *testing.T) {
+	s := &Screen{colLines: 8}
+	if err := s.SetTheme(ThemeAuto, 256); err != nil || s.Theme().Name != ThemeDefault {
+		t.Errorf("256 colors: %q, %v", s.Theme().Name, err)
+	}
+	// 8色以下の端末では白黒のテーマを選ぶ
+	if err := s.SetTheme(ThemeAuto, 8); err != nil || s.Theme().Name != ThemeMonochrome {
+		t.Errorf("8 colors: %q, %v", s.Theme().Name, err)
+	}
+	if err := s.SetTheme("solarized", 256); err == nil || s.Theme().Name != ThemeMonochrome {
+		t.Errorf("unknown theme must be rejected without changing the theme: %q, %v", s.Theme().Name, err)
+	}
+	if got, want := ThemeNames(), []string{ThemeAuto, ThemeDefault, ThemeDusk, ThemeHighContrast, ThemeMonochrome}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ThemeNames() = %q", got)
+	}
+}
+
+func TestSetTheme_Monochrome(t *testing.T) {
+	s := &Screen{colLines: 8}
+	s.SetTheme(ThemeMonochrome, 256)
+	got := s.drawTextRow(contents.NewRow("ab a"), 0, 8,
+		Highlight{Line: 0, Col: 0, Length: 1, Current: true},
+		Highlight{Line: 0, Col: 2, Length: 2})
+	want := "\x1b[1;4ma" + resetColor + "b" +
+		"\x1b[4m " + resetColor + "\x1b[4ma" + resetColor +
+		"↵" + resetColor + "   "
+	if got != want {
+		t.Errorf("drawTextRow() = %q, want %q", got, want)
+	}
+
+	// 太字・下線・反転表示とその解除だけを使う
+	allowed := map[string]bool{"0": true, "1": true, "4": true, "7": true}
+	mono := themes[ThemeMonochrome]
+	for _, m := range regexp.MustCompile(`\x1b\[([0-9;]*)m`).FindAllStringSubmatch(got+mono.Status+mono.InactiveStatus+mono.Selection, -1) {
+		for _, p := range strings.Split(m[1], ";") {
+			if !allowed[p] {
+				t.Errorf("monochrome theme must not use %q", m[0])
+			}
+		}
+	}
+}
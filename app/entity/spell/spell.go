@@ -0,0 +1,178 @@
+package spell
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Word は行の中で綴りを確認する単語
+type Word struct {
+	Col  int    // 0始まりのルーン単位の列位置
+	Text string // 単語
+}
+
+// isLetter は綴りを確認する単語を構成する文字（ASCII の英字）かどうかを返す
+func isLetter(r rune) bool {
+	return r < unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+// Words は行から綴りを確認する単語を取り出す
+// ASCII の英字の並び（間のアポストロフィを含む）を1語とする。URL・パス・ファイル名・識別子と思われる、
+// 数字や / _ @ などを含む語や途中に . のある語と、1文字の語、すべて大文字の語（略語）は確認しない
+func Words(line string) []Word {
+	var words []Word
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		// 空白で区切った語ごとに確認するかどうかを決める
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		if !skipToken(runes[i:end]) {
+			words = append(words, tokenWords(runes, i, end)...)
+		}
+		i = end
+	}
+	return words
+}
+
+// tokenWords は runes[start:end] の語から英字の並びを取り出す
+func tokenWords(runes []rune, start, end int) []Word {
+	var words []Word
+	for i := start; i < end; {
+		if !isLetter(runes[i]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < end && (isLetter(runes[j]) || runes[j] == '\'' && j+1 < end && isLetter(runes[j+1])) {
+			j++
+		}
+		if check(runes, i, j) {
+			words = append(words, Word{Col: i, Text: string(runes[i:j])})
+		}
+		i = j
+	}
+	return words
+}
+
+// skipToken は空白で区切った語が URL・パス・識別子などで、綴りを確認しないかどうかを返す
+func skipToken(token []rune) bool {
+	for i, r := range token {
+		if unicode.IsDigit(r) || strings.ContainsRune("/\\@_#$%&=~<>{}|", r) {
+			return true
+		}
+		if r == '.' && i+1 < len(token) && unicode.IsLetter(token[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// check は runes[start:end] の語の綴りを確認するかどうかを返す
+// 英字以外の文字（日本語など）と続けて書いた語は確認しない
+func check(runes []rune, start, end int) bool {
+	if end-start < 2 {
+		return false
+	}
+	if start > 0 && unicode.IsLetter(runes[start-1]) || end < len(runes) && unicode.IsLetter(runes[end]) {
+		return false
+	}
+	word := string(runes[start:end])
+	return strings.ToUpper(word) != word
+}
+
+// At は line の x 列目を含む（または直前で終わる）確認対象の単語を返す
+func At(line string, x int) (Word, bool) {
+	for _, w := range Words(line) {
+		if x >= w.Col && x <= w.Col+len([]rune(w.Text)) {
+			return w, true
+		}
+	}
+	return Word{}, false
+}
+
+// maxDistance は候補にする単語の編集距離の上限
+const maxDistance = 2
+
+// Suggest は words の中から word に近い綴りの単語を、編集距離の小さい順に最大 n 件返す
+// 大文字・小文字は区別せずに比べ、word が大文字で始まる場合は候補も大文字で始める
+func Suggest(words []string, word string, n int) []string {
+	type candidate struct {
+		text     string
+		distance int
+	}
+	target := []rune(strings.ToLower(word))
+	seen := make(map[string]bool)
+	var candidates []candidate
+	for _, w := range words {
+		lower := strings.ToLower(w)
+		if seen[lower] || lower == string(target) {
+			continue
+		}
+		runes := []rune(lower)
+		if abs(len(runes)-len(target)) > maxDistance {
+			continue
+		}
+		if d := distance(target, runes); d <= maxDistance {
+			seen[lower] = true
+			candidates = append(candidates, candidate{text: w, distance: d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	capital := unicode.IsUpper([]rune(word)[0])
+	var result []string
+	for _, c := range candidates {
+		if len(result) == n {
+			break
+		}
+		text := c.text
+		if capital {
+			r := []rune(text)
+			r[0] = unicode.ToUpper(r[0])
+			text = string(r)
+		}
+		result = append(result, text)
+	}
+	return result
+}
+
+// distance は隣り合う文字の入れ替えを1回の編集とみなす a と b の編集距離を返す
+func distance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
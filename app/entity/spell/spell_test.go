@@ -0,0 +1,48 @@
+package spell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	got := Words("Don't chek NASA, foo_bar v2 a http://exmple.com main.go 日本語 word 日本語text (well-knwn)")
+	want := []Word{
+		{Col: 0, Text: "Don't"},
+		{Col: 6, Text: "chek"},
+		{Col: 60, Text: "word"},
+		{Col: 74, Text: "well"},
+		{Col: 79, Text: "knwn"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAt(t *testing.T) {
+	if w, ok := At("a speling error", 6); !ok || w.Text != "speling" || w.Col != 2 {
+		t.Errorf("At() = %+v, %v", w, ok)
+	}
+	// 単語の直後も対象とする
+	if w, ok := At("speling", 7); !ok || w.Text != "speling" {
+		t.Errorf("At() = %+v, %v", w, ok)
+	}
+	if _, ok := At("a  b", 2); ok {
+		t.Error("no word at a space")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	words := []string{"spelling", "spewing", "selling", "swelling", "speaking", "spell"}
+	got := Suggest(words, "speling", 3)
+	if len(got) == 0 || got[0] != "spelling" {
+		t.Errorf("Suggest() = %v", got)
+	}
+	// 隣り合う文字の入れ替えは1回の編集
+	if got := Suggest([]string{"the", "then"}, "teh", 5); !reflect.DeepEqual(got, []string{"the", "then"}) {
+		t.Errorf("Suggest() = %v", got)
+	}
+	if got := Suggest([]string{"word"}, "Wrod", 5); !reflect.DeepEqual(got, []string{"Word"}) {
+		t.Errorf("capitalized words must get capitalized suggestions: %v", got)
+	}
+}
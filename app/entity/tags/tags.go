@@ -0,0 +1,217 @@
+package tags
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tag は ctags 形式のタグファイルの1エントリを表す
+type Tag struct {
+	Name        string
+	File        string // タグファイルの位置を基準に解決済みのパス
+	Line        int    // 1始まりの行番号（0の場合は Pattern で検索する）
+	Pattern     string // 検索パターン（^と$のアンカーを除いたもの）
+	Kind        string
+	anchorStart bool
+	anchorEnd   bool
+}
+
+// Index はタグ名からタグを引くための索引
+type Index struct {
+	byName map[string][]Tag
+	names  []string
+}
+
+// Parse は ctags 形式のタグファイルを読み込む
+// baseDir はタグファイル内の相対パスを解決する基準ディレクトリ
+func Parse(r io.Reader, baseDir string) (*Index, error) {
+	idx := &Index{byName: make(map[string][]Tag)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "!_TAG_") {
+			continue
+		}
+		tag, ok := parseLine(line, baseDir)
+		if !ok {
+			continue
+		}
+		if _, exists := idx.byName[tag.Name]; !exists {
+			idx.names = append(idx.names, tag.Name)
+		}
+		idx.byName[tag.Name] = append(idx.byName[tag.Name], tag)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(idx.names)
+	return idx, nil
+}
+
+// ParseGlobal は GNU GLOBAL の `global -x` の出力を読み込む
+// 各行は「名前 行番号 パス 行の内容」の形式で、パスは baseDir（global を実行したディレクトリ）を基準に解決する
+func ParseGlobal(r io.Reader, baseDir string) (*Index, error) {
+	idx := &Index{byName: make(map[string][]Tag)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n <= 0 {
+			continue
+		}
+		tag := Tag{Name: fields[0], File: fields[2], Line: n}
+		if !filepath.IsAbs(tag.File) && baseDir != "" {
+			tag.File = filepath.Join(baseDir, tag.File)
+		}
+		if _, exists := idx.byName[tag.Name]; !exists {
+			idx.names = append(idx.names, tag.Name)
+		}
+		idx.byName[tag.Name] = append(idx.byName[tag.Name], tag)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(idx.names)
+	return idx, nil
+}
+
+// Lookup は名前に一致するタグを返す
+func (idx *Index) Lookup(name string) []Tag {
+	if idx == nil {
+		return nil
+	}
+	return idx.byName[name]
+}
+
+// Len は登録されているタグ名の数を返す
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.names)
+}
+
+// Complete は prefix で始まるタグ名を辞書順に最大 limit 件返す
+func (idx *Index) Complete(prefix string, limit int) []string {
+	if idx == nil || prefix == "" {
+		return nil
+	}
+	start := sort.SearchStrings(idx.names, prefix)
+	var result []string
+	for i := start; i < len(idx.names) && strings.HasPrefix(idx.names[i], prefix); i++ {
+		if idx.names[i] == prefix {
+			continue
+		}
+		result = append(result, idx.names[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// Resolve はタグの定義位置を lines の中から探し、0始まりの行番号を返す
+func (t Tag) Resolve(lines []string) (int, bool) {
+	if t.Line > 0 {
+		if t.Line > len(lines) {
+			return len(lines) - 1, len(lines) > 0
+		}
+		return t.Line - 1, true
+	}
+	if t.Pattern == "" {
+		return 0, false
+	}
+	for i, line := range lines {
+		switch {
+		case t.anchorStart && t.anchorEnd:
+			if line == t.Pattern {
+				return i, true
+			}
+		case t.anchorStart:
+			if strings.HasPrefix(line, t.Pattern) {
+				return i, true
+			}
+		default:
+			if strings.Contains(line, t.Pattern) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseLine は "name<TAB>file<TAB>address;\"<TAB>fields" 形式の1行を解析する
+func parseLine(line, baseDir string) (Tag, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) < 3 {
+		return Tag{}, false
+	}
+	tag := Tag{Name: parts[0], File: parts[1]}
+	if !filepath.IsAbs(tag.File) && baseDir != "" {
+		tag.File = filepath.Join(baseDir, tag.File)
+	}
+
+	address := parts[2]
+	fields := ""
+	if i := strings.Index(address, ";\""); i >= 0 {
+		fields = address[i+2:]
+		address = address[:i]
+	}
+
+	switch {
+	case address == "":
+		return Tag{}, false
+	case address[0] == '/' || address[0] == '?':
+		parsePattern(&tag, address)
+	default:
+		n, err := strconv.Atoi(strings.TrimSpace(address))
+		if err != nil {
+			return Tag{}, false
+		}
+		tag.Line = n
+	}
+
+	for _, f := range strings.Split(strings.TrimSpace(fields), "\t") {
+		if f == "" {
+			continue
+		}
+		if strings.HasPrefix(f, "line:") {
+			if n, err := strconv.Atoi(f[len("line:"):]); err == nil {
+				tag.Line = n
+			}
+		} else if strings.HasPrefix(f, "kind:") {
+			tag.Kind = f[len("kind:"):]
+		} else if !strings.Contains(f, ":") {
+			tag.Kind = f
+		}
+	}
+	return tag, true
+}
+
+// parsePattern は /^pattern$/ 形式の検索アドレスを解析する
+func parsePattern(tag *Tag, address string) {
+	delim := address[0]
+	body := address[1:]
+	if strings.HasSuffix(body, string(delim)) {
+		body = body[:len(body)-1]
+	}
+	if strings.HasPrefix(body, "^") {
+		tag.anchorStart = true
+		body = body[1:]
+	}
+	if strings.HasSuffix(body, "$") && !strings.HasSuffix(body, "\\$") {
+		tag.anchorEnd = true
+		body = body[:len(body)-1]
+	}
+	replacer := strings.NewReplacer("\\"+string(delim), string(delim), "\\\\", "\\", "\\$", "$")
+	tag.Pattern = replacer.Replace(body)
+}
@@ -0,0 +1,71 @@
+package tags
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = "!_TAG_FILE_FORMAT\t2\t/extended format/\n" +
+	"NewEditor\teditor.go\t/^func NewEditor() (*editor.Editor, error) {$/;\"\tf\n" +
+	"NewRow\tapp/entity/contents/row.go\t14;\"\tf\n" +
+	"Row\tapp/entity/contents/row.go\t/^type Row struct {$/;\"\tkind:t\tline:5\n" +
+	"die\tmain.go\t/^func die(err error) {$/;\"\tf\n"
+
+func TestParseAndLookup(t *testing.T) {
+	idx, err := Parse(strings.NewReader(sample), "/proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if idx.Len() != 4 {
+		t.Fatalf("expected 4 tags, got %d", idx.Len())
+	}
+
+	tag := idx.Lookup("NewRow")[0]
+	if tag.File != filepath.Join("/proj", "app/entity/contents/row.go") || tag.Line != 14 || tag.Kind != "f" {
+		t.Errorf("unexpected tag: %+v", tag)
+	}
+	if row := idx.Lookup("Row")[0]; row.Line != 5 || row.Kind != "t" {
+		t.Errorf("unexpected extended fields: %+v", row)
+	}
+}
+
+func TestParseGlobal(t *testing.T) {
+	out := "helper              3 app/helper.go    func helper() {}\n" +
+		"helper             12 cmd/main.go      func helper() {\n" +
+		"garbage\n"
+	idx, err := ParseGlobal(strings.NewReader(out), "/proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := idx.Lookup("helper")
+	if len(found) != 2 {
+		t.Fatalf("expected 2 definitions, got %+v", found)
+	}
+	if found[0].File != filepath.Join("/proj", "app/helper.go") || found[0].Line != 3 || found[1].Line != 12 {
+		t.Errorf("unexpected tags: %+v", found)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	idx, _ := Parse(strings.NewReader(sample), "")
+	got := idx.Complete("New", 0)
+	if len(got) != 2 || got[0] != "NewEditor" || got[1] != "NewRow" {
+		t.Errorf("unexpected completion: %v", got)
+	}
+	if got := idx.Complete("Row", 0); len(got) != 0 {
+		t.Errorf("exact match should not be offered: %v", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	idx, _ := Parse(strings.NewReader(sample), "")
+	lines := []string{"package main", "", "func die(err error) {", "}"}
+	line, ok := idx.Lookup("die")[0].Resolve(lines)
+	if !ok || line != 2 {
+		t.Errorf("got line %d (ok=%v), want 2", line, ok)
+	}
+	if _, ok := idx.Lookup("NewEditor")[0].Resolve(lines); ok {
+		t.Errorf("pattern should not resolve in unrelated file")
+	}
+}
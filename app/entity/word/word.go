@@ -0,0 +1,67 @@
+package word
+
+import "unicode"
+
+// IsWordRune は識別子を構成する文字かどうかを返す
+func IsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// PrefixStart は位置 x の直前にある単語の開始位置を返す
+// 直前が単語でない場合は x をそのまま返す
+func PrefixStart(runes []rune, x int) int {
+	if x > len(runes) {
+		x = len(runes)
+	}
+	start := x
+	for start > 0 && IsWordRune(runes[start-1]) {
+		start--
+	}
+	return start
+}
+
+// At は位置 x を含む（または直前で終わる）単語の範囲を返す
+// 単語が見つからない場合は ok=false を返す
+func At(runes []rune, x int) (start, end int, ok bool) {
+	if x > len(runes) {
+		x = len(runes)
+	}
+	if x < 0 {
+		x = 0
+	}
+	if x == len(runes) || !IsWordRune(runes[x]) {
+		// カーソルが単語の直後にある場合も対象とする
+		if x == 0 || !IsWordRune(runes[x-1]) {
+			return x, x, false
+		}
+		x--
+	}
+	start = x
+	for start > 0 && IsWordRune(runes[start-1]) {
+		start--
+	}
+	end = x
+	for end < len(runes) && IsWordRune(runes[end]) {
+		end++
+	}
+	return start, end, true
+}
+
+// Split は行を単語の列に分割する
+func Split(line string) []string {
+	var words []string
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		if !IsWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && IsWordRune(runes[j]) {
+			j++
+		}
+		words = append(words, string(runes[i:j]))
+		i = j
+	}
+	return words
+}
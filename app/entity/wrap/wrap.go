@@ -0,0 +1,112 @@
+package wrap
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Strategy は長い行を表示幅に収まるように折り返す位置を決める
+type Strategy interface {
+	// Breaks は各表示行の先頭となるルーン位置を返す（最初の要素は常に0）
+	// widths は runes の各文字の表示幅、width は1行の表示幅
+	Breaks(runes []rune, widths []int, width int) []int
+}
+
+// Default は既定の折り返し方法を返す
+func Default() Strategy {
+	return UAX14Lite{}
+}
+
+// CharWrap は文字の種類を考慮せず、表示幅いっぱいで折り返す
+type CharWrap struct{}
+
+// Breaks は表示幅を超える文字の直前で折り返す
+func (CharWrap) Breaks(runes []rune, widths []int, width int) []int {
+	return breaks(runes, widths, width, func([]rune, int) bool { return false })
+}
+
+// UAX14Lite は Unicode の行分割規則（UAX #14）の主要な部分を簡略化して実装した折り返し方法
+//   - 空白の後で折り返す
+//   - 空白のない日本語・中国語などでは文字の間で折り返す
+//   - 句読点や閉じ括弧（。、」など）で行を始めない、開き括弧（「など）で行を終えない（禁則処理）
+//
+// 折り返せる位置がない場合は表示幅いっぱいで折り返す
+type UAX14Lite struct{}
+
+// Breaks は折り返し位置を返す
+func (UAX14Lite) Breaks(runes []rune, widths []int, width int) []int {
+	return breaks(runes, widths, width, canBreakBefore)
+}
+
+// breaks は貪欲法で折り返し位置を決める。canBreak(runes, i) が true の位置を優先して折り返す
+// 行末の \r（CRLF の改行コード）は表示されないので、折り返しの対象に含めない
+func breaks(runes []rune, widths []int, width int, canBreak func([]rune, int) bool) []int {
+	result := []int{0}
+	n := len(runes)
+	if n > 0 && runes[n-1] == '\r' {
+		n--
+	}
+	if width <= 0 {
+		return result
+	}
+
+	start, used, candidate := 0, 0, -1
+	for i := 0; i < n; i++ {
+		if i > start && canBreak(runes, i) {
+			candidate = i
+		}
+		for used+widths[i] > width && i > start {
+			cut := i
+			if candidate > start {
+				cut = candidate
+			}
+			result = append(result, cut)
+			start = cut
+			used = sum(widths[cut:i])
+			candidate = -1
+			for j := cut + 1; j <= i; j++ {
+				if canBreak(runes, j) {
+					candidate = j
+				}
+			}
+		}
+		used += widths[i]
+	}
+	return result
+}
+
+func sum(widths []int) int {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
+
+// 行頭禁則文字（行の先頭に置かない文字）
+const noStart = "、。，．・：；？！ー…‥」』）】〉》〕］｝〟’”ぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮヵヶ々ゝゞヽヾ)]},.;:!?%"
+
+// 行末禁則文字（行の末尾に置かない文字）
+const noEnd = "「『（【〈《〔［｛〝‘“([{"
+
+// canBreakBefore は runes[i] の直前で折り返せるかどうかを返す
+func canBreakBefore(runes []rune, i int) bool {
+	prev, cur := runes[i-1], runes[i]
+	if strings.ContainsRune(noStart, cur) || strings.ContainsRune(noEnd, prev) {
+		return false
+	}
+	if unicode.IsSpace(cur) {
+		return false
+	}
+	if unicode.IsSpace(prev) {
+		return true
+	}
+	return isCJK(prev) || isCJK(cur)
+}
+
+// isCJK は単語の区切りに空白を使わない文字かどうかを返す
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(0x3000 <= r && r <= 0x303f) || // CJK の記号と句読点
+		(0xff00 <= r && r <= 0xffef) // 全角英数と半角カナ
+}
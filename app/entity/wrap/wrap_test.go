@@ -0,0 +1,61 @@
+package wrap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// lines は折り返し結果を表示行の文字列に変換する
+func lines(s Strategy, text string, width int) []string {
+	row := contents.NewRow(text)
+	runes := row.GetRunes()
+	widths := make([]int, len(runes))
+	for i := range runes {
+		widths[i] = row.GetRuneWidth(i)
+	}
+	breaks := s.Breaks(runes, widths, width)
+	var out []string
+	for i, start := range breaks {
+		end := len(runes)
+		if i+1 < len(breaks) {
+			end = breaks[i+1]
+		}
+		out = append(out, string(runes[start:end]))
+	}
+	return out
+}
+
+func TestUAX14Lite(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"空白で折り返す", "hello big world", 10, []string{"hello big ", "world"}},
+		{"日本語は文字の間で折り返す", "あいうえおかき", 6, []string{"あいう", "えおか", "き"}},
+		{"句読点で行を始めない", "あいう。えお", 6, []string{"あい", "う。え", "お"}},
+		{"開き括弧で行を終えない", "あい「うえ」", 6, []string{"あい", "「う", "え」"}},
+		{"折り返せる位置がなければ幅いっぱいで切る", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"英単語と日本語の境界", "Goは速い", 3, []string{"Go", "は", "速", "い"}},
+		{"行末の CR は数えない", "abcd\r", 4, []string{"abcd\r"}},
+		{"収まる行は折り返さない", "abc", 10, []string{"abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lines(Default(), tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharWrap(t *testing.T) {
+	got := lines(CharWrap{}, "hello world", 4)
+	want := []string{"hell", "o wo", "rld"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
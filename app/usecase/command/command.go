@@ -0,0 +1,64 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownCommand は登録されていないコマンドを実行しようとした場合のエラー
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Command は名前で呼び出せるエディタの操作
+type Command struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+	// Preview は実行した場合の変更をバッファを変更せずに返す。nil ならプレビューに対応しない
+	Preview func(args []string) (Preview, error)
+	// Complete は入力途中の最後の引数 arg を補完する候補を返す。nil なら引数を補完しない
+	Complete func(arg string) []string
+}
+
+// Registry はコマンド名と処理の対応を管理する
+type Registry struct {
+	commands map[string]Command
+	order    []string
+	aliases  map[string]alias
+}
+
+// NewRegistry は空の Registry を作成する
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]Command), aliases: make(map[string]alias)}
+}
+
+// Register はコマンドを登録する。同じ名前のコマンドがある場合は置き換える
+func (r *Registry) Register(cmd Command) {
+	if _, ok := r.commands[cmd.Name]; !ok {
+		r.order = append(r.order, cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+}
+
+// Lookup は名前からコマンドを探す
+func (r *Registry) Lookup(name string) (Command, bool) {
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Execute は名前を指定してコマンドを実行する。名前には別名も指定できる
+func (r *Registry) Execute(name string, args []string) error {
+	cmd, args, ok := r.resolve(name, args)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, name)
+	}
+	return cmd.Run(args)
+}
+
+// Commands は登録されているコマンドを登録順に返す
+func (r *Registry) Commands() []Command {
+	cmds := make([]Command, 0, len(r.order))
+	for _, name := range r.order {
+		cmds = append(cmds, r.commands[name])
+	}
+	return cmds
+}
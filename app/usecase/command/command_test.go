@@ -0,0 +1,29 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	var got []string
+	r.Register(Command{Name: "save", Run: func(args []string) error { got = args; return nil }})
+	r.Register(Command{Name: "quit", Run: func([]string) error { return nil }})
+	r.Register(Command{Name: "save", Description: "replaced", Run: func(args []string) error { got = args; return nil }})
+
+	if err := r.Execute("save", []string{"a.txt"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("args must be passed to the command: %v", got)
+	}
+	if err := r.Execute("missing", nil); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected ErrUnknownCommand, got %v", err)
+	}
+
+	cmds := r.Commands()
+	if len(cmds) != 2 || cmds[0].Name != "save" || cmds[0].Description != "replaced" || cmds[1].Name != "quit" {
+		t.Errorf("unexpected commands: %+v", cmds)
+	}
+}
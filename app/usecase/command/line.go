@@ -0,0 +1,145 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnterminatedQuote は閉じていない引用符を含むコマンド行のエラー
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// alias はコマンドの別名。呼び出すと name のコマンドを args を前に付けて実行する
+type alias struct {
+	name string
+	args []string
+}
+
+// Alias は name のコマンドを別の名前 short で呼び出せるようにする
+// args を指定すると、呼び出し時の引数の前に付けて渡す
+func (r *Registry) Alias(short, name string, args ...string) {
+	r.aliases[short] = alias{name: name, args: args}
+}
+
+// resolve は名前（別名を含む）からコマンドと渡す引数を求める
+func (r *Registry) resolve(name string, args []string) (Command, []string, bool) {
+	if cmd, ok := r.commands[name]; ok {
+		return cmd, args, true
+	}
+	a, ok := r.aliases[name]
+	if !ok {
+		return Command{}, nil, false
+	}
+	cmd, ok := r.commands[a.name]
+	if !ok {
+		return Command{}, nil, false
+	}
+	return cmd, append(append([]string(nil), a.args...), args...), true
+}
+
+// ParseLine はコマンド行を空白で区切り、コマンド名と引数に分ける
+// 空白を含む引数は "..." で囲み、\ で続く1文字をそのまま含められる。空の行は空の名前を返す
+func ParseLine(line string) (name string, args []string, err error) {
+	var fields []string
+	var field strings.Builder
+	inField, quoted, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			inField, escaped = true, true
+		case r == '"':
+			inField, quoted = true, !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quoted || escaped {
+		return "", nil, ErrUnterminatedQuote
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+	return fields[0], fields[1:], nil
+}
+
+// RunLine はコマンド行を解釈して実行する
+func (r *Registry) RunLine(line string) error {
+	name, args, err := ParseLine(line)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, line)
+	}
+	return r.Execute(name, args)
+}
+
+// Complete は入力途中のコマンド行 line を補完した候補を、行全体の形で返す
+// 最初の語はコマンド名と別名から、以降はコマンドの Complete が返す候補から最後の語を補う
+func (r *Registry) Complete(line string) []string {
+	line = strings.TrimLeft(line, " \t")
+	i := strings.LastIndexAny(line, " \t")
+	if i < 0 {
+		var names []string
+		for name := range r.commands {
+			if strings.HasPrefix(name, line) {
+				names = append(names, name)
+			}
+		}
+		for name := range r.aliases {
+			if strings.HasPrefix(name, line) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	head, partial := line[:i+1], line[i+1:]
+	name, args, err := ParseLine(head)
+	if err != nil {
+		return nil
+	}
+	cmd, _, ok := r.resolve(name, args)
+	if !ok || cmd.Complete == nil {
+		return nil
+	}
+	var lines []string
+	for _, c := range cmd.Complete(partial) {
+		lines = append(lines, head+c)
+	}
+	return lines
+}
+
+// CommonPrefix は候補全てに共通する先頭の部分を返す
+func CommonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// 複数バイトの文字の途中で切れた場合は、その文字を含めない
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
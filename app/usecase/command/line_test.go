@@ -0,0 +1,115 @@
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		args []string
+		err  error
+	}{
+		{"", "", nil, nil},
+		{"  w  ", "w", []string{}, nil},
+		{"set tabwidth=2", "set", []string{"tabwidth=2"}, nil},
+		{`open "my file.txt" x`, "open", []string{"my file.txt", "x"}, nil},
+		{`open my\ file.txt`, "open", []string{"my file.txt"}, nil},
+		{`e ""`, "e", []string{""}, nil},
+		{`open "a`, "", nil, ErrUnterminatedQuote},
+	}
+	for _, tt := range tests {
+		name, args, err := ParseLine(tt.line)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q: error = %v, want %v", tt.line, err, tt.err)
+			continue
+		}
+		if name != tt.name || (tt.args != nil && !reflect.DeepEqual(args, tt.args)) {
+			t.Errorf("%q: got %q %q, want %q %q", tt.line, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+func TestRegistry_Alias(t *testing.T) {
+	r := NewRegistry()
+	var got []string
+	r.Register(Command{Name: "quit", Run: func(args []string) error { got = args; return nil }})
+	r.Alias("q", "quit")
+	r.Alias("q!", "quit", "force")
+	r.Alias("broken", "missing")
+
+	if err := r.RunLine("q!  now"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"force", "now"}) {
+		t.Errorf("alias args must come before the given args: %q", got)
+	}
+	if err := r.RunLine("q"); err != nil || len(got) != 0 {
+		t.Errorf("q must run quit without args: %q, %v", got, err)
+	}
+	if err := r.Execute("broken", nil); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("alias to a missing command must fail, got %v", err)
+	}
+	if err := r.RunLine("  "); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("empty line must fail, got %v", err)
+	}
+	if _, ok := r.Lookup("q"); ok {
+		t.Error("Lookup must not resolve aliases")
+	}
+}
+
+func TestRegistry_Complete(t *testing.T) {
+	r := NewRegistry()
+	noop := func([]string) error { return nil }
+	r.Register(Command{Name: "save", Run: noop, Complete: func(arg string) []string {
+		var out []string
+		for _, f := range []string{"main.go", "main_test.go", "go.mod"} {
+			if len(f) >= len(arg) && f[:len(arg)] == arg {
+				out = append(out, f)
+			}
+		}
+		return out
+	}})
+	r.Register(Command{Name: "set", Run: noop})
+	r.Register(Command{Name: "search", Run: noop})
+	r.Alias("w", "save")
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"s", []string{"save", "search", "set"}},
+		{"se", []string{"search", "set"}},
+		{"w", []string{"w"}},
+		{"w ma", []string{"w main.go", "w main_test.go"}},
+		{"save x.go ", []string{"save x.go main.go", "save x.go main_test.go", "save x.go go.mod"}},
+		{"set a", nil},
+		{"nothing a", nil},
+	}
+	for _, tt := range tests {
+		if got := r.Complete(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		candidates []string
+		want       string
+	}{
+		{nil, ""},
+		{[]string{"toggle-read-only"}, "toggle-read-only"},
+		{[]string{"toggle-read-only", "toggle-selection"}, "toggle-"},
+		{[]string{"日本語", "日本人"}, "日本"},
+		{[]string{"aé", "aè"}, "a"},
+	}
+	for _, tt := range tests {
+		if got := CommonPrefix(tt.candidates); got != tt.want {
+			t.Errorf("CommonPrefix(%q) = %q, want %q", tt.candidates, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package command
+
+import "github.com/wasya-io/go-kilo/app/entity/contents"
+
+// LineBuffer は LineEdit が編集するバッファ
+type LineBuffer interface {
+	ApplyLineChanges(changes []contents.LineChange, undo bool) error
+}
+
+// LineEdit は行数を変えずに複数の行の内容を置き換える編集操作
+// 変更した行の前後の内容だけを持つため、大きなバッファを一括置換しても記録は変更した行の分で済む
+type LineEdit struct {
+	Buffer  LineBuffer
+	Changes []contents.LineChange
+	Before  contents.Position // 編集前のカーソル位置（取り消した後に戻す位置）
+	After   contents.Position // 編集後のカーソル位置（やり直した後に置く位置）
+}
+
+// Execute は各行を置き換えた後の内容にする
+func (e *LineEdit) Execute() error {
+	return e.Buffer.ApplyLineChanges(e.Changes, false)
+}
+
+// Undo は各行を置き換える前の内容に戻す
+func (e *LineEdit) Undo() error {
+	return e.Buffer.ApplyLineChanges(e.Changes, true)
+}
@@ -0,0 +1,112 @@
+package command
+
+import "github.com/wasya-io/go-kilo/app/entity/contents"
+
+// EditBuffer は保存した履歴を戻す先のバッファ
+type EditBuffer interface {
+	TextBuffer
+	LineBuffer
+}
+
+// Saved はファイルに保存するための履歴の形式
+type Saved struct {
+	Done      []SavedEdit `json:"done"`
+	Undone    []SavedEdit `json:"undone,omitempty"`
+	SavePoint int         `json:"save_point"`
+}
+
+// SavedEdit は編集操作1件分の保存形式
+// Kind が "text" なら TextEdit、"lines" なら LineEdit を表す
+type SavedEdit struct {
+	Kind     string                `json:"kind"`
+	Start    contents.Position     `json:"start"`
+	Removed  []string              `json:"removed,omitempty"`
+	Inserted []string              `json:"inserted,omitempty"`
+	Changes  []contents.LineChange `json:"changes,omitempty"`
+	Before   contents.Position     `json:"before"`
+	After    contents.Position     `json:"after"`
+}
+
+// Save は履歴を保存用の形式に変換する
+// 保存できない種類の操作があれば、そこより前（やり直し用の履歴では全て）は保存しない
+func (h *History) Save() Saved {
+	saved := Saved{SavePoint: h.savePoint}
+	start := len(h.done)
+	for start > 0 {
+		if _, ok := toSaved(h.done[start-1]); !ok {
+			break
+		}
+		start--
+	}
+	for _, cmd := range h.done[start:] {
+		edit, _ := toSaved(cmd)
+		saved.Done = append(saved.Done, edit)
+	}
+	if saved.SavePoint >= 0 {
+		saved.SavePoint -= start
+		if saved.SavePoint < 0 {
+			saved.SavePoint = -1
+		}
+	}
+
+	for _, cmd := range h.undone {
+		edit, ok := toSaved(cmd)
+		if !ok {
+			saved.Undone = nil
+			break
+		}
+		saved.Undone = append(saved.Undone, edit)
+	}
+	return saved
+}
+
+// Restore は保存した履歴で現在の履歴を置き換える。編集操作は buffer に対して行う
+// 種類の分からない操作を含む場合は、その操作より前を読み込まない
+func (h *History) Restore(saved Saved, buffer EditBuffer) {
+	h.Clear()
+	h.savePoint = saved.SavePoint
+	for _, edit := range saved.Done {
+		cmd, ok := fromSaved(edit, buffer)
+		if !ok {
+			h.done = nil
+			h.savePoint = -1
+			continue
+		}
+		h.done = append(h.done, cmd)
+	}
+	for _, edit := range saved.Undone {
+		cmd, ok := fromSaved(edit, buffer)
+		if !ok {
+			h.undone = nil
+			break
+		}
+		h.undone = append(h.undone, cmd)
+	}
+	if h.limit > 0 && len(h.done) > h.limit {
+		drop := len(h.done) - h.limit
+		h.done = h.done[drop:]
+		if h.savePoint >= 0 {
+			h.savePoint = max(h.savePoint-drop, -1)
+		}
+	}
+}
+
+func toSaved(cmd UndoableCommand) (SavedEdit, bool) {
+	switch e := cmd.(type) {
+	case *TextEdit:
+		return SavedEdit{Kind: "text", Start: e.Start, Removed: e.Removed, Inserted: e.Inserted}, true
+	case *LineEdit:
+		return SavedEdit{Kind: "lines", Changes: e.Changes, Before: e.Before, After: e.After}, true
+	}
+	return SavedEdit{}, false
+}
+
+func fromSaved(edit SavedEdit, buffer EditBuffer) (UndoableCommand, bool) {
+	switch edit.Kind {
+	case "text":
+		return &TextEdit{Buffer: buffer, Start: edit.Start, Removed: edit.Removed, Inserted: edit.Inserted}, true
+	case "lines":
+		return &LineEdit{Buffer: buffer, Changes: edit.Changes, Before: edit.Before, After: edit.After}, true
+	}
+	return nil, false
+}
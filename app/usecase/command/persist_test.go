@@ -0,0 +1,51 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// opaqueEdit は保存できない種類の操作
+type opaqueEdit struct{}
+
+func (opaqueEdit) Execute() error { return nil }
+func (opaqueEdit) Undo() error    { return nil }
+
+func TestHistory_SaveRestore(t *testing.T) {
+	buffer := contents.NewContents(logger.New(false))
+	buffer.LoadContent([]string{"foo"})
+
+	h := NewHistory(0)
+	h.Do(opaqueEdit{})
+	h.Do(&TextEdit{Buffer: buffer, Start: contents.Position{X: 3}, Inserted: []string{"bar"}})
+	h.MarkSaved()
+	h.Do(&LineEdit{Buffer: buffer, Changes: []contents.LineChange{{Line: 0, Old: "foobar", New: "baz"}}, After: contents.Position{X: 3}})
+	h.Undo()
+
+	// 保存できない操作より前は保存しない
+	saved := h.Save()
+	if len(saved.Done) != 1 || len(saved.Undone) != 1 || saved.SavePoint != 1 {
+		t.Fatalf("unexpected saved history: %+v", saved)
+	}
+
+	restored := NewHistory(0)
+	restored.Restore(saved, buffer)
+	if !restored.AtSavePoint() {
+		t.Error("restored history must be at the save point")
+	}
+	restored.Redo()
+	if got := buffer.GetAllLines(); !reflect.DeepEqual(got, []string{"baz"}) {
+		t.Errorf("redo: got %q", got)
+	}
+	restored.Undo()
+	restored.Undo()
+	if got := buffer.GetAllLines(); !reflect.DeepEqual(got, []string{"foo"}) {
+		t.Errorf("undo: got %q", got)
+	}
+	if cmd, _ := restored.Undo(); cmd != nil {
+		t.Error("nothing older than the restored history must be undone")
+	}
+}
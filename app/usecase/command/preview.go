@@ -0,0 +1,68 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoPreview はプレビューに対応していないコマンドを指定した場合のエラー
+var ErrNoPreview = errors.New("command has no preview")
+
+// Preview はコマンドを実行した場合にバッファに加わる変更。実行せずに確認するために使う
+type Preview struct {
+	Summary string   // 変更の概要
+	Diff    []string // 変更する行の差分。"-" で始まる行を "+" で始まる行に置き換える
+}
+
+// Empty は変更がないかどうかを返す
+func (p Preview) Empty() bool {
+	return len(p.Diff) == 0
+}
+
+// Preview は名前を指定してコマンドを実行した場合の変更を返す。バッファは変更しない
+func (r *Registry) Preview(name string, args []string) (Preview, error) {
+	cmd, args, ok := r.resolve(name, args)
+	if !ok {
+		return Preview{}, fmt.Errorf("%w: %s", ErrUnknownCommand, name)
+	}
+	if cmd.Preview == nil {
+		return Preview{}, fmt.Errorf("%w: %s", ErrNoPreview, name)
+	}
+	return cmd.Preview(args)
+}
+
+// DiffLines は before を after に変える変更を、行番号（1 始まり）付きの差分で返す
+// 行数が同じ場合は内容の変わる行ごとに、違う場合は前後の共通の行を除いた範囲をまとめて示す
+func DiffLines(before, after []string) []string {
+	var diff []string
+	if len(before) == len(after) {
+		for i := range before {
+			if before[i] != after[i] {
+				diff = append(diff, diffLine('-', i, before[i]), diffLine('+', i, after[i]))
+			}
+		}
+		return diff
+	}
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	for i := prefix; i < len(before)-suffix; i++ {
+		diff = append(diff, diffLine('-', i, before[i]))
+	}
+	for i := prefix; i < len(after)-suffix; i++ {
+		diff = append(diff, diffLine('+', i, after[i]))
+	}
+	return diff
+}
+
+// diffLine は差分の1行を返す
+func diffLine(mark rune, index int, text string) string {
+	return fmt.Sprintf("%c%4d: %s", mark, index+1, text)
+}
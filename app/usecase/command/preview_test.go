@@ -0,0 +1,46 @@
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_Preview(t *testing.T) {
+	r := NewRegistry()
+	ran := false
+	r.Register(Command{
+		Name: "upper",
+		Run:  func([]string) error { ran = true; return nil },
+		Preview: func(args []string) (Preview, error) {
+			return Preview{Summary: "Upper", Diff: DiffLines([]string{"a"}, args)}, nil
+		},
+	})
+	r.Register(Command{Name: "quit", Run: func([]string) error { return nil }})
+
+	p, err := r.Preview("upper", []string{"A"})
+	if err != nil || ran || !reflect.DeepEqual(p.Diff, []string{"-   1: a", "+   1: A"}) {
+		t.Errorf("preview must not run the command: %+v %v ran=%v", p, err, ran)
+	}
+	if _, err := r.Preview("quit", nil); !errors.Is(err, ErrNoPreview) {
+		t.Errorf("expected ErrNoPreview, got %v", err)
+	}
+	if _, err := r.Preview("missing", nil); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected ErrUnknownCommand, got %v", err)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	// 行数が変わる場合は前後の共通の行を除く
+	got := DiffLines([]string{"a", "b", "c", "d"}, []string{"a", "x", "y", "z", "d"})
+	want := []string{"-   2: b", "-   3: c", "+   2: x", "+   3: y", "+   4: z"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines = %q", got)
+	}
+	if got := DiffLines([]string{"a", "b"}, []string{"a", "b"}); len(got) != 0 {
+		t.Errorf("no changes: %q", got)
+	}
+	if got := DiffLines([]string{"a", "a"}, []string{"a"}); !reflect.DeepEqual(got, []string{"-   2: a"}) {
+		t.Errorf("removed line: %q", got)
+	}
+}
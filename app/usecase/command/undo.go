@@ -0,0 +1,210 @@
+package command
+
+import (
+	"unicode"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// UndoableCommand は実行と取り消しができる編集操作
+type UndoableCommand interface {
+	Execute() error
+	Undo() error
+}
+
+// Merger は直後の操作を自身にまとめられる UndoableCommand
+// 1文字ずつの入力を1回の取り消しで戻せるようにするために使う
+type Merger interface {
+	Merge(next UndoableCommand) bool
+}
+
+// History は編集操作の履歴を取り消し用と再実行用のスタックで管理する
+type History struct {
+	done      []UndoableCommand
+	undone    []UndoableCommand
+	limit     int
+	savePoint int // 保存した時点の done の長さ（その時点に戻れなくなった場合は -1）
+}
+
+// NewHistory は最大 limit 件の操作を記録する History を作成する。limit が0以下なら無制限
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Do は操作を実行して履歴に記録する
+func (h *History) Do(cmd UndoableCommand) error {
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	h.Record(cmd)
+	return nil
+}
+
+// Record は実行済みの操作を履歴に記録する
+// 新しい操作を記録すると再実行用の履歴は破棄する
+func (h *History) Record(cmd UndoableCommand) {
+	h.undone = nil
+	if h.savePoint > len(h.done) {
+		h.savePoint = -1
+	}
+	// 保存した時点の操作にまとめると、その時点に戻れなくなるのでまとめない
+	if n := len(h.done); n > 0 && n != h.savePoint {
+		if m, ok := h.done[n-1].(Merger); ok && m.Merge(cmd) {
+			return
+		}
+	}
+	h.done = append(h.done, cmd)
+	if h.limit > 0 && len(h.done) > h.limit {
+		h.done = h.done[1:]
+		if h.savePoint >= 0 {
+			h.savePoint--
+		}
+	}
+}
+
+// Undo は直前の操作を取り消し、取り消した操作を返す。取り消せる操作がなければ nil を返す
+func (h *History) Undo() (UndoableCommand, error) {
+	if len(h.done) == 0 {
+		return nil, nil
+	}
+	cmd := h.done[len(h.done)-1]
+	if err := cmd.Undo(); err != nil {
+		return nil, err
+	}
+	h.done = h.done[:len(h.done)-1]
+	h.undone = append(h.undone, cmd)
+	return cmd, nil
+}
+
+// Redo は直前に取り消した操作を再実行し、その操作を返す。再実行できる操作がなければ nil を返す
+func (h *History) Redo() (UndoableCommand, error) {
+	if len(h.undone) == 0 {
+		return nil, nil
+	}
+	cmd := h.undone[len(h.undone)-1]
+	if err := cmd.Execute(); err != nil {
+		return nil, err
+	}
+	h.undone = h.undone[:len(h.undone)-1]
+	h.done = append(h.done, cmd)
+	return cmd, nil
+}
+
+// MarkSaved は現在の状態を保存済みとして記録する
+func (h *History) MarkSaved() {
+	h.savePoint = len(h.done)
+}
+
+// AtSavePoint は取り消し・再実行の結果、保存した時点の状態に戻っているかどうかを返す
+func (h *History) AtSavePoint() bool {
+	return h.savePoint == len(h.done)
+}
+
+// Clear は履歴を全て破棄する。現在の状態を保存済みとみなす
+func (h *History) Clear() {
+	h.done = nil
+	h.undone = nil
+	h.savePoint = 0
+}
+
+// TextBuffer は TextEdit が編集するバッファ
+type TextBuffer interface {
+	InsertText(pos contents.Position, text []string) (contents.Position, error)
+	DeleteRange(start, end contents.Position) ([]string, error)
+}
+
+// TextEdit は Start の位置の Removed を Inserted に置き換える編集操作
+// 文字の挿入・削除、改行、貼り付けなどのバッファの変更はすべてこの形で表せる
+// Removed, Inserted は行ごとの文字列で、2行以上なら改行を含む
+type TextEdit struct {
+	Buffer   TextBuffer
+	Start    contents.Position
+	Removed  []string
+	Inserted []string
+	cursor   contents.Position
+}
+
+// Execute は Removed を Inserted に置き換える
+func (e *TextEdit) Execute() error {
+	return e.replace(e.Removed, e.Inserted)
+}
+
+// Undo は Inserted を Removed に戻す
+func (e *TextEdit) Undo() error {
+	return e.replace(e.Inserted, e.Removed)
+}
+
+// Cursor は直前の Execute または Undo で置き換えた文字列の末尾の位置を返す
+func (e *TextEdit) Cursor() contents.Position {
+	return e.cursor
+}
+
+func (e *TextEdit) replace(old, new []string) error {
+	if !empty(old) {
+		if _, err := e.Buffer.DeleteRange(e.Start, contents.EndOf(e.Start, old)); err != nil {
+			return err
+		}
+	}
+	e.cursor = e.Start
+	if !empty(new) {
+		end, err := e.Buffer.InsertText(e.Start, new)
+		if err != nil {
+			return err
+		}
+		e.cursor = end
+	}
+	return nil
+}
+
+// Merge は同じ行で続けて入力した文字や、続けて削除した文字（前後どちらの方向でも）を1つの操作にまとめる
+// 空白の後に単語を入力し始めた場合は、単語単位で取り消せるようにまとめない
+func (e *TextEdit) Merge(next UndoableCommand) bool {
+	n, ok := next.(*TextEdit)
+	if !ok || n.Buffer != e.Buffer || !singleLine(e) || !singleLine(n) {
+		return false
+	}
+	switch {
+	case len(e.Removed) == 0 && len(n.Removed) == 0:
+		// 文字の入力
+		if n.Start != contents.EndOf(e.Start, e.Inserted) || wordStart(e.Inserted[0], n.Inserted[0]) {
+			return false
+		}
+		e.Inserted = []string{e.Inserted[0] + n.Inserted[0]}
+		e.cursor = n.cursor
+		return true
+	case len(e.Inserted) == 0 && len(n.Inserted) == 0:
+		switch {
+		case contents.EndOf(n.Start, n.Removed) == e.Start:
+			// Backspace による削除
+			e.Start = n.Start
+			e.Removed = []string{n.Removed[0] + e.Removed[0]}
+		case n.Start == e.Start:
+			// Delete による削除
+			e.Removed = []string{e.Removed[0] + n.Removed[0]}
+		default:
+			return false
+		}
+		e.cursor = n.cursor
+		return true
+	}
+	return false
+}
+
+// singleLine は改行を含まない挿入または削除かどうかを返す
+func singleLine(e *TextEdit) bool {
+	return len(e.Removed)+len(e.Inserted) == 1
+}
+
+// wordStart は before に続けて after を入力すると、空白の後に単語が始まるかどうかを返す
+func wordStart(before, after string) bool {
+	b, a := []rune(before), []rune(after)
+	if len(b) == 0 || len(a) == 0 {
+		return false
+	}
+	return unicode.IsSpace(b[len(b)-1]) && !unicode.IsSpace(a[0])
+}
+
+// empty は text が何も含まないかどうかを返す
+func empty(text []string) bool {
+	return len(text) == 0 || (len(text) == 1 && text[0] == "")
+}
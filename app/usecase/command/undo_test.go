@@ -0,0 +1,114 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func newBuffer(lines ...string) *contents.Contents {
+	b := contents.NewContents(logger.New(false))
+	b.LoadContent(lines)
+	return b
+}
+
+func TestHistory_UndoRedo(t *testing.T) {
+	buf := newBuffer("ab")
+	h := NewHistory(0)
+
+	// 連続した入力は1つの操作にまとめる
+	for i, ch := range []string{"c", "d"} {
+		if err := h.Do(&TextEdit{Buffer: buf, Start: contents.Position{X: 2 + i}, Inserted: []string{ch}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 改行はまとめない
+	h.Do(&TextEdit{Buffer: buf, Start: contents.Position{X: 1}, Inserted: []string{"", "  "}})
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, []string{"a", "  bcd"}) {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+
+	cmd, err := h.Undo()
+	if err != nil || cmd.(*TextEdit).Cursor() != (contents.Position{X: 1}) {
+		t.Fatalf("Undo() = %+v, %v", cmd, err)
+	}
+	cmd, _ = h.Undo()
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, []string{"ab"}) || cmd.(*TextEdit).Cursor() != (contents.Position{X: 2}) {
+		t.Errorf("unexpected state after undo: %q %+v", got, cmd)
+	}
+	if cmd, _ := h.Undo(); cmd != nil {
+		t.Errorf("nothing left to undo: %+v", cmd)
+	}
+
+	h.Redo()
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, []string{"abcd"}) {
+		t.Errorf("unexpected state after redo: %q", got)
+	}
+	// 新しい操作で再実行用の履歴は破棄する
+	h.Do(&TextEdit{Buffer: buf, Start: contents.Position{X: 4}, Inserted: []string{"!"}})
+	if cmd, _ := h.Redo(); cmd != nil {
+		t.Errorf("redo history must be discarded: %+v", cmd)
+	}
+}
+
+func TestHistory_MergeDeletes(t *testing.T) {
+	buf := newBuffer("one two")
+	h := NewHistory(0)
+	// Backspace を2回押した場合
+	for x := 7; x > 5; x-- {
+		removed, _ := buf.DeleteRange(contents.Position{X: x - 1}, contents.Position{X: x})
+		h.Record(&TextEdit{Buffer: buf, Start: contents.Position{X: x - 1}, Removed: removed})
+	}
+	cmd, _ := h.Undo()
+	if buf.GetContentLine(0) != "one two" || cmd.(*TextEdit).Cursor() != (contents.Position{X: 7}) {
+		t.Errorf("both deletes must be undone at once: %q %+v", buf.GetContentLine(0), cmd)
+	}
+}
+
+func TestHistory_MergeForwardDeletes(t *testing.T) {
+	buf := newBuffer("one two")
+	h := NewHistory(0)
+	// Delete を2回押した場合
+	for i := 0; i < 2; i++ {
+		removed, _ := buf.DeleteRange(contents.Position{X: 3}, contents.Position{X: 4})
+		h.Record(&TextEdit{Buffer: buf, Start: contents.Position{X: 3}, Removed: removed})
+	}
+	if buf.GetContentLine(0) != "onewo" {
+		t.Fatalf("unexpected line: %q", buf.GetContentLine(0))
+	}
+	cmd, _ := h.Undo()
+	if buf.GetContentLine(0) != "one two" || cmd.(*TextEdit).Cursor() != (contents.Position{X: 5}) {
+		t.Errorf("both deletes must be undone at once: %q %+v", buf.GetContentLine(0), cmd)
+	}
+}
+
+func TestHistory_SavePointAndLimit(t *testing.T) {
+	buf := newBuffer("")
+	h := NewHistory(2)
+	h.Do(&TextEdit{Buffer: buf, Inserted: []string{"a"}})
+	h.MarkSaved()
+	// 保存した時点の操作にはまとめない
+	h.Do(&TextEdit{Buffer: buf, Start: contents.Position{X: 1}, Inserted: []string{"b"}})
+	if h.AtSavePoint() {
+		t.Error("must not be at the save point after an edit")
+	}
+	h.Undo()
+	if !h.AtSavePoint() || buf.GetContentLine(0) != "a" {
+		t.Errorf("undo must return to the save point: %q", buf.GetContentLine(0))
+	}
+
+	h.Undo()
+	h.Do(&TextEdit{Buffer: buf, Inserted: []string{"x", ""}})
+	h.Do(&TextEdit{Buffer: buf, Inserted: []string{"y", ""}})
+	h.Do(&TextEdit{Buffer: buf, Inserted: []string{"z", ""}})
+	if h.AtSavePoint() {
+		t.Error("the save point is no longer reachable")
+	}
+	h.Undo()
+	h.Undo()
+	if cmd, _ := h.Undo(); cmd != nil {
+		t.Error("history must be limited to 2 entries")
+	}
+}
@@ -0,0 +1,72 @@
+package completion
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/word"
+)
+
+// Source は補完候補を提供するインターフェース
+type Source interface {
+	Candidates(prefix string) []string
+}
+
+// SourceFunc は関数を Source として扱うためのアダプタ
+type SourceFunc func(prefix string) []string
+
+// Candidates は Source インターフェースの実装
+func (f SourceFunc) Candidates(prefix string) []string {
+	return f(prefix)
+}
+
+// Completer は複数の補完ソースを優先順に問い合わせ、重複を除いた候補を返す
+type Completer struct {
+	sources []Source
+	limit   int
+}
+
+// NewCompleter は sources の順で候補を並べる Completer を作成する
+func NewCompleter(limit int, sources ...Source) *Completer {
+	return &Completer{sources: sources, limit: limit}
+}
+
+// Complete は prefix に対する補完候補を返す
+func (c *Completer) Complete(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	seen := map[string]bool{prefix: true}
+	var result []string
+	for _, src := range c.sources {
+		for _, cand := range src.Candidates(prefix) {
+			if seen[cand] || !strings.HasPrefix(cand, prefix) {
+				continue
+			}
+			seen[cand] = true
+			result = append(result, cand)
+			if c.limit > 0 && len(result) >= c.limit {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// BufferWords はバッファ内の単語を候補として提供する
+func BufferWords(lines func() []string) Source {
+	return SourceFunc(func(prefix string) []string {
+		seen := make(map[string]bool)
+		var result []string
+		for _, line := range lines() {
+			for _, w := range word.Split(line) {
+				if w != prefix && strings.HasPrefix(w, prefix) && !seen[w] {
+					seen[w] = true
+					result = append(result, w)
+				}
+			}
+		}
+		sort.Strings(result)
+		return result
+	})
+}
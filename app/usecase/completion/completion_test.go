@@ -0,0 +1,35 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompleter_Complete(t *testing.T) {
+	lines := func() []string {
+		return []string{"foo fooBar", "foobaz := fooBar + 1"}
+	}
+	tagsSource := SourceFunc(func(prefix string) []string {
+		return []string{"fooBar", "fooTag", "other"}
+	})
+
+	tests := []struct {
+		name     string
+		limit    int
+		prefix   string
+		expected []string
+	}{
+		{"バッファとタグを重複なく結合", 0, "foo", []string{"fooBar", "foobaz", "fooTag"}},
+		{"件数制限", 2, "foo", []string{"fooBar", "foobaz"}},
+		{"空のプレフィックス", 0, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompleter(tt.limit, BufferWords(lines), tagsSource)
+			if got := c.Complete(tt.prefix); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Complete(%q) = %v, want %v", tt.prefix, got, tt.expected)
+			}
+		})
+	}
+}
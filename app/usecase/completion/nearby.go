@@ -0,0 +1,116 @@
+package completion
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/word"
+)
+
+// Recent は最近入力した単語を新しい順に覚えておく
+type Recent struct {
+	words []string
+	limit int
+}
+
+// NewRecent は最大 limit 個の単語を覚える Recent を作成する
+func NewRecent(limit int) *Recent {
+	return &Recent{limit: limit}
+}
+
+// Add は w を最も新しく入力した単語として記録する
+func (r *Recent) Add(w string) {
+	if w == "" {
+		return
+	}
+	for i, existing := range r.words {
+		if existing == w {
+			r.words = append(r.words[:i], r.words[i+1:]...)
+			break
+		}
+	}
+	r.words = append([]string{w}, r.words...)
+	if r.limit > 0 && len(r.words) > r.limit {
+		r.words = r.words[:r.limit]
+	}
+}
+
+// rank は w が何番目に新しく入力された単語かを返す（0 が最新）
+func (r *Recent) rank(w string) (int, bool) {
+	if r == nil {
+		return 0, false
+	}
+	for i, existing := range r.words {
+		if existing == w {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// distance はカーソルから単語までの距離。行の差を優先し、同じ行数なら列の差で比べる
+type distance struct {
+	lines, cols int
+}
+
+func (d distance) less(o distance) bool {
+	if d.lines != o.lines {
+		return d.lines < o.lines
+	}
+	return d.cols < o.cols
+}
+
+// NearbyWords はバッファ内の単語を、最近入力したものを新しい順に、
+// それ以外をカーソルに近い位置に現れるものから順に候補として提供する
+// カーソル位置で入力中の単語自身は候補に含めない
+func NearbyWords(lines func() []string, cursor func() (x, y int), recent *Recent) Source {
+	return SourceFunc(func(prefix string) []string {
+		cx, cy := cursor()
+		nearest := make(map[string]distance)
+		var found []string
+		for y, line := range lines() {
+			runes := []rune(line)
+			for i := 0; i < len(runes); {
+				if !word.IsWordRune(runes[i]) {
+					i++
+					continue
+				}
+				j := i
+				for j < len(runes) && word.IsWordRune(runes[j]) {
+					j++
+				}
+				w := string(runes[i:j])
+				if w != prefix && strings.HasPrefix(w, prefix) && !(y == cy && i <= cx && cx <= j) {
+					d := distance{lines: abs(y - cy), cols: abs(i - cx)}
+					if prev, ok := nearest[w]; !ok {
+						found = append(found, w)
+						nearest[w] = d
+					} else if d.less(prev) {
+						nearest[w] = d
+					}
+				}
+				i = j
+			}
+		}
+
+		sort.SliceStable(found, func(a, b int) bool {
+			ra, okA := recent.rank(found[a])
+			rb, okB := recent.rank(found[b])
+			if okA != okB {
+				return okA
+			}
+			if okA {
+				return ra < rb
+			}
+			return nearest[found[a]].less(nearest[found[b]])
+		})
+		return found
+	})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
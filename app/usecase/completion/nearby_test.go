@@ -0,0 +1,58 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNearbyWords(t *testing.T) {
+	lines := func() []string {
+		return []string{
+			"fooFar := 1",
+			"fooMid := 2",
+			"foo",
+			"fooNear fooSame",
+		}
+	}
+	cursor := func() (int, int) { return 3, 2 }
+
+	src := NearbyWords(lines, cursor, nil)
+	want := []string{"fooMid", "fooNear", "fooSame", "fooFar"}
+	if got := src.Candidates("foo"); !reflect.DeepEqual(got, want) {
+		t.Errorf("by distance: got %v, want %v", got, want)
+	}
+
+	// 最近入力した単語を新しい順に優先する
+	recent := NewRecent(10)
+	recent.Add("fooSame")
+	recent.Add("fooFar")
+	recent.Add("fooSame")
+	src = NearbyWords(lines, cursor, recent)
+	want = []string{"fooSame", "fooFar", "fooMid", "fooNear"}
+	if got := src.Candidates("foo"); !reflect.DeepEqual(got, want) {
+		t.Errorf("by recency: got %v, want %v", got, want)
+	}
+}
+
+func TestNearbyWords_SkipsWordAtCursor(t *testing.T) {
+	lines := func() []string { return []string{"fooBar foobaz"} }
+	cursor := func() (int, int) { return 3, 0 }
+
+	// カーソルのある fooBar 自身は候補にしない
+	if got := NearbyWords(lines, cursor, nil).Candidates("foo"); !reflect.DeepEqual(got, []string{"foobaz"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestRecent_Limit(t *testing.T) {
+	r := NewRecent(2)
+	r.Add("a")
+	r.Add("b")
+	r.Add("c")
+	if _, ok := r.rank("a"); ok {
+		t.Error("oldest word must be forgotten")
+	}
+	if i, ok := r.rank("c"); !ok || i != 0 {
+		t.Errorf("rank(c) = %d, %v", i, ok)
+	}
+}
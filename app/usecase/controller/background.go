@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
+)
+
+// backgroundState はほかの goroutine からメインループでの実行を依頼された処理
+type backgroundState struct {
+	mutex     sync.Mutex
+	tasks     []func()
+	results   []func()           // postResult で依頼された処理。idle の間だけ実行する
+	idle      bool               // Process が次のキー入力を待っている（一覧や確認の入力を待っていない）か
+	interrupt context.CancelFunc // キー入力の待ちを中断する（待っていなければ nil）
+}
+
+// Post は task をメインループで実行するよう依頼する。キー入力を待っている間も待ちを中断してすぐに実行する
+// シグナルやタイマーの goroutine からの、端末や入力の待ちに関わる処理をメインループで実行するために使う
+// キー入力による編集はイベントバスの goroutine で適用されるため、task はそれと並行して動くことがある
+// バッファやウィンドウを変える処理は、task の中で直接行わずイベントとして発行すること
+// 外部のコマンドに端末を渡している間など、メインループが入力を待っていない間は、次に入力を待つときまで遅らせる
+func (c *Controller) Post(task func()) {
+	b := &c.background
+	b.mutex.Lock()
+	b.tasks = append(b.tasks, task)
+	interrupt := b.interrupt
+	b.mutex.Unlock()
+	if interrupt != nil {
+		interrupt()
+	}
+}
+
+// postResult は非同期の処理の結果を表示する task を、メインループで実行するよう依頼する
+// 一覧や確認の入力を待っている間は、表示中の一覧やメッセージを置き換えないよう、それを閉じるまで遅らせる
+func (c *Controller) postResult(task func()) {
+	b := &c.background
+	b.mutex.Lock()
+	b.results = append(b.results, task)
+	interrupt := b.interrupt
+	b.mutex.Unlock()
+	if interrupt != nil {
+		interrupt()
+	}
+}
+
+// setIdle は Process が次のキー入力を待っているかどうかを設定する
+func (b *backgroundState) setIdle(idle bool) {
+	b.mutex.Lock()
+	b.idle = idle
+	b.mutex.Unlock()
+}
+
+// runBackgroundTasks は依頼された処理を順に実行する
+func (c *Controller) runBackgroundTasks() {
+	b := &c.background
+	b.mutex.Lock()
+	tasks := b.tasks
+	b.tasks = nil
+	if b.idle {
+		tasks = append(tasks, b.results...)
+		b.results = nil
+	}
+	b.mutex.Unlock()
+	for _, task := range tasks {
+		task()
+	}
+}
+
+// waitContext はキー入力を待つ間に使う、Post で取り消される parent の子の context を返す
+// 既に依頼された処理があれば、取り消した context を返す
+func (b *backgroundState) waitContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.tasks) > 0 || (b.idle && len(b.results) > 0) {
+		cancel()
+	} else {
+		b.interrupt = cancel
+	}
+	return ctx, func() {
+		b.mutex.Lock()
+		b.interrupt = nil
+		b.mutex.Unlock()
+		cancel()
+	}
+}
+
+// StopInput はキー入力を待っているメインループを戻し、以後の待ちもすぐに戻す
+// シグナルによる終了処理で入力を閉じる前に呼び出し、メインループが終了をエラーとして扱わないようにする
+func (c *Controller) StopInput() {
+	c.stopInput()
+}
+
+// quitting は err がエディタの終了でキー入力の待ちをやめたか、入力を閉じたことによるものかどうかを返す
+func (c *Controller) quitting(err error) bool {
+	return (errors.Is(err, context.Canceled) || errors.Is(err, input.ErrClosed)) && c.inputCtx.Err() != nil
+}
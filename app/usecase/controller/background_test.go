@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// channelTerminal は keys に送られたキーを1件ずつ返す端末
+type channelTerminal struct {
+	keys chan key.KeyEvent
+}
+
+func (t *channelTerminal) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	return <-t.keys, nil, nil
+}
+
+// useChannelTerminal は入力を待つ間も取り消せる端末からキーを読むようにする
+func useChannelTerminal(controller *Controller) *channelTerminal {
+	terminal := &channelTerminal{keys: make(chan key.KeyEvent, 1)}
+	controller.inputs = input.NewCompositeProvider(input.NewAsyncProvider(terminal), 0, controller.macroSource)
+	return terminal
+}
+
+func TestPost_RunsWhileWaitingForInput(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{""})
+	terminal := useChannelTerminal(controller)
+
+	// キー入力を待っている間に依頼された処理を実行し、その後に届いたキーを処理する
+	done := make(chan error, 1)
+	go func() { done <- controller.Process() }()
+	ran := false
+	controller.Post(func() {
+		ran = true
+		terminal.keys <- char('b')
+	})
+	assert.NoError(t, <-done)
+	assert.True(t, ran)
+	assert.Equal(t, []string{"b"}, c.GetAllLines())
+}
+
+func TestProcess_ReturnsOnQuit(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{""})
+	useChannelTerminal(controller)
+
+	done := make(chan error, 1)
+	go func() { done <- controller.Process() }()
+	controller.Post(func() { controller.PublishQuitEvent(false) })
+	assert.NoError(t, <-done, "the pending read does not block quitting")
+	assert.True(t, controller.isQuitChannelClosed())
+}
+
+func TestProcess_ReturnsWhenInputIsClosed(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{""})
+	terminal := &channelTerminal{keys: make(chan key.KeyEvent)}
+	async := input.NewAsyncProvider(terminal)
+	controller.inputs = input.NewCompositeProvider(async, 0, controller.macroSource)
+
+	// シグナルによる終了処理と同じく、待ちをやめさせてから入力を閉じる
+	done := make(chan error, 1)
+	go func() { done <- controller.Process() }()
+	controller.StopInput()
+	async.Close()
+	assert.NoError(t, <-done)
+	assert.NoError(t, controller.Process(), "later reads also return without an error")
+}
+
+func TestPostResult_WaitsForOpenPrompt(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{""})
+	terminal := useChannelTerminal(controller)
+
+	// 確認の入力を待っている間は実行せず、表示中の確認を置き換えない
+	ran := false
+	controller.postResult(func() {
+		ran = true
+		terminal.keys <- char('b')
+	})
+	terminal.keys <- char('n')
+	ok, err := controller.confirmYesNo("Proceed? (y/n)")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, ran)
+
+	// Process が次のキー入力を待つときに実行する
+	assert.NoError(t, controller.Process())
+	assert.True(t, ran)
+	assert.Equal(t, []string{"b"}, c.GetAllLines())
+}
+
+func TestRefresh_DebouncedRedrawGoesThroughBus(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"one"})
+	tracer := event.NewTracer(100)
+	controller.eventBus.SetTracer(tracer)
+	controller.SetRefreshDelay(time.Millisecond)
+
+	controller.eventBus.Publish(event.NewRefreshEvent())
+	// タイマーは再描画せず、すぐに再描画するイベントをバスに戻す
+	assert.Eventually(t, func() bool {
+		refreshes := 0
+		for _, r := range tracer.Records() {
+			if r.Type == event.TypeRefresh {
+				refreshes++
+			}
+		}
+		return refreshes == 2
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+// blockRange は矩形選択の範囲を、先頭と末尾の行と表示上の左端・右端（右端は含まない）で返す
+// 範囲は anchor とカーソルの表示上の桁の間。幅が 0 の場合 ok は false
+func (c *Controller) blockRange() (top, bottom, left, right int, ok bool) {
+	pos := c.screen.GetCursor().ToPosition()
+	cursor := contents.Position{X: pos.X, Y: pos.Y}
+	anchor := c.selection.anchor
+	top, bottom = min(anchor.Y, cursor.Y), max(anchor.Y, cursor.Y)
+	a, b := c.displayColumn(anchor), c.displayColumn(cursor)
+	left, right = min(a, b), max(a, b)
+	return top, bottom, left, right, left < right
+}
+
+// displayColumn は pos の表示上の桁を返す
+func (c *Controller) displayColumn(pos contents.Position) int {
+	row := c.contents.GetRow(pos.Y)
+	if row == nil {
+		return 0
+	}
+	return row.OffsetToScreenPosition(pos.X)
+}
+
+// displayWidth は s の表示幅を返す
+func displayWidth(s string) int {
+	row := contents.NewRow(s)
+	return row.OffsetToScreenPosition(row.GetRuneCount())
+}
+
+// blockLines は矩形選択の範囲の各行を、左端より前・範囲内・右端以降の3つに分けて返す
+func (c *Controller) blockLines(top, bottom, left, right int) (heads, bodies, tails []string) {
+	for y := top; y <= bottom; y++ {
+		row := contents.NewRow(c.contents.GetContentLine(y))
+		runes := row.GetRunes()
+		from, to := row.ScreenPositionToOffset(left), row.ScreenPositionToOffset(right)
+		heads = append(heads, string(runes[:from]))
+		bodies = append(bodies, string(runes[from:to]))
+		tails = append(tails, string(runes[to:]))
+	}
+	return heads, bodies, tails
+}
+
+// copyBlock は矩形選択の範囲をクリップボードにコピーする。cut が true の場合は範囲を削除する
+func (c *Controller) copyBlock(cut bool) {
+	top, bottom, left, right, ok := c.blockRange()
+	if !ok {
+		c.setStatusMessage("Nothing selected")
+		return
+	}
+	_, text, _ := c.blockLines(top, bottom, left, right)
+	if cut {
+		if !c.deleteBlock() {
+			return
+		}
+		c.contents.SetBlockClipboard(text)
+		c.setStatusMessage("Cut %d-line block", len(text))
+		return
+	}
+	c.contents.SetBlockClipboard(text)
+	c.clearSelection()
+	c.setStatusMessage("Copied %d-line block", len(text))
+}
+
+// deleteBlock は矩形選択の範囲を各行から削除し、カーソルを範囲の左上に移す。削除できなかった場合は false を返す
+func (c *Controller) deleteBlock() bool {
+	top, bottom, left, right, ok := c.blockRange()
+	if !ok {
+		c.clearSelection()
+		return false
+	}
+	heads, _, tails := c.blockLines(top, bottom, left, right)
+	lines := make([]string, len(heads))
+	for i := range heads {
+		lines[i] = heads[i] + tails[i]
+	}
+	if !c.replaceLines(top, len(lines), lines) {
+		return false
+	}
+	c.screen.SetCursorPosition(len([]rune(heads[0])), top)
+	c.updateScroll()
+	return true
+}
+
+// pasteBlock は矩形選択からコピーした text の各行を、カーソルの表示上の桁に揃えてカーソル行から順に挿入する
+// 桁に届かない行は空白で埋め、後ろに文字が続く行では表の列がずれないよう挿入する文字列を幅まで空白で埋める
+// バッファの末尾を越える分は行を追加する。1回の取り消しで戻せる
+func (c *Controller) pasteBlock(text []string) {
+	c.clearSelection()
+	pos := c.screen.GetCursor().ToPosition()
+	col := c.displayColumn(contents.Position{X: pos.X, Y: pos.Y})
+	width := 0
+	for _, t := range text {
+		width = max(width, displayWidth(t))
+	}
+
+	count := min(len(text), max(c.contents.GetLineCount()-pos.Y, 0))
+	lines := make([]string, len(text))
+	x := 0
+	for i, piece := range text {
+		line := ""
+		if i < count {
+			line = c.contents.GetContentLine(pos.Y + i)
+		}
+		if w := displayWidth(line); w < col {
+			line += strings.Repeat(" ", col-w)
+		}
+		runes := []rune(line)
+		at := contents.NewRow(line).ScreenPositionToOffset(col)
+		if at < len(runes) {
+			piece += strings.Repeat(" ", width-displayWidth(piece))
+		}
+		lines[i] = string(runes[:at]) + piece + string(runes[at:])
+		if i == 0 {
+			x = at
+		}
+	}
+	if !c.replaceLines(pos.Y, count, lines) {
+		return
+	}
+	c.screen.SetCursorPosition(x, pos.Y)
+	c.updateScroll()
+}
+
+// replaceLines は top 行目から count 行を lines に置き換え、1回の取り消しで戻せるよう記録する
+// lines の方が多い場合は行を追加する。置き換えられなかった場合は false を返す
+func (c *Controller) replaceLines(top, count int, lines []string) bool {
+	start := contents.Position{Y: top}
+	end := start
+	if count > 0 {
+		last := top + count - 1
+		end = contents.Position{X: len([]rune(c.contents.GetContentLine(last))), Y: last}
+	}
+	return c.replaceRange(start, end, lines)
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// selectBlock は (x1, y1) から (x2, y2) までを矩形選択する
+func selectBlock(controller *Controller, x1, y1, x2, y2 int) {
+	controller.screen.SetCursorPosition(x1, y1)
+	controller.performSelection(event.BufferSelectBlock)
+	controller.screen.SetCursorPosition(x2, y2)
+}
+
+func TestBlockSelection_CutAndUndo(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abcd", "efgh", "ij"})
+	selectBlock(controller, 1, 0, 3, 1)
+
+	controller.performCut()
+	assert.Equal(t, []string{"ad", "eh", "ij"}, c.GetAllLines())
+	assert.Equal(t, []string{"bc", "fg"}, c.Clipboard())
+	assert.True(t, c.ClipboardIsBlock())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 1, pos.X)
+
+	// 矩形の削除は1回で取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, c.GetAllLines())
+}
+
+func TestBlockSelection_CopyUsesDisplayColumns(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"あいう", "abcdef"})
+	selectBlock(controller, 1, 0, 4, 1)
+
+	controller.performCopy()
+	// 全角文字の行でも表示上の同じ桁の範囲をコピーする
+	assert.Equal(t, []string{"い", "cd"}, c.Clipboard())
+	assert.False(t, controller.selection.active)
+}
+
+func TestPasteBlock(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"12345", "1", "123"})
+	c.SetBlockClipboard([]string{"ab", "c"})
+	controller.screen.SetCursorPosition(3, 0)
+
+	controller.performPaste()
+	// 桁に届かない行は空白で埋めて、同じ桁に挿入する
+	assert.Equal(t, []string{"123ab45", "1  c", "123"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 3, pos.X)
+
+	controller.performUndo()
+	assert.Equal(t, []string{"12345", "1", "123"}, c.GetAllLines())
+}
+
+func TestPasteBlock_KeepsColumns(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"| x |", "| y |"})
+	c.SetBlockClipboard([]string{"long", "s"})
+	controller.screen.SetCursorPosition(2, 0)
+
+	controller.performPaste()
+	// 短い行は幅まで空白で埋め、後ろの列をずらさない
+	assert.Equal(t, []string{"| longx |", "| s   y |"}, c.GetAllLines())
+}
+
+func TestPasteBlock_PastEnd(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"x"})
+	c.SetBlockClipboard([]string{"a", "b"})
+	controller.screen.SetCursorPosition(1, 0)
+
+	controller.performPaste()
+	// バッファの末尾を越える分は行を追加する
+	assert.Equal(t, []string{"xa", " b"}, c.GetAllLines())
+
+	// 通常のコピーは矩形として貼り付けない
+	c.SetClipboard([]string{"a", "b"})
+	assert.False(t, c.ClipboardIsBlock())
+}
+
+func TestBlockSelection_KeyBinding(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abcd", "efgh"},
+		ctrlKey(key.KeyCtrlK), char('B'), special(key.KeyArrowDown), special(key.KeyArrowRight), special(key.KeyArrowRight),
+		ctrlKey(key.KeyCtrlK), char('c'),
+	)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	assert.True(t, controller.selection.active && controller.selection.block, "C-k B must start a block selection")
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"ab", "ef"}, c.Clipboard())
+	assert.True(t, c.ClipboardIsBlock())
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/boundary/bookmarkfile"
+	"github.com/wasya-io/go-kilo/app/entity/bookmark"
+)
+
+// Bookmarks は現在のバッファのブックマークを返します。
+func (c *Controller) Bookmarks() *bookmark.Bookmarks {
+	return c.bookmarks
+}
+
+// SetBookmarkStore はブックマークの入出力先を差し替えます（主にテスト用）
+func (c *Controller) SetBookmarkStore(store bookmarkfile.Store) {
+	c.bookmarkStore = store
+}
+
+// toggleBookmark はカーソル行のブックマークを切り替える
+func (c *Controller) toggleBookmark() {
+	line := c.screen.GetCursor().Row()
+	if c.bookmarks.Toggle(line, "") {
+		c.setStatusMessage("Bookmark set at line %d", line+1)
+	} else {
+		c.setStatusMessage("Bookmark removed from line %d", line+1)
+	}
+}
+
+// ExportBookmarks は現在のバッファのブックマークを dest に書き出します。
+// 書き出し形式は "path:line:label" で、チームメンバー間で共有できます。
+func (c *Controller) ExportBookmarks(dest string) error {
+	marks := c.bookmarks.List()
+	if err := c.bookmarkStore.Export(dest, c.fileManager.GetFilename(), marks); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to export bookmarks: %v", err))
+		return fmt.Errorf("failed to export bookmarks: %w", err)
+	}
+	c.setStatusMessage("Exported %d bookmarks to %s", len(marks), dest)
+	return nil
+}
+
+// ImportBookmarks は src から現在のファイルに対するブックマークを読み込みます。
+// 既存のブックマークは保持したまま、読み込んだものを追加します。
+func (c *Controller) ImportBookmarks(src string) (int, error) {
+	marks, err := c.bookmarkStore.Import(src, c.fileManager.GetFilename())
+	if err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to import bookmarks: %v", err))
+		return 0, fmt.Errorf("failed to import bookmarks: %w", err)
+	}
+	lineCount := c.contents.GetLineCount()
+	imported := 0
+	for _, m := range marks {
+		if m.Line >= lineCount {
+			continue
+		}
+		c.bookmarks.Set(m.Line, m.Label)
+		imported++
+	}
+	c.setStatusMessage("Imported %d bookmarks from %s", imported, src)
+	return imported, nil
+}
@@ -0,0 +1,35 @@
+package controller_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookmarkExportImport(t *testing.T) {
+	ctrl, mockFM, mockWriter, eventBus := setupController(t)
+	defer eventBus.Shutdown()
+	eventBus.SetSynchronous(true)
+
+	mockFM.EXPECT().GetFilename().Return("test.txt").AnyTimes()
+	mockWriter.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+
+	ctrl.Bookmarks().Set(0, "first")
+	ctrl.Bookmarks().Set(1, "second")
+
+	dest := filepath.Join(t.TempDir(), "marks.txt")
+	assert.NoError(t, ctrl.ExportBookmarks(dest))
+
+	data, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, "test.txt:1:first\ntest.txt:2:second\n", string(data))
+
+	ctrl.Bookmarks().Clear()
+	n, err := ctrl.ImportBookmarks(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, ctrl.Bookmarks().Has(1))
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"unicode"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/word"
+)
+
+// autoPairs は入力すると閉じる文字を補う開き括弧と引用符
+var autoPairs = map[rune]rune{'(': ')', '[': ']', '{': '}', '"': '"', '\'': '\'', '`': '`'}
+
+// closingBrackets は閉じ括弧と、対応する開き括弧
+var closingBrackets = map[rune]rune{')': '(', ']': '[', '}': '{'}
+
+// maxBracketScanLines は対応する括弧を探す行数の上限。大きなファイルで描画が遅くならないようにする
+const maxBracketScanLines = 2000
+
+// runeAt は line の x 文字目を返す。範囲外なら false を返す
+func runeAt(line []rune, x int) (rune, bool) {
+	if x < 0 || x >= len(line) {
+		return 0, false
+	}
+	return line[x], true
+}
+
+// skipClosingChar はカーソル位置の文字と同じ閉じ括弧・引用符を入力した場合に、入力せずにカーソルを進める
+// 補った閉じ括弧を打ち直しても二重にならない。進めた場合は true を返す
+func (c *Controller) skipClosingChar(pos contents.Position, ch rune) bool {
+	if !c.autoPair {
+		return false
+	}
+	if _, closer := closingBrackets[ch]; !closer && autoPairs[ch] != ch {
+		return false
+	}
+	if next, ok := runeAt([]rune(c.contents.GetContentLine(pos.Y)), pos.X); !ok || next != ch {
+		return false
+	}
+	c.screen.SetCursorPosition(pos.X+1, pos.Y)
+	return true
+}
+
+// insertPair は開き括弧・引用符を入力した場合に閉じる文字を補い、カーソルをその間に置く
+// 直後に単語が続く場合や、引用符が単語の直後（don't など）の場合は補わない。補った場合は true を返す
+func (c *Controller) insertPair(pos contents.Position, ch rune) bool {
+	closer, ok := autoPairs[ch]
+	if !c.autoPair || !ok {
+		return false
+	}
+	line := []rune(c.contents.GetContentLine(pos.Y))
+	if next, ok := runeAt(line, pos.X); ok && !unicode.IsSpace(next) && !isCloser(next) {
+		return false
+	}
+	if prev, ok := runeAt(line, pos.X-1); ok && closer == ch && (word.IsWordRune(prev) || prev == ch) {
+		return false
+	}
+	text := string([]rune{ch, closer})
+	if err := c.contents.InsertChars(pos, []rune(text)); err != nil {
+		c.reportEditError(err)
+		return true
+	}
+	c.recordEdit(pos, nil, []string{text})
+	c.screen.SetCursorPosition(pos.X+1, pos.Y)
+	return true
+}
+
+// deletePair はカーソルが空の括弧・引用符の組の間（例: (|)）にある場合に、組をまとめて削除する
+// 1回の取り消しで元に戻せる。組の間でなければ false を返す
+func (c *Controller) deletePair(pos contents.Position) bool {
+	if !c.autoPair {
+		return false
+	}
+	line := []rune(c.contents.GetContentLine(pos.Y))
+	prev, ok := runeAt(line, pos.X-1)
+	if !ok {
+		return false
+	}
+	if next, ok := runeAt(line, pos.X); !ok || autoPairs[prev] != next {
+		return false
+	}
+	c.replaceRange(contents.Position{X: pos.X - 1, Y: pos.Y}, contents.Position{X: pos.X + 1, Y: pos.Y}, nil)
+	return true
+}
+
+// isCloser は ch が閉じ括弧か引用符かを返す
+func isCloser(ch rune) bool {
+	_, ok := closingBrackets[ch]
+	return ok || autoPairs[ch] == ch
+}
+
+// updateBracketMatch はカーソル位置（括弧でなければ直前）の括弧と、対応する括弧を強調表示する
+func (c *Controller) updateBracketMatch() {
+	pos := c.screen.GetCursor().ToPosition()
+	line := []rune(c.contents.GetContentLine(pos.Y))
+	for _, x := range []int{pos.X, pos.X - 1} {
+		ch, ok := runeAt(line, x)
+		if !ok || !isBracket(ch) {
+			continue
+		}
+		at := contents.Position{X: x, Y: pos.Y}
+		if match, ok := c.matchBracket(at, ch); ok {
+			c.screen.SetBracketMatch([]contents.Position{at, match})
+			return
+		}
+		break
+	}
+	c.screen.SetBracketMatch(nil)
+}
+
+// isBracket は ch が括弧かどうかを返す
+func isBracket(ch rune) bool {
+	_, opener := bracketPairs[ch]
+	_, closer := closingBrackets[ch]
+	return opener || closer
+}
+
+// matchBracket は at にある括弧 ch に対応する括弧の位置を返す
+// 開き括弧なら後ろへ、閉じ括弧なら前へ入れ子を数えながら探す。見つからなければ false を返す
+func (c *Controller) matchBracket(at contents.Position, ch rune) (contents.Position, bool) {
+	pair, step := bracketPairs[ch], 1
+	if opener, ok := closingBrackets[ch]; ok {
+		pair, step = opener, -1
+	}
+
+	depth := 0
+	x := at.X
+	for y, scanned := at.Y, 0; y >= 0 && y < c.contents.GetLineCount() && scanned < maxBracketScanLines; y, scanned = y+step, scanned+1 {
+		line := []rune(c.contents.GetContentLine(y))
+		if y != at.Y {
+			x = 0
+			if step < 0 {
+				x = len(line) - 1
+			}
+		}
+		for ; x >= 0 && x < len(line); x += step {
+			switch line[x] {
+			case ch:
+				depth++
+			case pair:
+				depth--
+				if depth == 0 {
+					return contents.Position{X: x, Y: y}, true
+				}
+			}
+		}
+	}
+	return contents.Position{}, false
+}
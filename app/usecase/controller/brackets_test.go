@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestAutoPair_InsertAndSkipOver(t *testing.T) {
+	events := append(typeString("f(\"a\")"), ctrlKey(key.KeyCtrlZ))
+	controller, c := newKeyInputController(t, []string{""}, events...)
+
+	for range events[:6] {
+		assert.NoError(t, controller.Process())
+	}
+	// 補った閉じる文字を打ち直しても二重にならない
+	assert.Equal(t, []string{`f("a")`}, c.GetAllLines())
+	assert.Equal(t, 6, controller.screen.GetCursor().ToPosition().X)
+
+	// 補った組の中の入力だけを取り消す
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{`f("")`}, c.GetAllLines())
+}
+
+func TestAutoPair_OnlyBeforeSpaceOrCloser(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"dont x"})
+
+	// 単語の直後の引用符は補わない
+	controller.screen.SetCursorPosition(3, 0)
+	controller.performInsertChar('\'')
+	assert.Equal(t, "don't x", c.GetContentLine(0))
+
+	// 直後に単語が続く場合は補わない
+	controller.screen.SetCursorPosition(6, 0)
+	controller.performInsertChar('(')
+	assert.Equal(t, "don't (x", c.GetContentLine(0))
+
+	controller.autoPair = false
+	controller.screen.SetCursorPosition(8, 0)
+	controller.performInsertChar('[')
+	assert.Equal(t, "don't (x[", c.GetContentLine(0))
+}
+
+func TestAutoPair_BackspaceDeletesEmptyPair(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"x = []", "(a)"},
+		special(key.KeyBackspace), ctrlKey(key.KeyCtrlZ))
+	controller.screen.SetCursorPosition(5, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"x = ", "(a)"}, c.GetAllLines())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"x = []", "(a)"}, c.GetAllLines())
+}
+
+func TestBracketMatch(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"f(a[0],", "  {b})", "x)"})
+
+	match, ok := controller.matchBracket(contents.Position{X: 1, Y: 0}, '(')
+	assert.True(t, ok)
+	assert.Equal(t, contents.Position{X: 5, Y: 1}, match)
+
+	// 閉じ括弧は前へ入れ子を数えながら探す
+	match, ok = controller.matchBracket(contents.Position{X: 4, Y: 1}, '}')
+	assert.True(t, ok)
+	assert.Equal(t, contents.Position{X: 2, Y: 1}, match)
+
+	// 対応する括弧がなければ強調しない
+	_, ok = controller.matchBracket(contents.Position{X: 1, Y: 2}, ')')
+	assert.False(t, ok)
+}
@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// maxTabWidth は set tabwidth で指定できるタブ幅の上限（設定ファイルと同じ）
+const maxTabWidth = 16
+
+// maxShownCandidates はプロンプトの後ろに並べる補完候補の数
+const maxShownCandidates = 8
+
+// CommandLine は「コマンド名 引数...」の形式でコマンドを入力させて実行する
+// コマンド名と、ファイル名などの引数は Tab で補完できる。行番号だけを入力するとその行へ移動する
+func (c *Controller) CommandLine() error {
+	input, ok, err := c.promptCompleted(":", false, c.commands.Complete)
+	if err != nil || !ok {
+		return err
+	}
+	return c.runCommandLine(input)
+}
+
+// runCommandLine はコマンド行を解釈して実行する
+// !CMD・r!CMD・|CMD とシェルのコマンドは、残りを区切らずにシェルへ渡す
+func (c *Controller) runCommandLine(line string) error {
+	if name, command, ok := shellCommandLine(line); ok {
+		return c.commands.Execute(name, []string{command})
+	}
+	name, args, err := command.ParseLine(line)
+	if err != nil {
+		c.setStatusMessage("%v: %s", err, line)
+		return nil
+	}
+	if name == "" {
+		return nil
+	}
+	if _, _, err := parseLineCol(name); err == nil && len(args) == 0 {
+		return c.gotoLineCommand([]string{name})
+	}
+	err = c.commands.Execute(name, args)
+	if errors.Is(err, command.ErrUnknownCommand) {
+		c.setStatusMessage("Unknown command: %s", name)
+		return nil
+	}
+	return err
+}
+
+// registerAliases はコマンド行で使う短い別名を登録する
+func (c *Controller) registerAliases() {
+	c.commands.Alias("w", "save")
+	c.commands.Alias("q", "quit")
+	c.commands.Alias("q!", "force-quit")
+	c.commands.Alias("e", "open-file")
+	c.commands.Alias("open", "open-file")
+	c.commands.Alias("goto", "goto-line")
+}
+
+// completionSummary は補完候補をプロンプトの後ろに表示する形に並べる
+// 候補はコマンド行全体の形なので、最後の語だけを表示する
+func completionSummary(candidates []string) string {
+	shown := make([]string, 0, min(len(candidates), maxShownCandidates))
+	for _, c := range candidates[:min(len(candidates), maxShownCandidates)] {
+		shown = append(shown, c[strings.LastIndexAny(c, " \t")+1:])
+	}
+	summary := strings.Join(shown, " ")
+	if len(candidates) > maxShownCandidates {
+		summary += fmt.Sprintf(" ... (%d)", len(candidates))
+	}
+	return summary
+}
+
+// completePath は入力途中のパスを補完した候補を返す。ディレクトリには / を付ける
+// 隠しファイルは入力が . で始まる場合だけ候補にする
+func completePath(partial string) []string {
+	dir, base := filepath.Split(partial)
+	entries, err := os.ReadDir(filepath.Join(".", dir))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if e.IsDir() {
+			name += string(filepath.Separator)
+		}
+		paths = append(paths, dir+name)
+	}
+	return paths
+}
+
+// setting は set コマンドで変更できる項目
+type setting struct {
+	name    string
+	boolean bool // on / off の項目。"name" で有効に、"noname" で無効にできる
+	get     func() string
+	set     func(value string) error
+}
+
+// settings は set コマンドで変更できる項目の一覧を返す
+func (c *Controller) settings() []setting {
+	return []setting{
+		{
+			name: "tabwidth",
+			get:  func() string { return strconv.Itoa(c.tabWidth) },
+			set: func(value string) error {
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 || n > maxTabWidth {
+					return fmt.Errorf("tabwidth must be 1-%d", maxTabWidth)
+				}
+				c.tabWidth = n
+				return nil
+			},
+		},
+		{
+			name:    "wrap",
+			boolean: true,
+			get:     func() string { return onOff(c.screen.Wrap()) },
+			set: func(value string) error {
+				wrap, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+				c.screen.SetWrap(wrap)
+				c.updateScroll()
+				return nil
+			},
+		},
+		{
+			name:    "autopair",
+			boolean: true,
+			get:     func() string { return onOff(c.autoPair) },
+			set: func(value string) error {
+				autoPair, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+				c.autoPair = autoPair
+				return nil
+			},
+		},
+		{
+			name:    "readonly",
+			boolean: true,
+			get:     func() string { return onOff(c.contents.ReadOnly()) },
+			set: func(value string) error {
+				readOnly, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+				c.contents.SetReadOnly(readOnly)
+				return nil
+			},
+		},
+	}
+}
+
+// onOff は真偽値を set コマンドで表示する形にする
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// parseOnOff は on / off や true / false などの値を真偽値にする
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("expected on or off: %s", value)
+	}
+	return b, nil
+}
+
+// setCommand は "name=value" の形式で指定した項目を変更する
+// on / off の項目は "name" で有効に、"noname" で無効にできる。引数がなければ現在の値を表示する
+func (c *Controller) setCommand(args []string) error {
+	settings := c.settings()
+	if len(args) == 0 {
+		values := make([]string, 0, len(settings))
+		for _, s := range settings {
+			values = append(values, s.name+"="+s.get())
+		}
+		c.setStatusMessage("%s", strings.Join(values, " "))
+		return nil
+	}
+
+	var changed []string
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		s, ok := findSetting(settings, name)
+		if !ok && !hasValue {
+			if s, ok = findSetting(settings, strings.TrimPrefix(name, "no")); ok && s.boolean && strings.HasPrefix(name, "no") {
+				value, hasValue = "off", true
+			}
+		}
+		switch {
+		case !ok:
+			c.setStatusMessage("Unknown setting: %s", name)
+			return nil
+		case !hasValue && !s.boolean:
+			c.setStatusMessage("%s=%s", s.name, s.get())
+			return nil
+		case !hasValue:
+			value = "on"
+		}
+		if err := s.set(value); err != nil {
+			c.setStatusMessage("Invalid value for %s: %v", s.name, err)
+			return nil
+		}
+		changed = append(changed, s.name+"="+s.get())
+	}
+	c.setStatusMessage("%s", strings.Join(changed, " "))
+	c.eventBus.Publish(event.NewRefreshEvent())
+	return nil
+}
+
+// findSetting は名前から項目を探す
+func findSetting(settings []setting, name string) (setting, bool) {
+	for _, s := range settings {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// completeSetting は set コマンドの引数を補完した候補を返す
+func (c *Controller) completeSetting(partial string) []string {
+	var names []string
+	for _, s := range c.settings() {
+		candidates := []string{s.name + "="}
+		if s.boolean {
+			candidates = []string{s.name, "no" + s.name}
+		}
+		for _, name := range candidates {
+			if strings.HasPrefix(name, partial) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// commandLine は M-: でコマンド行を開き、line を入力して確定するキー操作を返す
+func commandLine(line string) []key.KeyEvent {
+	events := []key.KeyEvent{{Type: key.KeyEventChar, Rune: ':', Modifiers: key.ModAlt}}
+	events = append(events, typeString(line)...)
+	return append(events, special(key.KeyEnter))
+}
+
+func TestCommandLine_SetAndGoto(t *testing.T) {
+	var events []key.KeyEvent
+	events = append(events, commandLine("set tabwidth=2 wrap")...)
+	events = append(events, commandLine("goto 3")...)
+	events = append(events, special(key.KeyTab))
+	events = append(events, commandLine("2")...)
+	controller, c := newKeyInputController(t, []string{"one", "two", "three"}, events...)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 2, controller.tabWidth)
+	assert.True(t, controller.screen.Wrap())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().Y)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "  three", c.GetContentLine(2))
+
+	// 行番号だけを入力するとその行へ移動する
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+}
+
+func TestCommandLine_Completion(t *testing.T) {
+	events := []key.KeyEvent{{Type: key.KeyEventChar, Rune: ':', Modifiers: key.ModAlt}}
+	events = append(events, typeString("set tabw")...)
+	events = append(events, special(key.KeyTab), char('3'), special(key.KeyEnter))
+	// 複数の候補は共通する部分まで補う
+	events = append(events, ctrlKey(key.KeyCtrlK), char(':'))
+	events = append(events, typeString("tog")...)
+	events = append(events, special(key.KeyTab))
+	events = append(events, typeString("read-only")...)
+	events = append(events, special(key.KeyEnter))
+	controller, c := newKeyInputController(t, []string{"one"}, events...)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 3, controller.tabWidth)
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, c.ReadOnly())
+}
+
+func TestCommandLine_InvalidInputKeepsState(t *testing.T) {
+	var events []key.KeyEvent
+	events = append(events, commandLine("set tabwidth=99")...)
+	events = append(events, commandLine("set bogus")...)
+	events = append(events, commandLine("no-such-command")...)
+	events = append(events, commandLine(`open "unterminated`)...)
+	controller, c := newKeyInputController(t, []string{"one"}, events...)
+	width := controller.tabWidth
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	assert.Equal(t, width, controller.tabWidth)
+	assert.Equal(t, "one", c.GetContentLine(0))
+}
+
+func TestCommandLine_ForceQuit(t *testing.T) {
+	events := []key.KeyEvent{char('x')}
+	events = append(events, commandLine("q!")...)
+	controller, c := newKeyInputController(t, []string{"one"}, events...)
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, c.IsDirty())
+	assert.NoError(t, controller.Process())
+	assert.True(t, controller.isQuitChannelClosed(), "q! must quit without asking about unsaved changes")
+}
+
+func TestCompletionSummary(t *testing.T) {
+	assert.Equal(t, "a.go b.go", completionSummary([]string{"open a.go", "open b.go"}))
+	many := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+	assert.Equal(t, "a b c d e f g h ... (9)", completionSummary(many))
+}
+
+func TestPrompt_EditsAtCursor(t *testing.T) {
+	events := []key.KeyEvent{{Type: key.KeyEventChar, Rune: ':', Modifiers: key.ModAlt}}
+	events = append(events, typeString("set tabwidth=2")...)
+	events = append(events, special(key.KeyArrowLeft), char('1'), special(key.KeyArrowRight), special(key.KeyArrowRight))
+	events = append(events, special(key.KeyEnter))
+	controller, _ := newKeyInputController(t, []string{"one"}, events...)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 12, controller.tabWidth)
+	assert.False(t, controller.screen.Prompting(), "the prompt must be closed after Enter")
+}
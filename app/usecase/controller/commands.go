@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/keymap"
+	"github.com/wasya-io/go-kilo/app/entity/word"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// Commands はエディタのコマンド一覧を返す
+func (c *Controller) Commands() *command.Registry {
+	return c.commands
+}
+
+// Keymap はキー割り当てを返す
+func (c *Controller) Keymap() *keymap.Keymap {
+	return c.keymap
+}
+
+// registerCommands は組み込みコマンドを登録する
+// コマンドが返すエラーは入力の読み取り失敗などメインループを止めるべきものに限り、
+// 利用者の操作ミスはステータスメッセージで知らせる
+func (c *Controller) registerCommands() {
+	simple := func(f func()) func([]string) error {
+		return func([]string) error {
+			f()
+			return nil
+		}
+	}
+
+	for _, cmd := range []command.Command{
+		{Name: "save", Description: "Save the buffer (optionally to the given file)", Run: c.saveCommand, Complete: completePath},
+		{Name: "quit", Description: "Quit the editor", Run: simple(func() { c.PublishQuitEvent(false) })},
+		{Name: "force-quit", Description: "Quit the editor without saving changes", Run: simple(func() { c.PublishQuitEvent(true) })},
+		{Name: "suspend", Description: "Suspend the editor and return to the shell (resume with fg)", Run: simple(c.Suspend)},
+		{Name: "toggle-bookmark", Description: "Toggle a bookmark on the cursor line", Run: simple(c.toggleBookmark)},
+		{Name: "goto-bookmark", Description: "Jump to the n-th bookmark (0 is the 10th)", Run: c.gotoBookmarkCommand},
+		{Name: "export-bookmarks", Description: "Export bookmarks to a file", Run: c.exportBookmarksCommand, Complete: completePath},
+		{Name: "import-bookmarks", Description: "Import bookmarks from a file", Run: c.importBookmarksCommand, Complete: completePath},
+		{Name: "complete-word", Description: "Complete the word before the cursor", Run: simple(c.completeWord)},
+		{Name: "goto-definition", Description: "Jump to the definition of the identifier under the cursor", Run: simple(c.jumpToDefinition)},
+		{Name: "lsp-complete", Description: "Complete at the cursor with the language server", Run: func([]string) error { return c.lspComplete() }},
+		{Name: "next-diagnostic", Description: "Move to the next diagnostic reported by the language server", Run: simple(c.nextDiagnostic)},
+		{Name: "show-diagnostics", Description: "List the diagnostics reported by the language server", Run: func([]string) error { return c.ShowDiagnostics() }},
+		{Name: "spell-suggest", Description: "Pick a spelling suggestion for the word under the cursor", Run: func([]string) error { return c.spellSuggest() }},
+		{Name: "show-godoc", Description: "Show go doc for the identifier under the cursor", Run: simple(c.showGoDoc)},
+		{Name: "undo", Description: "Undo the last edit", Run: simple(c.undo)},
+		{Name: "redo", Description: "Redo the last undone edit", Run: simple(c.redo)},
+		{Name: "transpose-chars", Description: "Swap the characters around the cursor", Run: simple(c.transposeChars)},
+		{Name: "transpose-lines", Description: "Swap the current line with the previous one", Run: simple(c.transposeLines)},
+		{Name: "delete-word", Description: "Delete to the end of the next word", Run: simple(c.deleteWord)},
+		{Name: "delete-line", Description: "Delete the whole current line and keep it in the clipboard", Run: simple(c.killLine)},
+		{Name: "duplicate-line", Description: "Insert a copy of the current line below it", Run: simple(c.duplicateLine)},
+		{Name: "move-line-up", Description: "Move the current line up by one line", Run: simple(c.moveLineUp)},
+		{Name: "move-line-down", Description: "Move the current line down by one line", Run: simple(c.moveLineDown)},
+		{Name: "join-line", Description: "Join the next line to the end of the current line", Run: simple(c.joinLine)},
+		{Name: "delete-forward", Description: "Delete the character under the cursor, joining the next line at the end of a line", Run: simple(c.deleteForward)},
+		{Name: "begin-macro", Description: "Start recording a keyboard macro", Run: simple(c.beginMacro)},
+		{Name: "end-macro", Description: "Stop recording the keyboard macro", Run: simple(c.endMacro)},
+		{Name: "run-macro", Description: "Replay the last keyboard macro", Run: simple(c.runMacro)},
+		{Name: "project-replace", Description: "Find and replace across files", Run: func([]string) error { return c.ProjectReplace() }},
+		{Name: "dismiss-message", Description: "Close the status message, cancel the selection and end the search", Run: simple(c.dismissMessage)},
+		{Name: "toggle-read-only", Description: "Lock or unlock the buffer against edits", Run: simple(c.toggleReadOnly)},
+		{Name: "toggle-selection", Description: "Start or cancel selecting text at the cursor", Run: simple(c.toggleSelection)},
+		{Name: "toggle-block-selection", Description: "Start or cancel a rectangular selection at the cursor", Run: simple(c.toggleBlockSelection)},
+		{Name: "copy", Description: "Copy the selection to the clipboard", Run: simple(c.copySelection)},
+		{Name: "cut", Description: "Cut the selection to the clipboard", Run: simple(c.cutSelection)},
+		{Name: "paste", Description: "Paste the clipboard at the cursor", Run: simple(c.paste)},
+		{Name: "search", Description: "Search the buffer incrementally", Run: func([]string) error { return c.IncrementalSearch() }},
+		{Name: "search-next", Description: "Move to the next match of the last search", Run: simple(func() { c.searchNext(true) })},
+		{Name: "search-previous", Description: "Move to the previous match of the last search", Run: simple(func() { c.searchNext(false) })},
+		{Name: "goto-line", Description: "Move the cursor to the given line (line or line:col)", Run: c.gotoLineCommand},
+		{Name: "select-file-type", Description: "Choose the file type shown in the status bar", Run: func([]string) error { return c.SelectFileType() }},
+		{Name: "toggle-word-wrap", Description: "Wrap long lines instead of scrolling horizontally", Run: simple(c.toggleWordWrap)},
+		{Name: "replace", Description: "Find and replace in the buffer, confirming each match", Run: func([]string) error { return c.Replace() }},
+		{Name: "open-url", Description: "Open the URL under the cursor in the default application", Run: simple(c.openURL)},
+		{Name: "shell", Description: "Run a shell command on the terminal and show its output (:!CMD)", Run: c.shellCommand},
+		{Name: "shell-insert", Description: "Insert the output of a shell command at the cursor (:r!CMD)", Run: c.shellInsertCommand},
+		{Name: "shell-filter", Description: "Replace the selection with the output of a shell command fed with it (:|CMD)", Run: c.shellFilterCommand},
+		{Name: "build", Description: "Run a build command from .go-kilo.toml (default if omitted)", Run: c.buildCommand},
+		{Name: "split-window", Description: "Split the screen into upper and lower windows", Run: simple(c.splitWindow)},
+		{Name: "split-window-right", Description: "Split the screen into left and right windows", Run: simple(c.splitWindowRight)},
+		{Name: "other-window", Description: "Move the focus to the other window", Run: simple(c.otherWindow)},
+		{Name: "close-other-windows", Description: "Close all windows except the focused one", Run: simple(c.closeOtherWindows)},
+		{Name: "zoom-window", Description: "Show only the focused window, or restore the split", Run: simple(c.zoomWindow)},
+		{Name: "enlarge-window", Description: "Make the focused window larger", Run: simple(c.enlargeWindow)},
+		{Name: "shrink-window", Description: "Make the focused window smaller", Run: simple(c.shrinkWindow)},
+		{Name: "open-file", Description: "Open a file in the focused window", Run: c.openFileCommand, Complete: completePath},
+		{Name: "file-browser", Description: "Pick a file to open from a directory listing", Run: func([]string) error { return c.FileBrowser() }},
+		{Name: "find-file", Description: "Open a file under the working directory by fuzzy-matching its path", Run: func([]string) error { return c.FindFile() }},
+		{Name: "set-line-ending", Description: "Convert the line endings of the buffer (lf or crlf; toggles if omitted)", Run: c.setLineEndingCommand},
+		{Name: "set-encoding", Description: "Convert the buffer to another encoding on save (shows the current one if omitted)", Run: c.setEncodingCommand, Complete: completeEncoding},
+		{Name: "reopen-with-encoding", Description: "Discard the changes and reload the file decoded with the given encoding", Run: c.reopenWithEncodingCommand, Complete: completeEncoding},
+		{Name: "next-buffer", Description: "Show the next open buffer in the focused window", Run: simple(c.nextBuffer)},
+		{Name: "previous-buffer", Description: "Show the previous open buffer in the focused window", Run: simple(c.previousBuffer)},
+		{Name: "close-buffer", Description: "Close the buffer shown in the focused window", Run: simple(c.closeBuffer)},
+		{Name: "revert-buffer", Description: "Discard the changes and reload the file from disk", Run: func([]string) error { return c.revertBuffer() }},
+		{Name: "keep-buffer", Description: "Ignore changes made to the file on disk and keep the buffer", Run: simple(c.keepBuffer)},
+		{Name: "diff-disk", Description: "Show the differences between the buffer and the file on disk", Run: func([]string) error { return c.diffDisk() }},
+		{Name: "replace-all", Description: "Replace every occurrence in the buffer after previewing the changes", Run: c.replaceAllCommand, Preview: c.previewReplaceAll},
+		{Name: "format-buffer", Description: "Format the buffer with the save hooks after previewing the changes", Run: func([]string) error { return c.formatBuffer() }, Preview: c.previewFormatBuffer},
+		{Name: "dry-run", Description: "Show what a command would change without running it", Run: c.dryRunCommand},
+		{Name: "show-key-conflicts", Description: "List key bindings that shadow each other and where each one is defined", Run: simple(c.showKeyConflicts)},
+		{Name: "memory-stats", Description: "Show memory usage and the caches held by each buffer", Run: simple(c.showMemoryStats)},
+		{Name: "toggle-event-trace", Description: "Start recording events on the event bus, or stop and write the trace", Run: simple(c.toggleEventTrace)},
+		{Name: "command-palette", Description: "Search and run a command by name", Run: func([]string) error { return c.CommandPalette() }},
+		{Name: "command-line", Description: "Type a command with arguments (e.g. open FILE, set tabwidth=2)", Run: func([]string) error { return c.CommandLine() }},
+		{Name: "set", Description: "Change an editor setting (tabwidth=N, wrap/nowrap, readonly/noreadonly)", Run: c.setCommand, Complete: c.completeSetting},
+		{Name: "browse-recovery", Description: "List recovery files of unsaved changes", Run: func([]string) error { return c.BrowseRecovery() }},
+	} {
+		c.commands.Register(cmd)
+	}
+	c.registerAliases()
+}
+
+// bindDefaultKeys は既定のキー割り当てを登録する
+func (c *Controller) bindDefaultKeys() {
+	global := []keymap.Binding{
+		{Key: key.KeyCtrlS.Name(), Command: "save", Description: "save"},
+		{Key: key.KeyCtrlX.Name(), Command: "quit", Description: "quit"},
+		{Key: key.KeyCtrlC.Name(), Command: "quit", Description: "quit"},
+		{Key: key.KeyCtrlB.Name(), Command: "toggle-bookmark", Description: "bookmark"},
+		{Key: key.KeyCtrlN.Name(), Command: "complete-word", Description: "complete"},
+		{Key: key.KeyCtrlRightBracket.Name(), Command: "goto-definition", Description: "definition"},
+		{Key: key.KeyCtrlSpace.Name(), Command: "lsp-complete", Description: "complete (LSP)"},
+		{Key: "M-e", Command: "next-diagnostic", Description: "next diagnostic"},
+		{Key: "M-s", Command: "spell-suggest", Description: "spelling"},
+		{Key: key.KeyCtrlD.Name(), Command: "show-godoc", Description: "go doc"},
+		{Key: key.KeyCtrlF.Name(), Command: "search", Description: "search"},
+		{Key: "M-n", Command: "search-next", Description: "next match"},
+		{Key: "M-p", Command: "search-previous", Description: "previous match"},
+		{Key: key.KeyCtrlZ.Name(), Command: "undo", Description: "undo"},
+		{Key: key.KeyCtrlY.Name(), Command: "redo", Description: "redo"},
+		{Key: "M-d", Command: "delete-word", Description: "del word"},
+		{Key: key.KeyDelete.Name(), Command: "delete-forward", Description: "delete"},
+		{Key: key.KeyCtrlT.Name(), Command: "transpose-chars", Description: "transpose"},
+		{Key: key.KeyCtrlG.Name(), Command: "goto-line", Description: "go to line"},
+		{Key: key.KeyCtrlO.Name(), Command: "file-browser", Description: "open"},
+		{Key: key.KeyCtrlP.Name(), Command: "find-file", Description: "find file"},
+		{Key: "M-t", Command: "transpose-lines", Description: "transpose lines"},
+		{Key: "M-Up", Command: "move-line-up", Description: "move line up"},
+		{Key: "M-Down", Command: "move-line-down", Description: "move line down"},
+		{Key: "M-x", Command: "command-palette", Description: "commands"},
+		{Key: "M-:", Command: "command-line", Description: "command line"},
+		{Key: "M-%", Command: "replace", Description: "replace"},
+		{Key: "M-o", Command: "other-window", Description: "other window"},
+		{Key: "M-.", Command: "next-buffer", Description: "next buffer"},
+		{Key: "M-,", Command: "previous-buffer", Description: "previous buffer"},
+		{Key: "M-z", Command: "toggle-word-wrap", Description: "wrap"},
+		{Key: key.KeyEsc.Name(), Command: "dismiss-message", Description: "dismiss message"},
+	}
+	for _, b := range global {
+		c.keymap.Bind(keymap.LayerGlobal, b)
+	}
+
+	ctrlK := []keymap.Binding{
+		{Key: "s", Command: "save", Description: "save"},
+		{Key: "w", Command: "delete-word", Description: "del word"},
+		{Key: "b", Command: "begin-macro", Description: "begin macro"},
+		{Key: "e", Command: "end-macro", Description: "end macro"},
+		{Key: "x", Command: "run-macro", Description: "run macro"},
+	}
+	for n := 0; n <= 9; n++ {
+		ctrlK = append(ctrlK, keymap.Binding{
+			Key:         strconv.Itoa(n),
+			Command:     "goto-bookmark",
+			Args:        []string{strconv.Itoa(n)},
+			Description: "bookmark",
+		})
+	}
+	ctrlK = append(ctrlK,
+		keymap.Binding{Key: "r", Command: "project-replace", Description: "replace in files"},
+		keymap.Binding{Key: "E", Command: "export-bookmarks", Description: "export marks"},
+		keymap.Binding{Key: "I", Command: "import-bookmarks", Description: "import marks"},
+		keymap.Binding{Key: "R", Command: "browse-recovery", Description: "recovery"},
+		keymap.Binding{Key: "o", Command: "open-url", Description: "open URL"},
+		keymap.Binding{Key: "p", Command: "command-palette", Description: "commands"},
+		keymap.Binding{Key: ":", Command: "command-line", Description: "command line"},
+		keymap.Binding{Key: "m", Command: "toggle-selection", Description: "select"},
+		keymap.Binding{Key: "B", Command: "toggle-block-selection", Description: "select block"},
+		keymap.Binding{Key: "l", Command: "toggle-read-only", Description: "lock"},
+		keymap.Binding{Key: "-", Command: "split-window", Description: "split"},
+		keymap.Binding{Key: "|", Command: "split-window-right", Description: "split right"},
+		keymap.Binding{Key: "n", Command: "other-window", Description: "other window"},
+		keymap.Binding{Key: "u", Command: "close-other-windows", Description: "unsplit"},
+		keymap.Binding{Key: "f", Command: "open-file", Description: "open file"},
+		keymap.Binding{Key: "q", Command: "close-buffer", Description: "close buffer"},
+		keymap.Binding{Key: "z", Command: "suspend", Description: "suspend"},
+		keymap.Binding{Key: "c", Command: "copy", Description: "copy"},
+		keymap.Binding{Key: "k", Command: "cut", Description: "cut"},
+		keymap.Binding{Key: "v", Command: "paste", Description: "paste"},
+		keymap.Binding{Key: "d", Command: "delete-line", Description: "del line"},
+		keymap.Binding{Key: "D", Command: "duplicate-line", Description: "dup line"},
+		keymap.Binding{Key: "j", Command: "join-line", Description: "join"},
+		keymap.Binding{Key: "!", Command: "show-diagnostics", Description: "diagnostics"},
+	)
+	for _, b := range ctrlK {
+		c.keymap.Bind(keymap.LayerCtrlK, b)
+	}
+
+	ctrlW := []keymap.Binding{
+		{Key: ">", Command: "enlarge-window", Description: "enlarge"},
+		{Key: "<", Command: "shrink-window", Description: "shrink"},
+		{Key: "z", Command: "zoom-window", Description: "zoom"},
+	}
+	for _, b := range ctrlW {
+		c.keymap.Bind(keymap.LayerCtrlW, b)
+	}
+}
+
+// runBinding はキー割り当てに対応するコマンドを実行する
+// sequence は割り当てを呼び出したキー操作の数（マクロ記録から除くため）
+func (c *Controller) runBinding(b keymap.Binding, sequence int) error {
+	c.lastSequence = sequence
+	err := c.commands.Execute(b.Command, b.Args)
+	if errors.Is(err, command.ErrUnknownCommand) {
+		c.setStatusMessage("Unknown command: %s", b.Command)
+		return nil
+	}
+	return err
+}
+
+// handlePrefix はプレフィックスキーの後に続くキーを読み取り、layer の割り当てを実行する
+// 待機中は続けて押せるキーの一覧をメッセージバーに表示する
+func (c *Controller) handlePrefix(layer string) error {
+	c.setStatusMessage("%s: %s (Esc: cancel)", layer, keymap.Summary(c.keymap.Bindings(layer)))
+
+	ev, err := c.readEvent()
+	if err != nil {
+		return err
+	}
+	if ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc {
+		c.setStatusMessage("")
+		return nil
+	}
+
+	b, ok := c.keymap.Lookup(layer, ev.Name())
+	if !ok {
+		c.setStatusMessage("%s %s is not bound", layer, ev.Name())
+		return nil
+	}
+	c.setStatusMessage("")
+	return c.runBinding(b, 2)
+}
+
+// saveCommand はバッファを保存する。ファイル名がない場合は入力を求める
+func (c *Controller) saveCommand(args []string) error {
+	filename := c.fileManager.GetFilename()
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	if filename == "" {
+		var err error
+		filename, err = c.prompt("Save as: ")
+		if err != nil {
+			return err
+		}
+		if filename == "" {
+			c.setStatusMessage("Save aborted")
+			return nil
+		}
+	}
+	c.logger.Log("event", "Saving file")
+	c.waitForLoad()
+	if filename == c.fileManager.GetFilename() {
+		if ok, err := c.confirmDiskOverwrite(); err != nil || !ok {
+			return err
+		}
+	}
+	// 書き込み権限がなかった場合に続けて尋ねられるよう、保存が終わるのを待つ
+	if _, err := c.eventBus.PublishAndWaitResponse(event.NewSaveEvent(filename, false)); err != nil {
+		return err
+	}
+	if c.pendingElevatedSave != nil {
+		return c.promptElevatedSave()
+	}
+	return nil
+}
+
+// gotoBookmarkCommand は行順で n 番目のブックマークへ移動する
+func (c *Controller) gotoBookmarkCommand(args []string) error {
+	if len(args) == 0 {
+		c.setStatusMessage("Bookmark number is required")
+		return nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		c.setStatusMessage("Invalid bookmark number: %s", args[0])
+		return nil
+	}
+	if n == 0 {
+		n = 10
+	}
+	marks := c.bookmarks.List()
+	if n > len(marks) {
+		c.setStatusMessage("No bookmark %d (%d bookmarks)", n%10, len(marks))
+		return nil
+	}
+	c.eventBus.Publish(event.NewCursorSetEvent(marks[n-1].Line, 0))
+	c.setStatusMessage("Bookmark %d: line %d", n%10, marks[n-1].Line+1)
+	return nil
+}
+
+// pathArgument は引数のパスを返す。引数がない場合は入力を求める
+func (c *Controller) pathArgument(args []string, prompt string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	return c.prompt(prompt)
+}
+
+func (c *Controller) exportBookmarksCommand(args []string) error {
+	dest, err := c.pathArgument(args, "Export bookmarks to: ")
+	if err != nil || dest == "" {
+		return err
+	}
+	if err := c.ExportBookmarks(dest); err != nil {
+		c.setStatusMessage("%v", err)
+	}
+	return nil
+}
+
+func (c *Controller) importBookmarksCommand(args []string) error {
+	src, err := c.pathArgument(args, "Import bookmarks from: ")
+	if err != nil || src == "" {
+		return err
+	}
+	if _, err := c.ImportBookmarks(src); err != nil {
+		c.setStatusMessage("%v", err)
+	}
+	return nil
+}
+
+// deleteWord はカーソル位置から次の単語の末尾までを削除する
+func (c *Controller) deleteWord() {
+	pos := c.screen.GetCursor().ToPosition()
+	runes := []rune(c.contents.GetContentLine(pos.Y))
+	end := pos.X
+	for end < len(runes) && !word.IsWordRune(runes[end]) {
+		end++
+	}
+	for end < len(runes) && word.IsWordRune(runes[end]) {
+		end++
+	}
+	if end == pos.X {
+		return
+	}
+
+	// 単語の末尾へ移動してから後退削除することで、既存の削除処理を再利用する
+	c.eventBus.Publish(event.NewCursorSetEvent(pos.Y, end))
+	for i := pos.X; i < end; i++ {
+		c.eventBus.Publish(event.NewBufferEvent(event.BufferDelete, 0))
+	}
+}
+
+// beginMacro はキーボードマクロの記録を開始する
+func (c *Controller) beginMacro() {
+	c.macro.Start()
+	c.setStatusMessage("Recording macro...")
+}
+
+// endMacro はキーボードマクロの記録を終了する
+func (c *Controller) endMacro() {
+	if !c.macro.Recording() {
+		c.setStatusMessage("Not recording a macro")
+		return
+	}
+	n := c.macro.Stop(c.lastSequence)
+	c.setStatusMessage("Macro recorded (%d keys)", n)
+}
+
+// runMacro は最後に記録したマクロを再生する
+// 再生するイベントは入力待ちのイベントより先に処理される
+func (c *Controller) runMacro() {
+	if c.macro.Recording() {
+		c.setStatusMessage("Cannot run a macro while recording")
+		return
+	}
+	events := c.macro.Last()
+	if len(events) == 0 {
+		c.setStatusMessage("No macro recorded")
+		return
+	}
+	c.macroSource.PushFront(events...)
+	c.setStatusMessage("Running macro (%d keys)", len(events))
+}
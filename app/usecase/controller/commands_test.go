@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	mock_input "github.com/wasya-io/go-kilo/app/boundary/provider/input/mock"
+	mock_writer "github.com/wasya-io/go-kilo/app/boundary/writer/mock"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	mock_contents "github.com/wasya-io/go-kilo/app/entity/contents/mock"
+	mock_core "github.com/wasya-io/go-kilo/app/entity/core/mock"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+// newKeyInputController は指定したキー入力を順に返すコントローラーを作成する
+func newKeyInputController(t *testing.T, lines []string, events ...key.KeyEvent) (*Controller, *contents.Contents) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	mockFileManager := mock_filemanager.NewMockFileManager(ctrl)
+	mockInputProvider := mock_input.NewMockProvider(ctrl)
+	mockLogger := mock_core.NewMockLogger(ctrl)
+	mockWriter := mock_writer.NewMockScreenWriter(ctrl)
+	mockBuilder := mock_contents.NewMockBuilder(ctrl)
+
+	mockFileManager.EXPECT().GetFilename().Return("test.txt").AnyTimes()
+	mockLogger.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+	mockBuilder.EXPECT().Clear().AnyTimes()
+	mockBuilder.EXPECT().Write(gomock.Any()).AnyTimes()
+	mockBuilder.EXPECT().Build().Return("").AnyTimes()
+	mockWriter.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+
+	var calls []*gomock.Call
+	for _, ev := range events {
+		calls = append(calls, mockInputProvider.EXPECT().GetInputEvents().Return(ev, nil, nil))
+	}
+	gomock.InOrder(calls...)
+
+	eventBus := event.NewBus()
+	eventBus.SetSynchronous(true)
+	t.Cleanup(eventBus.Shutdown)
+
+	c := contents.NewContents(mockLogger)
+	c.LoadContent(lines)
+	scr := screen.NewScreen(mockBuilder, mockWriter, contents.NewMessage(""), cursor.NewCursor(), 24, 80)
+	controller := NewController(scr, c, mockFileManager, mockInputProvider, mockLogger, nil, eventBus)
+	controller.SetRefreshDelay(0)
+	return controller, c
+}
+
+func ctrlKey(k key.Key) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventControl, Key: k}
+}
+
+func char(r rune) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventChar, Rune: r}
+}
+
+func TestCtrlKPrefix_DeleteWord(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo  bar baz"},
+		ctrlKey(key.KeyCtrlK), char('w'),
+	)
+	controller.screen.SetCursorPosition(3, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo baz", c.GetContentLine(0))
+	assert.Equal(t, 3, controller.screen.GetCursor().ToPosition().X)
+}
+
+func TestAltKey_RunsBindingWithoutInserting(t *testing.T) {
+	altD := key.KeyEvent{Type: key.KeyEventChar, Rune: 'd', Modifiers: key.ModAlt}
+	altZ := key.KeyEvent{Type: key.KeyEventChar, Rune: 'z', Modifiers: key.ModAlt}
+	controller, c := newKeyInputController(t, []string{"foo  bar baz"}, altD, altZ)
+	controller.screen.SetCursorPosition(3, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo baz", c.GetContentLine(0))
+
+	// 割り当てのない Alt キーは文字として挿入されない
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo baz", c.GetContentLine(0))
+}
+
+func TestCtrlKPrefix_Cancel(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo"},
+		ctrlKey(key.KeyCtrlK), key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEsc},
+	)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo", c.GetContentLine(0))
+	assert.False(t, c.IsDirty())
+}
+
+func TestCtrlKPrefix_Macro(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{""},
+		ctrlKey(key.KeyCtrlK), char('b'),
+		char('a'), char('b'),
+		ctrlKey(key.KeyCtrlK), char('e'),
+		ctrlKey(key.KeyCtrlK), char('x'),
+	)
+
+	// 記録開始、2文字入力、記録終了、再生
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	// 再生したイベントを処理する
+	for controller.macroSource.Len() > 0 {
+		assert.NoError(t, controller.Process())
+	}
+	assert.Equal(t, "abab", c.GetContentLine(0))
+	assert.Len(t, controller.macro.Last(), 2)
+}
+
+func TestCtrlKPrefix_GotoBookmark(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a", "b", "c", "d"},
+		ctrlKey(key.KeyCtrlK), char('2'),
+	)
+	controller.bookmarks.Set(3, "")
+	controller.bookmarks.Set(1, "")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 3, controller.screen.GetCursor().ToPosition().Y)
+}
+
+func TestReadOnlyRegion_RejectsEditing(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"header", "body"},
+		char('x'), key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyBackspace},
+	)
+	c.ProtectLines(0, 1)
+
+	// 保護された行に入力した場合は変更せず、カーソルも動かさない
+	controller.screen.SetCursorPosition(3, 0)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "header", c.GetContentLine(0))
+	assert.Equal(t, 3, controller.screen.GetCursor().ToPosition().X)
+
+	// 保護された行への結合も拒否する
+	controller.screen.SetCursorPosition(0, 1)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"header", "body"}, c.GetAllLines())
+	assert.False(t, c.IsDirty())
+}
+
+func TestErrorMessage_PersistsUntilDismissed(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a"},
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEsc},
+	)
+	controller.ApplyConfig(&config.Config{StatusMessageDuration: 2})
+	assert.Equal(t, 2*time.Second, controller.screen.MessageDuration())
+
+	controller.setStatusMessage("File saved")
+	controller.ExpireStatusMessage(time.Now().Add(3 * time.Second))
+	assert.False(t, controller.screen.DismissMessage(), "normal messages expire after the configured duration")
+
+	controller.setErrorMessage("Failed to open %s", "x")
+	controller.ExpireStatusMessage(time.Now().Add(time.Hour))
+
+	// Esc で閉じる
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.screen.DismissMessage(), "Esc must dismiss the error message")
+}
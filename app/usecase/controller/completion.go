@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/tagsfile"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/tags"
+	"github.com/wasya-io/go-kilo/app/entity/word"
+	"github.com/wasya-io/go-kilo/app/usecase/completion"
+)
+
+// maxCompletionCandidates は一度に扱う補完候補の上限
+const maxCompletionCandidates = 50
+
+// recentWordsLimit は補完で優先する最近入力した単語の数
+const recentWordsLimit = 20
+
+// completionState は Ctrl-N による補完の巡回状態を保持する
+type completionState struct {
+	prefix     string
+	candidates []string
+	index      int // 現在挿入中の候補（len(candidates) の場合は元の入力に戻った状態）
+	inserted   int // 現在挿入されている補完部分のルーン数
+}
+
+// globalTimeout は GNU GLOBAL で定義を探す際の最大実行時間
+const globalTimeout = 10 * time.Second
+
+// tagsDir はタグファイルを探し始めるディレクトリ（開いているファイルのディレクトリ）を返す
+func (c *Controller) tagsDir() string {
+	if filename := c.fileManager.GetFilename(); filename != "" {
+		return filepath.Dir(filename)
+	}
+	return "."
+}
+
+// tagSource はプロジェクトのタグファイルを返す。見つからない場合は nil を返す
+// タグファイルは後から生成されることもあるため、見つかるまで毎回探索する
+func (c *Controller) tagSource() *tagsfile.Source {
+	path := tagsfile.Find(c.tagsDir())
+	if path == "" {
+		return nil
+	}
+	if c.tags == nil || c.tags.Path() != path {
+		c.tags = tagsfile.NewSource(path)
+	}
+	return c.tags
+}
+
+// newCompleter はバッファ内の単語とタグファイルを候補とする Completer を作成する
+// バッファ内の単語は最近入力したもの、カーソルに近いものの順に並べる
+func (c *Controller) newCompleter() *completion.Completer {
+	cursor := func() (int, int) {
+		pos := c.screen.GetCursor().ToPosition()
+		return pos.X, pos.Y
+	}
+	tagCandidates := completion.SourceFunc(func(prefix string) []string {
+		src := c.tagSource()
+		if src == nil {
+			return nil
+		}
+		index, err := src.Index()
+		if err != nil {
+			c.logger.Log("error", fmt.Sprintf("Failed to load tags: %v", err))
+			return nil
+		}
+		return index.Complete(prefix, maxCompletionCandidates)
+	})
+	return completion.NewCompleter(maxCompletionCandidates, completion.NearbyWords(c.contents.GetAllLines, cursor, c.typedWords), tagCandidates)
+}
+
+// rememberTypedWord は位置 (x, y) の直前で入力を終えた単語を最近入力した単語として記録する
+func (c *Controller) rememberTypedWord(x, y int) {
+	runes := []rune(c.contents.GetContentLine(y))
+	x = min(x, len(runes))
+	if start := word.PrefixStart(runes, x); start < x {
+		c.typedWords.Add(string(runes[start:x]))
+	}
+}
+
+// resetCompletion は補完の巡回状態を破棄する
+func (c *Controller) resetCompletion() {
+	c.completion = nil
+}
+
+// completeWord はカーソル直前の単語を補完する。連続して呼ばれた場合は次の候補に切り替える
+func (c *Controller) completeWord() {
+	if c.completion == nil {
+		pos := c.screen.GetCursor().ToPosition()
+		runes := []rune(c.contents.GetContentLine(pos.Y))
+		if pos.X > len(runes) {
+			pos.X = len(runes)
+		}
+		start := word.PrefixStart(runes, pos.X)
+		prefix := string(runes[start:pos.X])
+		if prefix == "" {
+			return
+		}
+		candidates := c.newCompleter().Complete(prefix)
+		if len(candidates) == 0 {
+			c.setStatusMessage("No completions for %q", prefix)
+			return
+		}
+		c.completion = &completionState{prefix: prefix, candidates: candidates, index: -1}
+	}
+
+	state := c.completion
+	// 前回挿入した補完部分を取り消す
+	for i := 0; i < state.inserted; i++ {
+		c.deleteChar()
+	}
+	state.inserted = 0
+
+	state.index = (state.index + 1) % (len(state.candidates) + 1)
+	if state.index == len(state.candidates) {
+		c.setStatusMessage("Back to original: %s", state.prefix)
+		return
+	}
+
+	suffix := []rune(state.candidates[state.index])[len([]rune(state.prefix)):]
+	for _, r := range suffix {
+		c.insertChar(r)
+	}
+	state.inserted = len(suffix)
+	c.setStatusMessage("Completion %d/%d", state.index+1, len(state.candidates))
+}
+
+// jumpToDefinition はカーソル下の識別子の定義位置へ移動する
+func (c *Controller) jumpToDefinition() {
+	pos := c.screen.GetCursor().ToPosition()
+	runes := []rune(c.contents.GetContentLine(pos.Y))
+	start, end, ok := word.At(runes, pos.X)
+	if !ok {
+		c.setStatusMessage("No identifier under cursor")
+		return
+	}
+	name := string(runes[start:end])
+
+	found, searched, err := c.findDefinitions(name)
+	if err != nil {
+		c.setErrorMessage("Failed to load tags: %v", err)
+		return
+	}
+	if !searched {
+		c.setStatusMessage("No tags file found")
+		return
+	}
+	if len(found) == 0 {
+		c.setStatusMessage("Tag not found: %s", name)
+		return
+	}
+	tag := found[0]
+
+	// 定義のファイルは新しいバッファに開き、既に開いていればそのバッファに切り替える
+	// 開けなかった場合のエラーは openFileCommand が表示する
+	if !samePath(tag.File, c.fileManager.GetFilename()) {
+		if err := c.openFileCommand([]string{tag.File}); err != nil || !samePath(tag.File, c.fileManager.GetFilename()) {
+			return
+		}
+	}
+
+	// 定義はファイルの後半にあることが多いので、全体の読み込みを待ってから探す
+	c.waitForLoad()
+	line, ok := tag.Resolve(c.contents.GetAllLines())
+	if !ok {
+		c.setStatusMessage("Definition of %s not found in %s", name, tag.File)
+		return
+	}
+	c.eventBus.Publish(event.NewCursorSetEvent(line, 0))
+	if len(found) > 1 {
+		c.setStatusMessage("%s (1 of %d definitions)", tag.File, len(found))
+	}
+}
+
+// findDefinitions は name の定義を ctags のタグファイルから探し、なければ GNU GLOBAL の GTAGS から探す
+// searched はどちらかのタグファイルが見つかったかどうか
+func (c *Controller) findDefinitions(name string) (found []tags.Tag, searched bool, err error) {
+	if src := c.tagSource(); src != nil {
+		index, err := src.Index()
+		if err != nil {
+			return nil, true, err
+		}
+		return index.Lookup(name), true, nil
+	}
+	root := tagsfile.FindGtags(c.tagsDir())
+	if root == "" {
+		return nil, false, nil
+	}
+	if !c.runner.Available("global") {
+		return nil, true, fmt.Errorf("%s found but global is not installed", filepath.Join(root, tagsfile.GtagsFileName))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	defer cancel()
+	out, err := c.runner.Run(ctx, root, "global", []string{"-x", name}, nil)
+	if err != nil {
+		return nil, true, err
+	}
+	index, err := tags.ParseGlobal(bytes.NewReader(out), root)
+	if err != nil {
+		return nil, true, err
+	}
+	return index.Lookup(name), true, nil
+}
+
+// samePath は2つのパスが同じファイルを指すかどうかを返す
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// globalRunner は global -x の出力として out を返す Runner
+type globalRunner struct {
+	out  string
+	dir  string
+	args []string
+}
+
+func (r *globalRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.dir, r.args = dir, args
+	return []byte(r.out), nil
+}
+
+func (r *globalRunner) Available(name string) bool { return name == "global" }
+
+// definitionProject は helper() を呼ぶ main.go と、その定義を置く helper.go を作成し、main.go を開く
+func definitionProject(t *testing.T) (*Controller, string) {
+	t.Helper()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\thelper()\n}\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "helper.go"), []byte("package main\n\nfunc helper() {}\n"), 0644))
+	controller, _ := newKeyInputController(t, []string{"one"})
+	assert.NoError(t, controller.openFileCommand([]string{filepath.Join(dir, "main.go")}))
+	controller.screen.SetCursorPosition(2, 3)
+	return controller, dir
+}
+
+func TestCompleteWord_NearestFirstAndCycle(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"fooFar", "", "", "fooNear"},
+		char('f'), char('o'), char('o'),
+		ctrlKey(key.KeyCtrlN), ctrlKey(key.KeyCtrlN), ctrlKey(key.KeyCtrlN),
+	)
+	controller.screen.SetCursorPosition(0, 2)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "fooNear", c.GetContentLine(2))
+
+	// 続けて押すとその場で次の候補に切り替わり、最後に元の入力に戻る
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "fooFar", c.GetContentLine(2))
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo", c.GetContentLine(2))
+}
+
+func TestCompleteWord_RecentlyTypedFirst(t *testing.T) {
+	events := []key.KeyEvent{}
+	for _, r := range "fooFar foo" {
+		events = append(events, char(r))
+	}
+	events = append(events, ctrlKey(key.KeyCtrlN))
+	controller, c := newKeyInputController(t, []string{"", "fooNear"}, events...)
+
+	for range events {
+		assert.NoError(t, controller.Process())
+	}
+	assert.Equal(t, "fooFar fooFar", c.GetContentLine(0))
+}
+
+func TestJumpToDefinition_OpensNewBuffer(t *testing.T) {
+	controller, dir := definitionProject(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tags"), []byte("helper\thelper.go\t/^func helper() {}$/;\"\tf\n"), 0644))
+	main := controller.contents
+	assert.NoError(t, main.InsertChar(contents.Position{X: 0, Y: 0}, ' '))
+
+	// 未保存の変更があっても、定義は新しいバッファに開く
+	controller.jumpToDefinition()
+	assert.Equal(t, filepath.Join(dir, "helper.go"), controller.fileManager.GetFilename())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().Y)
+	assert.Len(t, controller.windows.Buffers(), 3)
+	assert.True(t, main.IsDirty(), "the calling buffer keeps its changes")
+}
+
+func TestJumpToDefinition_Gtags(t *testing.T) {
+	controller, dir := definitionProject(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "GTAGS"), nil, 0644))
+	runner := &globalRunner{out: "helper              3 helper.go        func helper() {}\n"}
+	controller.runner = runner
+
+	controller.jumpToDefinition()
+	assert.Equal(t, []string{"-x", "helper"}, runner.args)
+	assert.Equal(t, dir, runner.dir, "global runs where GTAGS is")
+	assert.Equal(t, filepath.Join(dir, "helper.go"), controller.fileManager.GetFilename())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().Y)
+}
@@ -1,29 +1,62 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/boundary/bookmarkfile"
+	"github.com/wasya-io/go-kilo/app/boundary/dirlist"
+	"github.com/wasya-io/go-kilo/app/boundary/elevate"
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+	"github.com/wasya-io/go-kilo/app/boundary/fileindex"
 	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/boundary/gitrepo"
+	"github.com/wasya-io/go-kilo/app/boundary/grep"
+	"github.com/wasya-io/go-kilo/app/boundary/historyfile"
+	"github.com/wasya-io/go-kilo/app/boundary/positionfile"
 	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
+	"github.com/wasya-io/go-kilo/app/boundary/recoveryfile"
+	"github.com/wasya-io/go-kilo/app/boundary/sessionfile"
+	"github.com/wasya-io/go-kilo/app/boundary/shell"
+	"github.com/wasya-io/go-kilo/app/boundary/tagsfile"
+	"github.com/wasya-io/go-kilo/app/boundary/tracefile"
+	"github.com/wasya-io/go-kilo/app/boundary/trustfile"
 	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/bookmark"
 	"github.com/wasya-io/go-kilo/app/entity/contents"
 	"github.com/wasya-io/go-kilo/app/entity/core"
 	"github.com/wasya-io/go-kilo/app/entity/cursor"
 	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/indent"
 	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/keymap"
+	"github.com/wasya-io/go-kilo/app/entity/macro"
+	"github.com/wasya-io/go-kilo/app/entity/reminder"
 	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/entity/word"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/completion"
+	"github.com/wasya-io/go-kilo/app/usecase/finder"
+	"github.com/wasya-io/go-kilo/app/usecase/palette"
+	"github.com/wasya-io/go-kilo/app/usecase/projectreplace"
+	"github.com/wasya-io/go-kilo/app/usecase/recovery"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+	"github.com/wasya-io/go-kilo/app/usecase/tutor"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
 )
 
 type Controller struct {
 	screen                *screen.Screen
 	contents              *contents.Contents
 	fileManager           filemanager.FileManager
-	inputProvider         input.Provider
+	inputs                *input.CompositeProvider // 合成したイベントを端末の入力より先に返す
 	logger                core.Logger
 	metrics               *core.MetricsCollector
-	eventBuffer           []key.KeyEvent
 	quitWarningShown      bool
 	debugMode             bool
 	statusMessageDuration int
@@ -32,6 +65,80 @@ type Controller struct {
 	refreshTimer          *time.Timer
 	refreshMutex          sync.Mutex
 	refreshDelay          time.Duration
+	bookmarks             *bookmark.Bookmarks
+	bookmarkStore         bookmarkfile.Store
+	tags                  *tagsfile.Source
+	completion            *completionState
+	typedWords            *completion.Recent // 最近入力した単語（補完候補の順位付けに使う）
+	runner                external.Runner
+	savePipeline          *save.Pipeline
+	reminder              *reminder.Reminder
+	projectSearcher       grep.Searcher
+	projectFS             projectreplace.FileSystem
+	dirLister             dirlist.Lister   // ファイル一覧から開く際にディレクトリを一覧にする
+	fileLister            fileindex.Lister // ファイル検索の一覧を作成する際にファイルを列挙する
+	fileIndex             *finder.Index    // ファイル検索に使う作業ディレクトリ以下のファイルの一覧
+	recoveryStore         recoveryfile.Store
+	recovery              *recovery.Manager
+	checkpointTrigger     *recovery.Trigger      // 未保存の変更を自動で書き出す時機
+	readahead             bool                   // 大きなファイルを開く際に先読みを指示する
+	pendingRecovery       string                 // 開いたファイルに見つかった復元用ファイルのパス（復元するかを尋ねる前）
+	openStats             *filemanager.OpenStats // 開いたファイルの計測結果（最初の描画の計測が済むまで保持する）
+	commands              *command.Registry
+	keymap                *keymap.Keymap
+	macro                 *macro.Recorder
+	macroSource           *input.Source // 再生中のマクロのイベント
+	lastSequence          int           // 直前のコマンドを呼び出したキー操作の数
+	dragging              bool          // 区画の境界をマウスでドラッグしている
+	tabBar                bool          // 複数のバッファを開いている場合にタブバーを表示する
+	tabWidth              int           // Tab で挿入する空白の数
+	autoPair              bool          // 開き括弧・引用符の入力で閉じる文字を補う
+	forceReadOnly         bool          // 開くファイルをすべて編集できない状態にする（--readonly）
+	saveCount             int           // 保存に成功した回数
+	tutor                 *tutor.Tutor
+	tutorPath             string
+	preloadLines          int           // 先に読み込んで表示する行数（0 なら全体を読み込む）
+	loadMutex             sync.Mutex    // 以下の読み込み状態を保護する
+	loadGeneration        int           // ファイルを開くたびに増える世代
+	loadDone              chan struct{} // 残りを読み込み中の場合に、完了時に閉じられる
+	loadFailed            bool          // 残りの読み込みに失敗した
+	goImportsOnSave       bool          // ユーザー設定で goimports による整形が有効か
+	project               *config.Project
+	keyPrecedence         keymap.Precedence // 既定の割り当てとプロジェクト設定の割り当てが食い違う場合に残す方
+	keyConflicts          []keymap.Conflict // 直前に適用したキー割り当ての競合
+	reportedConflicts     string            // 情報パネルで知らせたキー割り当ての競合（同じ内容を繰り返し表示しない）
+	indentRules           *indent.Rules     // ファイルの種類ごとの自動インデントの規則
+	history               *command.History  // 取り消し・やり直し用の編集履歴
+	historyStore          historyfile.Store // 編集履歴の保存先（nil なら保存しない）
+	paletteUsage          *palette.Usage    // コマンドパレットから実行したコマンドの履歴
+	selection             selectionState    // 選択範囲
+	windows               *window.Manager   // 画面の分割とウィンドウごとの表示状態
+	traceFormat           string            // イベントバスの記録を書き出す形式
+	writeTrace            traceWriter
+	idle                  idleState                    // 入力が止まった後にキャッシュを減らすための状態
+	freeOSMemory          func()                       // 解放したメモリを OS へ返す
+	pendingElevatedSave   *elevatedSave                // 書き込み権限がなく保存できなかった内容（権限を昇格して保存するかを尋ねる前）
+	elevatedWriter        elevate.Writer               // 書き込み権限のないファイルを権限を昇格して保存する
+	suspendTerminal       func(run func() error) error // 外部のコマンドに端末を使わせる間、Raw モードを解除する
+	drawMutex             sync.Mutex                   // 端末への書き込みと、外部のコマンドへの端末の受け渡しを排他する
+	terminalHandedOver    bool                         // 外部のコマンドに端末を渡しているか。drawMutex で保護する
+	stopProcess           func() error                 // 端末を戻してエディタを一時停止する（nil なら停止できない）
+	searching             activeSearch                 // ステータスバーに一致箇所の番号を表示している検索
+	trimOnSave            bool                         // ユーザー設定で保存時に行末の空白を取り除くか
+	formatCommands        map[string]string            // ユーザー設定でファイルの種類（小文字）ごとに保存時に通す整形コマンド
+	diskWatch             diskWatch                    // 開いているファイルがほかのプロセスに変更されたかの確認
+	backupStore           backupfile.Saver             // 保存で上書きする前の内容のバックアップ先（nil ならバックアップしない）
+	sessionStore          sessionfile.Store            // 終了時に開いているバッファの記録先（nil なら記録しない）
+	positionStore         positionfile.Store           // ファイルごとの最後のカーソル位置の記録先（nil なら記録しない）
+	trustStore            trustfile.Store              // ビルドコマンドの実行を許可したプロジェクト設定の記録先（nil ならエディタを終了するまでしか覚えない）
+	trustedProjects       map[string]string            // この起動中に信頼したプロジェクト設定のパス -> 内容のダイジェスト
+	languages             lspState                     // ファイルの種類ごとの言語サーバーと診断
+	gitStatus             gitState                     // 表示中のファイルの git の変更の印とブランチ
+	spelling              spellState                   // Markdown とテキストのファイルの綴りの確認
+	shell                 shellRunner                  // コマンド行の ! や | で実行するシェル
+	background            backgroundState              // ほかの goroutine からメインループでの実行を依頼された処理
+	inputCtx              context.Context              // キー入力の待ちに使い、終了すると取り消される
+	stopInput             context.CancelFunc
 }
 
 // GetContents はコントローラーが管理しているコンテンツを返します。
@@ -54,40 +161,143 @@ func NewController(
 		screen:                screen,
 		contents:              contents,
 		fileManager:           fileManager,
-		inputProvider:         inputProvider,
 		logger:                logger,
 		Quit:                  make(chan struct{}),
 		statusMessageDuration: 5,
-		eventBus:              eventBus, // 追加: イベントバスの設定
+		eventBus:              eventBus,              // 追加: イベントバスの設定
 		refreshDelay:          16 * time.Millisecond, // 60FPS相当のデバウンス
+		bookmarks:             bookmark.New(contents),
+		bookmarkStore:         bookmarkfile.NewFileStore(),
+		runner:                external.NewCommandRunner(),
+		savePipeline:          save.NewPipeline(),
+		projectSearcher:       grep.NewWalker(),
+		projectFS:             projectreplace.NewOSFileSystem(),
+		dirLister:             dirlist.NewOSLister(),
+		fileLister:            fileindex.NewWalker(),
+		commands:              command.NewRegistry(),
+		keymap:                keymap.New(),
+		macro:                 macro.New(),
+		macroSource:           input.NewSource("macro"),
+		reminder:              reminder.New(0, 0),
+		indentRules:           indent.Default(),
+		history:               command.NewHistory(undoLimit),
+		paletteUsage:          palette.NewUsage(),
+		typedWords:            completion.NewRecent(recentWordsLimit),
+		traceFormat:           tracefile.FormatJSON,
+		tabBar:                true,
+		autoPair:              true,
+		tabWidth:              config.GetTabWidth(),
+		writeTrace:            tracefile.Write,
+		freeOSMemory:          freeOSMemory,
+		elevatedWriter:        elevate.NewCommandWriter(elevate.DefaultCommand),
+		suspendTerminal:       func(run func() error) error { return run() },
 	}
+	// マクロの再生は端末の入力より先に処理する
+	c.inputs = input.NewCompositeProvider(inputProvider, input.DefaultBurst, c.macroSource)
+	c.fileIndex = finder.NewIndex(c.fileLister)
+	c.shell = shell.New(c.runner)
+	c.inputCtx, c.stopInput = context.WithCancel(context.Background())
+
+	c.windows = window.NewManager(&window.Window{Buffer: &window.Buffer{
+		Contents: contents, FileManager: fileManager, History: c.history, Bookmarks: c.bookmarks,
+	}})
+
+	c.SetRecoveryStore(recoveryfile.NewDefaultStore())
+	c.SetRecoveryPolicy(recovery.DefaultPolicy)
 
 	// イベントハンドラーの登録
 	c.registerEventHandlers()
+	c.registerCommands()
+	c.bindDefaultKeys()
 
 	return c
 }
 
+// ApplyConfig は設定に応じてコントローラーの動作を切り替えます
+func (c *Controller) ApplyConfig(conf *config.Config) {
+	c.statusMessageDuration = conf.StatusMessageDuration
+	c.screen.SetMessageDuration(time.Duration(conf.StatusMessageDuration) * time.Second)
+
+	c.goImportsOnSave = conf.GoImportsOnSave
+	c.trimOnSave = conf.TrimTrailingWhitespace
+	c.formatCommands, _ = config.ParseLanguageServers(conf.FormatOnSave)
+	c.rebuildSavePipeline()
+
+	c.readahead = conf.Readahead
+	c.applyReadahead(c.fileManager)
+
+	c.tabBar = conf.TabBar
+	c.autoPair = conf.AutoPair
+	if conf.ElevateCommand != "" {
+		c.elevatedWriter = elevate.NewCommandWriter(conf.ElevateCommand)
+	}
+	c.keyPrecedence = keymap.PrecedenceLast
+	if conf.KeyPrecedence == config.KeyPrecedenceDefault {
+		c.keyPrecedence = keymap.PrecedenceFirst
+	}
+	c.keymap.SetPrecedence(c.keyPrecedence)
+	if conf.TabWidth > 0 {
+		c.tabWidth = conf.TabWidth
+	}
+
+	if servers, err := config.ParseLanguageServers(conf.LanguageServers); err == nil && len(servers) > 0 {
+		c.SetLanguageServers(servers)
+	}
+
+	c.gitStatus.repo = nil
+	if repo := gitrepo.New(c.runner); conf.GitSigns && repo.Available() {
+		c.gitStatus.repo = repo
+	}
+
+	c.SetSpellCheck(conf.SpellCheck, conf.SpellDictionary, conf.SpellLanguage)
+
+	c.projectSearcher = grep.Select(conf.GrepBackend, c.runner)
+	c.applySearchExclude()
+
+	remindAfter := time.Duration(conf.UnsavedReminderMinutes) * time.Minute
+	c.reminder = reminder.New(remindAfter, remindAfter)
+
+	// 記録は設定で有効にした場合は起動時から、そうでなければコマンドで開始する
+	if conf.EventTrace != "" && conf.EventTrace != "off" {
+		c.traceFormat = conf.EventTrace
+		c.startEventTrace()
+	}
+}
+
 // registerEventHandlers はイベントハンドラーを登録します
+// バッファやウィンドウを変える perform で始まるメソッドと releaseBuffer・trimIdleCaches は、
+// キー入力による編集と順序が入れ替わらないよう、ここで登録したハンドラーの中でのみ呼び出す
 func (c *Controller) registerEventHandlers() {
 	// 保存イベントのハンドラー
 	saveHandler := event.NewSingleTypeHandler(event.TypeSave, func(e event.Event) (bool, error) {
 		if saveEvent, ok := e.Payload.(event.SaveEvent); ok {
 			c.logger.Log("event", fmt.Sprintf("Save event received: %s", saveEvent.Filename))
+			if err := c.checkFullyLoaded(); err != nil {
+				c.setErrorMessage("Cannot save: %v", err)
+				return true, nil
+			}
 			c.setStatusMessage("Saving...")
+			// 保存前フック（goimportsなど）で内容を整形する
+			// 整形に失敗した場合に備えて、整形する前の内容を書き出しておく
+			if c.savePipeline.Len() > 0 {
+				c.checkpoint(time.Now())
+			}
+			result := c.savePipeline.Run(saveEvent.Filename, c.contents.GetAllLines())
+			if result.Changed {
+				c.replaceContents(result.Lines)
+			}
+
 			// イベントから渡されたファイル名を使用して保存
 			// これにより、"Save As"で指定された新しいファイル名が使用される
-			err := c.fileManager.SaveFile(saveEvent.Filename, c.contents.GetAllLines())
+			err := c.fileManager.SaveFile(saveEvent.Filename, result.Lines)
 			if err != nil {
+				// 書き込み権限がなければ、保存を待っている saveCommand が権限を昇格して保存するかを尋ねる
+				if c.deferElevatedSave(saveEvent.Filename, result, err) {
+					return true, nil
+				}
 				return false, fmt.Errorf("failed to save file: %w", err)
 			}
-			c.setStatusMessage("File saved")
-
-			// 画面を明示的に更新して、isDirtyの状態変化をステータスバーに反映する
-			if err := c.RefreshScreen(); err != nil {
-				c.logger.Log("error", fmt.Sprintf("Failed to refresh screen after save: %v", err))
-			}
-
+			c.finishSave(saveEvent.Filename, result)
 			return true, nil
 		}
 		return false, nil
@@ -99,7 +309,7 @@ func (c *Controller) registerEventHandlers() {
 			c.logger.Log("event", fmt.Sprintf("Quit event received, force=%v", quitEvent.Force))
 
 			// ダーティ状態かつ強制終了でなく、警告が未表示の場合
-			if c.contents.IsDirty() && !quitEvent.Force && !c.quitWarningShown {
+			if c.hasUnsavedChanges() && !quitEvent.Force && !c.quitWarningShown {
 				c.quitWarningShown = true
 				c.logger.Log("warning", "File has unsaved changes. Showing warning message.")
 
@@ -123,9 +333,20 @@ func (c *Controller) registerEventHandlers() {
 			// 終了処理を実行
 			c.logger.Log("system", "Shutting down editor")
 
+			now := time.Now()
+			c.saveSession(now)
+			c.rememberPositions(c.windows.Buffers(), now)
+
+			// 変更を破棄して終了することを選んだので、途中経過の復元用ファイルも不要になる
+			if err := c.recovery.DiscardLast(); err != nil {
+				c.logger.Log("error", fmt.Sprintf("Failed to remove recovery file: %v", err))
+			}
+
 			// チャネルが既に閉じられているか確認して安全に閉じる
 			if !c.isQuitChannelClosed() {
 				close(c.Quit)
+				// キー入力を待っているメインループを戻す
+				c.stopInput()
 			} else {
 				c.logger.Log("warning", "Attempted to close an already closed quit channel")
 			}
@@ -142,6 +363,11 @@ func (c *Controller) registerEventHandlers() {
 	c.eventBus.Subscribe(c.createBufferHandler())
 	c.eventBus.Subscribe(c.createRefreshHandler())
 	c.eventBus.Subscribe(c.createErrorHandler())
+	c.eventBus.Subscribe(c.createCheckpointHandler())
+	c.eventBus.Subscribe(c.createTutorHandler())
+	c.eventBus.Subscribe(c.createFileLoadedHandler())
+	c.eventBus.Subscribe(c.createResizeHandler())
+	c.eventBus.Subscribe(c.createDiagnosticsHandler())
 }
 
 func (c *Controller) createErrorHandler() event.Handler {
@@ -149,7 +375,7 @@ func (c *Controller) createErrorHandler() event.Handler {
 		if errorEvent, ok := e.Payload.(event.ErrorEvent); ok {
 			c.logger.Log("error", fmt.Sprintf("Auto-caught event error during %s: %v", errorEvent.OriginalEvent.Type, errorEvent.Error))
 			// ステータスバーにエラー内容を表示
-			c.setStatusMessage("Error: %v", errorEvent.Error)
+			c.setErrorMessage("Error: %v", errorEvent.Error)
 			return true, nil
 		}
 		return false, nil
@@ -163,8 +389,11 @@ func (c *Controller) createCursorHandler() event.Handler {
 			if cursorEvent.Action == cursor.CursorSet {
 				c.screen.SetCursorPosition(cursorEvent.Col, cursorEvent.Row)
 			} else {
-				c.screen.MoveCursor(cursorEvent.Action, c.contents)
+				for i := 0; i < max(cursorEvent.Count, 1); i++ {
+					c.screen.MoveCursor(cursorEvent.Action, c.contents)
+				}
 			}
+			c.showSelection()
 			c.updateScroll()
 			c.eventBus.Publish(event.NewRefreshEvent())
 			return true, nil
@@ -177,13 +406,77 @@ func (c *Controller) createBufferHandler() event.Handler {
 	return event.NewSingleTypeHandler(event.TypeBuffer, func(e event.Event) (bool, error) {
 		if bufferEvent, ok := e.Payload.(event.BufferEvent); ok {
 			c.logger.Log("buffer", fmt.Sprintf("Handling buffer event: %v", bufferEvent.Action))
+			var err error
 			switch bufferEvent.Action {
 			case event.BufferInsert:
 				c.performInsertChar(bufferEvent.Rune)
 			case event.BufferDelete:
 				c.performDeleteChar()
+			case event.BufferDeleteNext:
+				c.performDeleteForward()
 			case event.BufferNewline:
 				c.performInsertNewline()
+			case event.BufferUndo:
+				c.performUndo()
+			case event.BufferRedo:
+				c.performRedo()
+			case event.BufferSelectExtend, event.BufferSelectCollapse, event.BufferSelectToggle, event.BufferSelectCancel, event.BufferSelectBlock:
+				c.performSelection(bufferEvent.Action)
+			case event.BufferCopy:
+				c.performCopy()
+			case event.BufferCut:
+				c.performCut()
+			case event.BufferPaste:
+				c.performPaste()
+			case event.BufferTransposeChars:
+				c.performTransposeChars()
+			case event.BufferTransposeLines:
+				c.performTransposeLines()
+			case event.BufferKillLine:
+				c.performKillLine()
+			case event.BufferDupLine:
+				c.performDuplicateLine()
+			case event.BufferMoveLine:
+				c.performMoveLine(bufferEvent.Size)
+			case event.BufferJoinLine:
+				c.performJoinLine()
+			case event.BufferSplitHorizontal, event.BufferSplitVertical, event.BufferFocusNext, event.BufferCloseOthers, event.BufferZoomWindow:
+				c.performWindow(bufferEvent.Action)
+			case event.BufferFocusWindow:
+				c.performFocusWindow(bufferEvent.Window)
+			case event.BufferResizeWindow, event.BufferMoveDivider:
+				c.performResize(bufferEvent.Action, bufferEvent.Size)
+			case event.BufferShowBuffer:
+				c.performShowBuffer(bufferEvent.Buffer)
+			case event.BufferCloseBuffer:
+				c.performCloseBuffer()
+			case event.BufferDetach:
+				c.performDetachBuffer()
+			case event.BufferDiscard:
+				c.performDiscardBuffer(bufferEvent.Buffer)
+			case event.BufferLineEnding:
+				c.performLineEnding(bufferEvent.LineEnding)
+			case event.BufferReplace:
+				c.performReplace(bufferEvent.Replacements)
+			case event.BufferSetLines:
+				c.performSetLines(bufferEvent.Lines)
+			case event.BufferRevert:
+				c.performRevert()
+			case event.BufferEncoding:
+				c.performEncoding(bufferEvent.Encoding)
+			case event.BufferReopen:
+				c.performReopen(bufferEvent.Encoding)
+			case event.BufferReloadFiles:
+				c.performReloadFiles(bufferEvent.Files)
+			case event.BufferSelectRange:
+				c.performSelectRange(bufferEvent.Start, bufferEvent.End)
+			case event.BufferInsertText:
+				err = c.performInsertText(bufferEvent.Lines)
+			case event.BufferDeleteRange:
+				err = c.performDeleteRange(bufferEvent.Start, bufferEvent.End)
+			}
+			if bufferEvent.Result != nil {
+				bufferEvent.Result <- err
 			}
 			c.eventBus.Publish(event.NewRefreshEvent())
 			return true, nil
@@ -208,15 +501,21 @@ func (c *Controller) createRefreshHandler() event.Handler {
 			c.refreshTimer.Stop()
 		}
 
+		if refresh, ok := e.Payload.(event.RefreshEvent); ok && refresh.Now {
+			c.logger.Log("screen", "Handling debounced refresh event")
+			c.RefreshScreen()
+			return true, nil
+		}
 		if c.refreshDelay == 0 {
 			c.logger.Log("screen", "Handling immediate refresh event")
 			c.RefreshScreen()
 			return true, nil
 		}
 
+		// 再描画はバッファを読むため、キー入力による編集と重ならないようタイマーの goroutine では行わず、
+		// イベントバスに戻してハンドラーで行う
 		c.refreshTimer = time.AfterFunc(c.refreshDelay, func() {
-			c.logger.Log("screen", "Handling debounced refresh event")
-			c.RefreshScreen()
+			c.eventBus.Publish(event.NewRefreshNowEvent())
 		})
 
 		return true, nil
@@ -235,6 +534,35 @@ func (c *Controller) isQuitChannelClosed() bool {
 	}
 }
 
+// finishSave は保存できた後の編集履歴・復元用ファイルの後始末を行い、結果をステータスバーに表示する
+func (c *Controller) finishSave(filename string, result save.Result) {
+	c.saveCount++
+	c.history.MarkSaved()
+	c.saveHistory(filename, result.Lines)
+	// 保存できた内容の復元用ファイルは不要になる
+	c.discardRecovery(filename)
+	// 失敗したフックのエラーとフックが報告した変換の内容を添える
+	var details []string
+	if len(result.Errors) > 0 {
+		details = append(details, result.Errors[0].Error())
+	}
+	details = append(details, result.Notes...)
+	switch {
+	case len(result.Errors) > 0:
+		// 整形に失敗した内容のまま保存したことを見落とさないよう、閉じるまで表示する
+		c.setErrorMessage("File saved (%s)", strings.Join(details, "; "))
+	case len(details) > 0:
+		c.setStatusMessage("File saved (%s)", strings.Join(details, "; "))
+	default:
+		c.setStatusMessage("File saved")
+	}
+
+	// 画面を明示的に更新して、isDirtyの状態変化をステータスバーに反映する
+	if err := c.RefreshScreen(); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to refresh screen after save: %v", err))
+	}
+}
+
 // PublishSaveEvent は保存イベントを発行します
 func (c *Controller) PublishSaveEvent(filename string, force bool) {
 	c.logger.Log("event", fmt.Sprintf("Publishing save event: %s", filename))
@@ -254,27 +582,44 @@ func (c *Controller) PublishQuitEvent(force bool) {
 func (c *Controller) RefreshScreen() error {
 	start := time.Now()
 
-	// UI更新の前にスクロール位置を更新
+	// UI更新の前に画面の分割とスクロール位置を更新
+	c.applyLayout()
+	c.updateGitStatus() // ガターの幅が本文の桁数に影響するため、スクロールより先に設定する
 	c.updateScroll()
+	c.updateBracketMatch()
+	c.updateSearchStatus()
+	c.syncLanguageServer()
+	c.updateDiagnostics()
+	c.updateMisspellings()
 
 	// ファイル名のロギングを追加
 	filename := c.fileManager.GetFilename()
 	c.logger.Log("screen", fmt.Sprintf("Refreshing screen with filename: '%s'", filename))
 
 	// UIの更新処理を実行
+	// 外部のコマンドに端末を渡している間は、その出力や入力の求めの上に描かない
+	c.drawMutex.Lock()
+	if c.terminalHandedOver {
+		c.drawMutex.Unlock()
+		return nil
+	}
 	err := c.screen.Redraw(c.contents, filename)
-	if err != nil {
-		return err
+	if err == nil {
+		// メッセージ表示後は即座にフラッシュする
+		err = c.screen.Flush()
 	}
-
-	// メッセージ表示後は即座にフラッシュする
-	if err := c.screen.Flush(); err != nil {
+	c.drawMutex.Unlock()
+	if err != nil {
 		return err
 	}
 
 	if c.metrics != nil && c.metrics.Enabled() {
 		c.metrics.RecordRefreshDuration(time.Since(start))
+		if s := c.openStats; s != nil {
+			c.metrics.RecordFileOpen(s.Read, s.Split, time.Since(start), s.Bytes, s.Lines)
+		}
 	}
+	c.openStats = nil
 
 	return nil
 }
@@ -291,12 +636,18 @@ func (c *Controller) updateScroll() {
 		return
 	}
 
-	screenRowLines := c.screen.GetRowLines()
-	// ステータスバーとメッセージバー用に2行確保
-	visibleLines := screenRowLines - 2
+	// フォーカスのあるウィンドウの本文の行数（端末が極端に小さくても1行は表示する前提で計算する）
+	visibleLines := max(c.screen.TextRows(), 1)
 
 	// カーソル周辺に表示する余白行数
-	const scrollMargin = 3
+	// 本文の行数が少ない場合は、余白でカーソルが表示範囲から押し出されないよう狭める
+	scrollMargin := min(3, (visibleLines-1)/2)
+
+	// 折り返し表示では1行が複数の表示行になるため、表示行単位でスクロールする
+	if c.screen.Wrap() {
+		c.screen.ScrollWrapped(c.contents, scrollMargin)
+		return
+	}
 
 	// スクロール条件の計算
 	// カーソルが表示領域の上端より上にある場合
@@ -317,8 +668,8 @@ func (c *Controller) updateScroll() {
 		offsetCol = cursorScreenPos
 	}
 
-	screenColLines := c.screen.GetColLines()
-	rightMargin := (screenColLines * 4) / 5
+	screenColLines := c.screen.TextCols()
+	rightMargin := max((screenColLines*4)/5, 1)
 	if cursorScreenPos >= (offsetCol + rightMargin) {
 		offsetCol = cursorScreenPos - rightMargin + 1
 	}
@@ -343,49 +694,122 @@ func (c *Controller) updateScroll() {
 
 // Process はキー入力を処理する
 func (c *Controller) Process() error {
-	event, err := c.readEvent()
+	if c.pendingRecovery != "" {
+		return c.promptRecovery()
+	}
+
+	c.background.setIdle(true)
+	ev, err := c.readEvent()
+	c.background.setIdle(false)
 	if err != nil {
+		if c.quitting(err) {
+			return nil
+		}
 		c.logger.Log("error", fmt.Sprintf("readEvent error: %v", err))
 		return err
 	}
 
 	// キーイベントを直接処理
-	return c.handleKeyEvent(event)
+	if err := c.handleKeyEvent(ev); err != nil {
+		if c.quitting(err) {
+			return nil
+		}
+		return err
+	}
+	// キー入力で発行したイベントの処理が終わったことを通知する
+	c.eventBus.Publish(event.NewKeyHandledEvent(ev.Name()))
+	return nil
 }
 
 // readEvent はイベントを読み取る
+// 入力を待つ間にほかの goroutine から依頼された処理を実行し、終了したら待つのをやめて context.Canceled を返す
 func (c *Controller) readEvent() (key.KeyEvent, error) {
-	// バッファにイベントがある場合はそれを返す
-	if len(c.eventBuffer) > 0 {
-		event := c.eventBuffer[0]
-		c.eventBuffer = c.eventBuffer[1:]
-		return event, nil
+	var event key.KeyEvent
+	var err error
+	for {
+		c.runBackgroundTasks()
+		ctx, cancel := c.background.waitContext(c.inputCtx)
+		event, _, err = c.inputs.GetInputEventsContext(ctx)
+		cancel()
+		// 依頼された処理のために待つのをやめた場合は、実行してから待ち直す。読み取り中の入力は失われない
+		if err == nil || ctx.Err() == nil || c.inputCtx.Err() != nil {
+			break
+		}
 	}
-
-	event, remainingEvents, err := c.inputProvider.GetInputEvents()
 	if err != nil {
-		c.logger.Log("error", fmt.Sprintf("Keypress error: %v", err))
+		if !c.quitting(err) {
+			c.logger.Log("error", fmt.Sprintf("Keypress error: %v", err))
+		}
 		return key.KeyEvent{}, err
 	}
 
-	// 残りのイベントがある場合はバッファに追加
-	if len(remainingEvents) > 0 {
-		c.eventBuffer = append(c.eventBuffer, remainingEvents...)
+	// 再生中のマクロなど、合成したイベントは記録しない
+	if !c.inputs.Synthetic() {
+		c.macro.Record(event)
 	}
-
 	return event, nil
 }
 
 // OpenFile は指定されたファイルを読み込む
+// 前回のセッションで保存されなかった変更の復元用ファイルがあれば、次の入力の前に復元するかを尋ねる
 func (c *Controller) OpenFile(filename string) error {
+	if err := c.openFile(filename); err != nil {
+		return err
+	}
+	c.detectRecovery(c.fileManager.GetFilename())
+	return nil
+}
+
+// OpenText は標準入力などから読み込んだ data を名前のないバッファに読み込む
+// ファイルを開く場合と同じく文字コードと改行コードを判定する。保存するまで内容が失われないよう、変更ありとして扱う
+func (c *Controller) OpenText(data []byte) error {
+	lines, ending, enc, err := filemanager.DecodeText("stdin", data)
+	if err != nil {
+		return err
+	}
+	c.contents.LoadContent(lines)
+	c.contents.SetLineEnding(ending)
+	c.contents.SetEncoding(enc)
+	c.contents.SetDirty(true)
+	c.bookmarks.Clear()
+	c.history.Clear()
+	c.clearSelection()
+	if c.forceReadOnly {
+		c.contents.SetReadOnly(true)
+	}
+	return nil
+}
+
+// openFile は指定されたファイルを読み込む。復元用ファイルは確認しない
+func (c *Controller) openFile(filename string) error {
 	c.logger.Log("file", fmt.Sprintf("Opening file: '%s'", filename))
-	err := c.fileManager.OpenFile(filename)
+	var err error
+	if c.preloadLines > 0 {
+		err = c.openFileProgressive(filename)
+	} else {
+		err = c.fileManager.OpenFile(filename)
+	}
 	if err != nil {
 		c.logger.Log("error", fmt.Sprintf("Failed to open file: %v", err))
 		return err
 	}
 	c.logger.Log("file", fmt.Sprintf("File opened successfully: '%s', current filename from fileManager: '%s'",
 		filename, c.fileManager.GetFilename()))
+	// 次の描画までを最初の描画の時間として計測する
+	if r, ok := c.fileManager.(filemanager.StatsReporter); ok {
+		stats := r.LastOpenStats()
+		c.openStats = &stats
+	}
+	// ブックマークはバッファごとの情報なので開き直した時点で破棄する
+	c.bookmarks.Clear()
+	c.history.Clear()
+	// 残りを読み込み中の場合は、読み込みが終わってから編集履歴を読み込む
+	if !c.isLoading() {
+		c.restoreHistory(c.fileManager.GetFilename())
+	}
+	c.clearSelection()
+	c.applyReadOnly(filename)
+	c.loadProjectSettings(filename)
 	return nil
 }
 
@@ -394,8 +818,25 @@ func (c *Controller) insertChar(ch rune) {
 }
 
 func (c *Controller) performInsertChar(ch rune) {
+	c.clearSelection()
 	pos := c.screen.GetCursor().ToPosition()
-	c.contents.InsertChar(contents.Position{X: pos.X, Y: pos.Y}, ch)
+	if !word.IsWordRune(ch) {
+		c.rememberTypedWord(pos.X, pos.Y)
+	}
+	if c.skipClosingChar(contents.Position{X: pos.X, Y: pos.Y}, ch) {
+		return
+	}
+	if c.insertOutdentedCloser(contents.Position{X: pos.X, Y: pos.Y}, ch) {
+		return
+	}
+	if c.insertPair(contents.Position{X: pos.X, Y: pos.Y}, ch) {
+		return
+	}
+	if err := c.contents.InsertChar(contents.Position{X: pos.X, Y: pos.Y}, ch); err != nil {
+		c.reportEditError(err)
+		return
+	}
+	c.recordEdit(contents.Position{X: pos.X, Y: pos.Y}, nil, []string{string(ch)})
 	// カーソルを1つ進める
 	c.screen.SetCursorPosition(pos.X+1, pos.Y)
 }
@@ -406,23 +847,60 @@ func (c *Controller) deleteChar() {
 
 func (c *Controller) performDeleteChar() {
 	c.logger.Log("edit", "Deleting character")
+	// 選択中は選択範囲を削除する
+	if c.selection.active && c.selection.block {
+		c.deleteBlock()
+		return
+	}
+	if start, end, ok := c.selectionRange(); ok {
+		c.replaceRange(start, end, nil)
+		return
+	}
+	c.clearSelection()
 	pos := c.screen.GetCursor().ToPosition()
 
+	if c.deletePair(contents.Position{X: pos.X, Y: pos.Y}) {
+		return
+	}
 	if pos.X > 0 {
 		// 行の途中での削除
-		c.contents.DeleteChar(contents.Position{X: pos.X, Y: pos.Y})
+		line := []rune(c.contents.GetContentLine(pos.Y))
+		if err := c.contents.DeleteChar(contents.Position{X: pos.X, Y: pos.Y}); err != nil {
+			c.reportEditError(err)
+			return
+		}
+		if pos.X <= len(line) {
+			c.recordEdit(contents.Position{X: pos.X - 1, Y: pos.Y}, []string{string(line[pos.X-1])}, nil)
+		}
 		c.screen.SetCursorPosition(pos.X-1, pos.Y) // カーソルを1つ左に移動
 	} else if pos.Y > 0 {
 		// 行頭での削除（前の行との結合）
 		prevRow := c.contents.GetRow(pos.Y - 1)
 		if prevRow != nil {
 			targetX := prevRow.GetRuneCount() // 前の行の末尾位置
-			c.contents.DeleteChar(contents.Position{X: pos.X, Y: pos.Y})
+			if err := c.contents.DeleteChar(contents.Position{X: pos.X, Y: pos.Y}); err != nil {
+				c.reportEditError(err)
+				return
+			}
+			c.recordEdit(contents.Position{X: targetX, Y: pos.Y - 1}, []string{"", ""}, nil)
 			c.screen.SetCursorPosition(targetX, pos.Y-1) // 前の行の末尾へ移動
 		}
 	}
 }
 
+// reportEditError は編集が拒否された理由をステータスメッセージに表示する
+func (c *Controller) reportEditError(err error) {
+	if errors.Is(err, contents.ErrReadOnly) {
+		if c.contents.ReadOnly() {
+			c.setStatusMessage("The buffer is read-only (C-k l to unlock)")
+			return
+		}
+		c.setStatusMessage("This part of the buffer is read-only")
+		return
+	}
+	c.setErrorMessage("Edit failed: %v", err)
+}
+
 func (c *Controller) moveCursor(movement cursor.Movement) {
 	c.logger.Log("cursor", fmt.Sprintf("Publishing cursor event: %v", movement))
 	c.eventBus.Publish(event.NewCursorEvent(movement))
@@ -434,8 +912,10 @@ func (c *Controller) insertNewline() {
 
 func (c *Controller) performInsertNewline() {
 	c.logger.Log("edit", "Inserting newline")
+	c.clearSelection()
 	cursor := c.screen.GetCursor()
 	pos := cursor.ToPosition()
+	c.rememberTypedWord(pos.X, pos.Y)
 
 	// 現在行のインデント文字数を計測
 	currentLine := c.contents.GetContentLine(pos.Y)
@@ -455,8 +935,25 @@ func (c *Controller) performInsertNewline() {
 		indentSize = pos.X
 	}
 
+	// 括弧の間での改行は、閉じ括弧を元のインデントの行に送り、間に1段深くした空行を作る
+	if c.insertBracketNewline(contents.Position{X: pos.X, Y: pos.Y}, currentLine, indentSize) {
+		return
+	}
+	// ファイルの種類の規則で、ブロックを開く行の後は1段深くする
+	if c.insertIndentedNewline(contents.Position{X: pos.X, Y: pos.Y}, currentLine, indentSize) {
+		return
+	}
+
 	// 改行をインデントサイズとともに挿入
-	c.contents.InsertNewline(contents.Position{X: pos.X, Y: pos.Y}, indentSize)
+	if err := c.contents.InsertNewline(contents.Position{X: pos.X, Y: pos.Y}, indentSize); err != nil {
+		c.reportEditError(err)
+		return
+	}
+	splitAt := pos.X
+	if n := len([]rune(currentLine)); splitAt > n {
+		splitAt = n
+	}
+	c.recordEdit(contents.Position{X: splitAt, Y: pos.Y}, nil, []string{"", strings.Repeat(" ", indentSize)})
 
 	// カーソルを新しい行のインデント位置に設定
 	cursor.NewLine() // まず次の行の行頭へ移動
@@ -466,6 +963,35 @@ func (c *Controller) performInsertNewline() {
 	c.updateScroll()
 }
 
+// replaceContents はバッファの内容を置き換え、カーソルを有効な範囲に収める
+func (c *Controller) replaceContents(lines []string) {
+	pos := c.screen.GetCursor().ToPosition()
+	old := c.contents.GetAllLines()
+	c.contents.LoadContent(lines)
+	c.contents.SetDirty(true)
+	// 整形や復元による置き換えも取り消せるよう、全体の置き換えとして記録する
+	c.recordEdit(contents.Position{}, old, append([]string{}, lines...))
+	c.clearSelection()
+	c.keepCursorNear(pos)
+}
+
+// keepCursorNear はカーソルを pos に、バッファの範囲に収めて移す
+// 内容を丸ごと置き換えた後に、置き換える前の位置の近くに留めるために使う
+func (c *Controller) keepCursorNear(pos contents.Position) {
+	y := pos.Y
+	if y >= c.contents.GetLineCount() {
+		y = c.contents.GetLineCount() - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	x := pos.X
+	if row := c.contents.GetRow(y); row != nil && x > row.GetRuneCount() {
+		x = row.GetRuneCount()
+	}
+	c.screen.SetCursorPosition(x, y)
+}
+
 // setStatusMessage はステータスメッセージを設定する（非公開メソッド）
 func (c *Controller) setStatusMessage(format string, args ...interface{}) {
 	if c.debugMode {
@@ -478,8 +1004,64 @@ func (c *Controller) setStatusMessage(format string, args ...interface{}) {
 	c.eventBus.Publish(event.NewRefreshEvent())
 }
 
+// setErrorMessage はエラーメッセージを表示する
+// 見落とさないよう、表示時間で消さずに dismissMessage で閉じるまで表示し続ける
+func (c *Controller) setErrorMessage(format string, args ...interface{}) {
+	if c.debugMode {
+		format = "[in Debug] " + format
+	}
+	c.screen.SetMessageFor(contents.Persistent, format, args...)
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
+// dismissMessage は表示中のステータスメッセージを閉じ、選択を解除する。確定した検索も終える
+func (c *Controller) dismissMessage() {
+	c.cancelSelection()
+	ended := c.endSearch()
+	if c.screen.DismissMessage() || ended {
+		c.eventBus.Publish(event.NewRefreshEvent())
+	}
+}
+
+// ExpireStatusMessage は表示時間を過ぎたステータスメッセージを消して画面を更新する
+// メッセージは描画時にしか消えないため、入力がない間も定期的に呼び出す
+func (c *Controller) ExpireStatusMessage(now time.Time) {
+	if c.screen.ExpireMessage(now) {
+		c.eventBus.Publish(event.NewRefreshEvent())
+	}
+}
+
+// ShowOverlay は編集領域の上に情報パネルを表示します。
+// パネルは次のキー入力で閉じられます。
+func (c *Controller) ShowOverlay(title string, lines []string) {
+	c.screen.SetOverlay(title, lines)
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
+// dismissOverlay は情報パネルを閉じて画面を更新する
+func (c *Controller) dismissOverlay() {
+	c.screen.ClearOverlay()
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
 // handleKeyEvent はキーイベントを処理してイベントバスに発行する
 func (c *Controller) handleKeyEvent(event key.KeyEvent) error {
+	// 情報パネルの表示中は、キー入力をパネルを閉じる操作として消費する
+	if c.screen.HasOverlay() && event.Type != key.KeyEventMouse {
+		c.dismissOverlay()
+		return nil
+	}
+
+	// 補完の巡回は Ctrl-N が連続している間だけ継続する
+	if !(event.Type == key.KeyEventControl && event.Key == key.KeyCtrlN) {
+		c.resetCompletion()
+	}
+
+	// Alt との同時押しは文字として挿入せず、キー割り当てだけを参照する
+	if event.Modifiers.Has(key.ModAlt) {
+		return c.handleAltKey(event)
+	}
+
 	switch event.Type {
 	case key.KeyEventChar, key.KeyEventSpecial:
 		// Rune=0 は無視する（無効なイベントやファントムイベントの可能性）
@@ -492,12 +1074,15 @@ func (c *Controller) handleKeyEvent(event key.KeyEvent) error {
 			c.quitWarningShown = false
 			c.setStatusMessage("")
 		}
-		
+
 		if event.Type == key.KeyEventChar {
 			c.logger.Log("input", fmt.Sprintf("Handling char event: %c", event.Rune))
 			c.insertChar(event.Rune)
 			return nil
 		}
+		if isArrowKey(event.Key) {
+			c.beforeCursorMove(event.Modifiers.Has(key.ModShift))
+		}
 		return c.handleSpecialKey(event.Key)
 
 	case key.KeyEventControl:
@@ -505,25 +1090,17 @@ func (c *Controller) handleKeyEvent(event key.KeyEvent) error {
 
 	case key.KeyEventMouse:
 		if event.Key == key.KeyMouseWheel {
-			// マウスホイールイベントは専用のカーソル移動コマンドを使用
-			switch event.MouseAction {
-			case key.MouseScrollUp:
-				c.logger.Log("cursor", "Moving cursor up")
-				c.moveCursor(cursor.MouseWheelUp)
-				c.updateScroll()
-				return nil
-			case key.MouseScrollDown:
-				c.logger.Log("cursor", "Moving cursor down")
-				c.moveCursor(cursor.MouseWheelDown)
-				c.updateScroll()
-				return nil
-			}
+			return c.handleMouseWheel(event)
 		} else if event.Key == key.KeyMouseClick {
 			// マウスクリックイベントを処理
 			switch event.MouseAction {
 			case key.MouseLeftClick:
 				c.logger.Log("mouse", fmt.Sprintf("Mouse left click at row: %d, col: %d", event.MouseRow, event.MouseCol))
-				c.handleMouseClick(event.MouseRow, event.MouseCol)
+				return c.handleMouseClick(event.MouseRow, event.MouseCol)
+			case key.MouseDrag:
+				return c.handleMouseDrag(event.MouseRow, event.MouseCol)
+			case key.MouseRelease:
+				c.dragging = false
 				return nil
 			}
 			c.logger.Log("mouse", fmt.Sprintf("Unhandled mouse click event: %v", event.MouseAction))
@@ -532,50 +1109,13 @@ func (c *Controller) handleKeyEvent(event key.KeyEvent) error {
 	return nil
 }
 
-// handleMouseClick はマウスクリックイベントを処理し、カーソルを移動します
-func (c *Controller) handleMouseClick(row, col int) {
-	// スクロールオフセットを考慮して、クリックされた画面上の位置をテキストバッファ上の位置に変換
-	offsetCol, offsetRow := c.screen.GetOffset()
-
-	// クリック位置にオフセットを加算して実際のテキスト位置を計算
-	bufferRow := row + offsetRow
-	bufferCol := col + offsetCol
-
-	// バッファの範囲内かチェック
-	if bufferRow >= c.contents.GetLineCount() {
-		bufferRow = c.contents.GetLineCount() - 1
-		if bufferRow < 0 {
-			bufferRow = 0
-		}
-	}
-
-	// 行を取得
-	targetRow := c.contents.GetRow(bufferRow)
-	if targetRow == nil {
-		return
-	}
-
-	// 画面上の列位置をバッファ内の文字位置（バイト位置）に変換
-	// この処理はタブ文字や全角文字を考慮する必要があります
-	bufferCol = targetRow.ScreenPositionToOffset(bufferCol)
-
-	// 行内の有効な位置にカーソルを制限
-	maxCol := targetRow.GetRuneCount()
-	if bufferCol > maxCol {
-		bufferCol = maxCol
-	}
-	if bufferCol < 0 {
-		bufferCol = 0
-	}
-
-	// カーソル位置を更新（イベントを発行）
-	c.logger.Log("cursor", fmt.Sprintf("Publishing cursor set event to row: %d, col: %d", bufferRow, bufferCol))
-	c.eventBus.Publish(event.NewCursorSetEvent(bufferRow, bufferCol))
-}
-
 // handleSpecialKey は特殊キーを処理する
 func (c *Controller) handleSpecialKey(k key.Key) error {
 	switch k {
+	case key.KeyEsc, key.KeyDelete:
+		if b, ok := c.keymap.Lookup(keymap.LayerGlobal, k.Name()); ok {
+			return c.runBinding(b, 1)
+		}
 	case key.KeyArrowLeft:
 		c.moveCursor(cursor.CursorLeft)
 	case key.KeyArrowRight:
@@ -593,8 +1133,7 @@ func (c *Controller) handleSpecialKey(k key.Key) error {
 	case key.KeyTab:
 		c.logger.Log("edit", "Inserting tab")
 		// タブは空白に展開
-		tabWidth := config.GetTabWidth()
-		for i := 0; i < tabWidth; i++ {
+		for i := 0; i < c.tabWidth; i++ {
 			c.insertChar(' ')
 		}
 	case key.KeyShiftTab:
@@ -622,10 +1161,9 @@ func (c *Controller) handleSpecialKey(k key.Key) error {
 		}
 
 		// 削除するスペース数を計算
-		tabWidth := config.GetTabWidth()
-		spacesToDelete := leftSpaces % tabWidth
+		spacesToDelete := leftSpaces % c.tabWidth
 		if spacesToDelete == 0 {
-			spacesToDelete = tabWidth
+			spacesToDelete = c.tabWidth
 		}
 
 		// スペースを削除
@@ -642,68 +1180,142 @@ func (c *Controller) handleSpecialKey(k key.Key) error {
 }
 
 // handleControlKey はコントロールキーを処理する
+// 割り当てはキーマップから取得し、Ctrl-K と Ctrl-W は続くキーで操作を選ぶプレフィックスとして扱う
 func (c *Controller) handleControlKey(k key.Key) error {
 	switch k {
-	case key.KeyCtrlS:
-		// 保存処理
-		filename := c.fileManager.GetFilename()
-		if filename == "" {
-			var err error
-			filename, err = c.prompt("Save as: ")
-			if err != nil {
-				return err
-			}
-			if filename == "" {
-				c.setStatusMessage("Save aborted")
-				return nil
-			}
-		}
-		c.logger.Log("event", "Saving file")
-		c.PublishSaveEvent(filename, false)
-	case key.KeyCtrlX, key.KeyCtrlC:
-		// 終了処理
-		c.logger.Log("event", "Quitting")
-		c.PublishQuitEvent(false)
+	case key.KeyCtrlK:
+		return c.handlePrefix(keymap.LayerCtrlK)
+	case key.KeyCtrlW:
+		return c.handlePrefix(keymap.LayerCtrlW)
 	}
-	return nil
+	b, ok := c.keymap.Lookup(keymap.LayerGlobal, k.Name())
+	if !ok {
+		return nil
+	}
+	return c.runBinding(b, 1)
+}
+
+// handleAltKey は Alt との同時押しに割り当てられたコマンドを実行する。割り当てがなければ無視する
+func (c *Controller) handleAltKey(event key.KeyEvent) error {
+	b, ok := c.keymap.Lookup(keymap.LayerGlobal, event.Name())
+	if !ok {
+		return nil
+	}
+	return c.runBinding(b, 1)
 }
 
 // prompt はユーザーに入力を求める
 func (c *Controller) prompt(prompt string) (string, error) {
-	c.setStatusMessage(prompt)
+	input, _, err := c.promptInput(prompt, false)
+	return input, err
+}
+
+// promptInput はユーザーに入力を求める
+// allowEmpty が true の場合は空文字列の確定も受け付ける。キャンセルされた場合 ok は false
+func (c *Controller) promptInput(prompt string, allowEmpty bool) (input string, ok bool, err error) {
+	return c.promptValidated(prompt, allowEmpty, nil)
+}
+
+// promptValidated は validate が nil を返す入力だけを確定として受け付ける promptInput
+// 受け付けられない入力で Enter を押した場合は、理由をプロンプトの後ろに表示して入力を続ける
+func (c *Controller) promptValidated(prompt string, allowEmpty bool, validate func(string) error) (input string, ok bool, err error) {
+	return c.promptWith(prompt, allowEmpty, validate, nil)
+}
+
+// promptCompleted は Tab で入力を補完できる promptInput
+// complete は入力全体を補完した候補を返す。候補が1つならそれに置き換え、
+// 複数なら共通する部分まで補ったうえで候補をプロンプトの後ろに表示する
+func (c *Controller) promptCompleted(prompt string, allowEmpty bool, complete func(string) []string) (input string, ok bool, err error) {
+	return c.promptWith(prompt, allowEmpty, nil, complete)
+}
+
+// promptWith は promptValidated と promptCompleted の共通の処理。validate と complete は nil でもよい
+// 入力はメッセージバーに表示し、左右キーで動かせるカーソルの位置に文字を挿入・削除する
+func (c *Controller) promptWith(prompt string, allowEmpty bool, validate func(string) error, complete func(string) []string) (input string, ok bool, err error) {
+	var runes []rune
+	cur := 0 // 入力中の文字列でのカーソルの位置
+	show := func(note string) {
+		c.screen.SetPrompt(prompt, runes, cur, note)
+		c.eventBus.Publish(event.NewRefreshEvent())
+	}
+	finish := func() {
+		c.screen.ClearPrompt()
+		c.setStatusMessage("")
+	}
+	show("")
 
-	var input []rune
 	for {
 		event, err := c.readEvent()
 		if err != nil {
-			return "", err
+			c.screen.ClearPrompt()
+			return "", false, err
 		}
 
 		switch event.Type {
 		case key.KeyEventChar:
-			input = append(input, event.Rune)
-			c.setStatusMessage(prompt + string(input))
+			if event.Modifiers.Has(key.ModAlt) {
+				continue
+			}
+			runes = append(runes[:cur], append([]rune{event.Rune}, runes[cur:]...)...)
+			cur++
+			show("")
 		case key.KeyEventSpecial:
 			switch event.Key {
 			case key.KeyEnter:
-				if len(input) > 0 {
-					c.setStatusMessage("")
-					return string(input), nil
+				if validate != nil {
+					if err := validate(string(runes)); err != nil {
+						show(fmt.Sprintf("  [%v]", err))
+						continue
+					}
+				}
+				if len(runes) > 0 || allowEmpty {
+					finish()
+					return string(runes), true, nil
 				}
 			case key.KeyBackspace:
-				if len(input) > 0 {
-					input = input[:len(input)-1]
-					c.setStatusMessage(prompt + string(input))
+				if cur > 0 {
+					runes = append(runes[:cur-1], runes[cur:]...)
+					cur--
+					show("")
+				}
+			case key.KeyArrowLeft:
+				if cur > 0 {
+					cur--
+					show("")
+				}
+			case key.KeyArrowRight:
+				if cur < len(runes) {
+					cur++
+					show("")
+				}
+			case key.KeyTab:
+				if complete == nil {
+					continue
+				}
+				// カーソルより前を補完し、後ろの入力はそのまま残す
+				head, tail := string(runes[:cur]), string(runes[cur:])
+				candidates := complete(head)
+				if prefix := command.CommonPrefix(candidates); len(prefix) > len(head) {
+					runes = []rune(prefix + tail)
+					cur = len([]rune(prefix))
+				}
+				switch len(candidates) {
+				case 0:
+					show("  [No match]")
+				case 1:
+					show("")
+				default:
+					show(fmt.Sprintf("  [%s]", completionSummary(candidates)))
 				}
 			case key.KeyEsc:
-				c.setStatusMessage("")
-				return "", nil
+				finish()
+				return "", false, nil
 			}
 		case key.KeyEventControl:
 			// コントロールキー（Ctrl+Cなど）が押された場合はキャンセル扱い
 			if event.Key == key.KeyCtrlC || event.Key == key.KeyCtrlX {
-				c.setStatusMessage("")
-				return "", nil
+				finish()
+				return "", false, nil
 			}
 		}
 	}
@@ -357,6 +357,19 @@ func TestController_OpenFile(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestController_OpenText(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc"})
+	controller.SetForceReadOnly(true)
+
+	assert.NoError(t, controller.OpenText([]byte("piped\r\ntext\r\n")))
+	assert.Equal(t, []string{"piped", "text", ""}, c.GetAllLines())
+	assert.Equal(t, contents.LineEndingCRLF, c.LineEnding())
+	assert.True(t, c.IsDirty(), "piped text is lost unless it is saved")
+	assert.True(t, c.ReadOnly())
+
+	assert.Error(t, controller.OpenText([]byte("bin\x00ary")))
+}
+
 func TestController_SpecialKeys(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
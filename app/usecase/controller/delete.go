@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// deleteForward はカーソル位置の文字を削除する
+func (c *Controller) deleteForward() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferDeleteNext, 0))
+}
+
+// performDeleteForward はカーソル位置の文字を削除する。カーソルは動かさない
+// 行末では次の行を結合し、選択中は Backspace と同じく選択範囲を削除する
+func (c *Controller) performDeleteForward() {
+	if c.selection.active && c.selection.block {
+		c.deleteBlock()
+		return
+	}
+	if start, end, ok := c.selectionRange(); ok {
+		c.replaceRange(start, end, nil)
+		return
+	}
+	c.clearSelection()
+	pos := c.screen.GetCursor().ToPosition()
+	start := contents.Position{X: pos.X, Y: pos.Y}
+	switch {
+	case pos.X < len([]rune(c.contents.GetContentLine(pos.Y))):
+		c.replaceRange(start, contents.Position{X: pos.X + 1, Y: pos.Y}, nil)
+	case pos.Y+1 < c.contents.GetLineCount():
+		c.replaceRange(start, contents.Position{Y: pos.Y + 1}, nil)
+	}
+}
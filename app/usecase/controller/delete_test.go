@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestDeleteForward(t *testing.T) {
+	del := special(key.KeyDelete)
+	controller, c := newKeyInputController(t, []string{"aいう", "xy"},
+		del, del, del, ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "aう", c.GetContentLine(0))
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X, "the cursor must stay in place")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"a", "xy"}, c.GetAllLines())
+
+	// 行末では次の行と結合する
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"axy"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"a", "xy"}, c.GetAllLines())
+
+	// 続けて削除した文字はまとめて元に戻す
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"aいう", "xy"}, c.GetAllLines())
+}
+
+func TestDeleteForward_EndOfBufferAndReadOnly(t *testing.T) {
+	del := special(key.KeyDelete)
+	controller, c := newKeyInputController(t, []string{"ab"}, del, del)
+	controller.screen.SetCursorPosition(2, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"ab"}, c.GetAllLines())
+	assert.False(t, c.IsDirty())
+
+	c.SetReadOnly(true)
+	controller.screen.SetCursorPosition(0, 0)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"ab"}, c.GetAllLines())
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// diskCheckInterval は開いているファイルがほかのプロセスに変更されたかを確認する間隔
+const diskCheckInterval = 2 * time.Second
+
+// diskWatch は開いているファイルの変更の確認の状態
+type diskWatch struct {
+	checkedAt time.Time             // 最後に確認した時刻
+	warned    filemanager.DiskStamp // 警告を表示した変更（同じ変更について繰り返し警告しない）
+}
+
+// checkDiskChange は開いているファイルがほかのプロセスに変更されていれば警告を表示する
+// 入力が止まった後の定期処理から呼び出すため、ファイルの状態の確認は diskCheckInterval ごとに行う
+func (c *Controller) checkDiskChange(now time.Time) {
+	if now.Sub(c.diskWatch.checkedAt) < diskCheckInterval {
+		return
+	}
+	c.diskWatch.checkedAt = now
+	watcher, ok := c.fileManager.(filemanager.DiskWatcher)
+	if !ok || c.isLoading() {
+		return
+	}
+	stamp, changed := watcher.DiskChange()
+	if !changed || stamp.Equal(c.diskWatch.warned) {
+		return
+	}
+	c.diskWatch.warned = stamp
+	c.setErrorMessage("%s changed on disk (revert-buffer: reload, keep-buffer: ignore, diff-disk: compare)",
+		filepath.Base(c.fileManager.GetFilename()))
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
+// keepBuffer はディスク上の変更を無視してバッファの内容を残す。次に保存するとディスク上の変更は上書きされる
+func (c *Controller) keepBuffer() {
+	watcher, ok := c.fileManager.(filemanager.DiskWatcher)
+	if !ok {
+		c.setStatusMessage("File changes on disk are not tracked")
+		return
+	}
+	stamp, changed := watcher.DiskChange()
+	if !changed {
+		c.setStatusMessage("File has not changed on disk")
+		return
+	}
+	watcher.AcceptDisk(stamp)
+	c.setStatusMessage("Keeping the buffer; saving will overwrite the file on disk")
+}
+
+// diffDisk はバッファとディスク上の内容の差分を情報パネルに表示する
+func (c *Controller) diffDisk() error {
+	c.waitForLoad()
+	preview, err := c.diskDiff()
+	if err != nil {
+		c.setErrorMessage("Cannot read the file on disk: %v", err)
+		return nil
+	}
+	if preview == nil {
+		c.setStatusMessage("Buffer matches the file on disk")
+		return nil
+	}
+	c.ShowOverlay(preview.Summary, preview.Diff)
+	return nil
+}
+
+// diskDiff はディスク上の内容をバッファの内容に変える差分を返す。違いがなければ nil を返す
+func (c *Controller) diskDiff() (*command.Preview, error) {
+	watcher, ok := c.fileManager.(filemanager.DiskWatcher)
+	if !ok {
+		return nil, fmt.Errorf("file changes on disk are not tracked")
+	}
+	lines, err := watcher.ReadDisk()
+	if err != nil {
+		return nil, err
+	}
+	diff := command.DiffLines(lines, c.contents.GetAllLines())
+	if len(diff) == 0 {
+		return nil, nil
+	}
+	return &command.Preview{
+		Summary: fmt.Sprintf("%s: - disk / + buffer", filepath.Base(c.fileManager.GetFilename())),
+		Diff:    diff,
+	}, nil
+}
+
+// confirmDiskOverwrite は開いているファイルがほかのプロセスに変更されていれば、上書きして保存するかを確認する
+// 上書きする場合は true を返す。読み直しを選んだ場合は変更を破棄して読み直し、false を返す
+func (c *Controller) confirmDiskOverwrite() (bool, error) {
+	watcher, ok := c.fileManager.(filemanager.DiskWatcher)
+	if !ok {
+		return true, nil
+	}
+	stamp, changed := watcher.DiskChange()
+	if !changed {
+		return true, nil
+	}
+	filename := filepath.Base(c.fileManager.GetFilename())
+	for {
+		c.setStatusMessage("%s changed on disk. Overwrite? (y: overwrite, r: reload, d: diff, n: cancel)", filename)
+		c.eventBus.Publish(event.NewRefreshEvent())
+		ev, err := c.readEvent()
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'y' || ev.Rune == 'Y'):
+			watcher.AcceptDisk(stamp)
+			return true, nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'r' || ev.Rune == 'R'):
+			c.eventBus.PublishAndWaitResponse(event.NewBufferEvent(event.BufferRevert, 0))
+			return false, nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'd' || ev.Rune == 'D'):
+			preview, err := c.diskDiff()
+			if err != nil {
+				c.setErrorMessage("Cannot read the file on disk: %v", err)
+				return false, nil
+			}
+			if preview == nil {
+				// 内容が同じなら、上書きしても失われる変更はない
+				watcher.AcceptDisk(stamp)
+				return true, nil
+			}
+			ok, err := c.confirmPreview(*preview)
+			if err != nil || !ok {
+				if err == nil {
+					c.setStatusMessage("Save cancelled")
+				}
+				return false, err
+			}
+			watcher.AcceptDisk(stamp)
+			return true, nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'n' || ev.Rune == 'N'),
+			ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			c.setStatusMessage("Save cancelled")
+			return false, nil
+		}
+	}
+}
+
+// acceptDiskState は自分で書き込んだ後のディスク上の状態を既知の状態とする
+func (c *Controller) acceptDiskState() {
+	if watcher, ok := c.fileManager.(filemanager.DiskWatcher); ok {
+		if stamp, changed := watcher.DiskChange(); changed {
+			watcher.AcceptDisk(stamp)
+		}
+	}
+}
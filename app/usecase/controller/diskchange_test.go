@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+)
+
+// diskFileManager はディスク上のファイルの変更を模したモック
+type diskFileManager struct {
+	*mock_filemanager.MockFileManager
+	stamp    filemanager.DiskStamp // ディスク上の現在の状態
+	accepted filemanager.DiskStamp // 既知の状態
+	disk     []string
+}
+
+func (fm *diskFileManager) DiskChange() (filemanager.DiskStamp, bool) {
+	return fm.stamp, !fm.stamp.Equal(fm.accepted)
+}
+
+func (fm *diskFileManager) AcceptDisk(stamp filemanager.DiskStamp) {
+	fm.accepted = stamp
+}
+
+func (fm *diskFileManager) ReadDisk() ([]string, error) {
+	return fm.disk, nil
+}
+
+func withDiskFileManager(controller *Controller) *diskFileManager {
+	fm := &diskFileManager{MockFileManager: controller.fileManager.(*mock_filemanager.MockFileManager)}
+	controller.fileManager = fm
+	return fm
+}
+
+func TestCheckDiskChange_WarnsOncePerChange(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a"})
+	fm := withDiskFileManager(controller)
+	now := time.Now()
+
+	controller.checkDiskChange(now)
+	assert.False(t, controller.screen.DismissMessage(), "unchanged files must not warn")
+
+	fm.stamp = filemanager.DiskStamp{ModTime: now, Size: 2}
+	controller.checkDiskChange(now.Add(time.Second))
+	assert.False(t, controller.screen.DismissMessage(), "checks are throttled")
+	controller.checkDiskChange(now.Add(diskCheckInterval))
+	assert.True(t, controller.screen.DismissMessage(), "the warning must persist until dismissed")
+
+	controller.checkDiskChange(now.Add(2 * diskCheckInterval))
+	assert.False(t, controller.screen.DismissMessage(), "the same change is reported once")
+
+	controller.keepBuffer()
+	_, changed := fm.DiskChange()
+	assert.False(t, changed)
+}
+
+func TestSave_ConfirmsOverwritingChangesOnDisk(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"buffer"}, char('n'), char('d'), char('y'))
+	fm := withDiskFileManager(controller)
+	fm.stamp = filemanager.DiskStamp{ModTime: time.Now(), Size: 5}
+	fm.disk = []string{"disk"}
+
+	// n で保存をやめる
+	assert.NoError(t, controller.saveCommand(nil))
+	_, changed := fm.DiskChange()
+	assert.True(t, changed)
+
+	// d で差分を確認し、y で上書きする
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(nil)
+	assert.NoError(t, controller.saveCommand(nil))
+	_, changed = fm.DiskChange()
+	assert.False(t, changed)
+
+	// 既知の状態なら確認せずに保存する
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(nil)
+	assert.NoError(t, controller.saveCommand(nil))
+}
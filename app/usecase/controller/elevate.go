@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+)
+
+// elevatedSave は書き込み権限がなく保存できなかった内容
+type elevatedSave struct {
+	filename string
+	result   save.Result
+}
+
+// SetTerminalSuspender は外部のコマンドに端末を使わせる間、端末を Raw モードから戻す関数を設定する
+// sudo がパスワードを端末から読めるようにするために使う
+func (c *Controller) SetTerminalSuspender(suspend func(run func() error) error) {
+	c.suspendTerminal = suspend
+}
+
+// deferElevatedSave は保存の失敗が書き込み権限によるものなら、内容を保持して true を返す
+// 保存先が開いているファイルと異なる場合（別名で保存）は、バッファのファイル名を更新できないため対象にしない
+func (c *Controller) deferElevatedSave(filename string, result save.Result, err error) bool {
+	if !errors.Is(err, fs.ErrPermission) || filename != c.fileManager.GetFilename() {
+		return false
+	}
+	c.logger.Log("event", fmt.Sprintf("No write permission for %s: %v", filename, err))
+	c.pendingElevatedSave = &elevatedSave{filename: filename, result: result}
+	return true
+}
+
+// promptElevatedSave は書き込み権限がなく保存できなかった内容を、設定したコマンド（sudo tee など）で保存するかを尋ねる
+// y で端末をコマンドに明け渡して保存する。パスワードはコマンドが直接端末から読むため、エディタは受け取らない
+func (c *Controller) promptElevatedSave() error {
+	pending := c.pendingElevatedSave
+	c.pendingElevatedSave = nil
+
+	c.setStatusMessage("Permission denied: %s. Save with '%s'? (y/n)", filepath.Base(pending.filename), c.elevatedWriter.Command())
+	c.eventBus.Publish(event.NewRefreshEvent())
+	for {
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'y' || ev.Rune == 'Y'):
+			c.saveElevated(pending)
+			return nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'n' || ev.Rune == 'N'),
+			ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			c.setErrorMessage("Not saved: no write permission for %s", pending.filename)
+			return nil
+		}
+	}
+}
+
+// saveElevated は端末の Raw モードを解除した状態で、設定したコマンドに内容を書き込ませる
+// 入力を読むメインのゴルーチンで実行するため、コマンドの実行中に編集が割り込むことはない
+func (c *Controller) saveElevated(pending *elevatedSave) {
+	data, err := filemanager.Encode(strings.Join(pending.result.Lines, c.contents.LineEnding().Separator()), c.contents.Encoding())
+	if err == nil {
+		err = c.handOverTerminal(func() error {
+			return c.elevatedWriter.WriteFile(pending.filename, data)
+		})
+	}
+	if err != nil {
+		c.setErrorMessage("Failed to save with '%s': %v", c.elevatedWriter.Command(), err)
+		c.eventBus.Publish(event.NewRefreshEvent())
+		return
+	}
+	c.contents.SetDirty(false)
+	if pending.filename == c.fileManager.GetFilename() {
+		c.acceptDiskState()
+	}
+	c.finishSave(pending.filename, pending.result)
+}
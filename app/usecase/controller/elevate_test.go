@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// fakeElevatedWriter は書き込んだ内容を記録する elevate.Writer
+type fakeElevatedWriter struct {
+	written map[string]string
+	err     error
+}
+
+func (w *fakeElevatedWriter) WriteFile(filename string, data []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.written[filename] = string(data)
+	return nil
+}
+
+func (w *fakeElevatedWriter) Command() string {
+	return "sudo tee"
+}
+
+func newElevateController(t *testing.T, events ...key.KeyEvent) (*Controller, *mock_filemanager.MockFileManager, *fakeElevatedWriter, *int) {
+	t.Helper()
+	controller, c := newKeyInputController(t, []string{"a", "b"}, events...)
+	c.SetDirty(true)
+	writer := &fakeElevatedWriter{written: make(map[string]string)}
+	controller.elevatedWriter = writer
+	suspended := 0
+	controller.SetTerminalSuspender(func(run func() error) error {
+		suspended++
+		return run()
+	})
+	return controller, controller.fileManager.(*mock_filemanager.MockFileManager), writer, &suspended
+}
+
+func TestSave_PermissionDeniedSavesWithElevation(t *testing.T) {
+	controller, fm, writer, suspended := newElevateController(t, char('y'))
+	fm.EXPECT().SaveFile("test.txt", []string{"a", "b"}).Return(&fs.PathError{Op: "open", Path: "test.txt", Err: fs.ErrPermission})
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.Equal(t, map[string]string{"test.txt": "a\nb"}, writer.written)
+	assert.Equal(t, 1, *suspended, "the terminal must be handed to the command")
+	assert.False(t, controller.contents.IsDirty())
+	assert.Equal(t, 1, controller.saveCount)
+	assert.Nil(t, controller.pendingElevatedSave)
+}
+
+func TestSave_ElevatedWriteStopsRedraws(t *testing.T) {
+	controller, fm, _, _ := newElevateController(t, char('y'))
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(fs.ErrPermission)
+	handedOver := false
+	controller.SetTerminalSuspender(func(run func() error) error {
+		handedOver = controller.terminalHandedOver
+		return run()
+	})
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.True(t, handedOver, "redraws must not paint over the password prompt")
+	assert.False(t, controller.terminalHandedOver)
+}
+
+func TestSave_PermissionDeniedDeclined(t *testing.T) {
+	controller, fm, writer, suspended := newElevateController(t, char('x'), char('n'))
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(fs.ErrPermission)
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.Empty(t, writer.written)
+	assert.Zero(t, *suspended)
+	assert.True(t, controller.contents.IsDirty())
+	assert.Zero(t, controller.saveCount)
+}
+
+func TestSave_ElevatedWriteFails(t *testing.T) {
+	controller, fm, writer, _ := newElevateController(t, char('y'))
+	writer.err = errors.New("sudo: 3 incorrect password attempts")
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(fs.ErrPermission)
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.True(t, controller.contents.IsDirty(), "a failed write must keep the buffer modified")
+	assert.Zero(t, controller.saveCount)
+}
+
+func TestSave_OnlyOffersElevationForPermissionErrorsOnTheOpenFile(t *testing.T) {
+	// 入力のイベントを用意していないため、尋ねた場合はモックがテストを失敗させる
+	controller, fm, writer, _ := newElevateController(t)
+	fm.EXPECT().SaveFile("test.txt", gomock.Any()).Return(fmt.Errorf("disk full"))
+	fm.EXPECT().SaveFile("other.txt", gomock.Any()).Return(fs.ErrPermission)
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.NoError(t, controller.commands.Execute("save", []string{"other.txt"}))
+	assert.Empty(t, writer.written)
+	assert.Nil(t, controller.pendingElevatedSave)
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// encodingNames は引数に指定できる文字コードの表記を返す
+func encodingNames() []string {
+	names := make([]string, len(contents.Encodings))
+	for i, enc := range contents.Encodings {
+		names[i] = strings.ToLower(enc.String())
+	}
+	return names
+}
+
+// completeEncoding は文字コードの引数を補完する
+func completeEncoding(arg string) []string {
+	var matches []string
+	for _, name := range encodingNames() {
+		if strings.HasPrefix(name, strings.ToLower(arg)) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// parseEncodingArg はコマンドの引数を文字コードに変換する。変換できなければ表記の一覧を表示して false を返す
+func (c *Controller) parseEncodingArg(args []string) (contents.Encoding, bool) {
+	if len(args) == 0 {
+		c.setStatusMessage("Encoding: %s (%s)", c.contents.Encoding(), strings.Join(encodingNames(), ", "))
+		return contents.EncodingUTF8, false
+	}
+	enc, ok := contents.ParseEncoding(args[0])
+	if !ok {
+		c.setStatusMessage("Unknown encoding %q (%s)", args[0], strings.Join(encodingNames(), ", "))
+	}
+	return enc, ok
+}
+
+// setEncodingCommand は保存する際の文字コードを引数に変える。引数がなければ現在の文字コードを表示する
+func (c *Controller) setEncodingCommand(args []string) error {
+	if enc, ok := c.parseEncodingArg(args); ok {
+		c.eventBus.Publish(event.NewEncodingEvent(enc))
+	}
+	return nil
+}
+
+// performEncoding は保存する際の文字コードを enc に変える
+// enc で表せない文字を含む場合は、保存に失敗しないよう変えずにその文字を知らせる
+func (c *Controller) performEncoding(enc contents.Encoding) {
+	if c.contents.ReadOnly() {
+		c.reportEditError(contents.ErrReadOnly)
+		return
+	}
+	if enc == c.contents.Encoding() {
+		c.setStatusMessage("Encoding is already %s", enc)
+		return
+	}
+	if _, err := filemanager.Encode(strings.Join(c.contents.GetAllLines(), "\n"), enc); err != nil {
+		c.setErrorMessage("Cannot convert to %s: %v", enc, err)
+		return
+	}
+	c.contents.SetEncoding(enc)
+	c.contents.SetDirty(true)
+	c.setStatusMessage("Encoding: %s (applied on save)", enc)
+}
+
+// reopenWithEncodingCommand は変更を破棄して、文字コードを引数としてファイルを読み直す
+// 文字コードの判定を誤った場合に使う。未保存の変更がある場合は破棄してよいか確認する
+func (c *Controller) reopenWithEncodingCommand(args []string) error {
+	enc, ok := c.parseEncodingArg(args)
+	if !ok {
+		return nil
+	}
+	filename := c.fileManager.GetFilename()
+	if filename == "" {
+		c.setStatusMessage("Buffer has no file to reopen")
+		return nil
+	}
+	if ok, err := c.confirmDiscard(filename); err != nil || !ok {
+		return err
+	}
+	c.eventBus.PublishAndWaitResponse(event.NewReopenEvent(enc))
+	return nil
+}
+
+// performReopen は文字コードを enc としてファイルを読み直す
+func (c *Controller) performReopen(enc contents.Encoding) {
+	opener, ok := c.fileManager.(filemanager.EncodingOpener)
+	if !ok {
+		c.setStatusMessage("Reopening with another encoding is not supported")
+		return
+	}
+	open := func(filename string) error {
+		return opener.OpenFileWithEncoding(filename, enc)
+	}
+	if err := c.reloadFile(open); err != nil {
+		c.setErrorMessage("Cannot reopen as %s: %v", enc, err)
+		return
+	}
+	c.setStatusMessage("Reopened %s as %s", c.fileManager.GetFilename(), enc)
+}
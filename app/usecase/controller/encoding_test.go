@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestSetEncoding(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"日本語"})
+
+	assert.NoError(t, controller.setEncodingCommand([]string{"sjis"}))
+	assert.Equal(t, contents.EncodingShiftJIS, c.Encoding())
+	assert.Equal(t, []string{"日本語"}, c.GetAllLines(), "the buffer keeps the text; it is converted on save")
+	assert.True(t, c.IsDirty())
+
+	// 引数がない場合と表記が不正な場合は変えない
+	c.SetDirty(false)
+	assert.NoError(t, controller.setEncodingCommand(nil))
+	assert.NoError(t, controller.setEncodingCommand([]string{"utf-16"}))
+	assert.NoError(t, controller.setEncodingCommand([]string{"Shift_JIS"}))
+	assert.Equal(t, contents.EncodingShiftJIS, c.Encoding())
+	assert.False(t, c.IsDirty())
+
+	// 表せない文字を含む場合は変えない
+	c.LoadContent([]string{"日本語 😀"})
+	c.SetEncoding(contents.EncodingUTF8)
+	assert.NoError(t, controller.setEncodingCommand([]string{"euc-jp"}))
+	assert.Equal(t, contents.EncodingUTF8, c.Encoding())
+	assert.False(t, c.IsDirty())
+
+	assert.Equal(t, []string{"euc-jp"}, completeEncoding("E"))
+	assert.Len(t, completeEncoding(""), len(contents.Encodings))
+}
+
+// encodingFileManager は文字コードを指定して開き直せるモック
+type encodingFileManager struct {
+	*mock_filemanager.MockFileManager
+	open func(filename string, enc contents.Encoding) error
+}
+
+func (fm *encodingFileManager) OpenFileWithEncoding(filename string, enc contents.Encoding) error {
+	return fm.open(filename, enc)
+}
+
+func TestReopenWithEncoding(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"\u0093ú\u0096{"}, char('y'))
+	controller.fileManager = &encodingFileManager{
+		MockFileManager: controller.fileManager.(*mock_filemanager.MockFileManager),
+		open: func(filename string, enc contents.Encoding) error {
+			assert.Equal(t, "test.txt", filename)
+			c.LoadContent([]string{"日本"})
+			c.SetEncoding(enc)
+			return nil
+		},
+	}
+	controller.performInsertChar('x')
+
+	// 変更がある場合は確認してから読み直す
+	assert.NoError(t, controller.reopenWithEncodingCommand([]string{"shift_jis"}))
+	assert.Equal(t, []string{"日本"}, c.GetAllLines())
+	assert.Equal(t, contents.EncodingShiftJIS, c.Encoding())
+	assert.False(t, c.IsDirty())
+
+	// 読み直しは取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"x\u0093ú\u0096{"}, c.GetAllLines())
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/wasya-io/go-kilo/app/boundary/dirlist"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+const browserFooter = "Enter: open  Backspace: parent  Up/Down: move  type: jump  Esc: close"
+
+// parentEntry は一覧の先頭に置く親ディレクトリの項目の名前
+const parentEntry = ".."
+
+// SetDirLister はファイル一覧に使う Lister を設定する（主にテスト用）
+func (c *Controller) SetDirLister(l dirlist.Lister) {
+	c.dirLister = l
+}
+
+// browseStart はファイル一覧を開く最初のディレクトリを返す
+// 開いているファイルがあればそのディレクトリ、なければ作業ディレクトリ
+func (c *Controller) browseStart() string {
+	dir := "."
+	if filename := c.fileManager.GetFilename(); filename != "" {
+		dir = filepath.Dir(filename)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return dir
+}
+
+// browserEntries は dir の項目を、親ディレクトリがあればその項目を先頭にして返す
+func (c *Controller) browserEntries(dir string) ([]dirlist.Entry, error) {
+	entries, err := c.dirLister.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Dir(dir) != dir {
+		entries = append([]dirlist.Entry{{Name: parentEntry, Dir: true}}, entries...)
+	}
+	return entries, nil
+}
+
+// FileBrowser はディレクトリの一覧からファイルを選んで開く
+func (c *Controller) FileBrowser() error {
+	path, ok, err := c.browseFile()
+	if err != nil || !ok {
+		return err
+	}
+	return c.openFileCommand([]string{displayPath(path)})
+}
+
+// browseFile はディレクトリの一覧を表示し、選んだファイルのパスを返す。閉じた場合 ok は false
+// 上下キーで選び、Enter でファイルを選ぶかディレクトリに入る。Backspace で親ディレクトリへ戻る
+// 文字を入力すると、その文字で始まる次の項目へ移動する
+func (c *Controller) browseFile() (path string, ok bool, err error) {
+	dir := c.browseStart()
+	entries, err := c.browserEntries(dir)
+	if err != nil {
+		c.setErrorMessage("Cannot list %s: %v", dir, err)
+		return "", false, nil
+	}
+	selected := 0
+
+	// enter は target のディレクトリに移り、名前が focus の項目を選ぶ。一覧にできなければ移らない
+	enter := func(target, focus string) {
+		list, err := c.browserEntries(target)
+		if err != nil {
+			c.setErrorMessage("Cannot list %s: %v", target, err)
+			return
+		}
+		dir, entries, selected = target, list, 0
+		for i, e := range entries {
+			if e.Name == focus {
+				selected = i
+			}
+		}
+	}
+	up := func() {
+		if parent := filepath.Dir(dir); parent != dir {
+			enter(parent, filepath.Base(dir))
+		}
+	}
+
+	for {
+		c.screen.SetBrowserOverlay(dir, browserItems(entries), selected, browserFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			c.dismissOverlay()
+			return "", false, err
+		}
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Modifiers.Has(key.ModAlt) {
+				continue
+			}
+			selected = nextEntryWith(entries, selected, ev.Rune)
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if selected > 0 {
+					selected--
+				}
+			case key.KeyArrowDown:
+				if selected < len(entries)-1 {
+					selected++
+				}
+			case key.KeyBackspace, key.KeyArrowLeft:
+				up()
+			case key.KeyEnter, key.KeyArrowRight:
+				if len(entries) == 0 {
+					continue
+				}
+				e := entries[selected]
+				switch {
+				case e.Name == parentEntry:
+					up()
+				case e.Dir:
+					enter(filepath.Join(dir, e.Name), "")
+				case ev.Key == key.KeyEnter:
+					c.dismissOverlay()
+					return filepath.Join(dir, e.Name), true, nil
+				}
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return "", false, nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX || ev.Key == key.KeyCtrlO {
+				c.dismissOverlay()
+				return "", false, nil
+			}
+		}
+	}
+}
+
+// browserItems は一覧の項目を情報パネルに表示する形にする
+func browserItems(entries []dirlist.Entry) []screen.BrowserItem {
+	items := make([]screen.BrowserItem, len(entries))
+	for i, e := range entries {
+		items[i] = screen.BrowserItem{Name: e.Name, Dir: e.Dir}
+		if !e.Dir {
+			items[i].Detail = formatSize(e.Size)
+		}
+	}
+	return items
+}
+
+// nextEntryWith は selected の次から順に、r で始まる項目を探す（大文字小文字は区別しない）
+// 見つからなければ selected を返す
+func nextEntryWith(entries []dirlist.Entry, selected int, r rune) int {
+	for i := 1; i <= len(entries); i++ {
+		j := (selected + i) % len(entries)
+		name := []rune(entries[j].Name)
+		if len(name) > 0 && unicode.ToLower(name[0]) == unicode.ToLower(r) && entries[j].Name != parentEntry {
+			return j
+		}
+	}
+	return selected
+}
+
+// formatSize はファイルの大きさを短く表す（例: 512B, 1.5K, 12M）
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value := float64(size)
+	for _, suffix := range []string{"K", "M", "G", "T"} {
+		value /= unit
+		if value < unit || suffix == "T" {
+			if value < 10 {
+				return fmt.Sprintf("%.1f%s", value, suffix)
+			}
+			return fmt.Sprintf("%.0f%s", value, suffix)
+		}
+	}
+	return ""
+}
+
+// displayPath は path を作業ディレクトリからの相対パスにする
+// 作業ディレクトリの外のパスは絶対パスのまま返す
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
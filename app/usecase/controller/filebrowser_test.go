@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/dirlist"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// fakeLister は絶対パスごとに決めた項目を返す dirlist.Lister
+type fakeLister map[string][]dirlist.Entry
+
+func (f fakeLister) List(dir string) ([]dirlist.Entry, error) {
+	entries, ok := f[dir]
+	if !ok {
+		return nil, errors.New("permission denied")
+	}
+	return append([]dirlist.Entry(nil), entries...), nil
+}
+
+func newBrowserController(t *testing.T, events ...key.KeyEvent) (*Controller, string) {
+	t.Helper()
+	// テスト用のコントローラーのファイル名は test.txt なので、作業ディレクトリから一覧を始める
+	wd, err := filepath.Abs(".")
+	assert.NoError(t, err)
+	controller, _ := newKeyInputController(t, []string{""}, events...)
+	controller.SetDirLister(fakeLister{
+		wd: {
+			{Name: "docs", Dir: true},
+			{Name: "locked", Dir: true},
+			{Name: "main.go", Size: 2048},
+			{Name: "Makefile", Size: 10},
+		},
+		filepath.Join(wd, "docs"):      {{Name: "guide.md", Size: 1}},
+		filepath.Dir(wd):               {{Name: filepath.Base(wd), Dir: true}, {Name: "other.txt"}},
+		filepath.Dir(filepath.Dir(wd)): {{Name: filepath.Base(filepath.Dir(wd)), Dir: true}},
+	})
+	return controller, wd
+}
+
+func TestBrowseFile_EntersDirectoriesAndPicksFile(t *testing.T) {
+	controller, wd := newBrowserController(t,
+		// ".." の次の docs に入り、guide.md を選ぶ
+		special(key.KeyArrowDown), special(key.KeyEnter), special(key.KeyArrowDown), special(key.KeyEnter),
+	)
+
+	path, ok, err := controller.browseFile()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(wd, "docs", "guide.md"), path)
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestBrowseFile_ParentAndJump(t *testing.T) {
+	controller, wd := newBrowserController(t,
+		// 一覧にできないディレクトリには入らない
+		char('l'), special(key.KeyEnter),
+		// 文字で始まる項目へ移動する（大文字小文字は区別しない）
+		char('m'), char('m'),
+		// Backspace で親に戻ると、元のディレクトリが選ばれている
+		special(key.KeyBackspace), special(key.KeyArrowDown), special(key.KeyEnter),
+	)
+
+	path, ok, err := controller.browseFile()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(filepath.Dir(wd), "other.txt"), path)
+}
+
+func TestBrowseFile_Cancel(t *testing.T) {
+	controller, _ := newBrowserController(t, special(key.KeyArrowDown), ctrlKey(key.KeyCtrlO))
+
+	_, ok, err := controller.browseFile()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{
+		0:          "0B",
+		1023:       "1023B",
+		1536:       "1.5K",
+		20 * 1024:  "20K",
+		5 << 20:    "5.0M",
+		3 << 40:    "3.0T",
+		4096 << 40: "4096T",
+	} {
+		assert.Equal(t, want, formatSize(size), size)
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("docs", "a.md"), displayPath(filepath.Join(wd, "docs", "a.md")))
+	outside := filepath.Join(filepath.Dir(wd), "b.md")
+	assert.Equal(t, outside, displayPath(outside))
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/filetype"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+const fileTypeFooter = "Up/Down: move  Enter: select  Esc: close"
+
+// SelectFileType はファイルの種類を一覧から選び、ファイル名から判定した種類の代わりに使う
+// ファイル名から判定した種類を選ぶと、設定を解除して判定に戻す
+func (c *Controller) SelectFileType() error {
+	names := filetype.Names()
+	current := screen.FileTypeOf(c.contents, c.fileManager.GetFilename())
+	selected := 0
+	for i, name := range names {
+		if name == current {
+			selected = i
+		}
+	}
+
+	for {
+		c.screen.SetListOverlay(fmt.Sprintf("File type (%s)", current), names, selected, fileTypeFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch ev.Type {
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if selected > 0 {
+					selected--
+				}
+			case key.KeyArrowDown:
+				if selected < len(names)-1 {
+					selected++
+				}
+			case key.KeyEnter:
+				c.dismissOverlay()
+				c.setFileType(names[selected])
+				return nil
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX {
+				c.dismissOverlay()
+				return nil
+			}
+		}
+	}
+}
+
+// setFileType はバッファのファイルの種類を name に設定する
+func (c *Controller) setFileType(name string) {
+	if name == filetype.Detect(c.fileManager.GetFilename()) {
+		name = ""
+	}
+	c.contents.SetFileType(name)
+	c.setStatusMessage("File type: %s", screen.FileTypeOf(c.contents, c.fileManager.GetFilename()))
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wasya-io/go-kilo/app/boundary/fileindex"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/finder"
+)
+
+const finderFooter = "Type to filter  Up/Down: move  Enter: open  Esc: close"
+
+// maxFinderResults はファイル検索で一覧に表示する件数の上限
+const maxFinderResults = 200
+
+// SetFileLister はファイル検索の一覧を作成する Lister を設定する（主にテスト用）
+func (c *Controller) SetFileLister(l fileindex.Lister) {
+	c.fileIndex.Stop()
+	c.fileLister = l
+	c.fileIndex = finder.NewIndex(l)
+}
+
+// FindFile は作業ディレクトリ以下のファイルをパスのあいまい検索で選んで開く
+func (c *Controller) FindFile() error {
+	path, ok, err := c.findFile()
+	if err != nil || !ok {
+		return err
+	}
+	return c.openFileCommand([]string{displayPath(path)})
+}
+
+// findFile は作業ディレクトリ以下のファイルの一覧を入力で絞り込み、選んだファイルのパスを返す。閉じた場合 ok は false
+// ファイルの一覧は開くたびに裏で作り直し、完了するまでは前回の一覧（初回は見つけた分）から探す
+func (c *Controller) findFile() (path string, ok bool, err error) {
+	root, err := os.Getwd()
+	if err != nil {
+		c.setErrorMessage("Cannot find files: %v", err)
+		return "", false, nil
+	}
+	if s := c.fileIndex.Snapshot(); s.Root != root || !s.Indexing {
+		c.fileIndex.Start(root)
+	}
+
+	var query []rune
+	selected := 0
+	for {
+		snapshot := c.fileIndex.Snapshot()
+		matches := finder.Rank(snapshot.Paths, string(query), maxFinderResults)
+		selected = min(max(selected, 0), max(len(matches)-1, 0))
+		c.screen.SetListOverlay("> "+string(query)+finderStatus(snapshot, len(matches)), finderLines(matches, snapshot), selected, finderFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			c.dismissOverlay()
+			return "", false, err
+		}
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Modifiers.Has(key.ModAlt) {
+				continue
+			}
+			query = append(query, ev.Rune)
+			selected = 0
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				selected--
+			case key.KeyArrowDown:
+				selected++
+			case key.KeyBackspace:
+				if len(query) > 0 {
+					query = query[:len(query)-1]
+					selected = 0
+				}
+			case key.KeyEnter:
+				if len(matches) == 0 {
+					continue
+				}
+				c.dismissOverlay()
+				return filepath.Join(snapshot.Root, matches[selected].Path), true, nil
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return "", false, nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX || ev.Key == key.KeyCtrlP {
+				c.dismissOverlay()
+				return "", false, nil
+			}
+		}
+	}
+}
+
+// finderStatus は一致した件数と一覧の作成状況をプロンプトの後ろに表示する形にする
+func finderStatus(snapshot finder.Snapshot, shown int) string {
+	status := fmt.Sprintf("  (%d/%d", shown, len(snapshot.Paths))
+	switch {
+	case snapshot.Indexing:
+		status += ", indexing..."
+	case errors.Is(snapshot.Err, fileindex.ErrTooManyFiles):
+		status += ", truncated"
+	}
+	return status + ")"
+}
+
+// finderLines は検索結果のパスを並べる
+func finderLines(matches []finder.Match, snapshot finder.Snapshot) []string {
+	if len(matches) == 0 {
+		if snapshot.Indexing {
+			return []string{"Indexing files..."}
+		}
+		return []string{"No matching files"}
+	}
+	lines := make([]string, len(matches))
+	for i, m := range matches {
+		lines[i] = m.Path
+	}
+	return lines
+}
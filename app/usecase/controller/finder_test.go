@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// fakeFileLister は決まったパスを列挙する fileindex.Lister
+type fakeFileLister []string
+
+func (f fakeFileLister) List(ctx context.Context, root string, found func([]string)) error {
+	found(append([]string(nil), f...))
+	return nil
+}
+
+func newFinderController(t *testing.T, events ...key.KeyEvent) (*Controller, string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	controller, _ := newKeyInputController(t, []string{""}, events...)
+	controller.SetFileLister(fakeFileLister{
+		filepath.Join("app", "usecase", "controller", "controller.go"),
+		filepath.Join("docs", "control.md"),
+		"README.md",
+	})
+	// 作り直しの間は前回の一覧から探すので、先に一覧を作っておくと結果が決まる
+	controller.fileIndex.Start(wd)
+	controller.fileIndex.Wait()
+	t.Cleanup(controller.fileIndex.Stop)
+	return controller, wd
+}
+
+func TestFindFile_FiltersAndPicks(t *testing.T) {
+	controller, wd := newFinderController(t,
+		// "ctl" はどちらにも一致し、短い docs/control.md が先に並ぶ
+		char('c'), char('t'), char('l'), special(key.KeyArrowDown), special(key.KeyEnter),
+	)
+
+	path, ok, err := controller.findFile()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(wd, "app", "usecase", "controller", "controller.go"), path)
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestFindFile_NoMatchAndCancel(t *testing.T) {
+	controller, _ := newFinderController(t,
+		// 一致するファイルがなければ Enter では閉じない
+		char('x'), char('y'), special(key.KeyEnter),
+		special(key.KeyBackspace), ctrlKey(key.KeyCtrlP),
+	)
+
+	_, ok, err := controller.findFile()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestFindFile_KeyBinding(t *testing.T) {
+	controller, _ := newFinderController(t, ctrlKey(key.KeyCtrlP), special(key.KeyEsc))
+
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.screen.HasOverlay())
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/git"
+)
+
+// gitTimeout は git の状態を調べるのを待つ時間の上限
+const gitTimeout = 5 * time.Second
+
+// gitRepository はファイルの変更とブランチを調べる git の操作
+type gitRepository interface {
+	Changes(ctx context.Context, path string) (git.LineChanges, error)
+	Branch(ctx context.Context, dir string) (string, error)
+}
+
+// gitKey は git の状態を調べ直す契機。表示するファイルが変わるか、保存するたびに調べ直す
+type gitKey struct {
+	path  string
+	saves int
+}
+
+// gitState は表示中のファイルのガターの印とブランチ
+// 調べるのは別の goroutine で行うため、結果は mutex で保護する
+type gitState struct {
+	repo      gitRepository // nil なら表示しない
+	mutex     sync.Mutex
+	requested gitKey          // 最後に調べ始めた契機
+	path      string          // changes と branch を調べたファイル
+	changes   git.LineChanges // nil ならリポジトリの外
+	branch    string
+}
+
+// updateGitStatus は表示中のファイルの変更の印とブランチを画面に設定する
+// ファイルを切り替えたか保存した後は、git を別の goroutine で実行して調べ直し、終わったら再描画する
+// 調べ終わるまでは同じファイルの前回の結果を表示する
+func (c *Controller) updateGitStatus() {
+	s := &c.gitStatus
+	path := c.documentPath()
+	if s.repo == nil || path == "" {
+		c.screen.SetGitChanges(nil)
+		c.screen.SetBranch("")
+		return
+	}
+
+	key := gitKey{path: path, saves: c.saveCount}
+	s.mutex.Lock()
+	if key != s.requested {
+		s.requested = key
+		go c.loadGitStatus(s.repo, key)
+	}
+	changes, branch := s.changes, s.branch
+	if s.path != path {
+		changes, branch = nil, ""
+	}
+	s.mutex.Unlock()
+
+	c.screen.SetGitChanges(changes)
+	c.screen.SetBranch(branch)
+}
+
+// loadGitStatus は key のファイルの変更とブランチを調べて記録し、再描画する
+// リポジトリの外のファイルや git が失敗した場合は何も表示しない。調べている間に別の契機があれば結果を捨てる
+func (c *Controller) loadGitStatus(repo gitRepository, key gitKey) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	changes, err := repo.Changes(ctx, key.path)
+	branch := ""
+	if err == nil {
+		branch, err = repo.Branch(ctx, filepath.Dir(key.path))
+	}
+	if err != nil {
+		changes, branch = nil, ""
+	}
+
+	s := &c.gitStatus
+	s.mutex.Lock()
+	if s.requested != key {
+		s.mutex.Unlock()
+		return
+	}
+	s.path, s.changes, s.branch = key.path, changes, branch
+	s.mutex.Unlock()
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/git"
+)
+
+// fakeGitRepository は決まった変更とブランチを返す
+type fakeGitRepository struct {
+	changes git.LineChanges
+	branch  string
+	err     error
+}
+
+func (r *fakeGitRepository) Changes(ctx context.Context, path string) (git.LineChanges, error) {
+	return r.changes, r.err
+}
+
+func (r *fakeGitRepository) Branch(ctx context.Context, dir string) (string, error) {
+	return r.branch, r.err
+}
+
+func TestGitStatus_ShowsChanges(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a", "b"})
+	repo := &fakeGitRepository{changes: git.LineChanges{1: git.ChangeModified}, branch: "main"}
+	controller.gitStatus.repo = repo
+	path, _ := filepath.Abs("test.txt")
+	key := gitKey{path: path}
+
+	// 調べている間に別の契機があった結果は捨てる
+	controller.gitStatus.requested = gitKey{path: path, saves: 1}
+	controller.loadGitStatus(repo, key)
+	assert.Nil(t, controller.gitStatus.changes)
+
+	controller.gitStatus.requested = key
+	controller.loadGitStatus(repo, key)
+	controller.updateGitStatus()
+	assert.Equal(t, 1, controller.screen.GutterWidth())
+	assert.Equal(t, "main", controller.gitStatus.branch)
+
+	// リポジトリの外のファイルではガターを表示しない
+	repo.err = errors.New("not a git repository")
+	controller.loadGitStatus(repo, key)
+	controller.updateGitStatus()
+	assert.Equal(t, 0, controller.screen.GutterWidth())
+	assert.Equal(t, "", controller.gitStatus.branch)
+}
+
+func TestGitStatus_Disabled(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a"})
+	controller.updateGitStatus()
+	assert.Equal(t, 0, controller.screen.GutterWidth())
+	assert.Equal(t, gitKey{}, controller.gitStatus.requested, "nothing is run without a repository")
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/word"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+)
+
+// goDocTimeout は go doc の実行時間の上限
+const goDocTimeout = 15 * time.Second
+
+// qualifiedIdentifierAt はカーソル下の識別子を返す
+// "fmt.Println" のようにパッケージ名で修飾されている場合は修飾部分も含める
+func qualifiedIdentifierAt(runes []rune, x int) string {
+	start, end, ok := word.At(runes, x)
+	if !ok {
+		return ""
+	}
+	// 修飾子を左側に辿る
+	for start > 1 && runes[start-1] == '.' && word.IsWordRune(runes[start-2]) {
+		start = word.PrefixStart(runes, start-1)
+	}
+	return string(runes[start:end])
+}
+
+// showGoDoc はカーソル下の識別子の go doc を非同期で取得して情報パネルに表示する
+func (c *Controller) showGoDoc() {
+	filename := c.fileManager.GetFilename()
+	if filepath.Ext(filename) != ".go" {
+		c.setStatusMessage("go doc is only available for Go files")
+		return
+	}
+
+	pos := c.screen.GetCursor().ToPosition()
+	ident := qualifiedIdentifierAt([]rune(c.contents.GetContentLine(pos.Y)), pos.X)
+	if ident == "" {
+		c.setStatusMessage("No identifier under cursor")
+		return
+	}
+
+	c.setStatusMessage("Running go doc %s...", ident)
+	dir := filepath.Dir(filename)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), goDocTimeout)
+		defer cancel()
+
+		out, err := c.runner.Run(ctx, dir, "go", []string{"doc", ident}, nil)
+		if err != nil {
+			c.postResult(func() { c.setStatusMessage("go doc %s: %v", ident, err) })
+			return
+		}
+		lines := save.SplitOutput(out)
+		for i, line := range lines {
+			// タブは情報パネル内で幅がずれるため空白に展開する
+			lines[i] = strings.ReplaceAll(line, "\t", "    ")
+		}
+		c.postResult(func() {
+			c.setStatusMessage("")
+			c.ShowOverlay("go doc "+ident, lines)
+		})
+	}()
+}
@@ -0,0 +1,25 @@
+package controller
+
+import "testing"
+
+func TestQualifiedIdentifierAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		x        int
+		expected string
+	}{
+		{"修飾された識別子", "\tfmt.Println(x)", 7, "fmt.Println"},
+		{"パッケージ名の上", "\tfmt.Println(x)", 2, "fmt"},
+		{"単独の識別子", "x := strings", 8, "strings"},
+		{"識別子なし", "a  b", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qualifiedIdentifierAt([]rune(tt.line), tt.x); got != tt.expected {
+				t.Errorf("qualifiedIdentifierAt(%q, %d) = %q, want %q", tt.line, tt.x, got, tt.expected)
+			}
+		})
+	}
+}
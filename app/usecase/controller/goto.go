@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// errInvalidLine は行番号として解釈できない入力のエラー
+var errInvalidLine = errors.New("enter line or line:col")
+
+// gotoLineCommand は指定した行（1 始まり）へ移動する。引数がない場合は入力を求める
+// "行:列" の形式で列（1 始まり）も指定でき、先頭の ":" は省略できる（例: "12", ":12", "12:5"）
+// 範囲外の行・列はバッファの範囲に丸める
+func (c *Controller) gotoLineCommand(args []string) error {
+	var input string
+	if len(args) > 0 {
+		input = args[0]
+	} else {
+		var err error
+		input, _, err = c.promptValidated("Go to line: ", false, func(s string) error {
+			if s == "" {
+				return nil
+			}
+			_, _, err := parseLineCol(s)
+			return err
+		})
+		if err != nil || input == "" {
+			return err
+		}
+	}
+	line, col, err := parseLineCol(input)
+	if err != nil {
+		c.setStatusMessage("Invalid line number: %s", input)
+		return nil
+	}
+
+	// ファイルの後半の行にも移動できるよう、全体の読み込みを待つ
+	c.waitForLoad()
+	y, x := c.lineColPosition(line, col)
+	c.beforeCursorMove(false)
+	c.eventBus.Publish(event.NewCursorSetEvent(y, x))
+	return nil
+}
+
+// GotoLine は起動時に指定された行（1 始まり）と列（1 始まり）へカーソルを移し、その行を画面の中央に表示する
+// コンパイラの出力の位置から開く場合に使う。範囲外の行・列はバッファの範囲に丸める
+func (c *Controller) GotoLine(line, col int) {
+	c.waitForLoad()
+	y, x := c.lineColPosition(line, col)
+	c.screen.SetCursorPosition(x, y)
+	c.screen.SetRowOffset(max(y-c.screen.TextRows()/2, 0))
+	c.updateScroll()
+}
+
+// lineColPosition は 1 始まりの行・列を、バッファの範囲に丸めた 0 始まりの位置にする
+func (c *Controller) lineColPosition(line, col int) (y, x int) {
+	y = min(max(line, 1), max(c.contents.GetLineCount(), 1)) - 1
+	if row := c.contents.GetRow(y); row != nil {
+		x = min(max(col, 1)-1, row.GetRuneCount())
+	}
+	return y, x
+}
+
+// parseLineCol は "行" または "行:列" の形式の入力を解釈する。列を省略した場合は 1 を返す
+func parseLineCol(input string) (line, col int, err error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(input), ":"), ":")
+	if len(parts) > 2 {
+		return 0, 0, errInvalidLine
+	}
+	line, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errInvalidLine
+	}
+	col = 1
+	if len(parts) == 2 {
+		if col, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, errInvalidLine
+		}
+	}
+	return line, col, nil
+}
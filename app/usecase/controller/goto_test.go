@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestParseLineCol(t *testing.T) {
+	tests := []struct {
+		input     string
+		line, col int
+		ok        bool
+	}{
+		{"12", 12, 1, true},
+		{":12", 12, 1, true},
+		{"12:5", 12, 5, true},
+		{" :3:4 ", 3, 4, true},
+		{"abc", 0, 0, false},
+		{"1:2:3", 0, 0, false},
+		{"1:x", 0, 0, false},
+	}
+	for _, tt := range tests {
+		line, col, err := parseLineCol(tt.input)
+		assert.Equal(t, tt.ok, err == nil, tt.input)
+		assert.Equal(t, tt.line, line, tt.input)
+		assert.Equal(t, tt.col, col, tt.input)
+	}
+}
+
+func TestGotoLine_PromptValidatesAndClamps(t *testing.T) {
+	events := []key.KeyEvent{ctrlKey(key.KeyCtrlG)}
+	// 行番号として解釈できない入力は確定せず、修正して続けられる
+	events = append(events, typeString("x")...)
+	events = append(events, special(key.KeyEnter), special(key.KeyBackspace))
+	events = append(events, typeString("2:3")...)
+	events = append(events, special(key.KeyEnter), ctrlKey(key.KeyCtrlG))
+	events = append(events, typeString("99:99")...)
+	events = append(events, special(key.KeyEnter))
+	controller, _ := newKeyInputController(t, []string{"one", "two", "three"}, events...)
+
+	assert.NoError(t, controller.Process())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.Y)
+	assert.Equal(t, 2, pos.X)
+
+	// 範囲外の行・列は最後の行の末尾に丸める
+	assert.NoError(t, controller.Process())
+	pos = controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 2, pos.Y)
+	assert.Equal(t, 5, pos.X)
+}
+
+func TestGotoLine_CentersTheLine(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	controller, _ := newKeyInputController(t, lines)
+
+	controller.GotoLine(60, 3)
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 59, pos.Y)
+	assert.Equal(t, 2, pos.X)
+	_, top := controller.screen.GetOffset()
+	assert.Equal(t, 59-controller.screen.TextRows()/2, top)
+
+	// 範囲外の行・列はバッファの範囲に丸める
+	controller.GotoLine(500, 99)
+	pos = controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 99, pos.Y)
+	assert.Equal(t, 4, pos.X)
+}
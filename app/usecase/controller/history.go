@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/historyfile"
+)
+
+// SetHistoryStore は編集履歴の保存先を設定する。nil なら編集履歴をセッションをまたいで残さない
+func (c *Controller) SetHistoryStore(store historyfile.Store) {
+	c.historyStore = store
+}
+
+// saveHistory は保存したファイルの内容 lines と共に編集履歴を保存する
+// 次に同じファイルを開いたとき、前回のセッションの編集を取り消せるようにする
+func (c *Controller) saveHistory(filename string, lines []string) {
+	if c.historyStore == nil || filename == "" {
+		return
+	}
+	entry := historyfile.Entry{
+		Original: filename,
+		SavedAt:  time.Now(),
+		Checksum: historyfile.Checksum(lines),
+		History:  c.history.Save(),
+	}
+	if err := c.historyStore.Save(entry); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to save edit history: %v", err))
+	}
+}
+
+// restoreHistory は filename の前回のセッションの編集履歴を読み込む
+// 履歴を保存した後にファイルが変更されている場合は、取り消すと内容が壊れるため読み込まない
+func (c *Controller) restoreHistory(filename string) {
+	if c.historyStore == nil || filename == "" {
+		return
+	}
+	entry, err := c.historyStore.Load(filename)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.logger.Log("error", fmt.Sprintf("Failed to load edit history: %v", err))
+		}
+		return
+	}
+	if entry.Checksum != historyfile.Checksum(c.contents.GetAllLines()) {
+		c.logger.Log("file", fmt.Sprintf("Edit history of '%s' is outdated", filename))
+		return
+	}
+	c.history.Restore(entry.History, c.contents)
+}
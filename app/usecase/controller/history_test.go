@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/historyfile"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestHistory_PersistAcrossSessions(t *testing.T) {
+	store := historyfile.NewDirStore(filepath.Join(t.TempDir(), "history"))
+
+	// 前回のセッション: 入力して保存する
+	first, c := newKeyInputController(t, []string{"foo"}, char('x'), char(' '), char('y'))
+	first.SetHistoryStore(store)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, first.Process())
+	}
+	first.history.MarkSaved()
+	first.saveHistory("test.txt", c.GetAllLines())
+
+	// 次のセッション: 同じ内容のファイルを開くと、前回の編集を取り消せる
+	second, c := newKeyInputController(t, []string{"x yfoo"}, ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlZ))
+	second.SetHistoryStore(store)
+	second.restoreHistory("test.txt")
+	assert.False(t, c.IsDirty())
+	assert.NoError(t, second.Process())
+	assert.Equal(t, []string{"x foo"}, c.GetAllLines())
+	assert.NoError(t, second.Process())
+	assert.Equal(t, []string{"foo"}, c.GetAllLines())
+	assert.True(t, c.IsDirty())
+
+	// 保存した後にファイルが変更されていれば読み込まない
+	third, c := newKeyInputController(t, []string{"changed"}, ctrlKey(key.KeyCtrlZ))
+	third.SetHistoryStore(store)
+	third.restoreHistory("test.txt")
+	assert.NoError(t, third.Process())
+	assert.Equal(t, []string{"changed"}, c.GetAllLines())
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/annotation"
+)
+
+// openURLTimeout は URL を開くコマンドの実行時間の上限
+const openURLTimeout = 10 * time.Second
+
+// urlOpener は URL を既定のアプリケーションで開くコマンドを返す
+func urlOpener() string {
+	if runtime.GOOS == "darwin" {
+		return "open"
+	}
+	return "xdg-open"
+}
+
+// urlAtCursor はカーソル下（またはカーソルの直前で終わる）URL を返す
+func (c *Controller) urlAtCursor() (string, bool) {
+	pos := c.screen.GetCursor().ToPosition()
+	spans := annotation.URLs.Annotate([]rune(c.contents.GetContentLine(pos.Y)))
+	if s, ok := annotation.At(spans, pos.X); ok {
+		return s.Target, true
+	}
+	if s, ok := annotation.At(spans, pos.X-1); ok {
+		return s.Target, true
+	}
+	return "", false
+}
+
+// openURL はカーソル下の URL を既定のアプリケーションで開く
+func (c *Controller) openURL() {
+	url, ok := c.urlAtCursor()
+	if !ok {
+		c.setStatusMessage("No URL under cursor")
+		return
+	}
+	opener := urlOpener()
+	if !c.runner.Available(opener) {
+		c.setErrorMessage("Cannot open URL: %s not found", opener)
+		return
+	}
+
+	c.setStatusMessage("Opening %s", url)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), openURLTimeout)
+		defer cancel()
+		if _, err := c.runner.Run(ctx, "", opener, []string{url}, nil); err != nil {
+			c.postResult(func() { c.setErrorMessage("Failed to open %s: %v", url, err) })
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLAtCursor(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"docs: https://example.com/x. end"})
+
+	tests := []struct {
+		x    int
+		want string
+		ok   bool
+	}{
+		{0, "", false},
+		{6, "https://example.com/x", true},
+		{27, "https://example.com/x", true}, // URL の直後
+		{29, "", false},
+	}
+	for _, tt := range tests {
+		controller.screen.SetCursorPosition(tt.x, 0)
+		got, ok := controller.urlAtCursor()
+		assert.Equal(t, tt.ok, ok, "x=%d", tt.x)
+		assert.Equal(t, tt.want, got, "x=%d", tt.x)
+	}
+}
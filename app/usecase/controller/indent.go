@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/filetype"
+	"github.com/wasya-io/go-kilo/app/entity/indent"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+// bracketPairs は改行で開く括弧と、対応する閉じ括弧
+var bracketPairs = map[rune]rune{'{': '}', '(': ')', '[': ']'}
+
+// indentRule はバッファのファイルの種類の自動インデントの規則を返す
+func (c *Controller) indentRule() indent.Rule {
+	return c.indentRules.For(screen.FileTypeOf(c.contents, c.fileManager.GetFilename()))
+}
+
+// applyIndentRules は既定の規則にプロジェクト設定の規則を重ね、適用できなかった項目を返す
+func (c *Controller) applyIndentRules(p *config.Project) []string {
+	c.indentRules = indent.Default()
+	if p == nil {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, name := range filetype.Names() {
+		known[name] = true
+	}
+	fileTypes := make([]string, 0, len(p.Indent))
+	for fileType := range p.Indent {
+		fileTypes = append(fileTypes, fileType)
+	}
+	sort.Strings(fileTypes)
+
+	var problems []string
+	for _, fileType := range fileTypes {
+		if !known[fileType] {
+			problems = append(problems, fmt.Sprintf("indent.%s: unknown file type", fileType))
+			continue
+		}
+		r := p.Indent[fileType]
+		c.indentRules.Set(fileType, indent.Rule{After: r.After, Dedent: r.Dedent, Unit: r.Unit})
+	}
+	return problems
+}
+
+// insertBracketNewline はカーソルが対応する括弧の間（例: {|}）にある場合に、
+// 1段深くインデントした空行と、元のインデントの閉じ括弧の行に分けて改行する
+// 1回の取り消しで元に戻せるよう、まとめて1つの編集として記録する。括弧の間でなければ false を返す
+func (c *Controller) insertBracketNewline(pos contents.Position, line string, indentSize int) bool {
+	runes := []rune(line)
+	if pos.X < 1 || pos.X >= len(runes) {
+		return false
+	}
+	if closer, ok := bracketPairs[runes[pos.X-1]]; !ok || runes[pos.X] != closer {
+		return false
+	}
+
+	indent := string(runes[:indentSize])
+	inner := indent + c.indentRule().Step(indent)
+	if !c.replaceRange(pos, pos, []string{"", inner, indent}) {
+		return true
+	}
+	c.screen.SetCursorPosition(len([]rune(inner)), pos.Y+1)
+	c.updateScroll()
+	return true
+}
+
+// insertIndentedNewline はカーソルより前がファイルの種類の規則でブロックを開く行（Go の { や Python の : で終わる行など）の場合に、
+// 次の行を1段深くインデントして改行する。規則に当たらなければ false を返す
+func (c *Controller) insertIndentedNewline(pos contents.Position, line string, indentSize int) bool {
+	runes := []rune(line)
+	rule := c.indentRule()
+	if pos.X > len(runes) || !rule.Increases(string(runes[:pos.X])) {
+		return false
+	}
+
+	base := string(runes[:indentSize])
+	c.replaceRange(pos, pos, []string{"", base + rule.Step(base)})
+	return true
+}
+
+// insertOutdentedCloser は行頭の空白の直後に規則の文字（閉じ括弧など）を入力した場合に、
+// 行を1段浅くしてから入力する。1回の取り消しで元に戻せる。規則に当たらなければ false を返す
+func (c *Controller) insertOutdentedCloser(pos contents.Position, ch rune) bool {
+	rule := c.indentRule()
+	if !rule.Dedents(ch) {
+		return false
+	}
+	runes := []rune(c.contents.GetContentLine(pos.Y))
+	if pos.X > len(runes) {
+		return false
+	}
+	leading := string(runes[:pos.X])
+	if leading == "" || strings.TrimLeft(leading, " \t") != "" {
+		return false
+	}
+	c.replaceRange(contents.Position{Y: pos.Y}, pos, []string{rule.Outdent(leading) + string(ch)})
+	return true
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestInsertNewline_BetweenBrackets(t *testing.T) {
+	enter := key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEnter}
+	controller, c := newKeyInputController(t, []string{"\tif x {}", "  f()"},
+		enter, ctrlKey(key.KeyCtrlZ), enter,
+	)
+	controller.screen.SetCursorPosition(7, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"\tif x {", "\t\t", "\t}", "  f()"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 2, pos.X)
+	assert.Equal(t, 1, pos.Y)
+
+	// 1回の取り消しで元に戻る
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"\tif x {}", "  f()"}, c.GetAllLines())
+
+	// スペースのインデントは4桁深くする
+	controller.screen.SetCursorPosition(4, 1)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"\tif x {}", "  f(", "      ", "  )"}, c.GetAllLines())
+}
+
+func TestInsertNewline_IndentsAfterBlockOpener(t *testing.T) {
+	enter := special(key.KeyEnter)
+	events := append([]key.KeyEvent{enter}, typeString("x}")...)
+	controller, c := newKeyInputController(t, []string{"func f() {"}, append(events, ctrlKey(key.KeyCtrlZ))...)
+	c.SetFileType("Go")
+	controller.screen.SetCursorPosition(10, 0)
+
+	// Go ではタブで1段深くする
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() {", "\t"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+
+	// 行頭の空白の直後に閉じ括弧を入力すると1段浅くする
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() {", "\tx}"}, c.GetAllLines())
+	controller.screen.SetCursorPosition(1, 1)
+	controller.performInsertChar('}')
+	assert.Equal(t, []string{"func f() {", "}x}"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+
+	// 浅くした入力は1回で取り消せる
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() {", "\tx}"}, c.GetAllLines())
+}
+
+func TestInsertNewline_RulesFollowFileType(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"  if x:", "if y {"})
+	// 規則のない種類は行頭の空白を引き継ぐだけ
+	controller.screen.SetCursorPosition(7, 0)
+	controller.performInsertNewline()
+	assert.Equal(t, "  ", c.GetAllLines()[1])
+
+	controller.performUndo()
+	c.SetFileType("Python")
+	controller.screen.SetCursorPosition(7, 0)
+	controller.performInsertNewline()
+	assert.Equal(t, []string{"  if x:", "      ", "if y {"}, c.GetAllLines())
+
+	// プロジェクト設定で規則を置き換える
+	problems := controller.ApplyProject(&config.Project{Indent: map[string]config.IndentRule{
+		"Text":   {After: []string{"{"}, Unit: "  "},
+		"Cobol":  {After: []string{"."}},
+		"Python": {},
+	}})
+	assert.Equal(t, []string{"indent.Cobol: unknown file type"}, problems)
+	c.SetFileType("")
+	controller.screen.SetCursorPosition(6, 2)
+	controller.performInsertNewline()
+	assert.Equal(t, []string{"if y {", "  "}, c.GetAllLines()[2:])
+}
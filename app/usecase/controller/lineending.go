@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// setLineEndingCommand は保存する際の改行コードを引数（lf / crlf）に変える。引数がなければ LF と CRLF を切り替える
+func (c *Controller) setLineEndingCommand(args []string) error {
+	ending := contents.LineEndingCRLF
+	if c.contents.LineEnding() == contents.LineEndingCRLF {
+		ending = contents.LineEndingLF
+	}
+	if len(args) > 0 {
+		parsed, ok := contents.ParseLineEnding(args[0])
+		if !ok {
+			c.setStatusMessage("Unknown line ending %q (lf or crlf)", args[0])
+			return nil
+		}
+		ending = parsed
+	}
+	c.eventBus.Publish(event.NewLineEndingEvent(ending))
+	return nil
+}
+
+// performLineEnding は保存する際の改行コードを ending に変える
+// LF と混在していたために行の内容として残した行末の \r は、どちらに変える場合も取り除いて揃える
+func (c *Controller) performLineEnding(ending contents.LineEnding) {
+	if c.contents.ReadOnly() {
+		c.reportEditError(contents.ErrReadOnly)
+		return
+	}
+	lines := c.contents.GetAllLines()
+	mixed := false
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			mixed = true
+			break
+		}
+	}
+	if !mixed && ending == c.contents.LineEnding() {
+		c.setStatusMessage("Line endings are already %s", ending)
+		return
+	}
+	if mixed {
+		trimmed := append([]string{}, lines...)
+		contents.TrimCR(trimmed)
+		c.replaceContents(trimmed)
+	}
+	c.contents.SetLineEnding(ending)
+	c.contents.SetDirty(true)
+	c.setStatusMessage("Line endings: %s (applied on save)", ending)
+}
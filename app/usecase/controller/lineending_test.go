@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func TestSetLineEnding(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one\r", "two"})
+
+	// 混在していた \r を取り除いて CRLF に揃える
+	assert.NoError(t, controller.setLineEndingCommand([]string{"crlf"}))
+	assert.Equal(t, contents.LineEndingCRLF, c.LineEnding())
+	assert.Equal(t, []string{"one", "two"}, c.GetAllLines())
+	assert.True(t, c.IsDirty())
+
+	// 引数がなければ切り替える
+	assert.NoError(t, controller.setLineEndingCommand(nil))
+	assert.Equal(t, contents.LineEndingLF, c.LineEnding())
+
+	c.SetDirty(false)
+	assert.NoError(t, controller.setLineEndingCommand([]string{"LF"}))
+	assert.False(t, c.IsDirty(), "converting to the current line ending changes nothing")
+	assert.NoError(t, controller.setLineEndingCommand([]string{"cr"}))
+	assert.Equal(t, contents.LineEndingLF, c.LineEnding())
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// killLine はカーソル行全体を削除する
+func (c *Controller) killLine() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferKillLine, 0))
+}
+
+// duplicateLine はカーソル行を複製する
+func (c *Controller) duplicateLine() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferDupLine, 0))
+}
+
+// moveLineUp はカーソル行を1行上に移動する
+func (c *Controller) moveLineUp() {
+	c.eventBus.Publish(event.NewMoveLineEvent(-1))
+}
+
+// moveLineDown はカーソル行を1行下に移動する
+func (c *Controller) moveLineDown() {
+	c.eventBus.Publish(event.NewMoveLineEvent(1))
+}
+
+// joinLine はカーソル行と次の行を結合する
+func (c *Controller) joinLine() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferJoinLine, 0))
+}
+
+// performKillLine はカーソル行を改行ごと削除し、クリップボードに保存する
+// 行頭で貼り付けると元の行を戻せる。カーソルは同じ桁のまま次の行（最終行なら前の行）に移る
+func (c *Controller) performKillLine() {
+	pos := c.screen.GetCursor().ToPosition()
+	line := c.contents.GetContentLine(pos.Y)
+	start, end := contents.Position{Y: pos.Y}, contents.Position{Y: pos.Y + 1}
+	switch {
+	case pos.Y+1 < c.contents.GetLineCount():
+	case pos.Y > 0:
+		// 最終行は前の行の末尾の改行から削除する
+		start = contents.Position{X: len([]rune(c.contents.GetContentLine(pos.Y - 1))), Y: pos.Y - 1}
+		end = contents.Position{X: len([]rune(line)), Y: pos.Y}
+	default:
+		end = contents.Position{X: len([]rune(line))}
+	}
+	if start == end {
+		c.setStatusMessage("Nothing to delete")
+		return
+	}
+	if !c.replaceRange(start, end, nil) {
+		return
+	}
+	c.contents.SetClipboard([]string{line, ""})
+	y := min(pos.Y, c.contents.GetLineCount()-1)
+	c.screen.SetCursorPosition(min(pos.X, len([]rune(c.contents.GetContentLine(y)))), y)
+	c.updateScroll()
+}
+
+// performDuplicateLine はカーソル行の複製を下に挿入し、カーソルを複製した行の同じ桁に移す
+func (c *Controller) performDuplicateLine() {
+	pos := c.screen.GetCursor().ToPosition()
+	line := c.contents.GetContentLine(pos.Y)
+	end := contents.Position{X: len([]rune(line)), Y: pos.Y}
+	if !c.replaceRange(end, end, []string{"", line}) {
+		return
+	}
+	c.screen.SetCursorPosition(pos.X, pos.Y+1)
+	c.updateScroll()
+}
+
+// performMoveLine はカーソル行を delta の向きに隣の行と入れ替える。カーソルは行と一緒に移動する
+func (c *Controller) performMoveLine(delta int) {
+	pos := c.screen.GetCursor().ToPosition()
+	target := pos.Y + delta
+	if target < 0 || target >= c.contents.GetLineCount() {
+		c.setStatusMessage("Cannot move the line any further")
+		return
+	}
+	if !c.swapLines(min(pos.Y, target)) {
+		return
+	}
+	c.screen.SetCursorPosition(pos.X, target)
+	c.updateScroll()
+}
+
+// performJoinLine は次の行の行頭の空白を除いて、カーソル行の末尾に空白1つを挟んで結合する
+// カーソル行が空か空白で終わる場合、または次の行が空の場合は空白を挟まない。カーソルは結合した行の内容の先頭に移る
+func (c *Controller) performJoinLine() {
+	pos := c.screen.GetCursor().ToPosition()
+	if pos.Y+1 >= c.contents.GetLineCount() {
+		c.setStatusMessage("Nothing to join")
+		return
+	}
+	line := c.contents.GetContentLine(pos.Y)
+	next := c.contents.GetContentLine(pos.Y + 1)
+	rest := strings.TrimLeft(next, " \t")
+	var sep []string
+	if line != "" && !strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\t") && rest != "" {
+		sep = []string{" "}
+	}
+	start := contents.Position{X: len([]rune(line)), Y: pos.Y}
+	end := contents.Position{X: len([]rune(next)) - len([]rune(rest)), Y: pos.Y + 1}
+	c.replaceRange(start, end, sep)
+}
+
+// swapLines は upper 行目と次の行を入れ替える。入れ替えられなかった場合は false を返す
+func (c *Controller) swapLines(upper int) bool {
+	first := c.contents.GetContentLine(upper)
+	second := c.contents.GetContentLine(upper + 1)
+	end := contents.Position{X: len([]rune(second)), Y: upper + 1}
+	return c.replaceRange(contents.Position{Y: upper}, end, []string{second, first})
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func altSpecial(k key.Key) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventSpecial, Key: k, Modifiers: key.ModAlt}
+}
+
+func TestDeleteLine(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one", "two", "three"},
+		ctrlKey(key.KeyCtrlK), char('d'),
+		ctrlKey(key.KeyCtrlK), char('d'),
+		ctrlKey(key.KeyCtrlK), char('v'),
+		ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(3, 1)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "three"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 3, pos.X)
+	assert.Equal(t, 1, pos.Y)
+
+	// 最終行では前の行の末尾の改行ごと削除する
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one"}, c.GetAllLines())
+	assert.Equal(t, 0, controller.screen.GetCursor().ToPosition().Y)
+
+	// 削除した行はクリップボードに残り、行頭に貼り付けると戻せる
+	controller.screen.SetCursorPosition(0, 0)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"three", "one"}, c.GetAllLines())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one"}, c.GetAllLines())
+}
+
+func TestDuplicateLine(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"aいb", "x"},
+		ctrlKey(key.KeyCtrlK), char('D'), ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(2, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"aいb", "aいb", "x"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 2, pos.X)
+	assert.Equal(t, 1, pos.Y)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"aいb", "x"}, c.GetAllLines())
+}
+
+func TestMoveLine(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one", "two", "three"},
+		altSpecial(key.KeyArrowDown), altSpecial(key.KeyArrowDown), altSpecial(key.KeyArrowUp),
+	)
+	controller.screen.SetCursorPosition(1, 1)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "three", "two"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.X)
+	assert.Equal(t, 2, pos.Y)
+
+	// 最終行より下には移動しない
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "three", "two"}, c.GetAllLines())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "two", "three"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+}
+
+func TestJoinLine(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"func f() {", "\treturn", "", "}"},
+		ctrlKey(key.KeyCtrlK), char('j'),
+		ctrlKey(key.KeyCtrlK), char('j'),
+		ctrlKey(key.KeyCtrlK), char('j'),
+		ctrlKey(key.KeyCtrlK), char('j'),
+	)
+
+	// 次の行の行頭の空白を除き、空白1つを挟む
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() { return", "", "}"}, c.GetAllLines())
+	assert.Equal(t, 11, controller.screen.GetCursor().ToPosition().X)
+
+	// 空の行は空白を挟まずに結合する
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() { return", "}"}, c.GetAllLines())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() { return }"}, c.GetAllLines())
+
+	// 最終行では何もしない
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"func f() { return }"}, c.GetAllLines())
+}
@@ -0,0 +1,504 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/lsp"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/diagnostic"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/entity/word"
+)
+
+// lspRequestTimeout は言語サーバーへの問い合わせの応答を待つ時間
+const lspRequestTimeout = 3 * time.Second
+
+// diagnosticsMeta はその行から始まる診断（[]diagnostic.Diagnostic）を置く行のメタデータの名前空間
+const diagnosticsMeta = "lsp.diagnostics"
+
+const (
+	completionFooter  = "Up/Down: move  Enter: insert  Esc: close"
+	diagnosticsFooter = "Up/Down: move  Enter: jump  Esc: close"
+)
+
+// languageServer は言語サーバーとの接続。テストでは偽のサーバーに置き換える
+type languageServer interface {
+	DidOpen(path, languageID string, version int, text string)
+	DidChange(path string, version int, text string)
+	DidClose(path string)
+	Completion(ctx context.Context, path string, pos lsp.Position) ([]lsp.CompletionItem, error)
+	Err() error
+	Shutdown(ctx context.Context) error
+}
+
+// serverStarter は command を root で起動し、診断を onDiagnostics で受け取る言語サーバーに接続する
+type serverStarter func(command, root string, onDiagnostics lsp.DiagnosticsFunc) (languageServer, error)
+
+// startLanguageServer は言語サーバーのプロセスを起動する
+func startLanguageServer(command, root string, onDiagnostics lsp.DiagnosticsFunc) (languageServer, error) {
+	client, err := lsp.Start(command, root, onDiagnostics)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// lspDocument は言語サーバーに開いたことを通知した文書
+type lspDocument struct {
+	server   languageServer
+	contents *contents.Contents
+	sent     uint64 // 最後に送った内容のバージョン（contents.Version）
+	version  int    // 言語サーバーに送ったバージョン番号
+}
+
+// lspState はファイルの種類ごとの言語サーバーと、受け取った診断
+type lspState struct {
+	commands map[string]string // ファイルの種類（小文字）ごとの起動コマンド
+	start    serverStarter
+
+	// 画面の更新はタイマーのゴルーチンからも行われるため、文書の同期は排他する
+	syncMutex sync.Mutex
+	servers   map[string]languageServer // ファイルの種類（小文字）ごとの起動済みのサーバー
+	failed    map[string]bool           // 起動に失敗した、または終了したサーバー（同じ失敗を繰り返さない）
+	documents map[string]*lspDocument   // 絶対パスごとの開いた文書
+
+	// 診断は受信用のゴルーチンからイベントで届くため、同期とは別に排他する
+	// イベントを発行する間は保持しないこと（同期モードのイベントバスではデッドロックする）
+	mutex       sync.Mutex
+	diagnostics map[string][]diagnostic.Diagnostic // 絶対パスごとの診断（列は UTF-16 のコード単位）
+	pending     map[string]bool                    // 受け取った後、まだバッファの行に置いていない診断のパス
+}
+
+// SetLanguageServers はファイルの種類（小文字）ごとに起動する言語サーバーのコマンドを設定する
+// サーバーはその種類のファイルを最初に表示したときに起動する
+func (c *Controller) SetLanguageServers(commands map[string]string) {
+	c.languages.commands = commands
+	if c.languages.start == nil {
+		c.languages.start = startLanguageServer
+	}
+}
+
+// ShutdownLanguageServers は起動した言語サーバーをすべて終了する
+func (c *Controller) ShutdownLanguageServers() {
+	c.languages.syncMutex.Lock()
+	servers := c.languages.servers
+	c.languages.servers = nil
+	c.languages.documents = nil
+	c.languages.syncMutex.Unlock()
+
+	var wg sync.WaitGroup
+	for name, server := range servers {
+		wg.Add(1)
+		go func(name string, server languageServer) {
+			defer wg.Done()
+			if err := server.Shutdown(context.Background()); err != nil {
+				c.logger.Log("lsp", fmt.Sprintf("Failed to shut down the %s language server: %v", name, err))
+			}
+		}(name, server)
+	}
+	wg.Wait()
+}
+
+// documentPath は言語サーバーに通知するバッファのファイルの絶対パスを返す。ファイル名がなければ空文字列を返す
+func (c *Controller) documentPath() string {
+	filename := c.fileManager.GetFilename()
+	if filename == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
+
+// syncLanguageServer はバッファの内容が前回の通知から変わっていれば、言語サーバーに通知する
+// 初めて表示したファイルは開いたことを通知し、必要ならサーバーを起動する
+func (c *Controller) syncLanguageServer() {
+	if len(c.languages.commands) == 0 {
+		return
+	}
+	path := c.documentPath()
+	if path == "" {
+		return
+	}
+	fileType := screen.FileTypeOf(c.contents, c.fileManager.GetFilename())
+
+	c.languages.syncMutex.Lock()
+	defer c.languages.syncMutex.Unlock()
+
+	doc := c.languages.documents[path]
+	version := c.contents.Version()
+	if doc != nil && doc.contents == c.contents && doc.sent == version && doc.server.Err() == nil {
+		return
+	}
+	server := c.serverFor(fileType)
+	if server == nil {
+		return
+	}
+
+	text := strings.Join(c.contents.GetAllLines(), "\n")
+	if doc != nil && doc.server == server && doc.contents == c.contents {
+		doc.version++
+		doc.sent = version
+		server.DidChange(path, doc.version, text)
+		return
+	}
+	// 別のバッファで開き直した場合や、ファイルの種類を変えた場合は開き直したことにする
+	if doc != nil && doc.server.Err() == nil {
+		doc.server.DidClose(path)
+	}
+	if c.languages.documents == nil {
+		c.languages.documents = make(map[string]*lspDocument)
+	}
+	c.languages.documents[path] = &lspDocument{server: server, contents: c.contents, sent: version, version: 1}
+	server.DidOpen(path, lsp.LanguageID(fileType), 1, text)
+	// 開き直したバッファにも、新しい診断が届くまでは前に受け取った診断を置く
+	c.languages.mutex.Lock()
+	c.markDiagnosticsPending(path)
+	c.languages.mutex.Unlock()
+}
+
+// markDiagnosticsPending は path の診断を次の描画でバッファの行に置くよう記録する。mutex を保持して呼び出すこと
+func (c *Controller) markDiagnosticsPending(path string) {
+	if c.languages.pending == nil {
+		c.languages.pending = make(map[string]bool)
+	}
+	c.languages.pending[path] = true
+}
+
+// serverFor は fileType の言語サーバーを返す。まだ起動していなければ起動する
+// 設定がない場合や、起動に失敗した場合は nil を返す。syncMutex を保持して呼び出すこと
+func (c *Controller) serverFor(fileType string) languageServer {
+	name := strings.ToLower(fileType)
+	if server, ok := c.languages.servers[name]; ok {
+		if err := server.Err(); err != nil {
+			// 終了したサーバーは起動し直さず、理由を知らせる
+			delete(c.languages.servers, name)
+			c.languages.failed[name] = true
+			c.reportLanguageServerError("%s language server stopped: %v", fileType, err)
+			return nil
+		}
+		return server
+	}
+	command, ok := c.languages.commands[name]
+	if !ok || c.languages.failed[name] {
+		return nil
+	}
+
+	if c.languages.failed == nil {
+		c.languages.failed = make(map[string]bool)
+	}
+	server, err := c.languages.start(command, c.projectRoot(), c.receiveDiagnostics)
+	if err != nil {
+		c.languages.failed[name] = true
+		c.reportLanguageServerError("Failed to start the %s language server: %v", fileType, err)
+		return nil
+	}
+	c.logger.Log("lsp", fmt.Sprintf("Started %q for %s files", command, fileType))
+	if c.languages.servers == nil {
+		c.languages.servers = make(map[string]languageServer)
+	}
+	c.languages.servers[name] = server
+	return server
+}
+
+// reportLanguageServerError は言語サーバーのエラーをメッセージバーに表示する
+// 画面の更新中に呼び出されるため、更新イベントは発行しない
+func (c *Controller) reportLanguageServerError(format string, args ...interface{}) {
+	c.logger.Log("error", fmt.Sprintf(format, args...))
+	c.screen.SetMessageFor(contents.Persistent, format, args...)
+}
+
+// receiveDiagnostics は言語サーバーから届いた診断をイベントとして発行する
+// 受信用のゴルーチンから呼び出されるため、バッファに合わせた変換はイベントのハンドラーで行う
+func (c *Controller) receiveDiagnostics(path string, reported []lsp.Diagnostic) {
+	diags := make([]diagnostic.Diagnostic, 0, len(reported))
+	for _, d := range reported {
+		severity := diagnostic.Severity(d.Severity)
+		if severity == 0 {
+			severity = diagnostic.SeverityError // 省略された場合はエラーとして扱う
+		}
+		diags = append(diags, diagnostic.Diagnostic{
+			Line:     d.Range.Start.Line,
+			Col:      d.Range.Start.Character,
+			EndLine:  d.Range.End.Line,
+			EndCol:   d.Range.End.Character,
+			Severity: severity,
+			Message:  d.Message,
+			Source:   d.Source,
+		})
+	}
+	c.eventBus.Publish(event.NewDiagnosticsEvent(path, diags))
+}
+
+// createDiagnosticsHandler は言語サーバーの診断を記録して画面を更新するハンドラーを作成する
+func (c *Controller) createDiagnosticsHandler() event.Handler {
+	return event.NewSingleTypeHandler(event.TypeDiagnostics, func(e event.Event) (bool, error) {
+		payload, ok := e.Payload.(event.DiagnosticsEvent)
+		if !ok {
+			return false, nil
+		}
+		c.languages.mutex.Lock()
+		if c.languages.diagnostics == nil {
+			c.languages.diagnostics = make(map[string][]diagnostic.Diagnostic)
+		}
+		if len(payload.Diagnostics) == 0 {
+			delete(c.languages.diagnostics, payload.Path)
+		} else {
+			c.languages.diagnostics[payload.Path] = payload.Diagnostics
+		}
+		c.markDiagnosticsPending(payload.Path)
+		c.languages.mutex.Unlock()
+		c.eventBus.Publish(event.NewRefreshEvent())
+		return true, nil
+	})
+}
+
+// currentDiagnostics はバッファの行に置いた診断を、位置の順に返す（列はルーン単位）
+// 診断は行と共に移動するので、受け取った後に行を挿入・削除しても位置がずれない
+func (c *Controller) currentDiagnostics() []diagnostic.Diagnostic {
+	c.updateDiagnostics()
+	lastLine := max(c.contents.GetLineCount()-1, 0)
+	var diags []diagnostic.Diagnostic
+	for _, y := range c.contents.LineMetaLines(diagnosticsMeta) {
+		value, _ := c.contents.LineMeta(diagnosticsMeta, y)
+		stored, _ := value.([]diagnostic.Diagnostic)
+		for _, d := range stored {
+			d.Line, d.EndLine = y, min(y+d.EndLine-d.Line, lastLine)
+			diags = append(diags, d)
+		}
+	}
+	diagnostic.Sort(diags)
+	return diags
+}
+
+// updateDiagnostics はバッファのファイルについてまだ置いていない診断を受け取っていれば、バッファの行に置く
+func (c *Controller) updateDiagnostics() {
+	path := c.documentPath()
+	c.languages.mutex.Lock()
+	pending := c.languages.pending[path]
+	delete(c.languages.pending, path)
+	reported := c.languages.diagnostics[path]
+	c.languages.mutex.Unlock()
+	if pending {
+		c.placeDiagnostics(reported)
+	}
+}
+
+// placeDiagnostics は言語サーバーの診断をバッファの行のメタデータに置き換え、範囲に下線を引く
+// 診断を受け取る前に編集した場合に備え、位置はバッファの範囲に丸める
+func (c *Controller) placeDiagnostics(reported []diagnostic.Diagnostic) {
+	c.contents.ClearLineMeta(diagnosticsMeta)
+	c.contents.ClearLineMeta(screen.DiagnosticsMeta)
+
+	lastLine := max(c.contents.GetLineCount()-1, 0)
+	column := func(line, col int) int {
+		return lsp.RuneColumn(c.contents.GetContentLine(line), col)
+	}
+	diags := make(map[int][]diagnostic.Diagnostic)
+	highlights := make(map[int][]screen.Highlight)
+	for _, d := range reported {
+		d.Line, d.EndLine = min(d.Line, lastLine), min(d.EndLine, lastLine)
+		d.Col, d.EndCol = column(d.Line, d.Col), column(d.EndLine, d.EndCol)
+		diags[d.Line] = append(diags[d.Line], d)
+		for y := d.Line; y <= d.EndLine; y++ {
+			from, to := 0, len([]rune(c.contents.GetContentLine(y)))+1
+			if y == d.Line {
+				from = d.Col
+			}
+			if y == d.EndLine {
+				to = d.EndCol
+			}
+			// 範囲が空の診断も見えるよう、少なくとも1文字に下線を引く
+			if y == d.EndLine && to <= from {
+				to = from + 1
+			}
+			highlights[y] = append(highlights[y], screen.Highlight{Line: y, Col: from, Length: to - from, Diagnostic: d.Severity})
+		}
+	}
+	for y, d := range diags {
+		c.contents.SetLineMeta(diagnosticsMeta, y, d)
+	}
+	for y, h := range highlights {
+		c.contents.SetLineMeta(screen.DiagnosticsMeta, y, h)
+	}
+}
+
+// formatDiagnostic は診断を一覧やメッセージバーに表示する形にする
+func formatDiagnostic(d diagnostic.Diagnostic) string {
+	message := strings.ReplaceAll(d.Message, "\n", " ")
+	if d.Source != "" {
+		return fmt.Sprintf("%d:%d %s: %s (%s)", d.Line+1, d.Col+1, d.Severity, message, d.Source)
+	}
+	return fmt.Sprintf("%d:%d %s: %s", d.Line+1, d.Col+1, d.Severity, message)
+}
+
+// nextDiagnostic はカーソルより後ろにある次の診断へ移動し、内容をメッセージバーに表示する
+func (c *Controller) nextDiagnostic() {
+	diags := c.currentDiagnostics()
+	pos := c.screen.GetCursor().ToPosition()
+	i, ok := diagnostic.Next(diags, pos.Y, pos.X)
+	if !ok {
+		c.setStatusMessage("No diagnostics")
+		return
+	}
+	c.eventBus.Publish(event.NewCursorSetEvent(diags[i].Line, diags[i].Col))
+	c.setStatusMessage("[%d/%d] %s", i+1, len(diags), formatDiagnostic(diags[i]))
+}
+
+// ShowDiagnostics はバッファのファイルの診断を一覧にし、選んだ位置へ移動する
+func (c *Controller) ShowDiagnostics() error {
+	diags := c.currentDiagnostics()
+	if len(diags) == 0 {
+		c.setStatusMessage("No diagnostics")
+		return nil
+	}
+	lines := make([]string, len(diags))
+	for i, d := range diags {
+		lines[i] = formatDiagnostic(d)
+	}
+	title := fmt.Sprintf("Diagnostics (%d)", len(diags))
+	selected, ok, err := c.pickFromList(title, lines, diagnosticsFooter)
+	if err != nil || !ok {
+		return err
+	}
+	d := diags[selected]
+	c.eventBus.Publish(event.NewCursorSetEvent(d.Line, d.Col))
+	return nil
+}
+
+// lspComplete はカーソル位置の補完候補を言語サーバーに問い合わせ、一覧から選んだ候補を挿入する
+func (c *Controller) lspComplete() error {
+	c.waitForLoad()
+	path := c.documentPath()
+	fileType := screen.FileTypeOf(c.contents, c.fileManager.GetFilename())
+	c.syncLanguageServer()
+
+	c.languages.syncMutex.Lock()
+	doc := c.languages.documents[path]
+	c.languages.syncMutex.Unlock()
+	if path == "" || doc == nil || doc.contents != c.contents {
+		c.setStatusMessage("No language server for %s files", fileType)
+		return nil
+	}
+
+	pos := c.screen.GetCursor().ToPosition()
+	line := c.contents.GetContentLine(pos.Y)
+	runes := []rune(line)
+	pos.X = min(pos.X, len(runes))
+
+	ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+	defer cancel()
+	items, err := doc.server.Completion(ctx, path, lsp.Position{Line: pos.Y, Character: lsp.UTF16Column(line, pos.X)})
+	if err != nil {
+		c.setErrorMessage("Completion failed: %v", err)
+		return nil
+	}
+	if len(items) == 0 {
+		c.setStatusMessage("No completions")
+		return nil
+	}
+	if len(items) > maxCompletionCandidates {
+		items = items[:maxCompletionCandidates]
+	}
+
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label
+		if item.Detail != "" {
+			labels[i] += "  " + item.Detail
+		}
+	}
+	selected, ok, err := c.pickFromList(fmt.Sprintf("Completions (%d)", len(items)), labels, completionFooter)
+	if err != nil || !ok {
+		return err
+	}
+	return c.insertCompletion(items[selected], pos, runes)
+}
+
+// insertCompletion は補完候補を挿入する。候補が置き換える範囲を指定していればその範囲を、
+// そうでなければカーソル直前の単語を置き換える。複数行にまたがる範囲は扱わない
+func (c *Controller) insertCompletion(item lsp.CompletionItem, pos contents.Position, runes []rune) error {
+	start := contents.Position{X: word.PrefixStart(runes, pos.X), Y: pos.Y}
+	end := pos
+	if edit := item.TextEdit; edit != nil && edit.Range.Start.Line == pos.Y && edit.Range.End.Line == pos.Y {
+		line := string(runes)
+		start.X = lsp.RuneColumn(line, edit.Range.Start.Character)
+		end.X = max(lsp.RuneColumn(line, edit.Range.End.Character), pos.X)
+	}
+	script := c.Script()
+	if start != end {
+		if err := script.Select(Range{Start: start, End: end}); err != nil {
+			return err
+		}
+	}
+	return script.Insert(item.Text())
+}
+
+// pickFromList は lines を一覧に表示して1つ選ばせ、選んだ位置を返す。選ばずに閉じた場合は false を返す
+func (c *Controller) pickFromList(title string, lines []string, footer string) (int, bool, error) {
+	selected := 0
+	for {
+		c.screen.SetListOverlay(title, lines, selected, footer)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return 0, false, err
+		}
+		switch ev.Type {
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if selected > 0 {
+					selected--
+				}
+			case key.KeyArrowDown:
+				if selected < len(lines)-1 {
+					selected++
+				}
+			case key.KeyEnter:
+				c.dismissOverlay()
+				return selected, true, nil
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return 0, false, nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX {
+				c.dismissOverlay()
+				return 0, false, nil
+			}
+		}
+	}
+}
+
+// closeDocument は path を閉じたことを言語サーバーに通知し、診断を捨てる
+func (c *Controller) closeDocument(path string) {
+	if path == "" || len(c.languages.commands) == 0 {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	c.languages.syncMutex.Lock()
+	if doc, ok := c.languages.documents[path]; ok {
+		delete(c.languages.documents, path)
+		if doc.server.Err() == nil {
+			doc.server.DidClose(path)
+		}
+	}
+	c.languages.syncMutex.Unlock()
+
+	c.languages.mutex.Lock()
+	delete(c.languages.diagnostics, path)
+	delete(c.languages.pending, path)
+	c.languages.mutex.Unlock()
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/lsp"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/diagnostic"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+// fakeLanguageServer は受け取った通知を記録し、決まった補完候補を返す言語サーバー
+type fakeLanguageServer struct {
+	calls       []string
+	text        string
+	version     int
+	items       []lsp.CompletionItem
+	completedAt lsp.Position
+	err         error
+	shutdown    bool
+}
+
+func (s *fakeLanguageServer) DidOpen(path, languageID string, version int, text string) {
+	s.calls = append(s.calls, "open "+languageID)
+	s.text, s.version = text, version
+}
+
+func (s *fakeLanguageServer) DidChange(path string, version int, text string) {
+	s.calls = append(s.calls, "change")
+	s.text, s.version = text, version
+}
+
+func (s *fakeLanguageServer) DidClose(path string) {
+	s.calls = append(s.calls, "close")
+}
+
+func (s *fakeLanguageServer) Completion(ctx context.Context, path string, pos lsp.Position) ([]lsp.CompletionItem, error) {
+	s.completedAt = pos
+	return s.items, nil
+}
+
+func (s *fakeLanguageServer) Err() error {
+	return s.err
+}
+
+func (s *fakeLanguageServer) Shutdown(ctx context.Context) error {
+	s.shutdown = true
+	return nil
+}
+
+// withLanguageServer はテキストファイルに偽の言語サーバーを使うよう設定する
+func withLanguageServer(controller *Controller) (*fakeLanguageServer, *lsp.DiagnosticsFunc) {
+	server := &fakeLanguageServer{}
+	var onDiagnostics lsp.DiagnosticsFunc
+	controller.languages.start = func(command, root string, f lsp.DiagnosticsFunc) (languageServer, error) {
+		onDiagnostics = f
+		return server, nil
+	}
+	controller.SetLanguageServers(map[string]string{"text": "fake-server"})
+	return server, &onDiagnostics
+}
+
+func TestSyncLanguageServer_SendsChangesOnce(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"ab", "c"})
+	server, _ := withLanguageServer(controller)
+
+	controller.syncLanguageServer()
+	controller.syncLanguageServer()
+	assert.Equal(t, []string{"open text"}, server.calls, "unchanged buffers are not sent again")
+	assert.Equal(t, "ab\nc", server.text)
+
+	controller.insertChar('x')
+	controller.syncLanguageServer()
+	assert.Equal(t, []string{"open text", "change"}, server.calls)
+	assert.Equal(t, "xab\nc", server.text)
+	assert.Equal(t, 2, server.version)
+
+	controller.closeDocument("test.txt")
+	assert.Equal(t, "close", server.calls[len(server.calls)-1])
+
+	controller.ShutdownLanguageServers()
+	assert.True(t, server.shutdown)
+}
+
+func TestSyncLanguageServer_ReportsStoppedServerOnce(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"ab"})
+	server, _ := withLanguageServer(controller)
+	controller.syncLanguageServer()
+
+	server.err = errors.New("broken pipe")
+	controller.insertChar('x')
+	controller.syncLanguageServer()
+	assert.True(t, controller.screen.DismissMessage(), "a stopped server must be reported")
+
+	controller.insertChar('y')
+	controller.syncLanguageServer()
+	assert.False(t, controller.screen.DismissMessage(), "the server is not restarted")
+	assert.Equal(t, []string{"open text"}, server.calls)
+}
+
+func TestDiagnostics_ConvertedToBufferColumns(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"😀ab", "cd"})
+	_, onDiagnostics := withLanguageServer(controller)
+	controller.syncLanguageServer()
+
+	path, _ := filepath.Abs("test.txt")
+	(*onDiagnostics)(path, []lsp.Diagnostic{
+		{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 1}}, Severity: 2, Message: "second"},
+		// 絵文字は UTF-16 で2単位なので、3単位目は2文字目になる
+		{Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 2}, End: lsp.Position{Line: 0, Character: 3}}, Message: "first"},
+	})
+
+	diags := controller.currentDiagnostics()
+	assert.Equal(t, []diagnostic.Diagnostic{
+		{Line: 0, Col: 1, EndLine: 0, EndCol: 2, Severity: diagnostic.SeverityError, Message: "first"},
+		{Line: 1, Col: 0, EndLine: 1, EndCol: 1, Severity: diagnostic.SeverityWarning, Message: "second"},
+	}, diags)
+
+	controller.nextDiagnostic()
+	assert.Equal(t, 0, controller.screen.GetCursor().ToPosition().Y)
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+	controller.nextDiagnostic()
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+
+	// 診断がすべて解消したら消す
+	(*onDiagnostics)(path, nil)
+	assert.Empty(t, controller.currentDiagnostics())
+}
+
+func TestDiagnostics_FollowEdits(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"ab", "cd", "ef"})
+	_, onDiagnostics := withLanguageServer(controller)
+	controller.syncLanguageServer()
+
+	path, _ := filepath.Abs("test.txt")
+	(*onDiagnostics)(path, []lsp.Diagnostic{
+		{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 1}}, Message: "second"},
+		{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 1}, End: lsp.Position{Line: 2, Character: 2}}, Message: "third"},
+	})
+	controller.updateDiagnostics()
+
+	// 次の診断が届くまでの編集にも、診断と下線は行と共に移動する
+	assert.NoError(t, c.InsertNewline(contents.Position{X: 0, Y: 0}, 0))
+	assert.NoError(t, c.InsertChar(contents.Position{X: 0, Y: 3}, 'x'))
+	diags := controller.currentDiagnostics()
+	if assert.Len(t, diags, 1, "the diagnostic on an edited line is dropped") {
+		assert.Equal(t, "second", diags[0].Message)
+		assert.Equal(t, 2, diags[0].Line)
+	}
+	_, ok := c.LineMeta(screen.DiagnosticsMeta, 2)
+	assert.True(t, ok, "the underline moves with the diagnostic")
+	_, ok = c.LineMeta(screen.DiagnosticsMeta, 3)
+	assert.False(t, ok)
+}
+
+func TestLSPComplete_ReplacesPrefix(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"fmt.Pr"}, special(key.KeyArrowDown), special(key.KeyEnter))
+	server, _ := withLanguageServer(controller)
+	server.items = []lsp.CompletionItem{
+		{Label: "Print"},
+		{Label: "Println", TextEdit: &lsp.TextEdit{
+			Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 4}, End: lsp.Position{Line: 0, Character: 6}},
+			NewText: "Println()",
+		}},
+	}
+	controller.screen.SetCursorPosition(6, 0)
+
+	assert.NoError(t, controller.lspComplete())
+	assert.Equal(t, lsp.Position{Line: 0, Character: 6}, server.completedAt)
+	assert.Equal(t, []string{"fmt.Println()"}, c.GetAllLines())
+}
+
+func TestLSPComplete_WithoutServer(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"fmt."})
+	assert.NoError(t, controller.lspComplete())
+	assert.Equal(t, []string{"fmt."}, c.GetAllLines())
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+const (
+	// reclaimMinBytes 以上の内容のバッファを閉じた場合は、解放したメモリをすぐに OS へ返す
+	// 強制的な GC を伴うため、小さなバッファを閉じるたびには行わない
+	reclaimMinBytes = 8 << 20
+	// idleTrimDelay の間カーソルも内容も変わらなければ、表示用のキャッシュを減らす
+	idleTrimDelay = 30 * time.Second
+	// idleWidthCacheKeep はキャッシュを減らす際に残す文字幅の行数
+	idleWidthCacheKeep = 256
+)
+
+// freeOSMemory は解放したメモリを OS へ返す。GC を待たせないよう別のゴルーチンで行う
+func freeOSMemory() {
+	go debug.FreeOSMemory()
+}
+
+// idleState は入力が止まった後に一度だけキャッシュを減らすための状態
+type idleState struct {
+	buffer  *contents.Contents
+	version uint64
+	cursor  contents.Position
+	offset  int
+	since   time.Time // 上の状態になった時刻
+	trimmed bool      // この状態で既にキャッシュを減らした
+}
+
+// releaseBuffer は閉じたバッファのキャッシュと編集履歴を手放す
+// 大きなバッファだった場合は、解放したメモリを OS へ返す
+func (c *Controller) releaseBuffer(b *window.Buffer) {
+	size := b.Contents.MemoryStats().Bytes
+	b.Contents.Compact()
+	b.History.Clear()
+	contents.SharedWidthCache().Trim(idleWidthCacheKeep)
+	if size >= reclaimMinBytes {
+		c.logger.Log("memory", fmt.Sprintf("Returning memory to the OS after closing a buffer of %d bytes", size))
+		c.freeOSMemory()
+	}
+}
+
+// trimIdleCaches はカーソルも内容もしばらく変わっていなければ、見えていない行のキャッシュを捨てる
+// 一度減らした後は、次に何か変わるまで繰り返さない
+func (c *Controller) trimIdleCaches(now time.Time) {
+	pos := c.screen.GetCursor().ToPosition()
+	_, offset := c.screen.GetOffset()
+	current := idleState{
+		buffer:  c.contents,
+		version: c.contents.Version(),
+		cursor:  contents.Position{X: pos.X, Y: pos.Y},
+		offset:  offset,
+	}
+	idle := &c.idle
+	if current.buffer != idle.buffer || current.version != idle.version || current.cursor != idle.cursor || current.offset != idle.offset {
+		current.since = now
+		*idle = current
+		return
+	}
+	if idle.trimmed || now.Sub(idle.since) < idleTrimDelay {
+		return
+	}
+	idle.trimmed = true
+
+	rows := c.screen.GetRowLines()
+	shown := make(map[*window.Buffer]bool)
+	for _, w := range c.windows.Windows() {
+		if w.Buffer == c.windows.Focused().Buffer {
+			continue
+		}
+		shown[w.Buffer] = true
+		w.Buffer.Contents.RetainRows(w.RowOffset, w.RowOffset+rows)
+	}
+	for _, b := range c.windows.Buffers() {
+		if b != c.windows.Focused().Buffer && !shown[b] {
+			b.Contents.RetainRows(0, 0)
+		}
+	}
+	c.contents.RetainRows(offset, offset+rows)
+	contents.SharedWidthCache().Trim(idleWidthCacheKeep)
+}
+
+// showMemoryStats はメモリの使用量とバッファごとのキャッシュの状態を情報パネルに表示する
+func (c *Controller) showMemoryStats() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	lines := []string{
+		fmt.Sprintf("Heap in use:      %s (live objects %s)", formatBytes(mem.HeapInuse), formatBytes(mem.HeapAlloc)),
+		fmt.Sprintf("Heap idle:        %s (returned to the OS %s)", formatBytes(mem.HeapIdle), formatBytes(mem.HeapReleased)),
+		fmt.Sprintf("Obtained from OS: %s", formatBytes(mem.Sys)),
+		fmt.Sprintf("GC cycles:        %d", mem.NumGC),
+		fmt.Sprintf("Goroutines:       %d", runtime.NumGoroutine()),
+	}
+	width := contents.SharedWidthCache().Stats()
+	lines = append(lines, fmt.Sprintf("Width cache:      %d lines (hit rate %.0f%%, %d evicted)", width.Size, width.HitRate()*100, width.Evictions), "")
+
+	focused := c.windows.Focused().Buffer
+	for _, b := range c.windows.Buffers() {
+		buffer, filename := b.Contents, b.FileManager.GetFilename()
+		if b == focused {
+			buffer, filename = c.contents, c.fileManager.GetFilename()
+		}
+		name := "[No Name]"
+		if filename != "" {
+			name = filepath.Base(filename)
+		}
+		stats := buffer.MemoryStats()
+		lines = append(lines, fmt.Sprintf("%s: %d lines, %s, %d slots, %d cached rows",
+			name, stats.Lines, formatBytes(uint64(stats.Bytes)), stats.Slots, stats.CachedRows))
+	}
+	c.ShowOverlay("Memory", lines)
+}
+
+// formatBytes はバイト数を読みやすい単位で表す
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseBuffer_ReleasesLargeBuffers(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	large := filepath.Join(dir, "large.txt")
+	assert.NoError(t, os.WriteFile(small, []byte("small\n"), 0644))
+	line := strings.Repeat("x", 1023) + "\n"
+	assert.NoError(t, os.WriteFile(large, []byte(strings.Repeat(line, reclaimMinBytes/1023+1)), 0644))
+
+	controller, _ := newKeyInputController(t, []string{"abc"})
+	freed := 0
+	controller.freeOSMemory = func() { freed++ }
+
+	// 小さなバッファを閉じても OS へは返さない
+	assert.NoError(t, controller.openFileCommand([]string{small}))
+	closed := controller.windows.Focused().Buffer
+	controller.closeBuffer()
+	assert.Equal(t, 0, freed)
+	assert.Zero(t, closed.Contents.MemoryStats().CachedRows)
+
+	assert.NoError(t, controller.openFileCommand([]string{large}))
+	controller.waitForLoad()
+	controller.contents.GetRow(0)
+	closed = controller.windows.Focused().Buffer
+	controller.closeBuffer()
+	assert.Equal(t, 1, freed)
+	assert.Zero(t, closed.Contents.MemoryStats().CachedRows)
+	assert.Equal(t, []string{"abc"}, controller.contents.GetAllLines())
+}
+
+func TestTrimIdleCaches_DropsRowsOutOfView(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	controller, c := newKeyInputController(t, lines)
+	for y := range lines {
+		c.GetRow(y)
+	}
+	now := time.Now()
+
+	controller.CheckIdleCheckpoint(now)
+	controller.CheckIdleCheckpoint(now.Add(idleTrimDelay / 2))
+	assert.Equal(t, len(lines), c.MemoryStats().CachedRows, "not idle long enough yet")
+
+	controller.CheckIdleCheckpoint(now.Add(idleTrimDelay))
+	kept := c.MemoryStats().CachedRows
+	assert.Equal(t, controller.screen.GetRowLines(), kept)
+
+	// 同じ状態のままなら繰り返さない
+	c.GetRow(150)
+	controller.CheckIdleCheckpoint(now.Add(2 * idleTrimDelay))
+	assert.Equal(t, kept+1, c.MemoryStats().CachedRows)
+}
+
+func TestShowMemoryStats(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"abc", "de"})
+	assert.NoError(t, controller.commands.Execute("memory-stats", nil))
+	assert.True(t, controller.screen.HasOverlay())
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "8.0 MiB", formatBytes(reclaimMinBytes))
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+// maxWheelBatch は1回の移動と描画にまとめるマウスホイールのイベントの上限
+// ホイールを回し続けて入力が途切れない間も、この件数ごとに描画する
+const maxWheelBatch = 64
+
+// handleMouseWheel はマウスホイールでカーソルを上下に移動する
+// 既に届いている続きのホイールのイベントもまとめて読み取り、上下の差し引きの回数だけ1回で移動して描画する
+func (c *Controller) handleMouseWheel(ev key.KeyEvent) error {
+	steps := wheelStep(ev)
+	for _, next := range c.inputs.TakeReady(isWheelEvent, maxWheelBatch) {
+		// まとめたイベントもマクロには1件ずつ記録する
+		c.macro.Record(next)
+		steps += wheelStep(next)
+	}
+	switch {
+	case steps < 0:
+		c.eventBus.Publish(event.NewRepeatedCursorEvent(cursor.MouseWheelUp, -steps))
+	case steps > 0:
+		c.eventBus.Publish(event.NewRepeatedCursorEvent(cursor.MouseWheelDown, steps))
+	}
+	return nil
+}
+
+// isWheelEvent はマウスホイールのイベントかどうかを返す
+func isWheelEvent(ev key.KeyEvent) bool {
+	return ev.Type == key.KeyEventMouse && ev.Key == key.KeyMouseWheel
+}
+
+// wheelStep はホイールのイベントで移動する向きを返す（上は -1、下は 1）
+func wheelStep(ev key.KeyEvent) int {
+	switch ev.MouseAction {
+	case key.MouseScrollUp:
+		return -1
+	case key.MouseScrollDown:
+		return 1
+	}
+	return 0
+}
+
+// handleMouseClick はマウスクリックをクリックされた領域に応じて処理する
+// 本文はカーソルの移動、ステータスバーは行番号・ファイルの種類の操作、メッセージバーはメッセージを閉じる
+// タブバーはクリックしたタブのバッファに切り替える
+// フォーカスのないウィンドウをクリックした場合は、先にそのウィンドウにフォーカスを移す
+// 区画の境界（上下に分割した場合は上のウィンドウのステータスバーの項目のない部分）を押すとドラッグを始める
+func (c *Controller) handleMouseClick(row, col int) error {
+	c.dragging = false
+	hit := c.windows.HitTest(c.screen.GetRowLines(), c.screen.GetColLines(), row, col)
+	if hit.Divider && (hit.Area == window.AreaDivider || c.statusSegmentOf(hit.Window, hit.Col) == screen.SegmentNone) {
+		c.dragging = true
+		return nil
+	}
+	switch hit.Area {
+	case window.AreaNone:
+		return nil
+	case window.AreaMessage:
+		if c.screen.DismissMessage() {
+			c.eventBus.Publish(event.NewRefreshEvent())
+		}
+		return nil
+	case window.AreaTabBar:
+		return c.clickTab(hit.Col)
+	}
+
+	if hit.Window != c.windows.FocusIndex() {
+		// 続けてクリック位置を切り替え後のウィンドウで解釈するため、切り替えの完了を待つ
+		c.waitForLoad()
+		if _, err := c.eventBus.PublishAndWaitResponse(event.NewFocusWindowEvent(hit.Window)); err != nil {
+			return nil
+		}
+	}
+
+	if hit.Area == window.AreaStatus {
+		return c.clickStatus(hit.Col)
+	}
+	c.clickText(hit.Row, hit.Col)
+	return nil
+}
+
+// handleMouseDrag は境界のドラッグ中に、境界をマウスの位置に移す
+// ドラッグの間も描画を続けるよう、移動のたびに区画の大きさを変える
+func (c *Controller) handleMouseDrag(row, col int) error {
+	if !c.dragging {
+		return nil
+	}
+	pos := row
+	if c.windows.Split() == window.SplitVertical {
+		pos = col
+	}
+	c.eventBus.Publish(event.NewMoveDividerEvent(pos))
+	return nil
+}
+
+// statusSegmentOf は index の位置のウィンドウのステータスバーで、左端から col 桁目に表示している項目を返す
+func (c *Controller) statusSegmentOf(index, col int) screen.StatusSegment {
+	if index == c.windows.FocusIndex() {
+		pos := c.screen.GetCursor().ToPosition()
+		return c.screen.StatusSegmentAt(c.contents, c.fileManager.GetFilename(), contents.Position{X: pos.X, Y: pos.Y}, c.screen.GutterWidth()+c.screen.TextCols(), col)
+	}
+	w := c.windows.Windows()[index]
+	buffer, filename := w.Buffer.Contents, w.Buffer.FileManager.GetFilename()
+	if w.Buffer == c.windows.Focused().Buffer {
+		buffer, filename = c.contents, c.fileManager.GetFilename()
+	}
+	width := c.windows.Regions(c.screen.GetRowLines(), c.screen.GetColLines())[index].Cols
+	return c.screen.StatusSegmentAt(buffer, filename, w.Cursor, width, col)
+}
+
+// clickStatus はフォーカスのある区画のステータスバーのクリックを処理する
+// カーソル位置をクリックすると行番号を入力して移動し、ファイルの種類をクリックすると種類を選び直す
+// 改行コードをクリックすると LF と CRLF を切り替える
+func (c *Controller) clickStatus(col int) error {
+	switch c.statusSegmentOf(c.windows.FocusIndex(), col) {
+	case screen.SegmentPosition:
+		return c.gotoLineCommand(nil)
+	case screen.SegmentFileType:
+		return c.SelectFileType()
+	case screen.SegmentLineEnding:
+		return c.setLineEndingCommand(nil)
+	}
+	return nil
+}
+
+// clickText はフォーカスのある区画の本文のクリックを処理し、カーソルを移動します
+// row と col は区画の左上からの位置。ガターをクリックした場合は行頭に移動する
+func (c *Controller) clickText(row, col int) {
+	col = max(col-c.screen.GutterWidth(), 0)
+	if c.screen.Wrap() {
+		x, y := c.screen.WrappedPositionAt(c.contents, row, col)
+		c.beforeCursorMove(false)
+		c.eventBus.Publish(event.NewCursorSetEvent(y, x))
+		return
+	}
+
+	// スクロールオフセットを考慮して、クリックされた画面上の位置をテキストバッファ上の位置に変換
+	offsetCol, offsetRow := c.screen.GetOffset()
+
+	// クリック位置にオフセットを加算して実際のテキスト位置を計算
+	bufferRow := row + offsetRow
+	bufferCol := col + offsetCol
+
+	// バッファの範囲内かチェック
+	if bufferRow >= c.contents.GetLineCount() {
+		bufferRow = c.contents.GetLineCount() - 1
+		if bufferRow < 0 {
+			bufferRow = 0
+		}
+	}
+
+	// 行を取得
+	targetRow := c.contents.GetRow(bufferRow)
+	if targetRow == nil {
+		return
+	}
+
+	// 画面上の列位置をバッファ内の文字位置（バイト位置）に変換
+	// この処理はタブ文字や全角文字を考慮する必要があります
+	bufferCol = targetRow.ScreenPositionToOffset(bufferCol)
+
+	// 行内の有効な位置にカーソルを制限
+	maxCol := targetRow.GetRuneCount()
+	if bufferCol > maxCol {
+		bufferCol = maxCol
+	}
+	if bufferCol < 0 {
+		bufferCol = 0
+	}
+
+	// カーソル位置を更新（イベントを発行）
+	c.beforeCursorMove(false)
+	c.logger.Log("cursor", fmt.Sprintf("Publishing cursor set event to row: %d, col: %d", bufferRow, bufferCol))
+	c.eventBus.Publish(event.NewCursorSetEvent(bufferRow, bufferCol))
+}
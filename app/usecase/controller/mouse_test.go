@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func click(row, col int) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventMouse, Key: key.KeyMouseClick, MouseAction: key.MouseLeftClick, MouseRow: row, MouseCol: col}
+}
+
+func TestMouseClick_RoutesByRegion(t *testing.T) {
+	// 24 行 80 桁を上下に分割すると、下の区画は 11 行目から始まり、ステータスバーは 21 行目、メッセージバーは 22 行目
+	// ステータスバーの右端は "Text  UTF-8  LF  Ln 2, Col 2  50%" で、46 桁目からファイルの種類、63 桁目から位置を表示する
+	controller, c := newKeyInputController(t, []string{"one", "two", "three", "four"},
+		ctrlKey(key.KeyCtrlK), char('-'),
+		click(12, 1),
+		click(21, 70), char('4'), special(key.KeyEnter),
+		click(21, 46), special(key.KeyArrowDown), special(key.KeyEnter),
+		click(22, 0),
+	)
+
+	assert.NoError(t, controller.Process())
+
+	// 下の区画の本文をクリックするとフォーカスを移してカーソルを置く
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 1, controller.windows.FocusIndex())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.X)
+	assert.Equal(t, 1, pos.Y)
+
+	// カーソル位置をクリックすると行番号を入力して移動する
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 3, controller.screen.GetCursor().ToPosition().Y)
+
+	// ファイルの種類をクリックすると一覧から選び直す
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "TypeScript", c.FileType())
+	assert.False(t, controller.screen.HasOverlay())
+
+	// メッセージバーをクリックするとメッセージを閉じる
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.screen.DismissMessage(), "the message must already be dismissed")
+}
+
+func TestMouseDrag_ResizesSplit(t *testing.T) {
+	drag := func(row, col int) key.KeyEvent {
+		return key.KeyEvent{Type: key.KeyEventMouse, Key: key.KeyMouseClick, MouseAction: key.MouseDrag, MouseRow: row, MouseCol: col}
+	}
+	release := key.KeyEvent{Type: key.KeyEventMouse, Key: key.KeyMouseClick, MouseAction: key.MouseRelease}
+	// 24 行 80 桁を上下に分割すると、上の区画のステータスバーは 10 行目
+	controller, _ := newKeyInputController(t, []string{"one", "two", "three", "four"},
+		ctrlKey(key.KeyCtrlK), char('-'),
+		click(10, 0), drag(7, 0), drag(5, 0), release, drag(15, 0),
+		ctrlKey(key.KeyCtrlW), char('>'),
+	)
+	rows := func() int {
+		return controller.windows.Regions(controller.screen.GetRowLines(), controller.screen.GetColLines())[0].Rows
+	}
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 10, rows())
+
+	// ステータスバーの項目のない部分を押して動かすと、境界がマウスの位置に付いてくる
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 7, rows())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 5, rows())
+
+	// ボタンを離した後のドラッグでは動かない
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 5, rows())
+	assert.Equal(t, 0, controller.windows.FocusIndex(), "grabbing the divider must not move the focus")
+
+	// C-w > でフォーカスのあるウィンドウを1行大きくする
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 6, rows())
+}
+
+// burstTerminal は全てのイベントを1回の読み取りでまとめて返す端末
+type burstTerminal struct {
+	events []key.KeyEvent
+}
+
+func (b *burstTerminal) GetInputEvents() (key.KeyEvent, []key.KeyEvent, error) {
+	if len(b.events) == 0 {
+		return key.KeyEvent{}, nil, io.EOF
+	}
+	events := b.events
+	b.events = nil
+	return events[0], events[1:], nil
+}
+
+func wheel(action key.MouseAction) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventMouse, Key: key.KeyMouseWheel, MouseAction: action}
+}
+
+func TestMouseWheel_CoalescesBurst(t *testing.T) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	controller, _ := newKeyInputController(t, lines)
+	events := make([]key.KeyEvent, 200)
+	for i := range events {
+		events[i] = wheel(key.MouseScrollDown)
+	}
+	controller.inputs = input.NewCompositeProvider(&burstTerminal{events: events}, 0, controller.macroSource)
+	tracer := event.NewTracer(10000)
+	controller.eventBus.SetTracer(tracer)
+
+	// 1回の処理で最大 maxWheelBatch 件の続きをまとめるため、200件は4回で処理し終わる
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	assert.Equal(t, 600, controller.screen.GetCursor().ToPosition().Y, "each wheel event scrolls 3 lines")
+	_, _, err := controller.inputs.GetInputEvents()
+	assert.ErrorIs(t, err, io.EOF, "all wheel events must have been consumed")
+
+	refreshes := 0
+	for _, r := range tracer.Records() {
+		if r.Type == event.TypeRefresh {
+			refreshes++
+		}
+	}
+	assert.LessOrEqual(t, refreshes, 4, "a burst of wheel events must not redraw for every event")
+}
+
+func TestMouseWheel_NetsOppositeDirections(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"})
+	controller.screen.SetCursorPosition(0, 5)
+	controller.inputs = input.NewCompositeProvider(&burstTerminal{events: []key.KeyEvent{
+		wheel(key.MouseScrollUp), wheel(key.MouseScrollDown), wheel(key.MouseScrollDown), char('x'),
+	}}, 0, controller.macroSource)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 8, controller.screen.GetCursor().ToPosition().Y)
+	// ホイール以外の入力はまとめずに残す
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "x", controller.contents.GetContentLine(8)[:1])
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/palette"
+)
+
+const paletteFooter = "Type to filter  Up/Down: move  Enter: run  Esc: close"
+
+// paletteItems はパレットに表示するコマンドと、それを呼び出すキーの一覧を返す
+func (c *Controller) paletteItems() []palette.Item {
+	var items []palette.Item
+	for _, cmd := range c.commands.Commands() {
+		if cmd.Name == "command-palette" {
+			continue
+		}
+		items = append(items, palette.Item{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Keys:        c.keymap.KeysFor(cmd.Name),
+		})
+	}
+	return items
+}
+
+// CommandPalette は名前や説明のあいまい検索でコマンドを選んで実行する
+// 最近・頻繁に使ったコマンドほど上位に表示し、割り当てられたキーを並べて表示する
+func (c *Controller) CommandPalette() error {
+	items := c.paletteItems()
+	var query []rune
+	selected := 0
+	for {
+		entries := palette.Rank(items, string(query), c.paletteUsage)
+		if selected >= len(entries) {
+			selected = len(entries) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+		c.screen.SetListOverlay("> "+string(query), paletteLines(entries), selected, paletteFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Modifiers.Has(key.ModAlt) {
+				continue
+			}
+			query = append(query, ev.Rune)
+			selected = 0
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if selected > 0 {
+					selected--
+				}
+			case key.KeyArrowDown:
+				if selected < len(entries)-1 {
+					selected++
+				}
+			case key.KeyBackspace:
+				if len(query) > 0 {
+					query = query[:len(query)-1]
+					selected = 0
+				}
+			case key.KeyEnter:
+				if len(entries) == 0 {
+					continue
+				}
+				c.dismissOverlay()
+				return c.runPaletteCommand(entries[selected].Name)
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX {
+				c.dismissOverlay()
+				return nil
+			}
+		}
+	}
+}
+
+// runPaletteCommand はパレットで選んだコマンドを実行し、使用履歴に記録する
+func (c *Controller) runPaletteCommand(name string) error {
+	c.paletteUsage.Record(name)
+	err := c.commands.Execute(name, nil)
+	if errors.Is(err, command.ErrUnknownCommand) {
+		c.setStatusMessage("Unknown command: %s", name)
+		return nil
+	}
+	return err
+}
+
+// paletteLines は検索結果を「名前 キー 説明」の形式で並べる
+func paletteLines(entries []palette.Entry) []string {
+	nameWidth, keysWidth := 0, 0
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = strings.Join(e.Keys, ", ")
+		nameWidth = max(nameWidth, len(e.Name))
+		keysWidth = max(keysWidth, len(keys[i]))
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("%-*s  %-*s  %s", nameWidth, e.Name, keysWidth, keys[i], e.Description)
+	}
+	if len(lines) == 0 {
+		lines = []string{"No matching commands"}
+	}
+	return lines
+}
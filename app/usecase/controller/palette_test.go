@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestCommandPalette_RunsSelectedCommand(t *testing.T) {
+	events := []key.KeyEvent{{Type: key.KeyEventChar, Rune: 'x', Modifiers: key.ModAlt}}
+	for _, r := range "delwrd" {
+		events = append(events, char(r))
+	}
+	events = append(events, key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEnter})
+	controller, c := newKeyInputController(t, []string{"foo  bar baz"}, events...)
+	controller.screen.SetCursorPosition(3, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo baz", c.GetContentLine(0))
+	assert.False(t, controller.screen.HasOverlay())
+	assert.Positive(t, controller.paletteUsage.Boost("delete-word"))
+}
+
+func TestCommandPalette_Cancel(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo"},
+		ctrlKey(key.KeyCtrlK), char('p'), char('q'),
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEsc},
+	)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "foo", c.GetContentLine(0))
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestPaletteItems_ShowKeys(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+
+	for _, item := range controller.paletteItems() {
+		assert.NotEqual(t, "command-palette", item.Name)
+		if item.Name == "delete-word" {
+			assert.Equal(t, []string{"M-d", "C-k w"}, item.Keys)
+		}
+	}
+	assert.Equal(t, []string{"No matching commands"}, paletteLines(nil))
+}
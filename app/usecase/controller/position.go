@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/positionfile"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+// SetPositionStore はファイルごとの最後のカーソル位置の記録先を設定する。nil なら記録も復元もしない
+func (c *Controller) SetPositionStore(store positionfile.Store) {
+	c.positionStore = store
+}
+
+// rememberPositions は buffers のうちファイルを開いているものについて、最後のカーソル位置を記録する
+// フォーカスのあるバッファの位置は storeWindow で Buffer に書き戻してから呼び出す
+func (c *Controller) rememberPositions(buffers []*window.Buffer, now time.Time) {
+	if c.positionStore == nil {
+		return
+	}
+	positions := map[string]positionfile.Position{}
+	for _, b := range buffers {
+		if name := b.FileManager.GetFilename(); name != "" {
+			positions[name] = positionfile.Position{Line: b.Cursor.Y, Column: b.Cursor.X, SavedAt: now}
+		}
+	}
+	if len(positions) == 0 {
+		return
+	}
+	if err := c.positionStore.Remember(positions); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to remember cursor positions: %v", err))
+	}
+}
+
+// RestorePosition は開いたファイルについて記録した最後のカーソル位置へ移動する
+// 記録した行がまだ読み込まれていなければ、読み込みが終わるのを待つ
+func (c *Controller) RestorePosition() {
+	filename := c.fileManager.GetFilename()
+	if c.positionStore == nil || filename == "" {
+		return
+	}
+	pos, ok, err := c.positionStore.Recall(filename)
+	if err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to recall the cursor position: %v", err))
+		return
+	}
+	if !ok {
+		return
+	}
+	if pos.Line >= c.contents.GetLineCount() {
+		c.waitForLoad()
+	}
+	c.keepCursorNear(contents.Position{X: pos.Column, Y: pos.Line})
+	c.updateScroll()
+}
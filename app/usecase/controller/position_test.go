@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/positionfile"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// memoryPositionStore はメモリ上にカーソル位置を置くストア
+type memoryPositionStore map[string]positionfile.Position
+
+func (s memoryPositionStore) Remember(positions map[string]positionfile.Position) error {
+	for path, pos := range positions {
+		s[path] = pos
+	}
+	return nil
+}
+
+func (s memoryPositionStore) Recall(path string) (positionfile.Position, bool, error) {
+	pos, ok := s[path]
+	return pos, ok, nil
+}
+
+func TestPosition_RestoredOnOpenAndRememberedOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644))
+
+	controller, _ := newKeyInputController(t, []string{"a"}, ctrlKey(key.KeyCtrlK), char('q'))
+	store := memoryPositionStore{path: {Line: 1, Column: 2}}
+	controller.SetPositionStore(store)
+
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().X)
+
+	// バッファを閉じると最後の位置を記録する
+	controller.screen.SetCursorPosition(3, 2)
+	assert.NoError(t, controller.Process())
+	assert.Len(t, controller.windows.Buffers(), 1)
+	assert.Equal(t, 2, store[path].Line)
+	assert.Equal(t, 3, store[path].Column)
+}
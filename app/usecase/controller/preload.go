@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// errPartiallyLoaded は読み込みが完了していないバッファを保存しようとした場合のエラー
+var errPartiallyLoaded = errors.New("the file is not fully loaded")
+
+// SetPreloadLines はファイルを開くときに先に読み込んで表示する行数を設定する
+// 残りは裏で読み込み、完了したらバッファの末尾に追加する。0 の場合はファイル全体を読み込んでから表示する
+func (c *Controller) SetPreloadLines(n int) {
+	c.preloadLines = n
+}
+
+// openFileProgressive はファイルの先頭だけを読み込み、残りの読み込みを開始する
+func (c *Controller) openFileProgressive(filename string) error {
+	rest, err := c.fileManager.OpenFileHead(filename, c.preloadLines)
+	if err != nil {
+		return err
+	}
+
+	c.loadMutex.Lock()
+	c.loadGeneration++
+	generation := c.loadGeneration
+	c.loadFailed = false
+	c.loadDone = nil
+	if rest != nil {
+		c.loadDone = make(chan struct{})
+	}
+	c.loadMutex.Unlock()
+
+	c.screen.SetLoading(rest != nil)
+	if rest == nil {
+		return nil
+	}
+	go c.loadRest(generation, rest)
+	return nil
+}
+
+// loadRest はファイルの残りを少しずつ読み込み、読み込んだ行をイベントとして発行する
+// 読み込み中も操作できるよう、行の追加はイベントバスのハンドラーで行う
+// 別のファイルを開き直した場合は読み込みをやめる
+func (c *Controller) loadRest(generation int, rest func() ([]string, int, error)) {
+	for {
+		lines, percent, err := rest()
+		if err == io.EOF {
+			c.eventBus.Publish(event.NewFileLoadedEvent(generation, lines, nil))
+			return
+		}
+		if err != nil {
+			c.eventBus.Publish(event.NewFileLoadedEvent(generation, nil, err))
+			return
+		}
+		c.eventBus.Publish(event.NewFileProgressEvent(generation, lines, percent))
+
+		c.loadMutex.Lock()
+		stale := generation != c.loadGeneration
+		c.loadMutex.Unlock()
+		if stale {
+			return
+		}
+	}
+}
+
+// createFileLoadedHandler は読み込んだ残りの行をバッファに追加するハンドラーを作成する
+func (c *Controller) createFileLoadedHandler() event.Handler {
+	return event.NewSingleTypeHandler(event.TypeFileLoaded, func(e event.Event) (bool, error) {
+		loaded, ok := e.Payload.(event.FileLoadedEvent)
+		if !ok {
+			return false, nil
+		}
+
+		c.loadMutex.Lock()
+		// 読み込み中に別のファイルを開いた場合、古い結果は捨てる
+		if loaded.Generation != c.loadGeneration || c.loadDone == nil {
+			c.loadMutex.Unlock()
+			return true, nil
+		}
+		if loaded.Partial {
+			c.loadMutex.Unlock()
+			// 読み込んだ分だけ先に追加し、スクロールして見られるようにする
+			c.contents.AppendLines(loaded.Lines)
+			c.screen.SetLoadProgress(loaded.Percent)
+			c.eventBus.Publish(event.NewRefreshEvent())
+			return true, nil
+		}
+		done := c.loadDone
+		c.loadDone = nil
+		c.loadFailed = loaded.Err != nil
+		c.loadMutex.Unlock()
+
+		if errors.Is(loaded.Err, filemanager.ErrInvalidUTF8) {
+			// 先頭が ASCII だけで文字コードを判定できなかった場合は、指定して開き直せる
+			c.setErrorMessage("Failed to load the rest of the file: %v (try reopen-with-encoding)", loaded.Err)
+		} else if loaded.Err != nil {
+			c.setErrorMessage("Failed to load the rest of the file: %v", loaded.Err)
+		} else {
+			c.contents.AppendLines(loaded.Lines)
+			c.restoreHistory(c.fileManager.GetFilename())
+		}
+		c.screen.SetLoading(false)
+		close(done)
+		c.eventBus.Publish(event.NewRefreshEvent())
+		return true, nil
+	})
+}
+
+// waitForLoad はファイルの残りの読み込みが完了するまで待つ
+// バッファ全体を必要とする操作（保存、定義へのジャンプなど）の前に呼び出す
+// イベントバスのハンドラーから呼び出すと読み込み完了イベントを処理できなくなるため、メインループからのみ呼び出すこと
+func (c *Controller) waitForLoad() {
+	c.loadMutex.Lock()
+	done := c.loadDone
+	c.loadMutex.Unlock()
+	if done == nil {
+		return
+	}
+	c.setStatusMessage("Loading %s...", c.fileManager.GetFilename())
+	<-done
+}
+
+// checkFullyLoaded はバッファ全体が読み込まれているかを確認する
+// 一部だけを保存して元のファイルを切り詰めないよう、保存の直前に確認する
+func (c *Controller) checkFullyLoaded() error {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+	if c.loadDone != nil {
+		return fmt.Errorf("%w: still loading", errPartiallyLoaded)
+	}
+	if c.loadFailed {
+		return fmt.Errorf("%w: reopen the file before saving", errPartiallyLoaded)
+	}
+	return nil
+}
+
+// isLoading はファイルの残りを読み込み中かどうかを返す
+func (c *Controller) isLoading() bool {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+	return c.loadDone != nil
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+func TestOpenFile_LoadsRestInBackground(t *testing.T) {
+	controller, c := newKeyInputController(t, nil)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	controller.SetPreloadLines(2)
+
+	release := make(chan struct{})
+	rest := func() ([]string, int, error) {
+		<-release
+		return []string{"c", "d"}, 100, io.EOF
+	}
+	fm.EXPECT().OpenFileHead("big.txt", 2).DoAndReturn(func(string, int) (func() ([]string, int, error), error) {
+		c.LoadContent([]string{"a", "b"})
+		return rest, nil
+	})
+
+	assert.NoError(t, controller.OpenFile("big.txt"))
+	assert.Equal(t, []string{"a", "b"}, c.GetAllLines())
+	assert.True(t, controller.isLoading())
+
+	// 読み込み中の保存は拒否する（SaveFile は呼ばれない）
+	controller.eventBus.Publish(event.NewSaveEvent("test.txt", false))
+
+	// テストのイベントバスは同期的に処理するため、読み込み完了イベントは読み込んだゴルーチンで処理される
+	// 画面の更新と重ならないよう、完了を待ってから waitForLoad を呼び出す
+	controller.loadMutex.Lock()
+	done := controller.loadDone
+	controller.loadMutex.Unlock()
+	close(release)
+	<-done
+	controller.waitForLoad()
+	assert.False(t, controller.isLoading())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, c.GetAllLines())
+	assert.NoError(t, controller.checkFullyLoaded())
+
+	fm.EXPECT().SaveFile("test.txt", []string{"a", "b", "c", "d"}).Return(nil)
+	controller.eventBus.Publish(event.NewSaveEvent("test.txt", false))
+}
+
+func TestOpenFile_IgnoresStaleLoad(t *testing.T) {
+	controller, c := newKeyInputController(t, nil)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	controller.SetPreloadLines(1)
+
+	release := make(chan struct{})
+	fm.EXPECT().OpenFileHead("first.txt", 1).DoAndReturn(func(string, int) (func() ([]string, int, error), error) {
+		c.LoadContent([]string{"first"})
+		return func() ([]string, int, error) {
+			<-release
+			return []string{"stale"}, 50, nil
+		}, nil
+	})
+	fm.EXPECT().OpenFileHead("second.txt", 1).DoAndReturn(func(string, int) (func() ([]string, int, error), error) {
+		c.LoadContent([]string{"second"})
+		return nil, nil
+	})
+
+	assert.NoError(t, controller.OpenFile("first.txt"))
+	assert.NoError(t, controller.OpenFile("second.txt"))
+	assert.False(t, controller.isLoading())
+
+	// 前のファイルの読み込み結果は捨てられる
+	controller.eventBus.Publish(event.NewFileLoadedEvent(1, []string{"stale"}, nil))
+	close(release)
+	assert.Equal(t, []string{"second"}, c.GetAllLines())
+}
+
+func TestOpenFile_AppendsChunksWhileLoading(t *testing.T) {
+	controller, c := newKeyInputController(t, nil)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	controller.SetPreloadLines(1)
+
+	// 読み込み側は1つ読み込むごとに次の指示を待つ
+	next := make(chan struct{})
+	read := make(chan struct{})
+	chunks := [][]string{{"b", "c"}, {"d"}, {"e", ""}}
+	calls := 0
+	fm.EXPECT().OpenFileHead("big.txt", 1).DoAndReturn(func(string, int) (func() ([]string, int, error), error) {
+		c.LoadContent([]string{"a"})
+		return func() ([]string, int, error) {
+			if calls > 0 {
+				read <- struct{}{}
+			}
+			<-next
+			calls++
+			if calls == len(chunks) {
+				return chunks[calls-1], 100, io.EOF
+			}
+			return chunks[calls-1], calls * 30, nil
+		}, nil
+	})
+	assert.NoError(t, controller.OpenFile("big.txt"))
+
+	// 読み込んだ分だけ先に追加され、読み込み中のまま操作できる
+	next <- struct{}{}
+	<-read
+	assert.Equal(t, []string{"a", "b", "c"}, c.GetAllLines())
+	assert.True(t, controller.isLoading())
+	controller.screen.SetCursorPosition(0, 2)
+	next <- struct{}{}
+	<-read
+	assert.Equal(t, []string{"a", "b", "c", "d"}, c.GetAllLines())
+
+	controller.loadMutex.Lock()
+	done := controller.loadDone
+	controller.loadMutex.Unlock()
+	next <- struct{}{}
+	<-done
+	assert.False(t, controller.isLoading())
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", ""}, c.GetAllLines())
+	assert.False(t, c.IsDirty())
+}
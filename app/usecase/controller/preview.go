@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/search"
+)
+
+const previewFooter = "y: apply  n/Esc: cancel  Up/Down: scroll"
+
+// confirmPreview は変更のプレビューを表示し、適用するかどうかを確認する
+// y で適用、n / Esc / C-c / C-x で取り消す。上下キーで差分をスクロールする
+func (c *Controller) confirmPreview(p command.Preview) (bool, error) {
+	defer c.dismissOverlay()
+
+	current := 0
+	for {
+		c.screen.SetListOverlay(p.Summary, p.Diff, current, previewFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'y' || ev.Rune == 'Y'):
+			return true, nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'n' || ev.Rune == 'N' || ev.Rune == 'q'),
+			ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			return false, nil
+		case ev.Type == key.KeyEventSpecial && ev.Key == key.KeyArrowUp:
+			if current > 0 {
+				current--
+			}
+		case ev.Type == key.KeyEventSpecial && ev.Key == key.KeyArrowDown:
+			if current < len(p.Diff)-1 {
+				current++
+			}
+		}
+	}
+}
+
+// dryRunCommand はコマンドを実行せずに、実行した場合の変更を表示する
+// 引数がなければ「コマンド名 引数...」を入力させる
+func (c *Controller) dryRunCommand(args []string) error {
+	if len(args) == 0 {
+		input, err := c.prompt("Dry run: ")
+		if err != nil || input == "" {
+			return err
+		}
+		args = strings.Fields(input)
+		if len(args) == 0 {
+			return nil
+		}
+	}
+	c.waitForLoad()
+
+	name := args[0]
+	p, err := c.commands.Preview(name, args[1:])
+	switch {
+	case errors.Is(err, command.ErrUnknownCommand):
+		c.setStatusMessage("Unknown command: %s", name)
+	case errors.Is(err, command.ErrNoPreview):
+		c.setStatusMessage("%s has no preview", name)
+	case err != nil:
+		c.setErrorMessage("%s: %v", name, err)
+	case p.Empty():
+		c.setStatusMessage("%s would change nothing", name)
+	default:
+		c.ShowOverlay(p.Summary, p.Diff)
+	}
+	return nil
+}
+
+// replacementPreview は一致箇所を replacement に置き換えた場合の変更を返す
+func (c *Controller) replacementPreview(pattern, replacement string, matches []search.Match) command.Preview {
+	lines := c.contents.GetAllLines()
+	return command.Preview{
+		Summary: fmt.Sprintf("Replace %d occurrence(s) of %q with %q", len(matches), pattern, replacement),
+		Diff:    command.DiffLines(lines, search.ReplacedLines(lines, matches, replacement)),
+	}
+}
+
+// previewReplaceAll は replace-all を実行した場合の変更を返す
+func (c *Controller) previewReplaceAll(args []string) (command.Preview, error) {
+	if len(args) < 2 {
+		return command.Preview{}, errors.New("usage: replace-all PATTERN REPLACEMENT")
+	}
+	matches := search.Find(c.contents.GetAllLines(), args[0])
+	return c.replacementPreview(args[0], args[1], matches), nil
+}
+
+// replaceAllCommand はバッファ内の pattern を全て replacement に置き換える
+// 置き換える前に変更のプレビューを表示して確認する。引数が足りなければ入力させる
+func (c *Controller) replaceAllCommand(args []string) error {
+	c.waitForLoad()
+
+	var pattern, replacement string
+	if len(args) > 0 {
+		pattern = args[0]
+	} else {
+		input, err := c.prompt("Replace all: ")
+		if err != nil || input == "" {
+			return err
+		}
+		pattern = input
+	}
+	if len(args) > 1 {
+		replacement = args[1]
+	} else {
+		input, ok, err := c.promptInput(fmt.Sprintf("Replace all %q with: ", pattern), true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			c.setStatusMessage("Replace aborted")
+			return nil
+		}
+		replacement = input
+	}
+
+	matches := search.Find(c.contents.GetAllLines(), pattern)
+	if len(matches) == 0 {
+		c.setStatusMessage("No matches for %q", pattern)
+		return nil
+	}
+	ok, err := c.confirmPreview(c.replacementPreview(pattern, replacement, matches))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.setStatusMessage("Replace cancelled")
+		return nil
+	}
+	// まとめて置き換える前の内容を書き出しておく
+	c.RequestCheckpoint(time.Now())
+	if c.applyReplacements(matches, replacement) {
+		c.setStatusMessage("Replaced %d occurrence(s)", len(matches))
+	}
+	return nil
+}
+
+// formatted は保存前フックで整形した場合の内容と、その変更のプレビューを返す
+func (c *Controller) formatted() ([]string, command.Preview, error) {
+	if c.savePipeline.Len() == 0 {
+		return nil, command.Preview{}, errors.New("no formatter is enabled for this buffer")
+	}
+	lines := c.contents.GetAllLines()
+	name := c.fileManager.GetFilename()
+	result := c.savePipeline.Run(name, lines)
+	if !result.Changed && len(result.Errors) > 0 {
+		return nil, command.Preview{}, result.Errors[0]
+	}
+	return result.Lines, command.Preview{
+		Summary: fmt.Sprintf("Format %s", name),
+		Diff:    command.DiffLines(lines, result.Lines),
+	}, nil
+}
+
+// previewFormatBuffer は format-buffer を実行した場合の変更を返す
+func (c *Controller) previewFormatBuffer([]string) (command.Preview, error) {
+	_, p, err := c.formatted()
+	return p, err
+}
+
+// formatBuffer は保存前フック（gofmt / goimports）でバッファを整形する
+// 保存せずに整形するため、適用する前に変更のプレビューを表示して確認する
+func (c *Controller) formatBuffer() error {
+	c.waitForLoad()
+
+	lines, p, err := c.formatted()
+	if err != nil {
+		c.setErrorMessage("Cannot format: %v", err)
+		return nil
+	}
+	if p.Empty() {
+		c.setStatusMessage("Already formatted")
+		return nil
+	}
+	ok, err := c.confirmPreview(p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.setStatusMessage("Format cancelled")
+		return nil
+	}
+	c.RequestCheckpoint(time.Now())
+	if _, err := c.eventBus.PublishAndWaitResponse(event.NewSetLinesEvent(lines)); err == nil {
+		c.setStatusMessage("Formatted")
+	}
+	return nil
+}
+
+// performSetLines はバッファ全体を lines に置き換える
+func (c *Controller) performSetLines(lines []string) {
+	if c.contents.ReadOnly() || len(c.contents.ReadOnlyRegions()) > 0 {
+		c.reportEditError(contents.ErrReadOnly)
+		return
+	}
+	c.replaceContents(lines)
+	c.updateScroll()
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+)
+
+// upperHook は全ての行を大文字にする保存前フック
+type upperHook struct{}
+
+func (upperHook) Name() string { return "upper" }
+func (upperHook) Apply(filename string, lines []string) ([]string, error) {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.ToUpper(line)
+	}
+	return out, nil
+}
+
+func TestReplaceAllCommand(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo bar", "foo"}, char('n'), char('y'))
+
+	// 取り消した場合は変更しない
+	assert.NoError(t, controller.replaceAllCommand([]string{"foo", "baz"}))
+	assert.Equal(t, []string{"foo bar", "foo"}, c.GetAllLines())
+	assert.False(t, controller.screen.HasOverlay())
+
+	assert.NoError(t, controller.replaceAllCommand([]string{"foo", "baz"}))
+	assert.Equal(t, []string{"baz bar", "baz"}, c.GetAllLines())
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestReplaceAll_Preview(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo bar", "qux", "foo"})
+
+	p, err := controller.commands.Preview("replace-all", []string{"foo", "baz"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-   1: foo bar", "+   1: baz bar", "-   3: foo", "+   3: baz"}, p.Diff)
+	// プレビューはバッファを変更しない
+	assert.Equal(t, []string{"foo bar", "qux", "foo"}, c.GetAllLines())
+	assert.False(t, c.IsDirty())
+
+	_, err = controller.commands.Preview("replace-all", []string{"foo"})
+	assert.Error(t, err)
+}
+
+func TestReplace_AllCancelled(t *testing.T) {
+	// 残りを全て置換する前の確認を取り消すと、今の一致箇所の確認に戻る
+	keys := append(replaceKeys("a", "x", "an"), special(key.KeyEsc))
+	controller, c := newKeyInputController(t, []string{"a a"}, keys...)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"a a"}, c.GetAllLines())
+}
+
+func TestFormatBuffer(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"package main"}, char('y'))
+	controller.savePipeline = save.NewPipeline(upperHook{})
+
+	p, err := controller.commands.Preview("format-buffer", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-   1: package main", "+   1: PACKAGE MAIN"}, p.Diff)
+	assert.Equal(t, []string{"package main"}, c.GetAllLines())
+
+	assert.NoError(t, controller.formatBuffer())
+	assert.Equal(t, []string{"PACKAGE MAIN"}, c.GetAllLines())
+	assert.True(t, c.IsDirty())
+
+	// 整形も1回で取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"package main"}, c.GetAllLines())
+}
+
+func TestFormatBuffer_NoFormatter(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"package main"})
+	controller.savePipeline = save.NewPipeline()
+
+	assert.NoError(t, controller.formatBuffer())
+	assert.Equal(t, []string{"package main"}, c.GetAllLines())
+	_, err := controller.commands.Preview("format-buffer", nil)
+	assert.Error(t, err)
+}
+
+func TestDryRunCommand(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo"})
+
+	assert.NoError(t, controller.dryRunCommand([]string{"replace-all", "foo", "bar"}))
+	assert.True(t, controller.screen.HasOverlay())
+	assert.Equal(t, []string{"foo"}, c.GetAllLines())
+	controller.dismissOverlay()
+
+	// プレビューに対応していないコマンドは実行しない
+	assert.NoError(t, controller.dryRunCommand([]string{"close-buffer"}))
+	assert.False(t, controller.screen.HasOverlay())
+	assert.NoError(t, controller.dryRunCommand([]string{"replace-all", "nothing", "bar"}))
+	assert.False(t, controller.screen.HasOverlay())
+}
+
+func TestDryRunCommand_BlankInput(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"foo"}, char(' '), char(' '), special(key.KeyEnter))
+
+	assert.NoError(t, controller.dryRunCommand(nil), "spaces only do nothing")
+	assert.False(t, controller.screen.HasOverlay())
+}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/grep"
+	"github.com/wasya-io/go-kilo/app/boundary/trustfile"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/keymap"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+)
+
+// buildTimeout はビルドコマンドの実行時間の上限
+const buildTimeout = 5 * time.Minute
+
+// projectBindingSource はプロジェクト設定で割り当てたキーの出どころ
+const projectBindingSource = "project"
+
+// loadProjectSettings は filename から親ディレクトリへ向かって .go-kilo.toml を探し、見つかれば適用する
+// 設定ファイルの誤りは編集を妨げないよう情報パネルに表示するだけにとどめる
+func (c *Controller) loadProjectSettings(filename string) {
+	path := config.FindProjectFile(filepath.Dir(filename))
+	if path == "" {
+		if c.project != nil {
+			c.ApplyProject(nil)
+		}
+		return
+	}
+
+	p, errs, err := config.LoadProject(path)
+	if err != nil {
+		c.setStatusMessage("Project settings: %v", err)
+		return
+	}
+	problems := append(errs.Lines(), c.ApplyProject(p)...)
+	if len(p.Build) > 0 && !c.projectTrusted(p) {
+		c.setStatusMessage("Build commands in %s are disabled until you trust it (run build)", path)
+	}
+	if len(problems) > 0 {
+		c.ShowOverlay("Project settings errors ("+path+")", problems)
+		return
+	}
+	c.reportKeyConflicts()
+}
+
+// applySearchExclude はプロジェクト設定で除外したディレクトリをプロジェクト検索とファイル検索に反映する
+func (c *Controller) applySearchExclude() {
+	var exclude []string
+	root := ""
+	if c.project != nil {
+		exclude, root = c.project.Exclude, c.project.Root
+	}
+	if e, ok := c.projectSearcher.(grep.Excluder); ok {
+		e.SetExclude(root, exclude)
+	}
+	if e, ok := c.fileLister.(grep.Excluder); ok {
+		e.SetExclude(root, exclude)
+	}
+}
+
+// ApplyProject はプロジェクト設定を適用し、適用できなかった項目を返す
+// nil を渡すとプロジェクト設定を外してユーザー設定だけの状態に戻す
+func (c *Controller) ApplyProject(p *config.Project) []string {
+	c.project = p
+
+	// 前のプロジェクトの割り当てが残らないよう、既定の割り当てから作り直す
+	c.keymap = keymap.New()
+	c.keymap.SetPrecedence(c.keyPrecedence)
+	c.bindDefaultKeys()
+	c.rebuildSavePipeline()
+
+	c.applySearchExclude()
+	problems := c.applyIndentRules(p)
+	c.keyConflicts = c.keymap.Conflicts()
+	if p == nil {
+		return nil
+	}
+
+	layers := make([]string, 0, len(p.Keys))
+	for layer := range p.Keys {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		if layer != keymap.LayerGlobal && layer != keymap.LayerCtrlK && layer != keymap.LayerCtrlW {
+			problems = append(problems, fmt.Sprintf("keys.%s: unknown key layer", layer))
+			continue
+		}
+		keys := make([]string, 0, len(p.Keys[layer]))
+		for k := range p.Keys[layer] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := p.Keys[layer][k]
+			// 空のコマンド名は既定の割り当てを外す指定
+			if name == "" {
+				c.keymap.Unbind(layer, k)
+				continue
+			}
+			if _, ok := c.commands.Lookup(name); !ok {
+				problems = append(problems, fmt.Sprintf("keys.%s: %s: unknown command %q", layer, k, name))
+				continue
+			}
+			// 取得したばかりのリポジトリの設定でキーからコマンドを実行させないよう、信頼するまでビルドは割り当てない
+			if name == "build" && !c.projectTrusted(p) {
+				continue
+			}
+			c.keymap.Bind(layer, keymap.Binding{Key: k, Command: name, Description: name, Source: projectBindingSource})
+		}
+	}
+	c.keyConflicts = c.keymap.Conflicts()
+	return problems
+}
+
+// reportKeyConflicts はキー割り当ての競合が前回知らせたものから変わっていれば、情報パネルに一覧を表示する
+// 同じプロジェクトのファイルを開くたびに同じ一覧を表示しないよう、変わらなければ何もしない
+func (c *Controller) reportKeyConflicts() {
+	lines := make([]string, len(c.keyConflicts))
+	for i, conflict := range c.keyConflicts {
+		lines[i] = conflict.String()
+	}
+	reported := strings.Join(lines, "\n")
+	if reported == c.reportedConflicts {
+		return
+	}
+	c.reportedConflicts = reported
+	if len(lines) > 0 {
+		c.showKeyConflicts()
+	}
+}
+
+// showKeyConflicts はキー割り当ての競合と、それぞれの割り当ての出どころを情報パネルに表示する
+func (c *Controller) showKeyConflicts() {
+	if len(c.keyConflicts) == 0 {
+		c.setStatusMessage("No key binding conflicts")
+		return
+	}
+	prefer := config.KeyPrecedenceProject
+	if c.keyPrecedence == keymap.PrecedenceFirst {
+		prefer = config.KeyPrecedenceDefault
+	}
+	lines := make([]string, 0, len(c.keyConflicts)+2)
+	for _, conflict := range c.keyConflicts {
+		lines = append(lines, conflict.String())
+	}
+	lines = append(lines, "", fmt.Sprintf("The %s bindings win (KEY_PRECEDENCE=%s or %s)", prefer, config.KeyPrecedenceProject, config.KeyPrecedenceDefault))
+	c.ShowOverlay(fmt.Sprintf("Key binding conflicts (%d)", len(c.keyConflicts)), lines)
+}
+
+// rebuildSavePipeline はユーザー設定とプロジェクト設定から保存前フックを組み立てる
+// 行末の空白を取り除く設定は、整形より前に適用する
+// Go ファイルはプロジェクトで formatter が指定されていればそちらを、なければ FORMAT_ON_SAVE のコマンドを goimports より優先する
+func (c *Controller) rebuildSavePipeline() {
+	formatter := config.FormatterNone
+	if c.goImportsOnSave {
+		formatter = config.FormatterGoImports
+	}
+	commands := make(map[string]string, len(c.formatCommands))
+	for fileType, command := range c.formatCommands {
+		commands[fileType] = command
+	}
+	if c.project != nil && c.project.Formatter != "" {
+		formatter = c.project.Formatter
+		delete(commands, "go")
+	} else if _, ok := commands["go"]; ok {
+		formatter = config.FormatterNone
+	}
+
+	c.savePipeline = save.NewPipeline()
+	// 整形で行末の空白が消える前に取り除き、取り除いた行数を報告する
+	if c.trimOnSave {
+		c.savePipeline.Add(save.NewTrimHook())
+	}
+	switch formatter {
+	case config.FormatterGoImports:
+		c.savePipeline.Add(save.NewGoImportsHook(c.runner))
+	case config.FormatterGofmt:
+		c.savePipeline.Add(save.NewGofmtHook(c.runner))
+	}
+	if len(commands) > 0 {
+		typeOf := func(filename string) string { return screen.FileTypeOf(c.contents, filename) }
+		c.savePipeline.Add(save.NewCommandHook(c.runner, typeOf, commands))
+	}
+}
+
+// buildCommand はプロジェクト設定のビルドコマンドを実行し、出力を情報パネルに表示する
+// 引数でコマンド名を指定でき、省略した場合は default を使う
+func (c *Controller) buildCommand(args []string) error {
+	name := "default"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if c.project == nil {
+		c.setStatusMessage("No %s found", config.ProjectFileName)
+		return nil
+	}
+	line, ok := c.project.Build[name]
+	if !ok {
+		c.setStatusMessage("No build command %q in %s", name, c.project.Path)
+		return nil
+	}
+	if !c.projectTrusted(c.project) {
+		trust, err := c.confirmYesNo(fmt.Sprintf("Trust %s and run its build commands? (y/n)", c.project.Path))
+		if err != nil {
+			return err
+		}
+		if !trust {
+			c.setStatusMessage("Build canceled")
+			return nil
+		}
+		c.trustProject(c.project)
+		// 信頼するまで外していたビルドのキー割り当てを加える
+		c.ApplyProject(c.project)
+	}
+
+	// コマンドラインはシェルを介さず空白で区切って実行する
+	fields := strings.Fields(line)
+	dir := c.project.Root
+	c.setStatusMessage("Running %s...", line)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+		defer cancel()
+
+		out, err := c.runner.Run(ctx, dir, fields[0], fields[1:], nil)
+		c.postResult(func() { c.showBuildResult(line, out, err) })
+	}()
+	return nil
+}
+
+// showBuildResult はビルドコマンド line の出力 out と失敗の理由 err を表示する
+func (c *Controller) showBuildResult(line string, out []byte, err error) {
+	lines := save.SplitOutput(out)
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	if err != nil {
+		c.setStatusMessage("")
+		c.ShowOverlay("Build failed: "+line, append([]string{err.Error()}, lines...))
+		return
+	}
+	if len(lines) == 0 {
+		c.setStatusMessage("Build succeeded: %s", line)
+		return
+	}
+	c.setStatusMessage("")
+	c.ShowOverlay("Build succeeded: "+line, lines)
+}
+
+// SetTrustStore はビルドコマンドの実行を許可したプロジェクト設定の記録先を設定する
+func (c *Controller) SetTrustStore(store trustfile.Store) {
+	c.trustStore = store
+}
+
+// projectTrusted はプロジェクト設定を今の内容のまま信頼しているかを返す
+func (c *Controller) projectTrusted(p *config.Project) bool {
+	if p == nil {
+		return false
+	}
+	if digest, ok := c.trustedProjects[p.Path]; ok && digest == p.Digest {
+		return true
+	}
+	if c.trustStore == nil {
+		return false
+	}
+	trusted, err := c.trustStore.Trusted(p.Path, p.Digest)
+	return err == nil && trusted
+}
+
+// trustProject はプロジェクト設定を今の内容で信頼したと記録する
+// 記録先に書き込めなくても、エディタを終了するまでは信頼したものとして扱う
+func (c *Controller) trustProject(p *config.Project) {
+	if c.trustedProjects == nil {
+		c.trustedProjects = make(map[string]string)
+	}
+	c.trustedProjects[p.Path] = p.Digest
+	if c.trustStore == nil {
+		return
+	}
+	if err := c.trustStore.Trust(p.Path, p.Digest); err != nil {
+		c.setErrorMessage("Cannot remember trusted project: %v", err)
+	}
+}
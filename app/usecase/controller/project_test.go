@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+	"github.com/wasya-io/go-kilo/app/boundary/grep"
+	"github.com/wasya-io/go-kilo/app/boundary/trustfile"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/keymap"
+)
+
+// formatRunner は整形ツールの呼び出しを記録する Runner
+type formatRunner struct {
+	names []string
+}
+
+func (r *formatRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.names = append(r.names, name)
+	return stdin, nil
+}
+
+func (r *formatRunner) Available(name string) bool { return true }
+
+// trustAll はどのプロジェクト設定も信頼済みとして扱う trustfile.Store
+type trustAll struct{}
+
+func (trustAll) Trust(path, digest string) error           { return nil }
+func (trustAll) Trusted(path, digest string) (bool, error) { return true, nil }
+
+// buildRunner はビルドコマンドの実行を記録し、完了を知らせる Runner
+type buildRunner struct {
+	ran chan string
+	out []byte
+}
+
+func (r *buildRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	r.ran <- name
+	return r.out, nil
+}
+
+func (r *buildRunner) Available(name string) bool { return true }
+
+func TestApplyProject(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	runner := &formatRunner{}
+	controller.runner = runner
+	controller.SetTrustStore(trustAll{})
+	controller.ApplyConfig(&config.Config{GoImportsOnSave: true, StatusMessageDuration: 5})
+
+	problems := controller.ApplyProject(&config.Project{
+		Root:      "/project",
+		Formatter: config.FormatterGofmt,
+		Keys: map[string]map[string]string{
+			keymap.LayerGlobal: {"C-d": "", "M-g": "goto-definition", "C-g": "no-such-command"},
+			keymap.LayerCtrlK:  {"B": "build"},
+			"C-q":              {"a": "save"},
+		},
+	})
+	assert.Len(t, problems, 2)
+
+	_, ok := controller.keymap.Lookup(keymap.LayerGlobal, "C-d")
+	assert.False(t, ok, "empty command must unbind the default key")
+	b, ok := controller.keymap.Lookup(keymap.LayerGlobal, "M-g")
+	assert.True(t, ok)
+	assert.Equal(t, "goto-definition", b.Command)
+	b, ok = controller.keymap.Lookup(keymap.LayerCtrlK, "B")
+	assert.True(t, ok)
+	assert.Equal(t, "build", b.Command)
+	assert.Equal(t, "/project", controller.projectRoot())
+
+	controller.savePipeline.Run("main.go", []string{"package main"})
+	assert.Equal(t, []string{"gofmt"}, runner.names)
+
+	// プロジェクト設定を外すとユーザー設定と既定の割り当てに戻る
+	assert.Empty(t, controller.ApplyProject(nil))
+	_, ok = controller.keymap.Lookup(keymap.LayerGlobal, "C-d")
+	assert.True(t, ok)
+	_, ok = controller.keymap.Lookup(keymap.LayerGlobal, "M-g")
+	assert.False(t, ok)
+	runner.names = nil
+	controller.savePipeline.Run("main.go", []string{"package main"})
+	assert.Equal(t, []string{"goimports"}, runner.names)
+}
+
+func TestApplyConfig_FormatOnSave(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	runner := &formatRunner{}
+	controller.runner = runner
+	controller.ApplyConfig(&config.Config{GoImportsOnSave: true, FormatOnSave: "go=gofumpt;python=black -q -", StatusMessageDuration: 5})
+
+	// Go ファイルは goimports の代わりに設定したコマンドで整形する
+	controller.savePipeline.Run("main.go", []string{"package main"})
+	controller.savePipeline.Run("a.py", []string{"x = 1"})
+	controller.savePipeline.Run("notes.txt", []string{"x"})
+	assert.Equal(t, []string{"gofumpt", "black"}, runner.names)
+
+	// プロジェクトで formatter を指定すると、Go ファイルはそちらを優先する
+	runner.names = nil
+	controller.ApplyProject(&config.Project{Root: "/project", Formatter: config.FormatterGofmt})
+	controller.savePipeline.Run("main.go", []string{"package main"})
+	controller.savePipeline.Run("a.py", []string{"x = 1"})
+	assert.Equal(t, []string{"gofmt", "black"}, runner.names)
+}
+
+func TestOpenFile_LoadsProjectSettings(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "cmd")
+	assert.NoError(t, os.MkdirAll(sub, 0755))
+	settings := "formatter = \"none\"\n\n[build]\ndefault = \"go build ./...\"\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(root, config.ProjectFileName), []byte(settings), 0644))
+
+	filename := filepath.Join(sub, "main.go")
+	fm.EXPECT().OpenFile(filename).Return(nil)
+	assert.NoError(t, controller.OpenFile(filename))
+
+	if assert.NotNil(t, controller.project) {
+		assert.Equal(t, root, controller.project.Root)
+		assert.Equal(t, "go build ./...", controller.project.Build["default"])
+	}
+	assert.Equal(t, 0, controller.savePipeline.Len())
+	assert.Equal(t, root, controller.projectRoot())
+}
+
+func TestBuildCommand_WithoutSettings(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	runner := &formatRunner{}
+	controller.runner = runner
+
+	assert.NoError(t, controller.commands.Execute("build", nil))
+	controller.ApplyProject(&config.Project{Path: "/p/.go-kilo.toml", Root: "/p", Build: map[string]string{"default": "make"}})
+	assert.NoError(t, controller.commands.Execute("build", []string{"test"}))
+	assert.Empty(t, runner.names, "unknown build commands must not run anything")
+}
+
+func TestApplyProject_BuildBindingsNeedTrust(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	project := &config.Project{Path: "/p/.go-kilo.toml", Digest: "abc", Keys: map[string]map[string]string{
+		keymap.LayerGlobal: {"C-s": "build", "M-b": "build"},
+	}}
+
+	// 信頼していないプロジェクトの設定では、保存のキーからビルドコマンドを実行させない
+	assert.Empty(t, controller.ApplyProject(project))
+	b, _ := controller.keymap.Lookup(keymap.LayerGlobal, "C-s")
+	assert.Equal(t, "save", b.Command)
+	_, ok := controller.keymap.Lookup(keymap.LayerGlobal, "M-b")
+	assert.False(t, ok)
+
+	controller.trustProject(project)
+	controller.ApplyProject(project)
+	b, _ = controller.keymap.Lookup(keymap.LayerGlobal, "C-s")
+	assert.Equal(t, "build", b.Command)
+
+	// 信頼した後に設定の内容が変われば、改めて信頼するまで割り当てない
+	changed := *project
+	changed.Digest = "def"
+	controller.ApplyProject(&changed)
+	b, _ = controller.keymap.Lookup(keymap.LayerGlobal, "C-s")
+	assert.Equal(t, "save", b.Command)
+}
+
+func TestBuildCommand_AsksForTrust(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil, char('n'), char('y'))
+	runner := &buildRunner{ran: make(chan string, 1)}
+	controller.runner = runner
+	store := trustfile.NewFileStore(filepath.Join(t.TempDir(), "trusted.json"))
+	controller.SetTrustStore(store)
+	project := &config.Project{Path: "/p/.go-kilo.toml", Root: "/p", Digest: "abc",
+		Build: map[string]string{"default": "make"},
+		Keys:  map[string]map[string]string{keymap.LayerGlobal: {"M-b": "build"}}}
+	controller.ApplyProject(project)
+
+	// 断ると何も実行しない
+	assert.NoError(t, controller.commands.Execute("build", nil))
+	assert.Empty(t, runner.ran)
+
+	// 信頼すると記録してからビルドし、外していたキー割り当てを加える
+	assert.NoError(t, controller.commands.Execute("build", nil))
+	assert.Equal(t, "make", <-runner.ran)
+	trusted, err := store.Trusted(project.Path, project.Digest)
+	assert.NoError(t, err)
+	assert.True(t, trusted)
+	b, _ := controller.keymap.Lookup(keymap.LayerGlobal, "M-b")
+	assert.Equal(t, "build", b.Command)
+
+	// 信頼した後は尋ねずに実行する
+	assert.NoError(t, controller.commands.Execute("build", nil))
+	assert.Equal(t, "make", <-runner.ran)
+}
+
+func TestBuildCommand_ShowsResultOnMainLoop(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	runner := &buildRunner{ran: make(chan string, 1), out: []byte("ok\n")}
+	controller.runner = runner
+	controller.SetTrustStore(trustAll{})
+	controller.ApplyProject(&config.Project{Root: t.TempDir(), Build: map[string]string{"default": "make"}})
+
+	assert.NoError(t, controller.commands.Execute("build", nil))
+	<-runner.ran
+	assert.Eventually(t, func() bool {
+		b := &controller.background
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		return len(b.results) == 1
+	}, time.Second, time.Millisecond)
+	assert.False(t, controller.screen.HasOverlay(), "the build goroutine leaves the screen to the main loop")
+
+	controller.background.setIdle(true)
+	controller.runBackgroundTasks()
+	assert.True(t, controller.screen.HasOverlay())
+}
+
+func TestApplyConfig_GrepBackend(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	controller.runner = &formatRunner{}
+	controller.ApplyConfig(&config.Config{GrepBackend: grep.BackendAuto, StatusMessageDuration: 5})
+	assert.IsType(t, &grep.Ripgrep{}, controller.projectSearcher, "ripgrep is used when it is available")
+
+	controller.ApplyConfig(&config.Config{GrepBackend: grep.BackendBuiltin, StatusMessageDuration: 5})
+	assert.IsType(t, &grep.Walker{}, controller.projectSearcher)
+}
+
+func TestOpenFile_ReportsKeyConflicts(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	controller.SetTrustStore(trustAll{})
+
+	root := t.TempDir()
+	settings := "[keys]\n\"C-s\" = \"build\"\n\"C-w\" = \"save\"\n\n[keys.C-k]\ns = \"save\"\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(root, config.ProjectFileName), []byte(settings), 0644))
+	filename := filepath.Join(root, "main.go")
+	fm.EXPECT().OpenFile(filename).Return(nil).Times(2)
+
+	// 既定の割り当てを隠す割り当てと、プレフィックスキーに隠される割り当てを知らせる
+	assert.NoError(t, controller.OpenFile(filename))
+	assert.True(t, controller.screen.HasOverlay())
+	if assert.Len(t, controller.keyConflicts, 2) {
+		assert.Equal(t, "C-s: save (default) shadowed by build (project)", controller.keyConflicts[0].String())
+		assert.Equal(t, "C-w: save (project) shadowed by the C-w prefix", controller.keyConflicts[1].String())
+	}
+
+	// 同じ競合は開き直しても繰り返し表示しない
+	controller.dismissOverlay()
+	assert.NoError(t, controller.OpenFile(filename))
+	assert.False(t, controller.screen.HasOverlay())
+	assert.NoError(t, controller.commands.Execute("show-key-conflicts", nil))
+	assert.True(t, controller.screen.HasOverlay())
+}
+
+func TestApplyProject_DefaultBindingsWin(t *testing.T) {
+	controller, _ := newKeyInputController(t, nil)
+	controller.ApplyConfig(&config.Config{KeyPrecedence: config.KeyPrecedenceDefault, StatusMessageDuration: 5})
+	controller.SetTrustStore(trustAll{})
+
+	controller.ApplyProject(&config.Project{Keys: map[string]map[string]string{
+		keymap.LayerGlobal: {"C-s": "build", "M-b": "build"},
+	}})
+	b, _ := controller.keymap.Lookup(keymap.LayerGlobal, "C-s")
+	assert.Equal(t, "save", b.Command)
+	b, _ = controller.keymap.Lookup(keymap.LayerGlobal, "M-b")
+	assert.Equal(t, "build", b.Command, "keys without a default binding are still bound")
+	assert.Len(t, controller.keyConflicts, 1)
+}
+
+func TestSave_TrimsTrailingWhitespace(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"a  ", "b", "c\t"}, ctrlKey(key.KeyCtrlZ))
+	controller.ApplyConfig(&config.Config{TrimTrailingWhitespace: true, StatusMessageDuration: 5})
+	controller.screen.SetCursorPosition(3, 0)
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	fm.EXPECT().SaveFile("test.txt", []string{"a", "b", "c"}).Return(nil)
+
+	assert.NoError(t, controller.commands.Execute("save", nil))
+	assert.Equal(t, []string{"a", "b", "c"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X, "the cursor must stay inside the trimmed line")
+
+	// 取り除いた空白は取り消しで戻せる
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"a  ", "b", "c\t"}, c.GetAllLines())
+}
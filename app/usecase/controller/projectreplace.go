@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/grep"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/projectreplace"
+)
+
+// projectSearchTimeout はプロジェクト検索の最大実行時間
+const projectSearchTimeout = 30 * time.Second
+
+const projectReplaceFooter = "Up/Down: move  Space: toggle  Enter: apply  Esc: cancel"
+
+// SetProjectSearcher はプロジェクト検索に使用する Searcher を差し替える
+func (c *Controller) SetProjectSearcher(searcher grep.Searcher) {
+	c.projectSearcher = searcher
+}
+
+// SetProjectFileSystem は置換の適用に使用するファイル操作を差し替える
+func (c *Controller) SetProjectFileSystem(fsys projectreplace.FileSystem) {
+	c.projectFS = fsys
+}
+
+// projectRoot はプロジェクト検索の起点ディレクトリを返す
+// .go-kilo.toml があればその場所をプロジェクトのルートとする
+func (c *Controller) projectRoot() string {
+	if c.project != nil {
+		return c.project.Root
+	}
+	if filename := c.fileManager.GetFilename(); filename != "" {
+		return filepath.Dir(filename)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return wd
+	}
+	return "."
+}
+
+// ProjectReplace は複数ファイルにまたがる置換を対話的に行う
+// 一致箇所をファイルごとにプレビューし、選択したものだけを置換する
+func (c *Controller) ProjectReplace() error {
+	// 開いているファイルも書き換える可能性があるので、読み込みの途中で内容が変わらないようにする
+	c.waitForLoad()
+	pattern, err := c.prompt("Project replace: ")
+	if err != nil || pattern == "" {
+		return err
+	}
+	replacement, ok, err := c.promptInput(fmt.Sprintf("Replace %q with: ", pattern), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.setStatusMessage("Replace aborted")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), projectSearchTimeout)
+	defer cancel()
+	c.setStatusMessage("Searching...")
+	matches, err := c.projectSearcher.Search(ctx, c.projectRoot(), pattern)
+	if err != nil {
+		c.setErrorMessage("Search failed: %v", err)
+		return nil
+	}
+	if len(matches) == 0 {
+		c.setStatusMessage("No matches for %q", pattern)
+		return nil
+	}
+
+	plan := projectreplace.NewPlan(pattern, replacement, matches)
+	apply, err := c.selectReplacements(plan)
+	if err != nil {
+		return err
+	}
+	c.dismissOverlay()
+	if !apply {
+		c.setStatusMessage("Replace aborted")
+		return nil
+	}
+	c.applyProjectReplace(plan)
+	return nil
+}
+
+// selectReplacements はプレビューを表示し、置換する一致箇所をユーザーに選ばせる
+// Enter で適用する場合は true、キャンセルした場合は false を返す
+func (c *Controller) selectReplacements(plan *projectreplace.Plan) (bool, error) {
+	current := 0
+	for {
+		lines, rows := plan.Preview()
+		selected := 0
+		for i, item := range rows {
+			if item == current {
+				selected = i
+				break
+			}
+		}
+		title := fmt.Sprintf("Replace %q -> %q (%d/%d selected)", plan.Pattern, plan.Replacement, plan.SelectedCount(), plan.Len())
+		c.screen.SetListOverlay(title, lines, selected, projectReplaceFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return false, err
+		}
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Rune == ' ' {
+				plan.Toggle(current)
+			}
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if current > 0 {
+					current--
+				}
+			case key.KeyArrowDown:
+				if current < plan.Len()-1 {
+					current++
+				}
+			case key.KeyEnter:
+				return true, nil
+			case key.KeyEsc:
+				return false, nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX {
+				return false, nil
+			}
+		}
+	}
+}
+
+// applyProjectReplace は選択された置換を適用し、結果を報告する
+// 書き換えたファイルを表示しているバッファは読み直し、適用できなかったファイルは手作業で直せるように新しいバッファに開く
+func (c *Controller) applyProjectReplace(plan *projectreplace.Plan) {
+	// 未保存の変更があるバッファのファイルは書き換えない。書き換えると、後で保存した際に置換が失われる
+	var skipped []projectreplace.Failure
+	for _, filename := range c.dirtyFiles() {
+		found := false
+		for i := 0; i < plan.Len(); i++ {
+			if item := plan.Item(i); item.Selected && samePath(item.File, filename) {
+				plan.Toggle(i)
+				found = true
+			}
+		}
+		if found {
+			skipped = append(skipped, projectreplace.Failure{File: filename, Err: fmt.Errorf("buffer has unsaved changes")})
+		}
+	}
+
+	changed, replaced, failures := plan.Apply(c.projectFS)
+	failures = append(skipped, failures...)
+	c.eventBus.PublishAndWaitResponse(event.NewReloadFilesEvent(replacedFiles(plan, failures)))
+
+	if len(failures) == 0 {
+		c.setStatusMessage("Replaced %d occurrences in %d files", replaced, changed)
+		return
+	}
+
+	lines := make([]string, 0, len(failures))
+	for _, f := range failures {
+		lines = append(lines, fmt.Sprintf("%s: %v", f.File, f.Err))
+	}
+	c.ShowOverlay(fmt.Sprintf("Replaced %d occurrences in %d files, %d files failed", replaced, changed, len(failures)), lines)
+	if err := c.openFileCommand([]string{failures[0].File}); err != nil {
+		c.setErrorMessage("Failed to open %s: %v", failures[0].File, err)
+	}
+}
+
+// dirtyFiles は未保存の変更がある、名前のあるバッファのファイル名を返す
+func (c *Controller) dirtyFiles() []string {
+	var files []string
+	focused := c.windows.Focused().Buffer
+	for _, b := range c.windows.Buffers() {
+		buffer, fm := b.Contents, b.FileManager
+		if b == focused {
+			buffer, fm = c.contents, c.fileManager
+		}
+		if filename := fm.GetFilename(); filename != "" && buffer.IsDirty() {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
+
+// performReloadFiles は files のいずれかを表示しているバッファのうち、未保存の変更がないものを読み直す
+// 読み直しはバッファごとに1つの編集として記録するため、取り消せば置換する前の内容に戻せる
+func (c *Controller) performReloadFiles(files []string) {
+	focused := c.windows.Focused().Buffer
+	for _, b := range c.windows.Buffers() {
+		if b == focused {
+			filename := c.fileManager.GetFilename()
+			if containsPath(files, filename) && !c.contents.IsDirty() {
+				if err := c.reloadFile(c.fileManager.OpenFile); err != nil {
+					c.setErrorMessage("Cannot reload %s: %v", filename, err)
+				}
+			}
+			continue
+		}
+		filename := b.FileManager.GetFilename()
+		if !containsPath(files, filename) || b.Contents.IsDirty() {
+			continue
+		}
+		old := b.Contents.GetAllLines()
+		if err := b.FileManager.OpenFile(filename); err != nil {
+			c.setErrorMessage("Cannot reload %s: %v", filename, err)
+			continue
+		}
+		b.History.Record(&command.TextEdit{Buffer: b.Contents, Start: contents.Position{}, Removed: old, Inserted: b.Contents.GetAllLines()})
+		b.History.MarkSaved()
+		b.Contents.SetDirty(false)
+	}
+}
+
+// replacedFiles は置換によって書き換えられたファイルを返す
+func replacedFiles(plan *projectreplace.Plan, failures []projectreplace.Failure) []string {
+	var files []string
+	for i := 0; i < plan.Len(); i++ {
+		item := plan.Item(i)
+		if !item.Selected || containsPath(files, item.File) {
+			continue
+		}
+		failed := false
+		for _, f := range failures {
+			failed = failed || samePath(f.File, item.File)
+		}
+		if !failed {
+			files = append(files, item.File)
+		}
+	}
+	return files
+}
+
+// containsPath は files に filename と同じパスが含まれるかどうかを返す
+func containsPath(files []string, filename string) bool {
+	for _, f := range files {
+		if samePath(f, filename) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/grep"
+	"github.com/wasya-io/go-kilo/app/usecase/projectreplace"
+)
+
+func TestApplyProjectReplace_ReloadsOpenBuffers(t *testing.T) {
+	dir := t.TempDir()
+	var matches []grep.Match
+	files := map[string]string{}
+	for _, name := range []string{"background.txt", "dirty.txt", "focused.txt", "closed.txt"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte("foo\n"), 0644))
+		files[name] = path
+		matches = append(matches, grep.Match{File: path, Line: 0, Col: 0, Length: 3, Text: "foo"})
+	}
+	controller, _ := newKeyInputController(t, []string{"one"})
+	assert.NoError(t, controller.openFileCommand([]string{files["background.txt"]}))
+	assert.NoError(t, controller.openFileCommand([]string{files["dirty.txt"]}))
+	assert.NoError(t, controller.contents.InsertChar(contents.Position{X: 0, Y: 0}, 'x'))
+	assert.NoError(t, controller.openFileCommand([]string{files["focused.txt"]}))
+
+	controller.applyProjectReplace(projectreplace.NewPlan("foo", "bar", matches))
+
+	background := controller.windows.Buffers()[controller.openBufferIndex(files["background.txt"])]
+	assert.Equal(t, "bar", background.Contents.GetContentLine(0), "a buffer in another tab is reloaded")
+	assert.False(t, background.Contents.IsDirty())
+	data, err := os.ReadFile(files["dirty.txt"])
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\n", string(data), "a file with unsaved changes in any buffer is not rewritten")
+	data, err = os.ReadFile(files["closed.txt"])
+	assert.NoError(t, err)
+	assert.Equal(t, "bar\n", string(data))
+
+	// 適用できなかったファイルは、既に開いているバッファに切り替えて表示する
+	assert.True(t, controller.screen.HasOverlay())
+	assert.Equal(t, files["dirty.txt"], controller.fileManager.GetFilename())
+	assert.Equal(t, "xfoo", controller.contents.GetContentLine(0))
+	focused := controller.windows.Buffers()[controller.openBufferIndex(files["focused.txt"])]
+	assert.Equal(t, "bar", focused.Contents.GetContentLine(0), "the focused buffer is reloaded")
+	assert.Len(t, controller.windows.Buffers(), 4)
+}
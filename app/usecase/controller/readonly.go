@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// SetForceReadOnly は以降に開くファイルをすべて編集できない状態で開くかどうかを設定する
+func (c *Controller) SetForceReadOnly(readOnly bool) {
+	c.forceReadOnly = readOnly
+}
+
+// applyReadOnly は開いたファイルのバッファを、起動時の指定か書き込み権限がなければ編集できない状態にする
+func (c *Controller) applyReadOnly(filename string) {
+	if c.forceReadOnly {
+		c.contents.SetReadOnly(true)
+		return
+	}
+	writable := filemanager.Writable(filename)
+	c.contents.SetReadOnly(!writable)
+	if !writable {
+		c.setStatusMessage("No write permission: opened read-only (C-k l to unlock)")
+	}
+}
+
+// toggleReadOnly はバッファ全体の編集の禁止を切り替える
+// ファイルの書き込み権限とは関係なく、参照用のファイルを誤って変更しないために使う
+func (c *Controller) toggleReadOnly() {
+	readOnly := !c.contents.ReadOnly()
+	c.contents.SetReadOnly(readOnly)
+	if readOnly {
+		c.setStatusMessage("Buffer locked: edits are disabled until C-k l")
+	} else {
+		c.setStatusMessage("Buffer unlocked")
+	}
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestToggleReadOnly(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc"},
+		ctrlKey(key.KeyCtrlK), char('l'),
+		char('x'),
+		ctrlKey(key.KeyCtrlK), char('l'),
+		char('y'),
+	)
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, c.ReadOnly())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "abc", c.GetContentLine(0), "a locked buffer must not be edited")
+	assert.False(t, c.IsDirty())
+
+	assert.NoError(t, controller.Process())
+	assert.False(t, c.ReadOnly())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "yabc", c.GetContentLine(0))
+}
+
+func TestOpenFile_ForceReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("other\n"), 0644))
+
+	controller, _ := newKeyInputController(t, []string{"abc"}, char('x'))
+	controller.SetForceReadOnly(true)
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	assert.True(t, controller.contents.ReadOnly(), "--readonly must lock every opened file")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "other", controller.contents.GetContentLine(0))
+}
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/recoveryfile"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/recovery"
+)
+
+const recoveryFooter = "Up/Down: move  Enter: restore  d: delete  Esc: close"
+
+// recoveryBuffer はコントローラーのバッファを recovery.Snapshotter / recovery.Restorer として扱う
+type recoveryBuffer struct {
+	c *Controller
+}
+
+// Snapshot は現在のバッファの状態を返す
+func (b recoveryBuffer) Snapshot() recovery.Snapshot {
+	snap := b.c.contents.Snapshot()
+	return recovery.Snapshot{
+		Original: b.c.fileManager.GetFilename(),
+		Lines:    snap.Lines(),
+		// 読み込みの途中の内容を復元すると元のファイルを切り詰めてしまうため、読み込み中は書き出さない
+		Dirty: b.c.contents.IsDirty() && !b.c.isLoading(),
+	}
+}
+
+// Restore はスナップショットの内容を元ファイルのバッファとして開く
+// 復元した内容は未保存の変更として扱い、保存するまで元ファイルは変更しない
+func (b recoveryBuffer) Restore(snap recovery.Snapshot) error {
+	if snap.Original != "" {
+		// 元ファイルが消えている場合も復元は続ける
+		if err := b.c.openFile(snap.Original); err != nil {
+			b.c.logger.Log("error", fmt.Sprintf("Failed to open original file: %v", err))
+		}
+	}
+	b.c.replaceContents(snap.Lines)
+	return nil
+}
+
+// SetRecoveryStore は復元用ファイルの保存先を差し替える
+func (c *Controller) SetRecoveryStore(store recoveryfile.Store) {
+	c.recoveryStore = store
+	c.recovery = recovery.NewManager(recoveryBuffer{c}, store)
+}
+
+// WriteRecovery は未保存の変更を復元用ファイルに書き出し、書き出したパスを返す
+// 表示中のバッファに加えて、タブに残っている未保存のバッファも書き出す。変更がない場合は何もせず空を返す
+// 異常終了時や、端末との接続が切れた場合に呼び出されることを想定している
+func (c *Controller) WriteRecovery() ([]string, error) {
+	now := time.Now()
+	var paths []string
+	var errs []error
+	if path, err := c.recovery.Flush(now); err != nil {
+		errs = append(errs, err)
+	} else if path != "" {
+		paths = append(paths, path)
+	}
+	focused := c.windows.Focused().Buffer
+	for _, b := range c.windows.Buffers() {
+		if b == focused || !b.Contents.IsDirty() {
+			continue
+		}
+		path, err := c.recoveryStore.Save(b.FileManager.GetFilename(), b.Contents.GetAllLines(), now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, errors.Join(errs...)
+}
+
+// SetRecoveryPolicy は未保存の変更を自動で書き出す時機を設定する
+func (c *Controller) SetRecoveryPolicy(policy recovery.Policy) {
+	c.checkpointTrigger = recovery.NewTrigger(policy, c.contents.Version())
+}
+
+// RequestCheckpoint は未保存の変更の書き出しを要求する。置換などの取り消しにくい操作の前に使う
+// 編集処理と競合しないよう、書き出しはイベントバス上で行う
+func (c *Controller) RequestCheckpoint(now time.Time) {
+	c.eventBus.Publish(event.NewCheckpointEvent(now))
+}
+
+// CheckIdleCheckpoint は一連の編集が区切れていれば未保存の変更を書き出す
+// エディタの定期処理から呼び出されます。
+func (c *Controller) CheckIdleCheckpoint(now time.Time) {
+	c.eventBus.Publish(event.NewIdleCheckpointEvent(now))
+}
+
+// createCheckpointHandler は書き出しイベントのハンドラーを作成する
+func (c *Controller) createCheckpointHandler() event.Handler {
+	return event.NewSingleTypeHandler(event.TypeCheckpoint, func(e event.Event) (bool, error) {
+		checkpoint, ok := e.Payload.(event.CheckpointEvent)
+		if !ok {
+			return false, nil
+		}
+		if checkpoint.Idle {
+			c.trimIdleCaches(checkpoint.Time)
+			c.checkDiskChange(checkpoint.Time)
+			c.checkpointTrigger.Observe(c.contents.Version(), checkpoint.Time)
+			if !c.checkpointTrigger.Due(checkpoint.Time) {
+				return true, nil
+			}
+		}
+		c.checkpoint(checkpoint.Time)
+		return true, nil
+	})
+}
+
+// checkpoint は未保存の変更を復元用ファイルに書き出す
+// イベントバスのハンドラーの中でのみ呼び出す
+func (c *Controller) checkpoint(now time.Time) {
+	path, err := c.recovery.Checkpoint(now)
+	if err != nil {
+		c.logger.Log("error", fmt.Sprintf("Checkpoint failed: %v", err))
+		return
+	}
+	c.checkpointTrigger.Written(now)
+	if path != "" {
+		c.logger.Log("recovery", fmt.Sprintf("Checkpoint written: %s", path))
+	}
+}
+
+// discardRecovery は filename に対応する復元用ファイルを削除する
+// 保存した内容が一連の編集の区切りになるため、それまでの編集は書き出し済みとして扱う
+func (c *Controller) discardRecovery(filename string) {
+	c.checkpointTrigger.Reset(c.contents.Version())
+	if err := c.recovery.Discard(filename); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to remove recovery file: %v", err))
+	}
+}
+
+// detectRecovery は filename の復元用ファイルがあれば、復元するかを尋ねるよう記録する
+// 内容がファイルと同じ場合は尋ねずに削除する
+func (c *Controller) detectRecovery(filename string) {
+	c.pendingRecovery = ""
+	if filename == "" || c.recoveryStore == nil {
+		return
+	}
+	path := c.recoveryStore.PathFor(filename)
+	entry, err := c.recoveryStore.Load(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Log("error", fmt.Sprintf("Failed to read recovery file: %v", err))
+		}
+		return
+	}
+	c.waitForLoad()
+	if slices.Equal(entry.Lines, c.contents.GetAllLines()) {
+		if err := c.recoveryStore.Remove(path); err != nil {
+			c.logger.Log("error", fmt.Sprintf("Failed to remove recovery file: %v", err))
+		}
+		return
+	}
+	c.pendingRecovery = path
+}
+
+// promptRecovery は開いたファイルの復元用ファイルの内容を読み込むかを尋ねる
+// y で復元し、n で復元用ファイルを削除する。Esc の場合は残しておき、後から一覧（Ctrl-K R）で復元できる
+func (c *Controller) promptRecovery() error {
+	path := c.pendingRecovery
+	c.pendingRecovery = ""
+	entry, err := c.recoveryStore.Load(path)
+	if err != nil {
+		c.setErrorMessage("Failed to read recovery file: %v", err)
+		return nil
+	}
+
+	c.setStatusMessage("Recover unsaved changes from %s? (y/n)", entry.SavedAt.Local().Format("2006-01-02 15:04"))
+	c.eventBus.Publish(event.NewRefreshEvent())
+	for {
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'y' || ev.Rune == 'Y'):
+			c.restoreRecovery(entry)
+			return nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'n' || ev.Rune == 'N'):
+			if err := c.recoveryStore.Remove(path); err != nil {
+				c.setErrorMessage("Failed to delete recovery file: %v", err)
+				return nil
+			}
+			c.setStatusMessage("Discarded unsaved changes")
+			return nil
+		case ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			c.setStatusMessage("Recovery file kept (C-k R to restore later)")
+			return nil
+		}
+	}
+}
+
+// BrowseRecovery は保存されている復元用ファイルを一覧表示し、選択したものをバッファに復元する
+func (c *Controller) BrowseRecovery() error {
+	entries, err := c.recoveryStore.List()
+	if err != nil {
+		c.setErrorMessage("Failed to list recovery files: %v", err)
+		return nil
+	}
+	if len(entries) == 0 {
+		c.setStatusMessage("No recovery files")
+		return nil
+	}
+
+	selected := 0
+	for {
+		lines := make([]string, len(entries))
+		for i, e := range entries {
+			lines[i] = fmt.Sprintf("%s  %s", e.SavedAt.Local().Format("2006-01-02 15:04:05"), e.Name())
+		}
+		c.screen.SetListOverlay(fmt.Sprintf("Recovery files (%d)", len(entries)), lines, selected, recoveryFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Rune != 'd' {
+				continue
+			}
+			if err := c.recoveryStore.Remove(entries[selected].Path); err != nil {
+				c.setErrorMessage("Failed to delete: %v", err)
+				continue
+			}
+			entries = append(entries[:selected], entries[selected+1:]...)
+			if len(entries) == 0 {
+				c.dismissOverlay()
+				c.setStatusMessage("No recovery files")
+				return nil
+			}
+			if selected >= len(entries) {
+				selected = len(entries) - 1
+			}
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyArrowUp:
+				if selected > 0 {
+					selected--
+				}
+			case key.KeyArrowDown:
+				if selected < len(entries)-1 {
+					selected++
+				}
+			case key.KeyEnter:
+				c.dismissOverlay()
+				c.restoreRecovery(entries[selected])
+				return nil
+			case key.KeyEsc:
+				c.dismissOverlay()
+				return nil
+			}
+		case key.KeyEventControl:
+			if ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX {
+				c.dismissOverlay()
+				return nil
+			}
+		}
+	}
+}
+
+// restoreRecovery は復元用ファイルの内容をバッファに戻す
+func (c *Controller) restoreRecovery(entry recoveryfile.Entry) {
+	if c.contents.IsDirty() {
+		c.setStatusMessage("Save changes before restoring %s", entry.Name())
+		return
+	}
+	loaded, err := c.recoveryStore.Load(entry.Path)
+	if err != nil {
+		c.setErrorMessage("Failed to restore: %v", err)
+		return
+	}
+	snap := recovery.Snapshot{Original: loaded.Original, Lines: loaded.Lines, Dirty: true}
+	if err := c.recovery.Restore(recoveryBuffer{c}, snap, loaded.Path); err != nil {
+		c.setErrorMessage("Failed to restore: %v", err)
+		return
+	}
+	c.eventBus.Publish(event.NewCursorSetEvent(0, 0))
+	c.setStatusMessage("Restored %s from %s", loaded.Name(), loaded.SavedAt.Local().Format("2006-01-02 15:04"))
+}
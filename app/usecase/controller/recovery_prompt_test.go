@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/recoveryfile"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestRecoveryPrompt(t *testing.T) {
+	store := recoveryfile.NewDirStore(filepath.Join(t.TempDir(), "recovery"))
+	path, err := store.Save("test.txt", []string{"unsaved"}, time.Now())
+	assert.NoError(t, err)
+
+	// Esc では復元用ファイルを残し、n で削除する
+	controller, c := newKeyInputController(t, []string{"saved"}, special(key.KeyEsc), char('x'), char('n'))
+	controller.SetRecoveryStore(store)
+	controller.detectRecovery("test.txt")
+	assert.NoError(t, controller.Process())
+	entries, _ := store.List()
+	assert.Len(t, entries, 1)
+
+	controller.detectRecovery("test.txt")
+	assert.NoError(t, controller.Process())
+	entries, _ = store.List()
+	assert.Empty(t, entries)
+	assert.Equal(t, []string{"saved"}, c.GetAllLines(), "keys while asking must not edit the buffer")
+
+	// ファイルと同じ内容なら尋ねずに削除する
+	_, err = store.Save("test.txt", []string{"saved"}, time.Now())
+	assert.NoError(t, err)
+	controller.detectRecovery("test.txt")
+	assert.Empty(t, controller.pendingRecovery)
+	_, err = store.Load(path)
+	assert.Error(t, err)
+}
+
+func TestWriteRecovery_IncludesBuffersInTabs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("other\n"), 0644))
+	store := recoveryfile.NewDirStore(filepath.Join(t.TempDir(), "recovery"))
+
+	controller, _ := newKeyInputController(t, []string{"one"}, char('x'))
+	controller.SetRecoveryStore(store)
+	assert.NoError(t, controller.Process())
+	// 未保存のバッファはタブに残したまま別のファイルを開く
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	assert.Len(t, controller.windows.Buffers(), 2)
+
+	paths, err := controller.WriteRecovery()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{store.PathFor("test.txt")}, paths, "only the buffer with unsaved changes is written")
+	entry, err := store.Load(paths[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"xone"}, entry.Lines)
+}
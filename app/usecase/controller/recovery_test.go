@@ -0,0 +1,90 @@
+package controller_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/recoveryfile"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/usecase/recovery"
+)
+
+func TestWriteRecoveryAndDiscardOnSave(t *testing.T) {
+	ctrl, mockFM, mockWriter, eventBus := setupController(t)
+	defer eventBus.Shutdown()
+	eventBus.SetSynchronous(true)
+
+	store := recoveryfile.NewDirStore(filepath.Join(t.TempDir(), "recovery"))
+	ctrl.SetRecoveryStore(store)
+
+	mockFM.EXPECT().GetFilename().Return("test.txt").AnyTimes()
+	mockFM.EXPECT().SaveFile("test.txt", gomock.Any()).Return(nil)
+	mockWriter.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+
+	// 変更がなければ書き出さない
+	paths, err := ctrl.WriteRecovery()
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+
+	ctrl.GetContents().SetDirty(true)
+	paths, err = ctrl.WriteRecovery()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{store.PathFor("test.txt")}, paths)
+
+	entries, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// 保存に成功したら復元用ファイルは削除される
+	eventBus.Publish(event.NewSaveEvent("test.txt", false))
+	entries, err = store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRequestCheckpoint(t *testing.T) {
+	ctrl, mockFM, _, eventBus := setupController(t)
+	defer eventBus.Shutdown()
+	eventBus.SetSynchronous(true)
+
+	store := recoveryfile.NewDirStore(filepath.Join(t.TempDir(), "recovery"))
+	ctrl.SetRecoveryStore(store)
+	mockFM.EXPECT().GetFilename().Return("test.txt").AnyTimes()
+
+	ctrl.GetContents().SetDirty(true)
+	ctrl.RequestCheckpoint(time.Now())
+
+	entries, err := store.List()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "test.txt", entries[0].Original)
+	}
+}
+
+func TestCheckIdleCheckpoint(t *testing.T) {
+	ctrl, mockFM, _, eventBus := setupController(t)
+	defer eventBus.Shutdown()
+	eventBus.SetSynchronous(true)
+
+	store := recoveryfile.NewDirStore(filepath.Join(t.TempDir(), "recovery"))
+	ctrl.SetRecoveryStore(store)
+	ctrl.SetRecoveryPolicy(recovery.Policy{Idle: 3 * time.Second})
+	mockFM.EXPECT().GetFilename().Return("test.txt").AnyTimes()
+
+	now := time.Now()
+	_, err := ctrl.GetContents().InsertText(contents.Position{}, []string{"edit"})
+	assert.NoError(t, err)
+
+	// 編集の直後は書き出さず、編集が止まってから書き出す
+	ctrl.CheckIdleCheckpoint(now)
+	entries, _ := store.List()
+	assert.Empty(t, entries)
+
+	ctrl.CheckIdleCheckpoint(now.Add(3 * time.Second))
+	entries, _ = store.List()
+	assert.Len(t, entries, 1)
+}
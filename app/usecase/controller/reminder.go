@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// CheckUnsavedReminder は未保存状態の継続時間を確認し、必要であれば保存を促すメッセージを表示します。
+// エディタの定期処理から呼び出されます。
+func (c *Controller) CheckUnsavedReminder(now time.Time) {
+	if !c.reminder.Enabled() {
+		return
+	}
+	notify, elapsed := c.reminder.Update(c.contents.IsDirty(), now)
+
+	overdue := c.reminder.Overdue(now)
+	if c.screen.SetDirtyAlert(overdue) && !notify {
+		// 強調表示の切り替えだけを反映する
+		c.eventBus.Publish(event.NewRefreshEvent())
+	}
+	if notify {
+		c.setStatusMessage("Unsaved changes for %d minutes. Press Ctrl-S to save.", int(elapsed.Minutes()))
+	}
+}
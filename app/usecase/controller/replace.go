@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/search"
+)
+
+// Replace はバッファ内の文字列を一致箇所ごとに確認しながら置換する
+// y: 置換して次へ  n: 置換せずに次へ  a: 残りを変更を確認してから全て置換  q/Esc: 終了
+// カーソル位置から末尾まで進んだ後は先頭に戻り、開始位置の手前まで確認する
+func (c *Controller) Replace() error {
+	// ファイルの後半も置換できるよう、全体の読み込みを待つ
+	c.waitForLoad()
+
+	pattern, err := c.prompt("Replace: ")
+	if err != nil || pattern == "" {
+		return err
+	}
+	replacement, ok, err := c.promptInput(fmt.Sprintf("Replace %q with: ", pattern), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.setStatusMessage("Replace aborted")
+		return nil
+	}
+
+	pos := c.screen.GetCursor().ToPosition()
+	replacer := search.NewReplacer(pattern, pos.Y, pos.X)
+	n := len([]rune(replacement))
+	defer c.screen.ClearHighlights()
+
+	count := 0
+	for {
+		m, found := replacer.Next(c.contents.GetAllLines())
+		if !found {
+			break
+		}
+		c.screen.SetHighlights([]screen.Highlight{{Line: m.Line, Col: m.Col, Length: m.Length, Current: true}})
+		c.eventBus.Publish(event.NewCursorSetEvent(m.Line, m.Col))
+		c.setStatusMessage("Replace with %q? (y: yes  n: no  a: all  q: quit)", replacement)
+
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && ev.Rune == 'y':
+			if !c.applyReplacements([]search.Match{m}, replacement) {
+				return nil
+			}
+			replacer.Replaced(m, n)
+			count++
+		case ev.Type == key.KeyEventChar && ev.Rune == 'n':
+			replacer.Skip(m)
+		case ev.Type == key.KeyEventChar && ev.Rune == 'a':
+			rest := replacer.Remaining(c.contents.GetAllLines())
+			// 残りをまとめて置き換える前に変更を確認する。取り消した場合は今の一致箇所の確認に戻る
+			ok, err := c.confirmPreview(c.replacementPreview(pattern, replacement, rest))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			// 残りをまとめて置き換える前の内容を書き出しておく
+			c.RequestCheckpoint(time.Now())
+			if c.applyReplacements(rest, replacement) {
+				count += len(rest)
+			}
+			c.setStatusMessage("Replaced %d occurrence(s)", count)
+			return nil
+		case ev.Type == key.KeyEventChar && ev.Rune == 'q',
+			ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			c.setStatusMessage("Replaced %d occurrence(s)", count)
+			return nil
+		}
+	}
+
+	if count == 0 {
+		c.setStatusMessage("No matches for %q", pattern)
+		return nil
+	}
+	c.setStatusMessage("Replaced %d occurrence(s)", count)
+	return nil
+}
+
+// applyReplacements は一致箇所を replacement に置き換え、置き換えが終わるまで待つ
+// 続けて最新の内容から次の一致箇所を探せるよう、編集を処理するハンドラーの完了を待ってから戻る
+// 置き換えられなかった場合は false を返す
+func (c *Controller) applyReplacements(matches []search.Match, replacement string) bool {
+	reps := make([]contents.Replacement, 0, len(matches))
+	for _, m := range matches {
+		if c.contents.IsReadOnly(m.Line) {
+			c.reportEditError(contents.ErrReadOnly)
+			return false
+		}
+		reps = append(reps, contents.Replacement{Line: m.Line, Col: m.Col, Length: m.Length, Text: replacement})
+	}
+	_, err := c.eventBus.PublishAndWaitResponse(event.NewReplaceEvent(reps))
+	return err == nil
+}
+
+// performReplace は置き換えをまとめて適用し、1回の取り消しで戻せるよう1つの編集として記録する
+// カーソルは最後の置き換えの末尾に移動する
+func (c *Controller) performReplace(reps []contents.Replacement) {
+	if len(reps) == 0 {
+		return
+	}
+	c.clearSelection()
+
+	// 1箇所だけの置き換えは範囲の置き換えとして扱い、取り消したときのカーソル位置を一致箇所に合わせる
+	if len(reps) == 1 {
+		r := reps[0]
+		c.replaceRange(contents.Position{X: r.Col, Y: r.Line}, contents.Position{X: r.Col + r.Length, Y: r.Line}, []string{r.Text})
+		return
+	}
+
+	// 行数は変わらないため、変更した行の前後の内容だけを記録して大きなバッファでも記録を小さく保つ
+	pos := c.screen.GetCursor().ToPosition()
+	changes, err := c.contents.ReplaceAll(reps)
+	if err != nil {
+		c.reportEditError(err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	end := reps[0]
+	for _, r := range reps[1:] {
+		if r.Line > end.Line || (r.Line == end.Line && r.Col > end.Col) {
+			end = r
+		}
+	}
+	// 同じ行の手前の置き換えによる位置のずれを反映する
+	x := end.Col + len([]rune(end.Text))
+	for _, r := range reps {
+		if r.Line == end.Line && r.Col < end.Col {
+			x += len([]rune(r.Text)) - r.Length
+		}
+	}
+	after := contents.Position{X: x, Y: end.Line}
+	c.history.Record(&command.LineEdit{Buffer: c.contents, Changes: changes, Before: contents.Position{X: pos.X, Y: pos.Y}, After: after})
+	c.screen.SetCursorPosition(after.X, after.Y)
+	c.updateScroll()
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func typeString(s string) []key.KeyEvent {
+	var events []key.KeyEvent
+	for _, r := range s {
+		events = append(events, char(r))
+	}
+	return events
+}
+
+func replaceKeys(pattern, replacement string, answers string) []key.KeyEvent {
+	events := []key.KeyEvent{{Type: key.KeyEventChar, Rune: '%', Modifiers: key.ModAlt}}
+	events = append(events, typeString(pattern)...)
+	events = append(events, special(key.KeyEnter))
+	events = append(events, typeString(replacement)...)
+	events = append(events, special(key.KeyEnter))
+	return append(events, typeString(answers)...)
+}
+
+func TestReplace_Confirm(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo foo", "foo"}, replaceKeys("foo", "bar", "yny")...)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"bar foo", "bar"}, c.GetAllLines())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.Y)
+	assert.Equal(t, 3, pos.X)
+
+	controller.performUndo()
+	assert.Equal(t, []string{"bar foo", "foo"}, c.GetAllLines())
+}
+
+func TestReplace_All(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"a-a", "b", "a"}, replaceKeys("a", "xy", "nay")...)
+	controller.screen.SetCursorPosition(1, 1)
+
+	assert.NoError(t, controller.Process())
+	// 開始位置以降を確認した後に先頭に戻り、スキップした箇所以外を全て置換する
+	assert.Equal(t, []string{"xy-xy", "b", "a"}, c.GetAllLines())
+
+	// 残りの置換は1回で取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"a-a", "b", "a"}, c.GetAllLines())
+	// 取り消すと、残りを全て置換すると答えた時点の一致箇所にカーソルを戻す
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 0, pos.X)
+
+	controller.performRedo()
+	assert.Equal(t, []string{"xy-xy", "b", "a"}, c.GetAllLines())
+	pos = controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 5, pos.X)
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// Resize は端末の大きさが変わったことを通知する
+// 描画中の画面の大きさを変えないよう、変更はイベントバス上で行う
+func (c *Controller) Resize(rows, cols int) {
+	c.eventBus.Publish(event.NewResizeEvent(rows, cols))
+}
+
+// createResizeHandler は端末の大きさの変更イベントのハンドラーを作成する
+// 小さすぎる間は画面に案内だけを表示し、大きくなればスクロール位置を合わせ直して元の表示に戻る
+func (c *Controller) createResizeHandler() event.Handler {
+	return event.NewSingleTypeHandler(event.TypeResize, func(e event.Event) (bool, error) {
+		resize, ok := e.Payload.(event.ResizeEvent)
+		if !ok {
+			return false, nil
+		}
+		c.logger.Log("screen", fmt.Sprintf("Terminal resized to %dx%d", resize.Cols, resize.Rows))
+		c.screen.Resize(resize.Rows, resize.Cols)
+		c.applyLayout()
+		c.updateScroll()
+		c.eventBus.Publish(event.NewRefreshEvent())
+		return true, nil
+	})
+}
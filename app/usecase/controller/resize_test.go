@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize_KeepsCursorVisible(t *testing.T) {
+	lines := make([]string, 50)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	controller, _ := newKeyInputController(t, lines)
+	controller.screen.SetCursorPosition(2, 30)
+
+	for _, size := range [][2]int{{5, 20}, {3, 2}, {1, 1}, {0, 0}} {
+		controller.Resize(size[0], size[1])
+		_, offsetRow := controller.screen.GetOffset()
+		visible := max(controller.screen.TextRows(), 1)
+		assert.GreaterOrEqual(t, 30, offsetRow, "size %v", size)
+		assert.Less(t, 30, offsetRow+visible, "size %v", size)
+	}
+
+	// 大きくなれば余白を取ってスクロールし直す
+	controller.Resize(24, 80)
+	_, offsetRow := controller.screen.GetOffset()
+	assert.Equal(t, 24, controller.screen.GetRowLines())
+	assert.LessOrEqual(t, offsetRow, 27)
+}
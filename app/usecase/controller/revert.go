@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// revertBuffer は変更を破棄して、開いているファイルをディスクから読み直す
+// 未保存の変更がある場合は破棄してよいか確認する
+func (c *Controller) revertBuffer() error {
+	filename := c.fileManager.GetFilename()
+	if filename == "" {
+		c.setStatusMessage("Buffer has no file to revert to")
+		return nil
+	}
+	if ok, err := c.confirmDiscard(filename); err != nil || !ok {
+		return err
+	}
+	c.eventBus.PublishAndWaitResponse(event.NewBufferEvent(event.BufferRevert, 0))
+	return nil
+}
+
+// confirmDiscard はファイルの残りの読み込みを待ち、未保存の変更があれば破棄して読み直してよいか確認する
+func (c *Controller) confirmDiscard(filename string) (bool, error) {
+	c.waitForLoad()
+
+	if c.contents.IsDirty() {
+		ok, err := c.confirmYesNo(fmt.Sprintf("Discard changes and reload %s? (y/n)", filename))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			c.setStatusMessage("Revert cancelled")
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// confirmYesNo はステータスバーに message を表示し、y なら true、n / Esc / C-c / C-x なら false を返す
+func (c *Controller) confirmYesNo(message string) (bool, error) {
+	c.setStatusMessage("%s", message)
+	for {
+		ev, err := c.readEvent()
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'y' || ev.Rune == 'Y'):
+			return true, nil
+		case ev.Type == key.KeyEventChar && (ev.Rune == 'n' || ev.Rune == 'N'),
+			ev.Type == key.KeyEventSpecial && ev.Key == key.KeyEsc,
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			return false, nil
+		}
+	}
+}
+
+// performRevert はファイルを読み直してバッファの内容を置き換える
+func (c *Controller) performRevert() {
+	filename := c.fileManager.GetFilename()
+	if err := c.reloadFile(c.fileManager.OpenFile); err != nil {
+		c.setErrorMessage("Cannot revert: %v", err)
+		return
+	}
+	c.setStatusMessage("Reverted %s", filename)
+}
+
+// reloadFile は open でファイルを読み直してバッファの内容を置き換える
+// 読み直しも1つの編集として記録するため、取り消せば破棄した変更に戻せる
+// カーソルは読み直す前の位置の近くに留める
+func (c *Controller) reloadFile(open func(filename string) error) error {
+	filename := c.fileManager.GetFilename()
+	pos := c.screen.GetCursor().ToPosition()
+	old := c.contents.GetAllLines()
+
+	if err := open(filename); err != nil {
+		return err
+	}
+	lines := c.contents.GetAllLines()
+	c.recordEdit(contents.Position{}, old, lines)
+	// 読み直した内容はディスクと同じなので、保存済みの状態とする
+	c.history.MarkSaved()
+	c.contents.SetDirty(false)
+	c.clearSelection()
+	c.keepCursorNear(pos)
+	c.updateScroll()
+	// 破棄した変更の復元用ファイルは不要になる
+	c.discardRecovery(filename)
+	return nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	mock_filemanager "github.com/wasya-io/go-kilo/app/boundary/filemanager/mock"
+)
+
+func TestRevertBuffer(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one", "two", "three"}, char('n'), char('y'))
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	controller.screen.SetCursorPosition(5, 2)
+	controller.performInsertChar('!')
+	assert.True(t, c.IsDirty())
+
+	// 確認で n を押すと読み直さない
+	assert.NoError(t, controller.revertBuffer())
+	assert.Equal(t, []string{"one", "two", "three!"}, c.GetAllLines())
+
+	fm.EXPECT().OpenFile("test.txt").DoAndReturn(func(string) error {
+		c.LoadContent([]string{"one", "two"})
+		return nil
+	})
+	assert.NoError(t, controller.revertBuffer())
+	assert.Equal(t, []string{"one", "two"}, c.GetAllLines())
+	assert.False(t, c.IsDirty())
+	// カーソルは読み直す前の位置の近くに留まる
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.Y)
+	assert.Equal(t, 3, pos.X)
+
+	// 読み直しは取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"one", "two", "three!"}, c.GetAllLines())
+	assert.True(t, c.IsDirty())
+}
+
+func TestRevertBuffer_Failure(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one"})
+	fm := controller.fileManager.(*mock_filemanager.MockFileManager)
+	fm.EXPECT().OpenFile("test.txt").Return(errors.New("gone"))
+
+	// 変更がなければ確認せずに読み直す。読み直せない場合は内容を変えない
+	assert.NoError(t, controller.revertBuffer())
+	assert.Equal(t, []string{"one"}, c.GetAllLines())
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/usecase/search"
+)
+
+// Range はバッファ上の範囲。Start から End の手前まで [Start, End) を表す
+type Range struct {
+	Start, End contents.Position
+}
+
+// Script はスクリプトやプラグインからカーソル・選択範囲・バッファを操作する API
+// 位置は contents.API と同じく行・列ともに 0 始まり（列はルーン単位）で、範囲外の位置はバッファの範囲に丸める
+// 操作は対話的な操作と同じくイベントバスを通して編集の順序を守り、取り消しの履歴に記録する
+// 各メソッドは操作が反映されるまで待つため、イベントバスのハンドラーの中から呼び出してはならない
+type Script struct {
+	c *Controller
+}
+
+// Script はフォーカスのあるバッファを操作する Script を返す
+func (c *Controller) Script() *Script {
+	return &Script{c: c}
+}
+
+// publish は result を受け取るイベントを発行し、処理されるまで待って結果を返す
+func (s *Script) publish(build func(result chan<- error) event.Event) error {
+	s.c.waitForLoad()
+	result := make(chan error, 1)
+	s.c.eventBus.Publish(build(result))
+	return <-result
+}
+
+// Cursor はカーソルの位置を返す
+func (s *Script) Cursor() contents.Position {
+	return s.c.screen.GetCursor().ToPosition()
+}
+
+// MoveCursor はカーソルを line 行 col 列に移動し、選択を解除する
+func (s *Script) MoveCursor(line, col int) error {
+	pos := contents.Position{X: col, Y: line}
+	return s.publish(func(result chan<- error) event.Event {
+		return event.NewSelectRangeEvent(pos, pos, result)
+	})
+}
+
+// Select は r を選択し、カーソルを r.End に置く。r.End が r.Start より前なら後ろ向きに選択する
+func (s *Script) Select(r Range) error {
+	return s.publish(func(result chan<- error) event.Event {
+		return event.NewSelectRangeEvent(r.Start, r.End, result)
+	})
+}
+
+// Insert はカーソル位置に text を挿入する。選択中の場合は選択範囲を置き換える
+// text は改行を含んでもよく、1回の取り消しで元に戻せる
+func (s *Script) Insert(text string) error {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	return s.publish(func(result chan<- error) event.Event {
+		return event.NewInsertTextEvent(lines, result)
+	})
+}
+
+// DeleteRange は r を削除し、カーソルを削除した位置に置く。1回の取り消しで元に戻せる
+func (s *Script) DeleteRange(r Range) error {
+	return s.publish(func(result chan<- error) event.Event {
+		return event.NewDeleteRangeEvent(r.Start, r.End, result)
+	})
+}
+
+// GetText は r の文字列を返す。行の区切りは改行にする
+func (s *Script) GetText(r Range) string {
+	s.c.waitForLoad()
+	return strings.Join(s.c.contents.TextRange(r.Start, r.End), "\n")
+}
+
+// SearchNext はカーソル位置以降で pattern に最初に一致する箇所を選択する。末尾まで見つからなければ先頭から探す
+// 一致の判定はインクリメンタル検索と同じく、pattern が小文字だけなら大文字小文字を区別しない
+// 一致箇所がなければ ok は false で、カーソルは動かさない
+func (s *Script) SearchNext(pattern string) (r Range, ok bool, err error) {
+	s.c.waitForLoad()
+	matches := search.Find(s.c.contents.GetAllLines(), pattern)
+	if len(matches) == 0 {
+		return Range{}, false, nil
+	}
+	cur := s.Cursor()
+	m := matches[0]
+	for _, candidate := range matches {
+		if candidate.Line > cur.Y || (candidate.Line == cur.Y && candidate.Col >= cur.X) {
+			m = candidate
+			break
+		}
+	}
+	r = Range{
+		Start: contents.Position{X: m.Col, Y: m.Line},
+		End:   contents.Position{X: m.Col + m.Length, Y: m.Line},
+	}
+	if err := s.Select(r); err != nil {
+		return Range{}, false, err
+	}
+	return r, true, nil
+}
+
+// clampPosition は pos をバッファの範囲に丸める
+func (c *Controller) clampPosition(pos contents.Position) contents.Position {
+	n := c.contents.GetLineCount()
+	if n == 0 {
+		return contents.Position{}
+	}
+	pos.Y = min(max(pos.Y, 0), n-1)
+	pos.X = min(max(pos.X, 0), c.contents.GetRow(pos.Y).GetRuneCount())
+	return pos
+}
+
+// orderedRange は start と end をバッファの範囲に丸め、前から順に並べて返す
+func (c *Controller) orderedRange(start, end contents.Position) (contents.Position, contents.Position) {
+	start, end = c.clampPosition(start), c.clampPosition(end)
+	if end.Y < start.Y || (end.Y == start.Y && end.X < start.X) {
+		start, end = end, start
+	}
+	return start, end
+}
+
+// performSelectRange は start から end までを選択し、カーソルを end に置く。同じ位置なら選択を解除する
+func (c *Controller) performSelectRange(start, end contents.Position) {
+	start, end = c.clampPosition(start), c.clampPosition(end)
+	c.clearSelection()
+	if start != end {
+		c.selection = selectionState{active: true, anchor: start}
+	}
+	c.screen.SetCursorPosition(end.X, end.Y)
+	c.showSelection()
+	c.updateScroll()
+}
+
+// performInsertText は text をカーソル位置に挿入する。選択中の場合は選択範囲を置き換える
+func (c *Controller) performInsertText(text []string) error {
+	start, end, ok := c.selectionRange()
+	if !ok {
+		start = c.clampPosition(c.screen.GetCursor().ToPosition())
+		end = start
+	}
+	return c.editRange(start, end, text)
+}
+
+// performDeleteRange は start から end の手前までを削除する
+func (c *Controller) performDeleteRange(start, end contents.Position) error {
+	start, end = c.orderedRange(start, end)
+	if start == end {
+		return nil
+	}
+	return c.editRange(start, end, nil)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+)
+
+func pos(line, col int) contents.Position {
+	return contents.Position{X: col, Y: line}
+}
+
+func TestScript_InsertAndUndo(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"hello world"})
+	s := controller.Script()
+
+	assert.NoError(t, s.MoveCursor(0, 5))
+	assert.NoError(t, s.Insert(",\nbig"))
+	assert.Equal(t, []string{"hello,", "big world"}, c.GetAllLines())
+	assert.Equal(t, pos(1, 3), s.Cursor())
+
+	// 改行を含む挿入も1回で取り消せる
+	controller.performUndo()
+	assert.Equal(t, []string{"hello world"}, c.GetAllLines())
+}
+
+func TestScript_InsertReplacesSelection(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"hello world"})
+	s := controller.Script()
+
+	// 後ろ向きの選択も前から順の範囲として置き換える
+	assert.NoError(t, s.Select(Range{Start: pos(0, 11), End: pos(0, 6)}))
+	assert.Equal(t, "world", s.GetText(Range{Start: pos(0, 6), End: pos(0, 11)}))
+	assert.NoError(t, s.Insert("there"))
+	assert.Equal(t, []string{"hello there"}, c.GetAllLines())
+	assert.False(t, controller.selection.active)
+
+	controller.performUndo()
+	assert.Equal(t, []string{"hello world"}, c.GetAllLines())
+}
+
+func TestScript_DeleteRangeClampsAndUndo(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc", "def", "ghi"})
+	s := controller.Script()
+
+	// 範囲外の位置はバッファの範囲に丸める
+	assert.NoError(t, s.DeleteRange(Range{Start: pos(0, 2), End: pos(1, 99)}))
+	assert.Equal(t, []string{"ab", "ghi"}, c.GetAllLines())
+	assert.Equal(t, pos(0, 2), s.Cursor())
+	assert.Equal(t, "ab\ngh", s.GetText(Range{Start: pos(0, 0), End: pos(1, 2)}))
+
+	controller.performUndo()
+	assert.Equal(t, []string{"abc", "def", "ghi"}, c.GetAllLines())
+}
+
+func TestScript_ReadOnly(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc"})
+	c.SetReadOnly(true)
+	s := controller.Script()
+
+	assert.ErrorIs(t, s.Insert("x"), contents.ErrReadOnly)
+	assert.ErrorIs(t, s.DeleteRange(Range{Start: pos(0, 0), End: pos(0, 1)}), contents.ErrReadOnly)
+	assert.Equal(t, []string{"abc"}, c.GetAllLines())
+	// カーソルの移動は読み取り専用でもできる
+	assert.NoError(t, s.MoveCursor(0, 2))
+	assert.Equal(t, pos(0, 2), s.Cursor())
+}
+
+func TestScript_SearchNextWraps(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"foo bar", "Foo foo"})
+	s := controller.Script()
+
+	assert.NoError(t, s.MoveCursor(0, 1))
+	r, ok, err := s.SearchNext("foo")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Range{Start: pos(1, 0), End: pos(1, 3)}, r)
+	assert.True(t, controller.selection.active)
+
+	r, _, _ = s.SearchNext("foo")
+	assert.Equal(t, Range{Start: pos(1, 4), End: pos(1, 7)}, r)
+
+	// 末尾まで見つからなければ先頭に戻る
+	r, ok, _ = s.SearchNext("foo")
+	assert.True(t, ok)
+	assert.Equal(t, Range{Start: pos(0, 0), End: pos(0, 3)}, r)
+
+	_, ok, err = s.SearchNext("baz")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, pos(0, 3), s.Cursor())
+}
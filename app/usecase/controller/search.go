@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/search"
+)
+
+// IncrementalSearch はメッセージバーで検索文字列を受け付け、入力のたびに一致箇所を強調表示する
+// 上下（左右）の矢印キーで一致箇所を移動し、Enter で確定、Esc で検索前の位置に戻る
+// Alt-R で検索文字列を正規表現として扱うかどうかを切り替える
+func (c *Controller) IncrementalSearch() error {
+	// ファイルの後半も検索できるよう、全体の読み込みを待つ
+	c.waitForLoad()
+
+	pos := c.screen.GetCursor().ToPosition()
+	offsetCol, offsetRow := c.screen.GetOffset()
+	state := search.New(pos.Y, pos.X)
+	defer c.screen.ClearHighlights()
+
+	var query []rune
+	update := func() {
+		state.SetQuery(string(query), c.contents.GetAllLines())
+		c.showSearch(state)
+	}
+	c.showSearch(state)
+
+	for {
+		ev, err := c.readEvent()
+		if err != nil {
+			return err
+		}
+
+		switch ev.Type {
+		case key.KeyEventChar:
+			if ev.Modifiers.Has(key.ModAlt) {
+				if ev.Rune == 'r' || ev.Rune == 'R' {
+					state.SetRegexp(!state.Regexp(), c.contents.GetAllLines())
+					c.showSearch(state)
+				}
+				continue
+			}
+			query = append(query, ev.Rune)
+			update()
+		case key.KeyEventSpecial:
+			switch ev.Key {
+			case key.KeyBackspace:
+				if len(query) > 0 {
+					query = query[:len(query)-1]
+					update()
+				}
+			case key.KeyArrowDown, key.KeyArrowRight:
+				state.Next()
+				c.showSearch(state)
+			case key.KeyArrowUp, key.KeyArrowLeft:
+				state.Prev()
+				c.showSearch(state)
+			case key.KeyEnter:
+				c.finishSearch(state)
+				return nil
+			case key.KeyEsc:
+				c.cancelSearch(state, offsetRow, offsetCol)
+				return nil
+			}
+		case key.KeyEventControl:
+			switch ev.Key {
+			case key.KeyCtrlF:
+				// 続けて Ctrl-F を押すと次の一致箇所へ移動する
+				state.Next()
+				c.showSearch(state)
+			case key.KeyCtrlC, key.KeyCtrlX:
+				c.cancelSearch(state, offsetRow, offsetCol)
+				return nil
+			}
+		}
+	}
+}
+
+// showSearch は一致箇所を強調表示し、選択中の一致箇所にカーソルを移動する
+func (c *Controller) showSearch(state *search.State) {
+	matches := state.Matches()
+	current, index, ok := state.Current()
+
+	highlights := make([]screen.Highlight, 0, len(matches))
+	for i, m := range matches {
+		highlights = append(highlights, screen.Highlight{Line: m.Line, Col: m.Col, Length: m.Length, Current: i == index})
+	}
+	c.screen.SetHighlights(highlights)
+
+	label := "Search"
+	if state.Regexp() {
+		label = "Regex search"
+	}
+	status := ""
+	switch {
+	case state.Query() == "":
+	case state.Err() != nil:
+		status = " (invalid regex)"
+	case ok:
+		status = fmt.Sprintf(" (%d/%d)", index+1, len(matches))
+		c.eventBus.Publish(event.NewCursorSetEvent(current.Line, current.Col))
+	default:
+		status = " (no matches)"
+	}
+	c.setStatusMessage("%s: %s%s  (Up/Down: move  M-r: regex  Enter: done  Esc: cancel)", label, state.Query(), status)
+	c.trackSearch(state)
+}
+
+// trackSearch は入力中の検索の一致箇所を、ステータスバーの一致箇所の番号の表示に使う
+func (c *Controller) trackSearch(state *search.State) {
+	if state.Query() == "" || state.Err() != nil {
+		c.searching.set(nil, nil)
+		return
+	}
+	c.searching.set(c.contents, state.Index(c.contents.Version()))
+}
+
+// finishSearch は選択中の一致箇所にカーソルを置いたまま検索を終える
+func (c *Controller) finishSearch(state *search.State) {
+	c.screen.ClearHighlights()
+	if _, index, ok := state.Current(); ok {
+		c.setStatusMessage("Found %q (%d/%d)  (M-n/M-p: next/previous match  Esc: end search)", state.Query(), index+1, len(state.Matches()))
+		return
+	}
+	c.searching.set(nil, nil)
+	if state.Query() != "" {
+		c.setStatusMessage("No matches for %q", state.Query())
+		return
+	}
+	c.setStatusMessage("")
+}
+
+// cancelSearch は検索を取り消し、カーソルと表示位置を検索前に戻す
+func (c *Controller) cancelSearch(state *search.State, offsetRow, offsetCol int) {
+	y, x := state.Origin()
+	c.eventBus.Publish(event.NewCursorSetEvent(y, x))
+	c.screen.SetRowOffset(offsetRow)
+	c.screen.SetColOffset(offsetCol)
+	c.screen.ClearHighlights()
+	c.searching.set(nil, nil)
+	c.setStatusMessage("Search cancelled")
+}
+
+// activeSearch は確定した（または入力中の）検索の一致箇所の一覧と、その対象のバッファ
+// 検索を終えた後もバッファの一致箇所の間を移動でき、ステータスバーにカーソル位置の一致箇所の番号を表示する
+// 画面の更新はイベントバスのゴルーチンで行うため、mu で保護する
+type activeSearch struct {
+	mu     sync.Mutex
+	buffer *contents.Contents
+	index  *search.Index // 検索していなければ nil
+}
+
+// set は buffer の検索の一致箇所の一覧を index に置き換える。nil なら検索を終える
+func (s *activeSearch) set(buffer *contents.Contents, index *search.Index) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffer, s.index = buffer, index
+}
+
+// active は buffer を検索しているかどうかを返す
+func (s *activeSearch) active(buffer *contents.Contents) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index != nil && s.buffer == buffer
+}
+
+// status は buffer のカーソル位置 pos でのステータスバーの表示を返す
+// 前回の表示から編集されていれば、ここで一致箇所を探し直す
+func (s *activeSearch) status(buffer *contents.Contents, pos contents.Position) screen.SearchStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil || s.buffer != buffer {
+		return screen.SearchStatus{}
+	}
+	version := buffer.Version()
+	total := len(s.index.Matches(version, buffer.GetAllLines))
+	index, _ := s.index.At(version, buffer.GetAllLines, pos.Y, pos.X)
+	return screen.SearchStatus{Active: true, Index: index, Total: total}
+}
+
+// move は pos の次（forward が false なら前）の一致箇所を返す
+func (s *activeSearch) move(buffer *contents.Contents, pos contents.Position, forward bool) (search.Match, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil || s.buffer != buffer {
+		return search.Match{}, "", false
+	}
+	version := buffer.Version()
+	var m search.Match
+	var ok bool
+	if forward {
+		m, _, ok = s.index.Next(version, buffer.GetAllLines, pos.Y, pos.X)
+	} else {
+		m, _, ok = s.index.Prev(version, buffer.GetAllLines, pos.Y, pos.X)
+	}
+	return m, s.index.Query(), ok
+}
+
+// updateSearchStatus はステータスバーにカーソル位置の一致箇所の番号と件数を表示する
+func (c *Controller) updateSearchStatus() {
+	pos := c.screen.GetCursor().ToPosition()
+	c.screen.SetSearchStatus(c.searching.status(c.contents, contents.Position{X: pos.X, Y: pos.Y}))
+}
+
+// searchNext は確定した検索の次（forward が false なら前）の一致箇所へ移動する。末尾の次は先頭に戻る
+func (c *Controller) searchNext(forward bool) {
+	if !c.searching.active(c.contents) {
+		c.setStatusMessage("No active search (Ctrl-F to search)")
+		return
+	}
+	pos := c.screen.GetCursor().ToPosition()
+	m, query, ok := c.searching.move(c.contents, contents.Position{X: pos.X, Y: pos.Y}, forward)
+	if !ok {
+		c.setStatusMessage("No matches for %q", query)
+		return
+	}
+	c.eventBus.Publish(event.NewCursorSetEvent(m.Line, m.Col))
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
+// endSearch は確定した検索を終え、ステータスバーの一致箇所の表示を消す。検索していなければ false を返す
+func (c *Controller) endSearch() bool {
+	if !c.searching.active(c.contents) {
+		return false
+	}
+	c.searching.set(nil, nil)
+	return true
+}
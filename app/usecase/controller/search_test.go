@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+func special(k key.Key) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventSpecial, Key: k}
+}
+
+func TestIncrementalSearch_Accept(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"foo", "bar foo", "Foo foo"},
+		ctrlKey(key.KeyCtrlF), char('f'), char('o'), special(key.KeyArrowDown), special(key.KeyArrowDown),
+		special(key.KeyArrowUp), special(key.KeyEnter),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	// 開始位置以降の最初の一致 (1,4) から2つ進んで1つ戻る
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 2, pos.Y)
+	assert.Equal(t, 0, pos.X)
+}
+
+func TestIncrementalSearch_Cancel(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc", "xyz"},
+		ctrlKey(key.KeyCtrlF), char('y'), special(key.KeyEsc),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 1, pos.X)
+	assert.False(t, c.IsDirty(), "typed characters must not be inserted")
+}
+
+func TestIncrementalSearch_Regexp(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"日本語 x12", "y3"},
+		ctrlKey(key.KeyCtrlF), key.KeyEvent{Type: key.KeyEventChar, Rune: 'r', Modifiers: key.ModAlt},
+		char('\\'), char('d'), char('+'), special(key.KeyArrowDown), special(key.KeyEnter),
+	)
+
+	assert.NoError(t, controller.Process())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 1, pos.Y)
+	assert.Equal(t, 1, pos.X)
+}
+
+func TestIncrementalSearch_StatusTracksMatches(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"foo", "bar foo", "Foo foo"},
+		ctrlKey(key.KeyCtrlF), char('f'), char('o'), special(key.KeyArrowDown), special(key.KeyEnter),
+		key.KeyEvent{Type: key.KeyEventChar, Rune: 'n', Modifiers: key.ModAlt},
+		key.KeyEvent{Type: key.KeyEventChar, Rune: 'p', Modifiers: key.ModAlt},
+		special(key.KeyEsc),
+	)
+	status := func() screen.SearchStatus {
+		pos := controller.screen.GetCursor().ToPosition()
+		return controller.searching.status(c, contents.Position{X: pos.X, Y: pos.Y})
+	}
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: 1, Total: 4}, status(), "the search stays active after Enter")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: 2, Total: 4}, status())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: 1, Total: 4}, status())
+
+	// 編集すると次に参照した時点で探し直す
+	controller.screen.SetCursorPosition(0, 0)
+	c.InsertChars(contents.Position{}, []rune("foo "))
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: 0, Total: 5}, status())
+	controller.screen.SetCursorPosition(3, 0)
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: -1, Total: 5}, status(), "the cursor is not on a match")
+	c.LoadContent([]string{"bar"})
+	assert.Equal(t, screen.SearchStatus{Active: true, Index: -1, Total: 0}, status())
+
+	// Esc で検索を終える
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, screen.SearchStatus{}, status())
+}
+
+func TestSearchNext_WithoutSearch(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"foo"})
+	assert.NoError(t, controller.commands.Execute("search-next", nil))
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.X)
+}
+
+func TestIncrementalSearch_CancelEndsSearch(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abc", "xyz"},
+		ctrlKey(key.KeyCtrlF), char('y'), special(key.KeyEsc),
+	)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, screen.SearchStatus{}, controller.searching.status(c, contents.Position{}))
+}
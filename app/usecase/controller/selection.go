@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+// selectionState は選択範囲の状態。範囲は anchor とカーソル位置の間
+// 入力中の文字の反映と順序が入れ替わらないよう、編集と同じくイベントバスのハンドラーの中でのみ変更する
+type selectionState struct {
+	active bool
+	sticky bool // Ctrl-K m で開始した場合は Shift なしの矢印キーでも範囲を広げる
+	block  bool // 矩形選択（anchor とカーソルの表示上の桁の間を各行から選ぶ）
+	anchor contents.Position
+}
+
+// isArrowKey は矢印キーかどうかを返す
+func isArrowKey(k key.Key) bool {
+	return k == key.KeyArrowUp || k == key.KeyArrowDown || k == key.KeyArrowLeft || k == key.KeyArrowRight
+}
+
+// beforeCursorMove はカーソル移動の前に呼び出し、Shift が押されていれば選択を始め、
+// そうでなければ Shift+矢印キーで始めた選択を解除する
+func (c *Controller) beforeCursorMove(extend bool) {
+	action := event.BufferSelectCollapse
+	if extend {
+		action = event.BufferSelectExtend
+	}
+	c.eventBus.Publish(event.NewBufferEvent(action, 0))
+}
+
+// toggleSelection はカーソル位置から選択を始める。選択中の場合は解除する
+func (c *Controller) toggleSelection() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferSelectToggle, 0))
+}
+
+// toggleBlockSelection はカーソル位置から矩形選択を始める。選択中の場合は解除する
+func (c *Controller) toggleBlockSelection() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferSelectBlock, 0))
+}
+
+// cancelSelection は選択を解除する
+func (c *Controller) cancelSelection() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferSelectCancel, 0))
+}
+
+// copySelection は選択範囲をクリップボードにコピーする
+func (c *Controller) copySelection() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferCopy, 0))
+}
+
+// cutSelection は選択範囲をクリップボードに移して削除する
+func (c *Controller) cutSelection() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferCut, 0))
+}
+
+// paste はクリップボードの内容をカーソル位置に挿入する。選択中の場合は選択範囲を置き換える
+func (c *Controller) paste() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferPaste, 0))
+}
+
+// performSelection は選択範囲の開始・解除を処理する
+func (c *Controller) performSelection(action event.BufferAction) {
+	pos := c.screen.GetCursor().ToPosition()
+	switch action {
+	case event.BufferSelectExtend:
+		if !c.selection.active {
+			c.selection = selectionState{active: true, anchor: contents.Position{X: pos.X, Y: pos.Y}}
+		}
+	case event.BufferSelectCollapse:
+		if c.selection.active && !c.selection.sticky {
+			c.clearSelection()
+		}
+	case event.BufferSelectToggle:
+		if c.selection.active {
+			c.clearSelection()
+			c.setStatusMessage("Selection cancelled")
+			return
+		}
+		c.selection = selectionState{active: true, sticky: true, anchor: contents.Position{X: pos.X, Y: pos.Y}}
+		c.setStatusMessage("Selection started: move the cursor, then C-k c to copy or C-k k to cut")
+	case event.BufferSelectBlock:
+		if c.selection.active {
+			c.clearSelection()
+			c.setStatusMessage("Selection cancelled")
+			return
+		}
+		c.selection = selectionState{active: true, sticky: true, block: true, anchor: contents.Position{X: pos.X, Y: pos.Y}}
+		c.setStatusMessage("Block selection started: move the cursor, then C-k c to copy or C-k k to cut")
+	case event.BufferSelectCancel:
+		c.clearSelection()
+	}
+	c.showSelection()
+}
+
+// selectionRange は選択範囲を先頭・末尾の順で返す。選択していないか範囲が空の場合 ok は false
+// 矩形選択は行をまたぐ範囲として扱えないため、ok は false
+func (c *Controller) selectionRange() (start, end contents.Position, ok bool) {
+	if !c.selection.active || c.selection.block {
+		return start, end, false
+	}
+	pos := c.screen.GetCursor().ToPosition()
+	start, end = c.selection.anchor, contents.Position{X: pos.X, Y: pos.Y}
+	if end.Y < start.Y || (end.Y == start.Y && end.X < start.X) {
+		start, end = end, start
+	}
+	return start, end, start != end
+}
+
+// showSelection は選択範囲の表示をカーソル位置に合わせて更新する
+func (c *Controller) showSelection() {
+	if !c.selection.active {
+		c.screen.ClearSelection()
+		return
+	}
+	if c.selection.block {
+		top, bottom, left, right, _ := c.blockRange()
+		c.screen.SetBlockSelection(top, bottom, left, right)
+		return
+	}
+	start, end, _ := c.selectionRange()
+	c.screen.SetSelection(start, end)
+}
+
+// clearSelection は選択を解除する
+func (c *Controller) clearSelection() {
+	c.selection = selectionState{}
+	c.screen.ClearSelection()
+}
+
+func (c *Controller) performCopy() {
+	if c.selection.active && c.selection.block {
+		c.copyBlock(false)
+		return
+	}
+	start, end, ok := c.selectionRange()
+	if !ok {
+		c.setStatusMessage("Nothing selected")
+		return
+	}
+	text := c.contents.TextRange(start, end)
+	c.contents.SetClipboard(text)
+	c.clearSelection()
+	c.setStatusMessage("Copied %d line(s)", len(text))
+}
+
+func (c *Controller) performCut() {
+	if c.selection.active && c.selection.block {
+		c.copyBlock(true)
+		return
+	}
+	start, end, ok := c.selectionRange()
+	if !ok {
+		c.setStatusMessage("Nothing selected")
+		return
+	}
+	text := c.contents.TextRange(start, end)
+	if c.replaceRange(start, end, nil) {
+		c.contents.SetClipboard(text)
+		c.setStatusMessage("Cut %d line(s)", len(text))
+	}
+}
+
+func (c *Controller) performPaste() {
+	text := c.contents.Clipboard()
+	if len(text) == 0 {
+		c.setStatusMessage("Clipboard is empty")
+		return
+	}
+	if c.contents.ClipboardIsBlock() {
+		c.pasteBlock(text)
+		return
+	}
+	start, end, ok := c.selectionRange()
+	if !ok {
+		pos := c.screen.GetCursor().ToPosition()
+		start = contents.Position{X: pos.X, Y: pos.Y}
+		end = start
+	}
+	c.replaceRange(start, end, text)
+}
+
+// replaceRange は start から end の手前までを text に置き換え、取り消し用の履歴に記録する
+// カーソルは挿入した文字列の末尾に移動し、選択は解除する。置き換えられなかった場合は false を返す
+func (c *Controller) replaceRange(start, end contents.Position, text []string) bool {
+	if err := c.editRange(start, end, text); err != nil {
+		c.reportEditError(err)
+		return false
+	}
+	return true
+}
+
+// editRange は replaceRange と同じく置き換えるが、置き換えられなかった理由を知らせずにエラーとして返す
+func (c *Controller) editRange(start, end contents.Position, text []string) error {
+	var removed []string
+	if start != end {
+		r, err := c.contents.DeleteRange(start, end)
+		if err != nil {
+			return err
+		}
+		removed = r
+	}
+	cursorPos := start
+	if len(text) > 0 {
+		e, err := c.contents.InsertText(start, text)
+		if err != nil {
+			// 削除した内容を戻してから中断する
+			if len(removed) > 0 {
+				c.contents.InsertText(start, removed)
+			}
+			return err
+		}
+		cursorPos = e
+	}
+
+	c.recordEdit(start, removed, text)
+	c.clearSelection()
+	c.screen.SetCursorPosition(cursorPos.X, cursorPos.Y)
+	c.updateScroll()
+	return nil
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func shiftArrow(k key.Key) key.KeyEvent {
+	return key.KeyEvent{Type: key.KeyEventSpecial, Key: k, Modifiers: key.ModShift}
+}
+
+func TestSelection_CopyPasteUndo(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abcd", "ef"},
+		shiftArrow(key.KeyArrowRight), shiftArrow(key.KeyArrowRight),
+		ctrlKey(key.KeyCtrlK), char('c'),
+		ctrlKey(key.KeyCtrlK), char('v'),
+		ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	start, end, ok := controller.selectionRange()
+	assert.True(t, ok)
+	assert.Equal(t, contents.Position{X: 1, Y: 0}, start)
+	assert.Equal(t, contents.Position{X: 3, Y: 0}, end)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"bc"}, c.Clipboard())
+	assert.False(t, controller.selection.active, "copying ends the selection")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"abcbcd", "ef"}, c.GetAllLines())
+	assert.Equal(t, 5, controller.screen.GetCursor().ToPosition().X)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"abcd", "ef"}, c.GetAllLines())
+}
+
+func TestSelection_ToggleAndCut(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abcd", "ef", "g"},
+		ctrlKey(key.KeyCtrlK), char('m'),
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowDown},
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowRight},
+		ctrlKey(key.KeyCtrlK), char('k'),
+		ctrlKey(key.KeyCtrlK), char('v'),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+	controller.bookmarks.Set(2, "")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	_, end, ok := controller.selectionRange()
+	assert.True(t, ok, "arrow keys extend a selection started with C-k m")
+	assert.Equal(t, contents.Position{X: 2, Y: 1}, end)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"a", "g"}, c.GetAllLines())
+	assert.Equal(t, []string{"bcd", "ef"}, c.Clipboard())
+	assert.True(t, controller.bookmarks.Has(1), "bookmarks follow removed lines")
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"abcd", "ef", "g"}, c.GetAllLines())
+	assert.Equal(t, contents.Position{X: 2, Y: 1}, contents.Position(controller.screen.GetCursor().ToPosition()))
+}
+
+func TestSelection_BackspaceAndCollapse(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"abcd"},
+		shiftArrow(key.KeyArrowLeft),
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowLeft},
+		shiftArrow(key.KeyArrowLeft), shiftArrow(key.KeyArrowLeft),
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyBackspace},
+	)
+	controller.screen.SetCursorPosition(4, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, controller.selection.active)
+	// Shift なしの矢印キーで選択を解除する
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.selection.active)
+
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "cd", c.GetContentLine(0))
+	assert.False(t, controller.selection.active)
+}
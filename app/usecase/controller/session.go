@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/sessionfile"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// SetSessionStore は終了時に開いているバッファを記録する先を設定する。nil なら記録しない
+func (c *Controller) SetSessionStore(store sessionfile.Store) {
+	c.sessionStore = store
+}
+
+// saveSession は開いているファイルのバッファと、それぞれのカーソルとスクロールの位置を記録する
+// ファイルを1つも開いていない場合は、前回のセッションを残すため記録しない
+func (c *Controller) saveSession(now time.Time) {
+	if c.sessionStore == nil {
+		return
+	}
+	c.storeWindow()
+	focused := c.windows.Focused().Buffer
+	session := sessionfile.Session{SavedAt: now}
+	for _, b := range c.windows.Buffers() {
+		name := b.FileManager.GetFilename()
+		if name == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+		if b == focused {
+			session.Focus = len(session.Buffers)
+		}
+		session.Buffers = append(session.Buffers, sessionfile.Buffer{
+			Path:      name,
+			Line:      b.Cursor.Y,
+			Column:    b.Cursor.X,
+			RowOffset: b.RowOffset,
+			ColOffset: b.ColOffset,
+		})
+	}
+	if len(session.Buffers) == 0 {
+		return
+	}
+	if err := c.sessionStore.Save(session); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to save session: %v", err))
+	}
+}
+
+// RestoreSession は前回の終了時に開いていたファイルをそれぞれのバッファで開き直し、カーソルとスクロールの位置を戻す
+// 開けなくなったファイルは飛ばしてメッセージで知らせる。起動時、メインループを始める前に呼び出す
+func (c *Controller) RestoreSession() {
+	if c.sessionStore == nil {
+		return
+	}
+	session, err := c.sessionStore.Load()
+	if errors.Is(err, os.ErrNotExist) {
+		c.setStatusMessage("No previous session")
+		return
+	}
+	if err != nil {
+		c.setErrorMessage("Cannot restore the session: %v", err)
+		return
+	}
+
+	focus := -1
+	var skipped []string
+	for i, b := range session.Buffers {
+		if !c.openSessionBuffer(b) {
+			skipped = append(skipped, filepath.Base(b.Path))
+			continue
+		}
+		if i == session.Focus {
+			focus = c.windows.BufferIndex(c.windows.Focused().Buffer)
+		}
+	}
+	if focus >= 0 {
+		c.waitForLoad()
+		c.performShowBuffer(focus)
+	}
+	if len(skipped) > 0 {
+		c.setErrorMessage("Session restored without %s", strings.Join(skipped, ", "))
+	}
+}
+
+// openSessionBuffer は記録したバッファのファイルを開いて位置を戻す。開けなければ false を返す
+// 最初のファイルは起動時の空のバッファで開き、以降は新しいバッファで開く
+func (c *Controller) openSessionBuffer(b sessionfile.Buffer) bool {
+	if err := c.openStartupBuffer(b.Path); err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to restore %s: %v", b.Path, err))
+		return false
+	}
+	if b.Line >= c.contents.GetLineCount() {
+		c.waitForLoad()
+	}
+	c.keepCursorNear(contents.Position{X: b.Column, Y: b.Line})
+	c.screen.SetRowOffset(b.RowOffset)
+	c.screen.SetColOffset(b.ColOffset)
+	c.updateScroll()
+	return true
+}
+
+// openStartupBuffer は起動時に開くファイルを、最初のファイルは起動時の空のバッファで、以降は新しいバッファで開く
+// 開けなかった場合は新しいバッファを閉じて元のバッファに戻し、エラーを返す
+func (c *Controller) openStartupBuffer(path string) error {
+	previous := c.windows.BufferIndex(c.windows.Focused().Buffer)
+	detached := c.fileManager.GetFilename() != ""
+	if detached {
+		// 読み込み中の残りの行が新しいバッファに追加されないよう、読み込みの完了を待つ
+		c.waitForLoad()
+		c.eventBus.PublishAndWaitResponse(event.NewDetachBufferEvent())
+	}
+	if err := c.OpenFile(path); err != nil {
+		if detached {
+			c.eventBus.PublishAndWaitResponse(event.NewDiscardBufferEvent(previous))
+		}
+		return err
+	}
+	return nil
+}
+
+// StartupFile はコマンドラインで指定されたファイルと、開いた後に移動する位置
+type StartupFile struct {
+	Path string
+	Line int // 移動する行（1 始まり）。0 なら前回閉じたときの位置へ移動する
+	Col  int // 移動する列（1 始まり）
+}
+
+// OpenFiles はコマンドラインで指定されたファイルをそれぞれのバッファで開き、最初のファイルを表示する
+// 同じファイルを重ねて指定した場合は1つのバッファで開く。開けなかったファイルは飛ばしてメッセージで知らせ、
+// 1つも開けなかった場合は最初のエラーを返す。起動時、メインループを始める前に呼び出す
+func (c *Controller) OpenFiles(files []StartupFile) error {
+	first := -1
+	var failed []string
+	var firstErr error
+	for _, f := range files {
+		if i := c.openBufferIndex(f.Path); i >= 0 {
+			if first < 0 {
+				first = i
+			}
+			continue
+		}
+		if err := c.openStartupBuffer(f.Path); err != nil {
+			c.logger.Log("error", fmt.Sprintf("Failed to open %s: %v", f.Path, err))
+			failed = append(failed, fmt.Sprintf("%s (%v)", f.Path, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if f.Line > 0 {
+			c.GotoLine(f.Line, f.Col)
+		} else {
+			c.RestorePosition()
+		}
+		if first < 0 {
+			first = c.windows.BufferIndex(c.windows.Focused().Buffer)
+		}
+	}
+	if first < 0 {
+		return firstErr
+	}
+	c.waitForLoad()
+	c.performShowBuffer(first)
+	if len(failed) > 0 {
+		c.setErrorMessage("Could not open %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
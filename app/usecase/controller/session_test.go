@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/boundary/sessionfile"
+)
+
+// memorySessionStore はメモリ上にセッションを置くストア
+type memorySessionStore struct {
+	session *sessionfile.Session
+}
+
+func (s *memorySessionStore) Save(session sessionfile.Session) error {
+	s.session = &session
+	return nil
+}
+
+func (s *memorySessionStore) Load() (sessionfile.Session, error) {
+	if s.session == nil {
+		return sessionfile.Session{}, os.ErrNotExist
+	}
+	return *s.session, nil
+}
+
+func TestSession_RestoreAndSave(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	assert.NoError(t, os.WriteFile(a, []byte("one\ntwo\nthree\n"), 0644))
+	assert.NoError(t, os.WriteFile(b, []byte("alpha\nbeta\n"), 0644))
+
+	controller, c := newKeyInputController(t, []string{""})
+	// 起動時の空のバッファと同じく、ファイル名のない実際の FileManager を使う
+	fm := filemanager.NewFileManager(c)
+	controller.fileManager = fm
+	controller.windows.Focused().Buffer.FileManager = fm
+
+	store := &memorySessionStore{session: &sessionfile.Session{
+		Buffers: []sessionfile.Buffer{
+			{Path: a, Line: 2, Column: 99},
+			{Path: filepath.Join(dir, "removed.txt")},
+			{Path: b, Line: 1, Column: 2},
+		},
+		Focus: 0,
+	}}
+	controller.SetSessionStore(store)
+	controller.RestoreSession()
+
+	// 開けなくなったファイルは飛ばし、フォーカスのあったバッファを表示する
+	assert.Len(t, controller.windows.Buffers(), 2)
+	assert.Equal(t, a, controller.fileManager.GetFilename())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().Y)
+	assert.Equal(t, 5, controller.screen.GetCursor().ToPosition().X, "the column is clamped to the line")
+	assert.True(t, controller.screen.DismissMessage(), "skipped files are reported")
+
+	controller.performShowBuffer(1)
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().X)
+
+	controller.screen.SetCursorPosition(1, 0)
+	controller.saveSession(time.Now())
+	assert.Equal(t, []sessionfile.Buffer{
+		{Path: a, Line: 2, Column: 5},
+		{Path: b, Line: 0, Column: 1},
+	}, store.session.Buffers)
+	assert.Equal(t, 1, store.session.Focus)
+}
+
+func TestSession_NotSavedWithoutFiles(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{""})
+	fm := filemanager.NewFileManager(c)
+	controller.fileManager = fm
+	controller.windows.Focused().Buffer.FileManager = fm
+
+	store := &memorySessionStore{}
+	controller.SetSessionStore(store)
+	controller.saveSession(time.Now())
+	assert.Nil(t, store.session, "the previous session must be kept when no file is open")
+}
+
+func TestOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	assert.NoError(t, os.WriteFile(a, []byte("one\ntwo\nthree\n"), 0644))
+	assert.NoError(t, os.WriteFile(b, []byte("alpha\nbeta\n"), 0644))
+
+	controller, c := newKeyInputController(t, []string{""})
+	fm := filemanager.NewFileManager(c)
+	controller.fileManager = fm
+	controller.windows.Focused().Buffer.FileManager = fm
+
+	err := controller.OpenFiles([]StartupFile{
+		{Path: a, Line: 3, Col: 2},
+		{Path: filepath.Join(dir, "missing", "c.txt")},
+		{Path: b},
+		{Path: a},
+	})
+	assert.NoError(t, err)
+
+	// 開けなかったファイルと重複は飛ばし、最初のファイルを表示する
+	assert.Len(t, controller.windows.Buffers(), 2)
+	assert.Equal(t, a, controller.fileManager.GetFilename())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().Y)
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+	assert.True(t, controller.screen.DismissMessage(), "files that could not be opened are reported")
+
+	controller.performShowBuffer(1)
+	assert.Equal(t, b, controller.fileManager.GetFilename())
+}
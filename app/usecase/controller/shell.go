@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/save"
+)
+
+// shellTimeout は出力を挿入・選択範囲を置き換えるシェルのコマンドを待つ時間の上限
+const shellTimeout = 30 * time.Second
+
+// shellOutputFooter はシェルのコマンドの出力を表示する情報パネルの操作の案内
+const shellOutputFooter = "i: insert at cursor  Up/Down: scroll  Esc/q: close"
+
+// shellRunner はユーザーのシェルでコマンドラインを実行する
+type shellRunner interface {
+	// Output は command の標準出力を返す。stdin が nil でなければ標準入力として渡す
+	Output(ctx context.Context, dir, command string, stdin []byte) ([]byte, error)
+	// Interactive は端末の標準入出力で command を実行し、端末に表示した出力を返す
+	Interactive(dir, command string) ([]byte, error)
+}
+
+// shellPrefixes はコマンド行の先頭に付けてシェルのコマンドを実行する記号と、実行するコマンド
+// 長い記号から順に照合する
+var shellPrefixes = []struct{ prefix, command string }{
+	{"r!", "shell-insert"},
+	{"!", "shell"},
+	{"|", "shell-filter"},
+}
+
+// shellCommands はコマンド行の残りをそのままシェルに渡すコマンド
+var shellCommands = map[string]bool{"shell": true, "shell-insert": true, "shell-filter": true}
+
+// shellCommandLine はコマンド行がシェルのコマンドを実行するものなら、実行するコマンドとシェルに渡すコマンドラインを返す
+// 引用符や空白をシェルが解釈できるよう、コマンドラインは区切らずにそのまま返す
+func shellCommandLine(line string) (name, command string, ok bool) {
+	line = strings.TrimSpace(line)
+	for _, p := range shellPrefixes {
+		if rest, found := strings.CutPrefix(line, p.prefix); found {
+			return p.command, strings.TrimSpace(rest), true
+		}
+	}
+	name, rest, _ := strings.Cut(line, " ")
+	if shellCommands[name] {
+		return name, strings.TrimSpace(rest), true
+	}
+	return "", "", false
+}
+
+// shellCommandArg は引数をシェルに渡すコマンドラインにする。引数がなければ入力させる
+func (c *Controller) shellCommandArg(args []string, prompt string) (string, bool, error) {
+	if command := strings.TrimSpace(strings.Join(args, " ")); command != "" {
+		return command, true, nil
+	}
+	command, err := c.prompt(prompt)
+	if err != nil || command == "" {
+		return "", false, err
+	}
+	return command, true, nil
+}
+
+// shellDir はシェルのコマンドを実行するディレクトリを返す
+// 開いているファイルのディレクトリで実行し、名前のないバッファなら作業ディレクトリで実行する
+func (c *Controller) shellDir() string {
+	if path := c.documentPath(); path != "" {
+		return filepath.Dir(path)
+	}
+	return ""
+}
+
+// shellCommand は端末を Raw モードから戻してシェルのコマンドを実行し、出力を情報パネルに表示する
+// コマンドは端末を直接使うため、入力を求めるコマンドも実行できる
+func (c *Controller) shellCommand(args []string) error {
+	command, ok, err := c.shellCommandArg(args, "!")
+	if err != nil || !ok {
+		return err
+	}
+
+	var out []byte
+	runErr := c.handOverTerminal(func() error {
+		var err error
+		out, err = c.shell.Interactive(c.shellDir(), command)
+		return err
+	})
+
+	lines := shellOutputLines(out)
+	if len(lines) == 0 {
+		if runErr != nil {
+			c.setErrorMessage("%v", runErr)
+		} else {
+			c.setStatusMessage("!%s: no output", command)
+		}
+		c.eventBus.Publish(event.NewRefreshEvent())
+		return nil
+	}
+	title := "!" + command
+	if runErr != nil {
+		title = runErr.Error()
+	}
+	return c.showShellOutput(title, lines)
+}
+
+// showShellOutput はシェルのコマンドの出力を情報パネルに表示する
+// 上下キーでスクロールし、i で出力をカーソル位置に挿入する
+func (c *Controller) showShellOutput(title string, lines []string) error {
+	current := 0
+	for {
+		c.screen.SetListOverlay(title, lines, current, shellOutputFooter)
+		c.eventBus.Publish(event.NewRefreshEvent())
+
+		ev, err := c.readEvent()
+		if err != nil {
+			c.dismissOverlay()
+			return err
+		}
+		switch {
+		case ev.Type == key.KeyEventChar && ev.Rune == 'i':
+			c.dismissOverlay()
+			c.insertShellOutput(strings.Join(lines, "\n"))
+			return nil
+		case ev.Type == key.KeyEventChar && ev.Rune == 'q',
+			ev.Type == key.KeyEventSpecial && (ev.Key == key.KeyEsc || ev.Key == key.KeyEnter),
+			ev.Type == key.KeyEventControl && (ev.Key == key.KeyCtrlC || ev.Key == key.KeyCtrlX):
+			c.dismissOverlay()
+			return nil
+		case ev.Type == key.KeyEventSpecial && ev.Key == key.KeyArrowUp:
+			current = max(current-1, 0)
+		case ev.Type == key.KeyEventSpecial && ev.Key == key.KeyArrowDown:
+			current = min(current+1, len(lines)-1)
+		}
+	}
+}
+
+// shellInsertCommand はシェルのコマンドを実行し、標準出力をカーソル位置に挿入する
+func (c *Controller) shellInsertCommand(args []string) error {
+	command, ok, err := c.shellCommandArg(args, "r!")
+	if err != nil || !ok {
+		return err
+	}
+	out, err := c.runShellOutput(command, nil)
+	if err != nil {
+		c.setErrorMessage("%v", err)
+		return nil
+	}
+	if len(out) == 0 {
+		c.setStatusMessage("!%s: no output", command)
+		return nil
+	}
+	c.insertShellOutput(strings.Join(shellOutputLines(out), "\n"))
+	return nil
+}
+
+// shellFilterCommand は選択範囲を標準入力としてシェルのコマンドに渡し、標準出力で置き換える
+// コマンドが失敗した場合は選択範囲を変更しない
+func (c *Controller) shellFilterCommand(args []string) error {
+	start, end, ok := c.selectionRange()
+	if !ok {
+		c.setStatusMessage("Select the text to filter")
+		return nil
+	}
+	command, ok, err := c.shellCommandArg(args, "|")
+	if err != nil || !ok {
+		return err
+	}
+
+	script := c.Script()
+	r := Range{Start: start, End: end}
+	// 行単位で処理するコマンドが最後の行も扱えるよう、末尾に改行を付けて渡す
+	out, err := c.runShellOutput(command, []byte(script.GetText(r)+"\n"))
+	if err != nil {
+		c.setErrorMessage("Filter failed, text unchanged: %v", err)
+		return nil
+	}
+	text := strings.Join(shellOutputLines(out), "\n")
+	if text == "" {
+		err = script.DeleteRange(r)
+	} else if err = script.Select(r); err == nil {
+		err = script.Insert(text)
+	}
+	if err != nil {
+		c.reportEditError(err)
+	}
+	return nil
+}
+
+// runShellOutput は shellTimeout を上限にシェルのコマンドを実行し、標準出力を返す
+func (c *Controller) runShellOutput(command string, stdin []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
+	defer cancel()
+	return c.shell.Output(ctx, c.shellDir(), command, stdin)
+}
+
+// insertShellOutput はシェルのコマンドの出力をカーソル位置に挿入する
+func (c *Controller) insertShellOutput(text string) {
+	if err := c.Script().Insert(text); err != nil {
+		c.reportEditError(err)
+	}
+}
+
+// shellOutputLines はシェルのコマンドの出力を行に分ける。出力がなければ nil を返す
+func shellOutputLines(out []byte) []string {
+	lines := save.SplitOutput(out)
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	return lines
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/cursor"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+// fakeShell は実行したコマンドラインを記録し、決まった出力を返すシェル
+type fakeShell struct {
+	commands []string
+	stdin    []byte
+	out      string
+	err      error
+}
+
+func (s *fakeShell) Output(ctx context.Context, dir, command string, stdin []byte) ([]byte, error) {
+	s.commands = append(s.commands, command)
+	s.stdin = stdin
+	return []byte(s.out), s.err
+}
+
+func (s *fakeShell) Interactive(dir, command string) ([]byte, error) {
+	s.commands = append(s.commands, command)
+	return []byte(s.out), s.err
+}
+
+// countingWriter は端末への書き込みの回数を数える ScreenWriter
+type countingWriter struct {
+	writes int
+}
+
+func (w *countingWriter) Write(s string) error {
+	w.writes++
+	return nil
+}
+
+func TestShellCommand_NoRedrawWhileTerminalHandedOver(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"x"}, commandLine("!vi")...)
+	w := &countingWriter{}
+	controller.screen = screen.NewScreen(contents.NewBuilder(), w, contents.NewMessage(""), cursor.NewCursor(), 24, 80)
+	controller.shell = &fakeShell{}
+	writesDuring := -1
+	controller.SetTerminalSuspender(func(run func() error) error {
+		// ほかの goroutine からの再描画の代わりに、コマンドの実行中に直接再描画する
+		before := w.writes
+		assert.NoError(t, controller.RefreshScreen())
+		writesDuring = w.writes - before
+		return run()
+	})
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 0, writesDuring, "nothing is drawn over the command")
+	before := w.writes
+	assert.NoError(t, controller.RefreshScreen())
+	assert.Greater(t, w.writes, before, "drawing resumes after the command returns")
+}
+
+func TestShellCommandLine(t *testing.T) {
+	tests := []struct {
+		line, name, command string
+	}{
+		{`!grep -n "a b" *.go`, "shell", `grep -n "a b" *.go`},
+		{"r! date", "shell-insert", "date"},
+		{"|sort -u", "shell-filter", "sort -u"},
+		{`shell-filter tr "a" "b"`, "shell-filter", `tr "a" "b"`},
+		{"!", "shell", ""},
+	}
+	for _, tt := range tests {
+		name, command, ok := shellCommandLine(tt.line)
+		assert.True(t, ok, tt.line)
+		assert.Equal(t, tt.name, name, tt.line)
+		assert.Equal(t, tt.command, command, tt.line)
+	}
+	_, _, ok := shellCommandLine("set tabwidth=2")
+	assert.False(t, ok)
+}
+
+func TestShellCommand_ShowsOutputAndInserts(t *testing.T) {
+	events := commandLine("!ls")
+	events = append(events, special(key.KeyArrowDown), char('i'))
+	controller, c := newKeyInputController(t, []string{"x"}, events...)
+	sh := &fakeShell{out: "a.go\nb.go\n"}
+	controller.shell = sh
+	suspended := false
+	controller.SetTerminalSuspender(func(run func() error) error {
+		suspended = true
+		return run()
+	})
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, suspended, "the terminal is handed to the command")
+	assert.Equal(t, []string{"ls"}, sh.commands)
+	assert.False(t, controller.screen.HasOverlay())
+	assert.Equal(t, []string{"a.go", "b.gox"}, c.GetAllLines())
+}
+
+func TestShellInsertCommand(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"ab"}, commandLine("r!date")...)
+	sh := &fakeShell{out: "today\n"}
+	controller.shell = sh
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"date"}, sh.commands)
+	assert.Nil(t, sh.stdin)
+	assert.Equal(t, []string{"atodayb"}, c.GetAllLines())
+}
+
+func TestShellFilterCommand(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"c", "b", "a", "end"})
+	sh := &fakeShell{out: "a\nb\nc\n"}
+	controller.shell = sh
+	assert.NoError(t, controller.Script().Select(Range{Start: contents.Position{}, End: contents.Position{X: 1, Y: 2}}))
+
+	assert.NoError(t, controller.runCommandLine("|sort"))
+	assert.Equal(t, "c\nb\na\n", string(sh.stdin))
+	assert.Equal(t, []string{"a", "b", "c", "end"}, c.GetAllLines())
+}
+
+func TestShellFilterCommand_KeepsTextOnFailure(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one", "two"})
+	sh := &fakeShell{err: errors.New("sort: exit status 2")}
+	controller.shell = sh
+
+	// 選択していなければ実行しない
+	assert.NoError(t, controller.runCommandLine("|sort"))
+	assert.Empty(t, sh.commands)
+
+	assert.NoError(t, controller.Script().Select(Range{Start: contents.Position{}, End: contents.Position{X: 3, Y: 1}}))
+	assert.NoError(t, controller.runCommandLine("|sort"))
+	assert.Equal(t, []string{"sort"}, sh.commands)
+	assert.Equal(t, []string{"one", "two"}, c.GetAllLines())
+}
@@ -0,0 +1,16 @@
+package controller
+
+import "github.com/wasya-io/go-kilo/app/entity/event"
+
+// toggleWordWrap は長い行の折り返し表示を切り替える
+func (c *Controller) toggleWordWrap() {
+	wrap := !c.screen.Wrap()
+	c.screen.SetWrap(wrap)
+	c.updateScroll()
+	if wrap {
+		c.setStatusMessage("Word wrap on")
+	} else {
+		c.setStatusMessage("Word wrap off")
+	}
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
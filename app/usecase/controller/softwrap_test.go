@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestToggleWordWrap_MovesByVisualLine(t *testing.T) {
+	wrapKey := key.KeyEvent{Type: key.KeyEventChar, Rune: 'z', Modifiers: key.ModAlt}
+	long := strings.Repeat("word ", 30)
+	controller, _ := newKeyInputController(t, []string{long, "next"},
+		wrapKey, special(key.KeyArrowDown), wrapKey,
+	)
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, controller.screen.Wrap())
+
+	// 80 桁で折り返した2つ目の表示行へ移動し、次の行には移らない
+	assert.NoError(t, controller.Process())
+	pos := controller.screen.GetCursor().ToPosition()
+	assert.Equal(t, 0, pos.Y)
+	assert.Equal(t, 80, pos.X)
+
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.screen.Wrap())
+}
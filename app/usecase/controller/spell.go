@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/boundary/spellcheck"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/filetype"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/entity/spell"
+)
+
+// spellFooter は綴りの候補の一覧の操作の案内
+const spellFooter = "Up/Down: move  Enter: replace  Esc: close"
+
+// spellFileTypes は綴りの誤りに下線を引くファイルの種類
+var spellFileTypes = map[string]bool{"Markdown": true, filetype.Text: true}
+
+// spellState は綴りの確認の状態
+// 描画はタイマーの goroutine からも行うため、確認の結果は mutex で保護する
+type spellState struct {
+	start   func() (spellcheck.Checker, error) // 初めて確認するときに実装を用意する（nil なら確認しない）
+	mutex   sync.Mutex
+	started bool
+	checker spellcheck.Checker // nil なら確認しない（用意や確認に失敗した場合も nil にする）
+	known   map[string]bool    // 確認した語と、綴りが正しいかどうか
+}
+
+// SetSpellCheck は綴りの確認に使う実装を設定する。mode が spellcheck.ModeOff なら確認しない
+// aspell の起動や単語の一覧の読み込みは、Markdown やテキストのファイルを初めて表示するときまで遅らせる
+func (c *Controller) SetSpellCheck(mode, dictionary, language string) {
+	s := &c.spelling
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.start, s.started, s.checker, s.known = nil, false, nil, nil
+	c.forgetMisspellings()
+	if mode != spellcheck.ModeOff {
+		s.start = func() (spellcheck.Checker, error) {
+			return spellcheck.Select(mode, dictionary, language, c.runner)
+		}
+	}
+}
+
+// spellChecker は綴りの確認に使う実装を返す。確認しない場合は nil を返す
+// 初めて呼び出したときに用意し、失敗した場合はメッセージバーに知らせて以後は確認しない
+func (c *Controller) spellChecker() spellcheck.Checker {
+	s := &c.spelling
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.started && s.start != nil {
+		s.started = true
+		checker, err := s.start()
+		if err != nil {
+			c.screen.SetMessageFor(contents.Persistent, "Spell check is off: %v", err)
+			return nil
+		}
+		s.checker = checker
+	}
+	return s.checker
+}
+
+// misspelled は word の綴りが誤っているかどうかを返す。確認した結果は覚えておく
+// 確認に失敗した場合（aspell が終了したなど）は、メッセージバーに知らせて以後は確認しない
+func (c *Controller) misspelled(checker spellcheck.Checker, word string) bool {
+	s := &c.spelling
+	s.mutex.Lock()
+	correct, known := s.known[word]
+	s.mutex.Unlock()
+	if known {
+		return !correct
+	}
+
+	correct, err := checker.Check(word)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil {
+		if s.checker != nil {
+			s.checker = nil
+			c.screen.SetMessageFor(contents.Persistent, "Spell check stopped: %v", err)
+		}
+		return false
+	}
+	if s.known == nil {
+		s.known = make(map[string]bool)
+	}
+	s.known[word] = correct
+	return !correct
+}
+
+// updateMisspellings は画面に表示している行の綴りの誤りに下線を引く（Markdown とテキストのファイルのみ）
+// 結果は行のメタデータに置くので行と共に移動し、確認し直すのは内容を編集した行と初めて表示した行だけになる
+func (c *Controller) updateMisspellings() {
+	var checker spellcheck.Checker
+	if spellFileTypes[screen.FileTypeOf(c.contents, c.fileManager.GetFilename())] {
+		checker = c.spellChecker()
+	}
+	if checker == nil {
+		c.contents.ClearLineMeta(screen.MisspellingsMeta)
+		return
+	}
+
+	_, top := c.screen.GetOffset()
+	bottom := min(top+c.screen.TextRows(), c.contents.GetLineCount())
+	for y := top; y < bottom; y++ {
+		if _, checked := c.contents.LineMeta(screen.MisspellingsMeta, y); checked {
+			continue
+		}
+		highlights := []screen.Highlight{}
+		for _, w := range spell.Words(c.contents.GetContentLine(y)) {
+			if c.misspelled(checker, w.Text) {
+				highlights = append(highlights, screen.Highlight{Line: y, Col: w.Col, Length: len([]rune(w.Text)), Misspelled: true})
+			}
+		}
+		c.contents.SetLineMeta(screen.MisspellingsMeta, y, highlights)
+	}
+}
+
+// forgetMisspellings は開いている全てのバッファの綴りの確認の結果を破棄し、次の描画で確認し直させる
+func (c *Controller) forgetMisspellings() {
+	if c.windows == nil {
+		return
+	}
+	for _, b := range c.windows.Buffers() {
+		b.Contents.ClearLineMeta(screen.MisspellingsMeta)
+	}
+}
+
+// spellSuggest はカーソル位置の単語の綴りの候補を一覧から選ばせ、選んだ候補に置き換える
+// 一覧の最後の項目を選ぶと、エディタを終了するまでその単語を正しい綴りとして扱う
+func (c *Controller) spellSuggest() error {
+	checker := c.spellChecker()
+	if checker == nil {
+		c.setStatusMessage("Spell check is off (set SPELL_CHECK)")
+		return nil
+	}
+	pos := c.screen.GetCursor().ToPosition()
+	w, ok := spell.At(c.contents.GetContentLine(pos.Y), pos.X)
+	if !ok {
+		c.setStatusMessage("No word under cursor")
+		return nil
+	}
+	if !c.misspelled(checker, w.Text) {
+		c.setStatusMessage("%q is spelled correctly", w.Text)
+		return nil
+	}
+	suggestions, err := checker.Suggest(w.Text)
+	if err != nil {
+		c.setErrorMessage("Spell check failed: %v", err)
+		return nil
+	}
+
+	lines := append(suggestions[:len(suggestions):len(suggestions)], fmt.Sprintf("Accept %q for this session", w.Text))
+	selected, ok, err := c.pickFromList(fmt.Sprintf("Spelling: %s", w.Text), lines, spellFooter)
+	if err != nil || !ok {
+		return err
+	}
+	if selected == len(suggestions) {
+		c.spelling.mutex.Lock()
+		c.spelling.known[w.Text] = true
+		c.spelling.mutex.Unlock()
+		c.forgetMisspellings()
+		c.setStatusMessage("Accepted %q", w.Text)
+		return nil
+	}
+
+	script := c.Script()
+	start := contents.Position{X: w.Col, Y: pos.Y}
+	end := contents.Position{X: w.Col + len([]rune(w.Text)), Y: pos.Y}
+	if err := script.Select(Range{Start: start, End: end}); err != nil {
+		return err
+	}
+	return script.Insert(suggestions[selected])
+}
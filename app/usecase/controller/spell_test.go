@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/boundary/spellcheck"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+// withWordList は決まった単語の一覧で綴りを確認するよう設定する
+func withWordList(controller *Controller, words ...string) {
+	controller.spelling.start = func() (spellcheck.Checker, error) {
+		return spellcheck.NewWordList(words), nil
+	}
+}
+
+func TestUpdateMisspellings_ChecksVisibleWords(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"teh cat", "fine"})
+	withWordList(controller, "the", "cat", "fine")
+
+	controller.updateMisspellings()
+	assert.Equal(t, map[string]bool{"teh": false, "cat": true, "fine": true}, controller.spelling.known)
+}
+
+func TestUpdateMisspellings_FollowEdits(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"teh cat", "fine"})
+	withWordList(controller, "the", "cat", "fine")
+	underlined := func(y int) int {
+		v, ok := c.LineMeta(screen.MisspellingsMeta, y)
+		if !ok {
+			return -1
+		}
+		return len(v.([]screen.Highlight))
+	}
+
+	controller.updateMisspellings()
+	assert.Equal(t, 1, underlined(0))
+	assert.Equal(t, 0, underlined(1), "a checked line without errors is kept as checked")
+
+	c.InsertNewline(contents.Position{X: 0, Y: 0}, 0)
+	assert.Equal(t, 1, underlined(1), "the result moves with an inserted line")
+
+	c.DeleteChar(contents.Position{X: 3, Y: 1})
+	c.InsertChar(contents.Position{X: 1, Y: 1}, 'h')
+	assert.Equal(t, -1, underlined(1), "editing a line drops its result")
+	controller.updateMisspellings()
+	assert.Equal(t, 0, underlined(1), "an edited line is checked again")
+}
+
+func TestSpellSuggest_ReplacesWord(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"teh cat"}, special(key.KeyEnter))
+	withWordList(controller, "the", "cat")
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.spellSuggest())
+	assert.Equal(t, []string{"the cat"}, c.GetAllLines())
+}
+
+func TestSpellSuggest_AcceptsWord(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"teh cat"}, special(key.KeyArrowDown), special(key.KeyEnter))
+	withWordList(controller, "the", "cat")
+
+	assert.NoError(t, controller.spellSuggest())
+	assert.Equal(t, []string{"teh cat"}, c.GetAllLines())
+	assert.False(t, controller.misspelled(controller.spellChecker(), "teh"), "an accepted word is no longer underlined")
+}
+
+func TestSpellChecker_ReportsFailureOnce(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"teh"})
+	controller.spelling.start = func() (spellcheck.Checker, error) {
+		return nil, errors.New("aspell not found")
+	}
+
+	assert.Nil(t, controller.spellChecker())
+	assert.True(t, controller.screen.DismissMessage(), "a failure must be reported")
+	assert.Nil(t, controller.spellChecker())
+	assert.False(t, controller.screen.DismissMessage(), "the checker is not started again")
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// SetProcessStopper はエディタを一時停止する関数を設定する
+// stop は端末を元の状態に戻してプロセスを停止し、再開したら Raw モードに戻してから返る
+func (c *Controller) SetProcessStopper(stop func() error) {
+	c.stopProcess = stop
+}
+
+// Suspend はエディタを一時停止してシェルに戻る。シェルの fg で再開したら画面全体を描き直す
+// SIGTSTP を受け取ったときにも呼び出す
+func (c *Controller) Suspend() {
+	if c.stopProcess == nil {
+		c.setStatusMessage("Suspend is not available")
+		return
+	}
+	if err := c.stopProcess(); err != nil {
+		c.setErrorMessage("Could not suspend: %v", err)
+	}
+	// 停止中にシェルが端末に出力しているため、差分ではなく全体を描き直す
+	c.screen.Invalidate()
+	c.eventBus.Publish(event.NewRefreshEvent())
+}
+
+// handOverTerminal は Raw モードを解除して、run の間だけ外部のコマンドに端末を使わせる
+// その間はほかの goroutine からの再描画を止め、戻ったらコマンドの出力を消すよう全体を描き直す準備をする
+func (c *Controller) handOverTerminal(run func() error) error {
+	c.drawMutex.Lock()
+	c.terminalHandedOver = true
+	c.drawMutex.Unlock()
+
+	err := c.suspendTerminal(run)
+
+	c.drawMutex.Lock()
+	c.terminalHandedOver = false
+	c.drawMutex.Unlock()
+	// コマンドが端末に出力しているため、差分ではなく全体を描き直す
+	c.screen.Invalidate()
+	return err
+}
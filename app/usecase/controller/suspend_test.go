@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestSuspend(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"x"}, ctrlKey(key.KeyCtrlK), char('z'))
+	stopped := 0
+	controller.SetProcessStopper(func() error {
+		stopped++
+		return nil
+	})
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 1, stopped)
+	assert.False(t, controller.screen.DismissMessage())
+
+	controller.SetProcessStopper(func() error { return errors.New("operation not permitted") })
+	controller.Suspend()
+	assert.True(t, controller.screen.DismissMessage(), "the failure is reported")
+}
+
+func TestSuspend_NotAvailable(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"x"})
+	controller.Suspend()
+	assert.True(t, controller.screen.DismissMessage())
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+// applyTabs は開いているバッファをタブバーに反映する
+// タブバーは設定で有効にしていて、2つ以上のバッファを開いている場合にだけ画面の上端の1行に表示する
+func (c *Controller) applyTabs() {
+	buffers := c.windows.Buffers()
+	if !c.tabBar || len(buffers) < 2 {
+		c.windows.SetReservedTop(0)
+		c.screen.SetTabs(nil, 0)
+		return
+	}
+	focused := c.windows.Focused().Buffer
+	tabs := make([]screen.Tab, len(buffers))
+	for i, b := range buffers {
+		buffer, filename := b.Contents, b.FileManager.GetFilename()
+		if b == focused {
+			buffer, filename = c.contents, c.fileManager.GetFilename()
+		}
+		name := "[No Name]"
+		if filename != "" {
+			name = filepath.Base(filename)
+		}
+		tabs[i] = screen.Tab{Name: name, Dirty: buffer.IsDirty()}
+	}
+	c.windows.SetReservedTop(1)
+	c.screen.SetTabs(tabs, c.windows.BufferIndex(focused))
+}
+
+// openBufferIndex は filename を開いているバッファの位置を返す。開いていなければ -1
+func (c *Controller) openBufferIndex(filename string) int {
+	target, err := filepath.Abs(filename)
+	if err != nil {
+		return -1
+	}
+	focused := c.windows.Focused().Buffer
+	for i, b := range c.windows.Buffers() {
+		name := b.FileManager.GetFilename()
+		if b == focused {
+			name = c.fileManager.GetFilename()
+		}
+		if name == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(name); err == nil && abs == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextBuffer はフォーカスのあるウィンドウに次に開いたバッファを表示する
+func (c *Controller) nextBuffer() {
+	c.cycleBuffer(1)
+}
+
+// previousBuffer はフォーカスのあるウィンドウに前に開いたバッファを表示する
+func (c *Controller) previousBuffer() {
+	c.cycleBuffer(-1)
+}
+
+// cycleBuffer は開いているバッファを delta だけ順に進めて表示する。端では反対側に戻る
+// 読み込み中の行が切り替え後のバッファに追加されないよう、読み込みの完了を待ってから切り替える
+func (c *Controller) cycleBuffer(delta int) {
+	buffers := c.windows.Buffers()
+	if len(buffers) < 2 {
+		c.setStatusMessage("There is only one buffer")
+		return
+	}
+	c.waitForLoad()
+	index := c.windows.BufferIndex(c.windows.Focused().Buffer)
+	c.eventBus.Publish(event.NewShowBufferEvent((index + delta + len(buffers)) % len(buffers)))
+}
+
+// closeBuffer はフォーカスのあるウィンドウのバッファを閉じる
+func (c *Controller) closeBuffer() {
+	c.waitForLoad()
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferCloseBuffer, 0))
+}
+
+// clickTab はタブバーの左端から col 桁目のタブのバッファを表示する
+func (c *Controller) clickTab(col int) error {
+	index := c.screen.TabAt(col)
+	if index < 0 || index == c.windows.BufferIndex(c.windows.Focused().Buffer) {
+		return nil
+	}
+	c.waitForLoad()
+	c.eventBus.Publish(event.NewShowBufferEvent(index))
+	return nil
+}
+
+// performShowBuffer は index の位置の開いているバッファをフォーカスのあるウィンドウに表示する
+// バッファを最後に表示していた位置に戻す
+func (c *Controller) performShowBuffer(index int) {
+	buffers := c.windows.Buffers()
+	w := c.windows.Focused()
+	if index < 0 || index >= len(buffers) || buffers[index] == w.Buffer {
+		return
+	}
+	c.storeWindow()
+	showBuffer(w, buffers[index])
+	c.loadWindow(w)
+	c.applyLayout()
+	c.updateScroll()
+}
+
+// performCloseBuffer はフォーカスのあるウィンドウのバッファを閉じ、そのバッファを表示していたウィンドウには隣のバッファを表示する
+// 保存していない変更は失われるため、変更のあるバッファと最後の1つは閉じない
+func (c *Controller) performCloseBuffer() {
+	if len(c.windows.Buffers()) < 2 {
+		c.setStatusMessage("Cannot close the only buffer")
+		return
+	}
+	if c.contents.IsDirty() {
+		c.setStatusMessage("Save the changes before closing the buffer")
+		return
+	}
+	c.storeWindow()
+	closed := c.windows.Focused().Buffer
+	c.rememberPositions([]*window.Buffer{closed}, time.Now())
+	index := c.windows.BufferIndex(closed)
+	c.windows.RemoveBuffer(closed)
+	buffers := c.windows.Buffers()
+	next := buffers[min(index, len(buffers)-1)]
+	for _, w := range c.windows.AllWindows() {
+		if w.Buffer == closed {
+			showBuffer(w, next)
+		}
+	}
+	c.loadWindow(c.windows.Focused())
+	c.applyLayout()
+	c.updateScroll()
+	c.releaseBuffer(closed)
+	c.closeDocument(closed.FileManager.GetFilename())
+}
+
+// showBuffer は w に b を、b を最後に表示していた位置で表示する
+func showBuffer(w *window.Window, b *window.Buffer) {
+	w.Buffer = b
+	w.Cursor, w.RowOffset, w.ColOffset = b.Cursor, b.RowOffset, b.ColOffset
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestTabBar_SwitchAndCloseBuffers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("other\n"), 0644))
+
+	previous := key.KeyEvent{Type: key.KeyEventChar, Rune: ',', Modifiers: key.ModAlt}
+	controller, c := newKeyInputController(t, []string{"one", "two"},
+		previous, click(0, 12), ctrlKey(key.KeyCtrlK), char('q'),
+	)
+	controller.screen.SetCursorPosition(1, 1)
+
+	// 名前のあるバッファを表示している場合は、新しいバッファで開いて元のバッファをタブに残す
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	controller.applyLayout()
+	assert.Len(t, controller.windows.Buffers(), 2)
+	assert.Equal(t, "other", controller.contents.GetContentLine(0))
+	assert.Equal(t, 1, controller.windows.Regions(24, 80)[0].Top, "the tab bar takes the top row")
+
+	// 前のバッファに戻ると最後のカーソル位置も戻る
+	assert.NoError(t, controller.Process())
+	assert.Same(t, c, controller.contents)
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+
+	// " test.txt " の次のタブをクリックする
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "other", controller.contents.GetContentLine(0))
+
+	// 既に開いているファイルは同じバッファに切り替える
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	assert.Len(t, controller.windows.Buffers(), 2)
+
+	// バッファを閉じると隣のバッファを表示し、タブバーを消す
+	assert.NoError(t, controller.Process())
+	assert.Len(t, controller.windows.Buffers(), 1)
+	assert.Same(t, c, controller.contents)
+	assert.Equal(t, 22, controller.screen.TextRows())
+}
+
+func TestTabBar_KeepsDirtyBuffer(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one"},
+		char('x'), ctrlKey(key.KeyCtrlK), char('q'),
+	)
+	assert.NoError(t, controller.Process())
+	controller.performDetachBuffer()
+	assert.True(t, controller.hasUnsavedChanges(), "changes in a buffer that is not shown still count")
+
+	controller.performShowBuffer(0)
+	assert.Same(t, c, controller.contents)
+	assert.NoError(t, controller.Process())
+	assert.Len(t, controller.windows.Buffers(), 2, "a buffer with unsaved changes must not be closed")
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// traceLimit はイベントバスの記録として残すイベントの数
+const traceLimit = 10000
+
+// traceWriter はイベントの記録を書き出し、書き出したパスを返す
+type traceWriter func(tracer *event.Tracer, format string, now time.Time) (string, error)
+
+// startEventTrace はイベントバスの記録を開始する
+func (c *Controller) startEventTrace() {
+	if c.eventBus.Tracer() == nil {
+		c.eventBus.SetTracer(event.NewTracer(traceLimit))
+	}
+}
+
+// toggleEventTrace はイベントバスの記録を開始する。記録中の場合は止めてファイルに書き出す
+func (c *Controller) toggleEventTrace() {
+	if c.eventBus.Tracer() == nil {
+		c.startEventTrace()
+		c.setStatusMessage("Event tracing started (run toggle-event-trace again to write the trace)")
+		return
+	}
+	path, err := c.WriteEventTrace()
+	if err != nil {
+		c.setErrorMessage("%v", err)
+		return
+	}
+	c.setStatusMessage("Event trace written to %s", path)
+}
+
+// WriteEventTrace はイベントバスの記録を止めて、設定された形式でファイルに書き出す
+// 記録していない場合は何もせず空のパスを返す
+func (c *Controller) WriteEventTrace() (string, error) {
+	tracer := c.eventBus.Tracer()
+	if tracer == nil {
+		return "", nil
+	}
+	c.eventBus.SetTracer(nil)
+	path, err := c.writeTrace(tracer, c.traceFormat, time.Now())
+	if err != nil {
+		c.logger.Log("error", fmt.Sprintf("Failed to write event trace: %v", err))
+		return "", err
+	}
+	return path, nil
+}
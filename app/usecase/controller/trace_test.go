@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+func TestToggleEventTrace(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a"})
+	var written []event.TraceRecord
+	var format string
+	controller.writeTrace = func(tracer *event.Tracer, f string, now time.Time) (string, error) {
+		written, format = tracer.Records(), f
+		return "/tmp/trace.mmd", nil
+	}
+	conf := config.Default()
+	conf.EventTrace = "mermaid"
+	conf.GitSigns = false // git を調べる goroutine の再描画を記録に含めない
+	controller.ApplyConfig(conf)
+	assert.NotNil(t, controller.eventBus.Tracer(), "tracing starts when enabled in the config")
+
+	controller.insertChar('x')
+	assert.NoError(t, controller.commands.Execute("toggle-event-trace", nil))
+	assert.Nil(t, controller.eventBus.Tracer())
+	assert.Equal(t, "mermaid", format)
+	if assert.NotEmpty(t, written) {
+		assert.Equal(t, event.TypeBuffer, written[0].Type)
+		assert.Equal(t, "buffer", written[0].Handlers[0].Name)
+	}
+
+	// もう一度実行すると記録を再開する
+	assert.NoError(t, controller.commands.Execute("toggle-event-trace", nil))
+	assert.NotNil(t, controller.eventBus.Tracer())
+}
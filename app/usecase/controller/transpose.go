@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+)
+
+// transposeChars はカーソルの前後の文字を入れ替える
+func (c *Controller) transposeChars() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferTransposeChars, 0))
+}
+
+// transposeLines はカーソル行と前の行を入れ替える
+func (c *Controller) transposeLines() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferTransposeLines, 0))
+}
+
+// performTransposeChars はカーソルの直前と直後の文字を入れ替え、カーソルを1文字進める
+// 行末では直前の2文字を入れ替える。文字単位（ルーン単位）で扱うため全角文字も入れ替えられる
+func (c *Controller) performTransposeChars() {
+	pos := c.screen.GetCursor().ToPosition()
+	runes := []rune(c.contents.GetContentLine(pos.Y))
+	x := min(pos.X, len(runes))
+	if x == len(runes) {
+		x--
+	}
+	if x < 1 {
+		c.setStatusMessage("Nothing to transpose")
+		return
+	}
+	c.replaceRange(contents.Position{X: x - 1, Y: pos.Y}, contents.Position{X: x + 1, Y: pos.Y},
+		[]string{string(runes[x]) + string(runes[x-1])})
+}
+
+// performTransposeLines はカーソル行と前の行を入れ替える
+// カーソルは入れ替えた行と一緒に移動するため、繰り返すと行を上に移動できる
+func (c *Controller) performTransposeLines() {
+	pos := c.screen.GetCursor().ToPosition()
+	if pos.Y < 1 || pos.Y >= c.contents.GetLineCount() {
+		c.setStatusMessage("Nothing to transpose")
+		return
+	}
+	current := c.contents.GetContentLine(pos.Y)
+	if !c.swapLines(pos.Y - 1) {
+		return
+	}
+	c.screen.SetCursorPosition(min(pos.X, len([]rune(current))), pos.Y-1)
+	c.updateScroll()
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestTransposeChars(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"aいう", "x"},
+		ctrlKey(key.KeyCtrlT), ctrlKey(key.KeyCtrlT), ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(1, 0)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "いaう", c.GetContentLine(0))
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().X)
+
+	// 繰り返すと文字を右に運ぶ。行末では直前の2文字を入れ替える
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "いうa", c.GetContentLine(0))
+	assert.Equal(t, 3, controller.screen.GetCursor().ToPosition().X)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, "いaう", c.GetContentLine(0))
+}
+
+func TestTransposeLines(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one", "two", "three"},
+		key.KeyEvent{Type: key.KeyEventChar, Rune: 't', Modifiers: key.ModAlt},
+		key.KeyEvent{Type: key.KeyEventChar, Rune: 't', Modifiers: key.ModAlt},
+		ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(4, 2)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "three", "two"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().Y)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"three", "one", "two"}, c.GetAllLines())
+
+	// 先頭行では入れ替えない
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"one", "three", "two"}, c.GetAllLines())
+}
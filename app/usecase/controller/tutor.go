@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/usecase/tutor"
+)
+
+// StartTutorial はチュートリアルを開始する
+// 練習用バッファは path に書き出して開くため、保存の練習もできる
+func (c *Controller) StartTutorial(t *tutor.Tutor, path string) error {
+	c.tutor = t
+	c.tutorPath = path
+	return c.loadLesson(t.Begin(c.tutorState()))
+}
+
+// loadLesson は練習用バッファの内容をファイルに書き出して開く
+func (c *Controller) loadLesson(lines []string) error {
+	if err := os.WriteFile(c.tutorPath, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		return fmt.Errorf("failed to write tutorial file: %w", err)
+	}
+	if err := c.OpenFile(c.tutorPath); err != nil {
+		return err
+	}
+	// 説明文を誤って消さないよう、見出しと説明文は編集できないようにする
+	c.contents.ProtectLines(0, c.tutor.ReadOnlyLines())
+	c.screen.SetCursorPosition(0, 0)
+	c.setStatusMessage("%s", c.tutor.Title())
+	return nil
+}
+
+// tutorState はレッスンの達成判定に使う状態を返す
+func (c *Controller) tutorState() tutor.State {
+	pos := c.screen.GetCursor().ToPosition()
+	return tutor.State{
+		Lines: c.contents.GetAllLines(),
+		X:     pos.X,
+		Y:     pos.Y,
+		Dirty: c.contents.IsDirty(),
+		Saves: c.saveCount,
+	}
+}
+
+// createTutorHandler はキー入力の処理後にレッスンの達成を判定するハンドラーを作成する
+func (c *Controller) createTutorHandler() event.Handler {
+	return event.NewSingleTypeHandler(event.TypeKeyHandled, func(e event.Event) (bool, error) {
+		if c.tutor == nil || c.tutor.Finished() {
+			return false, nil
+		}
+		if !c.tutor.Update(c.tutorState()) {
+			return true, nil
+		}
+		if err := c.loadLesson(c.tutor.Text()); err != nil {
+			c.setErrorMessage("Tutorial error: %v", err)
+		}
+		return true, nil
+	})
+}
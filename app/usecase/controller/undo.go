@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// undoLimit は取り消せる操作の最大数
+const undoLimit = 1000
+
+// recordEdit はバッファに対して行った編集を取り消し用の履歴に記録する
+func (c *Controller) recordEdit(start contents.Position, removed, inserted []string) {
+	c.history.Record(&command.TextEdit{Buffer: c.contents, Start: start, Removed: removed, Inserted: inserted})
+}
+
+// undo は直前の編集を取り消す
+// 入力中の文字の反映より先に取り消さないよう、編集と同じくイベント経由で処理する
+func (c *Controller) undo() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferUndo, 0))
+}
+
+// redo は取り消した編集をやり直す
+func (c *Controller) redo() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferRedo, 0))
+}
+
+func (c *Controller) performUndo() {
+	cmd, err := c.history.Undo()
+	if err != nil {
+		c.reportEditError(err)
+		return
+	}
+	if cmd == nil {
+		c.setStatusMessage("Nothing to undo")
+		return
+	}
+	switch edit := cmd.(type) {
+	case *command.TextEdit:
+		c.afterHistoryChange(edit)
+	case *command.LineEdit:
+		c.afterLineEdit(edit.Before)
+	}
+}
+
+func (c *Controller) performRedo() {
+	cmd, err := c.history.Redo()
+	if err != nil {
+		c.reportEditError(err)
+		return
+	}
+	if cmd == nil {
+		c.setStatusMessage("Nothing to redo")
+		return
+	}
+	switch edit := cmd.(type) {
+	case *command.TextEdit:
+		c.afterHistoryChange(edit)
+	case *command.LineEdit:
+		c.afterLineEdit(edit.After)
+	}
+}
+
+// afterHistoryChange は取り消し・やり直しで編集を戻した（やり直した）後に、
+// カーソルを追従させて選択を解除し、保存した時点に戻った場合は未保存の状態を解除する
+func (c *Controller) afterHistoryChange(edit *command.TextEdit) {
+	c.clearSelection()
+
+	pos := edit.Cursor()
+	c.screen.SetCursorPosition(pos.X, pos.Y)
+	c.contents.SetDirty(!c.history.AtSavePoint())
+	c.updateScroll()
+}
+
+// afterLineEdit は行数の変わらない編集を取り消し・やり直した後に、カーソルを pos に置いて選択を解除し、
+// 保存した時点に戻った場合は未保存の状態を解除する
+func (c *Controller) afterLineEdit(pos contents.Position) {
+	c.clearSelection()
+	c.screen.SetCursorPosition(pos.X, pos.Y)
+	c.contents.SetDirty(!c.history.AtSavePoint())
+	c.updateScroll()
+}
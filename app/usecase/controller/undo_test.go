@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+)
+
+func TestUndoRedo(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"  foo"},
+		char('x'), char('y'), key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEnter},
+		ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlY),
+	)
+	controller.screen.SetCursorPosition(2, 0)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	assert.Equal(t, []string{"  xy", "  foo"}, c.GetAllLines())
+
+	// 改行と、続けて入力した文字を順に取り消す
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"  xyfoo"}, c.GetAllLines())
+	assert.Equal(t, 4, controller.screen.GetCursor().ToPosition().X)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"  foo"}, c.GetAllLines())
+	assert.False(t, c.IsDirty(), "undoing every edit returns to the unmodified state")
+
+	// 取り消す操作がなければ何もしない
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"  foo"}, c.GetAllLines())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"  xyfoo"}, c.GetAllLines())
+	assert.Equal(t, 4, controller.screen.GetCursor().ToPosition().X)
+	assert.True(t, c.IsDirty())
+}
+
+func TestUndo_DeleteAndJoin(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"ab", "cd"},
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyBackspace},
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyBackspace},
+		ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlZ),
+	)
+	controller.screen.SetCursorPosition(1, 1)
+
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"abd"}, c.GetAllLines())
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"ab", "d"}, c.GetAllLines())
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, []string{"ab", "cd"}, c.GetAllLines())
+	assert.Equal(t, 1, controller.screen.GetCursor().ToPosition().X)
+}
+
+func TestUndo_BookmarksFollowLines(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"a", "b", "c"},
+		key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEnter},
+		ctrlKey(key.KeyCtrlZ), ctrlKey(key.KeyCtrlY),
+	)
+	controller.screen.SetCursorPosition(0, 1)
+	controller.bookmarks.Set(1, "b")
+	controller.bookmarks.Set(2, "c")
+
+	// 行頭での改行、その取り消しとやり直しに、ブックマークは行の内容と共に移動する
+	for _, want := range [][]int{{2, 3}, {1, 2}, {2, 3}} {
+		assert.NoError(t, controller.Process())
+		lines := []int{}
+		for _, m := range controller.bookmarks.List() {
+			lines = append(lines, m.Line)
+		}
+		assert.Equal(t, want, lines)
+	}
+}
@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/bookmark"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+// splitWindow は画面を上下に分割する
+func (c *Controller) splitWindow() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferSplitHorizontal, 0))
+}
+
+// splitWindowRight は画面を左右に分割する
+func (c *Controller) splitWindowRight() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferSplitVertical, 0))
+}
+
+// otherWindow は次のウィンドウにフォーカスを移す
+// 読み込み中の行が切り替え後のバッファに追加されないよう、読み込みの完了を待ってから切り替える
+func (c *Controller) otherWindow() {
+	c.waitForLoad()
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferFocusNext, 0))
+}
+
+// closeOtherWindows はフォーカスのあるウィンドウ以外を閉じる
+func (c *Controller) closeOtherWindows() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferCloseOthers, 0))
+}
+
+// zoomWindow はフォーカスのあるウィンドウだけを表示する。もう一度呼び出すと元の分割に戻す
+func (c *Controller) zoomWindow() {
+	c.eventBus.Publish(event.NewBufferEvent(event.BufferZoomWindow, 0))
+}
+
+// enlargeWindow はフォーカスのあるウィンドウを1行（左右に分割している場合は1桁）大きくする
+func (c *Controller) enlargeWindow() {
+	c.eventBus.Publish(event.NewResizeWindowEvent(1))
+}
+
+// shrinkWindow はフォーカスのあるウィンドウを1行（左右に分割している場合は1桁）小さくする
+func (c *Controller) shrinkWindow() {
+	c.eventBus.Publish(event.NewResizeWindowEvent(-1))
+}
+
+// performWindow はウィンドウの分割・切り替えを処理する
+func (c *Controller) performWindow(action event.BufferAction) {
+	switch action {
+	case event.BufferSplitHorizontal, event.BufferSplitVertical:
+		split := window.SplitHorizontal
+		if action == event.BufferSplitVertical {
+			split = window.SplitVertical
+		}
+		c.storeWindow()
+		if _, err := c.windows.SplitWindow(split); err != nil {
+			c.setStatusMessage("Cannot split: %v (C-k u to unsplit)", err)
+			return
+		}
+		c.setStatusMessage("Window split: M-o to switch, C-k u to unsplit")
+	case event.BufferFocusNext:
+		if c.windows.Zoomed() {
+			c.setStatusMessage("Window is zoomed: C-w z to restore the split")
+			return
+		}
+		if len(c.windows.Windows()) < 2 {
+			c.setStatusMessage("There is only one window")
+			return
+		}
+		c.storeWindow()
+		c.loadWindow(c.windows.FocusNext())
+	case event.BufferCloseOthers:
+		focused := c.windows.Focused()
+		for _, w := range c.windows.Others() {
+			// 他のウィンドウにしか表示していないバッファの変更は失われるため閉じない
+			if w.Buffer != focused.Buffer && w.Buffer.Contents.IsDirty() {
+				c.setStatusMessage("Save the changes in the other window first")
+				return
+			}
+		}
+		c.windows.CloseOthers()
+	case event.BufferZoomWindow:
+		zoomed, err := c.windows.ToggleZoom()
+		switch {
+		case err != nil:
+			c.setStatusMessage("There is only one window")
+			return
+		case zoomed:
+			c.setStatusMessage("Window zoomed: C-w z to restore the split")
+		default:
+			c.setStatusMessage("Split restored")
+		}
+	}
+	c.applyLayout()
+	c.updateScroll()
+}
+
+// performFocusWindow は index の位置のウィンドウにフォーカスを移す
+func (c *Controller) performFocusWindow(index int) {
+	if index == c.windows.FocusIndex() {
+		return
+	}
+	c.storeWindow()
+	c.loadWindow(c.windows.FocusWindow(index))
+	c.applyLayout()
+	c.updateScroll()
+}
+
+// performResize は区画の大きさを変え、描画に反映する。区画の最小の大きさはウィンドウの管理側で保つ
+func (c *Controller) performResize(action event.BufferAction, size int) {
+	if c.windows.Split() == window.SplitNone {
+		c.setStatusMessage("There is only one window")
+		return
+	}
+	rows, cols := c.screen.GetRowLines(), c.screen.GetColLines()
+	switch action {
+	case event.BufferResizeWindow:
+		c.windows.Resize(c.windows.FocusIndex(), size, rows, cols)
+	case event.BufferMoveDivider:
+		c.windows.MoveDivider(size, rows, cols)
+	}
+	c.applyLayout()
+	c.updateScroll()
+}
+
+// storeWindow はフォーカスのあるウィンドウに現在の編集状態を保存する
+// 表示位置はバッファにも残し、別のバッファから切り替えて戻ったときに使う
+func (c *Controller) storeWindow() {
+	w := c.windows.Focused()
+	b := w.Buffer
+	b.Contents, b.FileManager, b.History, b.Bookmarks = c.contents, c.fileManager, c.history, c.bookmarks
+	pos := c.screen.GetCursor().ToPosition()
+	w.Cursor = contents.Position{X: pos.X, Y: pos.Y}
+	w.ColOffset, w.RowOffset = c.screen.GetOffset()
+	b.Cursor, b.RowOffset, b.ColOffset = w.Cursor, w.RowOffset, w.ColOffset
+}
+
+// loadWindow は w の編集状態をフォーカスのあるウィンドウとして復元する
+// 選択範囲と検索の強調表示はウィンドウをまたいで引き継がない
+func (c *Controller) loadWindow(w *window.Window) {
+	c.contents = w.Buffer.Contents
+	c.fileManager = w.Buffer.FileManager
+	c.history = w.Buffer.History
+	c.bookmarks = w.Buffer.Bookmarks
+	c.clearSelection()
+	c.screen.ClearHighlights()
+	c.screen.SetCursorPosition(w.Cursor.X, w.Cursor.Y)
+	c.screen.SetRowOffset(w.RowOffset)
+	c.screen.SetColOffset(w.ColOffset)
+}
+
+// applyLayout は画面の分割状態を描画に反映する
+// フォーカスのないウィンドウのファイル名は保存で変わることがあるため、描画のたびに呼び出す
+func (c *Controller) applyLayout() {
+	c.applyTabs()
+	if c.windows.Split() == window.SplitNone && c.windows.ReservedTop() == 0 {
+		c.screen.ClearLayout()
+		return
+	}
+	regions := c.windows.Regions(c.screen.GetRowLines(), c.screen.GetColLines())
+	var others []screen.Pane
+	for i, w := range c.windows.Windows() {
+		if i == c.windows.FocusIndex() {
+			continue
+		}
+		buffer, filename := w.Buffer.Contents, w.Buffer.FileManager.GetFilename()
+		if w.Buffer == c.windows.Focused().Buffer {
+			// フォーカスのあるウィンドウと同じバッファは現在の状態を表示する
+			buffer, filename = c.contents, c.fileManager.GetFilename()
+		}
+		others = append(others, screen.Pane{Region: regions[i], Buffer: buffer, Filename: filename, Cursor: w.Cursor, RowOffset: w.RowOffset, ColOffset: w.ColOffset})
+	}
+	c.screen.SetLayout(regions[c.windows.FocusIndex()], others)
+}
+
+// hasUnsavedChanges は開いているいずれかのバッファに保存していない変更があるかどうかを返す
+func (c *Controller) hasUnsavedChanges() bool {
+	if c.contents.IsDirty() {
+		return true
+	}
+	focused := c.windows.Focused().Buffer
+	for _, b := range c.windows.Buffers() {
+		if b != focused && b.Contents.IsDirty() {
+			return true
+		}
+	}
+	return false
+}
+
+// openFileCommand はファイルを開く。名前のない空のバッファ以外を表示している場合は、
+// 新しいバッファを開いてフォーカスのあるウィンドウに割り当てるため、元のバッファはタブに残る
+// 既に開いているファイルはそのバッファに切り替える
+func (c *Controller) openFileCommand(args []string) error {
+	filename, err := c.pathArgument(args, "Open file: ")
+	if err != nil || filename == "" {
+		return err
+	}
+	c.waitForLoad()
+	if i := c.openBufferIndex(filename); i >= 0 {
+		c.eventBus.PublishAndWaitResponse(event.NewShowBufferEvent(i))
+		return nil
+	}
+	// バッファの入れ替えはキー入力による編集と順序が入れ替わらないよう、イベントバスのハンドラーで行う
+	// ファイルの読み込みは残りの読み込みを待つことがあるため、入れ替えた後にメインループで行う
+	focused := c.windows.Focused()
+	previous := c.windows.BufferIndex(focused.Buffer)
+	detached := c.fileManager.GetFilename() != "" || c.contents.IsDirty() || c.windows.Shows(focused.Buffer, focused)
+	if detached {
+		c.eventBus.PublishAndWaitResponse(event.NewDetachBufferEvent())
+	}
+	if err := c.OpenFile(filename); err != nil {
+		c.setErrorMessage("Failed to open %s: %v", filename, err)
+		if detached {
+			// 開けなかった場合は新しいバッファを閉じて元のバッファに戻す
+			c.eventBus.PublishAndWaitResponse(event.NewDiscardBufferEvent(previous))
+		}
+		return nil
+	}
+	c.screen.SetCursorPosition(0, 0)
+	c.screen.SetRowOffset(0)
+	c.screen.SetColOffset(0)
+	c.RestorePosition()
+	c.eventBus.Publish(event.NewRefreshEvent())
+	return nil
+}
+
+// applyReadahead は大きなファイルを開く際の先読みの指示を設定に合わせる
+func (c *Controller) applyReadahead(fm filemanager.FileManager) {
+	if r, ok := fm.(interface{ SetReadahead(bool) }); ok {
+		r.SetReadahead(c.readahead)
+	}
+}
+
+// SetBackupStore は保存で上書きする前の内容のバックアップ先を設定する。nil ならバックアップしない
+// 開いているバッファと、以降に開くバッファの保存に使う
+func (c *Controller) SetBackupStore(store backupfile.Saver) {
+	c.backupStore = store
+	if b, ok := c.fileManager.(interface{ SetBackup(backupfile.Saver) }); ok {
+		b.SetBackup(store)
+	}
+}
+
+// performDetachBuffer はフォーカスのあるウィンドウに空の新しいバッファを割り当て、開いているバッファに加える
+func (c *Controller) performDetachBuffer() {
+	c.storeWindow()
+	buffer := contents.NewContents(c.logger)
+	fm := filemanager.NewFileManager(buffer)
+	fm.SetReadahead(c.readahead)
+	fm.SetBackup(c.backupStore)
+	w := c.windows.Focused()
+	w.Buffer = &window.Buffer{
+		Contents:    buffer,
+		FileManager: fm,
+		History:     command.NewHistory(undoLimit),
+		Bookmarks:   bookmark.New(buffer),
+	}
+	c.windows.AddBuffer(w.Buffer)
+	c.loadWindow(w)
+}
+
+// performDiscardBuffer はフォーカスのあるウィンドウのバッファを開いているバッファから除き、index の位置のバッファを表示し直す
+// 開けなかったファイルのために割り当てた新しいバッファを片付けるため、位置は記録しない
+func (c *Controller) performDiscardBuffer(index int) {
+	buffers := c.windows.Buffers()
+	w := c.windows.Focused()
+	if index < 0 || index >= len(buffers) || buffers[index] == w.Buffer {
+		return
+	}
+	previous := buffers[index]
+	c.windows.RemoveBuffer(w.Buffer)
+	w.Buffer = previous
+	c.loadWindow(w)
+}
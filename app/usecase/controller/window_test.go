@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wasya-io/go-kilo/app/entity/event"
+	"github.com/wasya-io/go-kilo/app/entity/key"
+	"github.com/wasya-io/go-kilo/app/usecase/window"
+)
+
+func TestSplitWindow_SwitchKeepsCursorPerWindow(t *testing.T) {
+	right := key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowRight}
+	otherWindow := key.KeyEvent{Type: key.KeyEventChar, Rune: 'o', Modifiers: key.ModAlt}
+	controller, c := newKeyInputController(t, []string{"one", "two"},
+		ctrlKey(key.KeyCtrlK), char('-'), right, right, otherWindow, char('x'), otherWindow,
+		ctrlKey(key.KeyCtrlK), char('u'),
+	)
+	controller.screen.SetCursorPosition(0, 1)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, window.SplitHorizontal, controller.windows.Split())
+	assert.Equal(t, 10, controller.screen.TextRows())
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, controller.Process())
+	}
+	// 両方のウィンドウは同じバッファを表示する
+	assert.Equal(t, "xtwo", c.GetContentLine(1))
+	assert.Equal(t, 1, controller.windows.FocusIndex())
+
+	// 元のウィンドウに戻るとカーソル位置も戻る
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 0, controller.windows.FocusIndex())
+	assert.Equal(t, 2, controller.screen.GetCursor().ToPosition().X)
+
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, window.SplitNone, controller.windows.Split())
+	assert.Equal(t, 22, controller.screen.TextRows())
+}
+
+func TestOpenFile_InSplitWindowUsesNewBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("other\n"), 0644))
+
+	controller, c := newKeyInputController(t, []string{"one"},
+		ctrlKey(key.KeyCtrlK), char('|'), char('x'),
+	)
+	assert.NoError(t, controller.Process())
+	assert.NoError(t, controller.Process())
+	assert.True(t, c.IsDirty())
+
+	assert.NoError(t, controller.openFileCommand([]string{path}))
+	assert.Equal(t, "other", controller.contents.GetContentLine(0))
+	assert.Equal(t, "xone", c.GetContentLine(0), "the other window keeps its buffer")
+	assert.True(t, controller.hasUnsavedChanges())
+
+	// 他のウィンドウにしかない変更は、分割を解除して失わないようにする
+	controller.performWindow(event.BufferCloseOthers)
+	assert.Equal(t, window.SplitVertical, controller.windows.Split())
+}
+
+func TestOpenFile_FailureRestoresBuffer(t *testing.T) {
+	controller, c := newKeyInputController(t, []string{"one"})
+
+	assert.NoError(t, controller.openFileCommand([]string{filepath.Join(t.TempDir(), "missing", "file.txt")}))
+	assert.Same(t, c, controller.contents, "the window shows the original buffer again")
+	assert.Len(t, controller.windows.Buffers(), 1, "the buffer for the file that failed to open is discarded")
+	assert.Same(t, c, controller.windows.Focused().Buffer.Contents)
+}
+
+func TestZoomWindow_TogglesSplit(t *testing.T) {
+	controller, _ := newKeyInputController(t, []string{"one"},
+		ctrlKey(key.KeyCtrlK), char('-'), ctrlKey(key.KeyCtrlW), char('z'), ctrlKey(key.KeyCtrlW), char('z'),
+	)
+	assert.NoError(t, controller.Process())
+	assert.Equal(t, 10, controller.screen.TextRows())
+
+	assert.NoError(t, controller.Process())
+	assert.True(t, controller.windows.Zoomed())
+	assert.Equal(t, 22, controller.screen.TextRows(), "the zoomed window fills the screen")
+
+	assert.NoError(t, controller.Process())
+	assert.False(t, controller.windows.Zoomed())
+	assert.Equal(t, window.SplitHorizontal, controller.windows.Split())
+	assert.Equal(t, 10, controller.screen.TextRows())
+}
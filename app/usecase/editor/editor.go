@@ -1,6 +1,7 @@
 package editor
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
+	"github.com/wasya-io/go-kilo/app/boundary/reader"
 	"github.com/wasya-io/go-kilo/app/config"
 	"github.com/wasya-io/go-kilo/app/entity/contents"
 	"github.com/wasya-io/go-kilo/app/entity/core"
@@ -18,6 +20,8 @@ import (
 	"github.com/wasya-io/go-kilo/app/entity/event"
 	"github.com/wasya-io/go-kilo/app/entity/screen"
 	"github.com/wasya-io/go-kilo/app/usecase/controller"
+	"github.com/wasya-io/go-kilo/app/usecase/recovery"
+	"github.com/wasya-io/go-kilo/app/usecase/tutor"
 )
 
 // Editor はエディタの状態を管理する構造体
@@ -36,6 +40,8 @@ type Editor struct {
 	metrics          *core.MetricsCollector
 	inputProvider    input.Provider
 	eventBus         *event.Bus // イベントバスを追加
+	titlePushed      bool
+	tutorialFile     string // チュートリアル用の一時ファイル
 }
 
 type WinSize struct {
@@ -43,6 +49,14 @@ type WinSize struct {
 	Cols int
 }
 
+// terminalColors は設定で指定した色数を返す。auto の場合は端末から判定する
+func terminalColors(depth string) int {
+	if colors, ok := screen.ColorsByName(depth); ok {
+		return colors
+	}
+	return term.Colors()
+}
+
 // New は新しいEditorインスタンスを作成する
 func New(
 	testMode bool,
@@ -82,6 +96,8 @@ func New(
 			"Press Ctrl-Q or Ctrl-C to quit.",
 		}
 		e.buffer.LoadContent(defaultContent)
+		// 設定の読み込み時にテーマ名を検証しているため、ここでは失敗しない
+		_ = e.screen.SetTheme(conf.Theme, terminalColors(conf.ColorDepth))
 		// 9. ターミナルの設定
 		term, err := term.EnableRawMode()
 		if err != nil {
@@ -89,6 +105,20 @@ func New(
 		}
 		e.term = term
 		e.termState = term
+		// sudo などのコマンドがパスワードを端末から読めるよう、実行中は Raw モードを解除する
+		controller.SetTerminalSuspender(term.Suspend)
+		controller.SetProcessStopper(e.stopProcess)
+		// 終了時に元のタイトルへ戻せるよう、変更前のタイトルを退避する
+		if conf.TerminalTitle {
+			term.PushTitle()
+			e.titlePushed = true
+			e.screen.EnableTitle(true)
+		}
+		e.screen.EnableHyperlinks(conf.Hyperlinks)
+		e.screen.SetWelcome(conf.WelcomeMessage)
+		e.screen.SetWrap(conf.WordWrap)
+		// 設定の読み込み時に項目名を検証しているため、ここでは失敗しない
+		_ = e.screen.SetStatusSegments(conf.StatusSegments)
 		// 10. クリーンアップハンドラの設定
 		go e.setupCleanupHandler()
 	}
@@ -102,6 +132,7 @@ func (e *Editor) setupCleanupHandler() {
 		if r := recover(); r != nil {
 			// パニック時の端末状態復元を保証
 			e.Cleanup()
+			e.ReportRecovery()
 			// スタックトレースとエラー情報を出力
 			fmt.Fprintf(os.Stderr, "Editor panic: %v\n", r)
 			debug.PrintStack()
@@ -111,11 +142,11 @@ func (e *Editor) setupCleanupHandler() {
 
 	// シグナル処理
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	select {
 	case <-sigChan:
-		e.Cleanup()
+		e.Terminate()
 		os.Exit(0)
 	case <-e.cleanupChan:
 		return
@@ -125,27 +156,123 @@ func (e *Editor) setupCleanupHandler() {
 // Cleanup は終了時の後処理を行う
 func (e *Editor) Cleanup() {
 	e.cleanupOnce.Do(func() {
+		// イベントバスを記録している場合は、止める前に書き出す
+		var tracePath string
+		if e.controller != nil {
+			tracePath, _ = e.controller.WriteEventTrace()
+		}
+
+		// 言語サーバーを残さないよう、終了を待つ
+		if e.controller != nil {
+			e.controller.ShutdownLanguageServers()
+		}
+
 		// イベントバスのシャットダウン
 		if e.eventBus != nil {
 			e.eventBus.Shutdown()
 		}
 
+		// キー入力を待っている goroutine を残さないよう、読み取りを中断する
+		// メインループが中断をエラーとして扱わないよう、先にキー入力の待ちをやめさせる
+		if e.controller != nil {
+			e.controller.StopInput()
+		}
+		if closer, ok := e.inputProvider.(interface{ Close() }); ok {
+			closer.Close()
+		}
+
 		// 最後にログをフラッシュする
 		e.logger.Flush()
 
+		// チュートリアルの練習用ファイルは残さない
+		if e.tutorialFile != "" {
+			os.Remove(e.tutorialFile)
+		}
+
+		// 端末タイトルを復元
+		if e.titlePushed && e.termState != nil {
+			e.termState.PopTitle()
+			e.titlePushed = false
+		}
+
 		// 端末の状態を復元
 		if e.termState != nil {
 			e.termState.DisableRawMode()
 			e.termState = nil
 		}
+		if tracePath != "" {
+			fmt.Fprintf(os.Stderr, "Event trace written to %s\n", tracePath)
+		}
 
 		// クリーンアップ処理の完了を通知
 		close(e.cleanupChan)
 	})
 }
 
+// Terminate は端末の状態を戻し、未保存の変更を復元用ファイルに書き出す
+// シグナルを受けた場合と、端末との接続が切れた場合に同じ手順で終了するために使う
+func (e *Editor) Terminate() {
+	e.Cleanup()
+	e.ReportRecovery()
+}
+
+// ReportRecovery は未保存の変更を復元用ファイルに書き出し、その場所を標準エラー出力に表示する
+// 端末の状態を復元した後に呼び出すこと
+func (e *Editor) ReportRecovery() {
+	paths, err := e.controller.WriteRecovery()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write recovery file: %v\n", err)
+	}
+	for _, path := range paths {
+		fmt.Fprintf(os.Stderr, "Unsaved changes were written to %s\n", path)
+	}
+}
+
+// OpenFile はファイルを開き、前回そのファイルを閉じたときのカーソル位置へ移動する
 func (e *Editor) OpenFile(filename string) error {
-	return e.controller.OpenFile(filename)
+	if err := e.controller.OpenFile(filename); err != nil {
+		return err
+	}
+	e.controller.RestorePosition()
+	return nil
+}
+
+// OpenFiles はコマンドラインで指定されたファイルをそれぞれのバッファで開き、最初のファイルを表示する
+// 位置を指定していないファイルは、前回そのファイルを閉じたときのカーソル位置へ移動する
+func (e *Editor) OpenFiles(files []controller.StartupFile) error {
+	return e.controller.OpenFiles(files)
+}
+
+// OpenText は標準入力から読み込んだ data を名前のないバッファとして開く
+func (e *Editor) OpenText(data []byte) error {
+	return e.controller.OpenText(data)
+}
+
+// SetReadOnly は以降に開くファイルを編集できない状態で開くかどうかを設定する
+func (e *Editor) SetReadOnly(readOnly bool) {
+	e.controller.SetForceReadOnly(readOnly)
+}
+
+// ResumeSession は前回の終了時に開いていたファイルを開き直す
+// requested が false の場合は、設定で有効にしている場合だけ開き直す
+func (e *Editor) ResumeSession(requested bool) {
+	if requested || e.config.ResumeSession {
+		e.controller.RestoreSession()
+	}
+}
+
+// StartTutorial は一時ファイルに練習用バッファを作成してチュートリアルを開始する
+// 練習用の一時ファイルはセッションにもカーソル位置の記録にも残さない
+func (e *Editor) StartTutorial() error {
+	e.controller.SetSessionStore(nil)
+	e.controller.SetPositionStore(nil)
+	f, err := os.CreateTemp("", "go-kilo-tutor-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create tutorial file: %w", err)
+	}
+	f.Close()
+	e.tutorialFile = f.Name()
+	return e.controller.StartTutorial(tutor.New(tutor.DefaultLessons()), e.tutorialFile)
 }
 
 // Run はエディタのメインループを実行する
@@ -164,12 +291,45 @@ func (e *Editor) Run() error {
 		go e.startMetricsTicker()
 	}
 
+	go e.startMessageTicker()
+
+	if e.term != nil {
+		go e.watchResize()
+		go e.watchSuspend()
+	}
+
+	if e.config.UnsavedReminderMinutes > 0 {
+		go e.startReminderTicker()
+	}
+
+	if e.config.RecoveryInterval > 0 {
+		e.controller.SetRecoveryPolicy(recovery.Policy{
+			Idle:        time.Duration(e.config.RecoveryIdle) * time.Second,
+			MinInterval: time.Duration(e.config.RecoveryMinInterval) * time.Second,
+			MaxDelay:    time.Duration(e.config.RecoveryInterval) * time.Second,
+		})
+		go e.startCheckpointTicker()
+	}
+
 	for {
 		select {
 		case <-e.controller.Quit:
 			return nil
+		case <-e.cleanupChan:
+			// シグナルによる終了処理で入力を閉じた
+			return nil
 		default:
 			if err := e.controller.Process(); err != nil {
+				// SSH の切断などで端末との接続が切れた場合は、SIGHUP と同じく未保存の変更を書き出して正常に終了する
+				if errors.Is(err, reader.ErrHangup) {
+					e.logger.Log("system", fmt.Sprintf("Terminal hung up: %v", err))
+					e.Terminate()
+					return nil
+				}
+				// シグナルによる終了処理で入力を閉じた場合
+				if errors.Is(err, input.ErrClosed) {
+					return nil
+				}
 				e.logger.Log("error", fmt.Sprintf("Main loop error: %v", err))
 				return err
 			}
@@ -191,6 +351,104 @@ func (e *Editor) startMetricsTicker() {
 	}
 }
 
+// startMessageTicker は入力がない間も表示時間を過ぎたステータスメッセージを消す
+func (e *Editor) startMessageTicker() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.controller.Post(func() { e.controller.ExpireStatusMessage(now) })
+		case <-e.cleanupChan:
+			return
+		}
+	}
+}
+
+// watchResize は端末の大きさの変更（SIGWINCH）を受け取って画面の大きさに反映する
+func (e *Editor) watchResize() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGWINCH)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-sigChan:
+			rows, cols, err := term.QueryWinSize()
+			if err != nil {
+				e.logger.Log("error", fmt.Sprintf("Failed to get window size: %v", err))
+				continue
+			}
+			e.controller.Post(func() { e.controller.Resize(rows, cols) })
+		case <-e.cleanupChan:
+			return
+		}
+	}
+}
+
+// watchSuspend は SIGTSTP（kill -TSTP など）を受け取ってエディタを一時停止する
+// Raw モードでは Ctrl-Z がシグナルにならないため、キー操作からの停止は suspend コマンドで行う
+func (e *Editor) watchSuspend() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTSTP)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-sigChan:
+			e.controller.Post(e.controller.Suspend)
+		case <-e.cleanupChan:
+			return
+		}
+	}
+}
+
+// stopProcess は端末を元の状態に戻してプロセスを停止し、SIGCONT で再開したら Raw モードに戻す
+// 停止中は SIGWINCH を受け取れないため、再開後に端末の大きさを取得し直す
+func (e *Editor) stopProcess() error {
+	if err := e.term.Stop(); err != nil {
+		return err
+	}
+	rows, cols, err := term.QueryWinSize()
+	if err != nil {
+		e.logger.Log("error", fmt.Sprintf("Failed to get window size: %v", err))
+		return nil
+	}
+	e.controller.Resize(rows, cols)
+	return nil
+}
+
+// startReminderTicker は未保存状態の継続時間を定期的に確認する
+func (e *Editor) startReminderTicker() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.controller.Post(func() { e.controller.CheckUnsavedReminder(now) })
+		case <-e.cleanupChan:
+			return
+		}
+	}
+}
+
+// startCheckpointTicker は一連の編集の区切りを定期的に確認し、未保存の変更を復元用ファイルへ書き出す
+func (e *Editor) startCheckpointTicker() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.controller.Post(func() { e.controller.CheckIdleCheckpoint(now) })
+		case <-e.cleanupChan:
+			return
+		}
+	}
+}
+
 func (e *Editor) collectSystemMetrics() {
 	var mem runtime.MemStats
 	runtime.ReadMemStats(&mem)
@@ -199,5 +457,7 @@ func (e *Editor) collectSystemMetrics() {
 	}
 	if e.metrics != nil {
 		e.metrics.RecordSystemStats(mem.Alloc, mem.TotalAlloc, mem.Sys, runtime.NumGoroutine())
+		stats := contents.SharedWidthCache().Stats()
+		e.metrics.RecordWidthCacheStats(stats.Hits, stats.Misses, stats.Size)
 	}
 }
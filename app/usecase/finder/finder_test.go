@@ -0,0 +1,120 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeLister は決まったパスを列挙する Lister。release を閉じるまで最後の1件を渡さない
+type fakeLister struct {
+	paths   []string
+	release chan struct{}
+	err     error
+}
+
+func (f *fakeLister) List(ctx context.Context, root string, found func([]string)) error {
+	if len(f.paths) > 1 {
+		found(f.paths[:len(f.paths)-1])
+	}
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	found(f.paths[len(f.paths)-1:])
+	return f.err
+}
+
+func TestIndex_PartialAndRefresh(t *testing.T) {
+	lister := &fakeLister{paths: []string{"a.go", "b.go", "c.go"}, release: make(chan struct{})}
+	x := NewIndex(lister)
+	x.Start("/p")
+
+	// 初回は見つけた分から参照できる
+	waitFor(t, func() bool { return len(x.Snapshot().Paths) == 2 })
+	if s := x.Snapshot(); !s.Indexing || s.Root != "/p" {
+		t.Errorf("unexpected snapshot while indexing: %+v", s)
+	}
+	close(lister.release)
+	x.Wait()
+	if s := x.Snapshot(); s.Indexing || !reflect.DeepEqual(s.Paths, lister.paths) {
+		t.Errorf("unexpected snapshot after indexing: %+v", s)
+	}
+
+	// 作り直す間は完了するまで前回の一覧を返す
+	lister.paths = []string{"d.go", "e.go"}
+	lister.release = make(chan struct{})
+	x.Start("/p")
+	if s := x.Snapshot(); !s.Indexing || len(s.Paths) != 3 {
+		t.Errorf("previous list must be kept while refreshing: %+v", s)
+	}
+	close(lister.release)
+	x.Wait()
+	if s := x.Snapshot(); !reflect.DeepEqual(s.Paths, []string{"d.go", "e.go"}) {
+		t.Errorf("refreshed list = %v", s.Paths)
+	}
+
+	// 別のディレクトリでは前回の一覧を捨てる
+	lister.release = make(chan struct{})
+	x.Start("/q")
+	x.Stop()
+	if s := x.Snapshot(); s.Indexing || len(s.Paths) > 1 || s.Root != "/q" {
+		t.Errorf("unexpected snapshot after changing root: %+v", s)
+	}
+}
+
+func TestIndex_Error(t *testing.T) {
+	errTooMany := errors.New("too many")
+	x := NewIndex(&fakeLister{paths: []string{"a"}, err: errTooMany})
+	x.Start("/p")
+	x.Wait()
+	if s := x.Snapshot(); !errors.Is(s.Err, errTooMany) || len(s.Paths) != 1 {
+		t.Errorf("unexpected snapshot: %+v", s)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("condition was not met")
+}
+
+func TestRank(t *testing.T) {
+	paths := []string{
+		filepath.Join("app", "usecase", "controller", "controller.go"),
+		filepath.Join("docs", "control.md"),
+		filepath.Join("app", "entity", "contents", "row.go"),
+		"README.md",
+	}
+
+	// 点数が同じなら短いパスを先に、ディレクトリ名にだけ一致するものは後にする
+	got := Rank(paths, "contr", 10)
+	if len(got) != 3 || got[0].Path != paths[1] || got[1].Path != paths[0] || got[2].Path != paths[2] {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+	// ファイル名での一致を優先する
+	if got := Rank(paths, "row", 10); len(got) == 0 || got[0].Path != paths[2] {
+		t.Errorf("file name match must come first: %+v", got)
+	}
+	// 区切り文字を含む場合はパス全体で比べる
+	if got := Rank(paths, "entity/row", 10); len(got) != 1 || got[0].Path != paths[2] {
+		t.Errorf("path query must match directories: %+v", got)
+	}
+	if got := Rank(paths, "", 2); len(got) != 2 || got[0].Path != paths[0] {
+		t.Errorf("empty query must list paths in order: %+v", got)
+	}
+	if got := Rank(paths, "xyz", 10); len(got) != 0 {
+		t.Errorf("unexpected matches: %+v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wasya-io/go-kilo/app/boundary/fileindex"
+)
+
+// Snapshot はある時点での索引の内容
+type Snapshot struct {
+	Root     string
+	Paths    []string // Root からの相対パス。読み取り専用として扱う
+	Indexing bool     // 索引を作成中
+	Err      error    // 最後に完了した索引作成のエラー（打ち切った場合など）
+}
+
+// Index はディレクトリ以下のファイルの一覧を裏で作成して保持する
+// 初回の作成中は見つけた分から参照でき、作り直す間は前回の一覧を参照できる
+type Index struct {
+	mu         sync.Mutex
+	lister     fileindex.Lister
+	root       string
+	paths      []string
+	complete   bool // root の一覧を最後まで作成したことがある
+	err        error
+	generation int
+	cancel     context.CancelFunc
+	done       chan struct{} // 作成中の場合に、完了時に閉じられる
+}
+
+// NewIndex は lister でファイルを列挙する Index を作成する
+func NewIndex(lister fileindex.Lister) *Index {
+	return &Index{lister: lister}
+}
+
+// Start は root 以下の索引の作成を裏で始める。作成中のものは取り消す
+// root が前回と異なる場合は前回の一覧を捨てる
+func (x *Index) Start(root string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stopLocked()
+	if root != x.root {
+		x.root, x.paths, x.complete, x.err = root, nil, false, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	x.generation++
+	generation := x.generation
+	x.cancel = cancel
+	done := make(chan struct{})
+	x.done = done
+
+	go func() {
+		defer close(done)
+		var found []string
+		err := x.lister.List(ctx, root, func(batch []string) {
+			found = append(found, batch...)
+			x.update(generation, found, false, nil)
+		})
+		x.update(generation, found, true, err)
+	}()
+}
+
+// update は作成中の一覧を反映する。取り消した作成の結果は捨てる
+func (x *Index) update(generation int, found []string, finished bool, err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if generation != x.generation {
+		return
+	}
+	// 作り直す間は前回の一覧を残し、完了したら置き換える
+	if finished || !x.complete {
+		x.paths = found[:len(found):len(found)]
+	}
+	if finished {
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+		x.complete, x.err, x.cancel, x.done = true, err, nil, nil
+	}
+}
+
+// Stop は作成中の索引を取り消す
+func (x *Index) Stop() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stopLocked()
+}
+
+func (x *Index) stopLocked() {
+	if x.cancel == nil {
+		return
+	}
+	x.cancel()
+	x.generation++
+	x.cancel, x.done = nil, nil
+}
+
+// Wait は作成中の索引が完了するまで待つ
+func (x *Index) Wait() {
+	x.mu.Lock()
+	done := x.done
+	x.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Snapshot は現在の索引の内容を返す
+func (x *Index) Snapshot() Snapshot {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return Snapshot{Root: x.root, Paths: x.paths, Indexing: x.cancel != nil, Err: x.err}
+}
@@ -0,0 +1,51 @@
+package finder
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/usecase/palette"
+)
+
+// Match は検索結果の1件
+type Match struct {
+	Path  string
+	Score int
+}
+
+// Rank は query にあいまい一致するパスを点数の高い順に最大 limit 件返す
+// ファイル名での一致をディレクトリを含めたパス全体での一致より重視する。query が区切り文字を含む場合はパス全体だけで比べる
+// 点数が同じ場合は短いパスを、長さも同じなら paths の順序を優先する。空の query は paths の先頭から返す
+func Rank(paths []string, query string, limit int) []Match {
+	var matches []Match
+	if query == "" {
+		for _, p := range paths[:min(len(paths), limit)] {
+			matches = append(matches, Match{Path: p})
+		}
+		return matches
+	}
+
+	byPath := strings.ContainsAny(query, "/"+string(filepath.Separator))
+	for _, p := range paths {
+		score, ok := palette.Match(query, p)
+		if !byPath {
+			if bs, bok := palette.Match(query, filepath.Base(p)); bok && (!ok || bs*2 > score) {
+				score, ok = bs*2, true
+			}
+		}
+		if ok {
+			matches = append(matches, Match{Path: p, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].Path) < len(matches[j].Path)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,80 @@
+package palette
+
+import "unicode"
+
+// 一致の評価に使う点数
+const (
+	scoreMatch       = 1  // 一致した文字ごとの点数
+	scoreConsecutive = 5  // 直前の文字に続けて一致した場合の加点
+	scoreWordStart   = 8  // 単語の先頭で一致した場合の加点
+	scorePrefix      = 10 // 文字列の先頭から一致した場合の加点
+	maxGapPenalty    = 3  // 一致の間に挟まった文字による減点の上限（1か所あたり）
+)
+
+// Match は query の文字が text に順番に現れるかどうか（あいまい一致）を判定し、一致の良さを点数で返す
+// 大文字小文字は区別しない。単語の先頭や連続した文字での一致ほど点数が高い
+// 空の query は点数0で全てに一致する
+func Match(query, text string) (score int, ok bool) {
+	q := []rune(query)
+	t := []rune(text)
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	prev := -1
+	for _, qr := range q {
+		qr = unicode.ToLower(qr)
+		found := -1
+		// 単語の先頭で一致する位置があればそちらを優先する（例: "gd" は goto-definition の g と d）
+		for i := prev + 1; i < len(t); i++ {
+			if unicode.ToLower(t[i]) != qr {
+				continue
+			}
+			if found < 0 {
+				found = i
+			}
+			if i == prev+1 || isWordStart(t, i) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return 0, false
+		}
+
+		score += scoreMatch
+		switch {
+		case found == prev+1 && prev >= 0:
+			score += scoreConsecutive
+		case isWordStart(t, found):
+			score += scoreWordStart
+		}
+		if gap := found - prev - 1; prev >= 0 && gap > 0 {
+			score -= min(gap, maxGapPenalty)
+		}
+		prev = found
+	}
+	if len(q) <= len(t) && equalFold(q, t[:len(q)]) {
+		score += scorePrefix
+	}
+	return score, true
+}
+
+// isWordStart は t の i 番目の文字が単語の先頭かどうかを返す
+func isWordStart(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	p := t[i-1]
+	// 記号の直後か、camelCase の大文字
+	return (!unicode.IsLetter(p) && !unicode.IsDigit(p)) || (unicode.IsLower(p) && unicode.IsUpper(t[i]))
+}
+
+func equalFold(a, b []rune) bool {
+	for i := range a {
+		if unicode.ToLower(a[i]) != unicode.ToLower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package palette
+
+import "sort"
+
+const (
+	// recentSize は最近使ったコマンドとして覚えておく数
+	recentSize = 10
+	// maxFrequencyBoost は使用回数による加点の上限
+	maxFrequencyBoost = 10
+)
+
+// Item はパレットに表示するコマンド
+type Item struct {
+	Name        string
+	Description string
+	Keys        []string // コマンドを呼び出すキーの表記
+}
+
+// Entry は検索結果の1件
+type Entry struct {
+	Item
+	Score int
+}
+
+// Usage はパレットから実行したコマンドの履歴
+type Usage struct {
+	counts map[string]int
+	recent []string // 新しい順
+}
+
+// NewUsage は空の Usage を作成する
+func NewUsage() *Usage {
+	return &Usage{counts: make(map[string]int)}
+}
+
+// Record は name のコマンドを実行したことを記録する
+func (u *Usage) Record(name string) {
+	u.counts[name]++
+	for i, r := range u.recent {
+		if r == name {
+			u.recent = append(u.recent[:i], u.recent[i+1:]...)
+			break
+		}
+	}
+	u.recent = append([]string{name}, u.recent...)
+	if len(u.recent) > recentSize {
+		u.recent = u.recent[:recentSize]
+	}
+}
+
+// Boost は最近・頻繁に使ったコマンドほど大きい加点を返す
+func (u *Usage) Boost(name string) int {
+	if u == nil {
+		return 0
+	}
+	boost := min(u.counts[name], maxFrequencyBoost)
+	for i, r := range u.recent {
+		if r == name {
+			boost += (recentSize - i) * 2
+			break
+		}
+	}
+	return boost
+}
+
+// Rank は query にあいまい一致する items を点数の高い順に返す
+// 名前での一致を説明での一致より重視し、usage で最近・頻繁に使ったものを上位にする
+// 点数が同じ場合は items の順序を保つ
+func Rank(items []Item, query string, usage *Usage) []Entry {
+	var entries []Entry
+	for _, item := range items {
+		score, ok := Match(query, item.Name)
+		score *= 2
+		if ds, dok := Match(query, item.Description); dok && (!ok || ds > score) {
+			score, ok = ds, true
+		}
+		if !ok {
+			continue
+		}
+		entries = append(entries, Entry{Item: item, Score: score + usage.Boost(item.Name)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+	return entries
+}
@@ -0,0 +1,76 @@
+package palette
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	if _, ok := Match("gdf", "goto-definition"); !ok {
+		t.Error("subsequence must match")
+	}
+	if _, ok := Match("xyz", "goto-definition"); ok {
+		t.Error("missing characters must not match")
+	}
+	if score, ok := Match("", "anything"); !ok || score != 0 {
+		t.Errorf("empty query must match with score 0: %d %v", score, ok)
+	}
+
+	// 単語の先頭や連続した一致の方が点数が高い
+	wordStart, _ := Match("gd", "goto-definition")
+	scattered, _ := Match("gd", "toggle-bookmark-hidden")
+	if wordStart <= scattered {
+		t.Errorf("word starts must score higher: %d <= %d", wordStart, scattered)
+	}
+	prefix, _ := Match("sav", "save")
+	inner, _ := Match("sav", "browse-saved")
+	if prefix <= inner {
+		t.Errorf("prefix must score higher: %d <= %d", prefix, inner)
+	}
+	if _, ok := Match("SAVE", "save"); !ok {
+		t.Error("matching must ignore case")
+	}
+}
+
+func TestRank(t *testing.T) {
+	items := []Item{
+		{Name: "save", Description: "Save the buffer"},
+		{Name: "search", Description: "Search the buffer incrementally"},
+		{Name: "quit", Description: "Quit the editor"},
+	}
+
+	got := Rank(items, "s", nil)
+	if len(got) != 2 || got[0].Name != "save" || got[1].Name != "search" {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+	// 説明にだけ一致するものも候補にする
+	if got := Rank(items, "editor", nil); len(got) != 1 || got[0].Name != "quit" {
+		t.Errorf("description must be searched: %+v", got)
+	}
+
+	// 最近使ったコマンドを上位にする
+	usage := NewUsage()
+	usage.Record("search")
+	if got := Rank(items, "s", usage); got[0].Name != "search" {
+		t.Errorf("recently used command must come first: %+v", got)
+	}
+	if got := Rank(items, "", usage); len(got) != 3 || got[0].Name != "search" || got[1].Name != "save" {
+		t.Errorf("empty query must list everything by usage: %+v", got)
+	}
+}
+
+func TestUsage_Boost(t *testing.T) {
+	u := NewUsage()
+	u.Record("a")
+	u.Record("b")
+	u.Record("a")
+	if u.Boost("a") <= u.Boost("b") {
+		t.Errorf("frequent and recent commands must get a larger boost: %d %d", u.Boost("a"), u.Boost("b"))
+	}
+	if u.Boost("c") != 0 {
+		t.Error("unused commands must not be boosted")
+	}
+	for i := 0; i < recentSize+5; i++ {
+		u.Record(string(rune('d' + i)))
+	}
+	if len(u.recent) != recentSize {
+		t.Errorf("recent list must be bounded: %d", len(u.recent))
+	}
+}
@@ -36,6 +36,12 @@ func (p *StandardInputParser) Parse(buf []byte, n int) ([]key.KeyEvent, error) {
 
 	// エスケープシーケンスの処理
 	if buf[0] == '\x1b' {
+		if isAltSequence(buf, n) || isAltEscapeSequence(buf, n) {
+			return p.parseAltKey(buf[1:n])
+		}
+		if end := sgrMouseEnd(buf, n); end < n {
+			return p.parseMouseSequences(buf[:n], end)
+		}
 		event, err := p.parseEscapeSequence(buf, n)
 		if err == nil {
 			return []key.KeyEvent{event}, nil
@@ -49,12 +55,40 @@ func (p *StandardInputParser) Parse(buf []byte, n int) ([]key.KeyEvent, error) {
 // parseControlKey はコントロールキーの解析を行う
 func (p *StandardInputParser) parseControlKey(b byte) (key.KeyEvent, bool) {
 	switch b {
+	case 0: // Ctrl-Space
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlSpace}, true
 	case 3: // Ctrl+C
 		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlC}, true
 	case 24: // Ctrl+X
 		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlX}, true
 	case 19: // Ctrl-S
 		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlS}, true
+	case 2: // Ctrl-B
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlB}, true
+	case 14: // Ctrl-N
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlN}, true
+	case 4: // Ctrl-D
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlD}, true
+	case 6: // Ctrl-F
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlF}, true
+	case 26: // Ctrl-Z
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlZ}, true
+	case 25: // Ctrl-Y
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlY}, true
+	case 20: // Ctrl-T
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlT}, true
+	case 7: // Ctrl-G
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlG}, true
+	case 23: // Ctrl-W
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlW}, true
+	case 15: // Ctrl-O
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlO}, true
+	case 16: // Ctrl-P
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlP}, true
+	case 11: // Ctrl-K
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlK}, true
+	case 29: // Ctrl-]
+		return key.KeyEvent{Type: key.KeyEventControl, Key: key.KeyCtrlRightBracket}, true
 	}
 	return key.KeyEvent{}, false
 }
@@ -72,12 +106,69 @@ func (p *StandardInputParser) parseSpecialKey(b byte) (key.KeyEvent, bool) {
 	return key.KeyEvent{}, false
 }
 
+// isAltSequence は ESC に続く入力が Alt との同時押しかどうかを判定する
+// 端末は Alt+キーを ESC とキーの連続として送り、その間隔は ESC 単体の入力と区別できる程度に短い
+// （2回の読み込みに分かれた場合も入力元が短い間続きを待ってつなげる）ため、同じ読み込みに CSI/SS3 以外の続きがあれば Alt とみなす
+// ESC [ と ESC O は単体で届いた場合のみ Alt+[ / Alt+O として扱う
+func isAltSequence(buf []byte, n int) bool {
+	if n < 2 || buf[1] == '\x1b' {
+		return false
+	}
+	if n == 2 {
+		return true
+	}
+	return buf[1] != '[' && buf[1] != 'O'
+}
+
+// isAltEscapeSequence は ESC に続けて矢印キーなどのエスケープシーケンスが届いたかどうかを判定する
+// 一部の端末は Alt+矢印キーを ESC ESC [ A のように送る
+func isAltEscapeSequence(buf []byte, n int) bool {
+	return n >= 4 && buf[1] == '\x1b' && buf[2] == '['
+}
+
+// parseAltKey は ESC の後に続くキーを Alt 修飾付きのイベントとして解析する
+// 最初のキー以外のバイトは通常の文字として扱う
+func (p *StandardInputParser) parseAltKey(rest []byte) ([]key.KeyEvent, error) {
+	events, err := p.Parse(rest, len(rest))
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > 0 && events[0].Type != key.KeyEventMouse {
+		events[0].Modifiers |= key.ModAlt
+	}
+	return events, nil
+}
+
+// arrowKeys はエスケープシーケンスの末尾の文字と矢印キーの対応
+var arrowKeys = map[byte]key.Key{
+	'A': key.KeyArrowUp,
+	'B': key.KeyArrowDown,
+	'C': key.KeyArrowRight,
+	'D': key.KeyArrowLeft,
+}
+
+// arrowModifiers は修飾キー付きの矢印キーのエスケープシーケンスの修飾キーの番号と、修飾キーの対応
+var arrowModifiers = map[byte]key.Modifiers{
+	'2': key.ModShift,
+	'3': key.ModAlt,
+	'4': key.ModAlt | key.ModShift,
+}
+
 // parseEscapeSequence はエスケープシーケンスの解析を行う
 func (p *StandardInputParser) parseEscapeSequence(buf []byte, n int) (key.KeyEvent, error) {
 	if n == 1 {
 		return key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyEsc}, nil
 	}
 
+	// 修飾キー付きの矢印キー（Shift は ESC [ 1 ; 2 A、Alt は ESC [ 1 ; 3 A など）
+	if n == 6 && buf[1] == '[' && buf[2] == '1' && buf[3] == ';' {
+		k, isArrow := arrowKeys[buf[5]]
+		mods, hasMods := arrowModifiers[buf[4]]
+		if isArrow && hasMods {
+			return key.KeyEvent{Type: key.KeyEventSpecial, Key: k, Modifiers: mods}, nil
+		}
+	}
+
 	if n >= 3 && buf[1] == '[' {
 		switch buf[2] {
 		case 'A':
@@ -92,17 +183,60 @@ func (p *StandardInputParser) parseEscapeSequence(buf []byte, n int) (key.KeyEve
 			return key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyShiftTab}, nil
 		case 'M', '<':
 			return p.parseMouseEvent(buf, n)
+		case '3':
+			// Delete（ESC [ 3 ~）
+			if n == 4 && buf[3] == '~' {
+				return key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyDelete}, nil
+			}
 		}
 	}
 
 	return key.KeyEvent{}, fmt.Errorf("unknown escape sequence")
 }
 
+// sgrMouseEnd は buf が SGR 形式のマウスイベント（ESC [ < ... M/m）で始まる場合に、最初のイベントの終わりの位置を返す
+// マウスイベントでなければ n を返す
+func sgrMouseEnd(buf []byte, n int) int {
+	if n < 4 || buf[1] != '[' || buf[2] != '<' {
+		return n
+	}
+	for i := 3; i < n; i++ {
+		if buf[i] == 'M' || buf[i] == 'm' {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// parseMouseSequences は1回の読み取りに続けて届いた複数のマウスイベントを解析する
+// ホイールを速く回すと、端末は複数のイベントをまとめて送ることがある
+func (p *StandardInputParser) parseMouseSequences(buf []byte, end int) ([]key.KeyEvent, error) {
+	var events []key.KeyEvent
+	if event, err := p.parseMouseEvent(buf[:end], end); err == nil {
+		events = append(events, event)
+	}
+	rest, err := p.Parse(buf[end:], len(buf)-end)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, rest...), nil
+}
+
 // parseMouseEvent はマウスイベントの解析を行う
 func (p *StandardInputParser) parseMouseEvent(buf []byte, n int) (key.KeyEvent, error) {
 	if n >= 6 && buf[2] == '<' {
 		var cb, cx, cy int
 		if _, err := fmt.Sscanf(string(buf[3:n]), "%d;%d;%d", &cb, &cx, &cy); err == nil {
+			// 末尾が m ならボタンを離した
+			if buf[n-1] == 'm' && cb < 64 {
+				return key.KeyEvent{
+					Type:        key.KeyEventMouse,
+					Key:         key.KeyMouseClick,
+					MouseRow:    cy - 1,
+					MouseCol:    cx - 1,
+					MouseAction: key.MouseRelease,
+				}, nil
+			}
 			switch cb {
 			case 64: // スクロールアップ
 				return key.KeyEvent{
@@ -36,6 +36,17 @@ func TestStandardInputParser_ParseControlKey(t *testing.T) {
 	}
 }
 
+func TestStandardInputParser_ParseCtrlSpace(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(true))
+	events, err := parser.Parse([]byte{0x00}, 1) // 端末は Ctrl-Space を NUL として送る
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Key != key.KeyCtrlSpace || events[0].Name() != "C-Space" {
+		t.Errorf("unexpected event: %v", events)
+	}
+}
+
 func TestStandardInputParser_ParseSpecialKey(t *testing.T) {
 	logger := logger.New(true)
 	parser := NewStandardInputParser(logger) // テスト対象のインスタンスを生成
@@ -141,3 +152,133 @@ func TestStandardInputParser_ParseLargeJapaneseString(t *testing.T) {
 		t.Errorf("expected last char to be 'オ', got %c", events[24].Rune)
 	}
 }
+
+func TestStandardInputParser_ParseAltKey(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	tests := []struct {
+		name string
+		buf  []byte
+		want []key.KeyEvent
+	}{
+		{"alt letter", []byte{0x1b, 'd'}, []key.KeyEvent{{Type: key.KeyEventChar, Rune: 'd', Modifiers: key.ModAlt}}},
+		{"alt utf8", []byte("\x1bあ"), []key.KeyEvent{{Type: key.KeyEventChar, Rune: 'あ', Modifiers: key.ModAlt}}},
+		{"alt control", []byte{0x1b, 0x13}, []key.KeyEvent{{Type: key.KeyEventControl, Key: key.KeyCtrlS, Modifiers: key.ModAlt}}},
+		{"alt bracket alone", []byte{0x1b, '['}, []key.KeyEvent{{Type: key.KeyEventChar, Rune: '[', Modifiers: key.ModAlt}}},
+		{"following chars are not modified", []byte("\x1bあい"), []key.KeyEvent{
+			{Type: key.KeyEventChar, Rune: 'あ', Modifiers: key.ModAlt},
+			{Type: key.KeyEventChar, Rune: 'い'},
+		}},
+		{"arrow is not alt", []byte{0x1b, '[', 'A'}, []key.KeyEvent{{Type: key.KeyEventSpecial, Key: key.KeyArrowUp}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := parser.Parse(tt.buf, len(tt.buf))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %v", len(events), len(tt.want), events)
+			}
+			for i := range events {
+				if events[i] != tt.want[i] {
+					t.Errorf("event %d = %+v, want %+v", i, events[i], tt.want[i])
+				}
+			}
+			if tt.want[0].Modifiers.Has(key.ModAlt) && events[0].Name()[:2] != "M-" {
+				t.Errorf("unexpected name: %s", events[0].Name())
+			}
+		})
+	}
+}
+
+func TestStandardInputParser_ParseShiftArrow(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	buf := []byte("\x1b[1;2D")
+	events, err := parser.Parse(buf, len(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowLeft, Modifiers: key.ModShift}
+	if len(events) != 1 || events[0] != want {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if name := events[0].Name(); name != "S-Left" {
+		t.Errorf("unexpected name: %s", name)
+	}
+}
+
+func TestStandardInputParser_ParseDelete(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	buf := []byte("\x1b[3~")
+	events, err := parser.Parse(buf, len(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyDelete}
+	if len(events) != 1 || events[0] != want {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if name := events[0].Name(); name != "Delete" {
+		t.Errorf("unexpected name: %s", name)
+	}
+}
+
+func TestStandardInputParser_ParseMouseDragAndRelease(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	tests := []struct {
+		input string
+		want  key.MouseAction
+	}{
+		{"\x1b[<0;5;3M", key.MouseLeftClick},
+		{"\x1b[<32;6;3M", key.MouseDrag},
+		{"\x1b[<0;6;3m", key.MouseRelease},
+	}
+	for _, tt := range tests {
+		events, err := parser.Parse([]byte(tt.input), len(tt.input))
+		if err != nil || len(events) != 1 {
+			t.Fatalf("Parse(%q) = %+v, %v", tt.input, events, err)
+		}
+		if events[0].MouseAction != tt.want || events[0].MouseRow != 2 {
+			t.Errorf("Parse(%q) = %+v, want action %v", tt.input, events[0], tt.want)
+		}
+	}
+}
+
+func TestStandardInputParser_ParseMouseSequencesInOneRead(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	// ホイールを速く回すと、複数のイベントが1回の読み取りに届く
+	buf := []byte("\x1b[<64;5;3M\x1b[<65;5;3M\x1b[<65;5;3Mx")
+	events, err := parser.Parse(buf, len(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []key.MouseAction{key.MouseScrollUp, key.MouseScrollDown, key.MouseScrollDown}
+	if len(events) != len(want)+1 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i, action := range want {
+		if events[i].Key != key.KeyMouseWheel || events[i].MouseAction != action {
+			t.Errorf("event %d = %+v, want %v", i, events[i], action)
+		}
+	}
+	if last := events[len(want)]; last.Type != key.KeyEventChar || last.Rune != 'x' {
+		t.Errorf("the input after the mouse events must be kept: %+v", last)
+	}
+}
+
+func TestStandardInputParser_ParseAltArrow(t *testing.T) {
+	parser := NewStandardInputParser(logger.New(false))
+	for _, input := range []string{"\x1b[1;3A", "\x1b\x1b[A"} {
+		events, err := parser.Parse([]byte(input), len(input))
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", input, err)
+		}
+		want := key.KeyEvent{Type: key.KeyEventSpecial, Key: key.KeyArrowUp, Modifiers: key.ModAlt}
+		if len(events) != 1 || events[0] != want {
+			t.Fatalf("Parse(%q) = %+v", input, events)
+		}
+		if name := events[0].Name(); name != "M-Up" {
+			t.Errorf("Parse(%q): unexpected name %s", input, name)
+		}
+	}
+}
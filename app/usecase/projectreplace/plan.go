@@ -0,0 +1,220 @@
+package projectreplace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wasya-io/go-kilo/app/boundary/atomicfile"
+	"github.com/wasya-io/go-kilo/app/entity/grep"
+)
+
+// ErrStale は検索後にファイルが変更され、一致箇所が見つからなくなった場合のエラー
+var ErrStale = errors.New("file changed since search")
+
+// ErrNotUTF8 は UTF-8 として正しくないファイルのため置換しなかった場合のエラー
+// 文字単位で置換すると不正なバイトが U+FFFD に書き換わり、Shift_JIS などの2バイト文字の途中にも一致しうるため
+var ErrNotUTF8 = errors.New("file is not valid UTF-8")
+
+// FileSystem は置換の適用に必要なファイル操作
+type FileSystem interface {
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte) error
+}
+
+// OSFileSystem はローカルファイルシステムを使用し、書き込みをアトミックに行う FileSystem
+type OSFileSystem struct{}
+
+// NewOSFileSystem は新しい OSFileSystem を作成する
+func NewOSFileSystem() *OSFileSystem {
+	return &OSFileSystem{}
+}
+
+// ReadFile はファイルを読み込む
+func (OSFileSystem) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// WriteFile は一時ファイル経由でアトミックに書き込む
+func (OSFileSystem) WriteFile(path string, data []byte) error {
+	return atomicfile.WriteFile(path, data, 0644)
+}
+
+// Occurrence は置換対象の候補1件と、その選択状態を表す
+type Occurrence struct {
+	grep.Match
+	Selected bool
+}
+
+// Failure は置換を適用できなかったファイルとその理由
+type Failure struct {
+	File string
+	Err  error
+}
+
+// Plan は複数ファイルにまたがる置換の計画
+// プレビューで個別の一致箇所を選択・解除してから適用する
+type Plan struct {
+	Pattern     string
+	Replacement string
+	items       []Occurrence
+}
+
+// NewPlan は検索結果から置換計画を作成する。初期状態では全ての一致箇所が選択されている
+// UTF-8 として正しくない行の一致箇所は含めない
+func NewPlan(pattern, replacement string, matches []grep.Match) *Plan {
+	files, byFile := grep.GroupByFile(matches)
+	p := &Plan{Pattern: pattern, Replacement: replacement}
+	for _, f := range files {
+		for _, m := range byFile[f] {
+			if !utf8.ValidString(m.Text) {
+				continue
+			}
+			p.items = append(p.items, Occurrence{Match: m, Selected: true})
+		}
+	}
+	return p
+}
+
+// Len は一致箇所の総数を返す
+func (p *Plan) Len() int {
+	return len(p.items)
+}
+
+// Item は i 番目の一致箇所を返す
+func (p *Plan) Item(i int) Occurrence {
+	return p.items[i]
+}
+
+// Toggle は i 番目の一致箇所の選択状態を切り替える
+func (p *Plan) Toggle(i int) {
+	if i >= 0 && i < len(p.items) {
+		p.items[i].Selected = !p.items[i].Selected
+	}
+}
+
+// SelectedCount は選択されている一致箇所の数を返す
+func (p *Plan) SelectedCount() int {
+	n := 0
+	for _, it := range p.items {
+		if it.Selected {
+			n++
+		}
+	}
+	return n
+}
+
+// Preview はファイルごとにまとめたプレビュー行を返す
+// rows[i] はプレビューの i 行目に対応する一致箇所の番号（ファイル見出し行は -1）
+func (p *Plan) Preview() (lines []string, rows []int) {
+	current := ""
+	for i, it := range p.items {
+		if it.File != current {
+			current = it.File
+			lines = append(lines, fmt.Sprintf("%s (%d)", it.File, p.countInFile(it.File)))
+			rows = append(rows, -1)
+		}
+		mark := "[ ]"
+		if it.Selected {
+			mark = "[x]"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %d: %s", mark, it.Line+1, strings.TrimSpace(p.replaceIn(it))))
+		rows = append(rows, i)
+	}
+	return lines, rows
+}
+
+func (p *Plan) countInFile(file string) int {
+	n := 0
+	for _, it := range p.items {
+		if it.File == file {
+			n++
+		}
+	}
+	return n
+}
+
+// replaceIn は一致箇所1件だけを置換した行を返す（プレビュー用）
+func (p *Plan) replaceIn(it Occurrence) string {
+	runes := []rune(it.Text)
+	if it.Col+it.Length > len(runes) {
+		return it.Text
+	}
+	return string(runes[:it.Col]) + p.Replacement + string(runes[it.Col+it.Length:])
+}
+
+// Apply は選択された一致箇所を置換してファイルに書き込む
+// 1つのファイル内で一致箇所が見つからなくなっていた場合や、UTF-8 として正しくないファイルは一切変更しない
+func (p *Plan) Apply(fsys FileSystem) (changedFiles int, replaced int, failures []Failure) {
+	files, byFile := p.selectedByFile()
+	for _, file := range files {
+		n, err := p.applyFile(fsys, file, byFile[file])
+		if err != nil {
+			failures = append(failures, Failure{File: file, Err: err})
+			continue
+		}
+		changedFiles++
+		replaced += n
+	}
+	return changedFiles, replaced, failures
+}
+
+func (p *Plan) selectedByFile() ([]string, map[string][]Occurrence) {
+	var files []string
+	byFile := make(map[string][]Occurrence)
+	for _, it := range p.items {
+		if !it.Selected {
+			continue
+		}
+		if _, ok := byFile[it.File]; !ok {
+			files = append(files, it.File)
+		}
+		byFile[it.File] = append(byFile[it.File], it)
+	}
+	return files, byFile
+}
+
+func (p *Plan) applyFile(fsys FileSystem, file string, items []Occurrence) (int, error) {
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	if !utf8.Valid(data) {
+		return 0, ErrNotUTF8
+	}
+	lines := strings.Split(string(data), "\n")
+
+	// 同じ行で複数箇所を置換しても位置がずれないよう、後ろから適用する
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Line != items[j].Line {
+			return items[i].Line > items[j].Line
+		}
+		return items[i].Col > items[j].Col
+	})
+
+	pattern := []rune(p.Pattern)
+	for _, it := range items {
+		if it.Line >= len(lines) {
+			return 0, ErrStale
+		}
+		line := lines[it.Line]
+		cr := strings.HasSuffix(line, "\r")
+		runes := []rune(strings.TrimSuffix(line, "\r"))
+		if it.Col+len(pattern) > len(runes) || string(runes[it.Col:it.Col+len(pattern)]) != p.Pattern {
+			return 0, ErrStale
+		}
+		line = string(runes[:it.Col]) + p.Replacement + string(runes[it.Col+len(pattern):])
+		if cr {
+			line += "\r"
+		}
+		lines[it.Line] = line
+	}
+
+	if err := fsys.WriteFile(file, []byte(strings.Join(lines, "\n"))); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
@@ -0,0 +1,113 @@
+package projectreplace
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/grep"
+)
+
+type memFS struct {
+	files  map[string]string
+	failOn string
+}
+
+func (m *memFS) ReadFile(path string) ([]byte, error) {
+	data, ok := m.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(data), nil
+}
+
+func (m *memFS) WriteFile(path string, data []byte) error {
+	if path == m.failOn {
+		return os.ErrPermission
+	}
+	m.files[path] = string(data)
+	return nil
+}
+
+func TestPlan_PreviewAndToggle(t *testing.T) {
+	matches := []grep.Match{
+		{File: "a.txt", Line: 0, Col: 0, Length: 3, Text: "foo foo"},
+		{File: "a.txt", Line: 0, Col: 4, Length: 3, Text: "foo foo"},
+		{File: "b.txt", Line: 2, Col: 1, Length: 3, Text: " foo"},
+	}
+	p := NewPlan("foo", "bar", matches)
+	p.Toggle(1)
+
+	lines, rows := p.Preview()
+	expected := []string{"a.txt (2)", "  [x] 1: bar foo", "  [ ] 1: foo bar", "b.txt (1)", "  [x] 3: bar"}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected preview: %q", lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], expected[i])
+		}
+	}
+	if rows[0] != -1 || rows[2] != 1 || rows[4] != 2 {
+		t.Errorf("unexpected row mapping: %v", rows)
+	}
+	if p.SelectedCount() != 2 {
+		t.Errorf("unexpected selected count: %d", p.SelectedCount())
+	}
+}
+
+func TestPlan_Apply(t *testing.T) {
+	fsys := &memFS{files: map[string]string{
+		"a.txt": "foo foo\r\nあfoo\r\n",
+		"b.txt": "changed\n",
+		"c.txt": "foo\n",
+	}, failOn: "c.txt"}
+	matches := []grep.Match{
+		{File: "a.txt", Line: 0, Col: 0, Length: 3, Text: "foo foo"},
+		{File: "a.txt", Line: 0, Col: 4, Length: 3, Text: "foo foo"},
+		{File: "a.txt", Line: 1, Col: 1, Length: 3, Text: "あfoo"},
+		{File: "b.txt", Line: 0, Col: 0, Length: 3, Text: "foo"},
+		{File: "c.txt", Line: 0, Col: 0, Length: 3, Text: "foo"},
+	}
+	p := NewPlan("foo", "x", matches)
+
+	changed, replaced, failures := p.Apply(fsys)
+	if changed != 1 || replaced != 3 {
+		t.Errorf("changed=%d replaced=%d", changed, replaced)
+	}
+	if fsys.files["a.txt"] != "x x\r\nあx\r\n" {
+		t.Errorf("unexpected a.txt: %q", fsys.files["a.txt"])
+	}
+	if len(failures) != 2 || !errors.Is(failures[0].Err, ErrStale) || !errors.Is(failures[1].Err, os.ErrPermission) {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+	if fsys.files["b.txt"] != "changed\n" {
+		t.Errorf("stale file must not be modified: %q", fsys.files["b.txt"])
+	}
+}
+
+func TestPlan_SkipsInvalidUTF8(t *testing.T) {
+	// Shift_JIS の「表」（0x95 0x5C）の2バイト目は \ と同じバイトになる
+	sjis := "\x95\\foo\n"
+	fsys := &memFS{files: map[string]string{
+		"sjis.txt":   sjis,
+		"latin1.txt": "caf\xe9\nfoo\n",
+	}}
+	matches := []grep.Match{
+		{File: "sjis.txt", Line: 0, Col: 1, Length: 1, Text: "\x95\\foo"},
+		{File: "latin1.txt", Line: 1, Col: 0, Length: 3, Text: "foo"},
+	}
+	p := NewPlan("\\", "/", matches)
+	if p.Len() != 1 {
+		t.Fatalf("a match on a line that is not UTF-8 must be dropped: %d", p.Len())
+	}
+	p = NewPlan("foo", "bar", matches[1:])
+
+	changed, _, failures := p.Apply(fsys)
+	if changed != 0 || len(failures) != 1 || !errors.Is(failures[0].Err, ErrNotUTF8) {
+		t.Errorf("changed=%d failures=%v", changed, failures)
+	}
+	if fsys.files["latin1.txt"] != "caf\xe9\nfoo\n" || fsys.files["sjis.txt"] != sjis {
+		t.Errorf("files that are not UTF-8 must not be modified: %q", fsys.files)
+	}
+}
@@ -0,0 +1,116 @@
+package recovery
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Snapshot は復元に必要なバッファの状態
+type Snapshot struct {
+	// Original は元ファイルのパス。名前のないバッファの場合は空
+	Original string
+	Lines    []string
+	Dirty    bool
+}
+
+// Snapshotter は現在のバッファの状態を取得する
+type Snapshotter interface {
+	Snapshot() Snapshot
+}
+
+// Restorer はスナップショットの内容をバッファに戻す
+type Restorer interface {
+	Restore(Snapshot) error
+}
+
+// Store はスナップショットを永続化する
+type Store interface {
+	Save(original string, lines []string, now time.Time) (string, error)
+	Remove(path string) error
+	PathFor(original string) string
+}
+
+// Manager は未保存の変更を定期的に Store へ書き出し、必要に応じて復元する
+// 編集内容が前回の書き出しから変わっていない場合は書き込みを省略する
+type Manager struct {
+	snapshotter Snapshotter
+	store       Store
+	lastHash    uint64
+	lastPath    string
+}
+
+// NewManager は新しい Manager を作成する
+func NewManager(snapshotter Snapshotter, store Store) *Manager {
+	return &Manager{snapshotter: snapshotter, store: store}
+}
+
+// Checkpoint は未保存の変更があればスナップショットを書き出し、そのパスを返す
+// 変更がない、または前回から内容が変わっていない場合は空文字列を返す
+func (m *Manager) Checkpoint(now time.Time) (string, error) {
+	snap := m.snapshotter.Snapshot()
+	if !snap.Dirty {
+		return "", nil
+	}
+	h := hashSnapshot(snap)
+	if h == m.lastHash && m.lastPath != "" {
+		return "", nil
+	}
+	path, err := m.store.Save(snap.Original, snap.Lines, now)
+	if err != nil {
+		return "", fmt.Errorf("復元用ファイルを書き出せません: %w", err)
+	}
+	m.lastHash = h
+	m.lastPath = path
+	return path, nil
+}
+
+// Flush は前回から変化がなくても未保存の変更を書き出す。異常終了時に使用する
+func (m *Manager) Flush(now time.Time) (string, error) {
+	m.lastPath = ""
+	return m.Checkpoint(now)
+}
+
+// Discard は original の変更が保存されたので、対応するスナップショットを削除する
+func (m *Manager) Discard(original string) error {
+	m.lastHash = 0
+	m.lastPath = ""
+	if original == "" {
+		return nil
+	}
+	return m.store.Remove(m.store.PathFor(original))
+}
+
+// DiscardLast はこのセッションで最後に書き出したスナップショットを削除する
+// 変更を破棄して終了する場合に使用する
+func (m *Manager) DiscardLast() error {
+	path := m.lastPath
+	m.lastHash = 0
+	m.lastPath = ""
+	if path == "" {
+		return nil
+	}
+	return m.store.Remove(path)
+}
+
+// Restore はスナップショットを restorer に渡してバッファに戻す
+// 復元した内容は既に書き出されているので、次の Checkpoint では書き込みを省略する
+func (m *Manager) Restore(restorer Restorer, snap Snapshot, path string) error {
+	if err := restorer.Restore(snap); err != nil {
+		return err
+	}
+	m.lastHash = hashSnapshot(snap)
+	m.lastPath = path
+	return nil
+}
+
+// hashSnapshot はスナップショットの内容のハッシュ値を返す
+func hashSnapshot(snap Snapshot) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(snap.Original))
+	for _, line := range snap.Lines {
+		h.Write([]byte{0})
+		h.Write([]byte(line))
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,115 @@
+package recovery
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeBuffer struct {
+	snap     Snapshot
+	restored *Snapshot
+}
+
+func (b *fakeBuffer) Snapshot() Snapshot { return b.snap }
+
+func (b *fakeBuffer) Restore(s Snapshot) error {
+	b.restored = &s
+	return nil
+}
+
+type fakeStore struct {
+	saved   map[string][]string
+	writes  int
+	removed []string
+	fail    bool
+}
+
+func (s *fakeStore) Save(original string, lines []string, now time.Time) (string, error) {
+	if s.fail {
+		return "", errors.New("disk full")
+	}
+	s.writes++
+	s.saved[s.PathFor(original)] = lines
+	return s.PathFor(original), nil
+}
+
+func (s *fakeStore) Remove(path string) error {
+	s.removed = append(s.removed, path)
+	delete(s.saved, path)
+	return nil
+}
+
+func (s *fakeStore) PathFor(original string) string { return "/state/" + original }
+
+func TestManager_Checkpoint(t *testing.T) {
+	buf := &fakeBuffer{snap: Snapshot{Original: "a.txt", Lines: []string{"x"}}}
+	store := &fakeStore{saved: map[string][]string{}}
+	m := NewManager(buf, store)
+	now := time.Now()
+
+	if path, err := m.Checkpoint(now); err != nil || path != "" {
+		t.Errorf("clean buffers must not be written: %q, %v", path, err)
+	}
+
+	buf.snap.Dirty = true
+	if path, _ := m.Checkpoint(now); path != "/state/a.txt" {
+		t.Errorf("unexpected path: %q", path)
+	}
+	m.Checkpoint(now)
+	if store.writes != 1 {
+		t.Errorf("unchanged contents must not be rewritten: %d writes", store.writes)
+	}
+
+	buf.snap.Lines = []string{"y"}
+	m.Checkpoint(now)
+	if store.writes != 2 || store.saved["/state/a.txt"][0] != "y" {
+		t.Errorf("changed contents must be written: %v", store.saved)
+	}
+
+	m.Flush(now)
+	if store.writes != 3 {
+		t.Errorf("Flush must always write: %d writes", store.writes)
+	}
+
+	if err := m.Discard("a.txt"); err != nil || len(store.saved) != 0 {
+		t.Errorf("Discard must remove the snapshot: %v, %v", err, store.saved)
+	}
+
+	m.Checkpoint(now)
+	if err := m.DiscardLast(); err != nil || len(store.saved) != 0 {
+		t.Errorf("DiscardLast must remove the last snapshot: %v, %v", err, store.saved)
+	}
+	if err := m.DiscardLast(); err != nil || len(store.removed) != 2 {
+		t.Errorf("nothing to remove after DiscardLast: %v, %v", err, store.removed)
+	}
+}
+
+func TestManager_CheckpointError(t *testing.T) {
+	buf := &fakeBuffer{snap: Snapshot{Dirty: true}}
+	m := NewManager(buf, &fakeStore{fail: true})
+	if _, err := m.Checkpoint(time.Now()); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestManager_Restore(t *testing.T) {
+	buf := &fakeBuffer{}
+	store := &fakeStore{saved: map[string][]string{}}
+	m := NewManager(buf, store)
+
+	snap := Snapshot{Original: "a.txt", Lines: []string{"restored"}, Dirty: true}
+	if err := m.Restore(buf, snap, "/state/a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.restored == nil || buf.restored.Lines[0] != "restored" {
+		t.Errorf("snapshot must be passed to the restorer: %+v", buf.restored)
+	}
+
+	// 復元直後の内容は書き出し済みなので再度書き込まない
+	buf.snap = snap
+	m.Checkpoint(time.Now())
+	if store.writes != 0 {
+		t.Errorf("restored contents must not be rewritten: %d writes", store.writes)
+	}
+}
@@ -0,0 +1,66 @@
+package recovery
+
+import "time"
+
+// Policy は未保存の変更を自動で書き出す時機
+// 一連の編集が止まって Idle が経ったときに書き出し、MinInterval より短い間隔では書き出さない
+// 編集が止まらなくても、最初の書き出していない編集から MaxDelay が経ったら書き出す（0 なら待ち続ける）
+type Policy struct {
+	Idle        time.Duration
+	MinInterval time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultPolicy は既定の書き出しの時機
+var DefaultPolicy = Policy{Idle: 3 * time.Second, MinInterval: 10 * time.Second, MaxDelay: 30 * time.Second}
+
+// Trigger はバッファの版の変化から一連の編集の区切りを見つけ、書き出す時機を判定する
+type Trigger struct {
+	policy    Policy
+	version   uint64
+	pending   bool      // 前回の書き出しの後に編集がある
+	firstEdit time.Time // 書き出していない最初の編集を見つけた時刻
+	lastEdit  time.Time // 最後に編集を見つけた時刻
+	lastWrite time.Time
+}
+
+// NewTrigger は新しい Trigger を作成する。version は現在のバッファの版
+func NewTrigger(policy Policy, version uint64) *Trigger {
+	return &Trigger{policy: policy, version: version}
+}
+
+// Observe はバッファの版を受け取り、前回から変わっていれば now に編集があったとして記録する
+func (t *Trigger) Observe(version uint64, now time.Time) {
+	if version == t.version {
+		return
+	}
+	t.version = version
+	if !t.pending {
+		t.pending = true
+		t.firstEdit = now
+	}
+	t.lastEdit = now
+}
+
+// Due は now の時点で書き出す時機かどうかを返す
+func (t *Trigger) Due(now time.Time) bool {
+	if !t.pending || now.Sub(t.lastWrite) < t.policy.MinInterval {
+		return false
+	}
+	if now.Sub(t.lastEdit) >= t.policy.Idle {
+		return true
+	}
+	return t.policy.MaxDelay > 0 && now.Sub(t.firstEdit) >= t.policy.MaxDelay
+}
+
+// Written は now に書き出したことを記録する
+func (t *Trigger) Written(now time.Time) {
+	t.pending = false
+	t.lastWrite = now
+}
+
+// Reset は version の内容を保存したので、それまでの編集を書き出し済みとして扱う
+func (t *Trigger) Reset(version uint64) {
+	t.version = version
+	t.pending = false
+}
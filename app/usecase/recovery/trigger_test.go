@@ -0,0 +1,62 @@
+package recovery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrigger_IdleAfterBurst(t *testing.T) {
+	start := time.Now()
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+	tr := NewTrigger(Policy{Idle: 3 * time.Second, MinInterval: 10 * time.Second, MaxDelay: 30 * time.Second}, 1)
+
+	if tr.Due(at(0)) {
+		t.Error("nothing to write without edits")
+	}
+	// 編集が続いている間は書き出さず、止まって Idle が経ったら書き出す
+	tr.Observe(2, at(0))
+	tr.Observe(3, at(2))
+	if tr.Due(at(4)) {
+		t.Error("must wait for the burst to settle")
+	}
+	tr.Observe(3, at(5))
+	if !tr.Due(at(5)) {
+		t.Error("must write after the idle period")
+	}
+	tr.Written(at(5))
+
+	// 書き出してから MinInterval が経つまでは書き出さない
+	tr.Observe(4, at(6))
+	if tr.Due(at(10)) {
+		t.Error("must respect the minimum interval")
+	}
+	if !tr.Due(at(15)) {
+		t.Error("must write once the minimum interval has passed")
+	}
+
+	// 保存した内容までの編集は書き出し済みとして扱う
+	tr.Reset(4)
+	tr.Observe(4, at(20))
+	if tr.Due(at(30)) {
+		t.Error("saved edits must not be written")
+	}
+}
+
+func TestTrigger_MaxDelay(t *testing.T) {
+	start := time.Now()
+	tr := NewTrigger(Policy{Idle: 3 * time.Second, MaxDelay: 30 * time.Second}, 0)
+
+	// 編集が止まらなくても、最初の編集から MaxDelay が経ったら書き出す
+	for sec := 0; sec < 30; sec++ {
+		now := start.Add(time.Duration(sec) * time.Second)
+		tr.Observe(uint64(sec+1), now)
+		if tr.Due(now) {
+			t.Fatalf("written during the burst at %ds", sec)
+		}
+	}
+	now := start.Add(30 * time.Second)
+	tr.Observe(31, now)
+	if !tr.Due(now) {
+		t.Error("must write after the maximum delay")
+	}
+}
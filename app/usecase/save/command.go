@@ -0,0 +1,76 @@
+package save
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+)
+
+// ErrNoOutput は整形コマンドが何も出力しなかった場合のエラー
+var ErrNoOutput = errors.New("no output on stdout")
+
+// commandTimeout は整形コマンドの実行時間の上限
+const commandTimeout = 10 * time.Second
+
+// filePlaceholder は整形コマンドの引数で保存するファイルのパスに置き換える文字列
+// prettier の --stdin-filepath のように、ファイル名で整形方法を決めるツールに渡す
+const filePlaceholder = "{file}"
+
+// CommandHook はファイルの種類ごとに設定したコマンドで内容を整形するフック
+// 内容を標準入力に渡し、成功した場合だけ標準出力で置き換える。コマンドはシェルを介さず空白で区切って実行する
+type CommandHook struct {
+	runner   external.Runner
+	typeOf   func(filename string) string
+	commands map[string]string
+}
+
+// NewCommandHook は新しい CommandHook を作成する
+// commands はファイルの種類（小文字）ごとのコマンド、typeOf は保存するファイルの種類を返す
+func NewCommandHook(runner external.Runner, typeOf func(filename string) string, commands map[string]string) *CommandHook {
+	return &CommandHook{runner: runner, typeOf: typeOf, commands: commands}
+}
+
+// Name はフック名を返す
+func (h *CommandHook) Name() string {
+	return "format"
+}
+
+// Apply はファイルの種類にコマンドが設定されていれば、内容をそのコマンドに通す。それ以外のファイルはそのまま返す
+// 失敗した場合と、空でない内容に対して何も出力しなかった場合は、元の内容のままエラーを返す
+func (h *CommandHook) Apply(filename string, lines []string) ([]string, error) {
+	command, ok := h.commands[strings.ToLower(h.typeOf(filename))]
+	if !ok {
+		return lines, nil
+	}
+	args := strings.Fields(command)
+	if !h.runner.Available(args[0]) {
+		return lines, fmt.Errorf("%s: %w", args[0], ErrToolNotFound)
+	}
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		path = filename
+	}
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, filePlaceholder, path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	input := formatterInput(lines)
+	out, err := h.runner.Run(ctx, filepath.Dir(path), args[0], args[1:], input)
+	if err != nil {
+		return lines, err
+	}
+	// ファイルを直接書き換えるなど標準出力に結果を出さないコマンドで、内容を消さないようにする
+	if len(bytes.TrimSpace(out)) == 0 && len(bytes.TrimSpace(input)) != 0 {
+		return lines, fmt.Errorf("%s: %w", args[0], ErrNoOutput)
+	}
+	return formatterOutput(lines, out), nil
+}
@@ -0,0 +1,43 @@
+package save
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+)
+
+// GofmtHook は Go のソースを gofmt で整形するフック
+// goimports と違い import の追加・削除は行わない
+type GofmtHook struct {
+	runner external.Runner
+}
+
+// NewGofmtHook は新しい GofmtHook を作成する
+func NewGofmtHook(runner external.Runner) *GofmtHook {
+	return &GofmtHook{runner: runner}
+}
+
+// Name はフック名を返す
+func (h *GofmtHook) Name() string {
+	return "gofmt"
+}
+
+// Apply は .go ファイルの内容を gofmt に通す。それ以外のファイルはそのまま返す
+func (h *GofmtHook) Apply(filename string, lines []string) ([]string, error) {
+	if filepath.Ext(filename) != ".go" {
+		return lines, nil
+	}
+	if !h.runner.Available("gofmt") {
+		return lines, ErrToolNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), goimportsTimeout)
+	defer cancel()
+
+	out, err := h.runner.Run(ctx, filepath.Dir(filename), "gofmt", nil, formatterInput(lines))
+	if err != nil {
+		return lines, err
+	}
+	return formatterOutput(lines, out), nil
+}
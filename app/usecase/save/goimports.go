@@ -0,0 +1,78 @@
+package save
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/external"
+)
+
+// ErrToolNotFound は外部ツールが見つからない場合のエラー
+var ErrToolNotFound = errors.New("command not found in PATH")
+
+// goimportsTimeout は goimports の実行時間の上限
+const goimportsTimeout = 10 * time.Second
+
+// GoImportsHook は Go のソースを goimports で整形するフック
+type GoImportsHook struct {
+	runner external.Runner
+}
+
+// NewGoImportsHook は新しい GoImportsHook を作成する
+func NewGoImportsHook(runner external.Runner) *GoImportsHook {
+	return &GoImportsHook{runner: runner}
+}
+
+// Name はフック名を返す
+func (h *GoImportsHook) Name() string {
+	return "goimports"
+}
+
+// Apply は .go ファイルの内容を goimports に通す。それ以外のファイルはそのまま返す
+func (h *GoImportsHook) Apply(filename string, lines []string) ([]string, error) {
+	if filepath.Ext(filename) != ".go" {
+		return lines, nil
+	}
+	if !h.runner.Available("goimports") {
+		return lines, ErrToolNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), goimportsTimeout)
+	defer cancel()
+
+	dir := filepath.Dir(filename)
+	// -srcdir を指定して同じパッケージ内の識別子をimport候補から除外させる
+	out, err := h.runner.Run(ctx, dir, "goimports", []string{"-srcdir", dir}, formatterInput(lines))
+	if err != nil {
+		return lines, err
+	}
+	return formatterOutput(lines, out), nil
+}
+
+// SplitOutput は外部ツールの出力を行に分割する（末尾の改行1つは行として扱わない）
+func SplitOutput(out []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+// formatterInput は整形ツールの標準入力に渡す内容を返す
+// 最後の行が空（ファイルが改行で終わる）なら結合しただけで改行で終わるため、改行を重ねない
+func formatterInput(lines []string) []byte {
+	text := strings.Join(lines, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return []byte(text)
+}
+
+// formatterOutput は整形ツールの出力を行に分割する
+// SplitOutput は末尾の改行を取り除くため、元の内容が改行で終わっていれば最後の空行を戻す
+func formatterOutput(lines []string, out []byte) []string {
+	result := SplitOutput(out)
+	if len(lines) > 0 && lines[len(lines)-1] == "" && result[len(result)-1] != "" {
+		result = append(result, "")
+	}
+	return result
+}
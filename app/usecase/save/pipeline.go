@@ -0,0 +1,80 @@
+package save
+
+import "fmt"
+
+// Hook は保存直前にバッファの内容を変換する処理
+type Hook interface {
+	// Name はステータスメッセージに表示するフック名を返す
+	Name() string
+	// Apply は filename に保存される lines を変換して返す
+	Apply(filename string, lines []string) ([]string, error)
+}
+
+// Reporter は適用した変換の内容を報告するフック。報告はステータスメッセージに表示する
+type Reporter interface {
+	// Report は lines を out に変換した内容を説明する文を返す。報告することがなければ空文字列を返す
+	Report(lines, out []string) string
+}
+
+// Result は保存パイプラインの実行結果
+type Result struct {
+	Lines   []string // 保存すべき内容
+	Changed bool     // フックによって内容が変更されたか
+	Errors  []error  // 失敗したフックのエラー（失敗したフックの変換は適用されない）
+	Notes   []string // Reporter を実装したフックが報告した変換の内容
+}
+
+// Pipeline は保存前フックを順番に適用する
+type Pipeline struct {
+	hooks []Hook
+}
+
+// NewPipeline は新しい Pipeline を作成する
+func NewPipeline(hooks ...Hook) *Pipeline {
+	return &Pipeline{hooks: hooks}
+}
+
+// Add はフックを末尾に追加する
+func (p *Pipeline) Add(h Hook) {
+	p.hooks = append(p.hooks, h)
+}
+
+// Len は登録されているフックの数を返す
+func (p *Pipeline) Len() int {
+	return len(p.hooks)
+}
+
+// Run は全てのフックを適用する
+// フックが失敗しても保存自体は継続できるよう、直前までの内容を保持して次のフックへ進む
+func (p *Pipeline) Run(filename string, lines []string) Result {
+	result := Result{Lines: lines}
+	for _, h := range p.hooks {
+		out, err := h.Apply(filename, result.Lines)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", h.Name(), err))
+			continue
+		}
+		if !equalLines(out, result.Lines) {
+			if r, ok := h.(Reporter); ok {
+				if note := r.Report(result.Lines, out); note != "" {
+					result.Notes = append(result.Notes, note)
+				}
+			}
+			result.Lines = out
+			result.Changed = true
+		}
+	}
+	return result
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
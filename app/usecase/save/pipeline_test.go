@@ -0,0 +1,178 @@
+package save
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type upperHook struct{}
+
+func (upperHook) Name() string { return "upper" }
+func (upperHook) Apply(filename string, lines []string) ([]string, error) {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.ToUpper(l)
+	}
+	return out, nil
+}
+
+type failingHook struct{}
+
+func (failingHook) Name() string { return "failing" }
+func (failingHook) Apply(filename string, lines []string) ([]string, error) {
+	return nil, errors.New("boom")
+}
+
+func TestPipeline_Run(t *testing.T) {
+	p := NewPipeline(failingHook{}, upperHook{})
+	result := p.Run("a.txt", []string{"abc"})
+
+	if !result.Changed || !reflect.DeepEqual(result.Lines, []string{"ABC"}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Errors) != 1 || !strings.HasPrefix(result.Errors[0].Error(), "failing:") {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+}
+
+type fakeRunner struct {
+	available bool
+	stdin     string
+	out       string
+	err       error
+	name      string
+	args      []string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, dir, name string, args []string, stdin []byte) ([]byte, error) {
+	f.name, f.args, f.stdin = name, args, string(stdin)
+	return []byte(f.out), f.err
+}
+
+func (f *fakeRunner) Available(name string) bool { return f.available }
+
+func TestGoImportsHook(t *testing.T) {
+	runner := &fakeRunner{available: true, out: "package main\n\nimport \"fmt\"\n"}
+	h := NewGoImportsHook(runner)
+
+	lines, err := h.Apply("readme.md", []string{"x"})
+	if err != nil || !reflect.DeepEqual(lines, []string{"x"}) || runner.stdin != "" {
+		t.Errorf("non-Go files must be left untouched: %v %v", lines, err)
+	}
+
+	lines, err = h.Apply("main.go", []string{"package main", "", "import \"fmt\""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(lines, []string{"package main", "", "import \"fmt\""}) {
+		t.Errorf("unexpected output: %q", lines)
+	}
+
+	runner.available = false
+	if _, err := h.Apply("main.go", []string{"package main"}); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+}
+
+func TestPipeline_KeepsFinalNewline(t *testing.T) {
+	runner := &fakeRunner{available: true, out: "package main\n\nfunc main() {}\n"}
+	lines := []string{"package main", "", "func main() {}", ""}
+
+	// 整形しても変わらない内容は、最後の空行（ファイル末尾の改行）を含めて変更なしとする
+	result := NewPipeline(NewGoImportsHook(runner)).Run("main.go", lines)
+	if result.Changed || !reflect.DeepEqual(result.Lines, lines) || len(result.Errors) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if runner.stdin != "package main\n\nfunc main() {}\n" {
+		t.Errorf("unexpected stdin: %q", runner.stdin)
+	}
+}
+
+func TestGofmtHook(t *testing.T) {
+	runner := &fakeRunner{available: true, out: "package main\n\nfunc main() {}\n"}
+	h := NewGofmtHook(runner)
+
+	lines, err := h.Apply("main.go", []string{"package main", "func main(){}"})
+	if err != nil || !reflect.DeepEqual(lines, []string{"package main", "", "func main() {}"}) {
+		t.Errorf("unexpected output: %q %v", lines, err)
+	}
+	lines, err = h.Apply("main.go", []string{"package main", "func main(){}", ""})
+	if err != nil || !reflect.DeepEqual(lines, []string{"package main", "", "func main() {}", ""}) {
+		t.Errorf("the final newline must be kept: %q %v", lines, err)
+	}
+
+	runner.available = false
+	if _, err := h.Apply("main.go", []string{"package main"}); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+}
+
+func TestTrimHook(t *testing.T) {
+	h := NewTrimHook()
+	lines := []string{"a  ", "b", "\t", "c\t \t"}
+	out, err := h.Apply("a.txt", lines)
+	if err != nil || !reflect.DeepEqual(out, []string{"a", "b", "", "c"}) {
+		t.Fatalf("Apply() = %q, %v", out, err)
+	}
+	if lines[0] != "a  " {
+		t.Error("the input lines must not be modified")
+	}
+
+	result := NewPipeline(h, upperHook{}).Run("a.txt", lines)
+	if !reflect.DeepEqual(result.Notes, []string{"trimmed trailing whitespace on 3 lines"}) {
+		t.Errorf("unexpected notes: %q", result.Notes)
+	}
+	// 末尾の空白がなければ報告しない
+	if result := NewPipeline(h).Run("a.txt", []string{"x"}); result.Changed || len(result.Notes) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	runner := &fakeRunner{available: true, out: "x = 1\n"}
+	typeOf := func(filename string) string {
+		if strings.HasSuffix(filename, ".py") {
+			return "Python"
+		}
+		return "Text"
+	}
+	h := NewCommandHook(runner, typeOf, map[string]string{"python": "black -q --stdin-filename {file} -"})
+
+	lines, err := h.Apply("notes.txt", []string{"x=1"})
+	if err != nil || !reflect.DeepEqual(lines, []string{"x=1"}) || runner.name != "" {
+		t.Errorf("files without a command must be left untouched: %v %v", lines, err)
+	}
+
+	lines, err = h.Apply("/src/a.py", []string{"x=1"})
+	if err != nil || !reflect.DeepEqual(lines, []string{"x = 1"}) {
+		t.Fatalf("Apply() = %q, %v", lines, err)
+	}
+	if runner.name != "black" || !reflect.DeepEqual(runner.args, []string{"-q", "--stdin-filename", "/src/a.py", "-"}) || runner.stdin != "x=1\n" {
+		t.Errorf("unexpected command: %s %q %q", runner.name, runner.args, runner.stdin)
+	}
+
+	// ファイルが改行で終わる場合は、改行を重ねずに渡して最後の空行を戻す
+	runner.out = "b\na\n"
+	lines, err = h.Apply("/src/a.py", []string{"a", "b", ""})
+	if err != nil || !reflect.DeepEqual(lines, []string{"b", "a", ""}) || runner.stdin != "a\nb\n" {
+		t.Errorf("Apply() = %q, %v (stdin %q)", lines, err, runner.stdin)
+	}
+
+	// 失敗した場合と何も出力しなかった場合は元の内容を保つ
+	runner.err = errors.New("black: exit status 123: cannot parse")
+	if lines, err := h.Apply("/src/a.py", []string{"x=("}); err == nil || !reflect.DeepEqual(lines, []string{"x=("}) {
+		t.Errorf("Apply() = %q, %v", lines, err)
+	}
+	runner.err, runner.out = nil, ""
+	if lines, err := h.Apply("/src/a.py", []string{"x=1"}); !errors.Is(err, ErrNoOutput) || !reflect.DeepEqual(lines, []string{"x=1"}) {
+		t.Errorf("Apply() = %q, %v", lines, err)
+	}
+
+	runner.available = false
+	if _, err := h.Apply("/src/a.py", []string{"x=1"}); !errors.Is(err, ErrToolNotFound) || !strings.HasPrefix(err.Error(), "black:") {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+}
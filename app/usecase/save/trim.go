@@ -0,0 +1,52 @@
+package save
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrimHook は各行の末尾の空白（スペースとタブ）を取り除くフック
+type TrimHook struct{}
+
+// NewTrimHook は新しい TrimHook を作成する
+func NewTrimHook() *TrimHook {
+	return &TrimHook{}
+}
+
+// Name はフック名を返す
+func (h *TrimHook) Name() string {
+	return "trim trailing whitespace"
+}
+
+// Apply は全ての行の末尾の空白を取り除く。どの行にも末尾の空白がなければ lines をそのまま返す
+func (h *TrimHook) Apply(filename string, lines []string) ([]string, error) {
+	var out []string
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if out == nil && trimmed == line {
+			continue
+		}
+		if out == nil {
+			out = append(make([]string, 0, len(lines)), lines[:i]...)
+		}
+		out = append(out, trimmed)
+	}
+	if out == nil {
+		return lines, nil
+	}
+	return out, nil
+}
+
+// Report は末尾の空白を取り除いた行数を返す
+func (h *TrimHook) Report(lines, out []string) string {
+	n := 0
+	for i := range lines {
+		if i < len(out) && lines[i] != out[i] {
+			n++
+		}
+	}
+	if n == 1 {
+		return "trimmed trailing whitespace on 1 line"
+	}
+	return fmt.Sprintf("trimmed trailing whitespace on %d lines", n)
+}
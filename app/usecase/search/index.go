@@ -0,0 +1,87 @@
+package search
+
+import "sort"
+
+// Index は確定した検索文字列に対するバッファ全体の一致箇所を、位置の順に並べた一覧
+// バッファの版を記録しておき、編集で版が変わった後に参照された時点で探し直す
+type Index struct {
+	query   string
+	regexp  bool
+	version uint64 // matches を探したときのバッファの版
+	built   bool
+	matches []Match
+}
+
+// NewIndex は query の一致箇所の一覧を作成する。一致箇所は最初に参照された時点で探す
+func NewIndex(query string, regexp bool) *Index {
+	return &Index{query: query, regexp: regexp}
+}
+
+// Index は State の検索文字列と一致箇所から、版 version のバッファの Index を作成する
+// 入力中に探した一致箇所をそのまま使うため、探し直さない
+func (s *State) Index(version uint64) *Index {
+	return &Index{query: s.query, regexp: s.regexp, version: version, built: true, matches: s.matches}
+}
+
+// Query は検索文字列を返す
+func (ix *Index) Query() string {
+	return ix.query
+}
+
+// Matches は版 version のバッファの一致箇所を返す
+// 前回探したときから版が変わっていれば、lines で内容を取得して探し直す
+// 正規表現として解釈できない検索文字列の場合は一致なしとして扱う
+func (ix *Index) Matches(version uint64, lines func() []string) []Match {
+	if ix.built && ix.version == version {
+		return ix.matches
+	}
+	if ix.regexp {
+		ix.matches, _ = FindRegexp(lines(), ix.query)
+	} else {
+		ix.matches = Find(lines(), ix.query)
+	}
+	ix.version, ix.built = version, true
+	return ix.matches
+}
+
+// At は (y, x) を含む一致箇所の番号（0始まり）を返す。一致箇所の上になければ false を返す
+func (ix *Index) At(version uint64, lines func() []string, y, x int) (int, bool) {
+	matches := ix.Matches(version, lines)
+	// (y, x) より後ろで始まる最初の一致箇所の手前が、(y, x) を含み得る唯一の一致箇所
+	i := sort.Search(len(matches), func(i int) bool { return after(matches[i], y, x) }) - 1
+	if i < 0 || matches[i].Line != y || x >= matches[i].Col+matches[i].Length {
+		return -1, false
+	}
+	return i, true
+}
+
+// Next は (y, x) より後ろで始まる最初の一致箇所を返す。末尾を過ぎたら先頭に戻る
+func (ix *Index) Next(version uint64, lines func() []string, y, x int) (Match, int, bool) {
+	matches := ix.Matches(version, lines)
+	if len(matches) == 0 {
+		return Match{}, -1, false
+	}
+	i := sort.Search(len(matches), func(i int) bool { return after(matches[i], y, x) })
+	if i == len(matches) {
+		i = 0
+	}
+	return matches[i], i, true
+}
+
+// Prev は (y, x) より前で始まる最後の一致箇所を返す。先頭を過ぎたら末尾に戻る
+func (ix *Index) Prev(version uint64, lines func() []string, y, x int) (Match, int, bool) {
+	matches := ix.Matches(version, lines)
+	if len(matches) == 0 {
+		return Match{}, -1, false
+	}
+	i := sort.Search(len(matches), func(i int) bool { return !before(matches[i], y, x) }) - 1
+	if i < 0 {
+		i = len(matches) - 1
+	}
+	return matches[i], i, true
+}
+
+// after は m が (y, x) より後ろで始まるかどうかを返す
+func after(m Match, y, x int) bool {
+	return m.Line > y || (m.Line == y && m.Col > x)
+}
@@ -0,0 +1,72 @@
+package search
+
+import "testing"
+
+func TestIndex_RecomputesLazily(t *testing.T) {
+	lines := []string{"foo bar", "bar foo foo"}
+	reads := 0
+	get := func() []string {
+		reads++
+		return lines
+	}
+	ix := NewIndex("foo", false)
+	if reads != 0 {
+		t.Fatal("matches must not be searched before they are needed")
+	}
+	if got := ix.Matches(1, get); len(got) != 3 || reads != 1 {
+		t.Fatalf("unexpected matches %v after %d reads", got, reads)
+	}
+	ix.Matches(1, get)
+	if reads != 1 {
+		t.Error("the same version must reuse the matches")
+	}
+
+	lines = []string{"foo"}
+	if got := ix.Matches(2, get); len(got) != 1 || reads != 2 {
+		t.Errorf("an edited buffer must be searched again: %v after %d reads", got, reads)
+	}
+}
+
+func TestIndex_Navigation(t *testing.T) {
+	lines := []string{"x one", "two x", "x three"}
+	get := func() []string { return lines }
+	ix := NewIndex("x", false)
+
+	if i, ok := ix.At(0, get, 1, 4); !ok || i != 1 {
+		t.Errorf("At on a match = %d %v", i, ok)
+	}
+	if _, ok := ix.At(0, get, 1, 3); ok {
+		t.Error("At beside a match must fail")
+	}
+	if m, i, _ := ix.Next(0, get, 1, 4); i != 2 || m != (Match{2, 0, 1}) {
+		t.Errorf("Next = %v %d", m, i)
+	}
+	if m, i, _ := ix.Next(0, get, 2, 0); i != 0 || m != (Match{0, 0, 1}) {
+		t.Errorf("Next must wrap around, got %v %d", m, i)
+	}
+	if m, i, _ := ix.Prev(0, get, 1, 4); i != 0 || m != (Match{0, 0, 1}) {
+		t.Errorf("Prev = %v %d", m, i)
+	}
+	if m, i, _ := ix.Prev(0, get, 0, 0); i != 2 || m != (Match{2, 0, 1}) {
+		t.Errorf("Prev must wrap around, got %v %d", m, i)
+	}
+
+	empty := NewIndex("zzz", false)
+	if _, _, ok := empty.Next(0, get, 0, 0); ok {
+		t.Error("Next without matches must fail")
+	}
+}
+
+func TestState_Index(t *testing.T) {
+	s := New(0, 0)
+	s.SetQuery("x", []string{"x x"})
+	ix := s.Index(7)
+	// 入力中に探した一致箇所を使うため、同じ版では内容を読まない
+	got := ix.Matches(7, func() []string {
+		t.Error("the matches of the state must be reused")
+		return nil
+	})
+	if len(got) != 2 || ix.Query() != "x" {
+		t.Errorf("unexpected index: %q %v", ix.Query(), got)
+	}
+}
@@ -0,0 +1,98 @@
+package search
+
+import "sort"
+
+// Replacer は確認しながら置換する際の、次に確認する一致箇所の位置を管理する
+// 開始位置から末尾まで進んだ後は先頭に戻り、開始位置の手前まで確認する
+// 置換で行の内容が変わるため、一致箇所は毎回バッファの最新の内容から探し直す
+type Replacer struct {
+	query            string
+	originY, originX int  // 置換を開始した位置
+	y, x             int  // 次に確認する一致箇所の開始位置の下限
+	wrapped          bool // 末尾まで進んで先頭に戻った
+}
+
+// NewReplacer は (y, x) から query を探す Replacer を作成する
+func NewReplacer(query string, y, x int) *Replacer {
+	return &Replacer{query: query, originY: y, originX: x, y: y, x: x}
+}
+
+// Next は次に確認する一致箇所を返す。確認する一致箇所が残っていなければ false を返す
+func (r *Replacer) Next(lines []string) (Match, bool) {
+	matches := Find(lines, r.query)
+	for {
+		for _, m := range matches {
+			if r.pending(m) {
+				return m, true
+			}
+		}
+		if r.wrapped {
+			return Match{}, false
+		}
+		r.wrapped = true
+		r.y, r.x = 0, 0
+	}
+}
+
+// Remaining は確認していない一致箇所を全て返す
+func (r *Replacer) Remaining(lines []string) []Match {
+	var rest []Match
+	for _, m := range Find(lines, r.query) {
+		if r.pending(m) || (!r.wrapped && before(m, r.originY, r.originX)) {
+			rest = append(rest, m)
+		}
+	}
+	return rest
+}
+
+// Skip は m を置換せずに次の一致箇所へ進む
+func (r *Replacer) Skip(m Match) {
+	r.y, r.x = m.Line, m.Col+m.Length
+}
+
+// Replaced は m を長さ n の文字列に置換したことを記録し、置換後の文字列の後ろへ進む
+// 置換後の文字列の中の一致箇所は確認しない
+func (r *Replacer) Replaced(m Match, n int) {
+	if r.wrapped && m.Line == r.originY && m.Col < r.originX {
+		r.originX += n - m.Length
+	}
+	r.y, r.x = m.Line, m.Col+n
+}
+
+// pending は m がまだ確認していない一致箇所かどうかを返す
+func (r *Replacer) pending(m Match) bool {
+	if before(m, r.y, r.x) {
+		return false
+	}
+	return !r.wrapped || before(m, r.originY, r.originX)
+}
+
+// before は m が (y, x) より前から始まるかどうかを返す
+func before(m Match, y, x int) bool {
+	return m.Line < y || (m.Line == y && m.Col < x)
+}
+
+// ReplacedLines は matches をすべて replacement に置き換えた後の内容を返す。lines は変更しない
+// 一致箇所は重ならないものとし、同じ行の置き換えによる位置のずれは後ろから置き換えて避ける
+func ReplacedLines(lines []string, matches []Match, replacement string) []string {
+	byLine := make(map[int][]Match)
+	for _, m := range matches {
+		byLine[m.Line] = append(byLine[m.Line], m)
+	}
+	out := append([]string{}, lines...)
+	for y, ms := range byLine {
+		if y < 0 || y >= len(out) {
+			continue
+		}
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Col > ms[j].Col })
+		runes := []rune(out[y])
+		for _, m := range ms {
+			if m.Col < 0 || m.Col+m.Length > len(runes) {
+				continue
+			}
+			runes = append(runes[:m.Col], append([]rune(replacement), runes[m.Col+m.Length:]...)...)
+		}
+		out[y] = string(runes)
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplacer_WrapsAroundToOrigin(t *testing.T) {
+	lines := []string{"x one", "two x", "x three"}
+	r := NewReplacer("x", 1, 1)
+
+	var got []Match
+	for {
+		m, ok := r.Next(lines)
+		if !ok {
+			break
+		}
+		got = append(got, m)
+		r.Skip(m)
+	}
+	want := []Match{{1, 4, 1}, {2, 0, 1}, {0, 0, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReplacer_SkipsReplacedText(t *testing.T) {
+	// 置換後の文字列に検索文字列が含まれていても、同じ箇所を繰り返し確認しない
+	lines := []string{"a a"}
+	r := NewReplacer("a", 0, 0)
+
+	m, _ := r.Next(lines)
+	lines = []string{"aa a"}
+	r.Replaced(m, 2)
+	if m, ok := r.Next(lines); !ok || m != (Match{0, 3, 1}) {
+		t.Fatalf("expected the second match, got %v %v", m, ok)
+	}
+}
+
+func TestReplacer_Remaining(t *testing.T) {
+	lines := []string{"x x", "x", "x"}
+	r := NewReplacer("x", 1, 0)
+
+	m, _ := r.Next(lines)
+	r.Skip(m)
+	want := []Match{{0, 0, 1}, {0, 2, 1}, {2, 0, 1}}
+	if got := r.Remaining(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReplacer_OriginFollowsReplacementsBeforeIt(t *testing.T) {
+	lines := []string{"x x"}
+	r := NewReplacer("x", 0, 2)
+
+	m, _ := r.Next(lines) // (0,2)
+	r.Skip(m)
+	m, _ = r.Next(lines) // 先頭に戻って (0,0)
+	if m != (Match{0, 0, 1}) {
+		t.Fatalf("unexpected match: %v", m)
+	}
+	lines = []string{"yyy x"}
+	r.Replaced(m, 3)
+	if m, ok := r.Next(lines); ok {
+		t.Errorf("the match at the origin must not be visited twice: %v", m)
+	}
+}
+
+func TestReplacedLines(t *testing.T) {
+	lines := []string{"x x", "日本x", "y"}
+	got := ReplacedLines(lines, []Match{{0, 0, 1}, {0, 2, 1}, {1, 2, 1}}, "ab")
+	want := []string{"ab ab", "日本ab", "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if lines[0] != "x x" {
+		t.Error("the original lines must not be changed")
+	}
+}
@@ -0,0 +1,206 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Match はバッファ内の一致箇所（ルーン単位）
+type Match struct {
+	Line   int
+	Col    int
+	Length int
+}
+
+// State はインクリメンタル検索の状態
+// 入力のたびに SetQuery で一致箇所を計算し直し、Next/Prev で一致箇所を巡回する
+type State struct {
+	originY, originX int // 検索を開始したカーソル位置
+	query            string
+	regexp           bool  // 検索文字列を正規表現として扱う
+	err              error // 正規表現として解釈できなかった場合のエラー
+	matches          []Match
+	current          int // 選択中の一致箇所（一致がなければ -1）
+}
+
+// New は (y, x) から検索を開始する State を作成する
+func New(y, x int) *State {
+	return &State{originY: y, originX: x, current: -1}
+}
+
+// Origin は検索を開始した位置を返す
+func (s *State) Origin() (y, x int) {
+	return s.originY, s.originX
+}
+
+// Query は現在の検索文字列を返す
+func (s *State) Query() string {
+	return s.query
+}
+
+// Regexp は検索文字列を正規表現として扱っているかどうかを返す
+func (s *State) Regexp() bool {
+	return s.regexp
+}
+
+// Err は検索文字列が正規表現として正しくない場合にそのエラーを返す
+func (s *State) Err() error {
+	return s.err
+}
+
+// SetRegexp は検索文字列を正規表現として扱うかどうかを切り替え、lines から一致箇所を探し直す
+func (s *State) SetRegexp(on bool, lines []string) {
+	s.regexp = on
+	s.SetQuery(s.query, lines)
+}
+
+// SetQuery は検索文字列を変更し、lines から一致箇所を探し直す
+// 選択中の一致箇所は検索開始位置以降で最初のもの（なければ先頭に戻る）になる
+// 検索文字列が小文字だけの場合は大文字小文字を区別しない
+func (s *State) SetQuery(query string, lines []string) {
+	s.query = query
+	s.err = nil
+	if s.regexp {
+		s.matches, s.err = FindRegexp(lines, query)
+	} else {
+		s.matches = Find(lines, query)
+	}
+	s.current = -1
+	if len(s.matches) == 0 {
+		return
+	}
+	s.current = 0
+	for i, m := range s.matches {
+		if m.Line > s.originY || (m.Line == s.originY && m.Col >= s.originX) {
+			s.current = i
+			return
+		}
+	}
+}
+
+// Matches は全ての一致箇所を返す
+func (s *State) Matches() []Match {
+	return s.matches
+}
+
+// Current は選択中の一致箇所とその番号（0始まり）を返す
+func (s *State) Current() (Match, int, bool) {
+	if s.current < 0 {
+		return Match{}, -1, false
+	}
+	return s.matches[s.current], s.current, true
+}
+
+// Next は次の一致箇所を選択する。末尾の次は先頭に戻る
+func (s *State) Next() (Match, bool) {
+	if len(s.matches) == 0 {
+		return Match{}, false
+	}
+	s.current = (s.current + 1) % len(s.matches)
+	return s.matches[s.current], true
+}
+
+// Prev は前の一致箇所を選択する。先頭の前は末尾に戻る
+func (s *State) Prev() (Match, bool) {
+	if len(s.matches) == 0 {
+		return Match{}, false
+	}
+	s.current = (s.current - 1 + len(s.matches)) % len(s.matches)
+	return s.matches[s.current], true
+}
+
+// Find は lines から query に一致する箇所を全て探す（重なる一致は数えない）
+func Find(lines []string, query string) []Match {
+	if query == "" {
+		return nil
+	}
+	pattern := []rune(query)
+	fold := !hasUpper(pattern)
+	if fold {
+		pattern = []rune(strings.ToLower(query))
+	}
+
+	var matches []Match
+	for y, line := range lines {
+		runes := []rune(line)
+		for x := 0; x+len(pattern) <= len(runes); x++ {
+			if equalAt(runes, x, pattern, fold) {
+				matches = append(matches, Match{Line: y, Col: x, Length: len(pattern)})
+				x += len(pattern) - 1
+			}
+		}
+	}
+	return matches
+}
+
+// FindRegexp は lines から正規表現 pattern に一致する箇所を全て探す
+// 一致箇所の位置と長さはバイト単位ではなくルーン単位で返す。空文字列への一致は数えない
+// Find と同じく、pattern が小文字だけの場合は大文字小文字を区別しない
+func FindRegexp(lines []string, pattern string) ([]Match, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if !hasUpperLiteral(pattern) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for y, line := range lines {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			col := utf8.RuneCountInString(line[:loc[0]])
+			matches = append(matches, Match{Line: y, Col: col, Length: utf8.RuneCountInString(line[loc[0]:loc[1]])})
+		}
+	}
+	return matches, nil
+}
+
+func equalAt(runes []rune, x int, pattern []rune, fold bool) bool {
+	for i, p := range pattern {
+		r := runes[x+i]
+		if fold {
+			r = unicode.ToLower(r)
+		}
+		if r != p {
+			return false
+		}
+	}
+	return true
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUpperLiteral は正規表現に大文字が含まれるかどうかを返す
+// \S や \p{Han} のようなエスケープの中の大文字は数えない
+func hasUpperLiteral(pattern string) bool {
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			if (runes[i] == 'p' || runes[i] == 'P') && i+1 < len(runes) && runes[i+1] == '{' {
+				for i < len(runes) && runes[i] != '}' {
+					i++
+				}
+			}
+		case unicode.IsUpper(runes[i]):
+			return true
+		}
+	}
+	return false
+}
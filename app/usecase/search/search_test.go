@@ -0,0 +1,103 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	lines := []string{"Foo foo", "aaaa", "日本語の本"}
+	tests := []struct {
+		query string
+		want  []Match
+	}{
+		{"foo", []Match{{0, 0, 3}, {0, 4, 3}}},
+		{"Foo", []Match{{0, 0, 3}}},
+		{"aa", []Match{{1, 0, 2}, {1, 2, 2}}},
+		{"本", []Match{{2, 1, 1}, {2, 4, 1}}},
+		{"", nil},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		if got := Find(lines, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	lines := []string{"x one", "two x", "x three"}
+	s := New(1, 1)
+
+	s.SetQuery("x", lines)
+	m, i, ok := s.Current()
+	if !ok || i != 1 || m != (Match{1, 4, 1}) {
+		t.Fatalf("first match after the origin expected, got %v %d %v", m, i, ok)
+	}
+	if m, _ := s.Next(); m != (Match{2, 0, 1}) {
+		t.Errorf("Next = %v", m)
+	}
+	if m, _ := s.Next(); m != (Match{0, 0, 1}) {
+		t.Errorf("Next must wrap around, got %v", m)
+	}
+	if m, _ := s.Prev(); m != (Match{2, 0, 1}) {
+		t.Errorf("Prev must wrap around, got %v", m)
+	}
+
+	s.SetQuery("xyz", lines)
+	if _, _, ok := s.Current(); ok {
+		t.Error("no match expected")
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("Next without matches must fail")
+	}
+	if y, x := s.Origin(); y != 1 || x != 1 {
+		t.Errorf("unexpected origin %d,%d", y, x)
+	}
+}
+
+func TestFindRegexp(t *testing.T) {
+	lines := []string{"日本語 foo42", "Foo7 bar", "x"}
+	tests := []struct {
+		pattern string
+		want    []Match
+	}{
+		// 一致箇所の位置はバイト単位ではなくルーン単位
+		{`foo\d+`, []Match{{0, 4, 5}, {1, 0, 4}}},
+		{`Foo\d`, []Match{{1, 0, 4}}},
+		{`\S+語`, []Match{{0, 0, 3}}},
+		{`x*`, []Match{{2, 0, 1}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := FindRegexp(lines, tt.pattern)
+		if err != nil {
+			t.Fatalf("FindRegexp(%q): %v", tt.pattern, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindRegexp(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := FindRegexp(lines, "("); err == nil {
+		t.Error("an invalid pattern must be reported")
+	}
+}
+
+func TestState_Regexp(t *testing.T) {
+	lines := []string{"a1 b22", "c333"}
+	s := New(0, 0)
+
+	s.SetQuery(`\d+`, lines)
+	if len(s.Matches()) != 0 {
+		t.Fatalf("plain search must not interpret the pattern: %v", s.Matches())
+	}
+	s.SetRegexp(true, lines)
+	if got := s.Matches(); len(got) != 3 || got[2] != (Match{1, 1, 3}) {
+		t.Errorf("unexpected matches: %v", got)
+	}
+	s.SetQuery(`\d+(`, lines)
+	if s.Err() == nil || len(s.Matches()) != 0 {
+		t.Errorf("invalid pattern must clear the matches and report an error: %v %v", s.Matches(), s.Err())
+	}
+}
@@ -0,0 +1,160 @@
+package substitute
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrSyntax は置換式の書式が正しくない場合のエラー
+var ErrSyntax = errors.New("invalid substitution")
+
+// Substitution は sed 形式の置換式 s/pattern/replacement/flags を表す
+type Substitution struct {
+	re          *regexp.Regexp
+	replacement string // regexp.Expand 形式に変換済みの置換文字列
+	global      bool
+}
+
+// Parse は s/pattern/replacement/flags 形式の置換式を解析する
+// 区切り文字には s の直後の任意の1文字を使用でき、flags には g（全て置換）と i（大文字小文字を区別しない）を指定できる
+// 置換文字列では sed と同様に & がマッチ全体、\1〜\9 がグループを表す
+func Parse(expr string) (*Substitution, error) {
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, fmt.Errorf("%w: expression must start with s: %q", ErrSyntax, expr)
+	}
+	delim, size := utf8.DecodeRuneInString(expr[1:])
+	if delim == '\\' || delim == '\n' || delim == utf8.RuneError {
+		return nil, fmt.Errorf("%w: bad delimiter in %q", ErrSyntax, expr)
+	}
+	parts := splitUnescaped(expr[1+size:], delim)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected s%c...%c...%c[flags]: %q", ErrSyntax, delim, delim, delim, expr)
+	}
+	pattern, replacement, flags := parts[0], parts[1], parts[2]
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: empty pattern", ErrSyntax)
+	}
+
+	s := &Substitution{}
+	prefix := ""
+	for _, f := range flags {
+		switch f {
+		case 'g':
+			s.global = true
+		case 'i':
+			prefix = "(?i)"
+		default:
+			return nil, fmt.Errorf("%w: unknown flag %q", ErrSyntax, f)
+		}
+	}
+	re, err := regexp.Compile(prefix + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	s.re = re
+	s.replacement = convertReplacement(replacement)
+	return s, nil
+}
+
+// Apply は各行に置換を適用し、置換後の行と置換した箇所の数を返す
+// 行末の \r は置換の対象に含めず、そのまま残す
+func (s *Substitution) Apply(lines []string) ([]string, int) {
+	out := make([]string, len(lines))
+	count := 0
+	for i, line := range lines {
+		body, cr := strings.CutSuffix(line, "\r")
+		replaced, n := s.applyLine(body)
+		count += n
+		if cr {
+			replaced += "\r"
+		}
+		out[i] = replaced
+	}
+	return out, count
+}
+
+// applyLine は1行に置換を適用する。g フラグがない場合は最初のマッチだけを置換する
+func (s *Substitution) applyLine(line string) (string, int) {
+	limit := 1
+	if s.global {
+		limit = -1
+	}
+	matches := s.re.FindAllStringSubmatchIndex(line, limit)
+	if len(matches) == 0 {
+		return line, 0
+	}
+	var b []byte
+	last := 0
+	for _, m := range matches {
+		b = append(b, line[last:m[0]]...)
+		b = s.re.ExpandString(b, s.replacement, line, m)
+		last = m[1]
+	}
+	b = append(b, line[last:]...)
+	return string(b), len(matches)
+}
+
+// splitUnescaped は \ でエスケープされていない delim で文字列を分割する
+// エスケープされた区切り文字は区切り文字そのものに置き換え、それ以外のエスケープはそのまま残す
+func splitUnescaped(s string, delim rune) []string {
+	var parts []string
+	var cur strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != delim {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == delim:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if escaped {
+		cur.WriteRune('\\')
+	}
+	return append(parts, cur.String())
+}
+
+// convertReplacement は sed 形式の置換文字列を regexp.Expand 形式に変換する
+func convertReplacement(repl string) string {
+	var b strings.Builder
+	runes := []rune(repl)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			next := runes[i]
+			switch {
+			case '0' <= next && next <= '9':
+				fmt.Fprintf(&b, "${%c}", next)
+			case next == 'n':
+				b.WriteByte('\n')
+			case next == 't':
+				b.WriteByte('\t')
+			case next == '$':
+				b.WriteString("$$")
+			default:
+				b.WriteRune(next)
+			}
+		case r == '&':
+			b.WriteString("${0}")
+		case r == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package substitute
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseAndApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		lines []string
+		want  []string
+		count int
+	}{
+		{"first match only", "s/foo/bar/", []string{"foo foo"}, []string{"bar foo"}, 1},
+		{"global", "s/foo/bar/g", []string{"foo foo", "x"}, []string{"bar bar", "x"}, 2},
+		{"ignore case", "s/foo/bar/gi", []string{"Foo FOO"}, []string{"bar bar"}, 2},
+		{"other delimiter", "s|/usr|/opt|", []string{"/usr/bin"}, []string{"/opt/bin"}, 1},
+		{"escaped delimiter", `s/a\/b/c/`, []string{"a/b"}, []string{"c"}, 1},
+		{"groups and ampersand", `s/(\w+)=(\w+)/\2=\1 [&]/`, []string{"k=v"}, []string{"v=k [k=v]"}, 1},
+		{"dollar is literal", "s/x/$1/", []string{"x"}, []string{"$1"}, 1},
+		{"keeps CR", "s/a$/b/", []string{"a\r", "a"}, []string{"b\r", "b"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, count := s.Apply(tt.lines)
+			if !reflect.DeepEqual(got, tt.want) || count != tt.count {
+				t.Errorf("got %q (%d), want %q (%d)", got, count, tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{"", "x/a/b/", "s/a/b", "s//b/", "s/a/b/q", "s/(/b/"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) = %v, want ErrSyntax", expr, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tutor
+
+import "strings"
+
+// 練習用の行は見出し（3行）の後に続くので、行番号はその分ずらして判定する
+const headerLines = 3
+
+// line は練習用バッファの n 行目（見出しを除いた0始まり）を返す
+func line(s State, n int) string {
+	if n+headerLines >= len(s.Lines) {
+		return ""
+	}
+	return s.Lines[n+headerLines]
+}
+
+// DefaultLessons は組み込みのレッスン一覧を返す
+func DefaultLessons() []Lesson {
+	return []Lesson{
+		{
+			Title: "Moving the cursor",
+			Text: []string{
+				"Use the arrow keys to move the cursor onto the line marked with >>>.",
+				"",
+				"    this is not the line",
+				">>> move the cursor to this line",
+			},
+			Instructions: 1,
+			Done: func(start, now State) bool {
+				return now.Y == headerLines+3
+			},
+		},
+		{
+			Title: "Inserting text",
+			Text: []string{
+				"Move to the line below and type the missing word so that it reads:",
+				"Hello, kilo!",
+				"",
+				"Hello, !",
+			},
+			Instructions: 2,
+			Done: func(start, now State) bool {
+				return line(now, 3) == "Hello, kilo!"
+			},
+		},
+		{
+			Title: "Deleting text",
+			Text: []string{
+				"Use Backspace to remove the extra letters so that the line reads:",
+				"The cat sat on the mat.",
+				"",
+				"The caaat sat on the maaat.",
+			},
+			Instructions: 2,
+			Done: func(start, now State) bool {
+				return line(now, 3) == "The cat sat on the mat."
+			},
+		},
+		{
+			Title: "Splitting lines",
+			Text: []string{
+				"Press Enter at the end of the line below and type: second line",
+				"",
+				"first line",
+			},
+			Instructions: 1,
+			Done: func(start, now State) bool {
+				return line(now, 2) == "first line" && strings.TrimSpace(line(now, 3)) == "second line"
+			},
+		},
+		{
+			Title: "Saving",
+			Text: []string{
+				"This buffer is a temporary practice file.",
+				"Press Ctrl-S to save it.",
+			},
+			Instructions: 2,
+			Done: func(start, now State) bool {
+				return now.Saves > start.Saves && !now.Dirty
+			},
+		},
+	}
+}
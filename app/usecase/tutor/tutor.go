@@ -0,0 +1,104 @@
+package tutor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// State はレッスンの達成判定に使うエディタの状態
+type State struct {
+	Lines []string
+	X, Y  int
+	Dirty bool
+	Saves int // 起動してから保存した回数
+}
+
+// Lesson はチュートリアルの1課
+type Lesson struct {
+	Title string
+	// Text は練習用バッファの内容（説明文と練習用の行）
+	Text []string
+	// Instructions は Text の先頭から説明文として編集できないようにする行数
+	Instructions int
+	// Done は start（課の開始時の状態）から now までに課題が達成されたかどうかを返す
+	Done func(start, now State) bool
+}
+
+// Tutor はレッスンの進行状況を管理する
+type Tutor struct {
+	lessons []Lesson
+	current int
+	start   State
+}
+
+// New は lessons を順に進める Tutor を作成する
+func New(lessons []Lesson) *Tutor {
+	return &Tutor{lessons: lessons}
+}
+
+// Begin は最初の課を開始し、その練習用バッファの内容を返す
+func (t *Tutor) Begin(state State) []string {
+	t.current = 0
+	t.start = state
+	return t.Text()
+}
+
+// Current は現在の課の番号（0始まり）を返す
+func (t *Tutor) Current() int {
+	return t.current
+}
+
+// Len は課の総数を返す
+func (t *Tutor) Len() int {
+	return len(t.lessons)
+}
+
+// Finished は全ての課を終えたかどうかを返す
+func (t *Tutor) Finished() bool {
+	return t.current >= len(t.lessons)
+}
+
+// Text は現在の課の練習用バッファの内容を返す。全て終えた場合は終了メッセージを返す
+func (t *Tutor) Text() []string {
+	if t.Finished() {
+		return []string{
+			"Congratulations! You have finished the tutorial.",
+			"",
+			"Press Ctrl-X to quit. Run go-kilo <file> to start editing your own files.",
+		}
+	}
+	l := t.lessons[t.current]
+	header := fmt.Sprintf("Lesson %d/%d: %s", t.current+1, len(t.lessons), l.Title)
+	return append([]string{header, strings.Repeat("=", len(header)), ""}, l.Text...)
+}
+
+// ReadOnlyLines は練習用バッファの先頭から編集できないようにする行数（見出しと説明文）を返す
+// 全て終えた後の終了メッセージは全体を編集できないようにする
+func (t *Tutor) ReadOnlyLines() int {
+	if t.Finished() {
+		return len(t.Text())
+	}
+	return headerLines + t.lessons[t.current].Instructions
+}
+
+// Title は現在の課の見出しを返す
+func (t *Tutor) Title() string {
+	if t.Finished() {
+		return "Tutorial complete"
+	}
+	return fmt.Sprintf("Lesson %d/%d: %s", t.current+1, len(t.lessons), t.lessons[t.current].Title)
+}
+
+// Update は現在の状態で課題が達成されたかを判定し、達成していれば次の課へ進む
+// 次の課へ進んだ場合は true を返す。呼び出し側は Text() の内容でバッファを置き換える
+func (t *Tutor) Update(state State) bool {
+	if t.Finished() {
+		return false
+	}
+	if !t.lessons[t.current].Done(t.start, state) {
+		return false
+	}
+	t.current++
+	t.start = state
+	return true
+}
@@ -0,0 +1,54 @@
+package tutor
+
+import "testing"
+
+func TestDefaultLessons(t *testing.T) {
+	tu := New(DefaultLessons())
+	lines := tu.Begin(State{})
+	if lines[0] != "Lesson 1/5: Moving the cursor" {
+		t.Fatalf("unexpected header: %q", lines[0])
+	}
+	if tu.ReadOnlyLines() != headerLines+1 {
+		t.Errorf("unexpected read-only lines: %d", tu.ReadOnlyLines())
+	}
+
+	// 課題を達成していない状態では進まない
+	if tu.Update(State{Lines: lines, Y: 0}) {
+		t.Fatal("lesson must not advance before the exercise is done")
+	}
+	if !tu.Update(State{Lines: lines, Y: 6}) {
+		t.Fatal("moving onto the marked line must complete lesson 1")
+	}
+
+	edit := func(practice int, text string) State {
+		lines := tu.Text()
+		lines[headerLines+practice] = text
+		return State{Lines: lines, Dirty: true}
+	}
+	if !tu.Update(edit(3, "Hello, kilo!")) {
+		t.Fatal("lesson 2 must complete")
+	}
+	if !tu.Update(edit(3, "The cat sat on the mat.")) {
+		t.Fatal("lesson 3 must complete")
+	}
+	split := append(tu.Text(), "  second line")
+	if !tu.Update(State{Lines: split, Dirty: true}) {
+		t.Fatal("lesson 4 must complete")
+	}
+
+	if tu.Update(State{Lines: tu.Text(), Dirty: true, Saves: 0}) {
+		t.Fatal("lesson 5 requires saving")
+	}
+	if !tu.Update(State{Lines: tu.Text(), Saves: 1}) {
+		t.Fatal("lesson 5 must complete after saving")
+	}
+	if !tu.Finished() || tu.Title() != "Tutorial complete" {
+		t.Errorf("tutorial must be finished: %s", tu.Title())
+	}
+	if tu.ReadOnlyLines() != len(tu.Text()) {
+		t.Errorf("finished message should be read-only: %d", tu.ReadOnlyLines())
+	}
+	if tu.Update(State{}) {
+		t.Error("finished tutorial must not advance")
+	}
+}
@@ -0,0 +1,338 @@
+package window
+
+import (
+	"errors"
+
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/entity/bookmark"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+	"github.com/wasya-io/go-kilo/app/usecase/command"
+)
+
+// ErrAlreadySplit は既に分割している画面をさらに分割しようとした場合のエラー
+var ErrAlreadySplit = errors.New("the screen is already split")
+
+// ErrNotSplit は分割していない画面のウィンドウを最大化しようとした場合のエラー
+var ErrNotSplit = errors.New("the screen is not split")
+
+// Split は画面の分割方法
+type Split int
+
+const (
+	SplitNone       Split = iota
+	SplitHorizontal       // 上下に並べる
+	SplitVertical         // 左右に並べる
+)
+
+// Buffer はウィンドウに表示するバッファと、バッファごとに持つ編集状態
+type Buffer struct {
+	Contents    *contents.Contents
+	FileManager filemanager.FileManager
+	History     *command.History
+	Bookmarks   *bookmark.Bookmarks
+
+	// バッファを最後に表示していたウィンドウの位置。別のバッファから切り替えたときに戻す
+	Cursor    contents.Position
+	RowOffset int
+	ColOffset int
+}
+
+// Window は区画ごとの表示状態。同じ Buffer を複数のウィンドウで表示できる
+type Window struct {
+	Buffer    *Buffer
+	Cursor    contents.Position
+	RowOffset int
+	ColOffset int
+}
+
+// 分割した区画の最小の大きさ。境界を動かしてもこれより小さくはしない
+const (
+	MinPaneRows = 1 // 本文の行数（ステータスバーは含まない）
+	MinPaneCols = 8 // 桁数
+)
+
+// Manager は画面の分割とフォーカスのあるウィンドウを管理する
+type Manager struct {
+	split   Split
+	windows []*Window
+	focus   int
+	size    int // 先頭のウィンドウの大きさ（上下ならステータスバーを含む行数、左右なら桁数）。0 なら半分
+	top     int // 画面の上端でタブバーに使う行数
+	buffers []*Buffer
+	zoom    *zoom // ToggleZoom で最大化する前の分割。最大化していなければ nil
+}
+
+// zoom は最大化する前の分割の状態
+type zoom struct {
+	split   Split
+	windows []*Window
+	focus   int
+	size    int
+}
+
+// NewManager は w だけを表示する Manager を作成する
+func NewManager(w *Window) *Manager {
+	return &Manager{windows: []*Window{w}, buffers: []*Buffer{w.Buffer}}
+}
+
+// Buffers は開いているバッファを開いた順に返す
+func (m *Manager) Buffers() []*Buffer {
+	return m.buffers
+}
+
+// BufferIndex は b の Buffers() での位置を返す。開いていなければ -1
+func (m *Manager) BufferIndex(b *Buffer) int {
+	for i, buf := range m.buffers {
+		if buf == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddBuffer は b を開いているバッファの末尾に加える。既に開いていれば何もしない
+func (m *Manager) AddBuffer(b *Buffer) {
+	if m.BufferIndex(b) < 0 {
+		m.buffers = append(m.buffers, b)
+	}
+}
+
+// RemoveBuffer は b を開いているバッファから除く
+func (m *Manager) RemoveBuffer(b *Buffer) {
+	if i := m.BufferIndex(b); i >= 0 {
+		m.buffers = append(m.buffers[:i], m.buffers[i+1:]...)
+	}
+}
+
+// SetReservedTop は画面の上端から rows 行をウィンドウ以外の表示（タブバー）に空ける
+func (m *Manager) SetReservedTop(rows int) {
+	m.top = max(rows, 0)
+}
+
+// ReservedTop は画面の上端でウィンドウ以外の表示に空けている行数を返す
+func (m *Manager) ReservedTop() int {
+	return m.top
+}
+
+// Split は現在の分割方法を返す
+func (m *Manager) Split() Split {
+	return m.split
+}
+
+// Windows は画面に表示しているウィンドウを上（左）から順に返す
+func (m *Manager) Windows() []*Window {
+	return m.windows
+}
+
+// AllWindows は最大化して隠しているウィンドウも含めた全てのウィンドウを返す
+func (m *Manager) AllWindows() []*Window {
+	if m.zoom != nil {
+		return m.zoom.windows
+	}
+	return m.windows
+}
+
+// Focused はフォーカスのあるウィンドウを返す
+func (m *Manager) Focused() *Window {
+	return m.windows[m.focus]
+}
+
+// FocusIndex はフォーカスのあるウィンドウの Windows() での位置を返す
+func (m *Manager) FocusIndex() int {
+	return m.focus
+}
+
+// SplitWindow は画面を分割し、フォーカスのあるウィンドウと同じバッファを同じ位置で表示するウィンドウを後ろに追加する
+// フォーカスは元のウィンドウに残る
+func (m *Manager) SplitWindow(split Split) (*Window, error) {
+	if m.split != SplitNone || m.zoom != nil {
+		return nil, ErrAlreadySplit
+	}
+	copied := *m.Focused()
+	m.windows = append(m.windows, &copied)
+	m.split = split
+	m.size = 0
+	return &copied, nil
+}
+
+// FocusNext は次のウィンドウにフォーカスを移し、そのウィンドウを返す
+func (m *Manager) FocusNext() *Window {
+	m.focus = (m.focus + 1) % len(m.windows)
+	return m.Focused()
+}
+
+// Others はフォーカスのないウィンドウを返す（最大化して隠しているウィンドウも含む）
+func (m *Manager) Others() []*Window {
+	var others []*Window
+	for _, w := range m.AllWindows() {
+		if w != m.Focused() {
+			others = append(others, w)
+		}
+	}
+	return others
+}
+
+// CloseOthers はフォーカスのあるウィンドウ以外を閉じて分割を解除する。最大化している場合は元の分割に戻せなくなる
+func (m *Manager) CloseOthers() {
+	m.windows = []*Window{m.Focused()}
+	m.focus = 0
+	m.split = SplitNone
+	m.size = 0
+	m.zoom = nil
+}
+
+// ToggleZoom はフォーカスのあるウィンドウだけを画面全体に表示する
+// 最大化している場合は、元の分割と境界の位置に戻してフォーカスをそのウィンドウに残す。最大化したかどうかを返す
+func (m *Manager) ToggleZoom() (bool, error) {
+	if z := m.zoom; z != nil {
+		m.split, m.windows, m.focus, m.size = z.split, z.windows, z.focus, z.size
+		m.zoom = nil
+		return false, nil
+	}
+	if m.split == SplitNone {
+		return false, ErrNotSplit
+	}
+	m.zoom = &zoom{split: m.split, windows: m.windows, focus: m.focus, size: m.size}
+	m.windows = []*Window{m.Focused()}
+	m.focus = 0
+	m.split = SplitNone
+	m.size = 0
+	return true, nil
+}
+
+// Zoomed はウィンドウを最大化しているかどうかを返す
+func (m *Manager) Zoomed() bool {
+	return m.zoom != nil
+}
+
+// Shows は b を表示しているウィンドウがあるかどうかを返す（except は除く。最大化して隠しているウィンドウも含む）
+func (m *Manager) Shows(b *Buffer, except *Window) bool {
+	for _, w := range m.AllWindows() {
+		if w != except && w.Buffer == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Regions は rows 行 cols 桁の端末で各ウィンドウを表示する区画を Windows() の順に返す
+// 最下行の2行はメッセージバー用に、上端の ReservedTop() 行はタブバー用に空け、各区画は本文の直下にステータスバーを持つ
+func (m *Manager) Regions(rows, cols int) []screen.Region {
+	area := rows - 2 - m.top
+	switch m.split {
+	case SplitHorizontal:
+		top := m.firstSize(rows, cols)
+		return []screen.Region{
+			{Top: m.top, Left: 0, Rows: max(top-1, 0), Cols: cols},
+			{Top: m.top + top, Left: 0, Rows: max(area-top-1, 0), Cols: cols},
+		}
+	case SplitVertical:
+		// 境界線に1桁使う
+		left := m.firstSize(rows, cols)
+		return []screen.Region{
+			{Top: m.top, Left: 0, Rows: max(area-1, 0), Cols: left},
+			{Top: m.top, Left: left + 1, Rows: max(area-1, 0), Cols: max(cols-left-1, 0)},
+		}
+	}
+	return []screen.Region{{Top: m.top, Left: 0, Rows: max(area-1, 0), Cols: cols}}
+}
+
+// firstSize は先頭のウィンドウの大きさを、どちらの区画も最小の大きさを下回らない範囲に収めて返す
+// 端末が小さく収められない場合は半分に分ける
+func (m *Manager) firstSize(rows, cols int) int {
+	total, least := rows-2-m.top, MinPaneRows+1
+	if m.split == SplitVertical {
+		total, least = cols-1, MinPaneCols
+	}
+	size := total / 2
+	if m.size > 0 {
+		size = m.size
+	}
+	if total < least*2 {
+		return total / 2
+	}
+	return min(max(size, least), total-least)
+}
+
+// Resize は index の位置のウィンドウを delta 行（左右に分割している場合は桁）大きくする。負の値なら小さくする
+// rows 行 cols 桁の端末で、どちらの区画も最小の大きさを下回らない範囲に収める
+func (m *Manager) Resize(index, delta, rows, cols int) {
+	if m.split == SplitNone {
+		return
+	}
+	if index != 0 {
+		delta = -delta
+	}
+	m.size = max(m.firstSize(rows, cols)+delta, 1)
+	m.size = m.firstSize(rows, cols)
+}
+
+// MoveDivider は区画の境界を画面上の位置 pos（上下ならステータスバーの行、左右なら境界線の桁、0 始まり）に移す
+// 上下に分割している場合は、上のウィンドウのステータスバーを境界として扱う
+func (m *Manager) MoveDivider(pos, rows, cols int) {
+	switch m.split {
+	case SplitHorizontal:
+		m.size = max(pos-m.top+1, 1)
+	case SplitVertical:
+		m.size = max(pos, 1)
+	default:
+		return
+	}
+	m.size = m.firstSize(rows, cols)
+}
+
+// FocusWindow は index の位置のウィンドウにフォーカスを移し、そのウィンドウを返す
+func (m *Manager) FocusWindow(index int) *Window {
+	if index >= 0 && index < len(m.windows) {
+		m.focus = index
+	}
+	return m.Focused()
+}
+
+// Area はクリックされた画面上の領域の種類
+type Area int
+
+const (
+	AreaNone    Area = iota // 何も表示していない領域
+	AreaText                // ウィンドウの本文
+	AreaStatus              // ウィンドウのステータスバー
+	AreaMessage             // メッセージバー
+	AreaDivider             // 左右に分割した区画の境界線
+	AreaTabBar              // 画面の上端のタブバー
+)
+
+// Hit は画面上の位置にあるウィンドウと領域。Row と Col は区画の左上からの位置（0 始まり）
+// Divider は、その位置をドラッグすると区画の境界を動かせる（境界線と、上下に分割した上のウィンドウのステータスバー）
+type Hit struct {
+	Window   int
+	Area     Area
+	Row, Col int
+	Divider  bool
+}
+
+// HitTest は rows 行 cols 桁の端末で、画面上の位置 (y, x)（0 始まり）にあるウィンドウと領域を返す
+func (m *Manager) HitTest(rows, cols, y, x int) Hit {
+	if y == rows-2 {
+		return Hit{Window: -1, Area: AreaMessage, Col: x}
+	}
+	if y < m.top {
+		return Hit{Window: -1, Area: AreaTabBar, Row: y, Col: x}
+	}
+	regions := m.Regions(rows, cols)
+	if m.split == SplitVertical && x == regions[0].Cols && y < rows-2 {
+		return Hit{Window: -1, Area: AreaDivider, Row: y, Col: x, Divider: true}
+	}
+	for i, r := range regions {
+		if x < r.Left || x >= r.Left+r.Cols || y < r.Top || y > r.Top+r.Rows {
+			continue
+		}
+		area := AreaText
+		if y == r.Top+r.Rows {
+			area = AreaStatus
+		}
+		divider := m.split == SplitHorizontal && i == 0 && area == AreaStatus
+		return Hit{Window: i, Area: area, Row: y - r.Top, Col: x - r.Left, Divider: divider}
+	}
+	return Hit{Window: -1, Area: AreaNone}
+}
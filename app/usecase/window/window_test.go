@@ -0,0 +1,217 @@
+package window
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/entity/screen"
+)
+
+func TestManager_SplitAndFocus(t *testing.T) {
+	first := &Window{Buffer: &Buffer{}, RowOffset: 3}
+	m := NewManager(first)
+
+	second, err := m.SplitWindow(SplitHorizontal)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Buffer != first.Buffer || second.RowOffset != 3 || second == first {
+		t.Errorf("new window must show the same buffer at the same position: %+v", second)
+	}
+	if m.Focused() != first {
+		t.Error("focus must stay on the original window")
+	}
+	if _, err := m.SplitWindow(SplitVertical); !errors.Is(err, ErrAlreadySplit) {
+		t.Errorf("expected ErrAlreadySplit, got %v", err)
+	}
+
+	if m.FocusNext() != second || !reflect.DeepEqual(m.Others(), []*Window{first}) {
+		t.Error("focus must move to the second window")
+	}
+	if !m.Shows(first.Buffer, second) {
+		t.Error("the first window still shows the buffer")
+	}
+
+	m.CloseOthers()
+	if m.Split() != SplitNone || len(m.Windows()) != 1 || m.Focused() != second {
+		t.Errorf("only the focused window must remain: %+v", m.Windows())
+	}
+}
+
+func TestManager_Regions(t *testing.T) {
+	m := NewManager(&Window{})
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, []screen.Region{{Rows: 21, Cols: 80}}) {
+		t.Errorf("single: %+v", got)
+	}
+
+	m.SplitWindow(SplitHorizontal)
+	want := []screen.Region{{Rows: 10, Cols: 80}, {Top: 11, Rows: 10, Cols: 80}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("horizontal: %+v", got)
+	}
+
+	m.CloseOthers()
+	m.SplitWindow(SplitVertical)
+	want = []screen.Region{{Rows: 21, Cols: 39}, {Left: 40, Rows: 21, Cols: 40}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("vertical: %+v", got)
+	}
+}
+
+func TestManager_HitTest(t *testing.T) {
+	m := NewManager(&Window{Buffer: &Buffer{}})
+	if _, err := m.SplitWindow(SplitVertical); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 24 行 80 桁の端末では、左の区画は 0-38 桁、右の区画は 40-79 桁で、ステータスバーは 21 行目
+	tests := []struct {
+		y, x int
+		want Hit
+	}{
+		{2, 5, Hit{Window: 0, Area: AreaText, Row: 2, Col: 5}},
+		{2, 45, Hit{Window: 1, Area: AreaText, Row: 2, Col: 5}},
+		{21, 45, Hit{Window: 1, Area: AreaStatus, Row: 21, Col: 5}},
+		{2, 39, Hit{Window: -1, Area: AreaDivider, Row: 2, Col: 39, Divider: true}},
+		{22, 3, Hit{Window: -1, Area: AreaMessage, Col: 3}},
+	}
+	for _, tt := range tests {
+		if got := m.HitTest(24, 80, tt.y, tt.x); got != tt.want {
+			t.Errorf("HitTest(%d, %d) = %+v, want %+v", tt.y, tt.x, got, tt.want)
+		}
+	}
+
+	if m.FocusWindow(1) != m.Windows()[1] || m.FocusIndex() != 1 {
+		t.Error("focus must move to the clicked window")
+	}
+}
+
+func TestManager_Resize(t *testing.T) {
+	m := NewManager(&Window{})
+	m.Resize(0, 5, 24, 80) // 分割していなければ何もしない
+
+	m.SplitWindow(SplitHorizontal)
+	m.Resize(0, 3, 24, 80)
+	want := []screen.Region{{Rows: 13, Cols: 80}, {Top: 14, Rows: 7, Cols: 80}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("enlarge top: %+v", got)
+	}
+	// 下のウィンドウを大きくすると上のウィンドウが小さくなる
+	m.Resize(1, 5, 24, 80)
+	want = []screen.Region{{Rows: 8, Cols: 80}, {Top: 9, Rows: 12, Cols: 80}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("enlarge bottom: %+v", got)
+	}
+	// 最小の大きさを下回らない
+	m.Resize(0, -100, 24, 80)
+	want = []screen.Region{{Rows: MinPaneRows, Cols: 80}, {Top: 2, Rows: 19, Cols: 80}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("minimum: %+v", got)
+	}
+
+	// 上のウィンドウのステータスバーを 15 行目に移す。端末を縮めても最小の大きさは保つ
+	m.MoveDivider(15, 24, 80)
+	if got := m.Regions(24, 80)[0]; got.Rows != 15 {
+		t.Errorf("move divider: %+v", got)
+	}
+	if got := m.Regions(10, 80); got[1].Rows != MinPaneRows {
+		t.Errorf("shrunk terminal: %+v", got)
+	}
+	if hit := m.HitTest(24, 80, 15, 3); hit.Area != AreaStatus || !hit.Divider {
+		t.Errorf("status bar of the top window must be a divider: %+v", hit)
+	}
+
+	m.CloseOthers()
+	m.SplitWindow(SplitVertical)
+	m.MoveDivider(100, 24, 80)
+	want = []screen.Region{{Rows: 21, Cols: 80 - 1 - MinPaneCols}, {Left: 80 - MinPaneCols, Rows: 21, Cols: MinPaneCols}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("vertical: %+v", got)
+	}
+}
+
+func TestManager_Buffers(t *testing.T) {
+	first := &Buffer{}
+	m := NewManager(&Window{Buffer: first})
+	second := &Buffer{}
+	m.AddBuffer(second)
+	m.AddBuffer(second) // 同じバッファは二重に加えない
+	if !reflect.DeepEqual(m.Buffers(), []*Buffer{first, second}) || m.BufferIndex(second) != 1 {
+		t.Errorf("buffers: %+v", m.Buffers())
+	}
+	m.RemoveBuffer(first)
+	if !reflect.DeepEqual(m.Buffers(), []*Buffer{second}) || m.BufferIndex(first) != -1 {
+		t.Errorf("after remove: %+v", m.Buffers())
+	}
+}
+
+func TestManager_ReservedTop(t *testing.T) {
+	m := NewManager(&Window{})
+	m.SetReservedTop(1)
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, []screen.Region{{Top: 1, Rows: 20, Cols: 80}}) {
+		t.Errorf("single: %+v", got)
+	}
+	if hit := m.HitTest(24, 80, 0, 12); hit != (Hit{Window: -1, Area: AreaTabBar, Col: 12}) {
+		t.Errorf("tab bar: %+v", hit)
+	}
+	if hit := m.HitTest(24, 80, 1, 12); hit != (Hit{Window: 0, Area: AreaText, Col: 12}) {
+		t.Errorf("first text row: %+v", hit)
+	}
+
+	m.SplitWindow(SplitHorizontal)
+	want := []screen.Region{{Top: 1, Rows: 9, Cols: 80}, {Top: 11, Rows: 10, Cols: 80}}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, want) {
+		t.Errorf("horizontal: %+v", got)
+	}
+	// 境界は画面上の位置で指定する
+	m.MoveDivider(15, 24, 80)
+	if got := m.Regions(24, 80)[0]; got.Top+got.Rows != 15 {
+		t.Errorf("move divider: %+v", got)
+	}
+}
+
+func TestManager_ToggleZoom(t *testing.T) {
+	first := &Window{Buffer: &Buffer{}}
+	m := NewManager(first)
+	if _, err := m.ToggleZoom(); !errors.Is(err, ErrNotSplit) {
+		t.Errorf("expected ErrNotSplit, got %v", err)
+	}
+
+	second, _ := m.SplitWindow(SplitVertical)
+	second.Buffer = &Buffer{}
+	m.MoveDivider(30, 24, 80)
+	m.FocusNext()
+	if zoomed, err := m.ToggleZoom(); !zoomed || err != nil {
+		t.Fatalf("ToggleZoom() = %v, %v", zoomed, err)
+	}
+	if !m.Zoomed() || m.Split() != SplitNone || m.Focused() != second {
+		t.Errorf("only the focused window must be shown: %+v", m.Windows())
+	}
+	if got := m.Regions(24, 80); !reflect.DeepEqual(got, []screen.Region{{Rows: 21, Cols: 80}}) {
+		t.Errorf("the zoomed window must fill the screen: %+v", got)
+	}
+	if !m.Shows(first.Buffer, second) || !reflect.DeepEqual(m.Others(), []*Window{first}) {
+		t.Error("hidden windows still count as showing their buffers")
+	}
+	if _, err := m.SplitWindow(SplitHorizontal); !errors.Is(err, ErrAlreadySplit) {
+		t.Errorf("splitting a zoomed window must fail, got %v", err)
+	}
+
+	if zoomed, _ := m.ToggleZoom(); zoomed || m.Zoomed() {
+		t.Error("the second toggle must restore the split")
+	}
+	want := []screen.Region{{Rows: 21, Cols: 30}, {Left: 31, Rows: 21, Cols: 49}}
+	if got := m.Regions(24, 80); m.Split() != SplitVertical || !reflect.DeepEqual(got, want) {
+		t.Errorf("the split and divider must be restored: %v %+v", m.Split(), got)
+	}
+	if m.Focused() != second {
+		t.Error("the focus must stay on the zoomed window")
+	}
+
+	m.ToggleZoom()
+	m.CloseOthers()
+	if m.Zoomed() || len(m.AllWindows()) != 1 {
+		t.Error("closing the other windows must drop the saved split")
+	}
+}
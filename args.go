@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wasya-io/go-kilo/app/usecase/controller"
+)
+
+// parseFileArgs は開くファイルの引数を解釈する
+// 「+行 ファイル」で次のファイルの行を、「ファイル:行[:列]」でそのファイルの位置を指定できる
+// コンパイラの出力をそのまま渡せるよう、末尾の ":" は無視する。exists が true を返す名前はそのままファイル名として扱う
+func parseFileArgs(args []string, exists func(string) bool) ([]controller.StartupFile, error) {
+	var files []controller.StartupFile
+	for i := 0; i < len(args); i++ {
+		rest, ok := strings.CutPrefix(args[i], "+")
+		if !ok {
+			files = append(files, splitPosition(args[i], exists))
+			continue
+		}
+		line, err := strconv.Atoi(rest)
+		if err != nil || line < 1 {
+			return nil, fmt.Errorf("invalid line number: %s", args[i])
+		}
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("no file given after %s", args[i])
+		}
+		i++
+		files = append(files, controller.StartupFile{Path: args[i], Line: line, Col: 1})
+	}
+	return files, nil
+}
+
+// splitPosition は「ファイル:行[:列]」を分ける。位置を含まない場合や、名前全体がファイルとして存在する場合は分けない
+func splitPosition(arg string, exists func(string) bool) controller.StartupFile {
+	if exists(arg) {
+		return controller.StartupFile{Path: arg}
+	}
+	name := strings.TrimSuffix(arg, ":")
+	var numbers []int
+	for len(numbers) < 2 {
+		i := strings.LastIndexByte(name, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(name[i+1:])
+		if err != nil || n < 1 {
+			break
+		}
+		numbers = append([]int{n}, numbers...)
+		name = name[:i]
+	}
+	if len(numbers) == 0 || name == "" {
+		return controller.StartupFile{Path: arg}
+	}
+	file := controller.StartupFile{Path: name, Line: numbers[0], Col: 1}
+	if len(numbers) == 2 {
+		file.Col = numbers[1]
+	}
+	return file
+}
+
+// fileExists はパスにファイルかディレクトリが存在するかどうかを返す
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
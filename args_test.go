@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/usecase/controller"
+)
+
+func TestParseFileArgs(t *testing.T) {
+	existing := map[string]bool{"notes:2": true}
+	exists := func(name string) bool { return existing[name] }
+
+	tests := []struct {
+		args []string
+		want []controller.StartupFile
+	}{
+		{[]string{"main.go"}, []controller.StartupFile{{Path: "main.go"}}},
+		{[]string{"main.go:123"}, []controller.StartupFile{{Path: "main.go", Line: 123, Col: 1}}},
+		{[]string{"main.go:123:7"}, []controller.StartupFile{{Path: "main.go", Line: 123, Col: 7}}},
+		// コンパイラの出力の末尾の ":" は無視する
+		{[]string{"./cmd/main.go:12:5:"}, []controller.StartupFile{{Path: "./cmd/main.go", Line: 12, Col: 5}}},
+		{[]string{"+42", "main.go"}, []controller.StartupFile{{Path: "main.go", Line: 42, Col: 1}}},
+		// 存在するファイルの名前は分けない
+		{[]string{"notes:2"}, []controller.StartupFile{{Path: "notes:2"}}},
+		{[]string{"a:b", ":12"}, []controller.StartupFile{{Path: "a:b"}, {Path: ":12"}}},
+		// +行 は直後のファイルだけに適用する
+		{[]string{"a.go", "+3", "b.go", "c.go:4"}, []controller.StartupFile{{Path: "a.go"}, {Path: "b.go", Line: 3, Col: 1}, {Path: "c.go", Line: 4, Col: 1}}},
+	}
+	for _, tt := range tests {
+		got, err := parseFileArgs(tt.args, exists)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFileArgs(%q) = %+v, %v, want %+v", tt.args, got, err, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{{"+x", "main.go"}, {"+0", "main.go"}, {"a.go", "+3"}} {
+		if _, err := parseFileArgs(args, exists); err == nil {
+			t.Errorf("parseFileArgs(%q) must fail", args)
+		}
+	}
+}
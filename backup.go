@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/entity/core"
+)
+
+// backupPolicy は設定からバックアップの保持方針を作成する
+func backupPolicy(conf *config.Config) backupfile.Policy {
+	return backupfile.Policy{
+		Keep:   conf.BackupKeep,
+		MaxAge: time.Duration(conf.BackupMaxAgeDays) * 24 * time.Hour,
+	}
+}
+
+// backupStore は設定に従って、保存で上書きする前の内容のバックアップ先を返す。バックアップしないなら nil
+func backupStore(conf *config.Config) backupfile.Saver {
+	switch conf.Backup {
+	case "tilde":
+		return backupfile.TildeStore{}
+	case "off":
+		return nil
+	}
+	return backupfile.NewDefaultStore()
+}
+
+// cleanBackups は保持方針に従って古いバックアップを削除し、結果を出力する
+func cleanBackups(store *backupfile.DirStore, policy backupfile.Policy, w io.Writer) error {
+	if policy.Unlimited() {
+		fmt.Fprintln(w, "Backup retention is disabled (BACKUP_KEEP=0 and BACKUP_MAX_AGE_DAYS=0).")
+		return nil
+	}
+	removed, err := store.Prune(policy, time.Now())
+	fmt.Fprintf(w, "Removed %d backup(s).\n", removed)
+	return err
+}
+
+// pruneBackups は起動時に古いバックアップを削除する
+// 起動を遅らせないよう呼び出し側で別のゴルーチンから実行する
+func pruneBackups(store *backupfile.DirStore, policy backupfile.Policy, logger core.Logger) {
+	removed, err := store.Prune(policy, time.Now())
+	if err != nil {
+		logger.Log("backup", fmt.Sprintf("Failed to prune backups: %v", err))
+	}
+	if removed > 0 {
+		logger.Log("backup", fmt.Sprintf("Removed %d old backup(s)", removed))
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/config"
+)
+
+func TestCleanBackups(t *testing.T) {
+	store := backupfile.NewDirStore(t.TempDir())
+	old := time.Now().Add(-48 * time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := store.Save("/tmp/a.txt", []byte("a"), old.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := cleanBackups(store, backupfile.Policy{}, &out); err != nil || !bytes.Contains(out.Bytes(), []byte("disabled")) {
+		t.Errorf("unlimited policy: %q, %v", out.String(), err)
+	}
+
+	out.Reset()
+	policy := backupPolicy(&config.Config{BackupKeep: 1, BackupMaxAgeDays: 1})
+	if err := cleanBackups(store, policy, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Removed 2 backup(s).\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestBackupStore(t *testing.T) {
+	if _, ok := backupStore(&config.Config{Backup: "dir"}).(*backupfile.DirStore); !ok {
+		t.Error("dir must back up to the state directory")
+	}
+	if _, ok := backupStore(&config.Config{Backup: "tilde"}).(backupfile.TildeStore); !ok {
+		t.Error("tilde must back up next to the file")
+	}
+	if store := backupStore(&config.Config{Backup: "off"}); store != nil {
+		t.Errorf("off must disable backups, got %T", store)
+	}
+}
@@ -1,17 +1,25 @@
 #!/bin/bash
 
 # 変数定義
-PROJECT_DIR=$(dirname "$(realpath "$0")")
+# ログはエディタの状態ディレクトリに書き出される
+if [[ -n "$KILO_STATE_DIR" ]]; then
+    STATE_DIR="$KILO_STATE_DIR"
+elif [[ -n "$XDG_STATE_HOME" ]]; then
+    STATE_DIR="$XDG_STATE_HOME/go-kilo"
+else
+    STATE_DIR="$HOME/.local/state/go-kilo"
+fi
+LOG_DIR="$STATE_DIR/logs"
 LOG_PATTERN="log-*.json"
 
 # スクリプトの説明
 echo "ログファイル削除ユーティリティ"
 echo "パターン: $LOG_PATTERN"
-echo "ディレクトリ: $PROJECT_DIR"
+echo "ディレクトリ: $LOG_DIR"
 
 # 削除対象ファイル表示
 echo "削除対象ファイル:"
-find "$PROJECT_DIR" -maxdepth 1 -type f -name "$LOG_PATTERN" | sort
+find "$LOG_DIR" -maxdepth 1 -type f -name "$LOG_PATTERN" | sort
 
 # 削除確認
 echo ""
@@ -19,7 +27,7 @@ read -p "上記のログファイルを削除しますか？ (y/n): " confirmati
 
 if [[ $confirmation == [yY] || $confirmation == [yY][eE][sS] ]]; then
     # 削除実行
-    find "$PROJECT_DIR" -maxdepth 1 -type f -name "$LOG_PATTERN" -delete
+    find "$LOG_DIR" -maxdepth 1 -type f -name "$LOG_PATTERN" -delete
     echo "ログファイルを削除しました。"
 else
     echo "キャンセルしました。"
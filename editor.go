@@ -1,10 +1,15 @@
 package main
 
 import (
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
 	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/boundary/historyfile"
 	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/boundary/positionfile"
 	"github.com/wasya-io/go-kilo/app/boundary/provider/input"
 	"github.com/wasya-io/go-kilo/app/boundary/reader"
+	"github.com/wasya-io/go-kilo/app/boundary/sessionfile"
+	"github.com/wasya-io/go-kilo/app/boundary/trustfile"
 	"github.com/wasya-io/go-kilo/app/boundary/writer"
 	"github.com/wasya-io/go-kilo/app/config"
 	"github.com/wasya-io/go-kilo/app/entity/core"
@@ -19,9 +24,12 @@ import (
 )
 
 func NewEditor() (*editor.Editor, error) {
-	conf := config.LoadConfig()
+	conf, confErrs := config.Load()
 	logger := logger.New(conf.DebugMode)
 
+	// 保持期間を過ぎたバックアップを裏で削除する
+	go pruneBackups(backupfile.NewDefaultStore(), backupPolicy(conf), logger)
+
 	// イベントバスの初期化
 	eventBus := event.NewBus()
 
@@ -35,19 +43,38 @@ func NewEditor() (*editor.Editor, error) {
 	// インプットプロバイダの初期化
 	parser := parser.NewStandardInputParser(logger)
 	reader := reader.NewStandardKeyReader(logger)
-	inputProvider := input.NewStandardInputProvider(logger, reader, parser)
+	// 読み取りを別の goroutine で行い、キー入力を待つ間も終了やシグナル、タイマーの処理を受け付ける
+	inputProvider := input.NewAsyncProvider(input.NewStandardInputProvider(logger, reader, parser))
 
 	// 2. ウィンドウサイズの取得
 	screenRows, screenCols := term.GetWinSize()
 
 	builder := contents.NewBuilder()
-	writer := writer.NewStandardScreenWriter()
+	// 画面の変わった行だけを書き出して、描画のたびのちらつきを抑える
+	writer := writer.NewDiffWriter(writer.NewStandardScreenWriter())
 	message := contents.NewMessage("", nil)
 	cursor := cursor.NewCursor()
 	screen := screen.NewScreen(builder, writer, message, cursor, screenRows, screenCols)
 
 	// イベントバスをコントローラーに渡す
 	controller := controller.NewController(screen, c, fileManager, inputProvider, logger, metrics, eventBus)
+	controller.ApplyConfig(conf)
+	controller.SetBackupStore(backupStore(conf))
+	controller.SetSessionStore(sessionfile.NewDefaultStore())
+	controller.SetTrustStore(trustfile.NewDefaultStore())
+	if conf.RememberPosition {
+		controller.SetPositionStore(positionfile.NewDefaultStore())
+	}
+	if conf.PersistentUndo {
+		controller.SetHistoryStore(historyfile.NewDefaultStore())
+	}
+	// 起動を速く見せるため、最初の1画面分だけを読み込んで表示し、残りは裏で読み込む
+	controller.SetPreloadLines(screenRows)
+
+	// 不正な設定は起動を止めずに一覧として表示する
+	if len(confErrs) > 0 {
+		controller.ShowOverlay("Configuration errors (defaults used for these settings)", confErrs.Lines())
+	}
 
 	ed, err := editor.New(
 		false,
@@ -2,13 +2,22 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"runtime/debug"
 	"syscall"
+
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/boundary/recoveryfile"
+	"github.com/wasya-io/go-kilo/app/config"
+	"github.com/wasya-io/go-kilo/app/usecase/controller"
+	"github.com/wasya-io/go-kilo/app/usecase/editor"
 )
 
 func main() {
+	var ed *editor.Editor
+
 	// グローバルなパニックハンドラを設定
 	defer func() {
 		if r := recover(); r != nil {
@@ -18,6 +27,11 @@ func main() {
 			fmt.Print("\x1b[2J\x1b[H")                                        // 画面をクリア
 			fmt.Print("\x1b[?25h")                                    // カーソルを表示
 
+			// 未保存の変更を失わないよう、復元用ファイルに書き出す
+			if ed != nil {
+				ed.ReportRecovery()
+			}
+
 			// エラー情報を出力
 			fmt.Fprintf(os.Stderr, "Editor crashed: %v\n", r)
 			fmt.Fprintf(os.Stderr, "Stack trace:\n%s", debug.Stack())
@@ -25,27 +39,101 @@ func main() {
 		}
 	}()
 
+	// 設定項目の一覧表示は端末を初期化せずに終了する
+	if len(os.Args) > 1 && os.Args[1] == "--config-help" {
+		fmt.Printf("Settings are read from %s and the environment (.env).\n\n", config.FilePath())
+		config.Document(os.Stdout)
+		return
+	}
+
 	// シグナルハンドリングの設定
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	// 復元用ファイルの一覧表示も端末を初期化せずに終了する
+	if len(os.Args) > 1 && os.Args[1] == "--list-recovery" {
+		if err := listRecovery(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 古いバックアップの削除も端末を初期化せずに終了する
+	if len(os.Args) > 1 && os.Args[1] == "--clean-backups" {
+		conf, _ := config.Load()
+		if err := cleanBackups(backupfile.NewDefaultStore(), backupPolicy(conf), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 置換モードは端末を初期化せずにファイルを書き換えて終了する
+	if len(os.Args) > 1 && os.Args[1] == "--sub" {
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: go-kilo --sub 's/pattern/replacement/[gi]' FILE...")
+			os.Exit(2)
+		}
+		os.Exit(runSubstitute(os.Args[2], os.Args[3:], os.Stdout, os.Stderr))
+	}
 
-	ed, err := NewEditor()
+	// コマンドライン引数の処理
+	// --readonly を付けると、開くファイルをすべて編集できない状態にする
+	// --resume を付けてファイルを指定しないと、前回の終了時に開いていたファイルを開き直す
+	args := os.Args[1:]
+	readOnly, resume := false, false
+	for len(args) > 0 && (args[0] == "--readonly" || args[0] == "--resume") {
+		if args[0] == "--readonly" {
+			readOnly = true
+		} else {
+			resume = true
+		}
+		args = args[1:]
+	}
+
+	// - を指定すると標準入力の内容を開く。端末を初期化する前に読み切る
+	var piped []byte
+	var err error
+	if len(args) > 0 && args[0] == stdinArg {
+		if piped, err = readPipedInput(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ed, err = NewEditor()
 	if err != nil {
 		die(err)
 	}
 	defer ed.Cleanup() // 確実なクリーンアップを保証
 
-	// コマンドライン引数の処理
-	if len(os.Args) > 1 {
-		if err := ed.OpenFile(os.Args[1]); err != nil {
+	ed.SetReadOnly(readOnly)
+	if len(args) > 0 {
+		switch args[0] {
+		case "--tutor":
+			err = ed.StartTutorial()
+		case stdinArg:
+			err = ed.OpenText(piped)
+		default:
+			// 複数のファイルはそれぞれのバッファで開く
+			// 「+行 ファイル」や「ファイル:行:列」で、開いた後に移動する位置を指定できる
+			var files []controller.StartupFile
+			if files, err = parseFileArgs(args, fileExists); err == nil {
+				err = ed.OpenFiles(files)
+			}
+		}
+		if err != nil {
 			die(err)
 		}
+	} else {
+		ed.ResumeSession(resume)
 	}
 
 	// シグナル処理用のゴルーチン
 	go func() {
 		<-sigChan
-		ed.Cleanup() // クリーンアップを実行
+		ed.Terminate() // クリーンアップと未保存の変更の書き出しを実行
 		os.Exit(0)
 	}()
 
@@ -56,6 +144,23 @@ func main() {
 	}
 }
 
+// listRecovery は保存されている復元用ファイルの一覧を出力する
+func listRecovery(w io.Writer) error {
+	store := recoveryfile.NewDefaultStore()
+	entries, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No recovery files.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s  %s\n    %s\n", e.SavedAt.Local().Format("2006-01-02 15:04:05"), e.Name(), e.Path)
+	}
+	return nil
+}
+
 func die(err error) {
 	fmt.Print("\x1b[?1049l\x1b[?1000l\x1b[?1002l\x1b[?1015l\x1b[?1006l") // 代替バッファ・マウスモードを無効化
 	fmt.Print("\x1b[2J")                                                // 画面をクリア
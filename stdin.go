@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// stdinArg はファイル名の代わりに指定して標準入力の内容を開く引数
+const stdinArg = "-"
+
+// readPipedInput は標準入力を最後まで読み、以降のキー入力を端末から読めるよう標準入力を /dev/tty に付け替える
+// 端末を Raw モードにする前に呼び出すこと。付け替えた後は標準入力の端末設定やキー入力がそのまま端末に対して働く
+func readPipedInput(in io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	// os.Open は端末をノンブロッキングにするため、ファイル記述子を直接開いて付け替える
+	tty, err := unix.Open("/dev/tty", unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal for key input: %w", err)
+	}
+	defer unix.Close(tty)
+	if err := unix.Dup2(tty, int(os.Stdin.Fd())); err != nil {
+		return nil, fmt.Errorf("failed to reopen the terminal as stdin: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+	"github.com/wasya-io/go-kilo/app/boundary/filemanager"
+	"github.com/wasya-io/go-kilo/app/boundary/logger"
+	"github.com/wasya-io/go-kilo/app/entity/contents"
+	"github.com/wasya-io/go-kilo/app/usecase/substitute"
+)
+
+// runSubstitute は端末を初期化せずに各ファイルへ置換を適用し、終了コードを返す
+// 保存はエディタと同じ FileManager を通すので、一時ファイル経由の置き換えや改行コードの保持が行われる
+// 変更前の内容は設定に関わらず状態ディレクトリにバックアップする
+func runSubstitute(expr string, files []string, stdout, stderr io.Writer) int {
+	sub, err := substitute.Parse(expr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(stderr, "Error: --sub requires at least one file")
+		return 2
+	}
+
+	status := 0
+	for _, file := range files {
+		count, err := substituteFile(sub, file)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %d substitution(s)\n", file, count)
+	}
+	return status
+}
+
+// substituteFile は1つのファイルに置換を適用して保存する。変更がない場合は保存しない
+func substituteFile(sub *substitute.Substitution, file string) (int, error) {
+	buffer := contents.NewContents(logger.New(false))
+	fm := filemanager.NewFileManager(buffer)
+	fm.SetBackup(backupfile.NewDefaultStore())
+	if err := fm.OpenFile(file); err != nil {
+		return 0, err
+	}
+	lines, count := sub.Apply(buffer.GetAllLines())
+	if count == 0 {
+		return 0, nil
+	}
+
+	buffer.LoadContent(lines)
+	if err := fm.SaveCurrentFile(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
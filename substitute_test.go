@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wasya-io/go-kilo/app/boundary/backupfile"
+)
+
+func TestRunSubstitute(t *testing.T) {
+	t.Setenv("KILO_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	original := "foo foo\r\nbar\r\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	untouched := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(untouched, []byte("bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runSubstitute("s/foo/baz/g", []string{path, untouched}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "baz baz\r\nbar\r\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode not preserved: %v", info.Mode())
+	}
+	if backup, err := backupfile.NewDefaultStore().Load(path); err != nil || string(backup) != original {
+		t.Errorf("unexpected backup: %q, %v", backup, err)
+	}
+	if _, err := backupfile.NewDefaultStore().Load(untouched); err == nil {
+		t.Error("unchanged file should not be backed up")
+	}
+	if want := path + ": 2 substitution(s)\n" + untouched + ": 0 substitution(s)\n"; stdout.String() != want {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestRunSubstitute_InvalidExpression(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runSubstitute("s/foo", []string{"x"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unexpected exit code %d", code)
+	}
+}